@@ -2,6 +2,8 @@
 package cache
 
 import (
+	"context"
+	"strings"
 	"time"
 )
 
@@ -18,3 +20,58 @@ type TTLCache interface {
 	Cache
 	SetTTL(key string, value interface{}, ttl time.Duration) error
 }
+
+// PatternCache interface adds support for removing the keys matching a glob-style pattern,
+// returning the number of removed keys. See MatchPattern for the syntax of the patterns.
+type PatternCache interface {
+	RemoveMatching(ctx context.Context, pattern string) (int, error)
+}
+
+// MatchPattern reports whether the key matches the glob-style pattern, where * matches any sequence of characters,
+// ? matches any single character and \ escapes the next character.
+func MatchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if MatchPattern(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if key == "" {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if key == "" || key[0] != pattern[0] {
+				return false
+			}
+			pattern, key = pattern[1:], key[1:]
+		}
+	}
+	return key == ""
+}
+
+// EscapePattern escapes the special characters of a glob-style pattern, so that it matches the string literally.
+func EscapePattern(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
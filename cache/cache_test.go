@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := map[string]struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		"exact":                  {pattern: "/users:", key: "/users:", expected: true},
+		"exact mismatch":         {pattern: "/users:", key: "/users:page=1", expected: false},
+		"wildcard":               {pattern: "/users/*", key: "/users/1/orders:page=1", expected: true},
+		"wildcard empty":         {pattern: "/users/*", key: "/users/", expected: true},
+		"wildcard in the middle": {pattern: "/users/*:page=1", key: "/users/1:page=1", expected: true},
+		"wildcard mismatch":      {pattern: "/users/*:page=1", key: "/users/1:page=2", expected: false},
+		"consecutive wildcards":  {pattern: "**", key: "anything", expected: true},
+		"single character":       {pattern: "/users/?:", key: "/users/1:", expected: true},
+		"single character none":  {pattern: "/users/?:", key: "/users/:", expected: false},
+		"escaped wildcard":       {pattern: `/search\*:`, key: "/search*:", expected: true},
+		"escaped mismatch":       {pattern: `/search\*:`, key: "/search1:", expected: false},
+		"longer key":             {pattern: "/users", key: "/users/1", expected: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchPattern(tt.pattern, tt.key))
+		})
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, "/users/1:", EscapePattern("/users/1:"))
+	assert.Equal(t, `a\*b\?c\[d\]e\\f`, EscapePattern(`a*b?c[d]e\f`))
+	assert.True(t, MatchPattern(EscapePattern(`/a*?\b`), `/a*?\b`))
+}
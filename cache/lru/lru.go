@@ -2,6 +2,9 @@
 package lru
 
 import (
+	"context"
+
+	"github.com/beatlabs/patron/cache"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -43,3 +46,18 @@ func (c *Cache) Set(key string, value interface{}) error {
 	c.cache.Add(key, value)
 	return nil
 }
+
+// RemoveMatching evicts the keys matching the glob-style pattern and returns their number.
+func (c *Cache) RemoveMatching(_ context.Context, pattern string) (int, error) {
+	removed := 0
+	for _, k := range c.cache.Keys() {
+		key, ok := k.(string)
+		if !ok || !cache.MatchPattern(pattern, key) {
+			continue
+		}
+		if c.cache.Remove(key) {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -1,9 +1,11 @@
 package lru
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -78,3 +80,23 @@ func TestCacheOperations(t *testing.T) {
 		assert.Equal(t, c.cache.Len(), 0)
 	})
 }
+
+func TestCache_RemoveMatching(t *testing.T) {
+	c, err := New(10)
+	require.NoError(t, err)
+	for _, k := range []string{"/users/1:", "/users/1:page=2", "/users/2:", "/orders/1:"} {
+		require.NoError(t, c.Set(k, "value"))
+	}
+
+	removed, err := c.RemoveMatching(context.Background(), "/users/1:*")
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	removed, err = c.RemoveMatching(context.Background(), "/users/*")
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, ok, err := c.Get("/orders/1:")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
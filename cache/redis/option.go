@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// OptionFunc definition for configuring the cache in a functional way.
+type OptionFunc func(*Cache) error
+
+// KeyPrefix option for prefixing the keys of the cache, so that several caches can share a Redis server or cluster.
+// Purging a cache with a key prefix evicts only its own keys.
+func KeyPrefix(prefix string) OptionFunc {
+	return func(c *Cache) error {
+		if prefix == "" {
+			return errors.New("key prefix is empty")
+		}
+		c.prefix = prefix
+		return nil
+	}
+}
+
+// TTL option for expiring the keys which are set without an explicit expiry time.
+func TTL(ttl time.Duration) OptionFunc {
+	return func(c *Cache) error {
+		if ttl <= 0 {
+			return errors.New("TTL must be positive")
+		}
+		c.ttl = ttl
+		return nil
+	}
+}
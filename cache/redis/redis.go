@@ -3,30 +3,75 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/client/redis"
+	goredis "github.com/go-redis/redis/v8"
 )
 
+const purgeBatchSize = 100
+
 // Cache encapsulates a Redis-based caching mechanism,
-// driven by go-redis/redis/v7.
+// driven by go-redis/redis/v8.
 type Cache struct {
-	rdb redis.Client
-	ctx context.Context
+	rdb    goredis.UniversalClient
+	ctx    context.Context
+	prefix string
+	ttl    time.Duration
+	// forEachNode runs a function on every node holding keys, i.e. the single server or the masters of a cluster.
+	forEachNode func(ctx context.Context, fn func(ctx context.Context, node *goredis.Client) error) error
 }
 
 // Options exposes the options struct from go-redis package.
 type Options redis.Options
 
+// ClusterOptions exposes the cluster options struct from go-redis package.
+type ClusterOptions redis.ClusterOptions
+
 // New returns a new Redis client that will be used as the cache store.
-func New(ctx context.Context, opt Options) (*Cache, error) {
+func New(ctx context.Context, opt Options, oo ...OptionFunc) (*Cache, error) {
 	redisDB := redis.New(redis.Options(opt))
-	return &Cache{rdb: redisDB, ctx: ctx}, nil
+	c := &Cache{
+		rdb: &redisDB.Client,
+		ctx: ctx,
+		forEachNode: func(ctx context.Context, fn func(ctx context.Context, node *goredis.Client) error) error {
+			return fn(ctx, &redisDB.Client)
+		},
+	}
+	return c.apply(oo)
+}
+
+// NewCluster returns a new Redis cluster client that will be used as the cache store,
+// so that the cached values are shared by all the instances of a service.
+func NewCluster(ctx context.Context, opt ClusterOptions, oo ...OptionFunc) (*Cache, error) {
+	if len(opt.Addrs) == 0 {
+		return nil, errors.New("cluster addresses are empty")
+	}
+	redisCluster := redis.NewCluster(redis.ClusterOptions(opt))
+	c := &Cache{
+		rdb:         &redisCluster.ClusterClient,
+		ctx:         ctx,
+		forEachNode: redisCluster.ForEachMaster,
+	}
+	return c.apply(oo)
+}
+
+func (c *Cache) apply(oo []OptionFunc) (*Cache, error) {
+	for _, option := range oo {
+		if err := option(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
 // Get executes a lookup and returns whether a key exists in the cache along with its value.
 func (c *Cache) Get(key string) (interface{}, bool, error) {
-	res, err := c.rdb.Do(c.ctx, "get", key).Result()
+	res, err := c.rdb.Do(c.ctx, "get", c.key(key)).Result()
 	if err != nil {
 		if err == redis.Nil { // cache miss
 			return nil, false, nil
@@ -36,22 +81,109 @@ func (c *Cache) Get(key string) (interface{}, bool, error) {
 	return res, true, nil
 }
 
-// Set registers a key-value pair to the cache.
+// Set registers a key-value pair to the cache, expiring after the TTL of the cache if one is configured.
 func (c *Cache) Set(key string, value interface{}) error {
-	return c.rdb.Do(c.ctx, "set", key, value).Err()
+	if c.ttl > 0 {
+		return c.SetTTL(key, value, c.ttl)
+	}
+	return c.rdb.Do(c.ctx, "set", c.key(key), value).Err()
 }
 
 // Purge evicts all keys present in the cache.
+// With a key prefix, only the keys with the prefix are evicted, otherwise all the keys are flushed.
 func (c *Cache) Purge() error {
-	return c.rdb.FlushAll(c.ctx).Err()
+	if c.prefix == "" {
+		return c.forEachNode(c.ctx, func(ctx context.Context, node *goredis.Client) error {
+			return node.FlushAll(ctx).Err()
+		})
+	}
+	_, err := c.removeMatching(c.ctx, "*")
+	return err
+}
+
+// RemoveMatching evicts the keys matching the glob-style pattern, which applies to the keys without the key prefix,
+// and returns their number.
+func (c *Cache) RemoveMatching(ctx context.Context, pattern string) (int, error) {
+	return c.removeMatching(ctx, redisPattern(pattern))
+}
+
+func (c *Cache) removeMatching(ctx context.Context, pattern string) (int, error) {
+	var mu sync.Mutex
+	removed := 0
+	err := c.forEachNode(ctx, func(ctx context.Context, node *goredis.Client) error {
+		iter := node.Scan(ctx, 0, cache.EscapePattern(c.prefix)+pattern, purgeBatchSize).Iterator()
+		keys := make([]string, 0, purgeBatchSize)
+		deleted := 0
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+			if len(keys) == purgeBatchSize {
+				n, err := deleteKeys(ctx, node, keys)
+				deleted += n
+				if err != nil {
+					return err
+				}
+				keys = keys[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		n, err := deleteKeys(ctx, node, keys)
+		deleted += n
+
+		mu.Lock()
+		removed += deleted
+		mu.Unlock()
+		return err
+	})
+	return removed, err
 }
 
 // Remove evicts a specific key from the cache.
 func (c *Cache) Remove(key string) error {
-	return c.rdb.Do(c.ctx, "del", key).Err()
+	return c.rdb.Do(c.ctx, "del", c.key(key)).Err()
 }
 
 // SetTTL registers a key-value pair to the cache, specifying an expiry time.
 func (c *Cache) SetTTL(key string, value interface{}, ttl time.Duration) error {
-	return c.rdb.Do(c.ctx, "set", key, value, "px", int(ttl.Milliseconds())).Err()
+	return c.rdb.Do(c.ctx, "set", c.key(key), value, "px", int(ttl.Milliseconds())).Err()
+}
+
+func (c *Cache) key(key string) string {
+	return c.prefix + key
+}
+
+// deleteKeys deletes the keys one by one in a pipeline, since keys of different hash slots
+// cannot be deleted with a single command in a cluster.
+func deleteKeys(ctx context.Context, node *goredis.Client, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	cmds, err := node.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	deleted := 0
+	for _, cmd := range cmds {
+		if del, ok := cmd.(*goredis.IntCmd); ok {
+			deleted += int(del.Val())
+		}
+	}
+	return deleted, err
+}
+
+// redisPattern converts a pattern of the cache package to a Redis pattern, escaping the brackets which Redis supports.
+func redisPattern(pattern string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		if !escaped && (r == '[' || r == ']') {
+			sb.WriteRune('\\')
+		}
+		escaped = !escaped && r == '\\'
+		sb.WriteRune(r)
+	}
+	return sb.String()
 }
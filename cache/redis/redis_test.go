@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		oo             []OptionFunc
+		expectedPrefix string
+		expectedTTL    time.Duration
+		expectedErr    string
+	}{
+		"success":      {oo: []OptionFunc{KeyPrefix("routes:"), TTL(time.Minute)}, expectedPrefix: "routes:", expectedTTL: time.Minute},
+		"no options":   {},
+		"empty prefix": {oo: []OptionFunc{KeyPrefix("")}, expectedErr: "key prefix is empty"},
+		"zero ttl":     {oo: []OptionFunc{TTL(0)}, expectedErr: "TTL must be positive"},
+		"negative ttl": {oo: []OptionFunc{TTL(-time.Second)}, expectedErr: "TTL must be positive"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(context.Background(), Options{Addr: "localhost:6379"}, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPrefix, got.prefix)
+			assert.Equal(t, tt.expectedTTL, got.ttl)
+			assert.Equal(t, tt.expectedPrefix+"key", got.key("key"))
+		})
+	}
+}
+
+func TestNewCluster(t *testing.T) {
+	got, err := NewCluster(context.Background(), ClusterOptions{})
+	assert.EqualError(t, err, "cluster addresses are empty")
+	assert.Nil(t, got)
+
+	got, err = NewCluster(context.Background(), ClusterOptions{Addrs: []string{"localhost:7000", "localhost:7001"}}, KeyPrefix("routes:"))
+	require.NoError(t, err)
+	assert.Equal(t, "routes:key", got.key("key"))
+}
+
+func TestRedisPattern(t *testing.T) {
+	assert.Equal(t, "/users/*:*", redisPattern("/users/*:*"))
+	assert.Equal(t, `/a\[1\]?`, redisPattern("/a[1]?"))
+	assert.Equal(t, `/a\[1\]\*`, redisPattern(`/a\[1\]\*`))
+}
@@ -144,6 +144,9 @@ func (tc *TracedPublisher) Publish(ctx context.Context, msg *Message) error {
 		return fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
 	p.Headers[correlation.HeaderID] = correlation.IDFromContext(ctx)
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		p.Headers[k] = v
+	}
 
 	err = tc.ch.Publish(tc.exc, "", false, false, p)
 	trace.SpanComplete(sp, err)
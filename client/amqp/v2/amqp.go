@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/patron/correlation"
@@ -20,9 +21,15 @@ import (
 
 const (
 	publisherComponent = "amqp-publisher"
+
+	defaultHeartbeat = 10 * time.Second
+	defaultLocale    = "en_US"
 )
 
-var publishDurationMetrics *prometheus.HistogramVec
+var (
+	publishDurationMetrics *prometheus.HistogramVec
+	publishFailures        *prometheus.CounterVec
+)
 
 func init() {
 	publishDurationMetrics = prometheus.NewHistogramVec(
@@ -34,14 +41,28 @@ func init() {
 		},
 		[]string{"exchange", "success"},
 	)
-	prometheus.MustRegister(publishDurationMetrics)
+	publishFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "amqp",
+			Name:      "publish_failures",
+			Help:      "AMQP messages which were published but not delivered, classified by exchange and reason (nack, return, timeout)",
+		},
+		[]string{"exchange", "reason"},
+	)
+	prometheus.MustRegister(publishDurationMetrics, publishFailures)
 }
 
 // Publisher defines a RabbitMQ publisher with tracing instrumentation.
 type Publisher struct {
-	cfg        *amqp.Config
-	connection *amqp.Connection
-	channel    *amqp.Channel
+	cfg            *amqp.Config
+	confirmTimeout time.Duration
+	connection     *amqp.Connection
+	channel        *amqp.Channel
+
+	// lock to publish one message at a time while awaiting the confirms
+	mu        sync.Mutex
+	confirmer *confirmer
 }
 
 // New constructor.
@@ -78,27 +99,45 @@ func New(url string, oo ...OptionFunc) (*Publisher, error) {
 
 	pub.connection = conn
 	pub.channel = ch
+
+	if pub.confirmTimeout == 0 {
+		go observeReturns(ch.NotifyReturn(make(chan amqp.Return, 1)))
+		return pub, nil
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		return nil, patronerrors.Aggregate(fmt.Errorf("failed to enable publisher confirms: %w", err), ch.Close(), conn.Close())
+	}
+	pub.confirmer = &confirmer{
+		timeout:  pub.confirmTimeout,
+		confirms: ch.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		returns:  ch.NotifyReturn(make(chan amqp.Return, 1)),
+	}
 	return pub, nil
 }
 
+// config returns the dial configuration, which is initialized with the defaults of amqp.Dial,
+// so that the options can tune it without providing the whole configuration.
+func (tc *Publisher) config() *amqp.Config {
+	if tc.cfg == nil {
+		tc.cfg = &amqp.Config{Heartbeat: defaultHeartbeat, Locale: defaultLocale}
+	}
+	return tc.cfg
+}
+
 // Publish a message to a exchange.
+// When the publisher confirms are enabled, it waits until the broker confirms the message, returning ErrNacked if the broker
+// does not acknowledge it, ErrReturned if a mandatory message cannot be routed, and ErrConfirmTimeout if the confirm times out.
 func (tc *Publisher) Publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
 	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, exchange),
 		publisherComponent, ext.SpanKindProducer, opentracing.Tag{Key: "exchange", Value: exchange})
 
-	if msg.Headers == nil {
-		msg.Headers = amqp.Table{}
-	}
-
-	c := amqpHeadersCarrier(msg.Headers)
-
-	if err := sp.Tracer().Inject(sp.Context(), opentracing.TextMap, c); err != nil {
+	if err := injectHeaders(ctx, &msg, sp); err != nil {
 		log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
 	}
-	msg.Headers[correlation.HeaderID] = correlation.IDFromContext(ctx)
 
 	start := time.Now()
-	err := tc.channel.Publish(exchange, key, mandatory, immediate, msg)
+	err := tc.publish(ctx, exchange, key, mandatory, immediate, msg)
 
 	observePublish(sp, start, exchange, err)
 	if err != nil {
@@ -108,11 +147,47 @@ func (tc *Publisher) Publish(ctx context.Context, exchange, key string, mandator
 	return nil
 }
 
+func (tc *Publisher) publish(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if tc.confirmer == nil {
+		return tc.channel.Publish(exchange, key, mandatory, immediate, msg)
+	}
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.confirmer.drainReturns()
+	if err := tc.channel.Publish(exchange, key, mandatory, immediate, msg); err != nil {
+		return err
+	}
+	tc.confirmer.published()
+
+	reason, err := tc.confirmer.await(ctx)
+	if reason != "" {
+		publishFailuresInc(exchange, reason)
+	}
+	return err
+}
+
 // Close the channel and connection.
 func (tc *Publisher) Close() error {
 	return patronerrors.Aggregate(tc.channel.Close(), tc.connection.Close())
 }
 
+// injectHeaders injects the span, the correlation ID and the propagated headers of the context into the message's headers,
+// so that the consumers join the trace. A correlation ID header which is already set, e.g. when a consumed message is
+// published again with its headers, is kept.
+func injectHeaders(ctx context.Context, msg *amqp.Publishing, sp opentracing.Span) error {
+	if msg.Headers == nil {
+		msg.Headers = amqp.Table{}
+	}
+	if id, ok := msg.Headers[correlation.HeaderID].(string); !ok || id == "" {
+		msg.Headers[correlation.HeaderID] = correlation.IDFromContext(ctx)
+	}
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		msg.Headers[k] = v
+	}
+	return sp.Tracer().Inject(sp.Context(), opentracing.TextMap, amqpHeadersCarrier(msg.Headers))
+}
+
 type amqpHeadersCarrier map[string]interface{}
 
 // Set implements Set() of opentracing.TextMapWriter.
@@ -120,6 +195,10 @@ func (c amqpHeadersCarrier) Set(key, val string) {
 	c[key] = val
 }
 
+func publishFailuresInc(exchange, reason string) {
+	publishFailures.WithLabelValues(exchange, reason).Inc()
+}
+
 func observePublish(span opentracing.Span, start time.Time, exchange string, err error) {
 	trace.SpanComplete(span, err)
 	publishDurationMetrics.WithLabelValues(exchange, strconv.FormatBool(err != nil)).Observe(time.Since(start).Seconds())
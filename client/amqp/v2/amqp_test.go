@@ -1,9 +1,14 @@
 package v2
 
 import (
+	"context"
 	"testing"
 
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -29,3 +34,35 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestInjectHeaders(t *testing.T) {
+	correlation.PropagateHeaders("X-Tenant")
+	defer correlation.PropagateHeaders()
+
+	ctx := correlation.ContextWithID(context.Background(), "corID")
+	ctx = correlation.ContextWithHeaders(ctx, func(header string) string { return map[string]string{"X-Tenant": "acme"}[header] })
+
+	tests := map[string]struct {
+		headers     amqp.Table
+		expectedCor string
+	}{
+		"no headers":                {expectedCor: "corID"},
+		"correlation ID is kept":    {headers: amqp.Table{correlation.HeaderID: "existing", "X-Tenant": "other"}, expectedCor: "existing"},
+		"empty correlation ID":      {headers: amqp.Table{correlation.HeaderID: ""}, expectedCor: "corID"},
+		"non string correlation ID": {headers: amqp.Table{correlation.HeaderID: 1}, expectedCor: "corID"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			tracer := mocktracer.New()
+			sp := tracer.StartSpan("publish")
+			msg := amqp.Publishing{Headers: tt.headers}
+
+			require.NoError(t, injectHeaders(ctx, &msg, sp))
+			assert.Equal(t, tt.expectedCor, msg.Headers[correlation.HeaderID])
+			assert.Equal(t, "acme", msg.Headers["X-Tenant"])
+			assert.NotEmpty(t, msg.Headers["mockpfx-ids-traceid"])
+			assert.NotEmpty(t, msg.Headers["mockpfx-ids-spanid"])
+		})
+	}
+}
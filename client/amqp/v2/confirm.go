@@ -0,0 +1,109 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/streadway/amqp"
+)
+
+const (
+	failureNack    = "nack"
+	failureReturn  = "return"
+	failureTimeout = "timeout"
+)
+
+var (
+	// ErrNacked is returned when the broker does not confirm a published message, e.g. due to an internal error.
+	ErrNacked = errors.New("message was not acknowledged by the broker")
+	// ErrReturned is returned when a mandatory message cannot be routed to any queue.
+	ErrReturned = errors.New("message was returned by the broker")
+	// ErrConfirmTimeout is returned when the broker does not confirm a published message within the confirm timeout.
+	ErrConfirmTimeout = errors.New("message was not confirmed within the timeout")
+)
+
+// confirmer awaits the confirms of the published messages, along with the returns of the unroutable mandatory messages,
+// which the broker sends before their confirms. The messages are published one at a time, so that a return belongs to the
+// message which is awaited.
+type confirmer struct {
+	timeout  time.Duration
+	confirms <-chan amqp.Confirmation
+	returns  <-chan amqp.Return
+	// delivery tag of the last published message, which increments with every message published on the channel
+	tag uint64
+}
+
+// published increments the delivery tag of the last published message.
+func (c *confirmer) published() {
+	c.tag++
+}
+
+// drainReturns discards the returns of the messages which were not awaited, e.g. because their confirm timed out.
+func (c *confirmer) drainReturns() {
+	for {
+		select {
+		case <-c.returns:
+		default:
+			return
+		}
+	}
+}
+
+// await waits for the confirm of the last published message, returning the reason it failed, if any.
+func (c *confirmer) await(ctx context.Context) (string, error) {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	returns := c.returns
+	var returned *amqp.Return
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			returned = &r
+		case cf, ok := <-c.confirms:
+			if !ok {
+				return "", errors.New("channel closed before the message was confirmed")
+			}
+			if cf.DeliveryTag < c.tag {
+				// the confirm of a message which timed out
+				continue
+			}
+			if returned == nil {
+				select {
+				case r, ok := <-returns:
+					if ok {
+						returned = &r
+					}
+				default:
+				}
+			}
+			if returned != nil {
+				return failureReturn, fmt.Errorf("%w: %d %s", ErrReturned, returned.ReplyCode, returned.ReplyText)
+			}
+			if !cf.Ack {
+				return failureNack, ErrNacked
+			}
+			return "", nil
+		case <-timer.C:
+			return failureTimeout, fmt.Errorf("%w of %v", ErrConfirmTimeout, c.timeout)
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// observeReturns reports the returns of the unroutable mandatory messages when the confirms are disabled,
+// since the publisher does not wait for them.
+func observeReturns(returns <-chan amqp.Return) {
+	for r := range returns {
+		log.Warnf("message published to exchange %s with routing key %s was returned: %d %s", r.Exchange, r.RoutingKey, r.ReplyCode, r.ReplyText)
+		publishFailuresInc(r.Exchange, failureReturn)
+	}
+}
@@ -0,0 +1,85 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmer_await(t *testing.T) {
+	returned := amqp.Return{ReplyCode: amqp.NoRoute, ReplyText: "NO_ROUTE", Exchange: "exchange"}
+	tests := map[string]struct {
+		confirms       []amqp.Confirmation
+		returns        []amqp.Return
+		closeConfirms  bool
+		cancelCtx      bool
+		expectedReason string
+		expectedErr    error
+		expectedErrMsg string
+	}{
+		"acked":  {confirms: []amqp.Confirmation{{DeliveryTag: 2, Ack: true}}},
+		"nacked": {confirms: []amqp.Confirmation{{DeliveryTag: 2}}, expectedReason: failureNack, expectedErr: ErrNacked},
+		"returned": {
+			confirms: []amqp.Confirmation{{DeliveryTag: 2, Ack: true}}, returns: []amqp.Return{returned},
+			expectedReason: failureReturn, expectedErr: ErrReturned, expectedErrMsg: "message was returned by the broker: 312 NO_ROUTE",
+		},
+		"confirm of a timed out message": {
+			confirms: []amqp.Confirmation{{DeliveryTag: 1}, {DeliveryTag: 2, Ack: true}},
+		},
+		"timeout": {
+			expectedReason: failureTimeout, expectedErr: ErrConfirmTimeout, expectedErrMsg: "message was not confirmed within the timeout of 10ms",
+		},
+		"channel closed":    {closeConfirms: true, expectedErrMsg: "channel closed before the message was confirmed"},
+		"context cancelled": {cancelCtx: true, expectedErr: context.Canceled},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			confirms := make(chan amqp.Confirmation, len(tt.confirms))
+			for _, cf := range tt.confirms {
+				confirms <- cf
+			}
+			if tt.closeConfirms {
+				close(confirms)
+			}
+			returns := make(chan amqp.Return, len(tt.returns))
+			for _, r := range tt.returns {
+				returns <- r
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelCtx {
+				cancel()
+			}
+
+			c := &confirmer{timeout: 10 * time.Millisecond, confirms: confirms, returns: returns, tag: 1}
+			c.published()
+			reason, err := c.await(ctx)
+
+			assert.Equal(t, tt.expectedReason, reason)
+			if tt.expectedErr == nil && tt.expectedErrMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if tt.expectedErr != nil {
+				assert.True(t, errors.Is(err, tt.expectedErr))
+			}
+			if tt.expectedErrMsg != "" {
+				assert.EqualError(t, err, tt.expectedErrMsg)
+			}
+		})
+	}
+}
+
+func TestConfirmer_drainReturns(t *testing.T) {
+	returns := make(chan amqp.Return, 2)
+	returns <- amqp.Return{}
+	returns <- amqp.Return{}
+	c := &confirmer{returns: returns}
+	c.drainReturns()
+	assert.Empty(t, returns)
+}
@@ -1,16 +1,71 @@
 package v2
 
 import (
+	"crypto/tls"
+	"errors"
+	"time"
+
+	"github.com/beatlabs/patron/internal/amqp/dial"
 	"github.com/streadway/amqp"
 )
 
 // OptionFunc definition for configuring the publisher in a functional way.
 type OptionFunc func(*Publisher) error
 
-// Config option for providing dial configuration.
+// Config option for providing dial configuration. The TLS configuration, the heartbeat and the connection name
+// set by the TLS, Heartbeat and ConnectionName options are kept, unless they are set in the dial configuration as well.
 func Config(cfg amqp.Config) OptionFunc {
 	return func(p *Publisher) error {
+		if p.cfg != nil {
+			cfg = dial.MergeConfig(cfg, *p.cfg)
+		}
 		p.cfg = &cfg
 		return nil
 	}
 }
+
+// TLS option for setting the TLS configuration of the connection, which is used with the amqps scheme of the URL.
+func TLS(cfg *tls.Config) OptionFunc {
+	return func(p *Publisher) error {
+		if cfg == nil {
+			return errors.New("TLS config is nil")
+		}
+		p.config().TLSClientConfig = cfg
+		return nil
+	}
+}
+
+// Heartbeat option for setting the interval of the heartbeats which detect the loss of the connection, which is 10s by default.
+func Heartbeat(interval time.Duration) OptionFunc {
+	return func(p *Publisher) error {
+		if interval <= 0 {
+			return errors.New("heartbeat interval should be a positive number")
+		}
+		p.config().Heartbeat = interval
+		return nil
+	}
+}
+
+// ConnectionName option for setting the name of the connection, which is shown in the management UI of the broker.
+func ConnectionName(name string) OptionFunc {
+	return func(p *Publisher) error {
+		if name == "" {
+			return errors.New("connection name is empty")
+		}
+		dial.WithConnectionName(p.config(), name)
+		return nil
+	}
+}
+
+// PublisherConfirms option for enabling the publisher confirms, so that a message is published once the broker confirms it,
+// waiting for up to the timeout. The unroutable messages which are published as mandatory are returned as errors.
+// The messages are published one at a time while the confirms are awaited.
+func PublisherConfirms(timeout time.Duration) OptionFunc {
+	return func(p *Publisher) error {
+		if timeout <= 0 {
+			return errors.New("confirm timeout should be a positive number")
+		}
+		p.confirmTimeout = timeout
+		return nil
+	}
+}
@@ -1,10 +1,13 @@
 package v2
 
 import (
+	"crypto/tls"
 	"testing"
+	"time"
 
 	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTimeout(t *testing.T) {
@@ -16,3 +19,44 @@ func TestTimeout(t *testing.T) {
 	assert.NoError(t, Config(cfg)(&p))
 	assert.Equal(t, cfg, *p.cfg)
 }
+
+func TestConfig_Merge(t *testing.T) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	props := amqp.Table{"product": "orders"}
+	p := Publisher{}
+	require.NoError(t, TLS(tlsCfg)(&p))
+	require.NoError(t, Heartbeat(time.Second)(&p))
+	require.NoError(t, ConnectionName("orders-publisher")(&p))
+	require.NoError(t, Config(amqp.Config{Locale: "123", Properties: props})(&p))
+
+	assert.Equal(t, &amqp.Config{
+		Locale:          "123",
+		Heartbeat:       time.Second,
+		TLSClientConfig: tlsCfg,
+		Properties:      amqp.Table{"product": "orders", "connection_name": "orders-publisher"},
+	}, p.cfg, "the fields of the other options are kept")
+	assert.Equal(t, amqp.Table{"product": "orders"}, props, "the properties of the config are not modified")
+}
+
+func TestPublisherConfirms(t *testing.T) {
+	p := Publisher{}
+	assert.EqualError(t, PublisherConfirms(0)(&p), "confirm timeout should be a positive number")
+	assert.NoError(t, PublisherConfirms(time.Second)(&p))
+	assert.Equal(t, time.Second, p.confirmTimeout)
+}
+
+func TestConnectionOptions(t *testing.T) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	p := Publisher{}
+	assert.EqualError(t, TLS(nil)(&p), "TLS config is nil")
+	assert.EqualError(t, Heartbeat(0)(&p), "heartbeat interval should be a positive number")
+	assert.EqualError(t, ConnectionName("")(&p), "connection name is empty")
+	assert.Nil(t, p.cfg)
+
+	assert.NoError(t, TLS(tlsCfg)(&p))
+	assert.Equal(t, &amqp.Config{Heartbeat: 10 * time.Second, Locale: "en_US", TLSClientConfig: tlsCfg}, p.cfg, "the defaults of the dial are kept")
+	assert.NoError(t, Heartbeat(time.Second)(&p))
+	assert.NoError(t, ConnectionName("orders-publisher")(&p))
+	assert.Equal(t, time.Second, p.cfg.Heartbeat)
+	assert.Equal(t, amqp.Table{"connection_name": "orders-publisher"}, p.cfg.Properties)
+}
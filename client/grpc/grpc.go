@@ -4,6 +4,8 @@ package grpc
 
 import (
 	"context"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/patron/correlation"
@@ -20,6 +22,7 @@ import (
 const (
 	componentName = "grpc-client"
 	unary         = "unary"
+	stream        = "stream"
 )
 
 var (
@@ -39,20 +42,22 @@ func init() {
 	prometheus.MustRegister(rpcDurationMetrics)
 }
 
-// Dial creates a client connection to the given target with a tracing and
-// metrics unary interceptor.
+// Dial creates a client connection to the given target with tracing and
+// metrics unary and stream interceptors.
 func Dial(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	return DialContext(context.Background(), target, opts...)
 }
 
 // DialContext creates a client connection to the given target with a context and
-// a tracing and metrics unary interceptor.
+// tracing and metrics unary and stream interceptors, which propagate the correlation ID as well.
+// The interceptors of the options, e.g. WithRetry and WithCircuitBreaker, run after them in the order they are given,
+// so that an RPC is traced and measured once, including its retries.
 func DialContext(ctx context.Context, target string, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
 	if len(opts) == 0 {
 		opts = make([]grpc.DialOption, 0)
 	}
 
-	opts = append(opts, grpc.WithUnaryInterceptor(unaryInterceptor(target)))
+	opts = append(opts, grpc.WithUnaryInterceptor(unaryInterceptor(target)), grpc.WithStreamInterceptor(streamInterceptor(target)))
 
 	return grpc.DialContext(ctx, target, opts...)
 }
@@ -66,36 +71,111 @@ func (c *headersCarrier) Set(key, val string) {
 	c.Ctx = metadata.AppendToOutgoingContext(c.Ctx, key, val)
 }
 
+// startSpan starts the span of an RPC, injecting it along with the correlation ID and the propagated headers in the outgoing metadata.
+func startSpan(ctx context.Context, method string) (opentracing.Span, context.Context) {
+	span, ctx := trace.ChildSpan(ctx,
+		trace.ComponentOpName(componentName, method),
+		componentName,
+		ext.SpanKindProducer,
+	)
+	carrier := headersCarrier{Ctx: ctx}
+	err := span.Tracer().Inject(span.Context(), opentracing.TextMap, &carrier)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
+	}
+
+	corID := correlation.IDFromContext(carrier.Ctx)
+	ctx = metadata.AppendToOutgoingContext(carrier.Ctx, correlation.HeaderID, corID)
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	return span, ctx
+}
+
+func finishSpan(span opentracing.Span, typ, target, method string, start time.Time, err error) {
+	rpcStatus, _ := status.FromError(err) // codes.OK if err == nil, codes.Unknown if !ok
+	rpcDurationMetrics.
+		WithLabelValues(typ, target, method, rpcStatus.Code().String()).
+		Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		trace.SpanError(span)
+		return
+	}
+	trace.SpanSuccess(span)
+}
+
 func unaryInterceptor(target string) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		span, ctx := trace.ChildSpan(ctx,
-			trace.ComponentOpName(componentName, method),
-			componentName,
-			ext.SpanKindProducer,
-		)
-		carrier := headersCarrier{Ctx: ctx}
-		err := span.Tracer().Inject(span.Context(), opentracing.TextMap, &carrier)
+		span, ctx := startSpan(ctx, method)
+		invokeTime := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		finishSpan(span, unary, target, method, invokeTime, err)
+		return err
+	}
+}
+
+// streamInterceptor traces and measures the streams from their creation until they end, i.e. until the messages of the server
+// have been received, or the stream fails or its context is done.
+func streamInterceptor(target string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		span, ctx := startSpan(ctx, method)
+		invokeTime := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
 		if err != nil {
-			log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
+			finishSpan(span, stream, target, method, invokeTime, err)
+			return nil, err
 		}
 
-		corID := correlation.IDFromContext(carrier.Ctx)
-		ctx = metadata.AppendToOutgoingContext(carrier.Ctx, correlation.HeaderID, corID)
-		invokeTime := time.Now()
-		err = invoker(ctx, method, req, reply, cc, opts...)
-		invokeDuration := time.Since(invokeTime)
+		ts := &tracedClientStream{ClientStream: cs, serverStreams: desc.ServerStreams, done: make(chan struct{})}
+		ts.finish = func(err error) {
+			finishSpan(span, stream, target, method, invokeTime, err)
+		}
+		go func() {
+			select {
+			case <-ctx.Done():
+				ts.end(ctx.Err())
+			case <-ts.done:
+			}
+		}()
+		return ts, nil
+	}
+}
+
+type tracedClientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	once          sync.Once
+	done          chan struct{}
+	finish        func(error)
+}
 
-		rpcStatus, _ := status.FromError(err) // codes.OK if err == nil, codes.Unknown if !ok
-		rpcDurationMetrics.
-			WithLabelValues(unary, target, method, rpcStatus.Code().String()).
-			Observe(invokeDuration.Seconds())
+func (s *tracedClientStream) end(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		s.finish(err)
+	})
+}
 
-		if err != nil {
-			trace.SpanError(span)
-			return err
-		}
+// RecvMsg ends the stream when it fails or the server has sent all its messages, i.e. its only one with client streaming.
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.end(nil)
+	case err != nil:
+		s.end(err)
+	case !s.serverStreams:
+		s.end(nil)
+	}
+	return err
+}
 
-		trace.SpanSuccess(span)
-		return nil
+// SendMsg ends the stream when it fails.
+func (s *tracedClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && err != io.EOF {
+		s.end(err)
 	}
+	return err
 }
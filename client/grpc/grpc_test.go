@@ -178,3 +178,30 @@ func TestSayHello(t *testing.T) {
 		})
 	}
 }
+
+func TestSayHelloStream(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	rpcDurationMetrics.Reset()
+
+	ctx := context.Background()
+	conn, err := DialContext(ctx, target, grpc.WithContextDialer(bufDialer), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+
+	cs, err := examples.NewGreeterClient(conn).SayHelloStream(ctx, &examples.HelloRequest{Firstname: "John"})
+	require.NoError(t, err)
+	_, err = cs.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	// Tracing
+	require.Len(t, mtr.FinishedSpans(), 1, "the span finishes when the stream ends")
+	assert.Equal(t, true, mtr.FinishedSpans()[0].Tag("error"))
+
+	// Metrics
+	assert.Equal(t, 1, testutil.CollectAndCount(rpcDurationMetrics))
+	assert.True(t, rpcDurationMetrics.DeleteLabelValues(stream, target, "/greeter.Greeter/SayHelloStream", "Unavailable"))
+}
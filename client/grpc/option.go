@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/beatlabs/patron/reliability/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failureCodes are the codes of the RPCs which fail due to the server or the network, rather than the request.
+var failureCodes = []codes.Code{codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unavailable}
+
+// WithRetry retries the unary RPCs failing with one of the codes, which default to Unavailable, e.g. while a server restarts.
+// The RPCs are not retried once their context is done.
+func WithRetry(r *retry.Retry, cc ...codes.Code) grpc.DialOption {
+	if len(cc) == 0 {
+		cc = []codes.Code{codes.Unavailable}
+	}
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, conn *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if r == nil {
+			return invoker(ctx, method, req, reply, conn, opts...)
+		}
+		act, rpcErr := filter(func() error {
+			return invoker(ctx, method, req, reply, conn, opts...)
+		}, func(err error) bool {
+			return ctx.Err() == nil && hasCode(err, cc)
+		})
+		if _, err := r.Execute(act); err != nil {
+			return err
+		}
+		return *rpcErr
+	})
+}
+
+// WithCircuitBreaker executes the unary RPCs with the circuit breaker, counting the ones which fail due to the server or the network as failures,
+// i.e. with Unknown, DeadlineExceeded, ResourceExhausted, Internal or Unavailable. While the circuit is open, the RPCs fail with Unavailable.
+func WithCircuitBreaker(cb *circuitbreaker.CircuitBreaker) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, conn *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cb == nil {
+			return invoker(ctx, method, req, reply, conn, opts...)
+		}
+		act, rpcErr := filter(func() error {
+			return invoker(ctx, method, req, reply, conn, opts...)
+		}, func(err error) bool {
+			return hasCode(err, failureCodes)
+		})
+		_, err := cb.Execute(act)
+		var openErr *circuitbreaker.OpenError
+		if errors.As(err, &openErr) {
+			return status.Error(codes.Unavailable, openErr.Error())
+		}
+		if err != nil {
+			return err
+		}
+		return *rpcErr
+	})
+}
+
+// filter returns the action of the RPC for a retry or a circuit breaker, which fails only with the errors they have to handle,
+// along with the error of the last execution of the RPC, which is returned as it is when the action does not fail.
+func filter(rpc func() error, handled func(error) bool) (func() (interface{}, error), *error) {
+	var rpcErr error
+	return func() (interface{}, error) {
+		rpcErr = rpc()
+		if rpcErr != nil && handled(rpcErr) {
+			return nil, rpcErr
+		}
+		return nil, nil
+	}, &rpcErr
+}
+
+func hasCode(err error, cc []codes.Code) bool {
+	code := status.Code(err)
+	for _, c := range cc {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
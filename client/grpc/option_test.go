@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/examples"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/beatlabs/patron/reliability/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// flakyServer fails the RPCs with the code until it has failed the given number of times.
+type flakyServer struct {
+	examples.UnimplementedGreeterServer
+	calls    int32
+	failures int32
+	code     codes.Code
+}
+
+func (s *flakyServer) SayHello(_ context.Context, req *examples.HelloRequest) (*examples.HelloReply, error) {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return nil, status.Error(s.code, "failure")
+	}
+	return &examples.HelloReply{Message: "Hello " + req.GetFirstname()}, nil
+}
+
+func dialFlaky(t *testing.T, srv *flakyServer, opts ...grpc.DialOption) examples.GreeterClient {
+	l := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	examples.RegisterGreeterServer(s, srv)
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+
+	dialer := func(context.Context, string) (net.Conn, error) { return l.Dial() }
+	opts = append(opts, grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	conn, err := Dial(target, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		rpcDurationMetrics.Reset()
+	})
+	return examples.NewGreeterClient(conn)
+}
+
+func TestWithRetry(t *testing.T) {
+	r, err := retry.New(3, time.Millisecond)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		srv           *flakyServer
+		codes         []codes.Code
+		expectedCode  codes.Code
+		expectedCalls int32
+	}{
+		"success after retries": {srv: &flakyServer{failures: 2, code: codes.Unavailable}, expectedCode: codes.OK, expectedCalls: 3},
+		"retries exhausted":     {srv: &flakyServer{failures: 5, code: codes.Unavailable}, expectedCode: codes.Unavailable, expectedCalls: 3},
+		"not retried code":      {srv: &flakyServer{failures: 5, code: codes.InvalidArgument}, expectedCode: codes.InvalidArgument, expectedCalls: 1},
+		"custom codes": {
+			srv:   &flakyServer{failures: 1, code: codes.Aborted},
+			codes: []codes.Code{codes.Aborted}, expectedCode: codes.OK, expectedCalls: 2,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			client := dialFlaky(t, tt.srv, WithRetry(r, tt.codes...))
+			rsp, err := client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "John"})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "Hello John", rsp.GetMessage())
+			}
+			assert.Equal(t, tt.expectedCalls, atomic.LoadInt32(&tt.srv.calls))
+		})
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	cb, err := circuitbreaker.New("greeter", circuitbreaker.Setting{
+		FailureThreshold: 2, RetryTimeout: time.Hour, RetrySuccessThreshold: 1, MaxRetryExecutionThreshold: 10,
+	})
+	require.NoError(t, err)
+	srv := &flakyServer{failures: 2, code: codes.InvalidArgument}
+	client := dialFlaky(t, srv, WithCircuitBreaker(cb))
+
+	for i := 0; i < 3; i++ {
+		_, err = client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "John"})
+	}
+	assert.NoError(t, err, "the errors of the requests do not open the circuit")
+
+	atomic.StoreInt32(&srv.calls, 0)
+	srv.code = codes.Internal
+	for i := 0; i < 2; i++ {
+		_, err = client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "John"})
+		assert.Equal(t, codes.Internal, status.Code(err))
+	}
+	_, err = client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "John"})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "the circuit is open")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&srv.calls))
+}
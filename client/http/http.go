@@ -4,6 +4,8 @@ package http
 import (
 	"compress/flate"
 	"compress/gzip"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -46,6 +48,8 @@ type Client interface {
 }
 
 // TracedClient defines a HTTP client with tracing integrated.
+// A TracedClient is safe for concurrent use and should be created once and reused,
+// since creating a client per request prevents the reuse of pooled connections.
 type TracedClient struct {
 	cl *http.Client
 	cb *circuitbreaker.CircuitBreaker
@@ -79,6 +83,9 @@ func (tc *TracedClient) Do(req *http.Request) (*http.Response, error) {
 	defer ht.Finish()
 
 	req.Header.Set(correlation.HeaderID, correlation.IDFromContext(req.Context()))
+	for k, v := range correlation.HeadersFromContext(req.Context()) {
+		req.Header.Set(k, v)
+	}
 
 	start := time.Now()
 
@@ -104,6 +111,38 @@ func (tc *TracedClient) Do(req *http.Request) (*http.Response, error) {
 	return rsp, err
 }
 
+// DoWithTimeout executes an HTTP request like Do, bounding it with a per-call timeout.
+// The timeout is applied to the request context, which allows a single client and its pooled
+// transport to be reused across calls with different deadlines. The client-wide timeout still applies,
+// so the effective deadline is the shortest of the two.
+// The deadline also covers reading the response body, which must be closed by the caller.
+func (tc *TracedClient) DoWithTimeout(ctx context.Context, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+
+	ctx, cnl := context.WithTimeout(ctx, timeout)
+	rsp, err := tc.Do(req.WithContext(ctx))
+	if err != nil {
+		cnl()
+		return rsp, err
+	}
+
+	rsp.Body = &cancelReadCloser{ReadCloser: rsp.Body, cancel: cnl}
+	return rsp, nil
+}
+
+// cancelReadCloser releases the resources of the per-call context once the response body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
 func (tc *TracedClient) do(req *http.Request) (*http.Response, error) {
 	if tc.cb == nil {
 		return tc.cl.Do(req)
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -16,7 +17,9 @@ import (
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/reliability/circuitbreaker"
 )
@@ -83,6 +86,77 @@ func TestTracedClient_Do(t *testing.T) {
 	}
 }
 
+func TestTracedClient_Do_PropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tenant", r.Header.Get("X-Tenant-Id"))
+		assert.Equal(t, "123", r.Header.Get(correlation.HeaderID))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	inbound := http.Header{}
+	inbound.Set("X-Tenant-Id", "tenant")
+	ctx := correlation.ContextWithHeaders(correlation.ContextWithID(context.Background(), "123"), inbound.Get)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	c, err := New()
+	require.NoError(t, err)
+	rsp, err := c.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	_ = rsp.Body.Close()
+}
+
+func TestTracedClient_DoWithTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		_, _ = fmt.Fprint(w, "Hello, client")
+	}))
+	defer ts.Close()
+	opentracing.SetGlobalTracer(mocktracer.New())
+	c, err := New()
+	require.NoError(t, err)
+
+	type args struct {
+		path    string
+		timeout time.Duration
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":           {args: args{path: "/", timeout: time.Second}},
+		"deadline exceeded": {args: args{path: "/slow", timeout: 10 * time.Millisecond}, expectedErr: "context deadline exceeded"},
+		"invalid timeout":   {args: args{path: "/", timeout: 0}, expectedErr: "timeout must be positive"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.args.path, nil)
+			require.NoError(t, err)
+
+			rsp, err := c.DoWithTimeout(context.Background(), req, tt.args.timeout)
+
+			if tt.expectedErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, rsp)
+				return
+			}
+			require.NoError(t, err)
+			b, err := ioutil.ReadAll(rsp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "Hello, client", string(b))
+			assert.NoError(t, rsp.Body.Close())
+		})
+	}
+}
+
 func TestNew(t *testing.T) {
 	type args struct {
 		oo []OptionFunc
@@ -119,6 +119,9 @@ func (p *baseProducer) createProducerMessage(ctx context.Context, msg *Message,
 	}
 
 	c.Set(correlation.HeaderID, correlation.IDFromContext(ctx))
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		c.Set(k, v)
+	}
 	return &sarama.ProducerMessage{
 		Topic:   msg.topic,
 		Key:     saramaKey,
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/correlation"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/trace"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -26,12 +27,18 @@ func (ap *AsyncProducer) Send(ctx context.Context, msg *sarama.ProducerMessage)
 	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(componentTypeAsync, msg.Topic), componentTypeAsync,
 		ext.SpanKindProducer, asyncTag, opentracing.Tag{Key: "topic", Value: msg.Topic})
 
-	err := injectTracingHeaders(msg, sp)
+	err := injectTracingHeaders(ctx, msg, sp)
 	if err != nil {
 		statusCountAdd(deliveryTypeAsync, deliveryStatusSendError, msg.Topic, 1)
 		trace.SpanError(sp)
 		return fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
+	injectPropagatedHeaders(ctx, msg)
+	if err := ap.intercept(ctx, msg); err != nil {
+		statusCountAdd(deliveryTypeAsync, deliveryStatusSendError, msg.Topic, 1)
+		trace.SpanError(sp)
+		return err
+	}
 
 	ap.asyncProd.Input() <- msg
 	statusCountAdd(deliveryTypeAsync, deliveryStatusSent, msg.Topic, 1)
@@ -39,15 +46,28 @@ func (ap *AsyncProducer) Send(ctx context.Context, msg *sarama.ProducerMessage)
 	return nil
 }
 
-func injectTracingHeaders(msg *sarama.ProducerMessage, sp opentracing.Span) error {
-	c := kafkaHeadersCarrier(msg.Headers)
+// injectTracingHeaders injects the span and the correlation ID of the context into the message's headers, so that the consumers
+// join the trace and keep the correlation ID. The correlation ID header is kept if it is already set.
+func injectTracingHeaders(ctx context.Context, msg *sarama.ProducerMessage, sp opentracing.Span) error {
+	c := (*kafkaHeadersCarrier)(&msg.Headers)
+	if !c.has(correlation.HeaderID) {
+		c.Set(correlation.HeaderID, correlation.IDFromContext(ctx))
+	}
+	return sp.Tracer().Inject(sp.Context(), opentracing.TextMap, c)
+}
 
-	return sp.Tracer().Inject(sp.Context(), opentracing.TextMap, &c)
+// injectPropagatedHeaders injects the headers propagated in the context into the message's headers.
+func injectPropagatedHeaders(ctx context.Context, msg *sarama.ProducerMessage) {
+	c := (*kafkaHeadersCarrier)(&msg.Headers)
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		c.Set(k, v)
+	}
 }
 
 func (ap *AsyncProducer) propagateError(chErr chan<- error) {
 	for pe := range ap.asyncProd.Errors() {
 		statusCountAdd(deliveryTypeAsync, deliveryStatusSendError, pe.Msg.Topic, 1)
+		ap.monitor.check()
 		chErr <- fmt.Errorf("failed to send message: %w", pe)
 	}
 }
@@ -56,6 +76,7 @@ func (ap *AsyncProducer) propagateError(chErr chan<- error) {
 // flushed. You must call this function before a producer object passes out of
 // scope, as it may otherwise leak memory.
 func (ap *AsyncProducer) Close() error {
+	ap.monitor.close()
 	if err := ap.asyncProd.Close(); err != nil {
 		return patronerrors.Aggregate(fmt.Errorf("failed to close async producer client: %w", err), ap.prodClient.Close())
 	}
@@ -0,0 +1,146 @@
+package v2
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	defaultCheckInterval = 10 * time.Second
+	defaultMinBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff    = 30 * time.Second
+)
+
+// connectionMonitor keeps track of the connectivity of a producer to the Kafka brokers, by refreshing the metadata of the client.
+// The connection is checked periodically and whenever a producer error is reported. It does not re-create the client,
+// which dials the brokers again on its own, e.g. while refreshing the metadata, with the exponential backoff of its retries,
+// but while they are unreachable it spaces out the checks with an exponential backoff between minBackoff and maxBackoff.
+// State changes are logged once, instead of logging every failed check.
+type connectionMonitor struct {
+	refresh       func() error
+	checkInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	connected     int32
+	chCheck       chan struct{}
+	chDone        chan struct{}
+	closeOnce     sync.Once
+	wg            sync.WaitGroup
+}
+
+func newConnectionMonitor(refresh func() error, checkInterval, minBackoff, maxBackoff time.Duration) *connectionMonitor {
+	return &connectionMonitor{
+		refresh:       refresh,
+		checkInterval: checkInterval,
+		minBackoff:    minBackoff,
+		maxBackoff:    maxBackoff,
+		// the producer has been created successfully, which means that the brokers have been reached
+		connected: 1,
+		chCheck:   make(chan struct{}, 1),
+		chDone:    make(chan struct{}),
+	}
+}
+
+func (m *connectionMonitor) start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.run()
+	}()
+}
+
+func (m *connectionMonitor) run() {
+	backoff := m.minBackoff
+	lastCheck := time.Now()
+
+	for {
+		wait := m.checkInterval
+		chCheck := m.chCheck
+		if !m.isConnected() {
+			wait = backoff
+			// while the brokers are unreachable, error reports are ignored in order to respect the backoff
+			chCheck = nil
+		}
+
+		select {
+		case <-m.chDone:
+			return
+		case <-time.After(wait):
+		case <-chCheck:
+			// checks triggered by errors are spaced out by at least the minimum backoff
+			if elapsed := time.Since(lastCheck); elapsed < m.minBackoff {
+				select {
+				case <-m.chDone:
+					return
+				case <-time.After(m.minBackoff - elapsed):
+				}
+			}
+		}
+
+		err := m.refresh()
+		lastCheck = time.Now()
+		if err == nil {
+			if m.setConnected(true) {
+				log.Info("kafka producer reached the brokers again")
+			}
+			backoff = m.minBackoff
+			continue
+		}
+
+		if m.setConnected(false) {
+			log.Errorf("kafka producer cannot reach the brokers: %v", err)
+		} else {
+			log.Debugf("kafka producer still cannot reach the brokers, checking again in %v: %v", backoff, err)
+			backoff *= 2
+			if backoff > m.maxBackoff {
+				backoff = m.maxBackoff
+			}
+		}
+	}
+}
+
+// check requests a connectivity check without blocking.
+func (m *connectionMonitor) check() {
+	select {
+	case m.chCheck <- struct{}{}:
+	default:
+	}
+}
+
+func (m *connectionMonitor) isConnected() bool {
+	return atomic.LoadInt32(&m.connected) == 1
+}
+
+// setConnected stores the connection state and returns true if the state changed.
+func (m *connectionMonitor) setConnected(connected bool) bool {
+	var val int32
+	if connected {
+		val = 1
+	}
+	return atomic.SwapInt32(&m.connected, val) != val
+}
+
+func (m *connectionMonitor) close() {
+	m.closeOnce.Do(func() {
+		close(m.chDone)
+	})
+	m.wg.Wait()
+}
+
+// exponentialBackoff returns a backoff function of the retries of the Sarama client, which starts from the base backoff
+// and doubles on every retry, up to the max backoff.
+func exponentialBackoff(base, max time.Duration) func(retries, maxRetries int) time.Duration {
+	return func(retries, _ int) time.Duration {
+		backoff := base
+		for i := 0; i < retries && backoff < max; i++ {
+			backoff *= 2
+		}
+		if backoff > max {
+			return max
+		}
+		return backoff
+	}
+}
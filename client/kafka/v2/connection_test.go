@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/stretchr/testify/assert"
+)
+
+// logs are written by the std logger of all the levels concurrently.
+type logBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.buf.Write(p)
+}
+
+func (l *logBuffer) count(s string) int {
+	l.Lock()
+	defer l.Unlock()
+	return strings.Count(l.buf.String(), s)
+}
+
+var logs = &logBuffer{}
+
+func TestMain(m *testing.M) {
+	if err := log.Setup(std.New(logs, log.InfoLevel, nil)); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+type fakeBrokers struct {
+	sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *fakeBrokers) refresh() error {
+	f.Lock()
+	defer f.Unlock()
+	f.calls++
+	return f.err
+}
+
+func (f *fakeBrokers) setErr(err error) {
+	f.Lock()
+	defer f.Unlock()
+	f.err = err
+}
+
+func (f *fakeBrokers) callCount() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.calls
+}
+
+func TestConnectionMonitor_UnreachableAndReachable(t *testing.T) {
+	fb := &fakeBrokers{}
+	m := newConnectionMonitor(fb.refresh, time.Hour, 10*time.Millisecond, 20*time.Millisecond)
+	m.start()
+	defer m.close()
+
+	assert.True(t, m.isConnected())
+
+	fb.setErr(errors.New("kafka: client has run out of available brokers to talk to"))
+	m.check()
+	assert.Eventually(t, func() bool { return !m.isConnected() }, time.Second, 5*time.Millisecond)
+
+	fb.setErr(nil)
+	assert.Eventually(t, m.isConnected, time.Second, 5*time.Millisecond)
+}
+
+func TestConnectionMonitor_PeriodicCheck(t *testing.T) {
+	fb := &fakeBrokers{err: errors.New("broker down")}
+	m := newConnectionMonitor(fb.refresh, 10*time.Millisecond, 10*time.Millisecond, 20*time.Millisecond)
+	m.start()
+	defer m.close()
+
+	assert.Eventually(t, func() bool { return !m.isConnected() }, time.Second, 5*time.Millisecond)
+}
+
+func TestConnectionMonitor_Backoff(t *testing.T) {
+	fb := &fakeBrokers{err: errors.New("broker down")}
+	m := newConnectionMonitor(fb.refresh, time.Hour, 20*time.Millisecond, 40*time.Millisecond)
+	m.start()
+	m.check()
+	// error reports while disconnected must not trigger additional attempts
+	for i := 0; i < 100; i++ {
+		m.check()
+		time.Sleep(time.Millisecond)
+	}
+	m.close()
+
+	// ~100ms with a backoff of 20ms, 40ms, 40ms allows only a handful of attempts
+	assert.False(t, m.isConnected())
+	assert.LessOrEqual(t, fb.callCount(), 5)
+}
+
+func TestConnectionMonitor_LogsStateChangesOnce(t *testing.T) {
+	lost := logs.count("kafka producer cannot reach the brokers: monitor log test")
+	fb := &fakeBrokers{err: errors.New("monitor log test")}
+	m := newConnectionMonitor(fb.refresh, time.Millisecond, time.Millisecond, time.Millisecond)
+	m.start()
+	defer m.close()
+
+	// the checks fail in a tight loop, but the loss of the connection is logged once
+	assert.Eventually(t, func() bool { return fb.callCount() >= 20 }, time.Second, time.Millisecond)
+	assert.Equal(t, lost+1, logs.count("kafka producer cannot reach the brokers: monitor log test"))
+
+	reached := logs.count("kafka producer reached the brokers again")
+	fb.setErr(nil)
+	assert.Eventually(t, m.isConnected, time.Second, time.Millisecond)
+	calls := fb.callCount()
+	assert.Eventually(t, func() bool { return fb.callCount() >= calls+20 }, time.Second, time.Millisecond)
+	assert.Equal(t, reached+1, logs.count("kafka producer reached the brokers again"))
+}
+
+func Test_exponentialBackoff(t *testing.T) {
+	backoff := exponentialBackoff(100*time.Millisecond, time.Second)
+	tests := map[int]time.Duration{
+		0:   100 * time.Millisecond,
+		1:   200 * time.Millisecond,
+		3:   800 * time.Millisecond,
+		4:   time.Second,
+		100: time.Second,
+	}
+	for retries, expected := range tests {
+		assert.Equal(t, expected, backoff(retries, 3))
+	}
+	assert.Equal(t, time.Second, exponentialBackoff(2*time.Second, time.Second)(0, 3))
+}
+
+func TestConnectionMonitor_CloseIsIdempotent(t *testing.T) {
+	m := newConnectionMonitor(func() error { return nil }, time.Hour, time.Millisecond, time.Millisecond)
+	m.start()
+	m.close()
+	m.close()
+}
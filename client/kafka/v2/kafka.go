@@ -1,12 +1,15 @@
 package v2
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Shopify/sarama"
 	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/internal/kafka/security"
 	"github.com/beatlabs/patron/internal/validation"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -18,12 +21,14 @@ type (
 const (
 	deliveryTypeSync  = "sync"
 	deliveryTypeAsync = "async"
+	deliveryTypeTxn   = "transactional"
 
 	deliveryStatusSent      deliveryStatus = "sent"
 	deliveryStatusSendError deliveryStatus = "send-errors"
 
 	componentTypeAsync = "kafka-async-producer"
 	componentTypeSync  = "kafka-sync-producer"
+	componentTypeTxn   = "kafka-transactional-producer"
 )
 
 var messageStatus *prometheus.CounterVec
@@ -45,8 +50,37 @@ func statusCountAdd(deliveryType string, status deliveryStatus, topic string, cn
 	messageStatus.WithLabelValues(string(status), topic, deliveryType).Add(float64(cnt))
 }
 
+// Interceptor is called with every message before it is sent, in order to mutate or observe it, e.g. to wrap the value
+// in an envelope, to encrypt it or to add the tenant of the context to the headers. An error aborts the sending of the message.
+type Interceptor func(ctx context.Context, msg *sarama.ProducerMessage) error
+
 type baseProducer struct {
-	prodClient sarama.Client
+	prodClient   sarama.Client
+	monitor      *connectionMonitor
+	interceptors []Interceptor
+}
+
+// intercept calls the interceptors in the order they were added, after the tracing and the propagated headers are injected,
+// so that the interceptors see the message as it is sent.
+func (p *baseProducer) intercept(ctx context.Context, msg *sarama.ProducerMessage) error {
+	for _, i := range p.interceptors {
+		if err := i(ctx, msg); err != nil {
+			return fmt.Errorf("failed to intercept message: %w", err)
+		}
+	}
+	return nil
+}
+
+// Connected returns whether the producer is currently connected to the Kafka brokers.
+// It can be used in a readiness check, in order to flip the service to not ready while the brokers are unreachable.
+func (p *baseProducer) Connected() bool {
+	return p.monitor.isConnected()
+}
+
+func (p *baseProducer) startMonitor(b *Builder) {
+	p.monitor = newConnectionMonitor(func() error { return p.prodClient.RefreshMetadata() },
+		b.checkInterval, b.minBackoff, b.maxBackoff)
+	p.monitor.start()
 }
 
 // ActiveBrokers returns a list of active brokers' addresses.
@@ -61,9 +95,13 @@ func (p *baseProducer) ActiveBrokers() []string {
 
 // Builder definition for creating sync and async producers.
 type Builder struct {
-	brokers []string
-	cfg     *sarama.Config
-	errs    []error
+	brokers       []string
+	cfg           *sarama.Config
+	checkInterval time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	interceptors  []Interceptor
+	errs          []error
 }
 
 // New initiates the AsyncProducer/SyncProducer builder chain with the specified Sarama configuration.
@@ -77,10 +115,142 @@ func New(brokers []string, saramaConfig *sarama.Config) *Builder {
 	}
 
 	return &Builder{
-		brokers: brokers,
-		errs:    ee,
-		cfg:     saramaConfig,
+		brokers:       brokers,
+		errs:          ee,
+		cfg:           saramaConfig,
+		checkInterval: defaultCheckInterval,
+		minBackoff:    defaultMinBackoff,
+		maxBackoff:    defaultMaxBackoff,
+	}
+}
+
+// WithConnectionCheckInterval sets the interval of the periodic check of the connection to the brokers.
+func (b *Builder) WithConnectionCheckInterval(interval time.Duration) *Builder {
+	if interval <= 0 {
+		b.errs = append(b.errs, errors.New("connection check interval must be positive"))
+		return b
+	}
+	b.checkInterval = interval
+	return b
+}
+
+// WithConnectionCheckBackoff sets the exponential backoff between the connection checks while the brokers are unreachable,
+// which starts from the min value and doubles on every failed check, up to the max value.
+// The max value also caps the exponential backoff of the retries of the metadata requests and of the messages.
+func (b *Builder) WithConnectionCheckBackoff(min, max time.Duration) *Builder {
+	if min <= 0 || max < min {
+		b.errs = append(b.errs, errors.New("connection check backoff must be positive and max must not be less than min"))
+		return b
+	}
+	b.minBackoff = min
+	b.maxBackoff = max
+	return b
+}
+
+// WithInterceptors adds interceptors which are called with every message sent by the producers, in the order they were added.
+func (b *Builder) WithInterceptors(ii ...Interceptor) *Builder {
+	for _, i := range ii {
+		if i == nil {
+			b.errs = append(b.errs, errors.New("interceptor is nil"))
+			return b
+		}
+	}
+	b.interceptors = append(b.interceptors, ii...)
+	return b
+}
+
+// WithSASL enables the SASL authentication with the brokers, using the mechanism, which is either sarama.SASLTypePlaintext,
+// sarama.SASLTypeSCRAMSHA256 or sarama.SASLTypeSCRAMSHA512, and the credentials of the user.
+func (b *Builder) WithSASL(mechanism sarama.SASLMechanism, user, password string) *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	if err := security.SASL(b.cfg, mechanism, user, password); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+// WithTLS enables TLS, verifying the certificates of the brokers with the CA, or else with the system roots if the CA file is empty.
+// The client certificate is presented to the brokers if the cert and key files are provided.
+func (b *Builder) WithTLS(caFile, certFile, keyFile string) *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	if err := security.TLS(b.cfg, caFile, certFile, keyFile); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+// WithTLSInsecureSkipVerify enables TLS without verifying the certificates of the brokers, which should only be used for testing.
+func (b *Builder) WithTLSInsecureSkipVerify() *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	security.TLSInsecureSkipVerify(b.cfg)
+	return b
+}
+
+// WithIdempotence enables the idempotent producer, so that the retries of the producer do not write duplicate messages,
+// which requires all the in-sync replicas to acknowledge the messages and at most one in-flight request per broker.
+// The idempotent producer requires Kafka 0.11.0.0 or later, which has to be set as the version of the Sarama configuration.
+func (b *Builder) WithIdempotence() *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	if !b.cfg.Version.IsAtLeast(sarama.V0_11_0_0) {
+		b.errs = append(b.errs, errors.New("idempotence requires Kafka version 0.11.0.0 or later"))
+		return b
+	}
+	b.cfg.Producer.Idempotent = true
+	b.cfg.Producer.RequiredAcks = sarama.WaitForAll
+	b.cfg.Net.MaxOpenRequests = 1
+	return b
+}
+
+// WithRequiredAcks sets the acknowledgements the brokers have to receive before responding to a produce request,
+// which is either sarama.NoResponse, sarama.WaitForLocal or sarama.WaitForAll.
+func (b *Builder) WithRequiredAcks(acks sarama.RequiredAcks) *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	switch acks {
+	case sarama.NoResponse, sarama.WaitForLocal, sarama.WaitForAll:
+		b.cfg.Producer.RequiredAcks = acks
+	default:
+		b.errs = append(b.errs, fmt.Errorf("invalid required acks %d", acks))
+	}
+	return b
+}
+
+// WithMaxInFlight sets the maximum number of requests sent to a broker before it responds,
+// which has to be 1 for the idempotent producer.
+func (b *Builder) WithMaxInFlight(requests int) *Builder {
+	if b.cfg == nil {
+		return b
 	}
+	if requests <= 0 {
+		b.errs = append(b.errs, errors.New("max in-flight requests must be positive"))
+		return b
+	}
+	b.cfg.Net.MaxOpenRequests = requests
+	return b
+}
+
+// WithCompression sets the codec which compresses the messages, which is either sarama.CompressionNone, sarama.CompressionGZIP,
+// sarama.CompressionSnappy, sarama.CompressionLZ4 or sarama.CompressionZSTD.
+// The LZ4 codec requires Kafka 0.10.0.0 or later, and the ZSTD codec Kafka 2.1.0.0 or later.
+func (b *Builder) WithCompression(codec sarama.CompressionCodec) *Builder {
+	if b.cfg == nil {
+		return b
+	}
+	if codec < sarama.CompressionNone || codec > sarama.CompressionZSTD {
+		b.errs = append(b.errs, fmt.Errorf("invalid compression codec %d", codec))
+		return b
+	}
+	b.cfg.Producer.Compression = codec
+	return b
 }
 
 // DefaultProducerSaramaConfig creates a default Sarama configuration with idempotency enabled.
@@ -105,6 +275,18 @@ func DefaultProducerSaramaConfig(name string, idempotent bool) (*sarama.Config,
 	return cfg, nil
 }
 
+// setRetryBackoff sets an exponential backoff to the retries of the metadata requests and of the messages which failed
+// to be produced, starting from their backoff of the Sarama configuration, so that the client dials the unreachable brokers
+// increasingly less often. The backoff functions which are already set in the configuration are kept.
+func (b *Builder) setRetryBackoff() {
+	if b.cfg.Metadata.Retry.BackoffFunc == nil {
+		b.cfg.Metadata.Retry.BackoffFunc = exponentialBackoff(b.cfg.Metadata.Retry.Backoff, b.maxBackoff)
+	}
+	if b.cfg.Producer.Retry.BackoffFunc == nil {
+		b.cfg.Producer.Retry.BackoffFunc = exponentialBackoff(b.cfg.Producer.Retry.Backoff, b.maxBackoff)
+	}
+}
+
 // Create a new synchronous producer.
 func (b *Builder) Create() (*SyncProducer, error) {
 	if len(b.errs) > 0 {
@@ -113,8 +295,9 @@ func (b *Builder) Create() (*SyncProducer, error) {
 
 	// required for any SyncProducer; 'Errors' is already true by default for both async/sync producers
 	b.cfg.Producer.Return.Successes = true
+	b.setRetryBackoff()
 
-	p := SyncProducer{}
+	p := SyncProducer{baseProducer: baseProducer{interceptors: b.interceptors}}
 
 	var err error
 	p.prodClient, err = sarama.NewClient(b.brokers, b.cfg)
@@ -126,6 +309,7 @@ func (b *Builder) Create() (*SyncProducer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sync producer: %w", err)
 	}
+	p.startMonitor(b)
 
 	return &p, nil
 }
@@ -137,9 +321,10 @@ func (b Builder) CreateAsync() (*AsyncProducer, <-chan error, error) {
 	}
 
 	ap := &AsyncProducer{
-		baseProducer: baseProducer{},
+		baseProducer: baseProducer{interceptors: b.interceptors},
 		asyncProd:    nil,
 	}
+	b.setRetryBackoff()
 
 	var err error
 	ap.prodClient, err = sarama.NewClient(b.brokers, b.cfg)
@@ -151,6 +336,7 @@ func (b Builder) CreateAsync() (*AsyncProducer, <-chan error, error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create async producer: %w", err)
 	}
+	ap.startMonitor(&b)
 	chErr := make(chan error)
 	go ap.propagateError(chErr)
 
@@ -160,6 +346,22 @@ func (b Builder) CreateAsync() (*AsyncProducer, <-chan error, error) {
 type kafkaHeadersCarrier []sarama.RecordHeader
 
 // Set implements Set() of opentracing.TextMapWriter.
+// The value of a header which is already set is replaced, e.g. the tracing headers of a consumed message which is produced again.
 func (c *kafkaHeadersCarrier) Set(key, val string) {
+	for i, h := range *c {
+		if string(h.Key) == key {
+			(*c)[i].Value = []byte(val)
+			return
+		}
+	}
 	*c = append(*c, sarama.RecordHeader{Key: []byte(key), Value: []byte(val)})
 }
+
+func (c *kafkaHeadersCarrier) has(key string) bool {
+	for _, h := range *c {
+		if string(h.Key) == key {
+			return len(h.Value) > 0
+		}
+	}
+	return false
+}
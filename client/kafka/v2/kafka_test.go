@@ -1,11 +1,17 @@
 package v2
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/require"
 )
 
@@ -54,6 +60,115 @@ func TestBuilder_CreateAsync(t *testing.T) {
 	}
 }
 
+func TestBuilder_ConnectionOptions(t *testing.T) {
+	type args struct {
+		checkInterval time.Duration
+		minBackoff    time.Duration
+		maxBackoff    time.Duration
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":                   {args: args{checkInterval: time.Second, minBackoff: time.Second, maxBackoff: time.Minute}},
+		"invalid check interval":    {args: args{checkInterval: 0, minBackoff: time.Second, maxBackoff: time.Minute}, expectedErr: "connection check interval must be positive"},
+		"invalid min backoff":       {args: args{checkInterval: time.Second, minBackoff: 0, maxBackoff: time.Minute}, expectedErr: "connection check backoff must be positive and max must not be less than min"},
+		"max backoff less than min": {args: args{checkInterval: time.Second, minBackoff: time.Minute, maxBackoff: time.Second}, expectedErr: "connection check backoff must be positive and max must not be less than min"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := New([]string{"123"}, sarama.NewConfig()).
+				WithConnectionCheckInterval(tt.args.checkInterval).
+				WithConnectionCheckBackoff(tt.args.minBackoff, tt.args.maxBackoff)
+
+			if tt.expectedErr != "" {
+				require.Len(t, b.errs, 1)
+				require.EqualError(t, b.errs[0], tt.expectedErr)
+				return
+			}
+			require.Empty(t, b.errs)
+			require.Equal(t, tt.args.checkInterval, b.checkInterval)
+			require.Equal(t, tt.args.minBackoff, b.minBackoff)
+			require.Equal(t, tt.args.maxBackoff, b.maxBackoff)
+		})
+	}
+}
+
+func TestBuilder_setRetryBackoff(t *testing.T) {
+	cfg := sarama.NewConfig()
+	custom := func(int, int) time.Duration { return time.Hour }
+	cfg.Producer.Retry.BackoffFunc = custom
+	b := New([]string{"123"}, cfg).WithConnectionCheckBackoff(time.Millisecond, time.Second)
+	b.setRetryBackoff()
+
+	require.NotNil(t, cfg.Metadata.Retry.BackoffFunc)
+	require.Equal(t, cfg.Metadata.Retry.Backoff, cfg.Metadata.Retry.BackoffFunc(0, 3))
+	require.Equal(t, 2*cfg.Metadata.Retry.Backoff, cfg.Metadata.Retry.BackoffFunc(1, 3))
+	require.Equal(t, time.Second, cfg.Metadata.Retry.BackoffFunc(10, 3))
+	require.Equal(t, time.Hour, cfg.Producer.Retry.BackoffFunc(0, 3), "the backoff function of the configuration is kept")
+}
+
+func TestBuilder_SecurityOptions(t *testing.T) {
+	cfg := sarama.NewConfig()
+	b := New([]string{"123"}, cfg).
+		WithSASL(sarama.SASLTypeSCRAMSHA512, "user", "pass").
+		WithTLS("", "", "").
+		WithTLSInsecureSkipVerify()
+	require.Empty(t, b.errs)
+	require.True(t, cfg.Net.SASL.Enable)
+	require.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), cfg.Net.SASL.Mechanism)
+	require.NotNil(t, cfg.Net.SASL.SCRAMClientGeneratorFunc)
+	require.True(t, cfg.Net.TLS.Enable)
+	require.True(t, cfg.Net.TLS.Config.InsecureSkipVerify)
+
+	b = New([]string{"123"}, sarama.NewConfig()).WithSASL(sarama.SASLTypeOAuth, "user", "pass").WithTLS("", "cert.pem", "")
+	require.Len(t, b.errs, 2)
+	require.EqualError(t, b.errs[0], "unsupported SASL mechanism OAUTHBEARER")
+	require.EqualError(t, b.errs[1], "TLS client certificate requires both a cert and a key file")
+
+	b = New([]string{"123"}, nil).WithSASL(sarama.SASLTypePlaintext, "user", "pass").WithTLSInsecureSkipVerify()
+	require.Len(t, b.errs, 1, "the options are ignored without a Sarama configuration")
+}
+
+func TestBuilder_ProducerOptions(t *testing.T) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_1_0_0
+	b := New([]string{"123"}, cfg).
+		WithRequiredAcks(sarama.WaitForLocal).
+		WithMaxInFlight(5).
+		WithIdempotence().
+		WithCompression(sarama.CompressionZSTD)
+	require.Empty(t, b.errs)
+	require.True(t, cfg.Producer.Idempotent)
+	require.Equal(t, sarama.WaitForAll, cfg.Producer.RequiredAcks)
+	require.Equal(t, 1, cfg.Net.MaxOpenRequests)
+	require.Equal(t, sarama.CompressionZSTD, cfg.Producer.Compression)
+
+	cfg = sarama.NewConfig()
+	cfg.Version = sarama.V0_10_2_0
+	b = New([]string{"123"}, cfg).
+		WithIdempotence().
+		WithRequiredAcks(sarama.RequiredAcks(2)).
+		WithMaxInFlight(0).
+		WithCompression(sarama.CompressionCodec(10))
+	require.Len(t, b.errs, 4)
+	require.EqualError(t, b.errs[0], "idempotence requires Kafka version 0.11.0.0 or later")
+	require.EqualError(t, b.errs[1], "invalid required acks 2")
+	require.EqualError(t, b.errs[2], "max in-flight requests must be positive")
+	require.EqualError(t, b.errs[3], "invalid compression codec 10")
+
+	b = New([]string{"123"}, nil).WithIdempotence().WithRequiredAcks(sarama.WaitForAll).WithMaxInFlight(1).WithCompression(sarama.CompressionGZIP)
+	require.Len(t, b.errs, 1, "the options are ignored without a Sarama configuration")
+}
+
+func TestBuilder_Create_IdempotenceConflict(t *testing.T) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	got, err := New([]string{"123"}, cfg).WithIdempotence().WithMaxInFlight(5).Create()
+	require.EqualError(t, err, "failed to create producer client: kafka: invalid configuration (Idempotent producer requires Net.MaxOpenRequests to be 1)")
+	require.Nil(t, got)
+}
+
 func TestDefaultProducerSaramaConfig(t *testing.T) {
 	sc, err := DefaultProducerSaramaConfig("name", true)
 	require.NoError(t, err)
@@ -64,3 +179,132 @@ func TestDefaultProducerSaramaConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, sc.Producer.Idempotent)
 }
+
+func Test_injectPropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+	ctx := correlation.ContextWithHeaders(context.Background(), func(string) string { return "tenant" })
+
+	msg := &sarama.ProducerMessage{Topic: "topic"}
+	injectPropagatedHeaders(ctx, msg)
+	require.Equal(t, []sarama.RecordHeader{{Key: []byte("X-Tenant-Id"), Value: []byte("tenant")}}, msg.Headers)
+	injectPropagatedHeaders(ctx, msg)
+	require.Len(t, msg.Headers, 1)
+}
+
+func Test_injectTracingHeaders(t *testing.T) {
+	mtr := mocktracer.New()
+	sp := mtr.StartSpan("producer")
+	ctx := correlation.ContextWithID(context.Background(), "corID")
+
+	msg := &sarama.ProducerMessage{Topic: "topic"}
+	require.NoError(t, injectTracingHeaders(ctx, msg, sp))
+	require.NoError(t, injectTracingHeaders(ctx, msg, sp))
+	hh := map[string]string{}
+	for _, h := range msg.Headers {
+		hh[string(h.Key)] = string(h.Value)
+	}
+	require.Len(t, msg.Headers, len(hh), "the headers are replaced when injected again")
+	require.Equal(t, "corID", hh[correlation.HeaderID])
+
+	spCtx, err := mtr.Extract(opentracing.TextMap, opentracing.TextMapCarrier(hh))
+	require.NoError(t, err)
+	require.Equal(t, sp.Context().(mocktracer.MockSpanContext).SpanID, spCtx.(mocktracer.MockSpanContext).SpanID)
+
+	msg = &sarama.ProducerMessage{Topic: "topic", Headers: []sarama.RecordHeader{{Key: []byte(correlation.HeaderID), Value: []byte("existing")}}}
+	require.NoError(t, injectTracingHeaders(ctx, msg, sp))
+	require.Equal(t, "existing", string(msg.Headers[0].Value), "the correlation ID of the message is kept")
+}
+
+type tenantKey struct{}
+
+func TestBuilder_WithInterceptors(t *testing.T) {
+	noop := func(context.Context, *sarama.ProducerMessage) error { return nil }
+	b := New([]string{"123"}, sarama.NewConfig()).WithInterceptors(noop).WithInterceptors(noop, noop)
+	require.Empty(t, b.errs)
+	require.Len(t, b.interceptors, 3)
+
+	b = New([]string{"123"}, sarama.NewConfig()).WithInterceptors(noop, nil)
+	require.Len(t, b.errs, 1)
+	require.EqualError(t, b.errs[0], "interceptor is nil")
+	require.Empty(t, b.interceptors)
+}
+
+func TestProducers_Interceptors(t *testing.T) {
+	const topic = "topic"
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	var calls []string
+	tenant := func(ctx context.Context, msg *sarama.ProducerMessage) error {
+		calls = append(calls, "tenant")
+		tenant, ok := ctx.Value(tenantKey{}).(string)
+		if !ok {
+			return errors.New("tenant is missing")
+		}
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte("tenant"), Value: []byte(tenant)})
+		return nil
+	}
+	envelope := func(_ context.Context, msg *sarama.ProducerMessage) error {
+		calls = append(calls, "envelope")
+		value, err := msg.Value.Encode()
+		if err != nil {
+			return err
+		}
+		msg.Value = sarama.StringEncoder("envelope(" + string(value) + ")")
+		return nil
+	}
+
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_11_0_0
+	builder := New([]string{broker.Addr()}, cfg).WithInterceptors(tenant, envelope)
+	syncProducer, err := builder.Create()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, syncProducer.Close()) }()
+	asyncProducer, chErr, err := builder.CreateAsync()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, asyncProducer.Close()) }()
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	hasTenant := func(msg *sarama.ProducerMessage) bool {
+		for _, h := range msg.Headers {
+			if string(h.Key) == "tenant" {
+				return string(h.Value) == "acme"
+			}
+		}
+		return false
+	}
+
+	msg := &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("value")}
+	_, _, err = syncProducer.Send(ctx, msg)
+	require.NoError(t, err)
+	require.Equal(t, sarama.StringEncoder("envelope(value)"), msg.Value)
+	require.True(t, hasTenant(msg))
+	require.Equal(t, []string{"tenant", "envelope"}, calls)
+
+	msg = &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("value")}
+	require.NoError(t, syncProducer.SendBatch(ctx, []*sarama.ProducerMessage{msg}))
+	require.Equal(t, sarama.StringEncoder("envelope(value)"), msg.Value)
+
+	msg = &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("value")}
+	require.NoError(t, asyncProducer.Send(ctx, msg))
+	require.True(t, hasTenant(msg))
+	select {
+	case err := <-chErr:
+		require.NoError(t, err)
+	default:
+	}
+
+	calls = nil
+	_, _, err = syncProducer.Send(context.Background(), &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("value")})
+	require.EqualError(t, err, "failed to intercept message: tenant is missing")
+	require.Equal(t, []string{"tenant"}, calls, "the interceptors after a failed one are not called")
+	err = asyncProducer.Send(context.Background(), &sarama.ProducerMessage{Topic: topic, Value: sarama.StringEncoder("value")})
+	require.EqualError(t, err, "failed to intercept message: tenant is missing")
+}
@@ -27,16 +27,23 @@ func (p *SyncProducer) Send(ctx context.Context, msg *sarama.ProducerMessage) (p
 	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(componentTypeSync, msg.Topic), componentTypeSync,
 		ext.SpanKindProducer, syncTag, opentracing.Tag{Key: "topic", Value: msg.Topic})
 
-	err = injectTracingHeaders(msg, sp)
+	err = injectTracingHeaders(ctx, msg, sp)
 	if err != nil {
 		statusCountAdd(deliveryTypeSync, deliveryStatusSendError, msg.Topic, 1)
 		trace.SpanError(sp)
 		return -1, -1, fmt.Errorf("failed to inject tracing headers: %w", err)
 	}
+	injectPropagatedHeaders(ctx, msg)
+	if err = p.intercept(ctx, msg); err != nil {
+		statusCountAdd(deliveryTypeSync, deliveryStatusSendError, msg.Topic, 1)
+		trace.SpanError(sp)
+		return -1, -1, err
+	}
 
 	partition, offset, err = p.syncProd.SendMessage(msg)
 	if err != nil {
 		statusCountAdd(deliveryTypeSync, deliveryStatusSendError, msg.Topic, 1)
+		p.monitor.check()
 		trace.SpanError(sp)
 		return -1, -1, err
 	}
@@ -56,15 +63,22 @@ func (p *SyncProducer) SendBatch(ctx context.Context, messages []*sarama.Produce
 		ext.SpanKindProducer, syncTag, opentracing.Tag{Key: "topic", Value: batchTarget})
 
 	for _, msg := range messages {
-		if err := injectTracingHeaders(msg, sp); err != nil {
+		if err := injectTracingHeaders(ctx, msg, sp); err != nil {
 			statusCountAdd(deliveryTypeSync, deliveryStatusSendError, msg.Topic, len(messages))
 			trace.SpanError(sp)
 			return fmt.Errorf("failed to inject tracing headers: %w", err)
 		}
+		injectPropagatedHeaders(ctx, msg)
+		if err := p.intercept(ctx, msg); err != nil {
+			statusCountAdd(deliveryTypeSync, deliveryStatusSendError, msg.Topic, len(messages))
+			trace.SpanError(sp)
+			return err
+		}
 	}
 
 	if err := p.syncProd.SendMessages(messages); err != nil {
 		statusCountBatchAdd(deliveryTypeSync, deliveryStatusSendError, messages)
+		p.monitor.check()
 		trace.SpanError(sp)
 		return err
 	}
@@ -78,6 +92,7 @@ func (p *SyncProducer) SendBatch(ctx context.Context, messages []*sarama.Produce
 // flushed. You must call this function before a producer object passes out of
 // scope, as it may otherwise leak memory.
 func (p *SyncProducer) Close() error {
+	p.monitor.close()
 	if err := p.syncProd.Close(); err != nil {
 		return patronerrors.Aggregate(fmt.Errorf("failed to close sync producer client: %w", err), p.prodClient.Close())
 	}
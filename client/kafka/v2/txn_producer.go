@@ -0,0 +1,676 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// defaultTransactionTimeout is the time after which the coordinator aborts a transaction which was not completed.
+const defaultTransactionTimeout = time.Minute
+
+var txnTag = opentracing.Tag{Key: "type", Value: deliveryTypeTxn}
+
+var (
+	// ErrNoTransaction is returned when a transactional operation is called without an ongoing transaction.
+	ErrNoTransaction = errors.New("no transaction in progress")
+	// ErrTransactionInProgress is returned when a transaction is begun while another one is in progress.
+	ErrTransactionInProgress = errors.New("transaction already in progress")
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// partitionBatch is the batch of the messages of a partition, which are sent with a single record batch.
+type partitionBatch struct {
+	messages []*sarama.ProducerMessage
+	records  *sarama.RecordBatch
+}
+
+// TransactionalProducer is a Kafka producer which sends messages and consumer offsets in transactions,
+// so that they are either all visible to consumers with the read committed isolation level, or none of them.
+// It is not safe to run more than one transaction at a time with the same producer.
+type TransactionalProducer struct {
+	baseProducer
+	cfg             *sarama.Config
+	transactionalID string
+	coordinator     *sarama.Broker
+	producerID      int64
+	producerEpoch   int16
+
+	mu           sync.Mutex
+	partitioners map[string]sarama.Partitioner
+	sequences    map[topicPartition]int32
+	inTxn        bool
+	// partitions added to the transaction
+	partitions map[topicPartition]struct{}
+	hasOffsets bool
+}
+
+// CreateTransactional creates a new transactional producer with the transactional ID, which identifies the producer
+// across restarts, so that the transactions of a previous instance of the producer are fenced off.
+// Transactions require Kafka 0.11.0.0 or later, which has to be set as the version of the Sarama configuration.
+func (b *Builder) CreateTransactional(transactionalID string) (*TransactionalProducer, error) {
+	errs := append([]error{}, b.errs...)
+	if transactionalID == "" {
+		errs = append(errs, errors.New("transactional ID is empty"))
+	}
+	if b.cfg != nil && !b.cfg.Version.IsAtLeast(sarama.V0_11_0_0) {
+		errs = append(errs, errors.New("transactions require Kafka version 0.11.0.0 or later"))
+	}
+	if len(errs) > 0 {
+		return nil, patronerrors.Aggregate(errs...)
+	}
+
+	p := &TransactionalProducer{
+		baseProducer:    baseProducer{interceptors: b.interceptors},
+		cfg:             b.cfg,
+		transactionalID: transactionalID,
+		partitioners:    make(map[string]sarama.Partitioner),
+		sequences:       make(map[topicPartition]int32),
+	}
+	b.setRetryBackoff()
+
+	var err error
+	p.prodClient, err = sarama.NewClient(b.brokers, b.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer client: %w", err)
+	}
+
+	if err := p.initProducerID(); err != nil {
+		return nil, patronerrors.Aggregate(err, p.prodClient.Close())
+	}
+	p.startMonitor(b)
+
+	return p, nil
+}
+
+// initProducerID finds the transaction coordinator and gets the producer ID and epoch of the transactional ID.
+func (p *TransactionalProducer) initProducerID() error {
+	err := p.coordinate(context.Background(), func(coordinator *sarama.Broker) error {
+		rsp, err := coordinator.InitProducerID(&sarama.InitProducerIDRequest{
+			TransactionalID:    &p.transactionalID,
+			TransactionTimeout: defaultTransactionTimeout,
+		})
+		if err != nil {
+			return err
+		}
+		if rsp.Err != sarama.ErrNoError {
+			return rsp.Err
+		}
+		p.producerID = rsp.ProducerID
+		p.producerEpoch = rsp.ProducerEpoch
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize producer ID: %w", err)
+	}
+	return nil
+}
+
+// findCoordinator finds the transaction coordinator of the transactional ID through any of the brokers of the client.
+func (p *TransactionalProducer) findCoordinator() error {
+	var err error
+	for _, broker := range p.prodClient.Brokers() {
+		_ = broker.Open(p.cfg)
+		var rsp *sarama.FindCoordinatorResponse
+		rsp, err = broker.FindCoordinator(&sarama.FindCoordinatorRequest{
+			Version:         1,
+			CoordinatorKey:  p.transactionalID,
+			CoordinatorType: sarama.CoordinatorTransaction,
+		})
+		if err != nil {
+			continue
+		}
+		if rsp.Err != sarama.ErrNoError {
+			return fmt.Errorf("failed to find transaction coordinator: %w", rsp.Err)
+		}
+		p.coordinator = rsp.Coordinator
+		_ = p.coordinator.Open(p.cfg)
+		return nil
+	}
+	if err == nil {
+		err = errors.New("no brokers available")
+	}
+	return fmt.Errorf("failed to find transaction coordinator: %w", err)
+}
+
+// resetCoordinator closes the connection to the transaction coordinator, so that it is found again by the next request.
+func (p *TransactionalProducer) resetCoordinator() {
+	if p.coordinator == nil {
+		return
+	}
+	_ = p.coordinator.Close()
+	p.coordinator = nil
+}
+
+// coordinate sends a request to the transaction coordinator, which is found again when it moved or the connection to it failed,
+// retrying the retriable errors, e.g. while the coordinator is loading or the previous transaction is completing,
+// according to the retries of the producer configuration.
+func (p *TransactionalProducer) coordinate(ctx context.Context, request func(coordinator *sarama.Broker) error) error {
+	var err error
+	for attempt := 0; attempt <= p.cfg.Producer.Retry.Max; attempt++ {
+		if attempt > 0 {
+			if err := p.backoff(ctx); err != nil {
+				return err
+			}
+		}
+		if p.coordinator == nil {
+			if err = p.findCoordinator(); err != nil {
+				continue
+			}
+		}
+		err = request(p.coordinator)
+		var kerr sarama.KError
+		switch {
+		case err == nil:
+			return nil
+		case !errors.As(err, &kerr), kerr == sarama.ErrNotCoordinatorForConsumer, kerr == sarama.ErrConsumerCoordinatorNotAvailable:
+			// the connection failed or the coordinator moved
+			p.resetCoordinator()
+		case kerr == sarama.ErrOffsetsLoadInProgress, kerr == sarama.ErrConcurrentTransactions, kerr == sarama.ErrRequestTimedOut:
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+// backoff waits for the backoff of the retries of the producer configuration, unless the context is done in the meantime.
+func (p *TransactionalProducer) backoff(ctx context.Context) error {
+	timer := time.NewTimer(p.cfg.Producer.Retry.Backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// BeginTxn begins a transaction. The messages and offsets are sent in it until it is either committed or aborted.
+func (p *TransactionalProducer) BeginTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inTxn {
+		return ErrTransactionInProgress
+	}
+	p.inTxn = true
+	p.partitions = make(map[topicPartition]struct{})
+	p.hasOffsets = false
+	return nil
+}
+
+// Send a message to a topic in the transaction.
+func (p *TransactionalProducer) Send(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(componentTypeTxn, msg.Topic), componentTypeTxn,
+		ext.SpanKindProducer, txnTag, opentracing.Tag{Key: "topic", Value: msg.Topic})
+
+	if err := p.send(ctx, []*sarama.ProducerMessage{msg}, sp); err != nil {
+		statusCountAdd(deliveryTypeTxn, deliveryStatusSendError, msg.Topic, 1)
+		trace.SpanError(sp)
+		return -1, -1, err
+	}
+
+	statusCountAdd(deliveryTypeTxn, deliveryStatusSent, msg.Topic, 1)
+	trace.SpanSuccess(sp)
+	return msg.Partition, msg.Offset, nil
+}
+
+// SendBatch sends a batch of messages in the transaction, with a single request to the leader of their partitions.
+// The partitions and offsets of the messages are set once they are sent.
+func (p *TransactionalProducer) SendBatch(ctx context.Context, messages []*sarama.ProducerMessage) error {
+	if len(messages) == 0 {
+		return errors.New("messages are empty or nil")
+	}
+
+	sp, _ := trace.ChildSpan(ctx, trace.ComponentOpName(componentTypeTxn, batchTarget), componentTypeTxn,
+		ext.SpanKindProducer, txnTag, opentracing.Tag{Key: "topic", Value: batchTarget})
+
+	if err := p.send(ctx, messages, sp); err != nil {
+		statusCountBatchAdd(deliveryTypeTxn, deliveryStatusSendError, messages)
+		trace.SpanError(sp)
+		return err
+	}
+
+	statusCountBatchAdd(deliveryTypeTxn, deliveryStatusSent, messages)
+	trace.SpanSuccess(sp)
+	return nil
+}
+
+func (p *TransactionalProducer) send(ctx context.Context, messages []*sarama.ProducerMessage, sp opentracing.Span) error {
+	for _, msg := range messages {
+		if err := injectTracingHeaders(ctx, msg, sp); err != nil {
+			return fmt.Errorf("failed to inject tracing headers: %w", err)
+		}
+		injectPropagatedHeaders(ctx, msg)
+		if err := p.intercept(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return ErrNoTransaction
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	batches := make(map[topicPartition]*partitionBatch)
+	for _, msg := range messages {
+		tp, err := p.partition(msg)
+		if err != nil {
+			return err
+		}
+		msg.Partition = tp.partition
+		batch, ok := batches[tp]
+		if !ok {
+			batch = &partitionBatch{}
+			batches[tp] = batch
+		}
+		batch.messages = append(batch.messages, msg)
+	}
+	for tp, batch := range batches {
+		var err error
+		if batch.records, err = p.recordBatch(tp, batch.messages); err != nil {
+			return err
+		}
+	}
+	if err := p.addPartitions(ctx, batches); err != nil {
+		return err
+	}
+	return p.produce(ctx, batches)
+}
+
+// partition returns the topic partition of the message, using the partitioner of the Sarama configuration.
+func (p *TransactionalProducer) partition(msg *sarama.ProducerMessage) (topicPartition, error) {
+	tp := topicPartition{topic: msg.Topic}
+	partitioner, ok := p.partitioners[msg.Topic]
+	if !ok {
+		partitioner = p.cfg.Producer.Partitioner(msg.Topic)
+		p.partitioners[msg.Topic] = partitioner
+	}
+	partitions, err := p.prodClient.Partitions(msg.Topic)
+	if err != nil {
+		return tp, fmt.Errorf("failed to get partitions of %s: %w", msg.Topic, err)
+	}
+	if len(partitions) == 0 {
+		return tp, fmt.Errorf("no partitions found for %s", msg.Topic)
+	}
+	idx, err := partitioner.Partition(msg, int32(len(partitions)))
+	if err != nil {
+		return tp, fmt.Errorf("failed to partition message: %w", err)
+	}
+	if idx < 0 || idx >= int32(len(partitions)) {
+		return tp, sarama.ErrInvalidPartition
+	}
+	tp.partition = partitions[idx]
+	return tp, nil
+}
+
+// addPartitions adds the partitions which were not added before to the transaction, with a single request.
+func (p *TransactionalProducer) addPartitions(ctx context.Context, batches map[topicPartition]*partitionBatch) error {
+	added := make(map[string][]int32)
+	for tp := range batches {
+		if _, ok := p.partitions[tp]; !ok {
+			added[tp.topic] = append(added[tp.topic], tp.partition)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	err := p.coordinate(ctx, func(coordinator *sarama.Broker) error {
+		rsp, err := coordinator.AddPartitionsToTxn(&sarama.AddPartitionsToTxnRequest{
+			TransactionalID: p.transactionalID,
+			ProducerID:      p.producerID,
+			ProducerEpoch:   p.producerEpoch,
+			TopicPartitions: added,
+		})
+		if err != nil {
+			return err
+		}
+		for _, pp := range rsp.Errors {
+			for _, pe := range pp {
+				if pe.Err != sarama.ErrNoError {
+					return pe.Err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add partitions to transaction: %w", err)
+	}
+	for topic, partitions := range added {
+		for _, partition := range partitions {
+			p.partitions[topicPartition{topic: topic, partition: partition}] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// produce sends the batches of the partitions with a single request to each leader. The batches of the partitions
+// whose leader moved or was not available are sent again, after the metadata is refreshed, with the same sequence numbers,
+// so that the brokers discard the messages which were already written.
+func (p *TransactionalProducer) produce(ctx context.Context, batches map[topicPartition]*partitionBatch) error {
+	var err error
+	for attempt := 0; attempt <= p.cfg.Producer.Retry.Max; attempt++ {
+		if attempt > 0 {
+			if err := p.backoff(ctx); err != nil {
+				return err
+			}
+			topics := make([]string, 0, len(batches))
+			for tp := range batches {
+				topics = append(topics, tp.topic)
+			}
+			_ = p.prodClient.RefreshMetadata(topics...)
+		}
+		if err = p.produceBatches(batches); err == nil {
+			return nil
+		}
+		if !retriableProduceError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// produceBatches sends the batches to the leaders of their partitions, removing the batches which were written.
+func (p *TransactionalProducer) produceBatches(batches map[topicPartition]*partitionBatch) error {
+	requests := make(map[*sarama.Broker]*sarama.ProduceRequest)
+	partitions := make(map[*sarama.Broker][]topicPartition)
+	for tp, batch := range batches {
+		leader, err := p.prodClient.Leader(tp.topic, tp.partition)
+		if err != nil {
+			p.monitor.check()
+			return fmt.Errorf("failed to get leader of %s/%d: %w", tp.topic, tp.partition, err)
+		}
+		req, ok := requests[leader]
+		if !ok {
+			req = &sarama.ProduceRequest{
+				TransactionalID: &p.transactionalID,
+				RequiredAcks:    sarama.WaitForAll,
+				Timeout:         int32(p.cfg.Producer.Timeout / time.Millisecond),
+				Version:         3,
+			}
+			requests[leader] = req
+		}
+		req.AddBatch(tp.topic, tp.partition, batch.records)
+		partitions[leader] = append(partitions[leader], tp)
+	}
+
+	var errs []error
+	for leader, req := range requests {
+		rsp, err := leader.Produce(req)
+		if err != nil {
+			p.monitor.check()
+			errs = append(errs, fmt.Errorf("failed to produce messages: %w", err))
+			continue
+		}
+		for _, tp := range partitions[leader] {
+			block := rsp.GetBlock(tp.topic, tp.partition)
+			if block == nil {
+				errs = append(errs, fmt.Errorf("failed to produce messages to %s/%d: no response for the partition", tp.topic, tp.partition))
+				continue
+			}
+			// the messages were written by a previous attempt whose response was lost
+			if block.Err != sarama.ErrNoError && block.Err != sarama.ErrDuplicateSequenceNumber {
+				errs = append(errs, fmt.Errorf("failed to produce messages to %s/%d: %w", tp.topic, tp.partition, block.Err))
+				continue
+			}
+			batch := batches[tp]
+			for i, msg := range batch.messages {
+				msg.Offset = block.Offset + int64(i)
+			}
+			p.sequences[tp] += int32(len(batch.messages))
+			delete(batches, tp)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return patronerrors.Aggregate(errs...)
+}
+
+// retriableProduceError returns true if the messages can be produced again after the metadata is refreshed,
+// which is the case when the connection to the leader failed or the leader moved.
+func retriableProduceError(err error) bool {
+	var kerr sarama.KError
+	if !errors.As(err, &kerr) {
+		return true
+	}
+	switch kerr {
+	case sarama.ErrNotLeaderForPartition, sarama.ErrLeaderNotAvailable, sarama.ErrRequestTimedOut,
+		sarama.ErrNotEnoughReplicas, sarama.ErrNotEnoughReplicasAfterAppend:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordBatch returns the transactional record batch of the messages of the partition, starting from its next sequence number.
+func (p *TransactionalProducer) recordBatch(tp topicPartition, msgs []*sarama.ProducerMessage) (*sarama.RecordBatch, error) {
+	batch := &sarama.RecordBatch{
+		Version:         2,
+		ProducerID:      p.producerID,
+		ProducerEpoch:   p.producerEpoch,
+		FirstSequence:   p.sequences[tp],
+		IsTransactional: true,
+		LastOffsetDelta: int32(len(msgs) - 1),
+	}
+	for i, msg := range msgs {
+		rec := &sarama.Record{OffsetDelta: int64(i)}
+		var err error
+		if msg.Key != nil {
+			if rec.Key, err = msg.Key.Encode(); err != nil {
+				return nil, fmt.Errorf("failed to encode key: %w", err)
+			}
+		}
+		if msg.Value != nil {
+			if rec.Value, err = msg.Value.Encode(); err != nil {
+				return nil, fmt.Errorf("failed to encode value: %w", err)
+			}
+		}
+		for i := range msg.Headers {
+			rec.Headers = append(rec.Headers, &msg.Headers[i])
+		}
+
+		timestamp := msg.Timestamp
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		timestamp = timestamp.Truncate(time.Millisecond)
+		if i == 0 {
+			batch.FirstTimestamp = timestamp
+		}
+		rec.TimestampDelta = timestamp.Sub(batch.FirstTimestamp)
+		if timestamp.After(batch.MaxTimestamp) {
+			batch.MaxTimestamp = timestamp
+		}
+		batch.Records = append(batch.Records, rec)
+	}
+	return batch, nil
+}
+
+// SendOffsets sends the offsets of the consumed messages to the transaction, so that they are committed for the
+// consumer group along with the messages of the transaction, which makes the consume-transform-produce loop exactly once.
+// The committed offset of each partition is the one after the last consumed message.
+func (p *TransactionalProducer) SendOffsets(groupID string, messages ...*sarama.ConsumerMessage) error {
+	if groupID == "" {
+		return errors.New("consumer group is empty")
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return ErrNoTransaction
+	}
+
+	err := p.coordinate(context.Background(), func(coordinator *sarama.Broker) error {
+		rsp, err := coordinator.AddOffsetsToTxn(&sarama.AddOffsetsToTxnRequest{
+			TransactionalID: p.transactionalID,
+			ProducerID:      p.producerID,
+			ProducerEpoch:   p.producerEpoch,
+			GroupID:         groupID,
+		})
+		if err != nil {
+			return err
+		}
+		if rsp.Err != sarama.ErrNoError {
+			return rsp.Err
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add offsets to transaction: %w", err)
+	}
+	p.hasOffsets = true
+
+	offsets := make(map[topicPartition]int64)
+	for _, msg := range messages {
+		tp := topicPartition{topic: msg.Topic, partition: msg.Partition}
+		if offset, ok := offsets[tp]; !ok || msg.Offset+1 > offset {
+			offsets[tp] = msg.Offset + 1
+		}
+	}
+	topics := make(map[string][]*sarama.PartitionOffsetMetadata)
+	for tp, offset := range offsets {
+		topics[tp.topic] = append(topics[tp.topic], &sarama.PartitionOffsetMetadata{Partition: tp.partition, Offset: offset})
+	}
+
+	if err := p.commitOffsets(groupID, topics); err != nil {
+		return fmt.Errorf("failed to commit offsets to transaction: %w", err)
+	}
+	return nil
+}
+
+// commitOffsets commits the offsets to the coordinator of the consumer group, which is refreshed when it moved,
+// retrying the retriable errors according to the retries of the producer configuration.
+func (p *TransactionalProducer) commitOffsets(groupID string, topics map[string][]*sarama.PartitionOffsetMetadata) error {
+	var err error
+	for attempt := 0; attempt <= p.cfg.Producer.Retry.Max; attempt++ {
+		if attempt > 0 {
+			if err := p.backoff(context.Background()); err != nil {
+				return err
+			}
+		}
+		var coordinator *sarama.Broker
+		coordinator, err = p.prodClient.Coordinator(groupID)
+		if err != nil {
+			continue
+		}
+		var rsp *sarama.TxnOffsetCommitResponse
+		rsp, err = coordinator.TxnOffsetCommit(&sarama.TxnOffsetCommitRequest{
+			TransactionalID: p.transactionalID,
+			GroupID:         groupID,
+			ProducerID:      p.producerID,
+			ProducerEpoch:   p.producerEpoch,
+			Topics:          topics,
+		})
+		if err == nil {
+			err = txnOffsetCommitError(rsp)
+		}
+		var kerr sarama.KError
+		switch {
+		case err == nil:
+			return nil
+		case !errors.As(err, &kerr), kerr == sarama.ErrNotCoordinatorForConsumer, kerr == sarama.ErrConsumerCoordinatorNotAvailable:
+			// the connection failed or the coordinator moved
+			_ = p.prodClient.RefreshCoordinator(groupID)
+		case kerr == sarama.ErrOffsetsLoadInProgress, kerr == sarama.ErrRequestTimedOut:
+		default:
+			return err
+		}
+	}
+	return err
+}
+
+func txnOffsetCommitError(rsp *sarama.TxnOffsetCommitResponse) error {
+	for _, pp := range rsp.Topics {
+		for _, pe := range pp {
+			if pe.Err != sarama.ErrNoError {
+				return pe.Err
+			}
+		}
+	}
+	return nil
+}
+
+// CommitTxn commits the transaction, making its messages visible to the consumers and committing its offsets.
+func (p *TransactionalProducer) CommitTxn() error {
+	return p.endTxn(true)
+}
+
+// AbortTxn aborts the transaction, discarding its messages and offsets.
+// A transaction has to be aborted when any of its operations fails.
+func (p *TransactionalProducer) AbortTxn() error {
+	return p.endTxn(false)
+}
+
+func (p *TransactionalProducer) endTxn(commit bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return ErrNoTransaction
+	}
+
+	if len(p.partitions) > 0 || p.hasOffsets {
+		err := p.coordinate(context.Background(), func(coordinator *sarama.Broker) error {
+			rsp, err := coordinator.EndTxn(&sarama.EndTxnRequest{
+				TransactionalID:   p.transactionalID,
+				ProducerID:        p.producerID,
+				ProducerEpoch:     p.producerEpoch,
+				TransactionResult: commit,
+			})
+			if err != nil {
+				return err
+			}
+			if rsp.Err != sarama.ErrNoError {
+				return rsp.Err
+			}
+			return nil
+		})
+		if err != nil {
+			if commit {
+				return fmt.Errorf("failed to commit transaction: %w", err)
+			}
+			return fmt.Errorf("failed to abort transaction: %w", err)
+		}
+	}
+	p.inTxn = false
+	return nil
+}
+
+// Close shuts down the producer. A transaction in progress is aborted by the coordinator once it times out.
+func (p *TransactionalProducer) Close() error {
+	p.monitor.close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.coordinator != nil {
+		if err := p.coordinator.Close(); err != nil && !errors.Is(err, sarama.ErrNotConnected) {
+			return patronerrors.Aggregate(fmt.Errorf("failed to close transaction coordinator: %w", err), p.prodClient.Close())
+		}
+	}
+	if err := p.prodClient.Close(); err != nil {
+		return fmt.Errorf("failed to close transactional producer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package v2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	txnTopic = "txn-topic"
+	txnID    = "txn-id"
+	txnGroup = "txn-group"
+)
+
+func newTxnBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(txnHandlers(t, broker))
+	return broker
+}
+
+// txnHandlers returns the handlers of the requests of a successful transaction, which the tests override.
+func txnHandlers(t *testing.T, broker *sarama.MockBroker) map[string]sarama.MockResponse {
+	return map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(txnTopic, 0, broker.BrokerID()),
+		"FindCoordinatorRequest": findCoordinatorResponse(t, broker),
+		"InitProducerIDRequest":  sarama.NewMockWrapper(&sarama.InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+		"AddPartitionsToTxnRequest": sarama.NewMockWrapper(&sarama.AddPartitionsToTxnResponse{
+			Errors: map[string][]*sarama.PartitionError{txnTopic: {{Partition: 0, Err: sarama.ErrNoError}}},
+		}),
+		"ProduceRequest":         sarama.NewMockProduceResponse(t).SetVersion(3),
+		"AddOffsetsToTxnRequest": sarama.NewMockWrapper(&sarama.AddOffsetsToTxnResponse{}),
+		"TxnOffsetCommitRequest": sarama.NewMockWrapper(&sarama.TxnOffsetCommitResponse{
+			Topics: map[string][]*sarama.PartitionError{txnTopic: {{Partition: 0, Err: sarama.ErrNoError}}},
+		}),
+		"EndTxnRequest": sarama.NewMockWrapper(&sarama.EndTxnResponse{}),
+	}
+}
+
+// findCoordinatorResponse responds with the broker as the transaction coordinator, with the version 1 of the response
+// which is required for transactions, and then as the coordinator of the consumer group.
+func findCoordinatorResponse(t *testing.T, broker *sarama.MockBroker) sarama.MockResponse {
+	return sarama.NewMockSequence(
+		&sarama.FindCoordinatorResponse{Version: 1, Coordinator: sarama.NewBroker(broker.Addr())},
+		sarama.NewMockFindCoordinatorResponse(t).SetCoordinator(sarama.CoordinatorGroup, txnGroup, broker),
+	)
+}
+
+func txnConfig() *sarama.Config {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_0_0_0
+	cfg.Metadata.Retry.Max = 0
+	cfg.Producer.Retry.Backoff = time.Millisecond
+	return cfg
+}
+
+func TestBuilder_CreateTransactional(t *testing.T) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V0_10_2_0
+
+	tests := map[string]struct {
+		builder     *Builder
+		id          string
+		expectedErr string
+	}{
+		"missing transactional ID": {builder: New([]string{"123"}, txnConfig()), expectedErr: "transactional ID is empty\n"},
+		"unsupported version":      {builder: New([]string{"123"}, cfg), id: txnID, expectedErr: "transactions require Kafka version 0.11.0.0 or later\n"},
+		"builder errors": {
+			builder:     New(nil, txnConfig()),
+			expectedErr: "brokers are empty or have an empty value\ntransactional ID is empty\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.CreateTransactional(tt.id)
+			require.EqualError(t, err, tt.expectedErr)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestTransactionalProducer(t *testing.T) {
+	broker := newTxnBroker(t)
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+	assert.Equal(t, int64(1000), p.producerID)
+	assert.Equal(t, int16(1), p.producerEpoch)
+
+	_, _, err = p.Send(context.Background(), &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	assert.Equal(t, ErrNoTransaction, err)
+	assert.Equal(t, ErrNoTransaction, p.CommitTxn())
+
+	require.NoError(t, p.BeginTxn())
+	assert.Equal(t, ErrTransactionInProgress, p.BeginTxn())
+	for i := 0; i < 2; i++ {
+		partition, _, err := p.Send(context.Background(), &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), partition)
+	}
+	require.NoError(t, p.SendOffsets(txnGroup,
+		&sarama.ConsumerMessage{Topic: txnTopic, Partition: 0, Offset: 5},
+		&sarama.ConsumerMessage{Topic: txnTopic, Partition: 0, Offset: 4}))
+	require.NoError(t, p.CommitTxn())
+
+	var addPartitions, produced, endTxn int
+	var offsetCommit *sarama.TxnOffsetCommitRequest
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *sarama.AddPartitionsToTxnRequest:
+			addPartitions++
+			assert.Equal(t, map[string][]int32{txnTopic: {0}}, req.TopicPartitions)
+		case *sarama.ProduceRequest:
+			produced++
+			require.NotNil(t, req.TransactionalID)
+			assert.Equal(t, txnID, *req.TransactionalID)
+		case *sarama.TxnOffsetCommitRequest:
+			offsetCommit = req
+		case *sarama.EndTxnRequest:
+			endTxn++
+			assert.True(t, req.TransactionResult)
+			assert.Equal(t, int64(1000), req.ProducerID)
+		}
+	}
+	assert.Equal(t, 1, addPartitions, "the partition is added to the transaction once")
+	assert.Equal(t, 2, produced)
+	assert.Equal(t, 1, endTxn)
+	require.NotNil(t, offsetCommit)
+	assert.Equal(t, txnGroup, offsetCommit.GroupID)
+	require.Len(t, offsetCommit.Topics[txnTopic], 1)
+	assert.Equal(t, int64(6), offsetCommit.Topics[txnTopic][0].Offset, "the offset after the last consumed message is committed")
+	assert.Equal(t, int32(2), p.sequences[topicPartition{topic: txnTopic}])
+
+	// an empty transaction is not sent to the coordinator
+	require.NoError(t, p.BeginTxn())
+	require.NoError(t, p.AbortTxn())
+	endTxn = 0
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.EndTxnRequest); ok {
+			endTxn++
+		}
+	}
+	assert.Equal(t, 1, endTxn)
+}
+
+func TestTransactionalProducer_SendFailure(t *testing.T) {
+	broker := newTxnBroker(t)
+	handlers := txnHandlers(t, broker)
+	handlers["ProduceRequest"] = sarama.NewMockProduceResponse(t).SetVersion(3).SetError(txnTopic, 0, sarama.ErrInvalidProducerEpoch)
+	broker.SetHandlerByMap(handlers)
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	require.NoError(t, p.BeginTxn())
+	_, _, err = p.Send(context.Background(), &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	assert.EqualError(t, err, "failed to produce messages to txn-topic/0: kafka server: Producer attempted an operation with an old epoch.")
+	assert.NoError(t, p.AbortTxn())
+	assert.Equal(t, int32(0), p.sequences[topicPartition{topic: txnTopic}])
+}
+
+func TestTransactionalProducer_SendBatch(t *testing.T) {
+	broker := newTxnBroker(t)
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	assert.EqualError(t, p.SendBatch(context.Background(), nil), "messages are empty or nil")
+	require.NoError(t, p.BeginTxn())
+	msgs := []*sarama.ProducerMessage{
+		{Topic: txnTopic, Value: sarama.StringEncoder("value 1")},
+		{Topic: txnTopic, Value: sarama.StringEncoder("value 2")},
+		{Topic: txnTopic, Value: sarama.StringEncoder("value 3")},
+	}
+	require.NoError(t, p.SendBatch(context.Background(), msgs))
+	require.NoError(t, p.CommitTxn())
+
+	var produced int
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+		}
+	}
+	assert.Equal(t, 1, produced, "the messages of a partition are sent with a single request")
+	assert.Equal(t, int32(3), p.sequences[topicPartition{topic: txnTopic}])
+	for i, msg := range msgs {
+		assert.Equal(t, int32(0), msg.Partition)
+		assert.Equal(t, int64(i), msg.Offset)
+	}
+}
+
+func TestTransactionalProducer_CoordinatorRetries(t *testing.T) {
+	broker := newTxnBroker(t)
+	handlers := txnHandlers(t, broker)
+	handlers["FindCoordinatorRequest"] = sarama.NewMockWrapper(&sarama.FindCoordinatorResponse{Version: 1, Coordinator: sarama.NewBroker(broker.Addr())})
+	handlers["AddPartitionsToTxnRequest"] = sarama.NewMockSequence(
+		&sarama.AddPartitionsToTxnResponse{
+			Errors: map[string][]*sarama.PartitionError{txnTopic: {{Partition: 0, Err: sarama.ErrConcurrentTransactions}}},
+		},
+		&sarama.AddPartitionsToTxnResponse{
+			Errors: map[string][]*sarama.PartitionError{txnTopic: {{Partition: 0, Err: sarama.ErrNoError}}},
+		},
+	)
+	handlers["EndTxnRequest"] = sarama.NewMockSequence(
+		&sarama.EndTxnResponse{Err: sarama.ErrNotCoordinatorForConsumer},
+		&sarama.EndTxnResponse{Err: sarama.ErrOffsetsLoadInProgress},
+		&sarama.EndTxnResponse{},
+	)
+	broker.SetHandlerByMap(handlers)
+	p, err := New([]string{broker.Addr()}, txnConfig()).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	require.NoError(t, p.BeginTxn())
+	_, _, err = p.Send(context.Background(), &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	require.NoError(t, err)
+	require.NoError(t, p.CommitTxn())
+
+	var findCoordinator, addPartitions, endTxn int
+	for _, rr := range broker.History() {
+		switch rr.Request.(type) {
+		case *sarama.FindCoordinatorRequest:
+			findCoordinator++
+		case *sarama.AddPartitionsToTxnRequest:
+			addPartitions++
+		case *sarama.EndTxnRequest:
+			endTxn++
+		}
+	}
+	assert.Equal(t, 2, findCoordinator, "the coordinator is found again once it moved")
+	assert.Equal(t, 2, addPartitions)
+	assert.Equal(t, 3, endTxn)
+}
+
+func TestTransactionalProducer_RetriesExhausted(t *testing.T) {
+	broker := newTxnBroker(t)
+	handlers := txnHandlers(t, broker)
+	handlers["EndTxnRequest"] = sarama.NewMockWrapper(&sarama.EndTxnResponse{Err: sarama.ErrConcurrentTransactions})
+	broker.SetHandlerByMap(handlers)
+	cfg := txnConfig()
+	cfg.Producer.Retry.Max = 2
+	p, err := New([]string{broker.Addr()}, cfg).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	require.NoError(t, p.BeginTxn())
+	_, _, err = p.Send(context.Background(), &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	require.NoError(t, err)
+	assert.EqualError(t, p.CommitTxn(), "failed to commit transaction: kafka server: The producer attempted to update a transaction while another concurrent operation on the same transaction was ongoing.")
+
+	var endTxn int
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.EndTxnRequest); ok {
+			endTxn++
+		}
+	}
+	assert.Equal(t, 3, endTxn)
+}
+
+func TestTransactionalProducer_SendContext(t *testing.T) {
+	broker := newTxnBroker(t)
+	handlers := txnHandlers(t, broker)
+	handlers["AddPartitionsToTxnRequest"] = sarama.NewMockWrapper(&sarama.AddPartitionsToTxnResponse{
+		Errors: map[string][]*sarama.PartitionError{txnTopic: {{Partition: 0, Err: sarama.ErrConcurrentTransactions}}},
+	})
+	broker.SetHandlerByMap(handlers)
+	cfg := txnConfig()
+	cfg.Producer.Retry.Backoff = time.Minute
+	p, err := New([]string{broker.Addr()}, cfg).CreateTransactional(txnID)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, p.Close()) }()
+	require.NoError(t, p.BeginTxn())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = p.Send(ctx, &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	assert.Equal(t, context.Canceled, err)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err = p.Send(ctx, &sarama.ProducerMessage{Topic: txnTopic, Value: sarama.StringEncoder("value")})
+	assert.EqualError(t, err, "failed to add partitions to transaction: context deadline exceeded")
+	assert.NoError(t, p.AbortTxn())
+}
@@ -0,0 +1,19 @@
+package pubsub
+
+import "errors"
+
+// OptionFunc definition for configuring the publisher in a functional way.
+type OptionFunc func(*Publisher) error
+
+// OrderingKey sets the function which returns the ordering key of the messages which do not have one and are published
+// to a topic with message ordering enabled, e.g. the ID of the entity the message refers to, so that the messages of the entity
+// are delivered in order to the subscriptions with message ordering enabled.
+func OrderingKey(f KeyFunc) OptionFunc {
+	return func(p *Publisher) error {
+		if f == nil {
+			return errors.New("ordering key function is nil")
+		}
+		p.orderingKey = f
+		return nil
+	}
+}
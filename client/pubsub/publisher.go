@@ -0,0 +1,131 @@
+// Package pubsub provides a publisher for Google Cloud Pub/Sub, which includes distributed tracing capabilities by default.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const publisherComponent = "pubsub-publisher"
+
+var publishDurationMetrics *prometheus.HistogramVec
+
+func init() {
+	publishDurationMetrics = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "client",
+			Subsystem: "pubsub",
+			Name:      "publish_duration_seconds",
+			Help:      "Google Cloud Pub/Sub publish completed by the client.",
+		},
+		[]string{"topic", "success"},
+	)
+	prometheus.MustRegister(publishDurationMetrics)
+}
+
+// KeyFunc returns a key of the message, e.g. its ordering key.
+type KeyFunc func(msg *pubsub.Message) string
+
+// Publisher is a wrapper with added distributed tracing capabilities.
+type Publisher struct {
+	orderingKey KeyFunc
+}
+
+// New creates a new Pub/Sub publisher.
+func New(oo ...OptionFunc) (Publisher, error) {
+	p := Publisher{}
+	for _, o := range oo {
+		if err := o(&p); err != nil {
+			return Publisher{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// Publish publishes a message to the topic and waits for the result. It also stores tracing information.
+// The messages with an ordering key require a topic with message ordering enabled. When such a message fails to be published,
+// the topic does not publish more messages with its ordering key until Topic.ResumePublish is called.
+func (p Publisher) Publish(ctx context.Context, topic *pubsub.Topic, msg *pubsub.Message) (messageID string, err error) {
+	if topic == nil {
+		return "", errors.New("topic is nil")
+	}
+	if msg == nil {
+		return "", errors.New("message is nil")
+	}
+
+	if msg.OrderingKey == "" && p.orderingKey != nil && topic.EnableMessageOrdering {
+		msg.OrderingKey = p.orderingKey(msg)
+	}
+	if msg.OrderingKey != "" && !topic.EnableMessageOrdering {
+		return "", fmt.Errorf("ordering key requires message ordering to be enabled for topic %s", topic.ID())
+	}
+
+	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, topic.ID()), publisherComponent, ext.SpanKindProducer)
+
+	if msg.Attributes == nil {
+		msg.Attributes = make(map[string]string)
+	}
+	if err := injectHeaders(span, msg); err != nil {
+		log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
+	}
+	injectCorrelationID(ctx, msg)
+	injectPropagatedHeaders(ctx, msg)
+
+	start := time.Now()
+	messageID, err = topic.Publish(ctx, msg).Get(ctx)
+	observePublish(span, start, topic.ID(), err)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	return messageID, nil
+}
+
+type pubsubHeadersCarrier map[string]string
+
+// Set implements Set() of opentracing.TextMapWriter.
+func (c pubsubHeadersCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+// injectHeaders injects the tracing headers into the message's attributes.
+func injectHeaders(span opentracing.Span, msg *pubsub.Message) error {
+	carrier := pubsubHeadersCarrier(msg.Attributes)
+	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return fmt.Errorf("failed to inject tracing headers: %w", err)
+	}
+	return nil
+}
+
+// injectCorrelationID injects the correlation ID of the context into the message's attributes,
+// unless the message already has one, e.g. when a consumed message is published again with its attributes.
+func injectCorrelationID(ctx context.Context, msg *pubsub.Message) {
+	if msg.Attributes[correlation.HeaderID] != "" {
+		return
+	}
+	msg.Attributes[correlation.HeaderID] = correlation.IDFromContext(ctx)
+}
+
+// injectPropagatedHeaders injects the headers propagated in the context into the message's attributes.
+func injectPropagatedHeaders(ctx context.Context, msg *pubsub.Message) {
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		msg.Attributes[k] = v
+	}
+}
+
+func observePublish(span opentracing.Span, start time.Time, topic string, err error) {
+	trace.SpanComplete(span, err)
+	publishDurationMetrics.WithLabelValues(topic, strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+}
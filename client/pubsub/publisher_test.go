@@ -0,0 +1,133 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNew(t *testing.T) {
+	p, err := New()
+	assert.NoError(t, err)
+	assert.Nil(t, p.orderingKey)
+
+	_, err = New(OrderingKey(nil))
+	assert.EqualError(t, err, "ordering key function is nil")
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	mtr := mocktracer.New()
+	defer mtr.Reset()
+	opentracing.SetGlobalTracer(mtr)
+	correlation.PropagateHeaders("X-Tenant")
+	defer correlation.PropagateHeaders()
+
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	topic := createTopic(t, client, "topic")
+
+	p, err := New()
+	require.NoError(t, err)
+
+	ctx := correlation.ContextWithID(context.Background(), "corID")
+	ctx = correlation.ContextWithHeaders(ctx, func(string) string { return "tenant" })
+
+	id, err := p.Publish(ctx, topic, &pubsub.Message{Data: []byte("body")})
+	require.NoError(t, err)
+
+	msg := srv.Message(id)
+	require.NotNil(t, msg)
+	assert.Equal(t, []byte("body"), msg.Data)
+	assert.Equal(t, "corID", msg.Attributes[correlation.HeaderID])
+	assert.Equal(t, "tenant", msg.Attributes["X-Tenant"])
+	assert.NotEmpty(t, msg.Attributes["mockpfx-ids-traceid"], "the tracing headers are injected")
+
+	require.Len(t, mtr.FinishedSpans(), 1)
+	sp := mtr.FinishedSpans()[0]
+	assert.Equal(t, "pubsub-publisher topic", sp.OperationName)
+	assert.Equal(t, false, sp.Tag("error"))
+
+	// the correlation ID of the message is kept
+	id, err = p.Publish(ctx, topic, &pubsub.Message{Data: []byte("body"), Attributes: map[string]string{correlation.HeaderID: "existing"}})
+	require.NoError(t, err)
+	assert.Equal(t, "existing", srv.Message(id).Attributes[correlation.HeaderID])
+}
+
+func TestPublisher_Publish_OrderingKey(t *testing.T) {
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	ordered := createTopic(t, client, "ordered")
+	ordered.EnableMessageOrdering = true
+	unordered := createTopic(t, client, "unordered")
+
+	p, err := New(OrderingKey(func(msg *pubsub.Message) string { return string(msg.Data) }))
+	require.NoError(t, err)
+
+	id, err := p.Publish(context.Background(), ordered, &pubsub.Message{Data: []byte("key")})
+	require.NoError(t, err)
+	assert.Equal(t, "key", srv.Message(id).OrderingKey)
+
+	id, err = p.Publish(context.Background(), ordered, &pubsub.Message{Data: []byte("key"), OrderingKey: "existing"})
+	require.NoError(t, err)
+	assert.Equal(t, "existing", srv.Message(id).OrderingKey, "the ordering key of the message is kept")
+
+	id, err = p.Publish(context.Background(), unordered, &pubsub.Message{Data: []byte("key")})
+	require.NoError(t, err)
+	assert.Empty(t, srv.Message(id).OrderingKey, "the ordering key is set only for the topics with message ordering enabled")
+
+	_, err = p.Publish(context.Background(), unordered, &pubsub.Message{Data: []byte("key"), OrderingKey: "key"})
+	assert.EqualError(t, err, "ordering key requires message ordering to be enabled for topic unordered")
+}
+
+func TestPublisher_Publish_Error(t *testing.T) {
+	mtr := mocktracer.New()
+	defer mtr.Reset()
+	opentracing.SetGlobalTracer(mtr)
+
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	topic := createTopic(t, client, "topic")
+	srv.SetAutoPublishResponse(false)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "invalid"))
+
+	p, err := New()
+	require.NoError(t, err)
+
+	_, err = p.Publish(context.Background(), topic, &pubsub.Message{Data: []byte("body")})
+	assert.EqualError(t, err, "failed to publish message: rpc error: code = InvalidArgument desc = invalid")
+	require.Len(t, mtr.FinishedSpans(), 1)
+	assert.Equal(t, true, mtr.FinishedSpans()[0].Tag("error"))
+
+	_, err = p.Publish(context.Background(), nil, &pubsub.Message{})
+	assert.EqualError(t, err, "topic is nil")
+	_, err = p.Publish(context.Background(), topic, nil)
+	assert.EqualError(t, err, "message is nil")
+}
+
+func newTestClient(t *testing.T) (*pstest.Server, *pubsub.Client) {
+	srv := pstest.NewServer()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	client, err := pubsub.NewClient(context.Background(), "project", option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return srv, client
+}
+
+func createTopic(t *testing.T, client *pubsub.Client, id string) *pubsub.Topic {
+	topic, err := client.CreateTopic(context.Background(), id)
+	require.NoError(t, err)
+	t.Cleanup(topic.Stop)
+	return topic
+}
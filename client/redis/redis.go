@@ -4,6 +4,7 @@ package redis
 import (
 	"context"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/beatlabs/patron/trace"
@@ -57,6 +58,22 @@ func New(opt Options) Client {
 	return Client{Client: *cl}
 }
 
+// ClusterOptions wraps redis.ClusterOptions for easier usage.
+type ClusterOptions redis.ClusterOptions
+
+// ClusterClient represents a connection with a Redis cluster.
+type ClusterClient struct {
+	redis.ClusterClient
+}
+
+// NewCluster returns a new Redis cluster client.
+func NewCluster(opt ClusterOptions) ClusterClient {
+	clusterOptions := redis.ClusterOptions(opt)
+	cl := redis.NewClusterClient(&clusterOptions)
+	cl.AddHook(tracingHook{address: strings.Join(clusterOptions.Addrs, ",")})
+	return ClusterClient{ClusterClient: *cl}
+}
+
 type tracingHook struct {
 	address string
 }
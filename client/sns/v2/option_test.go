@@ -0,0 +1,23 @@
+package v2
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupID(t *testing.T) {
+	p := &Publisher{}
+	assert.EqualError(t, GroupID(nil)(p), "group ID function is nil")
+	require.NoError(t, GroupID(func(*sns.PublishInput) string { return "group" })(p))
+	assert.Equal(t, "group", p.groupID(&sns.PublishInput{}))
+}
+
+func TestDeduplicationID(t *testing.T) {
+	p := &Publisher{}
+	assert.EqualError(t, DeduplicationID(nil)(p), "deduplication ID function is nil")
+	require.NoError(t, DeduplicationID(func(*sns.PublishInput) string { return "dedup" })(p))
+	assert.Equal(t, "dedup", p.deduplicationID(&sns.PublishInput{}))
+}
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
@@ -27,9 +29,21 @@ const (
 	tracingTargetUnknown   = "unknown"
 	tracingTargetTopicArn  = "topic-arn"
 	tracingTargetTargetArn = "target-arn"
+
+	fifoSuffix = ".fifo"
+)
+
+type publishStatus string
+
+const (
+	publishStatusSent      publishStatus = "sent"
+	publishStatusSendError publishStatus = "send-errors"
 )
 
-var publishDurationMetrics *prometheus.HistogramVec
+var (
+	publishDurationMetrics *prometheus.HistogramVec
+	messageStatus          *prometheus.CounterVec
+)
 
 func init() {
 	publishDurationMetrics = prometheus.NewHistogramVec(
@@ -42,38 +56,63 @@ func init() {
 		[]string{"topic", "success"},
 	)
 	prometheus.MustRegister(publishDurationMetrics)
+	messageStatus = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "client",
+			Subsystem: "sns",
+			Name:      "message_status",
+			Help:      "Message status counter (sent, send-errors) classified by topic",
+		},
+		[]string{"status", "topic"},
+	)
+	prometheus.MustRegister(messageStatus)
 }
 
+// IDFunc returns an ID of the message, e.g. its message group ID or its deduplication ID.
+type IDFunc func(input *sns.PublishInput) string
+
 // Publisher is an implementation of the Publisher interface with added distributed tracing capabilities.
 type Publisher struct {
-	api snsiface.SNSAPI
+	api             snsiface.SNSAPI
+	groupID         IDFunc
+	deduplicationID IDFunc
 }
 
 // New creates a new SNS publisher.
-func New(api snsiface.SNSAPI) (Publisher, error) {
+func New(api snsiface.SNSAPI, oo ...OptionFunc) (Publisher, error) {
 	if api == nil {
 		return Publisher{}, errors.New("missing api")
 	}
 
-	return Publisher{api: api}, nil
+	p := Publisher{api: api}
+	for _, o := range oo {
+		if err := o(&p); err != nil {
+			return Publisher{}, err
+		}
+	}
+
+	return p, nil
 }
 
-// Publish tries to publish a new message to SNS. It also stores tracing information.
+// Publish tries to publish a new message to SNS. It also stores tracing information, along with the correlation ID
+// and the propagated headers of the context, in the message attributes.
+// The messages of FIFO topics, whose name ends with .fifo, require a message group ID.
 func (p Publisher) Publish(ctx context.Context, input *sns.PublishInput) (messageID string, err error) {
+	if err := p.fifo(input); err != nil {
+		return "", err
+	}
+
 	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, tracingTarget(input)), publisherComponent, ext.SpanKindProducer)
 
 	if err := injectHeaders(span, input); err != nil {
 		log.FromContext(ctx).Warnf("failed to inject tracing header: %v", err)
 	}
+	injectCorrelationID(ctx, input)
+	injectPropagatedHeaders(ctx, input)
 
 	start := time.Now()
 	out, err := p.api.PublishWithContext(ctx, input)
-	if input.TopicArn != nil {
-		observePublish(span, start, *input.TopicArn, err)
-	}
-	if input.TargetArn != nil {
-		observePublish(span, start, *input.TargetArn, err)
-	}
+	observePublish(span, start, publishTarget(input), err)
 	if err != nil {
 		return "", fmt.Errorf("failed to publish message: %w", err)
 	}
@@ -85,6 +124,24 @@ func (p Publisher) Publish(ctx context.Context, input *sns.PublishInput) (messag
 	return *out.MessageId, nil
 }
 
+// fifo sets the message group ID and the deduplication ID of the messages of FIFO topics, which are not already set,
+// and validates the messages.
+func (p Publisher) fifo(input *sns.PublishInput) error {
+	if !strings.HasSuffix(aws.StringValue(input.TopicArn), fifoSuffix) {
+		return nil
+	}
+	if input.MessageGroupId == nil && p.groupID != nil {
+		input.MessageGroupId = aws.String(p.groupID(input))
+	}
+	if input.MessageDeduplicationId == nil && p.deduplicationID != nil {
+		input.MessageDeduplicationId = aws.String(p.deduplicationID(input))
+	}
+	if aws.StringValue(input.MessageGroupId) == "" {
+		return fmt.Errorf("message group ID is required for FIFO topic %s", aws.StringValue(input.TopicArn))
+	}
+	return nil
+}
+
 type snsHeadersCarrier map[string]interface{}
 
 // Set implements Set() of opentracing.TextMapWriter.
@@ -124,7 +181,45 @@ func injectHeaders(span opentracing.Span, input *sns.PublishInput) error {
 	return nil
 }
 
+// publishTarget returns the topic or the target of the message, which labels the metrics.
+func publishTarget(input *sns.PublishInput) string {
+	if input.TopicArn != nil {
+		return aws.StringValue(input.TopicArn)
+	}
+	if input.TargetArn != nil {
+		return aws.StringValue(input.TargetArn)
+	}
+	return tracingTargetUnknown
+}
+
 func observePublish(span opentracing.Span, start time.Time, topic string, err error) {
 	trace.SpanComplete(span, err)
 	publishDurationMetrics.WithLabelValues(topic, strconv.FormatBool(err != nil)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		messageStatus.WithLabelValues(string(publishStatusSendError), topic).Inc()
+		return
+	}
+	messageStatus.WithLabelValues(string(publishStatusSent), topic).Inc()
+}
+
+// injectCorrelationID injects the correlation ID of the context into the message's attributes,
+// unless the message already has one, e.g. when a consumed message is published again with its attributes.
+func injectCorrelationID(ctx context.Context, input *sns.PublishInput) {
+	if attr, ok := input.MessageAttributes[correlation.HeaderID]; ok && aws.StringValue(attr.StringValue) != "" {
+		return
+	}
+	input.MessageAttributes[correlation.HeaderID] = &sns.MessageAttributeValue{
+		DataType:    aws.String(attributeDataTypeString),
+		StringValue: aws.String(correlation.IDFromContext(ctx)),
+	}
+}
+
+// injectPropagatedHeaders injects the headers propagated in the context into the message's attributes.
+func injectPropagatedHeaders(ctx context.Context, input *sns.PublishInput) {
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		input.MessageAttributes[k] = &sns.MessageAttributeValue{
+			DataType:    aws.String(attributeDataTypeString),
+			StringValue: aws.String(v),
+		}
+	}
 }
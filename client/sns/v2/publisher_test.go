@@ -12,9 +12,11 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -88,6 +90,100 @@ func Test_Publisher_Publish(t *testing.T) {
 	}
 }
 
+func Test_Publisher_Publish_Observability(t *testing.T) {
+	mtr := mocktracer.New()
+	defer mtr.Reset()
+	opentracing.SetGlobalTracer(mtr)
+	ctx := correlation.ContextWithID(context.Background(), "corID")
+
+	p, err := New(newStubSNSAPI((&sns.PublishOutput{}).SetMessageId("msgID"), nil))
+	require.NoError(t, err)
+	input := &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("observability-topic")}
+	_, err = p.Publish(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, "corID", aws.StringValue(input.MessageAttributes[correlation.HeaderID].StringValue))
+	assert.NotNil(t, input.MessageAttributes["mockpfx-ids-traceid"])
+	assert.Equal(t, 1.0, testutil.ToFloat64(messageStatus.WithLabelValues("sent", "observability-topic")))
+
+	input = &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("observability-topic"),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{correlation.HeaderID: {DataType: aws.String("String"), StringValue: aws.String("existing")}}}
+	_, err = p.Publish(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, "existing", aws.StringValue(input.MessageAttributes[correlation.HeaderID].StringValue), "the correlation ID of the message is kept")
+
+	p, err = New(newStubSNSAPI(nil, errors.New("publish error")))
+	require.NoError(t, err)
+	_, err = p.Publish(ctx, &sns.PublishInput{Message: aws.String("body"), PhoneNumber: aws.String("+3012345678")})
+	require.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(messageStatus.WithLabelValues("send-errors", "unknown")))
+	assert.Len(t, mtr.FinishedSpans(), 3, "the spans are completed without a topic")
+}
+
+func Test_Publisher_Publish_FIFO(t *testing.T) {
+	body := func(input *sns.PublishInput) string { return aws.StringValue(input.Message) }
+
+	testCases := map[string]struct {
+		input                   *sns.PublishInput
+		oo                      []OptionFunc
+		expectedGroupID         *string
+		expectedDeduplicationID *string
+		expectedErr             string
+	}{
+		"group ID set": {
+			input:           &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn.fifo"), MessageGroupId: aws.String("group")},
+			expectedGroupID: aws.String("group"),
+		},
+		"IDs set by the options": {
+			input:                   &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn.fifo")},
+			oo:                      []OptionFunc{GroupID(body), DeduplicationID(body)},
+			expectedGroupID:         aws.String("body"),
+			expectedDeduplicationID: aws.String("body"),
+		},
+		"IDs of the message are kept": {
+			input: &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn.fifo"),
+				MessageGroupId: aws.String("group"), MessageDeduplicationId: aws.String("dedup")},
+			oo:                      []OptionFunc{GroupID(body), DeduplicationID(body)},
+			expectedGroupID:         aws.String("group"),
+			expectedDeduplicationID: aws.String("dedup"),
+		},
+		"standard topic": {
+			input: &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn")},
+			oo:    []OptionFunc{GroupID(body), DeduplicationID(body)},
+		},
+		"missing group ID": {
+			input:       &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn.fifo")},
+			expectedErr: "message group ID is required for FIFO topic arn.fifo",
+		},
+	}
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			p, err := New(newStubSNSAPI((&sns.PublishOutput{}).SetMessageId("msgID"), nil), tt.oo...)
+			require.NoError(t, err)
+
+			_, err = p.Publish(context.Background(), tt.input)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGroupID, tt.input.MessageGroupId)
+			assert.Equal(t, tt.expectedDeduplicationID, tt.input.MessageDeduplicationId)
+		})
+	}
+}
+
+func Test_injectPropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+	ctx := correlation.ContextWithHeaders(context.Background(), func(string) string { return "tenant" })
+
+	input := &sns.PublishInput{Message: aws.String("body"), TopicArn: aws.String("arn"), MessageAttributes: map[string]*sns.MessageAttributeValue{}}
+	injectPropagatedHeaders(ctx, input)
+	require.Contains(t, input.MessageAttributes, "X-Tenant-Id")
+	assert.Equal(t, "tenant", aws.StringValue(input.MessageAttributes["X-Tenant-Id"].StringValue))
+}
+
 type stubSNSAPI struct {
 	snsiface.SNSAPI // Implement the interface's methods without defining all of them (just override what we need)
 
@@ -61,6 +61,9 @@ func (p TracedPublisher) Publish(ctx context.Context, msg Message) (messageID st
 
 	msg.injectHeaders(carrier)
 	msg.setMessageAttribute(correlation.HeaderID, correlation.IDFromContext(ctx))
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		msg.setMessageAttribute(k, v)
+	}
 
 	out, err := p.api.SendMessageWithContext(ctx, msg.input)
 
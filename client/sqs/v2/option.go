@@ -0,0 +1,31 @@
+package sqs
+
+import "errors"
+
+// OptionFunc definition for configuring the publisher in a functional way.
+type OptionFunc func(*Publisher) error
+
+// GroupID sets the function which returns the message group ID of the messages of FIFO queues which do not have one,
+// e.g. the ID of the entity the message refers to, so that the messages of the entity are received in order.
+func GroupID(f IDFunc) OptionFunc {
+	return func(p *Publisher) error {
+		if f == nil {
+			return errors.New("group ID function is nil")
+		}
+		p.groupID = f
+		return nil
+	}
+}
+
+// DeduplicationID sets the function which returns the deduplication ID of the messages of FIFO queues which do not have one,
+// e.g. an idempotency key, so that the messages published again within the deduplication interval are discarded.
+// It is not required for the queues with content-based deduplication, which deduplicate the messages by their body.
+func DeduplicationID(f IDFunc) OptionFunc {
+	return func(p *Publisher) error {
+		if f == nil {
+			return errors.New("deduplication ID function is nil")
+		}
+		p.deduplicationID = f
+		return nil
+	}
+}
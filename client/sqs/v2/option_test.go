@@ -0,0 +1,23 @@
+package sqs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupID(t *testing.T) {
+	p := &Publisher{}
+	assert.EqualError(t, GroupID(nil)(p), "group ID function is nil")
+	require.NoError(t, GroupID(func(*sqs.SendMessageInput) string { return "group" })(p))
+	assert.Equal(t, "group", p.groupID(&sqs.SendMessageInput{}))
+}
+
+func TestDeduplicationID(t *testing.T) {
+	p := &Publisher{}
+	assert.EqualError(t, DeduplicationID(nil)(p), "deduplication ID function is nil")
+	require.NoError(t, DeduplicationID(func(*sqs.SendMessageInput) string { return "dedup" })(p))
+	assert.Equal(t, "dedup", p.deduplicationID(&sqs.SendMessageInput{}))
+}
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
@@ -22,6 +24,7 @@ import (
 const (
 	publisherComponent      = "sqs-publisher"
 	attributeDataTypeString = "String"
+	fifoSuffix              = ".fifo"
 )
 
 var publishDurationMetrics *prometheus.HistogramVec
@@ -39,27 +42,45 @@ func init() {
 	prometheus.MustRegister(publishDurationMetrics)
 }
 
+// IDFunc returns an ID of the message, e.g. its message group ID or its deduplication ID.
+type IDFunc func(msg *sqs.SendMessageInput) string
+
 // Publisher is a wrapper with added distributed tracing capabilities.
 type Publisher struct {
-	api sqsiface.SQSAPI
+	api             sqsiface.SQSAPI
+	groupID         IDFunc
+	deduplicationID IDFunc
 }
 
 // New creates a new SQS publisher.
-func New(api sqsiface.SQSAPI) (Publisher, error) {
+func New(api sqsiface.SQSAPI, oo ...OptionFunc) (Publisher, error) {
 	if api == nil {
 		return Publisher{}, errors.New("missing api")
 	}
 
-	return Publisher{api: api}, nil
+	p := Publisher{api: api}
+	for _, o := range oo {
+		if err := o(&p); err != nil {
+			return Publisher{}, err
+		}
+	}
+
+	return p, nil
 }
 
 // Publish tries to publish a new message to SQS. It also stores tracing information.
+// The messages of FIFO queues, whose name ends with .fifo, require a message group ID, and they cannot be delayed individually.
 func (p Publisher) Publish(ctx context.Context, msg *sqs.SendMessageInput) (messageID string, err error) {
+	if err := p.fifo(msg); err != nil {
+		return "", err
+	}
+
 	span, _ := trace.ChildSpan(ctx, trace.ComponentOpName(publisherComponent, *msg.QueueUrl), publisherComponent, ext.SpanKindProducer)
 
 	if err := injectHeaders(span, msg); err != nil {
 		log.FromContext(ctx).Errorf("failed to inject trace headers: %v", err)
 	}
+	injectPropagatedHeaders(ctx, msg)
 
 	start := time.Now()
 	out, err := p.api.SendMessageWithContext(ctx, msg)
@@ -75,6 +96,27 @@ func (p Publisher) Publish(ctx context.Context, msg *sqs.SendMessageInput) (mess
 	return *out.MessageId, nil
 }
 
+// fifo sets the message group ID and the deduplication ID of the messages of FIFO queues, which are not already set,
+// and validates the messages.
+func (p Publisher) fifo(msg *sqs.SendMessageInput) error {
+	if !strings.HasSuffix(aws.StringValue(msg.QueueUrl), fifoSuffix) {
+		return nil
+	}
+	if msg.MessageGroupId == nil && p.groupID != nil {
+		msg.MessageGroupId = aws.String(p.groupID(msg))
+	}
+	if msg.MessageDeduplicationId == nil && p.deduplicationID != nil {
+		msg.MessageDeduplicationId = aws.String(p.deduplicationID(msg))
+	}
+	if aws.StringValue(msg.MessageGroupId) == "" {
+		return fmt.Errorf("message group ID is required for FIFO queue %s", aws.StringValue(msg.QueueUrl))
+	}
+	if msg.DelaySeconds != nil {
+		return fmt.Errorf("messages of FIFO queue %s cannot be delayed individually", aws.StringValue(msg.QueueUrl))
+	}
+	return nil
+}
+
 type sqsHeadersCarrier map[string]interface{}
 
 // Set implements Set() of opentracing.TextMapWriter.
@@ -101,6 +143,19 @@ func injectHeaders(span opentracing.Span, input *sqs.SendMessageInput) error {
 	return nil
 }
 
+// injectPropagatedHeaders injects the headers propagated in the context into the message's attributes.
+func injectPropagatedHeaders(ctx context.Context, input *sqs.SendMessageInput) {
+	if input.MessageAttributes == nil {
+		input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue)
+	}
+	for k, v := range correlation.HeadersFromContext(ctx) {
+		input.MessageAttributes[k] = &sqs.MessageAttributeValue{
+			DataType:    aws.String(attributeDataTypeString),
+			StringValue: aws.String(v),
+		}
+	}
+}
+
 func observePublish(span opentracing.Span, start time.Time, queue string, err error) {
 	trace.SpanComplete(span, err)
 	publishDurationMetrics.WithLabelValues(queue, strconv.FormatBool(err != nil)).Observe(time.Since(start).Seconds())
@@ -12,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
@@ -92,6 +93,76 @@ func Test_Publisher_Publish(t *testing.T) {
 	}
 }
 
+func Test_Publisher_Publish_FIFO(t *testing.T) {
+	body := func(msg *sqs.SendMessageInput) string { return aws.StringValue(msg.MessageBody) }
+
+	testCases := map[string]struct {
+		msg                     *sqs.SendMessageInput
+		oo                      []OptionFunc
+		expectedGroupID         *string
+		expectedDeduplicationID *string
+		expectedErr             string
+	}{
+		"group ID set": {
+			msg:             &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url.fifo"), MessageGroupId: aws.String("group")},
+			expectedGroupID: aws.String("group"),
+		},
+		"IDs set by the options": {
+			msg:                     &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url.fifo")},
+			oo:                      []OptionFunc{GroupID(body), DeduplicationID(body)},
+			expectedGroupID:         aws.String("body"),
+			expectedDeduplicationID: aws.String("body"),
+		},
+		"IDs of the message are kept": {
+			msg: &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url.fifo"),
+				MessageGroupId: aws.String("group"), MessageDeduplicationId: aws.String("dedup")},
+			oo:                      []OptionFunc{GroupID(body), DeduplicationID(body)},
+			expectedGroupID:         aws.String("group"),
+			expectedDeduplicationID: aws.String("dedup"),
+		},
+		"standard queue": {
+			msg: &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url")},
+			oo:  []OptionFunc{GroupID(body), DeduplicationID(body)},
+		},
+		"missing group ID": {
+			msg:         &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url.fifo")},
+			expectedErr: "message group ID is required for FIFO queue url.fifo",
+		},
+		"delayed message": {
+			msg:         &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url.fifo"), MessageGroupId: aws.String("group"), DelaySeconds: aws.Int64(1)},
+			expectedErr: "messages of FIFO queue url.fifo cannot be delayed individually",
+		},
+	}
+	for name, tt := range testCases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			api := newStubSQSAPI((&sqs.SendMessageOutput{}).SetMessageId("msgID"), nil)
+			p, err := New(api, tt.oo...)
+			require.NoError(t, err)
+
+			_, err = p.Publish(context.Background(), tt.msg)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGroupID, tt.msg.MessageGroupId)
+			assert.Equal(t, tt.expectedDeduplicationID, tt.msg.MessageDeduplicationId)
+		})
+	}
+}
+
+func Test_injectPropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+	ctx := correlation.ContextWithHeaders(context.Background(), func(string) string { return "tenant" })
+
+	input := &sqs.SendMessageInput{MessageBody: aws.String("body"), QueueUrl: aws.String("url")}
+	injectPropagatedHeaders(ctx, input)
+	require.Contains(t, input.MessageAttributes, "X-Tenant-Id")
+	assert.Equal(t, "tenant", aws.StringValue(input.MessageAttributes["X-Tenant-Id"].StringValue))
+}
+
 type stubSQSAPI struct {
 	sqsiface.SQSAPI // Implement the interface's methods without defining all of them (just override what we need)
 
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/component/shutdown"
 	"github.com/beatlabs/patron/correlation"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
@@ -29,6 +32,7 @@ const (
 	defaultStatsInterval     = 5 * time.Second
 	defaultRetryCount        = 10
 	defaultRetryDelay        = 5 * time.Second
+	defaultMaxRetryDelay     = time.Minute
 
 	consumerComponent = "amqp-consumer"
 
@@ -41,8 +45,12 @@ var (
 	messageAge     *prometheus.GaugeVec
 	messageCounter *prometheus.CounterVec
 	queueSize      *prometheus.GaugeVec
+	reconnects     *prometheus.CounterVec
 )
 
+// errNoRetries is the error of the component when the retry count is 0, so it never subscribes to the queue.
+var errNoRetries = errors.New("retry count is 0")
+
 func init() {
 	messageAge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -74,6 +82,16 @@ func init() {
 		[]string{"queue"},
 	)
 	prometheus.MustRegister(queueSize)
+	reconnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "amqp",
+			Name:      "reconnects",
+			Help:      "Reconnection attempts after the connection or the channel was lost, or the subscription failed, classified by queue",
+		},
+		[]string{"queue"},
+	)
+	prometheus.MustRegister(reconnects)
 }
 
 // ProcessorFunc definition of a async processor.
@@ -83,6 +101,8 @@ type queueConfig struct {
 	url     string
 	queue   string
 	requeue bool
+	// the number of unacknowledged messages delivered by the broker, unlimited when zero
+	prefetch int
 }
 
 type batchConfig struct {
@@ -91,8 +111,9 @@ type batchConfig struct {
 }
 
 type retryConfig struct {
-	count uint
-	delay time.Duration
+	count    uint
+	delay    time.Duration
+	maxDelay time.Duration
 }
 
 type statsConfig struct {
@@ -101,13 +122,16 @@ type statsConfig struct {
 
 // Component implementation of a async component.
 type Component struct {
-	queueCfg queueConfig
-	proc     ProcessorFunc
-	batchCfg batchConfig
-	statsCfg statsConfig
-	retryCfg retryConfig
-	cfg      amqp.Config
-	traceTag opentracing.Tag
+	queueCfg   queueConfig
+	proc       ProcessorFunc
+	batchCfg   batchConfig
+	statsCfg   statsConfig
+	retryCfg   retryConfig
+	failureCfg failureConfig
+	cfg        amqp.Config
+	traceTag   opentracing.Tag
+	// subscribeFunc subscribes to the queue, which is replaced in tests
+	subscribeFunc func() (subscription, error)
 }
 
 // New creates a new component with support for functional configuration.
@@ -147,10 +171,15 @@ func New(url, queue string, proc ProcessorFunc, oo ...OptionFunc) (*Component, e
 			interval: defaultStatsInterval,
 		},
 		retryCfg: retryConfig{
-			count: defaultRetryCount,
-			delay: defaultRetryDelay,
+			count:    defaultRetryCount,
+			delay:    defaultRetryDelay,
+			maxDelay: defaultMaxRetryDelay,
+		},
+		failureCfg: failureConfig{
+			stop: make(chan error, 1),
 		},
 	}
+	cmp.subscribeFunc = cmp.subscribe
 
 	var err error
 
@@ -161,36 +190,81 @@ func New(url, queue string, proc ProcessorFunc, oo ...OptionFunc) (*Component, e
 		}
 	}
 
+	if cmp.queueCfg.prefetch > 0 && cmp.queueCfg.prefetch < int(cmp.batchCfg.count) {
+		return nil, errors.New("prefetch count should not be less than the batch count")
+	}
+
+	if cmp.failureCfg.strategy != nil {
+		if len(cmp.failureCfg.options) > 0 {
+			return nil, fmt.Errorf("failure strategy cannot be combined with %s", strings.Join(cmp.failureCfg.options, ", "))
+		}
+		if patronfailure.Declares(cmp.failureCfg.strategy, patronfailure.DeadLetter) && cmp.failureCfg.exchange == "" {
+			return nil, errors.New("failure strategy requires a dead letter exchange")
+		}
+	}
+
 	return cmp, nil
 }
 
 // Run starts the consumer processing loop messages.
+// When the connection or the channel is lost, the component dials the broker and subscribes to the queue again,
+// waiting with an exponential backoff between the attempts, and fails once the retries are exhausted.
+// The component also fails when the failure strategy decides to stop it.
 func (c *Component) Run(ctx context.Context) error {
+	if c.retryCfg.count == 0 {
+		return fmt.Errorf("failed to subscribe to queue %s: %w", c.queueCfg.queue, errNoRetries)
+	}
 	count := c.retryCfg.count
+	delay := c.retryCfg.delay
 
 	var err error
 
 	for count > 0 {
-		sub, err := c.subscribe()
+		var sub subscription
+		sub, err = c.subscribeFunc()
 		if err != nil {
-			log.Warnf("failed to subscribe to queue: %v, waiting for %v to reconnect", err, c.retryCfg.delay)
-			time.Sleep(c.retryCfg.delay)
-			count--
-			continue
-		}
-		count = c.retryCfg.count
+			log.Warnf("failed to subscribe to queue: %v, waiting for %v to reconnect", err, delay)
+		} else {
+			count = c.retryCfg.count
+			delay = c.retryCfg.delay
 
-		err = c.processLoop(ctx, sub)
-		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			err = c.processLoop(ctx, sub)
 			closeSubscription(sub)
-			return nil
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil
+			}
+			if errors.Is(err, errStopped) {
+				return err
+			}
+			log.Warnf("process loop failure: %v, waiting for %v to reconnect", err, delay)
 		}
-		log.Warnf("process loop failure: %v, waiting for %v to reconnect", err, c.retryCfg.delay)
-		time.Sleep(c.retryCfg.delay)
+
 		count--
-		closeSubscription(sub)
+		if count == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = c.nextRetryDelay(delay)
+		reconnectCountInc(c.queueCfg.queue)
 	}
-	return err
+	return fmt.Errorf("failed to reconnect to queue %s after %d retries: %w", c.queueCfg.queue, c.retryCfg.count, err)
+}
+
+// nextRetryDelay doubles the delay up to the max retry delay, or up to the initial delay if it is greater.
+func (c *Component) nextRetryDelay(delay time.Duration) time.Duration {
+	max := c.retryCfg.maxDelay
+	if max < c.retryCfg.delay {
+		max = c.retryCfg.delay
+	}
+	delay *= 2
+	if delay > max {
+		return max
+	}
+	return delay
 }
 
 func closeSubscription(sub subscription) {
@@ -202,12 +276,17 @@ func closeSubscription(sub subscription) {
 }
 
 func (c *Component) processLoop(ctx context.Context, sub subscription) error {
-	batchTimeout := time.NewTicker(c.batchCfg.timeout)
+	// the timer is started once the first message of a batch is received
+	batchTimeout := time.NewTimer(c.batchCfg.timeout)
+	batchTimeout.Stop()
 	defer batchTimeout.Stop()
 	tickerStats := time.NewTicker(c.statsCfg.interval)
 	defer tickerStats.Stop()
 
 	btc := &batch{messages: make([]Message, 0, c.batchCfg.count)}
+	// the batch in flight when the service is terminated is processed until the shutdown deadline, if any,
+	// while the messages of the batch which was not processed yet are delivered again by the broker
+	pctx := shutdown.DrainFromContext(ctx)
 
 	for {
 		select {
@@ -216,14 +295,15 @@ func (c *Component) processLoop(ctx context.Context, sub subscription) error {
 			return ctx.Err()
 		case delivery, ok := <-sub.deliveries:
 			if !ok {
-				return errors.New("subscription channel closed")
+				return sub.closeError()
 			}
 			log.Debugf("processing message %d", delivery.DeliveryTag)
 			observeReceivedMessageStats(c.queueCfg.queue, delivery.Timestamp)
-			c.processBatch(ctx, c.createMessage(ctx, delivery), btc)
+			c.processBatch(pctx, c.createMessage(pctx, delivery, sub.channel), btc, batchTimeout)
 		case <-batchTimeout.C:
-			log.Debugf("batch timeout expired, sending batch")
-			c.sendBatch(ctx, btc)
+			c.sendBatch(pctx, btc, batchTimeout)
+		case err := <-c.failureCfg.stop:
+			return err
 		case <-tickerStats.C:
 			err := c.stats(sub)
 			if err != nil {
@@ -242,7 +322,21 @@ type subscription struct {
 	conn       *amqp.Connection
 	channel    *amqp.Channel
 	deliveries <-chan amqp.Delivery
-	closed     bool
+	// notifications of the channel being closed, e.g. due to the loss of the connection
+	notifyClose chan *amqp.Error
+	closed      bool
+}
+
+// closeError returns the reason the channel was closed, which is notified before the deliveries channel is closed.
+func (s *subscription) closeError() error {
+	select {
+	case amqpErr, ok := <-s.notifyClose:
+		if ok && amqpErr != nil {
+			return fmt.Errorf("subscription channel closed: %w", amqpErr)
+		}
+	default:
+	}
+	return errors.New("subscription channel closed")
 }
 
 func (s *subscription) close() error {
@@ -272,6 +366,12 @@ func (c *Component) subscribe() (subscription, error) {
 		return subscription{}, patronerrors.Aggregate(conn.Close(), fmt.Errorf("failed get channel: %w", err))
 	}
 	sub.channel = ch
+	if c.queueCfg.prefetch > 0 {
+		if err := ch.Qos(c.queueCfg.prefetch, 0, false); err != nil {
+			return subscription{}, patronerrors.Aggregate(ch.Close(), conn.Close(), fmt.Errorf("failed to set prefetch count: %w", err))
+		}
+	}
+	sub.notifyClose = ch.NotifyClose(make(chan *amqp.Error, 1))
 
 	tag := uuid.New().String()
 	log.Debugf("consuming messages for tag %s", tag)
@@ -285,24 +385,34 @@ func (c *Component) subscribe() (subscription, error) {
 	return sub, nil
 }
 
-func (c *Component) createMessage(ctx context.Context, delivery amqp.Delivery) *message {
+func (c *Component) createMessage(ctx context.Context, delivery amqp.Delivery, channel publisher) *message {
 	corID := getCorrelationID(delivery.Headers)
+	hdr := mapHeader(delivery.Headers)
 	sp, ctxMsg := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queueCfg.queue),
-		consumerComponent, corID, mapHeader(delivery.Headers), c.traceTag)
+		consumerComponent, corID, hdr, c.traceTag)
 
 	ctxMsg = correlation.ContextWithID(ctxMsg, corID)
+	ctxMsg = correlation.ContextWithHeaders(ctxMsg, func(header string) string { return hdr[header] })
 	ctxMsg = log.WithContext(ctxMsg, log.Sub(map[string]interface{}{correlation.ID: corID}))
 
 	return &message{
-		ctx:     ctxMsg,
-		span:    sp,
-		msg:     delivery,
-		requeue: c.queueCfg.requeue,
-		queue:   c.queueCfg.queue,
+		ctx:        ctxMsg,
+		span:       sp,
+		msg:        delivery,
+		requeue:    c.queueCfg.requeue,
+		queue:      c.queueCfg.queue,
+		failureCfg: c.failureCfg,
+		channel:    channel,
 	}
 }
 
-func (c *Component) processBatch(ctx context.Context, msg *message, btc *batch) {
+// processBatch appends the message to the batch, which is processed once it is full, or once its first message
+// has waited for the batch timeout.
+func (c *Component) processBatch(ctx context.Context, msg *message, btc *batch, timer *time.Timer) {
+	if len(btc.messages) == 0 {
+		btc.start = time.Now()
+		timer.Reset(c.batchCfg.timeout)
+	}
 	btc.append(msg)
 
 	if len(btc.messages) >= int(c.batchCfg.count) {
@@ -310,7 +420,17 @@ func (c *Component) processBatch(ctx context.Context, msg *message, btc *batch)
 	}
 }
 
-func (c *Component) sendBatch(ctx context.Context, btc *batch) {
+// sendBatch processes the batch once its first message has waited for the batch timeout.
+// The timer may fire for a batch which was already processed, in which case it is rearmed for the current one.
+func (c *Component) sendBatch(ctx context.Context, btc *batch, timer *time.Timer) {
+	if len(btc.messages) == 0 {
+		return
+	}
+	if wait := c.batchCfg.timeout - time.Since(btc.start); wait > 0 {
+		timer.Reset(wait)
+		return
+	}
+	log.Debugf("batch timeout expired, sending batch")
 	c.processAndResetBatch(ctx, btc)
 }
 
@@ -329,6 +449,10 @@ func (c *Component) stats(sub subscription) error {
 	return nil
 }
 
+func reconnectCountInc(queue string) {
+	reconnects.WithLabelValues(queue).Inc()
+}
+
 func messageCountInc(queue string, state messageState, err error) {
 	hasError := "false"
 	if err != nil {
@@ -2,10 +2,19 @@ package amqp
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -58,6 +67,15 @@ func TestNew(t *testing.T) {
 			},
 			expectedErr: "process function is nil",
 		},
+		"prefetch less than batch count": {
+			args: args{
+				url:   "url",
+				queue: "queue",
+				proc:  proc,
+				oo:    []OptionFunc{Batching(5, 5*time.Millisecond), Prefetch(4)},
+			},
+			expectedErr: "prefetch count should not be less than the batch count",
+		},
 		"batching option fails": {
 			args: args{
 				url:   "url",
@@ -67,6 +85,41 @@ func TestNew(t *testing.T) {
 			},
 			expectedErr: "count should be larger than 1 message",
 		},
+		"failure strategy with max attempts": {
+			args: args{
+				url:   "url",
+				queue: "queue",
+				proc:  proc,
+				oo:    []OptionFunc{Failure(patronfailure.SkipStrategy()), MaxAttempts(3)},
+			},
+			expectedErr: "failure strategy cannot be combined with max attempts",
+		},
+		"failure strategy with requeue and max attempts": {
+			args: args{
+				url:   "url",
+				queue: "queue",
+				proc:  proc,
+				oo:    []OptionFunc{Requeue(false), MaxAttempts(3), Failure(patronfailure.SkipStrategy())},
+			},
+			expectedErr: "failure strategy cannot be combined with requeue, max attempts",
+		},
+		"failure strategy without dead letter exchange": {
+			args: args{
+				url:   "url",
+				queue: "queue",
+				proc:  proc,
+				oo:    []OptionFunc{Failure(patronfailure.DeadLetterStrategy())},
+			},
+			expectedErr: "failure strategy requires a dead letter exchange",
+		},
+		"failure strategy with dead letter exchange": {
+			args: args{
+				url:   "url",
+				queue: "queue",
+				proc:  proc,
+				oo:    []OptionFunc{Failure(patronfailure.DeadLetterStrategy()), DeadLetterExchange("dlx", "")},
+			},
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -82,3 +135,202 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+// subscriptions returns a subscribe function which returns the subscriptions or errors in order, failing once they are exhausted.
+func subscriptions(ss ...interface{}) (func() (subscription, error), func() int) {
+	var mu sync.Mutex
+	calls := 0
+	subscribe := func() (subscription, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls > len(ss) {
+			return subscription{}, errors.New("no more subscriptions")
+		}
+		switch s := ss[calls-1].(type) {
+		case error:
+			return subscription{}, s
+		default:
+			return subscription{deliveries: s.(chan amqp.Delivery)}, nil
+		}
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+	return subscribe, count
+}
+
+func TestComponent_Run_Reconnect(t *testing.T) {
+	queue := "reconnect-queue"
+	received := make(chan struct{}, 2)
+	proc := func(context.Context, Batch) {
+		received <- struct{}{}
+	}
+	cmp, err := New("url", queue, proc, Retry(3, time.Millisecond), StatsInterval(time.Hour))
+	require.NoError(t, err)
+
+	lost := make(chan amqp.Delivery, 1)
+	lost <- amqp.Delivery{}
+	close(lost)
+	restored := make(chan amqp.Delivery, 1)
+	restored <- amqp.Delivery{}
+	var count func() int
+	cmp.subscribeFunc, count = subscriptions(lost, errors.New("dial error"), restored)
+	reconnectsBefore := testutil.ToFloat64(reconnects.WithLabelValues(queue))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	<-received
+	<-received
+	cancel()
+	assert.NoError(t, <-chDone)
+	assert.Equal(t, 3, count())
+	assert.Equal(t, 2.0, testutil.ToFloat64(reconnects.WithLabelValues(queue))-reconnectsBefore)
+}
+
+func TestComponent_Run_RetriesExhausted(t *testing.T) {
+	cmp, err := New("url", "queue", func(context.Context, Batch) {}, Retry(2, time.Millisecond))
+	require.NoError(t, err)
+	var count func() int
+	cmp.subscribeFunc, count = subscriptions(errors.New("dial error"), errors.New("dial error"))
+
+	assert.EqualError(t, cmp.Run(context.Background()), "failed to reconnect to queue queue after 2 retries: dial error")
+	assert.Equal(t, 2, count())
+}
+
+func TestComponent_Run_NoRetries(t *testing.T) {
+	cmp, err := New("url", "queue", func(context.Context, Batch) {}, Retry(0, time.Millisecond))
+	require.NoError(t, err)
+	var count func() int
+	cmp.subscribeFunc, count = subscriptions(errors.New("dial error"))
+
+	err = cmp.Run(context.Background())
+	assert.EqualError(t, err, "failed to subscribe to queue queue: retry count is 0")
+	assert.True(t, errors.Is(err, errNoRetries))
+	assert.Equal(t, 0, count())
+}
+
+func TestComponent_Run_CancelledWhileWaiting(t *testing.T) {
+	cmp, err := New("url", "queue", func(context.Context, Batch) {}, Retry(2, time.Hour))
+	require.NoError(t, err)
+	cmp.subscribeFunc, _ = subscriptions(errors.New("dial error"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, cmp.Run(ctx))
+}
+
+func TestComponent_nextRetryDelay(t *testing.T) {
+	tests := map[string]struct {
+		delay    time.Duration
+		maxDelay time.Duration
+		current  time.Duration
+		expected time.Duration
+	}{
+		"doubled":                  {delay: time.Second, maxDelay: time.Minute, current: 2 * time.Second, expected: 4 * time.Second},
+		"capped at max":            {delay: time.Second, maxDelay: time.Minute, current: 40 * time.Second, expected: time.Minute},
+		"initial greater than max": {delay: 2 * time.Minute, maxDelay: time.Minute, current: 2 * time.Minute, expected: 2 * time.Minute},
+		"zero delay":               {delay: 0, maxDelay: time.Minute, current: 0, expected: 0},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := &Component{retryCfg: retryConfig{delay: tt.delay, maxDelay: tt.maxDelay}}
+			assert.Equal(t, tt.expected, c.nextRetryDelay(tt.current))
+		})
+	}
+}
+
+func Test_subscription_closeError(t *testing.T) {
+	sub := subscription{notifyClose: make(chan *amqp.Error, 1)}
+	assert.EqualError(t, sub.closeError(), "subscription channel closed")
+	sub.notifyClose <- amqp.ErrClosed
+	assert.EqualError(t, sub.closeError(), "subscription channel closed: Exception (504) Reason: \"channel/connection is not open\"")
+}
+
+func TestComponent_Run_Batching(t *testing.T) {
+	batches := make(chan int, 10)
+	proc := func(_ context.Context, b Batch) {
+		batches <- len(b.Messages())
+	}
+	cmp, err := New("url", "batching-queue", proc, Batching(3, 50*time.Millisecond), StatsInterval(time.Hour))
+	require.NoError(t, err)
+	deliveries := make(chan amqp.Delivery)
+	cmp.subscribeFunc, _ = subscriptions(deliveries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	// a full batch is processed right away
+	for i := 0; i < 3; i++ {
+		deliveries <- amqp.Delivery{}
+	}
+	assert.Equal(t, 3, <-batches)
+
+	// a partial batch is processed once its first message has waited for the timeout
+	start := time.Now()
+	deliveries <- amqp.Delivery{}
+	time.Sleep(20 * time.Millisecond)
+	deliveries <- amqp.Delivery{}
+	assert.Equal(t, 2, <-batches)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(50*time.Millisecond))
+
+	// empty batches are not processed
+	select {
+	case size := <-batches:
+		assert.Fail(t, "unexpected batch", "size %d", size)
+	case <-time.After(120 * time.Millisecond):
+	}
+
+	cancel()
+	assert.NoError(t, <-chDone)
+}
+
+func TestComponent_createMessage(t *testing.T) {
+	defer mockTracer.Reset()
+	correlation.PropagateHeaders("X-Tenant")
+	defer correlation.PropagateHeaders()
+
+	cmp, err := New("url", queueName, func(context.Context, Batch) {})
+	require.NoError(t, err)
+
+	parent := mockTracer.StartSpan("publish")
+	headers := amqp.Table{correlation.HeaderID: "corID", "X-Tenant": "acme"}
+	require.NoError(t, mockTracer.Inject(parent.Context(), opentracing.TextMap, amqpTestCarrier(headers)))
+
+	msg := cmp.createMessage(context.Background(), amqp.Delivery{Headers: headers}, nil)
+	assert.Equal(t, "corID", correlation.IDFromContext(msg.Context()))
+	assert.Equal(t, map[string]string{"X-Tenant": "acme"}, correlation.HeadersFromContext(msg.Context()))
+	sp, ok := msg.Span().(*mocktracer.MockSpan)
+	require.True(t, ok)
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.TraceID, sp.SpanContext.TraceID, "the consumer span joins the trace of the publisher")
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, sp.ParentID)
+}
+
+type amqpTestCarrier amqp.Table
+
+func (c amqpTestCarrier) Set(key, val string) {
+	c[key] = val
+}
+
+func TestComponent_Run_FailureStop(t *testing.T) {
+	proc := func(_ context.Context, b Batch) {
+		_, _ = b.NACK()
+	}
+	cmp, err := New("url", "stop-queue", proc, Failure(patronfailure.StopStrategy()), StatsInterval(time.Hour))
+	require.NoError(t, err)
+	deliveries := make(chan amqp.Delivery, 1)
+	deliveries <- amqp.Delivery{MessageId: "1", Acknowledger: &recordingAcknowledger{}}
+	cmp.subscribeFunc, _ = subscriptions(deliveries)
+
+	assert.EqualError(t, cmp.Run(context.Background()), "component stopped by the failure strategy: message 1 failed processing on attempt 1: message was negatively acknowledged")
+}
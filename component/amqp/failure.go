@@ -0,0 +1,200 @@
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/streadway/amqp"
+)
+
+// Headers added to the messages which are requeued with the max attempts or published to the dead letter exchange,
+// next to their original headers.
+const (
+	// AttemptHeader contains the number of times the message has failed processing, starting from 1.
+	AttemptHeader = "attempt"
+	// DeadLetterQueueHeader contains the queue the message was consumed from.
+	DeadLetterQueueHeader = "dead-letter-queue"
+)
+
+const (
+	requeuedOutcome     = "requeued"
+	deadLetteredOutcome = "dead-lettered"
+	droppedOutcome      = "dropped"
+	skippedOutcome      = "skipped"
+)
+
+// errStopped is the error of the component when the failure strategy stops it.
+var errStopped = errors.New("component stopped by the failure strategy")
+
+var failureOutcomes *prometheus.CounterVec
+
+func init() {
+	failureOutcomes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "amqp",
+			Name:      "failure_outcomes",
+			Help:      "Outcomes of the messages which were not acknowledged (requeued, dead-lettered, dropped, skipped), classified by queue",
+		},
+		[]string{"queue", "outcome"},
+	)
+	prometheus.MustRegister(failureOutcomes)
+}
+
+// publisher publishes the messages which are requeued with the max attempts or dead-lettered, which is the channel of the subscription.
+type publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+type failureConfig struct {
+	// the number of attempts before a requeued message is dead-lettered or dropped, unlimited when zero
+	maxAttempts uint
+	// the exchange and the routing key of the dead-lettered messages, which are dropped when the exchange is empty
+	exchange string
+	key      string
+	// the decisions about the messages which are not acknowledged, which replace the requeue policy and the max attempts when set
+	strategy patronfailure.Strategy
+	// the options of the failure handling which are replaced by the strategy, which were set
+	options []string
+	// the notifications of the failure strategy stopping the component
+	stop chan error
+}
+
+// nack handles a message which is not acknowledged according to the failure strategy, or else the requeue policy,
+// the max attempts and the dead letter exchange. A message which cannot be republished is requeued, so that it is not lost.
+func (m message) nack(err error) error {
+	attempt := attempts(m.msg.Headers) + 1
+	if m.failureCfg.strategy != nil {
+		return m.decide(attempt, err)
+	}
+	switch {
+	case m.requeue && m.failureCfg.maxAttempts == 0:
+		return m.outcome(requeuedOutcome, m.msg.Nack(false, true))
+	case m.requeue && attempt < m.failureCfg.maxAttempts:
+		// a requeued message keeps its headers, so it is published again with the number of its attempts
+		pub := publishing(m.msg, attempt)
+		return m.republish("", m.queue, pub, requeuedOutcome)
+	case m.failureCfg.exchange != "":
+		return m.deadLetter(attempt)
+	default:
+		return m.outcome(droppedOutcome, m.msg.Nack(false, false))
+	}
+}
+
+// decide handles a message which is not acknowledged according to the decision of the failure strategy about the processing
+// error. A message to retry is published to the tail of the queue after the delay, which blocks the processing in the meantime,
+// and a message which stops the component is requeued. The component is stopped as well when the decision cannot be applied.
+func (m message) decide(attempt uint, err error) error {
+	d := m.failureCfg.strategy.Decide(attempt, err)
+	switch d.Action {
+	case patronfailure.Retry:
+		if !m.wait(d.Delay) {
+			return m.outcome(requeuedOutcome, m.msg.Nack(false, true))
+		}
+		return m.republish("", m.queue, publishing(m.msg, attempt), requeuedOutcome)
+	case patronfailure.Skip:
+		return m.outcome(skippedOutcome, m.msg.Ack(false))
+	case patronfailure.DeadLetter:
+		if m.failureCfg.exchange == "" {
+			return m.stop(errors.New("dead letter action requires a dead letter exchange"))
+		}
+		return m.deadLetter(attempt)
+	case patronfailure.Stop:
+		return m.stop(fmt.Errorf("message %s failed processing on attempt %d: %v", m.ID(), attempt, err))
+	default:
+		return m.stop(fmt.Errorf("unknown failure action: %v", d.Action))
+	}
+}
+
+// stop requeues the message and stops the component with the error.
+func (m message) stop(err error) error {
+	select {
+	case m.failureCfg.stop <- fmt.Errorf("%w: %v", errStopped, err):
+	default:
+	}
+	return m.outcome(requeuedOutcome, m.msg.Nack(false, true))
+}
+
+// wait waits for the delay, returning false if the context of the message is done in the meantime.
+func (m message) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+// deadLetter publishes the message to the dead letter exchange, with the queue it was consumed from.
+func (m message) deadLetter(attempt uint) error {
+	pub := publishing(m.msg, attempt)
+	pub.Headers[DeadLetterQueueHeader] = m.queue
+	key := m.failureCfg.key
+	if key == "" {
+		key = m.msg.RoutingKey
+	}
+	return m.republish(m.failureCfg.exchange, key, pub, deadLetteredOutcome)
+}
+
+// republish publishes the copy of the message and acknowledges the original one.
+func (m message) republish(exchange, key string, pub amqp.Publishing, outcome string) error {
+	if err := m.channel.Publish(exchange, key, false, false, pub); err != nil {
+		err = fmt.Errorf("failed to publish message to exchange %q with routing key %s: %w", exchange, key, err)
+		return patronerrors.Aggregate(err, m.outcome(requeuedOutcome, m.msg.Nack(false, true)))
+	}
+	return m.outcome(outcome, m.msg.Ack(false))
+}
+
+func (m message) outcome(outcome string, err error) error {
+	if err == nil {
+		failureOutcomes.WithLabelValues(m.queue, outcome).Inc()
+	}
+	return err
+}
+
+// attempts returns the number of times the message has failed processing before, according to its attempt header.
+func attempts(hh amqp.Table) uint {
+	switch v := hh[AttemptHeader].(type) {
+	case int32:
+		if v > 0 {
+			return uint(v)
+		}
+	case int64:
+		if v > 0 {
+			return uint(v)
+		}
+	}
+	return 0
+}
+
+// publishing copies the delivery to a message which is published again, with the number of its attempt.
+func publishing(d amqp.Delivery, attempt uint) amqp.Publishing {
+	headers := make(amqp.Table, len(d.Headers)+2)
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[AttemptHeader] = int32(attempt)
+
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		Expiration:      d.Expiration,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		UserId:          d.UserId,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+}
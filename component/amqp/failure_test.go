@@ -0,0 +1,261 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAcknowledger struct {
+	acked    bool
+	nacked   bool
+	requeued bool
+}
+
+func (r *recordingAcknowledger) Ack(uint64, bool) error {
+	r.acked = true
+	return nil
+}
+
+func (r *recordingAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	r.nacked = true
+	r.requeued = requeue
+	return nil
+}
+
+func (r *recordingAcknowledger) Reject(uint64, bool) error {
+	panic("implement me")
+}
+
+type stubPublisher struct {
+	err      error
+	exchange string
+	key      string
+	msg      *amqp.Publishing
+}
+
+func (s *stubPublisher) Publish(exchange, key string, _, _ bool, msg amqp.Publishing) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.exchange = exchange
+	s.key = key
+	s.msg = &msg
+	return nil
+}
+
+func Test_message_nack(t *testing.T) {
+	tests := map[string]struct {
+		requeue          bool
+		failureCfg       failureConfig
+		attempt          interface{}
+		publishErr       error
+		expectedOutcome  string
+		expectedAck      bool
+		expectedRequeue  bool
+		expectedExchange string
+		expectedKey      string
+		expectedAttempt  int32
+		expectedErr      string
+	}{
+		"requeue": {
+			requeue: true, expectedOutcome: requeuedOutcome, expectedRequeue: true,
+		},
+		"requeue with attempts left": {
+			requeue: true, failureCfg: failureConfig{maxAttempts: 3}, attempt: int32(1),
+			expectedOutcome: requeuedOutcome, expectedAck: true, expectedKey: queueName, expectedAttempt: 2,
+		},
+		"requeue with attempts exhausted": {
+			requeue: true, failureCfg: failureConfig{maxAttempts: 3}, attempt: int64(2),
+			expectedOutcome: droppedOutcome,
+		},
+		"requeue with attempts exhausted to dead letter exchange": {
+			requeue: true, failureCfg: failureConfig{maxAttempts: 1, exchange: "dlx", key: "failed"},
+			expectedOutcome: deadLetteredOutcome, expectedAck: true, expectedExchange: "dlx", expectedKey: "failed", expectedAttempt: 1,
+		},
+		"dead letter exchange with original routing key": {
+			failureCfg:      failureConfig{exchange: "dlx"},
+			expectedOutcome: deadLetteredOutcome, expectedAck: true, expectedExchange: "dlx", expectedKey: "orders.created", expectedAttempt: 1,
+		},
+		"drop": {
+			expectedOutcome: droppedOutcome,
+		},
+		"publish failure": {
+			failureCfg: failureConfig{exchange: "dlx"}, publishErr: errors.New("channel closed"),
+			expectedOutcome: requeuedOutcome, expectedRequeue: true,
+			expectedErr: "failed to publish message to exchange \"dlx\" with routing key orders.created: channel closed\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ack := &recordingAcknowledger{}
+			pub := &stubPublisher{err: tt.publishErr}
+			headers := amqp.Table{"key": "value"}
+			if tt.attempt != nil {
+				headers[AttemptHeader] = tt.attempt
+			}
+			m := createMessage("1", ack)
+			m.msg.Headers = headers
+			m.msg.RoutingKey = "orders.created"
+			m.msg.Body = []byte("body")
+			m.queue = queueName
+			m.requeue = tt.requeue
+			m.failureCfg = tt.failureCfg
+			m.channel = pub
+			before := testutil.ToFloat64(failureOutcomes.WithLabelValues(queueName, tt.expectedOutcome))
+
+			err := m.nack(errors.New("processing error"))
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, 1.0, testutil.ToFloat64(failureOutcomes.WithLabelValues(queueName, tt.expectedOutcome))-before)
+			assert.Equal(t, tt.expectedAck, ack.acked)
+			assert.Equal(t, !tt.expectedAck, ack.nacked)
+			assert.Equal(t, tt.expectedRequeue, ack.requeued)
+			if tt.expectedAttempt == 0 {
+				assert.Nil(t, pub.msg)
+				return
+			}
+			assert.Equal(t, tt.expectedExchange, pub.exchange)
+			assert.Equal(t, tt.expectedKey, pub.key)
+			assert.Equal(t, []byte("body"), pub.msg.Body)
+			assert.Equal(t, "value", pub.msg.Headers["key"])
+			assert.Equal(t, tt.expectedAttempt, pub.msg.Headers[AttemptHeader])
+			if tt.expectedExchange != "" {
+				assert.Equal(t, queueName, pub.msg.Headers[DeadLetterQueueHeader])
+			}
+		})
+	}
+}
+
+func Test_message_nack_Strategy(t *testing.T) {
+	retry, err := patronfailure.RetryStrategy(3, patronfailure.ConstantBackoff(time.Millisecond), patronfailure.DeadLetter)
+	require.NoError(t, err)
+	tests := map[string]struct {
+		strategy         patronfailure.Strategy
+		exchange         string
+		attempt          interface{}
+		expectedOutcome  string
+		expectedAck      bool
+		expectedRequeue  bool
+		expectedExchange string
+		expectedKey      string
+		expectedAttempt  int32
+		expectedStop     bool
+	}{
+		"retry": {
+			strategy: retry, attempt: int32(1),
+			expectedOutcome: requeuedOutcome, expectedAck: true, expectedKey: queueName, expectedAttempt: 2,
+		},
+		"retries exhausted": {
+			strategy: retry, exchange: "dlx", attempt: int32(2),
+			expectedOutcome: deadLetteredOutcome, expectedAck: true, expectedExchange: "dlx", expectedKey: "orders.created", expectedAttempt: 3,
+		},
+		"skip": {
+			strategy: patronfailure.SkipStrategy(), expectedOutcome: skippedOutcome, expectedAck: true,
+		},
+		"dead letter without exchange": {
+			strategy: patronfailure.DeadLetterStrategy(), expectedOutcome: requeuedOutcome, expectedRequeue: true, expectedStop: true,
+		},
+		"stop": {
+			strategy: patronfailure.StopStrategy(), expectedOutcome: requeuedOutcome, expectedRequeue: true, expectedStop: true,
+		},
+		"unknown action": {
+			strategy: patronfailure.StrategyFunc(func(uint, error) patronfailure.Decision {
+				return patronfailure.Decision{Action: patronfailure.Action(9)}
+			}),
+			expectedOutcome: requeuedOutcome, expectedRequeue: true, expectedStop: true,
+		},
+		"decision on the processing error": {
+			strategy: patronfailure.StrategyFunc(func(_ uint, err error) patronfailure.Decision {
+				if err.Error() == "processing error" {
+					return patronfailure.Decision{Action: patronfailure.Skip}
+				}
+				return patronfailure.Decision{Action: patronfailure.Stop}
+			}),
+			expectedOutcome: skippedOutcome, expectedAck: true,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ack := &recordingAcknowledger{}
+			pub := &stubPublisher{}
+			m := createMessage("1", ack)
+			m.msg.Headers = amqp.Table{}
+			if tt.attempt != nil {
+				m.msg.Headers[AttemptHeader] = tt.attempt
+			}
+			m.msg.RoutingKey = "orders.created"
+			m.queue = queueName
+			m.failureCfg = failureConfig{strategy: tt.strategy, exchange: tt.exchange, stop: make(chan error, 1)}
+			m.channel = pub
+			before := testutil.ToFloat64(failureOutcomes.WithLabelValues(queueName, tt.expectedOutcome))
+
+			err := m.nack(errors.New("processing error"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1.0, testutil.ToFloat64(failureOutcomes.WithLabelValues(queueName, tt.expectedOutcome))-before)
+			assert.Equal(t, tt.expectedAck, ack.acked)
+			assert.Equal(t, tt.expectedRequeue, ack.requeued)
+			if tt.expectedStop {
+				assert.True(t, errors.Is(<-m.failureCfg.stop, errStopped))
+			} else {
+				assert.Empty(t, m.failureCfg.stop)
+			}
+			if tt.expectedAttempt == 0 {
+				assert.Nil(t, pub.msg)
+				return
+			}
+			assert.Equal(t, tt.expectedExchange, pub.exchange)
+			assert.Equal(t, tt.expectedKey, pub.key)
+			assert.Equal(t, tt.expectedAttempt, pub.msg.Headers[AttemptHeader])
+		})
+	}
+}
+
+func Test_message_NACKWithError(t *testing.T) {
+	var decided []error
+	strategy := patronfailure.StrategyFunc(func(_ uint, err error) patronfailure.Decision {
+		decided = append(decided, err)
+		return patronfailure.Decision{Action: patronfailure.Skip}
+	})
+	errProcess := errors.New("processing error")
+
+	m := createMessage("1", &recordingAcknowledger{})
+	m.failureCfg = failureConfig{strategy: strategy}
+	assert.NoError(t, m.NACKWithError(errProcess))
+	m = createMessage("2", &recordingAcknowledger{})
+	m.failureCfg = failureConfig{strategy: strategy}
+	assert.NoError(t, m.NACK())
+
+	assert.Equal(t, []error{errProcess, patronfailure.ErrNACKed}, decided)
+}
+
+func Test_message_nack_Strategy_Cancelled(t *testing.T) {
+	ack := &recordingAcknowledger{}
+	pub := &stubPublisher{}
+	m := createMessage("1", ack)
+	ctx, cancel := context.WithCancel(m.ctx)
+	cancel()
+	m.ctx = ctx
+	m.failureCfg = failureConfig{strategy: patronfailure.StrategyFunc(func(uint, error) patronfailure.Decision {
+		return patronfailure.Decision{Action: patronfailure.Retry, Delay: time.Hour}
+	})}
+	m.channel = pub
+
+	assert.NoError(t, m.nack(errors.New("processing error")))
+	assert.True(t, ack.requeued, "the message is requeued when the component stops while waiting to retry it")
+	assert.Nil(t, pub.msg)
+}
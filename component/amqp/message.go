@@ -2,7 +2,9 @@ package amqp
 
 import (
 	"context"
+	"time"
 
+	patronfailure "github.com/beatlabs/patron/component/failure"
 	patronerrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
@@ -24,10 +26,18 @@ type Message interface {
 	Span() opentracing.Span
 	// ACK deletes the message from the queue and completes the tracing span.
 	ACK() error
-	// NACK leaves the message in the queue and completes the tracing span.
+	// NACK leaves the message in the queue, or publishes it to the dead letter exchange, or drops it,
+	// according to the failure options of the component, and completes the tracing span.
 	NACK() error
 }
 
+// ErrorNACKer is implemented by the messages of the component, which can be negatively acknowledged along with the
+// processing error, e.g. msg.(amqp.ErrorNACKer).NACKWithError(err), so that the failure strategy decides with it.
+type ErrorNACKer interface {
+	// NACKWithError negatively acknowledges the message like NACK, passing the processing error to the failure strategy.
+	NACKWithError(err error) error
+}
+
 // Batch interface for multiple AWS SQS messages.
 type Batch interface {
 	// Messages of the batch.
@@ -46,6 +56,9 @@ type message struct {
 	msg     amqp.Delivery
 	queue   string
 	requeue bool
+	// handling of the messages which are not acknowledged
+	failureCfg failureConfig
+	channel    publisher
 }
 
 func (m message) Context() context.Context {
@@ -76,14 +89,23 @@ func (m message) ACK() error {
 }
 
 func (m message) NACK() error {
-	err := m.msg.Nack(false, m.requeue)
-	messageCountInc(m.queue, nackMessageState, err)
-	trace.SpanComplete(m.span, err)
-	return err
+	return m.NACKWithError(nil)
+}
+
+func (m message) NACKWithError(err error) error {
+	if err == nil {
+		err = patronfailure.ErrNACKed
+	}
+	nackErr := m.nack(err)
+	messageCountInc(m.queue, nackMessageState, nackErr)
+	trace.SpanComplete(m.span, nackErr)
+	return nackErr
 }
 
 type batch struct {
 	messages []Message
+	// time the first message of the batch was received
+	start time.Time
 }
 
 func (b *batch) ACK() ([]Message, error) {
@@ -1,9 +1,12 @@
 package amqp
 
 import (
+	"crypto/tls"
 	"errors"
 	"time"
 
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/internal/amqp/dial"
 	"github.com/streadway/amqp"
 )
 
@@ -27,7 +30,10 @@ func Batching(count uint, timeout time.Duration) OptionFunc {
 	}
 }
 
-// Retry option for setting up retries.
+// Retry option for setting up the retries of the subscription to the queue, which is reestablished when the connection
+// or the channel is lost. The delay between the attempts starts from the delay and doubles on every attempt,
+// up to the one set with the MaxRetryDelay option. The retries are reset once the component subscribes successfully.
+// With a count of 0 the component does not subscribe at all, returning an error when it runs.
 func Retry(count uint, delay time.Duration) OptionFunc {
 	return func(c *Component) error {
 		c.retryCfg.count = count
@@ -36,10 +42,67 @@ func Retry(count uint, delay time.Duration) OptionFunc {
 	}
 }
 
-// Config option for setting AMQP configuration.
+// MaxRetryDelay option for setting the maximum delay between the retries, which is 1 minute by default.
+func MaxRetryDelay(delay time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if delay <= 0 {
+			return errors.New("max retry delay should be a positive number")
+		}
+		c.retryCfg.maxDelay = delay
+		return nil
+	}
+}
+
+// Config option for setting AMQP configuration. The TLS configuration, the heartbeat and the connection name
+// set by the TLS, Heartbeat and ConnectionName options are kept, unless they are set in the configuration as well.
 func Config(cfg amqp.Config) OptionFunc {
 	return func(c *Component) error {
-		c.cfg = cfg
+		c.cfg = dial.MergeConfig(cfg, c.cfg)
+		return nil
+	}
+}
+
+// TLS option for setting the TLS configuration of the connection, which is used with the amqps scheme of the URL.
+func TLS(cfg *tls.Config) OptionFunc {
+	return func(c *Component) error {
+		if cfg == nil {
+			return errors.New("TLS config is nil")
+		}
+		c.cfg.TLSClientConfig = cfg
+		return nil
+	}
+}
+
+// Heartbeat option for setting the interval of the heartbeats which detect the loss of the connection, which is 10s by default.
+func Heartbeat(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("heartbeat interval should be a positive number")
+		}
+		c.cfg.Heartbeat = interval
+		return nil
+	}
+}
+
+// ConnectionName option for setting the name of the connection, which is shown in the management UI of the broker.
+func ConnectionName(name string) OptionFunc {
+	return func(c *Component) error {
+		if name == "" {
+			return errors.New("connection name is empty")
+		}
+		dial.WithConnectionName(&c.cfg, name)
+		return nil
+	}
+}
+
+// Prefetch option for limiting the number of unacknowledged messages the broker delivers to the component,
+// which should not be less than the batch count, since the batches are acknowledged once they are processed.
+func Prefetch(count int) OptionFunc {
+	return func(c *Component) error {
+		if count <= 0 {
+			return errors.New("prefetch count should be a positive number")
+		}
+		c.queueCfg.prefetch = count
 		return nil
 	}
 }
@@ -56,9 +119,54 @@ func StatsInterval(interval time.Duration) OptionFunc {
 }
 
 // Requeue option for adjusting the requeue policy of a message.
+// The messages which are not requeued are published to the exchange of the DeadLetterExchange option, or else dropped.
 func Requeue(requeue bool) OptionFunc {
 	return func(c *Component) error {
 		c.queueCfg.requeue = requeue
+		c.failureCfg.options = append(c.failureCfg.options, "requeue")
+		return nil
+	}
+}
+
+// MaxAttempts option for limiting the number of times a requeued message is processed. The message is published to the tail
+// of the queue again, along with the number of its attempts in the AttemptHeader, and once the attempts are exhausted,
+// it is published to the exchange of the DeadLetterExchange option, or else dropped.
+func MaxAttempts(attempts uint) OptionFunc {
+	return func(c *Component) error {
+		if attempts == 0 {
+			return errors.New("max attempts should be a positive number")
+		}
+		c.failureCfg.maxAttempts = attempts
+		c.failureCfg.options = append(c.failureCfg.options, "max attempts")
+		return nil
+	}
+}
+
+// Failure option for deciding how the messages which are not acknowledged are handled with the strategy, in place of
+// the Requeue and MaxAttempts options, which cannot be combined with it. The strategy decides with the number of the attempt
+// of the message, according to its AttemptHeader, and the processing error passed to ErrorNACKer.NACKWithError.
+// A message to retry is published to the tail of the queue after the delay of the decision, and the failure.DeadLetter action
+// requires the DeadLetterExchange option.
+func Failure(s patronfailure.Strategy) OptionFunc {
+	return func(c *Component) error {
+		if s == nil {
+			return errors.New("failure strategy is nil")
+		}
+		c.failureCfg.strategy = s
+		return nil
+	}
+}
+
+// DeadLetterExchange option for publishing the messages which are not requeued to the exchange with the routing key,
+// or with their original routing key if it is empty. The messages keep their headers, and contain the queue they were
+// consumed from in the DeadLetterQueueHeader.
+func DeadLetterExchange(exchange, routingKey string) OptionFunc {
+	return func(c *Component) error {
+		if exchange == "" {
+			return errors.New("dead letter exchange is empty")
+		}
+		c.failureCfg.exchange = exchange
+		c.failureCfg.key = routingKey
 		return nil
 	}
 }
@@ -1,9 +1,11 @@
 package amqp
 
 import (
+	"crypto/tls"
 	"testing"
 	"time"
 
+	patronfailure "github.com/beatlabs/patron/component/failure"
 	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,6 +54,13 @@ func TestRetry(t *testing.T) {
 	assert.Equal(t, retryDelay, c.retryCfg.delay)
 }
 
+func TestMaxRetryDelay(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, MaxRetryDelay(0)(c), "max retry delay should be a positive number")
+	assert.NoError(t, MaxRetryDelay(time.Minute)(c))
+	assert.Equal(t, time.Minute, c.retryCfg.maxDelay)
+}
+
 func TestRequeue(t *testing.T) {
 	c := &Component{}
 	assert.NoError(t, Requeue(false)(c))
@@ -82,3 +91,50 @@ func TestStatsInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxAttempts(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, MaxAttempts(0)(c), "max attempts should be a positive number")
+	assert.NoError(t, MaxAttempts(3)(c))
+	assert.Equal(t, uint(3), c.failureCfg.maxAttempts)
+}
+
+func TestDeadLetterExchange(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, DeadLetterExchange("", "key")(c), "dead letter exchange is empty")
+	assert.NoError(t, DeadLetterExchange("dlx", "key")(c))
+	assert.Equal(t, failureConfig{exchange: "dlx", key: "key"}, c.failureCfg)
+}
+
+func TestFailure(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Failure(nil)(c), "failure strategy is nil")
+	assert.NoError(t, Failure(patronfailure.SkipStrategy())(c))
+	assert.NotNil(t, c.failureCfg.strategy)
+}
+
+func TestConnectionOptions(t *testing.T) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	c := &Component{}
+	assert.EqualError(t, TLS(nil)(c), "TLS config is nil")
+	assert.EqualError(t, Heartbeat(0)(c), "heartbeat interval should be a positive number")
+	assert.EqualError(t, ConnectionName("")(c), "connection name is empty")
+	assert.EqualError(t, Prefetch(0)(c), "prefetch count should be a positive number")
+
+	assert.NoError(t, TLS(tlsCfg)(c))
+	assert.NoError(t, Heartbeat(time.Second)(c))
+	assert.NoError(t, ConnectionName("orders-consumer")(c))
+	assert.NoError(t, Prefetch(50)(c))
+	assert.Equal(t, tlsCfg, c.cfg.TLSClientConfig)
+	assert.Equal(t, time.Second, c.cfg.Heartbeat)
+	assert.Equal(t, amqp.Table{"connection_name": "orders-consumer"}, c.cfg.Properties)
+	assert.Equal(t, 50, c.queueCfg.prefetch)
+
+	assert.NoError(t, Config(amqp.Config{Locale: "123"})(c))
+	assert.Equal(t, amqp.Config{
+		Locale:          "123",
+		Heartbeat:       time.Second,
+		TLSClientConfig: tlsCfg,
+		Properties:      amqp.Table{"connection_name": "orders-consumer"},
+	}, c.cfg, "the config keeps the connection options")
+}
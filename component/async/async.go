@@ -2,12 +2,11 @@
 package async
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
 	"github.com/beatlabs/patron/encoding"
-	"github.com/beatlabs/patron/encoding/json"
-	"github.com/beatlabs/patron/encoding/protobuf"
 )
 
 // FailStrategy type definition.
@@ -48,13 +47,13 @@ type Consumer interface {
 	Close() error
 }
 
-// DetermineDecoder determines the decoder based on the content type.
+// DetermineDecoder determines the decoder based on the content type, using the decoders registered in the encoding package.
 func DetermineDecoder(contentType string) (encoding.DecodeRawFunc, error) {
-	switch contentType {
-	case json.Type, json.TypeCharset:
-		return json.DecodeRaw, nil
-	case protobuf.Type, protobuf.TypeGoogle:
-		return protobuf.DecodeRaw, nil
+	_, dec, ok := encoding.Lookup(contentType)
+	if !ok {
+		return nil, fmt.Errorf("content header %s is unsupported", contentType)
 	}
-	return nil, fmt.Errorf("content header %s is unsupported", contentType)
+	return func(data []byte, v interface{}) error {
+		return dec(bytes.NewReader(data), v)
+	}, nil
 }
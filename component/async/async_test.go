@@ -1,11 +1,17 @@
 package async
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"testing"
 
+	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
 	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/encoding/yaml"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDetermineDecoder(t *testing.T) {
@@ -18,7 +24,9 @@ func TestDetermineDecoder(t *testing.T) {
 		wantErr bool
 	}{
 		{"success json", args{contentType: json.Type}, false},
+		{"success json with charset", args{contentType: json.TypeCharset}, false},
 		{"success protobuf", args{contentType: protobuf.Type}, false},
+		{"success yaml", args{contentType: yaml.Type}, false},
 		{"failure", args{contentType: "XXX"}, true},
 	}
 	for _, tt := range tests {
@@ -34,3 +42,21 @@ func TestDetermineDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestDetermineDecoder_Registered(t *testing.T) {
+	enc := func(v interface{}) ([]byte, error) {
+		return bytes.ToUpper([]byte(v.(string))), nil
+	}
+	dec := func(data io.Reader, v interface{}) error {
+		b, err := ioutil.ReadAll(data)
+		*(v.(*string)) = string(bytes.ToLower(b))
+		return err
+	}
+	require.NoError(t, encoding.Register("application/x-upper", enc, dec))
+
+	got, err := DetermineDecoder("application/x-upper")
+	require.NoError(t, err)
+	var v string
+	require.NoError(t, got([]byte("VALUE"), &v))
+	assert.Equal(t, "value", v)
+}
@@ -153,9 +153,11 @@ func ClaimMessage(ctx context.Context, msg *sarama.ConsumerMessage, d encoding.D
 
 	corID := getCorrelationID(msg.Headers)
 
+	hdr := mapHeader(msg.Headers)
 	sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, msg.Topic),
-		consumerComponent, corID, mapHeader(msg.Headers))
+		consumerComponent, corID, hdr)
 	ctxCh = correlation.ContextWithID(ctxCh, corID)
+	ctxCh = correlation.ContextWithHeaders(ctxCh, func(header string) string { return hdr[header] })
 	ctxCh = log.WithContext(ctxCh, log.Sub(map[string]interface{}{correlation.ID: corID}))
 
 	dec, err := determineDecoder(d, msg, sp)
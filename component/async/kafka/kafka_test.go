@@ -379,3 +379,27 @@ var process = func(counter *eventCounter, data *decodingTestData) func(message a
 		return nil
 	}
 }
+
+func TestClaimMessage_Propagation(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	producerSp := mtr.StartSpan("producer")
+	hdr := opentracing.TextMapCarrier{}
+	assert.NoError(t, mtr.Inject(producerSp.Context(), opentracing.TextMap, hdr))
+
+	cm := saramaConsumerMessage(`"value"`, &sarama.RecordHeader{Key: []byte(correlation.HeaderID), Value: []byte("corID")})
+	cm.Headers = append(cm.Headers, &sarama.RecordHeader{Key: []byte("X-Tenant-Id"), Value: []byte("tenant")})
+	for k, v := range hdr {
+		cm.Headers = append(cm.Headers, &sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
+	msg, err := ClaimMessage(context.Background(), cm, patronjson.DecodeRaw, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "corID", correlation.IDFromContext(msg.Context()))
+	assert.Equal(t, map[string]string{"X-Tenant-Id": "tenant"}, correlation.HeadersFromContext(msg.Context()))
+	sp := opentracing.SpanFromContext(msg.Context()).(*mocktracer.MockSpan)
+	assert.Equal(t, producerSp.(*mocktracer.MockSpan).SpanContext.TraceID, sp.SpanContext.TraceID, "the consumer span joins the trace of the producer")
+}
@@ -0,0 +1,211 @@
+// Package cron provides a component which runs jobs on a schedule, which is a cron expression or an interval.
+//
+// A job does not run while its previous run is still in progress, in which case the run is skipped. Every run has its own
+// tracing span, correlation ID and logger in its context, and its duration and outcome are reported as metrics.
+// Once the context of the component is done, no more runs start, and the runs in progress are waited for up to the shutdown timeout,
+// after which their context is cancelled.
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cronComponent = "cron"
+
+	defaultShutdownTimeout = 30 * time.Second
+
+	succeededRun = "succeeded"
+	failedRun    = "failed"
+	skippedRun   = "skipped"
+)
+
+var (
+	jobDuration *prometheus.HistogramVec
+	jobRuns     *prometheus.CounterVec
+)
+
+func init() {
+	jobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "cron",
+			Name:      "job_duration_seconds",
+			Help:      "Duration of the job runs, classified by component and job",
+		},
+		[]string{"component", "job"},
+	)
+	prometheus.MustRegister(jobDuration)
+	jobRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "cron",
+			Name:      "job_runs",
+			Help:      "Runs of the jobs (succeeded, failed, skipped), classified by component, job and status",
+		},
+		[]string{"component", "job", "status"},
+	)
+	prometheus.MustRegister(jobRuns)
+}
+
+// JobFunc definition of the function of a job, whose error fails the run.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       JobFunc
+	// whether a run of the job is in progress, which is set atomically
+	running int32
+}
+
+// start marks the job as running, returning false if a run is already in progress.
+func (j *job) start() bool {
+	return atomic.CompareAndSwapInt32(&j.running, 0, 1)
+}
+
+func (j *job) finish() {
+	atomic.StoreInt32(&j.running, 0)
+}
+
+// Component implementation of a cron component, which runs jobs on their schedule.
+type Component struct {
+	name            string
+	jobs            []*job
+	location        *time.Location
+	shutdownTimeout time.Duration
+}
+
+// New creates a new component with support for functional configuration, which requires at least one job.
+// The schedules are evaluated in the local time zone by default, and the shutdown timeout is 30 seconds.
+func New(name string, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("component name is empty")
+	}
+
+	cmp := &Component{
+		name:            name,
+		location:        time.Local,
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+
+	for _, optionFunc := range oo {
+		err := optionFunc(cmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cmp.jobs) == 0 {
+		return nil, errors.New("at least one job is required")
+	}
+
+	return cmp, nil
+}
+
+// Run runs the jobs on their schedule until the context is done, and then waits for the runs in progress
+// up to the shutdown timeout, returning an error if they did not finish in time.
+func (c *Component) Run(ctx context.Context) error {
+	// the runs are not cancelled along with the component, so that they can finish gracefully
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var schedulers, runs sync.WaitGroup
+	for _, j := range c.jobs {
+		schedulers.Add(1)
+		go func(j *job) {
+			defer schedulers.Done()
+			c.schedule(ctx, runCtx, j, &runs)
+		}(j)
+	}
+	schedulers.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		runs.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(c.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("jobs of cron component %s did not finish within the shutdown timeout of %v", c.name, c.shutdownTimeout)
+	}
+}
+
+// schedule starts the runs of the job at the times of its schedule until the context is done.
+func (c *Component) schedule(ctx, runCtx context.Context, j *job, runs *sync.WaitGroup) {
+	for {
+		next := j.schedule.Next(time.Now().In(c.location))
+		if next.IsZero() {
+			log.Warnf("job %s of cron component %s is not scheduled to run again", j.name, c.name)
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !j.start() {
+			jobRuns.WithLabelValues(c.name, j.name, skippedRun).Inc()
+			log.Warnf("job %s of cron component %s is skipped since its previous run is in progress", j.name, c.name)
+			continue
+		}
+		runs.Add(1)
+		go func() {
+			defer runs.Done()
+			defer j.finish()
+			c.run(runCtx, j)
+		}()
+	}
+}
+
+// run runs the job with its own span, correlation ID and logger, recovering from a panic as a failure.
+func (c *Component) run(ctx context.Context, j *job) {
+	corID := uuid.New().String()
+	sp, ctx := trace.ChildSpan(ctx, trace.ComponentOpName(cronComponent, j.name), cronComponent,
+		opentracing.Tag{Key: correlation.ID, Value: corID})
+	ctx = correlation.ContextWithID(ctx, corID)
+	ctx = log.WithContext(ctx, log.Sub(map[string]interface{}{correlation.ID: corID}))
+
+	start := time.Now()
+	err := execute(ctx, j.fn)
+	jobDuration.WithLabelValues(c.name, j.name).Observe(time.Since(start).Seconds())
+	trace.SpanComplete(sp, err)
+
+	if err != nil {
+		jobRuns.WithLabelValues(c.name, j.name, failedRun).Inc()
+		log.FromContext(ctx).Errorf("job %s of cron component %s failed: %v", j.name, c.name, err)
+		return
+	}
+	jobRuns.WithLabelValues(c.name, j.name, succeededRun).Inc()
+}
+
+func execute(ctx context.Context, fn JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
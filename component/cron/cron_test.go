@@ -0,0 +1,170 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTracer = mocktracer.New()
+
+func TestMain(m *testing.M) {
+	opentracing.SetGlobalTracer(mockTracer)
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	fn := func(context.Context) error { return nil }
+	tests := map[string]struct {
+		name        string
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":        {name: "cron", oo: []OptionFunc{Job("job", "@daily", fn), ShutdownTimeout(time.Second)}},
+		"missing name":   {oo: []OptionFunc{Job("job", "@daily", fn)}, expectedErr: "component name is empty"},
+		"missing jobs":   {name: "cron", expectedErr: "at least one job is required"},
+		"option failure": {name: "cron", oo: []OptionFunc{Job("job", "@often", fn)}, expectedErr: `invalid cron expression "@often" of job job: expected 5 fields but got 1`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.name, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, time.Local, got.location)
+			assert.Equal(t, time.Second, got.shutdownTimeout)
+		})
+	}
+}
+
+func TestComponent_Run(t *testing.T) {
+	mockTracer.Reset()
+	defer mockTracer.Reset()
+	runs := make(chan string, 10)
+	cmp, err := New("run", IntervalJob("succeeding", 10*time.Millisecond, func(ctx context.Context) error {
+		runs <- correlation.IDFromContext(ctx)
+		return nil
+	}), IntervalJob("failing", 10*time.Millisecond, func(context.Context) error {
+		return errors.New("job error")
+	}), IntervalJob("panicking", 10*time.Millisecond, func(context.Context) error {
+		panic("job panic")
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	first, second := <-runs, <-runs
+	assert.NotEmpty(t, first)
+	assert.NotEqual(t, first, second, "every run has its own correlation ID")
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(jobRuns.WithLabelValues("run", "panicking", failedRun)) > 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.NoError(t, <-chDone)
+
+	assert.GreaterOrEqual(t, testutil.ToFloat64(jobRuns.WithLabelValues("run", "succeeding", succeededRun)), 2.0)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(jobRuns.WithLabelValues("run", "failing", failedRun)), 1.0)
+	assert.Equal(t, 0.0, testutil.ToFloat64(jobRuns.WithLabelValues("run", "failing", succeededRun)))
+	assert.Greater(t, testutil.CollectAndCount(jobDuration), 0)
+
+	spans := mockTracer.FinishedSpans()
+	require.NotEmpty(t, spans)
+	for _, sp := range spans {
+		assert.Equal(t, cronComponent, sp.Tag("component"))
+		assert.Equal(t, sp.OperationName != "cron succeeding", sp.Tag("error"))
+	}
+}
+
+func TestComponent_Run_Overlap(t *testing.T) {
+	var running, maxRunning int32
+	unblock := make(chan struct{})
+	cmp, err := New("overlap", IntervalJob("slow", 5*time.Millisecond, func(context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if n > atomic.LoadInt32(&maxRunning) {
+			atomic.StoreInt32(&maxRunning, n)
+		}
+		<-unblock
+		return nil
+	}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(jobRuns.WithLabelValues("overlap", "slow", skippedRun)) >= 2
+	}, time.Second, 5*time.Millisecond)
+	close(unblock)
+	cancel()
+	assert.NoError(t, <-chDone)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning), "a job does not run while its previous run is in progress")
+}
+
+func TestComponent_Run_Shutdown(t *testing.T) {
+	tests := map[string]struct {
+		duration    time.Duration
+		expectedErr string
+	}{
+		"runs finish gracefully": {duration: 50 * time.Millisecond},
+		"shutdown timeout":       {duration: time.Hour, expectedErr: "jobs of cron component shutdown did not finish within the shutdown timeout of 100ms"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			started := make(chan struct{}, 1)
+			var finished, cancelled int32
+			cmp, err := New("shutdown", IntervalJob("job", time.Millisecond, func(ctx context.Context) error {
+				started <- struct{}{}
+				select {
+				case <-time.After(tt.duration):
+					atomic.StoreInt32(&finished, 1)
+				case <-ctx.Done():
+					atomic.StoreInt32(&cancelled, 1)
+				}
+				return nil
+			}), ShutdownTimeout(100*time.Millisecond))
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			chDone := make(chan error)
+			go func() {
+				chDone <- cmp.Run(ctx)
+			}()
+			<-started
+			cancel()
+
+			err = <-chDone
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Eventually(t, func() bool { return atomic.LoadInt32(&cancelled) == 1 }, time.Second, time.Millisecond,
+					"the runs are cancelled after the shutdown timeout")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&finished), "the runs in progress are not cancelled along with the component")
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Job option for running the function on the schedule of the cron expression, e.g. "0 3 * * *" for every day at 03:00.
+// The syntax of the expression is described in Parse.
+func Job(name, expr string, fn JobFunc) OptionFunc {
+	return func(c *Component) error {
+		s, err := Parse(expr)
+		if err != nil {
+			return fmt.Errorf("invalid cron expression %q of job %s: %w", expr, name, err)
+		}
+		return c.addJob(name, s, fn)
+	}
+}
+
+// IntervalJob option for running the function at the interval, starting one interval after the component runs.
+// The interval is measured between the starts of the runs, unless a run takes longer, in which case the following run is skipped.
+func IntervalJob(name string, interval time.Duration, fn JobFunc) OptionFunc {
+	return func(c *Component) error {
+		s, err := Every(interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval of job %s: %w", name, err)
+		}
+		return c.addJob(name, s, fn)
+	}
+}
+
+// ScheduledJob option for running the function on a custom schedule.
+func ScheduledJob(name string, s Schedule, fn JobFunc) OptionFunc {
+	return func(c *Component) error {
+		if s == nil {
+			return errors.New("schedule is nil")
+		}
+		return c.addJob(name, s, fn)
+	}
+}
+
+func (c *Component) addJob(name string, s Schedule, fn JobFunc) error {
+	if name == "" {
+		return errors.New("job name is empty")
+	}
+	if fn == nil {
+		return errors.New("job function is nil")
+	}
+	for _, j := range c.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %s is already registered", name)
+		}
+	}
+	c.jobs = append(c.jobs, &job{name: name, schedule: s, fn: fn})
+	return nil
+}
+
+// Location option for evaluating the cron expressions in the time zone of the location, e.g. time.UTC.
+func Location(loc *time.Location) OptionFunc {
+	return func(c *Component) error {
+		if loc == nil {
+			return errors.New("location is nil")
+		}
+		c.location = loc
+		return nil
+	}
+}
+
+// ShutdownTimeout option for setting how long the runs in progress are waited for once the component is stopped.
+func ShutdownTimeout(timeout time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if timeout <= 0 {
+			return errors.New("shutdown timeout should be a positive number")
+		}
+		c.shutdownTimeout = timeout
+		return nil
+	}
+}
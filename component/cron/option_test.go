@@ -0,0 +1,60 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobOptions(t *testing.T) {
+	fn := func(context.Context) error { return nil }
+	s, err := Every(time.Second)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		option      OptionFunc
+		expectedErr string
+	}{
+		"cron job":           {option: Job("job", "@hourly", fn)},
+		"interval job":       {option: IntervalJob("job", time.Minute, fn)},
+		"scheduled job":      {option: ScheduledJob("job", s, fn)},
+		"invalid expression": {option: Job("job", "* *", fn), expectedErr: `invalid cron expression "* *" of job job: expected 5 fields but got 2`},
+		"invalid interval":   {option: IntervalJob("job", 0, fn), expectedErr: "invalid interval of job job: interval should be a positive number"},
+		"missing schedule":   {option: ScheduledJob("job", nil, fn), expectedErr: "schedule is nil"},
+		"missing name":       {option: Job("", "@hourly", fn), expectedErr: "job name is empty"},
+		"missing function":   {option: Job("job", "@hourly", nil), expectedErr: "job function is nil"},
+		"duplicate name":     {option: IntervalJob("existing", time.Minute, fn), expectedErr: "job existing is already registered"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := &Component{jobs: []*job{{name: "existing"}}}
+			err := tt.option(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Len(t, c.jobs, 1)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, c.jobs, 2)
+			assert.Equal(t, "job", c.jobs[1].name)
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Location(nil)(c), "location is nil")
+	require.NoError(t, Location(time.UTC)(c))
+	assert.Equal(t, time.UTC, c.location)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, ShutdownTimeout(0)(c), "shutdown timeout should be a positive number")
+	require.NoError(t, ShutdownTimeout(time.Second)(c))
+	assert.Equal(t, time.Second, c.shutdownTimeout)
+}
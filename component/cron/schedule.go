@@ -0,0 +1,201 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next time a job runs after the given time, or the zero time if it does not run again.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// maxSearchYears limits the search of the next time of an expression which never matches, e.g. on the 30th of February.
+const maxSearchYears = 5
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field bounds of an expression
+type bounds struct {
+	name     string
+	min, max uint
+}
+
+var (
+	minuteBounds = bounds{name: "minute", min: 0, max: 59}
+	hourBounds   = bounds{name: "hour", min: 0, max: 23}
+	domBounds    = bounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = bounds{name: "month", min: 1, max: 12}
+	dowBounds    = bounds{name: "day of week", min: 0, max: 7}
+)
+
+// expression is the schedule of a cron expression, whose fields are the bitsets of the values they match.
+type expression struct {
+	minute, hour, dom, month, dow uint64
+	// whether the day of month and the day of week fields are unrestricted, in which case the day matches
+	// the other field, otherwise it matches either of them
+	domStar, dowStar bool
+}
+
+// Parse parses a cron expression with the five fields minute, hour, day of month, month and day of week, e.g. "*/15 9-17 * * 1-5".
+// The fields support the wildcard, lists, ranges and steps, and the day of week is between 0 and 7, where both 0 and 7 are Sunday.
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are also supported.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields but got %d", len(fields))
+	}
+
+	var e expression
+	var err error
+	if e.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if e.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if e.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, err
+	}
+	if e.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if e.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, err
+	}
+	// Sunday is both 0 and 7
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+	e.domStar = strings.HasPrefix(fields[2], "*")
+	e.dowStar = strings.HasPrefix(fields[4], "*")
+	return e, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps, e.g. 1,10-20/5,*/30, to the bitset of their values.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, uint(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			rng = part[:i]
+			s, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %s", b.name, part)
+			}
+			step = uint(s)
+		}
+
+		var lo, hi uint
+		switch {
+		case rng == "*":
+			lo, hi = b.min, b.max
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], b); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(bounds[1], b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field: %s", b.name, rng)
+			}
+		default:
+			v, err := parseValue(rng, b)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			// a value with a step is the start of a range up to the maximum, e.g. 5/15
+			if step > 1 {
+				hi = b.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s field: %s", b.name, s)
+	}
+	if uint(v) < b.min || uint(v) > b.max {
+		return 0, fmt.Errorf("%s value %d is out of the range %d-%d", b.name, v, b.min, b.max)
+	}
+	return uint(v), nil
+}
+
+// Next returns the first time after t which matches the expression, in the location of t.
+func (e expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !e.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if e.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (e expression) matchDay(t time.Time) bool {
+	dom := e.dom&(1<<uint(t.Day())) != 0
+	dow := e.dow&(1<<uint(t.Weekday())) != 0
+	if e.domStar || e.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// interval is the schedule of a job which runs at a fixed interval.
+type interval time.Duration
+
+// Every returns a schedule which runs a job at the interval, starting one interval after the component runs.
+func Every(d time.Duration) (Schedule, error) {
+	if d <= 0 {
+		return nil, errors.New("interval should be a positive number")
+	}
+	return interval(d), nil
+}
+
+// Next returns the time one interval after t.
+func (i interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
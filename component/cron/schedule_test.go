@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]struct {
+		expr        string
+		expectedErr string
+	}{
+		"missing fields":     {expr: "* * * *", expectedErr: "expected 5 fields but got 4"},
+		"unknown descriptor": {expr: "@often", expectedErr: "expected 5 fields but got 1"},
+		"invalid value":      {expr: "a * * * *", expectedErr: "invalid value in minute field: a"},
+		"out of range":       {expr: "* 24 * * *", expectedErr: "hour value 24 is out of the range 0-23"},
+		"zero day of month":  {expr: "* * 0 * *", expectedErr: "day of month value 0 is out of the range 1-31"},
+		"invalid range":      {expr: "* * * 10-2 *", expectedErr: "invalid range in month field: 10-2"},
+		"invalid step":       {expr: "*/0 * * * *", expectedErr: "invalid step in minute field: */0"},
+		"invalid day":        {expr: "* * * * 8", expectedErr: "day of week value 8 is out of the range 0-7"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			assert.EqualError(t, err, tt.expectedErr)
+			assert.Nil(t, s)
+		})
+	}
+}
+
+func TestExpression_Next(t *testing.T) {
+	// Wednesday
+	from := time.Date(2021, time.March, 10, 10, 17, 42, 0, time.UTC)
+	tests := map[string]struct {
+		expr     string
+		from     time.Time
+		expected time.Time
+	}{
+		"every minute":         {expr: "* * * * *", expected: time.Date(2021, time.March, 10, 10, 18, 0, 0, time.UTC)},
+		"step":                 {expr: "*/15 * * * *", expected: time.Date(2021, time.March, 10, 10, 30, 0, 0, time.UTC)},
+		"value with step":      {expr: "5/20 * * * *", expected: time.Date(2021, time.March, 10, 10, 25, 0, 0, time.UTC)},
+		"list":                 {expr: "0 8,12 * * *", expected: time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)},
+		"next day":             {expr: "0 9 * * *", expected: time.Date(2021, time.March, 11, 9, 0, 0, 0, time.UTC)},
+		"range of weekdays":    {expr: "0 9 * * 1-5", from: time.Date(2021, time.March, 12, 10, 0, 0, 0, time.UTC), expected: time.Date(2021, time.March, 15, 9, 0, 0, 0, time.UTC)},
+		"sunday as 7":          {expr: "0 0 * * 7", expected: time.Date(2021, time.March, 14, 0, 0, 0, 0, time.UTC)},
+		"day of month or week": {expr: "0 0 1 * 5", expected: time.Date(2021, time.March, 12, 0, 0, 0, 0, time.UTC)},
+		"next year":            {expr: "@yearly", expected: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		"leap day":             {expr: "0 0 29 2 *", expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		"never":                {expr: "0 0 30 2 *", expected: time.Time{}},
+		"end of month":         {expr: "0 0 31 * *", expected: time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			f := from
+			if !tt.from.IsZero() {
+				f = tt.from
+			}
+			assert.Equal(t, tt.expected, s.Next(f))
+		})
+	}
+}
+
+func TestExpression_Next_Location(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+1800)
+	s, err := Parse("0 9 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, time.March, 11, 9, 0, 0, 0, loc), s.Next(time.Date(2021, time.March, 10, 10, 0, 0, 0, loc)))
+}
+
+func TestEvery(t *testing.T) {
+	_, err := Every(0)
+	assert.EqualError(t, err, "interval should be a positive number")
+	s, err := Every(time.Minute)
+	require.NoError(t, err)
+	from := time.Date(2021, time.March, 10, 10, 17, 42, 0, time.UTC)
+	assert.Equal(t, from.Add(time.Minute), s.Next(from))
+}
@@ -0,0 +1,175 @@
+// Package failure provides the strategies which decide how the async components handle the messages that failed processing,
+// so that a failure policy is declared once and behaves the same regardless of the broker.
+//
+// The components apply the decisions with the means of their broker:
+//
+//   - Retry delivers the message again after the delay, starting a new attempt
+//   - Skip acknowledges the message, which is not delivered again
+//   - DeadLetter moves the message to the dead letter destination of the component, and acknowledges it
+//   - Stop does not acknowledge the message, and stops the component with an error
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNACKed is the processing error the strategies decide with, when a message is negatively acknowledged without an error.
+var ErrNACKed = errors.New("message was negatively acknowledged")
+
+// Action definition of what a component does with a message which failed processing.
+type Action int
+
+const (
+	// Stop does not acknowledge the message and stops the component.
+	Stop Action = iota
+	// Retry delivers the message again after the delay of the decision.
+	Retry
+	// Skip acknowledges the message and continues processing.
+	Skip
+	// DeadLetter moves the message to the dead letter destination, acknowledges it and continues processing.
+	DeadLetter
+)
+
+func (a Action) String() string {
+	switch a {
+	case Stop:
+		return "stop"
+	case Retry:
+		return "retry"
+	case Skip:
+		return "skip"
+	case DeadLetter:
+		return "dead-letter"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(a))
+	}
+}
+
+// Decision of a strategy about a message which failed processing.
+type Decision struct {
+	Action Action
+	// Delay before the message is delivered again, when the action is Retry.
+	Delay time.Duration
+}
+
+// Strategy decides what happens to a message which failed processing, given the number of its failed attempts,
+// starting from 1, and the processing error, which is ErrNACKed when the message is negatively acknowledged without an error.
+type Strategy interface {
+	Decide(attempt uint, err error) Decision
+}
+
+// Declarer is implemented by the strategies which declare the actions they can decide, so that the components validate
+// when they are created that they can apply them, e.g. that a dead letter destination is set. The strategies of this
+// package implement it, while the components stop when a strategy which does not implement it decides an action they cannot apply.
+type Declarer interface {
+	Actions() []Action
+}
+
+// Declares returns whether the strategy declares that it can decide the action.
+func Declares(s Strategy, a Action) bool {
+	d, ok := s.(Declarer)
+	if !ok {
+		return false
+	}
+	for _, action := range d.Actions() {
+		if action == a {
+			return true
+		}
+	}
+	return false
+}
+
+// StrategyFunc is an adapter which allows the use of a function as a strategy.
+type StrategyFunc func(attempt uint, err error) Decision
+
+// Decide calls f(attempt, err).
+func (f StrategyFunc) Decide(attempt uint, err error) Decision {
+	return f(attempt, err)
+}
+
+// StopStrategy stops the component on the first failure.
+func StopStrategy() Strategy {
+	return action(Stop)
+}
+
+// SkipStrategy skips the messages which failed processing.
+func SkipStrategy() Strategy {
+	return action(Skip)
+}
+
+// DeadLetterStrategy moves the messages which failed processing to the dead letter destination.
+func DeadLetterStrategy() Strategy {
+	return action(DeadLetter)
+}
+
+func action(a Action) Strategy {
+	return strategy{
+		decide: func(uint, error) Decision {
+			return Decision{Action: a}
+		},
+		actions: []Action{a},
+	}
+}
+
+// strategy is a strategy which declares its actions.
+type strategy struct {
+	decide  StrategyFunc
+	actions []Action
+}
+
+func (s strategy) Decide(attempt uint, err error) Decision {
+	return s.decide(attempt, err)
+}
+
+func (s strategy) Actions() []Action {
+	return s.actions
+}
+
+// RetryStrategy retries a message with the delays of the backoff, until it has failed the max attempts,
+// when the exhausted action is taken, which cannot be Retry.
+func RetryStrategy(maxAttempts uint, backoff Backoff, exhausted Action) (Strategy, error) {
+	if maxAttempts == 0 {
+		return nil, errors.New("max attempts should be a positive number")
+	}
+	if backoff == nil {
+		return nil, errors.New("backoff is nil")
+	}
+	if exhausted == Retry || exhausted < Stop || exhausted > DeadLetter {
+		return nil, fmt.Errorf("invalid exhausted action: %v", exhausted)
+	}
+	return strategy{
+		decide: func(attempt uint, _ error) Decision {
+			if attempt >= maxAttempts {
+				return Decision{Action: exhausted}
+			}
+			return Decision{Action: Retry, Delay: backoff(attempt)}
+		},
+		actions: []Action{Retry, exhausted},
+	}, nil
+}
+
+// Backoff returns the delay before the next attempt of a message, given the number of its failed attempts, starting from 1.
+type Backoff func(attempt uint) time.Duration
+
+// ConstantBackoff delays every attempt by the same delay.
+func ConstantBackoff(delay time.Duration) Backoff {
+	return func(uint) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff doubles the delay of every attempt, starting from the initial delay, up to the max delay.
+func ExponentialBackoff(initial, max time.Duration) Backoff {
+	return func(attempt uint) time.Duration {
+		delay := initial
+		for i := uint(1); i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			return max
+		}
+		return delay
+	}
+}
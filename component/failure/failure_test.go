@@ -0,0 +1,76 @@
+package failure
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategies(t *testing.T) {
+	err := errors.New("processing error")
+	assert.Equal(t, Decision{Action: Stop}, StopStrategy().Decide(1, err))
+	assert.Equal(t, Decision{Action: Skip}, SkipStrategy().Decide(1, err))
+	assert.Equal(t, Decision{Action: DeadLetter}, DeadLetterStrategy().Decide(3, nil))
+}
+
+func TestDeclares(t *testing.T) {
+	retry, err := RetryStrategy(3, ConstantBackoff(time.Second), DeadLetter)
+	require.NoError(t, err)
+
+	assert.True(t, Declares(DeadLetterStrategy(), DeadLetter))
+	assert.False(t, Declares(SkipStrategy(), DeadLetter))
+	assert.True(t, Declares(retry, Retry))
+	assert.True(t, Declares(retry, DeadLetter))
+	assert.False(t, Declares(retry, Stop))
+	assert.False(t, Declares(StrategyFunc(func(uint, error) Decision { return Decision{Action: DeadLetter} }), DeadLetter),
+		"the actions of a strategy func are not declared")
+}
+
+func TestRetryStrategy(t *testing.T) {
+	tests := map[string]struct {
+		maxAttempts uint
+		backoff     Backoff
+		exhausted   Action
+		expectedErr string
+	}{
+		"success":           {maxAttempts: 3, backoff: ConstantBackoff(time.Second), exhausted: DeadLetter},
+		"zero max attempts": {maxAttempts: 0, backoff: ConstantBackoff(time.Second), exhausted: DeadLetter, expectedErr: "max attempts should be a positive number"},
+		"missing backoff":   {maxAttempts: 3, exhausted: DeadLetter, expectedErr: "backoff is nil"},
+		"retry exhausted":   {maxAttempts: 3, backoff: ConstantBackoff(time.Second), exhausted: Retry, expectedErr: "invalid exhausted action: retry"},
+		"unknown exhausted": {maxAttempts: 3, backoff: ConstantBackoff(time.Second), exhausted: Action(10), expectedErr: "invalid exhausted action: unknown(10)"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			s, err := RetryStrategy(tt.maxAttempts, tt.backoff, tt.exhausted)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, s)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Decision{Action: Retry, Delay: time.Second}, s.Decide(1, nil))
+			assert.Equal(t, Decision{Action: Retry, Delay: time.Second}, s.Decide(2, nil))
+			assert.Equal(t, Decision{Action: DeadLetter}, s.Decide(3, nil))
+		})
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(time.Second, 5*time.Second)
+	assert.Equal(t, time.Second, b(1))
+	assert.Equal(t, 2*time.Second, b(2))
+	assert.Equal(t, 4*time.Second, b(3))
+	assert.Equal(t, 5*time.Second, b(4))
+	assert.Equal(t, 5*time.Second, b(100))
+}
+
+func TestAction_String(t *testing.T) {
+	assert.Equal(t, "stop", Stop.String())
+	assert.Equal(t, "retry", Retry.String())
+	assert.Equal(t, "skip", Skip.String())
+	assert.Equal(t, "dead-letter", DeadLetter.String())
+}
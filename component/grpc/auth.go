@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beatlabs/patron/component/grpc/auth"
+	"github.com/beatlabs/patron/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authenticators of the RPCs, either per method, per service or for all of them.
+type authenticators struct {
+	all      auth.Authenticator
+	services map[string]auth.Authenticator
+	methods  map[string]auth.Authenticator
+}
+
+func (aa *authenticators) add(a auth.Authenticator, scopes ...string) error {
+	if a == nil {
+		return errors.New("authenticator is nil")
+	}
+	if len(scopes) == 0 {
+		if aa.all != nil {
+			return errors.New("authenticator of all the RPCs is already set")
+		}
+		aa.all = a
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if scope == "" {
+			return errors.New("authentication scope is empty")
+		}
+		method, valid := scopeMethod(scope)
+		if !valid {
+			return fmt.Errorf("authentication scope %s is not a method", scope)
+		}
+		target := aa.services
+		if method {
+			target = aa.methods
+		}
+		if _, ok := target[scope]; ok {
+			return fmt.Errorf("authenticator of %s is already set", scope)
+		}
+		target[scope] = a
+	}
+	return nil
+}
+
+// scopeMethod returns whether the scope is a full method name, e.g. "/greeter.Greeter/SayHello", rather than a service name,
+// and whether it is valid, i.e. a full method name has both a service and a method.
+func scopeMethod(scope string) (method, valid bool) {
+	if !strings.HasPrefix(scope, "/") {
+		return false, true
+	}
+	return true, strings.Count(scope, "/") == 2 && !strings.HasSuffix(scope, "/")
+}
+
+// authenticator returns the authenticator of the method, which is the one of the method itself, or else of its service,
+// or else the one of all the RPCs, except for the ones of the health service, which are not authenticated by default.
+func (aa *authenticators) authenticator(fullMethodName string) auth.Authenticator {
+	if a, ok := aa.methods[fullMethodName]; ok {
+		return a
+	}
+	svc, _ := splitMethodName(fullMethodName)
+	if a, ok := aa.services[svc]; ok {
+		return a
+	}
+	if svc == grpc_health_v1.Health_ServiceDesc.ServiceName {
+		return nil
+	}
+	return aa.all
+}
+
+func (aa *authenticators) authenticate(ctx context.Context, fullMethodName string) (context.Context, error) {
+	a := aa.authenticator(fullMethodName)
+	if a == nil {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	authCtx, authenticated, err := a.Authenticate(ctx, md)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to authenticate %s: %v", fullMethodName, err)
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	if !authenticated {
+		return nil, status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
+	}
+	if authCtx == nil {
+		return ctx, nil
+	}
+	return authCtx, nil
+}
+
+func (aa *authenticators) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := aa.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (aa *authenticators) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := aa.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &observableServerStream{ServerStream: ss, ctx: ctx})
+}
@@ -0,0 +1,42 @@
+// Package apikey is a concrete implementation of the gRPC auth abstractions.
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beatlabs/patron/component/grpc/auth"
+	"google.golang.org/grpc/metadata"
+)
+
+// Validator interface for validating keys, which is satisfied by the validators of the HTTP API key authenticator as well.
+type Validator interface {
+	Validate(key string) (bool, error)
+}
+
+// Authenticator authenticates the RPC based on the metadata on the following key and value:
+// authorization: Apikey {api key}, where {api key} is the key.
+type Authenticator struct {
+	val Validator
+}
+
+// New constructor.
+func New(val Validator) (*Authenticator, error) {
+	if val == nil {
+		return nil, errors.New("validator is nil")
+	}
+	return &Authenticator{val: val}, nil
+}
+
+// Authenticate parses the metadata for the key and authenticates it.
+func (a *Authenticator) Authenticate(ctx context.Context, md metadata.MD) (context.Context, bool, error) {
+	key, ok := auth.Credentials(md, "apikey")
+	if !ok {
+		return nil, false, nil
+	}
+	valid, err := a.val.Validate(key)
+	if err != nil || !valid {
+		return nil, false, err
+	}
+	return ctx, true, nil
+}
@@ -0,0 +1,58 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+type mockValidator struct {
+	err error
+}
+
+func (mv mockValidator) Validate(key string) (bool, error) {
+	if mv.err != nil {
+		return false, mv.err
+	}
+	return key == "123456", nil
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.EqualError(t, err, "validator is nil")
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	tests := map[string]struct {
+		md          metadata.MD
+		val         Validator
+		expected    bool
+		expectedErr string
+	}{
+		"success":            {md: metadata.Pairs("authorization", "Apikey 123456"), val: mockValidator{}, expected: true},
+		"invalid key":        {md: metadata.Pairs("authorization", "Apikey 654321"), val: mockValidator{}},
+		"missing metadata":   {md: metadata.MD{}, val: mockValidator{}},
+		"missing key":        {md: metadata.Pairs("authorization", "Apikey"), val: mockValidator{}},
+		"invalid scheme":     {md: metadata.Pairs("authorization", "Bearer 123456"), val: mockValidator{}},
+		"validation failure": {md: metadata.Pairs("authorization", "Apikey 123456"), val: mockValidator{err: errors.New("store is down")}, expectedErr: "store is down"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			a, err := New(tt.val)
+			require.NoError(t, err)
+			ctx, ok, err := a.Authenticate(context.Background(), tt.md)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			assert.Equal(t, tt.expected, ctx != nil)
+		})
+	}
+}
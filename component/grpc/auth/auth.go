@@ -0,0 +1,30 @@
+// Package auth provides abstractions for authenticating the RPCs of the gRPC component, based on their metadata.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Authenticator authenticates an RPC based on its incoming metadata, returning the context of the RPC if it is authenticated,
+// e.g. enriched with the claims of a token, which is then used by the rest of the RPC handling.
+// Invalid credentials fail the authentication, while an error is returned only if the authentication could not be performed,
+// e.g. the keys could not be fetched.
+type Authenticator interface {
+	Authenticate(ctx context.Context, md metadata.MD) (context.Context, bool, error)
+}
+
+// Credentials returns the credentials of the "authorization" metadata with the scheme, e.g. "Bearer" for the value "Bearer {token}".
+// The scheme is compared case-insensitively.
+func Credentials(md metadata.MD, scheme string) (string, bool) {
+	for _, val := range md.Get("authorization") {
+		auth := strings.SplitN(val, " ", 2)
+		if len(auth) != 2 || !strings.EqualFold(auth[0], scheme) || auth[1] == "" {
+			continue
+		}
+		return auth[1], true
+	}
+	return "", false
+}
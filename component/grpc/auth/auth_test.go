@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCredentials(t *testing.T) {
+	tests := map[string]struct {
+		md            metadata.MD
+		expected      string
+		expectedFound bool
+	}{
+		"success":              {md: metadata.Pairs("authorization", "Bearer 123"), expected: "123", expectedFound: true},
+		"case-insensitive":     {md: metadata.Pairs("Authorization", "bearer 123"), expected: "123", expectedFound: true},
+		"second value":         {md: metadata.Pairs("authorization", "Apikey 456", "authorization", "Bearer 123"), expected: "123", expectedFound: true},
+		"missing metadata":     {md: metadata.MD{}},
+		"missing credentials":  {md: metadata.Pairs("authorization", "Bearer")},
+		"empty credentials":    {md: metadata.Pairs("authorization", "Bearer ")},
+		"other scheme":         {md: metadata.Pairs("authorization", "Apikey 123")},
+		"other metadata field": {md: metadata.Pairs("x-authorization", "Bearer 123")},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, ok := Credentials(tt.md, "Bearer")
+			assert.Equal(t, tt.expectedFound, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
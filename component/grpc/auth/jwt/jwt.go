@@ -0,0 +1,49 @@
+// Package jwt is a concrete implementation of the gRPC auth abstractions, which validates JSON Web Tokens
+// with the validation of the HTTP JWT authenticator.
+package jwt
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/beatlabs/patron/component/grpc/auth"
+	httpjwt "github.com/beatlabs/patron/component/http/auth/jwt"
+	"google.golang.org/grpc/metadata"
+)
+
+// ClaimsFromContext returns the claims of the token which authenticated the RPC.
+func ClaimsFromContext(ctx context.Context) (httpjwt.Claims, bool) {
+	return httpjwt.ClaimsFromContext(ctx)
+}
+
+// Authenticator authenticates the RPC based on a JSON Web Token in the metadata on the following key and value:
+// authorization: Bearer {token}.
+// It is configured with the options of the HTTP JWT authenticator, e.g. httpjwt.JWKS and httpjwt.Issuer.
+type Authenticator struct {
+	a *httpjwt.Authenticator
+}
+
+// New constructor. At least a key or a JWKS endpoint has to be provided.
+func New(oo ...httpjwt.OptionFunc) (*Authenticator, error) {
+	a, err := httpjwt.New(oo...)
+	if err != nil {
+		return nil, err
+	}
+	return &Authenticator{a: a}, nil
+}
+
+// Authenticate parses the metadata for the token and validates it,
+// returning a context with the claims of the token which can be retrieved with ClaimsFromContext.
+// Invalid tokens fail the authentication, while an error is returned only if the keys could not be fetched.
+func (a *Authenticator) Authenticate(ctx context.Context, md metadata.MD) (context.Context, bool, error) {
+	token, ok := auth.Credentials(md, "bearer")
+	if !ok {
+		return nil, false, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return a.a.AuthenticateContext(req)
+}
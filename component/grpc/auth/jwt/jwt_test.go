@@ -0,0 +1,67 @@
+package jwt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	httpjwt "github.com/beatlabs/patron/component/http/auth/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+var key = []byte("secret")
+
+func token(t *testing.T, claims map[string]interface{}) string {
+	hdr, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New()
+	assert.EqualError(t, err, "no keys or JWKS endpoint provided")
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	a, err := New(httpjwt.HMACKey(key), httpjwt.Issuer("patron"))
+	require.NoError(t, err)
+
+	valid := token(t, map[string]interface{}{"sub": "john", "iss": "patron", "exp": time.Now().Add(time.Hour).Unix()})
+	tests := map[string]struct {
+		md              metadata.MD
+		expected        bool
+		expectedSubject string
+	}{
+		"success":          {md: metadata.Pairs("authorization", "Bearer "+valid), expected: true, expectedSubject: "john"},
+		"expired":          {md: metadata.Pairs("authorization", "Bearer "+token(t, map[string]interface{}{"iss": "patron", "exp": time.Now().Add(-time.Hour).Unix()}))},
+		"invalid issuer":   {md: metadata.Pairs("authorization", "Bearer "+token(t, map[string]interface{}{"iss": "other"}))},
+		"malformed":        {md: metadata.Pairs("authorization", "Bearer abc")},
+		"missing metadata": {md: metadata.MD{}},
+		"invalid scheme":   {md: metadata.Pairs("authorization", "Apikey "+valid)},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ctx, ok, err := a.Authenticate(context.Background(), tt.md)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			if !tt.expected {
+				return
+			}
+			claims, ok := ClaimsFromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedSubject, claims.Subject())
+		})
+	}
+}
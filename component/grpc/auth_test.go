@@ -0,0 +1,150 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beatlabs/patron/component/grpc/auth"
+	"github.com/beatlabs/patron/examples"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type userKey struct{}
+
+// tokenAuthenticator authenticates the RPCs with the token, adding the user to their context.
+type tokenAuthenticator struct {
+	token string
+	err   error
+}
+
+func (a tokenAuthenticator) Authenticate(ctx context.Context, md metadata.MD) (context.Context, bool, error) {
+	if a.err != nil {
+		return nil, false, a.err
+	}
+	token, ok := auth.Credentials(md, "bearer")
+	if !ok || token != a.token {
+		return nil, false, nil
+	}
+	return context.WithValue(ctx, userKey{}, "user-"+token), true, nil
+}
+
+type authServer struct {
+	examples.UnimplementedGreeterServer
+}
+
+func (s *authServer) SayHello(ctx context.Context, _ *examples.HelloRequest) (*examples.HelloReply, error) {
+	user, _ := ctx.Value(userKey{}).(string)
+	return &examples.HelloReply{Message: "Hello " + user}, nil
+}
+
+func (s *authServer) SayHelloStream(_ *examples.HelloRequest, srv examples.Greeter_SayHelloStreamServer) error {
+	user, _ := srv.Context().Value(userKey{}).(string)
+	return srv.Send(&examples.HelloReply{Message: "Hello " + user})
+}
+
+func TestBuilder_WithAuthenticator(t *testing.T) {
+	a := tokenAuthenticator{token: "123"}
+
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success": {
+			builder: New(60000).WithAuthenticator(a).WithAuthenticator(a, "greeter.Greeter", "/greeter.Greeter/SayHello"),
+		},
+		"nil authenticator":      {builder: New(60000).WithAuthenticator(nil), expErr: "authenticator is nil\n"},
+		"empty scope":            {builder: New(60000).WithAuthenticator(a, ""), expErr: "authentication scope is empty\n"},
+		"invalid method":         {builder: New(60000).WithAuthenticator(a, "/greeter.Greeter/"), expErr: "authentication scope /greeter.Greeter/ is not a method\n"},
+		"duplicate all":          {builder: New(60000).WithAuthenticator(a).WithAuthenticator(a), expErr: "authenticator of all the RPCs is already set\n"},
+		"duplicate service":      {builder: New(60000).WithAuthenticator(a, "greeter.Greeter").WithAuthenticator(a, "greeter.Greeter"), expErr: "authenticator of greeter.Greeter is already set\n"},
+		"duplicate method scope": {builder: New(60000).WithAuthenticator(a, "/a.B/C", "/a.B/C"), expErr: "authenticator of /a.B/C is already set\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestComponent_Run_Authentication(t *testing.T) {
+	cmp, err := New(60003).
+		WithAuthenticator(tokenAuthenticator{token: "all"}).
+		WithAuthenticator(tokenAuthenticator{token: "method"}, "/greeter.Greeter/SayHelloStream").
+		WithHealthCheck().
+		Create()
+	require.NoError(t, err)
+	examples.RegisterGreeterServer(cmp.Server(), &authServer{})
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+	conn, err := grpc.Dial("localhost:60003", grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	c := examples.NewGreeterClient(conn)
+
+	tests := map[string]struct {
+		stream          bool
+		token           string
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		"unary":                     {token: "all", expectedMessage: "Hello user-all"},
+		"unary, invalid token":      {token: "method", expectedCode: codes.Unauthenticated},
+		"unary, missing token":      {expectedCode: codes.Unauthenticated},
+		"stream, method token":      {stream: true, token: "method", expectedMessage: "Hello user-method"},
+		"stream, token of the rest": {stream: true, token: "all", expectedCode: codes.Unauthenticated},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			reqCtx := context.Background()
+			if tt.token != "" {
+				reqCtx = metadata.AppendToOutgoingContext(reqCtx, "authorization", "Bearer "+tt.token)
+			}
+			var rsp *examples.HelloReply
+			if tt.stream {
+				var client examples.Greeter_SayHelloStreamClient
+				client, err = c.SayHelloStream(reqCtx, &examples.HelloRequest{})
+				require.NoError(t, err)
+				rsp, err = client.Recv()
+			} else {
+				rsp, err = c.SayHello(reqCtx, &examples.HelloRequest{})
+			}
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMessage, rsp.GetMessage())
+		})
+	}
+
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err, "the health service is not authenticated by default")
+
+	cnl()
+	<-chDone
+}
+
+func TestAuthenticators_Error(t *testing.T) {
+	aa := &authenticators{all: tokenAuthenticator{err: errors.New("keys are unavailable")}}
+	_, err := aa.authenticate(context.Background(), "/greeter.Greeter/SayHello")
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
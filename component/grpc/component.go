@@ -3,18 +3,38 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	stderrors "errors"
 	"fmt"
 	"net"
+	"os"
+	"reflect"
+	"time"
 
+	"github.com/beatlabs/patron/component/grpc/auth"
+	patronhttp "github.com/beatlabs/patron/component/http"
 	"github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
+const defaultShutdownGracePeriod = 5 * time.Second
+
 // Component of a gRPC service.
 type Component struct {
-	port int
-	srv  *grpc.Server
+	port                int
+	srv                 *grpc.Server
+	health              *healthServer
+	certs               *certReloader
+	reloadInterval      time.Duration
+	reloadSignals       []os.Signal
+	shutdownGracePeriod time.Duration
 }
 
 // Server returns the gRPC sever.
@@ -29,25 +49,94 @@ func (c *Component) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
 
+	chShutdown := make(chan struct{})
 	go func() {
 		<-ctx.Done()
-		c.srv.GracefulStop()
+		c.shutdown()
+		close(chShutdown)
 	}()
 
+	if c.certs != nil && (c.reloadInterval > 0 || len(c.reloadSignals) > 0) {
+		go c.certs.watch(ctx, c.reloadInterval, c.reloadSignals)
+	}
+
 	log.Debugf("gRPC component listening on port %d", c.port)
-	return c.srv.Serve(lis)
+	err = c.srv.Serve(lis)
+	if ctx.Err() == nil {
+		return err
+	}
+	// serving stops as soon as the shutdown begins, so the component returns once the pending RPCs are drained
+	<-chShutdown
+	return err
+}
+
+// ReloadCertificates reloads the certificate of the server, and the CA of the clients with mutual TLS, from their files.
+// The new connections are served with them, while the existing ones are not affected.
+// If any of the files is invalid, an error is returned and the previous certificates are kept.
+func (c *Component) ReloadCertificates() error {
+	if c.certs == nil {
+		return errNoTLS
+	}
+	return c.certs.load()
+}
+
+// shutdown stops the server gracefully, waiting for pending RPCs to finish within the shutdown grace period.
+// Once the grace period has elapsed, the server is stopped and the remaining RPCs are cancelled.
+func (c *Component) shutdown() {
+	log.Info("shutting down gRPC component")
+	if c.health != nil {
+		c.health.shutdown()
+	}
+	chDone := make(chan struct{})
+	go func() {
+		c.srv.GracefulStop()
+		close(chDone)
+	}()
+
+	select {
+	case <-chDone:
+	case <-time.After(c.shutdownGracePeriod):
+		log.Warnf("gRPC component did not stop gracefully within %v, stopping", c.shutdownGracePeriod)
+		c.srv.Stop()
+	}
+}
+
+type serviceRegistration struct {
+	desc *grpc.ServiceDesc
+	impl interface{}
 }
 
 // Builder pattern for our gRPC service.
 type Builder struct {
-	port          int
-	serverOptions []grpc.ServerOption
-	errors        []error
+	port                 int
+	serverOptions        []grpc.ServerOption
+	unaryInterceptors    []grpc.UnaryServerInterceptor
+	streamInterceptors   []grpc.StreamServerInterceptor
+	services             []serviceRegistration
+	health               bool
+	checks               patronhttp.ReadinessChecks
+	reflection           bool
+	authenticators       *authenticators
+	rateLimiters         *rateLimiters
+	validation           bool
+	certFile             string
+	keyFile              string
+	caFile               string
+	clientAuth           tls.ClientAuthType
+	reloadInterval       time.Duration
+	reloadSignals        []os.Signal
+	keepalive            *keepalive.ServerParameters
+	keepalivePolicy      *keepalive.EnforcementPolicy
+	maxRecvMsgSize       int
+	maxSendMsgSize       int
+	maxConcurrentStreams uint32
+	shutdownGracePeriod  time.Duration
+	errors               []error
 }
 
 // New builder.
 func New(port int) *Builder {
-	b := &Builder{}
+	b := &Builder{shutdownGracePeriod: defaultShutdownGracePeriod}
 	if port <= 0 || port > 65535 {
 		b.errors = append(b.errors, fmt.Errorf("port is invalid: %d", port))
 		return b
@@ -57,6 +146,9 @@ func New(port int) *Builder {
 }
 
 // WithOptions allows gRPC server options to be set.
+// An interceptor set with grpc.UnaryInterceptor or grpc.StreamInterceptor runs before the built-in interceptors,
+// so its panics are not recovered and it is not observed. Custom interceptors should be added with WithUnaryInterceptors
+// and WithStreamInterceptors instead.
 func (b *Builder) WithOptions(oo ...grpc.ServerOption) *Builder {
 	if len(b.errors) != 0 {
 		return b
@@ -65,19 +157,323 @@ func (b *Builder) WithOptions(oo ...grpc.ServerOption) *Builder {
 	return b
 }
 
+// WithUnaryInterceptors adds unary interceptors, which run after the observability and the recovery interceptors,
+// so that the context of the RPC contains the tracing span and the correlation ID, and their panics are recovered.
+func (b *Builder) WithUnaryInterceptors(ii ...grpc.UnaryServerInterceptor) *Builder {
+	if len(ii) == 0 {
+		b.errors = append(b.errors, stderrors.New("unary interceptors are empty"))
+		return b
+	}
+	b.unaryInterceptors = append(b.unaryInterceptors, ii...)
+	return b
+}
+
+// WithStreamInterceptors adds stream interceptors, which run after the observability and the recovery interceptors,
+// so that the context of the stream contains the tracing span and the correlation ID, and their panics are recovered.
+func (b *Builder) WithStreamInterceptors(ii ...grpc.StreamServerInterceptor) *Builder {
+	if len(ii) == 0 {
+		b.errors = append(b.errors, stderrors.New("stream interceptors are empty"))
+		return b
+	}
+	b.streamInterceptors = append(b.streamInterceptors, ii...)
+	return b
+}
+
+// WithService registers a service implementation on the server, e.g. WithService(&pb.Greeter_ServiceDesc, &greeter{}).
+// The implementation has to implement the handler type of the description, and a service can be registered only once.
+// Services can also be registered on the server returned by Component.Server, before the component runs.
+func (b *Builder) WithService(desc *grpc.ServiceDesc, impl interface{}) *Builder {
+	if desc == nil {
+		b.errors = append(b.errors, stderrors.New("service description is nil"))
+	}
+	if impl == nil {
+		b.errors = append(b.errors, stderrors.New("service implementation is nil"))
+	}
+	if desc == nil || impl == nil {
+		return b
+	}
+	if desc.HandlerType != nil {
+		ht := reflect.TypeOf(desc.HandlerType).Elem()
+		if it := reflect.TypeOf(impl); !it.Implements(ht) {
+			b.errors = append(b.errors, fmt.Errorf("service implementation %v does not implement %v of service %s", it, ht, desc.ServiceName))
+			return b
+		}
+	}
+	for _, svc := range b.services {
+		if svc.desc.ServiceName == desc.ServiceName {
+			b.errors = append(b.errors, fmt.Errorf("service %s is already registered", desc.ServiceName))
+			return b
+		}
+	}
+	b.services = append(b.services, serviceRegistration{desc: desc, impl: impl})
+	return b
+}
+
+// WithHealthCheck registers the grpc.health.v1.Health service, e.g. for the gRPC probes of Kubernetes.
+// The server and its services are reported as serving while all the readiness checks pass, and as not serving once the component shuts down.
+func (b *Builder) WithHealthCheck() *Builder {
+	b.health = true
+	return b
+}
+
+// WithReadinessCheck registers a named check of a dependency, e.g. a database, which is run by the health service,
+// registering it as well. The checks are the same as the readiness checks of the HTTP component, so they can be shared.
+func (b *Builder) WithReadinessCheck(name string, check patronhttp.CheckFunc) *Builder {
+	if err := b.checks.Add(name, check); err != nil {
+		b.errors = append(b.errors, err)
+		return b
+	}
+	b.health = true
+	return b
+}
+
+// WithAuthenticator authenticates the RPCs of the scopes, which are either service names, e.g. "greeter.Greeter",
+// or full method names, e.g. "/greeter.Greeter/SayHello", or else all the RPCs, except for the ones of the health service.
+// The authenticator of a method takes precedence over the one of its service, which takes precedence over the one of all the RPCs.
+// Unauthenticated RPCs get an Unauthenticated error, and the ones which could not be authenticated an Internal error.
+// The authentication runs after the observability, the recovery and the rate limiting interceptors, and before the custom interceptors.
+func (b *Builder) WithAuthenticator(a auth.Authenticator, scopes ...string) *Builder {
+	if b.authenticators == nil {
+		b.authenticators = &authenticators{
+			services: make(map[string]auth.Authenticator),
+			methods:  make(map[string]auth.Authenticator),
+		}
+	}
+	if err := b.authenticators.add(a, scopes...); err != nil {
+		b.errors = append(b.errors, err)
+	}
+	return b
+}
+
+// WithRateLimiting rate limits the RPCs of the scopes, which are either service names, e.g. "greeter.Greeter",
+// or full method names, e.g. "/greeter.Greeter/SayHello", or else all the RPCs, except for the ones of the health service,
+// with a token bucket of the limit per second and the burst. Each scope has its own bucket, which is shared by the methods of a service.
+// The limit of a method takes precedence over the one of its service, which takes precedence over the one of all the RPCs.
+// Rate limited RPCs get a ResourceExhausted error, with the seconds after which they can be retried in the retry-after header.
+// The rate limiting runs after the observability and the recovery interceptors, and before the authentication.
+func (b *Builder) WithRateLimiting(limit float64, burst int, scopes ...string) *Builder {
+	if b.rateLimiters == nil {
+		b.rateLimiters = &rateLimiters{
+			services: make(map[string]*rate.Limiter),
+			methods:  make(map[string]*rate.Limiter),
+		}
+	}
+	if err := b.rateLimiters.add(limit, burst, scopes...); err != nil {
+		b.errors = append(b.errors, err)
+	}
+	return b
+}
+
+// WithValidation validates the requests of the RPCs, and the messages of the client streams, whose types are generated
+// with protoc-gen-validate, calling their ValidateAll method, or else their Validate method. Invalid requests get an InvalidArgument error,
+// with the violations of the fields in its BadRequest details. The validation runs right after the authentication, before the custom interceptors.
+func (b *Builder) WithValidation() *Builder {
+	b.validation = true
+	return b
+}
+
+// WithReflection registers the server reflection service, e.g. for listing and calling the services with grpcurl.
+func (b *Builder) WithReflection() *Builder {
+	b.reflection = true
+	return b
+}
+
+// WithTLS enables TLS, serving the certificate and the key of the files, which are loaded when the component is created.
+func (b *Builder) WithTLS(certFile, keyFile string) *Builder {
+	if certFile == "" || keyFile == "" {
+		b.errors = append(b.errors, stderrors.New("invalid cert or key provided"))
+		return b
+	}
+	b.certFile = certFile
+	b.keyFile = keyFile
+	return b
+}
+
+// WithClientCertificates enables mutual TLS, verifying the certificates of the clients with the CAs of the PEM file.
+// The mode has to verify the certificates, i.e. either tls.RequireAndVerifyClientCert or tls.VerifyClientCertIfGiven.
+func (b *Builder) WithClientCertificates(caFile string, mode tls.ClientAuthType) *Builder {
+	if caFile == "" {
+		b.errors = append(b.errors, stderrors.New("client CA file is empty"))
+	}
+	if mode != tls.RequireAndVerifyClientCert && mode != tls.VerifyClientCertIfGiven {
+		b.errors = append(b.errors, fmt.Errorf("client auth mode %v does not verify client certificates", mode))
+	}
+	b.caFile = caFile
+	b.clientAuth = mode
+	return b
+}
+
+// WithCertificateReload reloads the TLS certificates while the component runs, when their files change, which is checked
+// every interval, and when one of the signals is received, e.g. syscall.SIGUSR2, so that they can be rotated without a restart.
+// A zero interval disables the checks of the files. Since a patron service shuts down on SIGHUP, it cannot be used within one.
+// The certificates can also be reloaded with Component.ReloadCertificates.
+func (b *Builder) WithCertificateReload(interval time.Duration, signals ...os.Signal) *Builder {
+	if interval < 0 {
+		b.errors = append(b.errors, stderrors.New("negative certificate reload interval provided"))
+		return b
+	}
+	if interval == 0 && len(signals) == 0 {
+		b.errors = append(b.errors, stderrors.New("certificate reload requires an interval or signals"))
+		return b
+	}
+	b.reloadInterval = interval
+	b.reloadSignals = signals
+	return b
+}
+
+// WithKeepalive sets the keepalive parameters of the server, e.g. the maximum age of the connections,
+// so that the clients reconnect and are balanced across the instances. Zero values keep the defaults of gRPC.
+func (b *Builder) WithKeepalive(params keepalive.ServerParameters) *Builder {
+	if params.MaxConnectionIdle < 0 || params.MaxConnectionAge < 0 || params.MaxConnectionAgeGrace < 0 ||
+		params.Time < 0 || params.Timeout < 0 {
+		b.errors = append(b.errors, stderrors.New("negative keepalive parameters provided"))
+		return b
+	}
+	b.keepalive = &params
+	return b
+}
+
+// WithKeepaliveEnforcementPolicy sets the keepalive enforcement policy of the server, i.e. the minimum interval of the pings of the clients,
+// which defaults to 5 minutes, and whether they are permitted without active streams. The connections of the clients violating it are closed.
+func (b *Builder) WithKeepaliveEnforcementPolicy(policy keepalive.EnforcementPolicy) *Builder {
+	if policy.MinTime < 0 {
+		b.errors = append(b.errors, stderrors.New("negative keepalive enforcement minimum time provided"))
+		return b
+	}
+	b.keepalivePolicy = &policy
+	return b
+}
+
+// WithMaxRecvMsgSize sets the maximum size in bytes of the messages the server can receive, which defaults to 4MB.
+func (b *Builder) WithMaxRecvMsgSize(size int) *Builder {
+	if size <= 0 {
+		b.errors = append(b.errors, stderrors.New("negative or zero max receive message size provided"))
+		return b
+	}
+	b.maxRecvMsgSize = size
+	return b
+}
+
+// WithMaxSendMsgSize sets the maximum size in bytes of the messages the server can send, which is not limited by default.
+func (b *Builder) WithMaxSendMsgSize(size int) *Builder {
+	if size <= 0 {
+		b.errors = append(b.errors, stderrors.New("negative or zero max send message size provided"))
+		return b
+	}
+	b.maxSendMsgSize = size
+	return b
+}
+
+// WithMaxConcurrentStreams sets the number of concurrent streams each client connection may have open, which is not limited by default.
+func (b *Builder) WithMaxConcurrentStreams(n uint32) *Builder {
+	if n == 0 {
+		b.errors = append(b.errors, stderrors.New("zero max concurrent streams provided"))
+		return b
+	}
+	b.maxConcurrentStreams = n
+	return b
+}
+
+// WithShutdownGracePeriod sets the period for pending RPCs to finish, when the component shuts down.
+func (b *Builder) WithShutdownGracePeriod(gp time.Duration) *Builder {
+	if gp <= 0 {
+		b.errors = append(b.errors, stderrors.New("negative or zero shutdown grace period provided"))
+		return b
+	}
+	b.shutdownGracePeriod = gp
+	return b
+}
+
 // Create the gRPC component.
 func (b *Builder) Create() (*Component, error) {
+	for _, svc := range b.services {
+		if b.health && svc.desc.ServiceName == grpc_health_v1.Health_ServiceDesc.ServiceName {
+			b.errors = append(b.errors, fmt.Errorf("service %s is registered by the health check", svc.desc.ServiceName))
+		}
+		if b.reflection && svc.desc.ServiceName == reflectionpb.ServerReflection_ServiceDesc.ServiceName {
+			b.errors = append(b.errors, fmt.Errorf("service %s is registered by the reflection", svc.desc.ServiceName))
+		}
+	}
 	if len(b.errors) != 0 {
 		return nil, errors.Aggregate(b.errors...)
 	}
 
-	b.serverOptions = append(b.serverOptions, grpc.UnaryInterceptor(observableUnaryInterceptor),
-		grpc.StreamInterceptor(observableStreamInterceptor))
+	// the options of the builder come first, so that they can be overridden with WithOptions
+	serverOptions := b.transportOptions()
+	var certs *certReloader
+	if b.certFile != "" {
+		var err error
+		certs, err = newCertReloader(b.certFile, b.keyFile, b.caFile, b.clientAuth)
+		if err != nil {
+			return nil, err
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(certs.tlsConfig())))
+	} else if b.caFile != "" || b.reloadInterval > 0 || len(b.reloadSignals) > 0 {
+		return nil, stderrors.New("client certificates and certificate reload require TLS")
+	}
 
-	srv := grpc.NewServer(b.serverOptions...)
+	// the recovery interceptors come right after the observability ones, so that the recovered panics are observed as errors
+	unaryInterceptors := []grpc.UnaryServerInterceptor{observableUnaryInterceptor, recoveryUnaryInterceptor}
+	streamInterceptors := []grpc.StreamServerInterceptor{observableStreamInterceptor, recoveryStreamInterceptor}
+	if b.rateLimiters != nil {
+		unaryInterceptors = append(unaryInterceptors, b.rateLimiters.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, b.rateLimiters.streamInterceptor)
+	}
+	if b.authenticators != nil {
+		unaryInterceptors = append(unaryInterceptors, b.authenticators.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, b.authenticators.streamInterceptor)
+	}
+	if b.validation {
+		unaryInterceptors = append(unaryInterceptors, validationUnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, validationStreamInterceptor)
+	}
+	unaryInterceptors = append(unaryInterceptors, b.unaryInterceptors...)
+	streamInterceptors = append(streamInterceptors, b.streamInterceptors...)
+	serverOptions = append(serverOptions, b.serverOptions...)
+	serverOptions = append(serverOptions, grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...))
+
+	srv := grpc.NewServer(serverOptions...)
+	for _, svc := range b.services {
+		srv.RegisterService(svc.desc, svc.impl)
+	}
+
+	var health *healthServer
+	if b.health {
+		health = newHealthServer(srv, b.checks)
+		grpc_health_v1.RegisterHealthServer(srv, health)
+	}
+	if b.reflection {
+		reflection.Register(srv)
+	}
 
 	return &Component{
-		port: b.port,
-		srv:  srv,
+		port:                b.port,
+		srv:                 srv,
+		health:              health,
+		certs:               certs,
+		reloadInterval:      b.reloadInterval,
+		reloadSignals:       b.reloadSignals,
+		shutdownGracePeriod: b.shutdownGracePeriod,
 	}, nil
 }
+
+func (b *Builder) transportOptions() []grpc.ServerOption {
+	var oo []grpc.ServerOption
+	if b.keepalive != nil {
+		oo = append(oo, grpc.KeepaliveParams(*b.keepalive))
+	}
+	if b.keepalivePolicy != nil {
+		oo = append(oo, grpc.KeepaliveEnforcementPolicy(*b.keepalivePolicy))
+	}
+	if b.maxRecvMsgSize > 0 {
+		oo = append(oo, grpc.MaxRecvMsgSize(b.maxRecvMsgSize))
+	}
+	if b.maxSendMsgSize > 0 {
+		oo = append(oo, grpc.MaxSendMsgSize(b.maxSendMsgSize))
+	}
+	if b.maxConcurrentStreams > 0 {
+		oo = append(oo, grpc.MaxConcurrentStreams(b.maxConcurrentStreams))
+	}
+	return oo
+}
@@ -3,13 +3,20 @@ package grpc
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/examples"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestCreate(t *testing.T) {
@@ -46,6 +53,9 @@ func (s *server) SayHello(_ context.Context, in *examples.HelloRequest) (*exampl
 	if in.GetFirstname() == "ERROR" {
 		return nil, errors.New("ERROR")
 	}
+	if in.GetFirstname() == "PANIC" {
+		panic("PANIC")
+	}
 	return &examples.HelloReply{Message: "Hello " + in.GetFirstname()}, nil
 }
 
@@ -53,6 +63,9 @@ func (s *server) SayHelloStream(req *examples.HelloRequest, srv examples.Greeter
 	if req.GetFirstname() == "ERROR" {
 		return errors.New("ERROR")
 	}
+	if req.GetFirstname() == "PANIC" {
+		panic("PANIC")
+	}
 
 	return srv.Send(&examples.HelloReply{Message: "Hello " + req.GetFirstname()})
 }
@@ -80,6 +93,7 @@ func TestComponent_Run_Unary(t *testing.T) {
 	}{
 		"success": {args: args{requestName: "TEST"}},
 		"error":   {args: args{requestName: "ERROR"}, expErr: "rpc error: code = Unknown desc = ERROR"},
+		"panic":   {args: args{requestName: "PANIC"}, expErr: "rpc error: code = Internal desc = Internal"},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -121,6 +135,7 @@ func TestComponent_Run_Stream(t *testing.T) {
 	}{
 		"success": {args: args{requestName: "TEST"}},
 		"error":   {args: args{requestName: "ERROR"}, expErr: "rpc error: code = Unknown desc = ERROR"},
+		"panic":   {args: args{requestName: "PANIC"}, expErr: "rpc error: code = Internal desc = Internal"},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -141,3 +156,179 @@ func TestComponent_Run_Stream(t *testing.T) {
 	require.NoError(t, conn.Close())
 	<-chDone
 }
+
+func TestBuilder_Options(t *testing.T) {
+	unary := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, ss)
+	}
+	desc := &grpc.ServiceDesc{ServiceName: "test.Service", HandlerType: (*interface{})(nil)}
+	greeterDesc := &grpc.ServiceDesc{ServiceName: "greeter.Greeter", HandlerType: (*examples.GreeterServer)(nil)}
+
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success": {
+			builder: New(60000).WithUnaryInterceptors(unary).WithStreamInterceptors(stream).
+				WithService(desc, &server{}).WithShutdownGracePeriod(time.Second),
+		},
+		"invalid options": {
+			builder: New(60000).WithUnaryInterceptors().WithStreamInterceptors().
+				WithService(nil, nil).WithShutdownGracePeriod(0),
+			expErr: "unary interceptors are empty\nstream interceptors are empty\nservice description is nil\n" +
+				"service implementation is nil\nnegative or zero shutdown grace period provided\n",
+		},
+		"implementation mismatch": {
+			builder: New(60000).WithService(greeterDesc, &struct{}{}),
+			expErr:  "service implementation *struct {} does not implement examples.GreeterServer of service greeter.Greeter\n",
+		},
+		"service already registered": {
+			builder: New(60000).WithService(greeterDesc, &server{}).WithService(greeterDesc, &server{}),
+			expErr:  "service greeter.Greeter is already registered\n",
+		},
+		"health service registered": {
+			builder: New(60000).WithHealthCheck().WithService(&grpc_health_v1.Health_ServiceDesc, &grpc_health_v1.UnimplementedHealthServer{}),
+			expErr:  "service grpc.health.v1.Health is registered by the health check\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, time.Second, got.shutdownGracePeriod)
+				assert.Contains(t, got.Server().GetServiceInfo(), "test.Service")
+			}
+		})
+	}
+}
+
+type blockingServer struct {
+	examples.UnimplementedGreeterServer
+	chStarted chan struct{}
+	corID     string
+}
+
+func (s *blockingServer) SayHelloStream(_ *examples.HelloRequest, srv examples.Greeter_SayHelloStreamServer) error {
+	s.corID = correlation.IDFromContext(srv.Context())
+	close(s.chStarted)
+	<-srv.Context().Done()
+	return srv.Context().Err()
+}
+
+func TestComponent_Run_ShutdownGracePeriod(t *testing.T) {
+	var interceptedCorID string
+	interceptor := func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		interceptedCorID = correlation.IDFromContext(ss.Context())
+		return handler(srv, ss)
+	}
+	cmp, err := New(60001).WithStreamInterceptors(interceptor).WithShutdownGracePeriod(100 * time.Millisecond).Create()
+	require.NoError(t, err)
+	srv := &blockingServer{chStarted: make(chan struct{})}
+	examples.RegisterGreeterServer(cmp.Server(), srv)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+	conn, err := grpc.Dial("localhost:60001", grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	reqCtx := metadata.AppendToOutgoingContext(context.Background(), correlation.HeaderID, "123")
+	_, err = examples.NewGreeterClient(conn).SayHelloStream(reqCtx, &examples.HelloRequest{Firstname: "TEST"})
+	require.NoError(t, err)
+	<-srv.chStarted
+	assert.Equal(t, "123", srv.corID)
+	assert.Equal(t, "123", interceptedCorID)
+
+	// the pending stream never finishes, so the component stops once the grace period has elapsed
+	cnl()
+	select {
+	case <-chDone:
+		assert.Fail(t, "component returned before draining the pending RPCs")
+	case <-time.After(50 * time.Millisecond):
+	}
+	select {
+	case <-chDone:
+	case <-time.After(2 * time.Second):
+		assert.Fail(t, "component did not stop after the shutdown grace period")
+	}
+}
+
+func TestBuilder_TransportOptions(t *testing.T) {
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success": {
+			builder: New(60000).WithKeepalive(keepalive.ServerParameters{MaxConnectionAge: time.Minute}).
+				WithKeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: time.Second, PermitWithoutStream: true}).
+				WithMaxRecvMsgSize(1024).WithMaxSendMsgSize(1024).WithMaxConcurrentStreams(10),
+		},
+		"invalid options": {
+			builder: New(60000).WithKeepalive(keepalive.ServerParameters{Time: -time.Second}).
+				WithKeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: -time.Second}).
+				WithMaxRecvMsgSize(0).WithMaxSendMsgSize(-1).WithMaxConcurrentStreams(0),
+			expErr: "negative keepalive parameters provided\nnegative keepalive enforcement minimum time provided\n" +
+				"negative or zero max receive message size provided\nnegative or zero max send message size provided\n" +
+				"zero max concurrent streams provided\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, tt.builder.transportOptions(), 5)
+			}
+		})
+	}
+}
+
+func TestComponent_Run_MaxMessageSize(t *testing.T) {
+	cmp, err := New(60005).WithMaxRecvMsgSize(1024).WithMaxSendMsgSize(64).Create()
+	require.NoError(t, err)
+	examples.RegisterGreeterServer(cmp.Server(), &server{})
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+	conn, err := grpc.Dial("localhost:60005", grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := examples.NewGreeterClient(conn)
+
+	tests := map[string]struct {
+		firstname    string
+		expectedCode codes.Code
+	}{
+		"success":            {firstname: "TEST", expectedCode: codes.OK},
+		"request too large":  {firstname: strings.Repeat("a", 2048), expectedCode: codes.ResourceExhausted},
+		"response too large": {firstname: strings.Repeat("a", 128), expectedCode: codes.ResourceExhausted},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := client.SayHello(context.Background(), &examples.HelloRequest{Firstname: tt.firstname})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+
+	cnl()
+	<-chDone
+}
@@ -0,0 +1,63 @@
+// Package gateway provides a helper for exposing the gRPC services of a patron service as REST with grpc-gateway,
+// mounting its mux as HTTP routes and bridging their tracing and correlation to the gRPC calls.
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	grpcclient "github.com/beatlabs/patron/client/grpc"
+	patronhttp "github.com/beatlabs/patron/component/http"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"google.golang.org/grpc"
+)
+
+// methods are the HTTP methods which the google.api.http annotations map to RPCs.
+var methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Dial creates the client connection of the gateway to the gRPC server, e.g. the gRPC component of the same service,
+// which continues the spans of the HTTP requests and propagates their correlation ID to the RPCs.
+func Dial(ctx context.Context, target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpcclient.DialContext(ctx, target, opts...)
+}
+
+// RouteBuilders returns the route builders which mount the mux, e.g. the runtime.ServeMux of grpc-gateway, under the prefix
+// for the methods of the google.api.http annotations, i.e. GET, POST, PUT, PATCH and DELETE.
+// The mux gets the whole path of the requests, so the prefix is the common prefix of the paths of the annotations, e.g. /v1.
+// The routes are traced, and the correlation ID of the requests is set to their context, so that the RPCs of a connection
+// created with Dial continue the spans and propagate the correlation ID. The route builders can be configured further,
+// e.g. with authentication, before they are appended to the routes builder of the HTTP component.
+func RouteBuilders(prefix string, mux http.Handler) ([]*patronhttp.RouteBuilder, error) {
+	if mux == nil {
+		return nil, errors.New("gateway mux is nil")
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(prefix, "/") {
+		// the catch-all route of the root path would conflict with the rest of the routes, e.g. the health checks
+		return nil, errors.New("gateway prefix has to be a non-root path, e.g. /v1")
+	}
+
+	hnd := correlationMiddleware(mux)
+	rbs := make([]*patronhttp.RouteBuilder, 0, len(methods))
+	for _, m := range methods {
+		rbs = append(rbs, patronhttp.NewHandlerRouteBuilder(m, prefix+"/*path", hnd).WithTrace())
+	}
+	return rbs, nil
+}
+
+// correlationMiddleware sets the correlation ID of the request, which is ensured by the tracing middleware, to its context.
+func correlationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		corID := r.Header.Get(correlation.HeaderID)
+		if corID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := correlation.ContextWithID(r.Context(), corID)
+		ctx = log.WithContext(ctx, log.Sub(map[string]interface{}{correlation.ID: corID}))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
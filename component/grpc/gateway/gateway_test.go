@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	patronhttp "github.com/beatlabs/patron/component/http"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/examples"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type greeter struct {
+	examples.UnimplementedGreeterServer
+	md metadata.MD
+}
+
+func (g *greeter) SayHello(ctx context.Context, req *examples.HelloRequest) (*examples.HelloReply, error) {
+	g.md, _ = metadata.FromIncomingContext(ctx)
+	return &examples.HelloReply{Message: "Hello " + req.GetFirstname()}, nil
+}
+
+func TestRouteBuilders(t *testing.T) {
+	mux := http.NewServeMux()
+
+	tests := map[string]struct {
+		prefix       string
+		mux          http.Handler
+		expectedPath string
+		expectedErr  string
+	}{
+		"success":         {prefix: "/v1", mux: mux, expectedPath: "/v1/*path"},
+		"trailing slash":  {prefix: "/v1/", mux: mux, expectedPath: "/v1/*path"},
+		"root prefix":     {prefix: "/", mux: mux, expectedErr: "gateway prefix has to be a non-root path, e.g. /v1"},
+		"relative prefix": {prefix: "v1", mux: mux, expectedErr: "gateway prefix has to be a non-root path, e.g. /v1"},
+		"nil mux":         {prefix: "/v1", expectedErr: "gateway mux is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rbs, err := RouteBuilders(tt.prefix, tt.mux)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			rb := patronhttp.NewRoutesBuilder()
+			for _, r := range rbs {
+				rb.Append(r)
+			}
+			routes, err := rb.Build()
+			require.NoError(t, err)
+			methods := make([]string, 0, len(routes))
+			for _, r := range routes {
+				assert.Equal(t, tt.expectedPath, r.Path())
+				methods = append(methods, r.Method())
+			}
+			assert.Equal(t, []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, methods)
+		})
+	}
+}
+
+func TestRouteBuilders_Bridging(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	g := &greeter{}
+	examples.RegisterGreeterServer(srv, g)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := Dial(context.Background(), lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := examples.NewGreeterClient(conn)
+
+	// the mux stands in for the runtime.ServeMux of grpc-gateway, which calls the RPCs with the context of the requests
+	mux := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rsp, err := client.SayHello(r.Context(), &examples.HelloRequest{Firstname: strings.TrimPrefix(r.URL.Path, "/v1/greet/")})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(rsp.GetMessage()))
+	})
+	rbs, err := RouteBuilders("/v1", mux)
+	require.NoError(t, err)
+	route, err := rbs[0].Build()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/greet/john", nil)
+	req.Header.Set(correlation.HeaderID, "123")
+	rsp := httptest.NewRecorder()
+	patronhttp.MiddlewareChain(route.Handler(), route.Middlewares()...).ServeHTTP(rsp, req)
+
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "Hello john", rsp.Body.String())
+	assert.Equal(t, []string{"123"}, g.md.Get(correlation.HeaderID), "the correlation ID is propagated to the RPC")
+
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 2)
+	rpcSpan, httpSpan := spans[0], spans[1]
+	assert.Equal(t, httpSpan.SpanContext.SpanID, rpcSpan.ParentID, "the span of the RPC is a child of the span of the request")
+	assert.Equal(t, []string{strconv.Itoa(rpcSpan.SpanContext.TraceID)}, g.md.Get("mockpfx-ids-traceid"), "the span of the RPC is propagated")
+}
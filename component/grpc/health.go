@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	patronhttp "github.com/beatlabs/patron/component/http"
+	"github.com/beatlabs/patron/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const healthWatchInterval = 5 * time.Second
+
+// healthServer implements the grpc.health.v1.Health service, reporting the services of the server as serving
+// only while all the readiness checks pass, and not serving once the component shuts down.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	srv      *grpc.Server
+	checks   patronhttp.ReadinessChecks
+	interval time.Duration
+	stopped  int32
+}
+
+func newHealthServer(srv *grpc.Server, checks patronhttp.ReadinessChecks) *healthServer {
+	return &healthServer{srv: srv, checks: checks, interval: healthWatchInterval}
+}
+
+// known returns whether the service is registered on the server, which is the case for the empty name of the server itself.
+func (hs *healthServer) known(service string) bool {
+	if service == "" {
+		return true
+	}
+	_, ok := hs.srv.GetServiceInfo()[service]
+	return ok
+}
+
+// Check reports the status of the server, with an empty service name, or of one of its services.
+func (hs *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if !hs.known(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: hs.status(ctx)}, nil
+}
+
+// Watch sends the status of the server or one of its services, and then every change of it, which is checked periodically.
+// The status of an unknown service is reported as SERVICE_UNKNOWN, since it may be registered later.
+func (hs *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, srv grpc_health_v1.Health_WatchServer) error {
+	ctx := srv.Context()
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	ticker := time.NewTicker(hs.interval)
+	defer ticker.Stop()
+	for {
+		st := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		if hs.known(req.GetService()) {
+			st = hs.status(ctx)
+		}
+		if st != last {
+			if err := srv.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			last = st
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+// shutdown reports the server as not serving, e.g. while it drains the pending RPCs.
+func (hs *healthServer) shutdown() {
+	atomic.StoreInt32(&hs.stopped, 1)
+}
+
+func (hs *healthServer) status(ctx context.Context) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if atomic.LoadInt32(&hs.stopped) == 1 {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	if err := hs.checks.Run(ctx); err != nil {
+		log.FromContext(ctx).Warnf("gRPC health check failed: %v", err)
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,129 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/examples"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+func TestBuilder_WithReadinessCheck(t *testing.T) {
+	check := func(context.Context) error { return nil }
+
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success":      {builder: New(60000).WithReadinessCheck("db", check).WithReadinessCheck("kafka", check)},
+		"empty name":   {builder: New(60000).WithReadinessCheck("", check), expErr: "readiness check name is empty\n"},
+		"nil check":    {builder: New(60000).WithReadinessCheck("db", nil), expErr: "readiness check db is nil\n"},
+		"already used": {builder: New(60000).WithReadinessCheck("db", check).WithReadinessCheck("db", check), expErr: "readiness check db is already registered\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, got.Server().GetServiceInfo(), "grpc.health.v1.Health", "the health service is registered with the checks")
+			assert.NotContains(t, got.Server().GetServiceInfo(), "grpc.reflection.v1alpha.ServerReflection")
+		})
+	}
+}
+
+func TestComponent_Run_HealthCheck(t *testing.T) {
+	var failing int32
+	check := func(context.Context) error {
+		if atomic.LoadInt32(&failing) == 1 {
+			return errors.New("db is down")
+		}
+		return nil
+	}
+	cmp, err := New(60002).WithReadinessCheck("db", check).WithReflection().Create()
+	require.NoError(t, err)
+	cmp.health.interval = 10 * time.Millisecond
+	examples.RegisterGreeterServer(cmp.Server(), &server{})
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+	conn, err := grpc.Dial("localhost:60002", grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	tests := map[string]struct {
+		service        string
+		failing        bool
+		expectedStatus grpc_health_v1.HealthCheckResponse_ServingStatus
+		expectedCode   codes.Code
+	}{
+		"server":                 {expectedStatus: grpc_health_v1.HealthCheckResponse_SERVING},
+		"service":                {service: "greeter.Greeter", expectedStatus: grpc_health_v1.HealthCheckResponse_SERVING},
+		"failing check":          {failing: true, expectedStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		"failing check, service": {service: "greeter.Greeter", failing: true, expectedStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		"unknown service":        {service: "greeter.Unknown", expectedCode: codes.NotFound},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			if tt.failing {
+				atomic.StoreInt32(&failing, 1)
+				defer atomic.StoreInt32(&failing, 0)
+			}
+			rsp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rsp.GetStatus())
+		})
+	}
+
+	streamCtx, streamCnl := context.WithCancel(context.Background())
+	watch, err := client.Watch(streamCtx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	rsp, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, rsp.GetStatus())
+	atomic.StoreInt32(&failing, 1)
+	rsp, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, rsp.GetStatus(), "the changes of the status are sent")
+
+	refl, err := grpc_reflection_v1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(streamCtx)
+	require.NoError(t, err)
+	require.NoError(t, refl.Send(&grpc_reflection_v1alpha.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1alpha.ServerReflectionRequest_ListServices{},
+	}))
+	reflRsp, err := refl.Recv()
+	require.NoError(t, err)
+	services := make([]string, 0)
+	for _, s := range reflRsp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	assert.ElementsMatch(t, []string{"greeter.Greeter", "grpc.health.v1.Health", "grpc.reflection.v1alpha.ServerReflection"}, services)
+
+	streamCnl()
+	cnl()
+	<-chDone
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, cmp.health.status(context.Background()),
+		"the server is not serving once it shuts down")
+}
@@ -67,6 +67,14 @@ func newObserver(ctx context.Context, typ, fullMethodName string) *observer {
 
 	sp, ctx := grpcSpan(ctx, fullMethodName, corID, md)
 
+	ctx = correlation.ContextWithID(ctx, corID)
+	ctx = correlation.ContextWithHeaders(ctx, func(header string) string {
+		values := md.Get(header)
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	})
 	ctx = log.WithContext(ctx, log.Sub(map[string]interface{}{correlation.ID: corID}))
 
 	svc, meth := splitMethodName(fullMethodName)
@@ -128,11 +136,22 @@ func observableUnaryInterceptor(ctx context.Context, req interface{}, info *grpc
 
 func observableStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	obs := newObserver(ss.Context(), stream, info.FullMethod)
-	err := handler(srv, ss)
+	err := handler(srv, &observableServerStream{ServerStream: ss, ctx: obs.ctx})
 	obs.observe(err)
 	return err
 }
 
+// observableServerStream exposes the context of the observer to the stream handler,
+// which contains the tracing span, the correlation ID and the logger.
+type observableServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *observableServerStream) Context() context.Context {
+	return s.ctx
+}
+
 func splitMethodName(fullMethodName string) (string, string) {
 	fullMethodName = strings.TrimPrefix(fullMethodName, "/") // remove leading slash
 	if i := strings.Index(fullMethodName, "/"); i >= 0 {
@@ -0,0 +1,137 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// retryAfterKey is the metadata key of the header of the rate limited RPCs, with the seconds after which they can be retried.
+const retryAfterKey = "retry-after"
+
+var rpcRateLimitedMetric *prometheus.CounterVec
+
+func init() {
+	rpcRateLimitedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "grpc",
+			Name:      "rate_limited_total",
+			Help:      "Total number of RPCs rejected by a rate limit.",
+		},
+		[]string{"grpc_type", "grpc_service", "grpc_method"},
+	)
+	prometheus.MustRegister(rpcRateLimitedMetric)
+}
+
+// rateLimiters of the RPCs, either per method, per service or for all of them.
+type rateLimiters struct {
+	all      *rate.Limiter
+	services map[string]*rate.Limiter
+	methods  map[string]*rate.Limiter
+}
+
+func (rl *rateLimiters) add(limit float64, burst int, scopes ...string) error {
+	if limit <= 0 {
+		return errors.New("rate limit must be positive")
+	}
+	if burst <= 0 {
+		return errors.New("rate limit burst must be positive")
+	}
+	if len(scopes) == 0 {
+		if rl.all != nil {
+			return errors.New("rate limit of all the RPCs is already set")
+		}
+		rl.all = rate.NewLimiter(rate.Limit(limit), burst)
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if scope == "" {
+			return errors.New("rate limiting scope is empty")
+		}
+		method, valid := scopeMethod(scope)
+		if !valid {
+			return fmt.Errorf("rate limiting scope %s is not a method", scope)
+		}
+		target := rl.services
+		if method {
+			target = rl.methods
+		}
+		if _, ok := target[scope]; ok {
+			return fmt.Errorf("rate limit of %s is already set", scope)
+		}
+		target[scope] = rate.NewLimiter(rate.Limit(limit), burst)
+	}
+	return nil
+}
+
+// limiter returns the limiter of the method, which is the one of the method itself, or else of its service,
+// or else the one of all the RPCs, except for the ones of the health service, which are not rate limited by default.
+func (rl *rateLimiters) limiter(fullMethodName string) *rate.Limiter {
+	if l, ok := rl.methods[fullMethodName]; ok {
+		return l
+	}
+	svc, _ := splitMethodName(fullMethodName)
+	if l, ok := rl.services[svc]; ok {
+		return l
+	}
+	if svc == grpc_health_v1.Health_ServiceDesc.ServiceName {
+		return nil
+	}
+	return rl.all
+}
+
+// allow returns whether the RPC is within its rate limit, or else the seconds after which it can be retried.
+func (rl *rateLimiters) allow(fullMethodName string) (bool, int) {
+	l := rl.limiter(fullMethodName)
+	if l == nil {
+		return true, 0
+	}
+	r := l.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return true, 0
+	}
+	r.Cancel()
+	if !r.OK() || delay == rate.InfDuration {
+		return false, 1
+	}
+	return false, int(math.Ceil(delay.Seconds()))
+}
+
+func rateLimitedError(ctx context.Context, typ, fullMethodName string, retryAfter int, setHeader func(metadata.MD) error) error {
+	svc, meth := splitMethodName(fullMethodName)
+	rpcRateLimitedMetric.WithLabelValues(typ, svc, meth).Inc()
+	if err := setHeader(metadata.Pairs(retryAfterKey, strconv.Itoa(retryAfter))); err != nil {
+		log.FromContext(ctx).Warnf("failed to set the retry after header: %v", err)
+	}
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+func (rl *rateLimiters) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if ok, retryAfter := rl.allow(info.FullMethod); !ok {
+		return nil, rateLimitedError(ctx, unary, info.FullMethod, retryAfter, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		})
+	}
+	return handler(ctx, req)
+}
+
+func (rl *rateLimiters) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if ok, retryAfter := rl.allow(info.FullMethod); !ok {
+		return rateLimitedError(ss.Context(), stream, info.FullMethod, retryAfter, ss.SetHeader)
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/beatlabs/patron/examples"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestBuilder_WithRateLimiting(t *testing.T) {
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success": {
+			builder: New(60000).WithRateLimiting(10, 1).WithRateLimiting(5, 5, "greeter.Greeter", "/greeter.Greeter/SayHello"),
+		},
+		"zero limit":        {builder: New(60000).WithRateLimiting(0, 1), expErr: "rate limit must be positive\n"},
+		"zero burst":        {builder: New(60000).WithRateLimiting(1, 0), expErr: "rate limit burst must be positive\n"},
+		"empty scope":       {builder: New(60000).WithRateLimiting(1, 1, ""), expErr: "rate limiting scope is empty\n"},
+		"invalid method":    {builder: New(60000).WithRateLimiting(1, 1, "/greeter.Greeter"), expErr: "rate limiting scope /greeter.Greeter is not a method\n"},
+		"duplicate all":     {builder: New(60000).WithRateLimiting(1, 1).WithRateLimiting(1, 1), expErr: "rate limit of all the RPCs is already set\n"},
+		"duplicate service": {builder: New(60000).WithRateLimiting(1, 1, "a.B").WithRateLimiting(1, 1, "a.B"), expErr: "rate limit of a.B is already set\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestComponent_Run_RateLimiting(t *testing.T) {
+	rpcRateLimitedMetric.Reset()
+	cmp, err := New(60006).WithHealthCheck().WithRateLimiting(0.01, 1, "/greeter.Greeter/SayHello").
+		WithRateLimiting(0.01, 1).Create()
+	require.NoError(t, err)
+	examples.RegisterGreeterServer(cmp.Server(), &server{})
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+	conn, err := grpc.Dial("localhost:60006", grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := examples.NewGreeterClient(conn)
+
+	_, err = client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "TEST"})
+	require.NoError(t, err)
+	var header metadata.MD
+	_, err = client.SayHello(context.Background(), &examples.HelloRequest{Firstname: "TEST"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"100"}, header.Get(retryAfterKey))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcRateLimitedMetric.WithLabelValues(unary, "greeter.Greeter", "SayHello")))
+
+	// the stream has the limit of all the RPCs, which is separate from the one of the method
+	cs, err := client.SayHelloStream(context.Background(), &examples.HelloRequest{Firstname: "TEST"})
+	require.NoError(t, err)
+	_, err = cs.Recv()
+	require.NoError(t, err)
+	cs, err = client.SayHelloStream(context.Background(), &examples.HelloRequest{Firstname: "TEST"})
+	require.NoError(t, err)
+	_, err = cs.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	header, err = cs.Header()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100"}, header.Get(retryAfterKey))
+
+	for i := 0; i < 3; i++ {
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		assert.NoError(t, err, "the health checks are not rate limited")
+	}
+
+	cnl()
+	<-chDone
+}
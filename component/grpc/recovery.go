@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var rpcPanicMetric *prometheus.CounterVec
+
+func init() {
+	rpcPanicMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "grpc",
+			Name:      "panic_total",
+			Help:      "Total number of panics recovered while handling RPCs.",
+		},
+		[]string{"grpc_type", "grpc_service", "grpc_method"},
+	)
+	prometheus.MustRegister(rpcPanicMetric)
+}
+
+// recoveryUnaryInterceptor recovers from the panics of the handlers and the custom interceptors,
+// returning an Internal error, which is observed by the observability interceptor.
+// The panics are logged with their stack and counted per method.
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoverPanic(ctx, unary, info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor recovers from the panics of the handlers and the custom interceptors,
+// returning an Internal error, which is observed by the observability interceptor.
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoverPanic(ss.Context(), stream, info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func recoverPanic(ctx context.Context, typ, fullMethodName string, p interface{}) error {
+	var err error
+	switch x := p.(type) {
+	case string:
+		err = errors.New(x)
+	case error:
+		err = x
+	default:
+		err = fmt.Errorf("unknown panic: %v", x)
+	}
+
+	svc, meth := splitMethodName(fullMethodName)
+	rpcPanicMetric.WithLabelValues(typ, svc, meth).Inc()
+	// the logger of the context contains the correlation ID of the RPC, set by the observability interceptor
+	log.FromContext(ctx).Sub(map[string]interface{}{
+		"grpc_type":   typ,
+		"grpc_method": fullMethodName,
+		"stack":       string(debug.Stack()),
+	}).Errorf("recovered from a panic: %v", err)
+	return status.Error(codes.Internal, codes.Internal.String())
+}
@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptors(t *testing.T) {
+	rpcPanicMetric.Reset()
+	var buf bytes.Buffer
+	ctx := log.WithContext(context.Background(), std.New(&buf, log.InfoLevel, map[string]interface{}{correlation.ID: "123"}))
+	unaryInfo := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Unary"}
+	_, err := recoveryUnaryInterceptor(ctx, nil, unaryInfo, func(context.Context, interface{}) (interface{}, error) {
+		panic(errors.New("failure"))
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcPanicMetric.WithLabelValues(unary, "test.Service", "Unary")))
+	for _, f := range []string{"correlationID=123", "grpc_method=/test.Service/Unary", "grpc_type=unary", "stack=goroutine", "recovered from a panic: failure"} {
+		assert.Contains(t, buf.String(), f)
+	}
+
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	err = recoveryStreamInterceptor(nil, &observableServerStream{ctx: context.Background()}, streamInfo, func(interface{}, grpc.ServerStream) error {
+		panic(42)
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcPanicMetric.WithLabelValues(stream, "test.Service", "Stream")))
+
+	resp, err := recoveryUnaryInterceptor(context.Background(), "req", unaryInfo, func(_ context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "req", resp)
+}
@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+var errNoTLS = errors.New("TLS is not enabled")
+
+// certReloader loads the certificate of the server and the CA of the clients from their files,
+// serving the last loaded ones to every new connection, so that they can be rotated without a restart.
+type certReloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+func newCertReloader(certFile, keyFile, caFile string, clientAuth tls.ClientAuthType) (*certReloader, error) {
+	cr := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile, clientAuth: clientAuth}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func (cr *certReloader) files() []string {
+	ff := []string{cr.certFile, cr.keyFile}
+	if cr.caFile != "" {
+		ff = append(ff, cr.caFile)
+	}
+	return ff
+}
+
+// load reads the files, keeping the previous certificates if any of them is invalid.
+func (cr *certReloader) load() error {
+	modTime := make(map[string]time.Time, 3)
+	for _, f := range cr.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", f, err)
+		}
+		modTime[f] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if cr.caFile != "" {
+		pem, err := ioutil.ReadFile(cr.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA %s", cr.caFile)
+		}
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cert = &cert
+	cr.pool = pool
+	cr.modTime = modTime
+	return nil
+}
+
+// changed returns whether any of the files was modified since they were last loaded.
+func (cr *certReloader) changed() bool {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	for _, f := range cr.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			// the files may be missing briefly while they are replaced
+			continue
+		}
+		if !fi.ModTime().Equal(cr.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// tlsConfig returns the config of the server, which looks up the current certificates on every handshake.
+func (cr *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cr.mu.RLock()
+			defer cr.mu.RUnlock()
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cr.cert},
+				NextProtos:   []string{"h2"},
+			}
+			if cr.pool != nil {
+				cfg.ClientCAs = cr.pool
+				cfg.ClientAuth = cr.clientAuth
+			}
+			return cfg, nil
+		},
+	}
+}
+
+// watch reloads the certificates when their files change, checking them every interval, or when one of the signals is received,
+// until the context is done. Failed reloads are logged, and the previous certificates are kept.
+func (cr *certReloader) watch(ctx context.Context, interval time.Duration, signals []os.Signal) {
+	var chSig chan os.Signal
+	if len(signals) > 0 {
+		chSig = make(chan os.Signal, 1)
+		signal.Notify(chSig, signals...)
+		defer signal.Stop(chSig)
+	}
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			if !cr.changed() {
+				continue
+			}
+			log.Info("gRPC certificate files changed, reloading")
+		case sig := <-chSig:
+			log.Infof("gRPC certificate reload requested with signal %v", sig)
+		}
+		if err := cr.load(); err != nil {
+			log.Errorf("failed to reload gRPC certificates, keeping the previous ones: %v", err)
+		}
+	}
+}
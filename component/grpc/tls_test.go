@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return testCA{cert: cert, key: key, pool: pool, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate of the CA in PEM, along with its key.
+func (ca testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	require.NoError(t, ioutil.WriteFile(name, data, 0o600))
+}
+
+func TestBuilder_WithTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.pem")
+	cert, key := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	writeFile(t, caFile, ca.pem)
+
+	tests := map[string]struct {
+		builder *Builder
+		expErr  string
+	}{
+		"success": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithClientCertificates(caFile, tls.RequireAndVerifyClientCert).
+				WithCertificateReload(time.Minute),
+		},
+		"missing key": {builder: New(60000).WithTLS(certFile, ""), expErr: "invalid cert or key provided\n"},
+		"missing client CA": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithClientCertificates("", tls.RequireAndVerifyClientCert),
+			expErr:  "client CA file is empty\n",
+		},
+		"client auth mode without verification": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithClientCertificates(caFile, tls.RequestClientCert),
+			expErr:  "client auth mode RequestClientCert does not verify client certificates\n",
+		},
+		"negative reload interval": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithCertificateReload(-time.Second),
+			expErr:  "negative certificate reload interval provided\n",
+		},
+		"no reload trigger": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithCertificateReload(0),
+			expErr:  "certificate reload requires an interval or signals\n",
+		},
+		"client certificates without TLS": {
+			builder: New(60000).WithClientCertificates(caFile, tls.RequireAndVerifyClientCert),
+			expErr:  "client certificates and certificate reload require TLS",
+		},
+		"invalid certificate": {
+			builder: New(60000).WithTLS(caFile, keyFile),
+			expErr:  "failed to load certificate: tls: private key does not match public key",
+		},
+		"invalid client CA": {
+			builder: New(60000).WithTLS(certFile, keyFile).WithClientCertificates(keyFile, tls.RequireAndVerifyClientCert),
+			expErr:  "no certificates found in client CA " + keyFile,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.builder.Create()
+			if tt.expErr != "" {
+				assert.EqualError(t, err, tt.expErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, got.certs)
+		})
+	}
+
+	cmp, err := New(60000).Create()
+	require.NoError(t, err)
+	assert.EqualError(t, cmp.ReloadCertificates(), "TLS is not enabled")
+}
+
+func TestComponent_Run_TLSReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.pem")
+	cert, key := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert)
+	writeFile(t, keyFile, key)
+	writeFile(t, caFile, ca.pem)
+	clientPEM, clientKey := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKey)
+	require.NoError(t, err)
+
+	cmp, err := New(60004).WithTLS(certFile, keyFile).WithClientCertificates(caFile, tls.RequireAndVerifyClientCert).
+		WithCertificateReload(10 * time.Millisecond).WithHealthCheck().Create()
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, cmp.Run(ctx))
+		close(chDone)
+	}()
+
+	clientCfg := &tls.Config{RootCAs: ca.pool, Certificates: []tls.Certificate{clientCert}, MinVersion: tls.VersionTLS12}
+	conn, err := grpc.Dial("localhost:60004", grpc.WithTransportCredentials(credentials.NewTLS(clientCfg)), grpc.WithBlock())
+	require.NoError(t, err)
+	rsp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, rsp.GetStatus())
+	require.NoError(t, conn.Close())
+
+	noCertCfg := &tls.Config{RootCAs: ca.pool, MinVersion: tls.VersionTLS12}
+	conn, err = grpc.Dial("localhost:60004", grpc.WithTransportCredentials(credentials.NewTLS(noCertCfg)))
+	require.NoError(t, err)
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "clients without a certificate are rejected")
+	require.NoError(t, conn.Close())
+
+	serial := func() int64 {
+		conn, err := tls.Dial("tcp", "localhost:60004", clientCfg)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	assert.Equal(t, int64(2), serial())
+
+	cert, key = ca.issue(t, 4, x509.ExtKeyUsageServerAuth)
+	writeFile(t, keyFile, key)
+	writeFile(t, certFile, cert)
+	assert.Eventually(t, func() bool { return serial() == 4 }, time.Second, 10*time.Millisecond, "the rotated certificate is served")
+
+	writeFile(t, certFile, []byte("invalid"))
+	assert.Error(t, cmp.ReloadCertificates())
+	assert.Equal(t, int64(4), serial(), "the previous certificate is kept if the new one is invalid")
+
+	cnl()
+	<-chDone
+}
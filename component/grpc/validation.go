@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/beatlabs/patron/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validator is implemented by the messages with the Validate method generated by protoc-gen-validate.
+type validator interface {
+	Validate() error
+}
+
+// allValidator is implemented by the messages with the ValidateAll method generated by recent versions of protoc-gen-validate,
+// which returns all the violations instead of the first one.
+type allValidator interface {
+	ValidateAll() error
+}
+
+// fieldError is implemented by the errors of the fields generated by protoc-gen-validate.
+type fieldError interface {
+	Field() string
+	Reason() string
+	Cause() error
+}
+
+// multiError is implemented by the errors of ValidateAll generated by protoc-gen-validate.
+type multiError interface {
+	AllErrors() []error
+}
+
+// validate validates the message, if it is generated with protoc-gen-validate, returning an InvalidArgument error
+// with the violations of the fields in the details.
+func validate(ctx context.Context, msg interface{}) error {
+	var err error
+	switch v := msg.(type) {
+	case allValidator:
+		err = v.ValidateAll()
+	case validator:
+		err = v.Validate()
+	default:
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+
+	st := status.New(codes.InvalidArgument, err.Error())
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: fieldViolations(err, "")})
+	if detailsErr != nil {
+		log.FromContext(ctx).Warnf("failed to add the field violations to the status: %v", detailsErr)
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// fieldViolations flattens the errors of the fields, with the path of the fields of embedded messages, e.g. "address.city".
+func fieldViolations(err error, prefix string) []*errdetails.BadRequest_FieldViolation {
+	var multi multiError
+	if errors.As(err, &multi) {
+		var vv []*errdetails.BadRequest_FieldViolation
+		for _, e := range multi.AllErrors() {
+			vv = append(vv, fieldViolations(e, prefix)...)
+		}
+		return vv
+	}
+
+	var fe fieldError
+	if !errors.As(err, &fe) {
+		return []*errdetails.BadRequest_FieldViolation{{Field: prefix, Description: err.Error()}}
+	}
+	field := fe.Field()
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+	if cause := fe.Cause(); cause != nil {
+		var causeMulti multiError
+		var causeField fieldError
+		if errors.As(cause, &causeMulti) || errors.As(cause, &causeField) {
+			// the cause is the violation of a field of the embedded message
+			return fieldViolations(cause, field)
+		}
+	}
+	return []*errdetails.BadRequest_FieldViolation{{Field: field, Description: fe.Reason()}}
+}
+
+func validationUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := validate(ctx, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func validationStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &validatingServerStream{ServerStream: ss})
+}
+
+// validatingServerStream validates the messages received from the client.
+type validatingServerStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validate(s.Context(), m)
+}
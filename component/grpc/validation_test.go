@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testFieldError and testMultiError have the methods of the errors generated by protoc-gen-validate.
+type testFieldError struct {
+	field  string
+	reason string
+	cause  error
+}
+
+func (e testFieldError) Error() string  { return "invalid " + e.field + ": " + e.reason }
+func (e testFieldError) Field() string  { return e.field }
+func (e testFieldError) Reason() string { return e.reason }
+func (e testFieldError) Cause() error   { return e.cause }
+
+type testMultiError []error
+
+func (m testMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, e := range m {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (m testMultiError) AllErrors() []error { return m }
+
+type validatedRequest struct{ err error }
+
+func (r validatedRequest) Validate() error { return r.err }
+
+type allValidatedRequest struct{ err error }
+
+func (r allValidatedRequest) Validate() error    { return errors.New("only the first violation") }
+func (r allValidatedRequest) ValidateAll() error { return r.err }
+
+func TestValidationUnaryInterceptor(t *testing.T) {
+	type violation struct{ field, description string }
+
+	tests := map[string]struct {
+		req                interface{}
+		expectedViolations []violation
+		expectedMsg        string
+	}{
+		"not validated": {req: "request"},
+		"valid":         {req: validatedRequest{}},
+		"invalid field": {
+			req:                validatedRequest{err: testFieldError{field: "Firstname", reason: "value is required"}},
+			expectedViolations: []violation{{"Firstname", "value is required"}},
+			expectedMsg:        "invalid Firstname: value is required",
+		},
+		"all the violations": {
+			req: allValidatedRequest{err: testMultiError{
+				testFieldError{field: "Firstname", reason: "value is required"},
+				testFieldError{field: "Address", reason: "embedded message failed validation", cause: testMultiError{
+					testFieldError{field: "City", reason: "value is required"},
+					testFieldError{field: "Zip", reason: "value does not match regex"},
+				}},
+			}},
+			expectedViolations: []violation{
+				{"Firstname", "value is required"},
+				{"Address.City", "value is required"},
+				{"Address.Zip", "value does not match regex"},
+			},
+		},
+		"not a field error": {
+			req:                validatedRequest{err: errors.New("invalid request")},
+			expectedViolations: []violation{{"", "invalid request"}},
+			expectedMsg:        "invalid request",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			handled := false
+			_, err := validationUnaryInterceptor(context.Background(), tt.req, &grpc.UnaryServerInfo{},
+				func(context.Context, interface{}) (interface{}, error) {
+					handled = true
+					return nil, nil
+				})
+			if tt.expectedViolations == nil {
+				assert.NoError(t, err)
+				assert.True(t, handled)
+				return
+			}
+			assert.False(t, handled, "the handler is not called with an invalid request")
+			st := status.Convert(err)
+			assert.Equal(t, codes.InvalidArgument, st.Code())
+			if tt.expectedMsg != "" {
+				assert.Equal(t, tt.expectedMsg, st.Message())
+			}
+			require.Len(t, st.Details(), 1)
+			br, ok := st.Details()[0].(*errdetails.BadRequest)
+			require.True(t, ok)
+			violations := make([]violation, 0, len(br.GetFieldViolations()))
+			for _, v := range br.GetFieldViolations() {
+				violations = append(violations, violation{v.GetField(), v.GetDescription()})
+			}
+			assert.Equal(t, tt.expectedViolations, violations)
+		})
+	}
+}
+
+// messageStream receives the message of the request.
+type messageStream struct {
+	grpc.ServerStream
+	msg interface{}
+}
+
+func (s *messageStream) Context() context.Context { return context.Background() }
+
+func (s *messageStream) RecvMsg(m interface{}) error {
+	*(m.(*validatedRequest)) = s.msg.(validatedRequest)
+	return nil
+}
+
+func TestValidationStreamInterceptor(t *testing.T) {
+	invalid := validatedRequest{err: testFieldError{field: "Firstname", reason: "value is required"}}
+	for _, msg := range []validatedRequest{{}, invalid} {
+		err := validationStreamInterceptor(nil, &messageStream{msg: msg}, &grpc.StreamServerInfo{}, func(_ interface{}, ss grpc.ServerStream) error {
+			var req validatedRequest
+			return ss.RecvMsg(&req)
+		})
+		if msg.err == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+	}
+}
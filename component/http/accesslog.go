@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// AccessLogField is a field of the access log entries.
+type AccessLogField string
+
+const (
+	// AccessLogMethod is the method of the request.
+	AccessLogMethod AccessLogField = "method"
+	// AccessLogPath is the path template of the route, e.g. /users/:id.
+	AccessLogPath AccessLogField = "path"
+	// AccessLogStatus is the status code of the response.
+	AccessLogStatus AccessLogField = "status"
+	// AccessLogLatency is the duration of handling the request.
+	AccessLogLatency AccessLogField = "latency"
+	// AccessLogBytes is the number of bytes of the response body.
+	AccessLogBytes AccessLogField = "bytes"
+	// AccessLogCorrelationID is the correlation ID of the request.
+	AccessLogCorrelationID AccessLogField = "correlationID"
+	// AccessLogUserAgent is the user agent of the client.
+	AccessLogUserAgent AccessLogField = "userAgent"
+)
+
+var accessLogFields = []AccessLogField{
+	AccessLogMethod, AccessLogPath, AccessLogStatus, AccessLogLatency, AccessLogBytes, AccessLogCorrelationID, AccessLogUserAgent,
+}
+
+// AccessLogOptions configures the access log middleware.
+type AccessLogOptions struct {
+	// Fields of the entries, defaulting to all of them.
+	Fields []AccessLogField
+	// SampleRate is the fraction of the requests which are logged, between 0 and 1, e.g. 0.1 for high-QPS routes.
+	// Zero logs all the requests. Requests with a 5xx response are always logged.
+	SampleRate float64
+}
+
+// NewAccessLogMiddleware creates a MiddlewareFunc that logs an info entry with the selected fields for the requests of the route,
+// through the logger of the request context.
+func NewAccessLogMiddleware(path string, opts AccessLogOptions) (MiddlewareFunc, error) {
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return nil, errors.New("access log sample rate should be between 0 and 1")
+	}
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = accessLogFields
+	}
+	for _, f := range fields {
+		if !validAccessLogField(f) {
+			return nil, fmt.Errorf("unknown access log field %q", f)
+		}
+	}
+	sampleRate := opts.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			corID := getOrSetCorrelationID(r.Header)
+			aw := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(aw, r)
+
+			if aw.status < http.StatusInternalServerError && sampleRate < 1 && rand.Float64() >= sampleRate { //nolint:gosec
+				return
+			}
+			entry := make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				switch f {
+				case AccessLogMethod:
+					entry[string(f)] = r.Method
+				case AccessLogPath:
+					entry[string(f)] = path
+				case AccessLogStatus:
+					entry[string(f)] = aw.status
+				case AccessLogLatency:
+					entry[string(f)] = time.Since(now).String()
+				case AccessLogBytes:
+					entry[string(f)] = aw.bytes
+				case AccessLogCorrelationID:
+					entry[string(f)] = corID
+				case AccessLogUserAgent:
+					entry[string(f)] = r.UserAgent()
+				}
+			}
+			log.FromContext(r.Context()).Sub(entry).Info("access")
+		})
+	}, nil
+}
+
+func validAccessLogField(field AccessLogField) bool {
+	for _, f := range accessLogFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// accessLogWriter records the status code and the number of bytes of a response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if supported by the internal ResponseWriter.
+func (w *accessLogWriter) Flush() {
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
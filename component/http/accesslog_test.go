@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccessLogMiddleware(t *testing.T) {
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("accepted"))
+	})
+
+	tests := map[string]struct {
+		opts             AccessLogOptions
+		target           string
+		expectedFields   []string
+		unexpectedFields []string
+		expectedErr      string
+	}{
+		"all fields": {
+			target: "/users/1",
+			expectedFields: []string{
+				"method=GET", "path=/users/:id", "status=202", "latency=", "bytes=8", "correlationID=123", "userAgent=test-agent",
+			},
+		},
+		"selected fields": {
+			opts:             AccessLogOptions{Fields: []AccessLogField{AccessLogStatus, AccessLogPath}},
+			target:           "/users/1",
+			expectedFields:   []string{"path=/users/:id", "status=202"},
+			unexpectedFields: []string{"method=", "bytes=", "userAgent="},
+		},
+		"sampled out": {
+			opts:   AccessLogOptions{SampleRate: 0.000001},
+			target: "/users/1",
+		},
+		"server errors are not sampled out": {
+			opts:           AccessLogOptions{SampleRate: 0.000001},
+			target:         "/users/1?fail=true",
+			expectedFields: []string{"status=500", "bytes=0"},
+		},
+		"unknown field": {
+			opts:        AccessLogOptions{Fields: []AccessLogField{"host"}},
+			expectedErr: `unknown access log field "host"`,
+		},
+		"invalid sample rate": {
+			opts:        AccessLogOptions{SampleRate: 1.5},
+			expectedErr: "access log sample rate should be between 0 and 1",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewAccessLogMiddleware("/users/:id", tt.opts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set(correlation.HeaderID, "123")
+			req.Header.Set("User-Agent", "test-agent")
+			req = req.WithContext(log.WithContext(req.Context(), std.New(&buf, log.InfoLevel, nil)))
+			mw(hnd).ServeHTTP(httptest.NewRecorder(), req)
+
+			entry := buf.String()
+			if len(tt.expectedFields) == 0 {
+				assert.Empty(t, entry)
+				return
+			}
+			assert.True(t, strings.HasSuffix(entry, "access\n"))
+			for _, f := range tt.expectedFields {
+				assert.Contains(t, entry, f)
+			}
+			for _, f := range tt.unexpectedFields {
+				assert.NotContains(t, entry, f)
+			}
+		})
+	}
+}
+
+func TestComponent_AccessLog(t *testing.T) {
+	_, err := NewBuilder().WithAccessLog(AccessLogOptions{SampleRate: -1}).Create()
+	assert.EqualError(t, err, "access log sample rate should be between 0 and 1\n")
+
+	_, err = NewRawRouteBuilder("/", func(http.ResponseWriter, *http.Request) {}).MethodGet().
+		WithAccessLog(AccessLogOptions{SampleRate: 2}).Build()
+	assert.EqualError(t, err, "access log sample rate should be between 0 and 1")
+
+	rb := NewRoutesBuilder().
+		Append(NewRawRouteBuilder("/default", func(http.ResponseWriter, *http.Request) {}).MethodGet()).
+		Append(NewRawRouteBuilder("/selected", func(http.ResponseWriter, *http.Request) {}).MethodGet().
+			WithAccessLog(AccessLogOptions{Fields: []AccessLogField{AccessLogPath}}))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithAccessLog(AccessLogOptions{Fields: []AccessLogField{AccessLogMethod, AccessLogPath}}).Create()
+	require.NoError(t, err)
+	hnd := cmp.createHTTPServer().Handler
+
+	tests := map[string]struct {
+		path     string
+		expected string
+	}{
+		"component access log": {path: "/default", expected: "method=GET path=/default"},
+		"route access log":     {path: "/selected", expected: "path=/selected"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(log.WithContext(req.Context(), std.New(&buf, log.InfoLevel, nil)))
+			hnd.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Contains(t, buf.String(), tt.expected)
+			assert.Equal(t, 1, strings.Count(buf.String(), "access\n"))
+		})
+	}
+}
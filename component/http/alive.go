@@ -17,9 +17,15 @@ const (
 // AliveCheckFunc defines a function type for implementing a liveness check.
 type AliveCheckFunc func() AliveStatus
 
-func aliveCheckRoute(acf AliveCheckFunc) *RouteBuilder {
+// aliveCheckRoute reports the status of the liveness check. With registered checks,
+// it reports their results as well, and the service is alive only if all of them pass.
+func aliveCheckRoute(acf AliveCheckFunc, checks ...namedCheck) *RouteBuilder {
 
 	f := func(w http.ResponseWriter, r *http.Request) {
+		if len(checks) > 0 {
+			writeChecksReport(w, r, acf() != Unresponsive, "alive", "unresponsive", checks)
+			return
+		}
 		switch acf() {
 		case Alive:
 			w.WriteHeader(http.StatusOK)
@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 )
 
@@ -9,3 +10,10 @@ import (
 type Authenticator interface {
 	Authenticate(req *http.Request) (bool, error)
 }
+
+// ContextAuthenticator is an Authenticator which also returns a context for the authenticated request,
+// e.g. enriched with the claims of a token, which is then used by the rest of the request handling.
+type ContextAuthenticator interface {
+	Authenticator
+	AuthenticateContext(req *http.Request) (context.Context, bool, error)
+}
@@ -0,0 +1,74 @@
+// Package basic is a concrete implementation of the auth abstractions, which authenticates requests with HTTP Basic authentication.
+package basic
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+type userKey struct{}
+
+// CredentialStore validates the credentials of a user, e.g. against a database of password hashes.
+type CredentialStore interface {
+	Validate(user, password string) (bool, error)
+}
+
+// StaticCredentials is a CredentialStore of users and their passwords, which are compared in constant time.
+type StaticCredentials map[string]string
+
+// Validate the password of the user.
+func (sc StaticCredentials) Validate(user, password string) (bool, error) {
+	expected, ok := sc[user]
+	// the comparison takes place for unknown users as well, in order not to reveal them by the response time
+	match := Equal(expected, password)
+	return ok && match, nil
+}
+
+// Equal compares two secrets in constant time, without revealing their length, e.g. for implementing a CredentialStore.
+func Equal(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// Authenticator authenticates the request based on the following header key and value:
+// Authorization: Basic {credentials}, where {credentials} is the base64 encoding of the user and the password joined by a colon.
+type Authenticator struct {
+	store CredentialStore
+}
+
+// New constructor.
+func New(store CredentialStore) (*Authenticator, error) {
+	if store == nil {
+		return nil, errors.New("credential store is nil")
+	}
+	return &Authenticator{store: store}, nil
+}
+
+// Authenticate parses the header for the credentials and validates them with the credential store.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	_, ok, err := a.AuthenticateContext(req)
+	return ok, err
+}
+
+// AuthenticateContext authenticates the request, and returns its context with the authenticated user.
+func (a *Authenticator) AuthenticateContext(req *http.Request) (context.Context, bool, error) {
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		return nil, false, nil
+	}
+	ok, err := a.store.Validate(user, password)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return context.WithValue(req.Context(), userKey{}, user), true, nil
+}
+
+// UserFromContext returns the user who authenticated the request.
+func UserFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(userKey{}).(string)
+	return user, ok
+}
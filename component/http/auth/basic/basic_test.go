@@ -0,0 +1,87 @@
+package basic
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStore struct {
+	err error
+}
+
+func (ms mockStore) Validate(_, _ string) (bool, error) {
+	return false, ms.err
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.EqualError(t, err, "credential store is nil")
+
+	got, err := New(StaticCredentials{"user": "pass"})
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestAuthenticator_AuthenticateContext(t *testing.T) {
+	static, err := New(StaticCredentials{"john": "secret", "jane": "password"})
+	require.NoError(t, err)
+	failing, err := New(mockStore{err: errors.New("TEST")})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		authenticator *Authenticator
+		user          string
+		password      string
+		header        string
+		want          bool
+		wantErr       bool
+	}{
+		"authenticated":                          {authenticator: static, user: "john", password: "secret", want: true},
+		"not authenticated, wrong password":      {authenticator: static, user: "john", password: "password"},
+		"not authenticated, unknown user":        {authenticator: static, user: "james", password: "secret"},
+		"not authenticated, empty password":      {authenticator: static, user: "john"},
+		"not authenticated, header missing":      {authenticator: static},
+		"not authenticated, invalid encoding":    {authenticator: static, header: "Basic !!!"},
+		"not authenticated, invalid auth method": {authenticator: static, header: "Bearer token"},
+		"failed, validation returned err":        {authenticator: failing, user: "john", password: "secret", wantErr: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/test", nil)
+			require.NoError(t, err)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.password)
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			got, err := tt.authenticator.Authenticate(req)
+			assert.Equal(t, tt.want, got)
+
+			ctx, got, err := tt.authenticator.AuthenticateContext(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			if tt.want {
+				user, ok := UserFromContext(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, tt.user, user)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal("secret", "secret"))
+	assert.False(t, Equal("secret", "secrets"))
+	assert.False(t, Equal("secret", ""))
+}
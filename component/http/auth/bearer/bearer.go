@@ -0,0 +1,84 @@
+// Package bearer is a concrete implementation of the auth abstractions, which authenticates requests with opaque bearer tokens.
+package bearer
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Validator interface for validating tokens.
+type Validator interface {
+	Validate(token string) (bool, error)
+}
+
+// ValidatorFunc is a Validator callback, e.g. for looking the token up in a store or introspecting it with an authorization server.
+type ValidatorFunc func(token string) (bool, error)
+
+// Validate the token.
+func (f ValidatorFunc) Validate(token string) (bool, error) {
+	return f(token)
+}
+
+// staticTokens is a Validator of a static set of tokens, which are compared in constant time.
+type staticTokens [][sha256.Size]byte
+
+func (st staticTokens) Validate(token string) (bool, error) {
+	h := sha256.Sum256([]byte(token))
+	match := 0
+	// all the tokens are compared, in order not to reveal by the response time which one matched
+	for _, t := range st {
+		match |= subtle.ConstantTimeCompare(t[:], h[:])
+	}
+	return match == 1, nil
+}
+
+// Authenticator authenticates the request based on the following header key and value:
+// Authorization: Bearer {token}, where {token} is the token.
+type Authenticator struct {
+	val Validator
+}
+
+// New constructor.
+func New(val Validator) (*Authenticator, error) {
+	if val == nil {
+		return nil, errors.New("validator is nil")
+	}
+	return &Authenticator{val: val}, nil
+}
+
+// NewStatic creates an Authenticator for a static set of tokens, e.g. for internal services.
+func NewStatic(tokens ...string) (*Authenticator, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("tokens are empty")
+	}
+	st := make(staticTokens, 0, len(tokens))
+	for _, token := range tokens {
+		if token == "" {
+			return nil, errors.New("token is empty")
+		}
+		st = append(st, sha256.Sum256([]byte(token)))
+	}
+	return &Authenticator{val: st}, nil
+}
+
+// Authenticate parses the header for the token and validates it.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	headerVal := req.Header.Get("Authorization")
+	if headerVal == "" {
+		return false, nil
+	}
+
+	auth := strings.SplitN(headerVal, " ", 2)
+	if len(auth) != 2 || auth[1] == "" {
+		return false, nil
+	}
+
+	if strings.ToLower(auth[0]) != "bearer" {
+		return false, nil
+	}
+
+	return a.val.Validate(auth[1])
+}
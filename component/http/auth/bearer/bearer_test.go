@@ -0,0 +1,83 @@
+package bearer
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.EqualError(t, err, "validator is nil")
+
+	got, err := New(ValidatorFunc(func(string) (bool, error) { return true, nil }))
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestNewStatic(t *testing.T) {
+	tests := map[string]struct {
+		tokens      []string
+		expectedErr string
+	}{
+		"success":     {tokens: []string{"token1", "token2"}},
+		"no tokens":   {expectedErr: "tokens are empty"},
+		"empty token": {tokens: []string{"token1", ""}, expectedErr: "token is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := NewStatic(tt.tokens...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	static, err := NewStatic("token1", "token2")
+	require.NoError(t, err)
+	failing, err := New(ValidatorFunc(func(string) (bool, error) { return false, errors.New("TEST") }))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		authenticator *Authenticator
+		header        string
+		want          bool
+		wantErr       bool
+	}{
+		"authenticated":                          {authenticator: static, header: "Bearer token2", want: true},
+		"authenticated, lower case scheme":       {authenticator: static, header: "bearer token1", want: true},
+		"not authenticated, unknown token":       {authenticator: static, header: "Bearer token3"},
+		"not authenticated, token prefix":        {authenticator: static, header: "Bearer token"},
+		"not authenticated, header missing":      {authenticator: static},
+		"not authenticated, missing token":       {authenticator: static, header: "Bearer "},
+		"not authenticated, invalid auth method": {authenticator: static, header: "Apikey token1"},
+		"failed, validation returned err":        {authenticator: failing, header: "Bearer token1", wantErr: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/test", nil)
+			require.NoError(t, err)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			got, err := tt.authenticator.Authenticate(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
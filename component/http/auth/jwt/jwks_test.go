@@ -0,0 +1,128 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func jwksServer(t *testing.T, status *int32, requests *int32) *httptest.Server {
+	keys := []jsonWebKey{
+		{KeyType: "RSA", KeyID: "rsa", Use: "sig", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		{KeyType: "EC", KeyID: "ec", Curve: "P-256", X: encodeBigInt(ecdsaKey.X), Y: encodeBigInt(ecdsaKey.Y)},
+		{KeyType: "RSA", KeyID: "enc", Use: "enc", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		{KeyType: "oct", KeyID: "oct"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if s := atomic.LoadInt32(status); s != http.StatusOK {
+			w.WriteHeader(int(s))
+			return
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys}))
+	}))
+}
+
+func TestJWKS_key(t *testing.T) {
+	status, requests := int32(http.StatusOK), int32(0)
+	srv := jwksServer(t, &status, &requests)
+	defer srv.Close()
+
+	now := time.Now()
+	j := newJWKS(srv.URL, time.Hour)
+	j.now = func() time.Time { return now }
+
+	key, err := j.key("rsa")
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+	key, err = j.key("ec")
+	require.NoError(t, err)
+	assert.Equal(t, &ecdsaKey.PublicKey, key)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// keys which are not used for signatures or have unsupported types are skipped
+	key, err = j.key("enc")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+	key, err = j.key("oct")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// an unknown key triggers a refresh, once the minimum interval has passed
+	now = now.Add(minJWKSRefresh)
+	key, err = j.key("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// a failed refresh keeps the keys fetched previously
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	now = now.Add(time.Hour)
+	key, err = j.key("rsa")
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestJWKS_key_Unavailable(t *testing.T) {
+	status, requests := int32(http.StatusInternalServerError), int32(0)
+	srv := jwksServer(t, &status, &requests)
+	defer srv.Close()
+
+	now := time.Now()
+	j := newJWKS(srv.URL, time.Hour)
+	j.now = func() time.Time { return now }
+
+	_, err := j.key("rsa")
+	assert.EqualError(t, err, "failed to fetch JWKS: unexpected status code 500")
+	_, err = j.key("rsa")
+	assert.EqualError(t, err, "failed to fetch JWKS: unexpected status code 500")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	now = now.Add(minJWKSRefresh)
+	key, err := j.key("rsa")
+	require.NoError(t, err)
+	assert.Equal(t, &rsaKey.PublicKey, key)
+}
+
+func TestAuthenticator_JWKS(t *testing.T) {
+	status, requests := int32(http.StatusInternalServerError), int32(0)
+	srv := jwksServer(t, &status, &requests)
+	defer srv.Close()
+
+	a, err := New(JWKS(srv.URL, time.Hour))
+	require.NoError(t, err)
+	token := "Bearer " + sign(t, "ES256", "ec", Claims{"sub": "user"})
+
+	ok, err := a.Authenticate(request(t, token))
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	a.jwks.attemptedAt = time.Time{}
+	ok, err = a.Authenticate(request(t, token))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestJWKS_Option(t *testing.T) {
+	a := &Authenticator{}
+	assert.EqualError(t, JWKS("invalid", time.Hour)(a), `JWKS endpoint "invalid" is invalid`)
+	assert.EqualError(t, JWKS("http://localhost/jwks", 0)(a), "JWKS refresh interval must be positive")
+	assert.NoError(t, JWKS("http://localhost/jwks", time.Hour)(a))
+	assert.NotNil(t, a.jwks)
+}
@@ -0,0 +1,341 @@
+// Package jwt is a concrete implementation of the auth abstractions, which validates JSON Web Tokens.
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	// hash functions used by the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/beatlabs/patron/log"
+)
+
+type claimsKey struct{}
+
+// Claims of a validated token.
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.string("sub")
+}
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string {
+	return c.string("iss")
+}
+
+// Audience returns the "aud" claim, which can be either a single value or a list of values.
+func (c Claims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		vals := make([]string, 0, len(aud))
+		for _, v := range aud {
+			if s, ok := v.(string); ok {
+				vals = append(vals, s)
+			}
+		}
+		return vals
+	default:
+		return nil
+	}
+}
+
+func (c Claims) string(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c Claims) time(name string) (time.Time, bool, error) {
+	v, ok := c[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("claim %s is not a number", name)
+	}
+	return time.Unix(int64(f), 0), true, nil
+}
+
+// ClaimsFromContext returns the claims of the token which authenticated the request.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(Claims)
+	return c, ok
+}
+
+// Authenticator authenticates the request based on a JSON Web Token in the following header key and value:
+// Authorization: Bearer {token}.
+// The signature of the token is verified with HMAC, RSA or ECDSA keys, which are either provided or fetched from a JWKS endpoint.
+// The expiration and not-before claims are always validated, while the issuer and audience are validated when configured.
+type Authenticator struct {
+	hmacKey    []byte
+	keys       map[string]crypto.PublicKey
+	jwks       *jwks
+	issuer     string
+	audience   string
+	leeway     time.Duration
+	now        func() time.Time
+	algorithms map[string]bool
+}
+
+// New constructor. At least a key or a JWKS endpoint has to be provided.
+func New(oo ...OptionFunc) (*Authenticator, error) {
+	a := &Authenticator{keys: make(map[string]crypto.PublicKey), now: time.Now}
+
+	for _, o := range oo {
+		if err := o(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(a.hmacKey) == 0 && len(a.keys) == 0 && a.jwks == nil {
+		return nil, errors.New("no keys or JWKS endpoint provided")
+	}
+
+	return a, nil
+}
+
+// Authenticate parses the header for the token and validates it.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	_, ok, err := a.AuthenticateContext(req)
+	return ok, err
+}
+
+// AuthenticateContext parses the header for the token and validates it,
+// returning a context with the claims of the token which can be retrieved with ClaimsFromContext.
+// Invalid tokens fail the authentication, while an error is returned only if the keys could not be fetched.
+func (a *Authenticator) AuthenticateContext(req *http.Request) (context.Context, bool, error) {
+	headerVal := req.Header.Get("Authorization")
+	if headerVal == "" {
+		return nil, false, nil
+	}
+
+	auth := strings.SplitN(headerVal, " ", 2)
+	if len(auth) != 2 || strings.ToLower(auth[0]) != "bearer" {
+		return nil, false, nil
+	}
+
+	claims, err := a.validate(auth[1])
+	if err != nil {
+		var kerr *keyError
+		if errors.As(err, &kerr) {
+			return nil, false, err
+		}
+		log.FromContext(req.Context()).Debugf("invalid token: %v", err)
+		return nil, false, nil
+	}
+
+	return context.WithValue(req.Context(), claimsKey{}, claims), true, nil
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// keyError is returned when the keys could not be retrieved, which is not a failure of the token itself.
+type keyError struct {
+	err error
+}
+
+func (e *keyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *keyError) Unwrap() error {
+	return e.err
+}
+
+func (a *Authenticator) validate(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is malformed")
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, fmt.Errorf("failed to decode header: %w", err)
+	}
+	if a.algorithms != nil && !a.algorithms[hdr.Algorithm] {
+		return nil, fmt.Errorf("algorithm %s is not allowed", hdr.Algorithm)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	if err := a.verify(hdr, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("failed to decode claims: %w", err)
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (a *Authenticator) verify(hdr header, signed string, sig []byte) error {
+	hash, err := hashOf(hdr.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(hdr.Algorithm, "HS") {
+		if len(a.hmacKey) == 0 {
+			return errors.New("no HMAC key provided")
+		}
+		mac := hmac.New(hash.New, a.hmacKey)
+		_, _ = mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("signature is invalid")
+		}
+		return nil
+	}
+
+	key, err := a.publicKey(hdr.KeyID)
+	if err != nil {
+		return err
+	}
+
+	h := hash.New()
+	_, _ = h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch hdr.Algorithm[:2] {
+	case "RS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not valid for algorithm %s", hdr.Algorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, sig); err != nil {
+			return errors.New("signature is invalid")
+		}
+	case "PS":
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not valid for algorithm %s", hdr.Algorithm)
+		}
+		if err := rsa.VerifyPSS(k, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			return errors.New("signature is invalid")
+		}
+	case "ES":
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key is not valid for algorithm %s", hdr.Algorithm)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("signature is invalid")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("signature is invalid")
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) publicKey(kid string) (crypto.PublicKey, error) {
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	if a.jwks != nil {
+		key, err := a.jwks.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q not found", kid)
+}
+
+func (a *Authenticator) validateClaims(claims Claims) error {
+	now := a.now()
+
+	exp, ok, err := claims.time("exp")
+	if err != nil {
+		return err
+	}
+	if ok && !now.Before(exp.Add(a.leeway)) {
+		return errors.New("token is expired")
+	}
+
+	nbf, ok, err := claims.time("nbf")
+	if err != nil {
+		return err
+	}
+	if ok && now.Add(a.leeway).Before(nbf) {
+		return errors.New("token is not valid yet")
+	}
+
+	if a.issuer != "" && claims.Issuer() != a.issuer {
+		return fmt.Errorf("issuer %q is not valid", claims.Issuer())
+	}
+
+	if a.audience != "" {
+		for _, aud := range claims.Audience() {
+			if aud == a.audience {
+				return nil
+			}
+		}
+		return fmt.Errorf("audience %v is not valid", claims.Audience())
+	}
+
+	return nil
+}
+
+func hashOf(alg string) (crypto.Hash, error) {
+	if len(alg) != 5 {
+		return 0, fmt.Errorf("algorithm %q is not supported", alg)
+	}
+
+	switch alg[:2] {
+	case "HS", "RS", "PS", "ES":
+	default:
+		return 0, fmt.Errorf("algorithm %q is not supported", alg)
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("algorithm %q is not supported", alg)
+	}
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,187 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	hmacKey   = []byte("secret")
+	rsaKey    *rsa.PrivateKey
+	ecdsaKey  *ecdsa.PrivateKey
+	testNow   = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	validExp  = float64(testNow.Add(time.Hour).Unix())
+	pastExp   = float64(testNow.Add(-time.Hour).Unix())
+	futureNbf = float64(testNow.Add(time.Hour).Unix())
+)
+
+func init() {
+	var err error
+	rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecdsaKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func sign(t *testing.T, alg, kid string, claims Claims) string {
+	hdr := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		hdr["kid"] = kid
+	}
+	h, err := json.Marshal(hdr)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	hash, err := hashOf(alg)
+	require.NoError(t, err)
+	digest := hash.New()
+	_, _ = digest.Write([]byte(signed))
+
+	var sig []byte
+	switch alg[:2] {
+	case "HS":
+		mac := hmac.New(hash.New, hmacKey)
+		_, _ = mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, hash, digest.Sum(nil))
+		require.NoError(t, err)
+	case "PS":
+		sig, err = rsa.SignPSS(rand.Reader, rsaKey, hash, digest.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		require.NoError(t, err)
+	case "ES":
+		r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, digest.Sum(nil))
+		require.NoError(t, err)
+		sig = append(padded(r, 32), padded(s, 32)...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func padded(i *big.Int, size int) []byte {
+	b := i.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func request(t *testing.T, auth string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return req
+}
+
+func TestNew(t *testing.T) {
+	got, err := New()
+	assert.EqualError(t, err, "no keys or JWKS endpoint provided")
+	assert.Nil(t, got)
+
+	got, err = New(HMACKey(nil))
+	assert.EqualError(t, err, "HMAC key is empty")
+	assert.Nil(t, got)
+
+	got, err = New(HMACKey(hmacKey))
+	assert.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestAuthenticator_AuthenticateContext(t *testing.T) {
+	claims := Claims{"sub": "user", "iss": "issuer", "aud": []string{"api", "other"}, "exp": validExp}
+
+	tests := map[string]struct {
+		auth          string
+		oo            []OptionFunc
+		authenticated bool
+	}{
+		"HS256":                 {auth: "Bearer " + sign(t, "HS256", "", claims), authenticated: true},
+		"HS512":                 {auth: "Bearer " + sign(t, "HS512", "", claims), authenticated: true},
+		"RS256":                 {auth: "Bearer " + sign(t, "RS256", "rsa", claims), authenticated: true},
+		"PS384":                 {auth: "Bearer " + sign(t, "PS384", "rsa", claims), authenticated: true},
+		"ES256":                 {auth: "Bearer " + sign(t, "ES256", "ec", claims), authenticated: true},
+		"issuer and audience":   {auth: "Bearer " + sign(t, "RS256", "rsa", claims), oo: []OptionFunc{Issuer("issuer"), Audience("api")}, authenticated: true},
+		"allowed algorithm":     {auth: "Bearer " + sign(t, "RS256", "rsa", claims), oo: []OptionFunc{Algorithms("RS256")}, authenticated: true},
+		"missing header":        {},
+		"wrong scheme":          {auth: "Apikey " + sign(t, "HS256", "", claims)},
+		"malformed token":       {auth: "Bearer abc.def"},
+		"none algorithm":        {auth: "Bearer " + unsigned(t, claims)},
+		"not allowed algorithm": {auth: "Bearer " + sign(t, "HS256", "", claims), oo: []OptionFunc{Algorithms("RS256")}},
+		"unknown key":           {auth: "Bearer " + sign(t, "RS256", "unknown", claims)},
+		"key type mismatch":     {auth: "Bearer " + sign(t, "ES256", "rsa", claims)},
+		"tampered token":        {auth: "Bearer " + sign(t, "HS256", "", claims) + "x"},
+		"expired":               {auth: "Bearer " + sign(t, "HS256", "", Claims{"exp": pastExp})},
+		"expired within leeway": {auth: "Bearer " + sign(t, "HS256", "", Claims{"exp": pastExp}), oo: []OptionFunc{Leeway(2 * time.Hour)},
+			authenticated: true},
+		"not valid yet":    {auth: "Bearer " + sign(t, "HS256", "", Claims{"nbf": futureNbf})},
+		"invalid issuer":   {auth: "Bearer " + sign(t, "HS256", "", claims), oo: []OptionFunc{Issuer("other-issuer")}},
+		"invalid audience": {auth: "Bearer " + sign(t, "HS256", "", claims), oo: []OptionFunc{Audience("web")}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			oo := append([]OptionFunc{HMACKey(hmacKey), PublicKey("rsa", &rsaKey.PublicKey), PublicKey("ec", &ecdsaKey.PublicKey)}, tt.oo...)
+			a, err := New(oo...)
+			require.NoError(t, err)
+			a.now = func() time.Time { return testNow }
+
+			ctx, authenticated, err := a.AuthenticateContext(request(t, tt.auth))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.authenticated, authenticated)
+			if !tt.authenticated {
+				assert.Nil(t, ctx)
+				return
+			}
+			got, ok := ClaimsFromContext(ctx)
+			require.True(t, ok)
+			assert.NotEmpty(t, got)
+
+			ok, err = a.Authenticate(request(t, tt.auth))
+			assert.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func unsigned(t *testing.T, claims Claims) string {
+	h, err := json.Marshal(map[string]string{"alg": "none"})
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c) + "."
+}
+
+func TestClaims(t *testing.T) {
+	c := Claims{"sub": "user", "iss": "issuer", "aud": "api"}
+	assert.Equal(t, "user", c.Subject())
+	assert.Equal(t, "issuer", c.Issuer())
+	assert.Equal(t, []string{"api"}, c.Audience())
+
+	c = Claims{"aud": []interface{}{"api", "web"}}
+	assert.Equal(t, []string{"api", "web"}, c.Audience())
+	assert.Empty(t, c.Subject())
+	assert.Nil(t, Claims{}.Audience())
+}
+
+func TestPublicKey(t *testing.T) {
+	a := &Authenticator{keys: make(map[string]crypto.PublicKey)}
+	assert.EqualError(t, PublicKey("kid", "key")(a), "public key is not an RSA or ECDSA key")
+	assert.NoError(t, PublicKey("kid", &rsaKey.PublicKey)(a))
+	assert.Equal(t, &rsaKey.PublicKey, a.keys["kid"])
+}
@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OptionFunc definition for configuring the authenticator in a functional way.
+type OptionFunc func(*Authenticator) error
+
+// HMACKey option for validating tokens signed with HS256, HS384 or HS512.
+func HMACKey(key []byte) OptionFunc {
+	return func(a *Authenticator) error {
+		if len(key) == 0 {
+			return errors.New("HMAC key is empty")
+		}
+		a.hmacKey = key
+		return nil
+	}
+}
+
+// PublicKey option for validating tokens signed with RSA (RS256, RS384, RS512, PS256, PS384, PS512)
+// or ECDSA (ES256, ES384, ES512) keys. The key id matches the "kid" header of the tokens, which may be empty.
+func PublicKey(kid string, key crypto.PublicKey) OptionFunc {
+	return func(a *Authenticator) error {
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			return errors.New("public key is not an RSA or ECDSA key")
+		}
+		a.keys[kid] = key
+		return nil
+	}
+}
+
+// JWKS option for validating tokens with the keys of a JWKS endpoint, which are refreshed at the provided interval.
+// Tokens signed with an unknown key trigger a refresh as well, in order to pick up rotated keys.
+func JWKS(endpoint string, refresh time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("JWKS endpoint %q is invalid", endpoint)
+		}
+		if refresh <= 0 {
+			return errors.New("JWKS refresh interval must be positive")
+		}
+		a.jwks = newJWKS(endpoint, refresh)
+		return nil
+	}
+}
+
+// Issuer option for validating the "iss" claim of the tokens.
+func Issuer(iss string) OptionFunc {
+	return func(a *Authenticator) error {
+		if iss == "" {
+			return errors.New("issuer is empty")
+		}
+		a.issuer = iss
+		return nil
+	}
+}
+
+// Audience option for validating that the "aud" claim of the tokens contains the audience.
+func Audience(aud string) OptionFunc {
+	return func(a *Authenticator) error {
+		if aud == "" {
+			return errors.New("audience is empty")
+		}
+		a.audience = aud
+		return nil
+	}
+}
+
+// Leeway option for tolerating clock skew, when validating the expiration and not-before claims.
+func Leeway(leeway time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		if leeway < 0 {
+			return errors.New("leeway must not be negative")
+		}
+		a.leeway = leeway
+		return nil
+	}
+}
+
+// Algorithms option for restricting the signing algorithms of the tokens, e.g. "RS256".
+func Algorithms(algs ...string) OptionFunc {
+	return func(a *Authenticator) error {
+		if len(algs) == 0 {
+			return errors.New("algorithms are empty")
+		}
+		a.algorithms = make(map[string]bool, len(algs))
+		for _, alg := range algs {
+			if _, err := hashOf(alg); err != nil {
+				return err
+			}
+			a.algorithms[alg] = true
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package oidc
+
+import (
+	"sync"
+	"time"
+)
+
+// maxMemoryCacheEntries bounds the default cache, whose entries are evicted when expired or when it is full.
+const maxMemoryCacheEntries = 10000
+
+type memoryCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// memoryCache is the default in-memory cache of the introspection responses.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	now     func() time.Time
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]memoryCacheEntry), now: time.Now}
+}
+
+func (c *memoryCache) Get(key string) (interface{}, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (c *memoryCache) Purge() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]memoryCacheEntry)
+	return nil
+}
+
+func (c *memoryCache) Remove(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+func (c *memoryCache) Set(key string, value interface{}) error {
+	return c.SetTTL(key, value, 0)
+}
+
+func (c *memoryCache) SetTTL(key string, value interface{}, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxMemoryCacheEntries {
+		for k, e := range c.entries {
+			if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= maxMemoryCacheEntries {
+		// no expired entries are left, so an arbitrary one is evicted
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+
+	e := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	c.entries[key] = e
+	return nil
+}
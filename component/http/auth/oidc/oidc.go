@@ -0,0 +1,304 @@
+// Package oidc is a concrete implementation of the auth abstractions, which validates bearer tokens
+// with the token introspection endpoint of an OpenID Connect provider.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+	"github.com/beatlabs/patron/component/http/auth/jwt"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	defaultCacheTTL = time.Minute
+	// discovery is attempted at most this often, in order not to flood the provider while it is unavailable
+	minDiscoveryRetry = 10 * time.Second
+	discoveryPath     = "/.well-known/openid-configuration"
+	cacheKeyPrefix    = "oidc:"
+)
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the introspection response for the token which authenticated the request.
+func ClaimsFromContext(ctx context.Context) (jwt.Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(jwt.Claims)
+	return c, ok
+}
+
+// Authenticator authenticates the request based on an OAuth2 access token in the following header key and value:
+// Authorization: Bearer {token}.
+// The introspection endpoint of the provider is resolved with OpenID Connect discovery,
+// and the results of the introspection are cached in order to avoid calling the provider on every request.
+type Authenticator struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	audience     string
+	scopes       []string
+	cache        cache.TTLCache
+	cacheTTL     time.Duration
+	cl           *http.Client
+	now          func() time.Time
+
+	mu                    sync.Mutex
+	introspectionEndpoint string
+	discoveredAt          time.Time
+	discoveryErr          error
+}
+
+// New constructor, which requires the issuer URL of the provider and the client credentials used for the introspection.
+func New(issuer string, oo ...OptionFunc) (*Authenticator, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("issuer %q is invalid", issuer)
+	}
+
+	a := &Authenticator{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		cache:    newMemoryCache(),
+		cacheTTL: defaultCacheTTL,
+		cl:       &http.Client{Timeout: defaultTimeout},
+		now:      time.Now,
+	}
+
+	for _, o := range oo {
+		if err := o(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.clientID == "" {
+		return nil, errors.New("client credentials are not provided")
+	}
+
+	return a, nil
+}
+
+// Authenticate parses the header for the token and validates it.
+func (a *Authenticator) Authenticate(req *http.Request) (bool, error) {
+	_, ok, err := a.AuthenticateContext(req)
+	return ok, err
+}
+
+// AuthenticateContext parses the header for the token and validates it with the provider,
+// returning a context with the claims of the introspection response which can be retrieved with ClaimsFromContext.
+// Inactive tokens fail the authentication, while an error is returned only if the provider could not be reached.
+func (a *Authenticator) AuthenticateContext(req *http.Request) (context.Context, bool, error) {
+	headerVal := req.Header.Get("Authorization")
+	if headerVal == "" {
+		return nil, false, nil
+	}
+
+	auth := strings.SplitN(headerVal, " ", 2)
+	if len(auth) != 2 || strings.ToLower(auth[0]) != "bearer" || auth[1] == "" {
+		return nil, false, nil
+	}
+
+	claims, err := a.claims(req.Context(), auth[1])
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := a.validate(claims); err != nil {
+		log.FromContext(req.Context()).Debugf("invalid token: %v", err)
+		return nil, false, nil
+	}
+
+	return context.WithValue(req.Context(), claimsKey{}, claims), true, nil
+}
+
+// claims returns the introspection response of the token, either cached or fetched from the provider.
+func (a *Authenticator) claims(ctx context.Context, token string) (jwt.Claims, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := cacheKeyPrefix + hex.EncodeToString(sum[:])
+
+	claims, ok := a.cached(ctx, key)
+	if ok {
+		return claims, nil
+	}
+
+	claims, err := a.introspect(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := a.cacheTTL
+	if exp, ok := claims["exp"].(float64); ok {
+		if untilExp := time.Unix(int64(exp), 0).Sub(a.now()); untilExp < ttl {
+			ttl = untilExp
+		}
+	}
+	if ttl > 0 {
+		b, err := json.Marshal(claims)
+		if err == nil {
+			err = a.cache.SetTTL(key, b, ttl)
+		}
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to cache introspection response: %v", err)
+		}
+	}
+
+	return claims, nil
+}
+
+func (a *Authenticator) cached(ctx context.Context, key string) (jwt.Claims, bool) {
+	v, ok, err := a.cache.Get(key)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to get introspection response from cache: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var b []byte
+	switch val := v.(type) {
+	case []byte:
+		b = val
+	case string:
+		b = []byte(val)
+	default:
+		return nil, false
+	}
+
+	var claims jwt.Claims
+	if err := json.Unmarshal(b, &claims); err != nil {
+		log.FromContext(ctx).Errorf("failed to decode cached introspection response: %v", err)
+		return nil, false
+	}
+	return claims, true
+}
+
+func (a *Authenticator) introspect(ctx context.Context, token string) (jwt.Claims, error) {
+	endpoint, err := a.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create introspection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	rsp, err := a.cl.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to introspect token: %w", err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to introspect token: unexpected status code %d", rsp.StatusCode)
+	}
+
+	var claims jwt.Claims
+	if err := json.NewDecoder(rsp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+	return claims, nil
+}
+
+// discover returns the introspection endpoint advertised in the discovery document of the provider.
+// The discovery is not bound to the context of the request which triggered it, since its result is shared by all requests.
+func (a *Authenticator) discover() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.introspectionEndpoint != "" {
+		return a.introspectionEndpoint, nil
+	}
+
+	now := a.now()
+	if now.Sub(a.discoveredAt) < minDiscoveryRetry {
+		return "", a.discoveryErr
+	}
+	a.discoveredAt = now
+
+	endpoint, err := a.fetchDiscoveryDocument()
+	if err != nil {
+		a.discoveryErr = err
+		return "", err
+	}
+	a.introspectionEndpoint = endpoint
+	return endpoint, nil
+}
+
+func (a *Authenticator) fetchDiscoveryDocument() (string, error) {
+	rsp, err := a.cl.Get(a.issuer + discoveryPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch discovery document: unexpected status code %d", rsp.StatusCode)
+	}
+
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		IntrospectionEndpoint string `json:"introspection_endpoint"`
+	}
+	if err := json.NewDecoder(rsp.Body).Decode(&doc); err != nil {
+		return "", fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != a.issuer {
+		return "", fmt.Errorf("issuer %q of the discovery document does not match", doc.Issuer)
+	}
+	if doc.IntrospectionEndpoint == "" {
+		return "", errors.New("introspection endpoint is not advertised by the provider")
+	}
+
+	return doc.IntrospectionEndpoint, nil
+}
+
+func (a *Authenticator) validate(claims jwt.Claims) error {
+	if active, _ := claims["active"].(bool); !active {
+		return errors.New("token is not active")
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && !a.now().Before(time.Unix(int64(exp), 0)) {
+		return errors.New("token is expired")
+	}
+
+	if iss := claims.Issuer(); iss != "" && strings.TrimSuffix(iss, "/") != a.issuer {
+		return fmt.Errorf("issuer %q is not valid", iss)
+	}
+
+	if a.audience != "" && !contains(claims.Audience(), a.audience) {
+		return fmt.Errorf("audience %v is not valid", claims.Audience())
+	}
+
+	scope, _ := claims["scope"].(string)
+	granted := strings.Fields(scope)
+	for _, s := range a.scopes {
+		if !contains(granted, s) {
+			return fmt.Errorf("scope %s is not granted", s)
+		}
+	}
+
+	return nil
+}
+
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
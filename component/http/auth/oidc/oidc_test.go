@@ -0,0 +1,220 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type provider struct {
+	srv            *httptest.Server
+	discoveries    int32
+	introspections int32
+	discoveryCode  int32
+	issuer         string
+	responses      map[string]map[string]interface{}
+}
+
+func newProvider(t *testing.T) *provider {
+	p := &provider{discoveryCode: http.StatusOK, responses: map[string]map[string]interface{}{
+		"active": {
+			"active": true, "sub": "user", "scope": "read write", "aud": "api",
+			"exp": float64(testNow.Add(time.Hour).Unix()),
+		},
+		"expiring": {"active": true, "exp": float64(testNow.Add(time.Second).Unix())},
+		"expired":  {"active": true, "exp": float64(testNow.Add(-time.Second).Unix())},
+		"other":    {"active": true, "iss": "https://other.example.com"},
+	}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&p.discoveries, 1)
+		if code := atomic.LoadInt32(&p.discoveryCode); code != http.StatusOK {
+			w.WriteHeader(int(code))
+			return
+		}
+		issuer := p.issuer
+		if issuer == "" {
+			issuer = p.srv.URL
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"introspection_endpoint": p.srv.URL + "/introspect",
+		}))
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&p.introspections, 1)
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		rsp, ok := p.responses[r.PostForm.Get("token")]
+		if !ok {
+			rsp = map[string]interface{}{"active": false}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(rsp))
+	})
+	p.srv = httptest.NewServer(mux)
+	return p
+}
+
+func request(t *testing.T, auth string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return req
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		issuer string
+		oo     []OptionFunc
+		err    string
+	}{
+		"success":             {issuer: "https://issuer.example.com", oo: []OptionFunc{ClientCredentials("client", "secret")}},
+		"invalid issuer":      {issuer: "issuer", oo: []OptionFunc{ClientCredentials("client", "secret")}, err: `issuer "issuer" is invalid`},
+		"missing credentials": {issuer: "https://issuer.example.com", err: "client credentials are not provided"},
+		"empty client id":     {issuer: "https://issuer.example.com", oo: []OptionFunc{ClientCredentials("", "secret")}, err: "client id is empty"},
+		"empty client secret": {issuer: "https://issuer.example.com", oo: []OptionFunc{ClientCredentials("client", "")}, err: "client secret is empty"},
+		"empty audience":      {issuer: "https://issuer.example.com", oo: []OptionFunc{Audience("")}, err: "audience is empty"},
+		"empty scopes":        {issuer: "https://issuer.example.com", oo: []OptionFunc{Scopes()}, err: "scopes are empty"},
+		"invalid cache TTL":   {issuer: "https://issuer.example.com", oo: []OptionFunc{CacheTTL(0)}, err: "cache TTL must be positive"},
+		"nil cache":           {issuer: "https://issuer.example.com", oo: []OptionFunc{Cache(nil)}, err: "cache is nil"},
+		"invalid timeout":     {issuer: "https://issuer.example.com", oo: []OptionFunc{Timeout(0)}, err: "timeout must be positive"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.issuer, tt.oo...)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_AuthenticateContext(t *testing.T) {
+	p := newProvider(t)
+	defer p.srv.Close()
+
+	tests := map[string]struct {
+		auth          string
+		oo            []OptionFunc
+		authenticated bool
+	}{
+		"active":             {auth: "Bearer active", authenticated: true},
+		"scopes":             {auth: "Bearer active", oo: []OptionFunc{Scopes("read", "write")}, authenticated: true},
+		"audience":           {auth: "Bearer active", oo: []OptionFunc{Audience("api")}, authenticated: true},
+		"missing header":     {},
+		"wrong scheme":       {auth: "Apikey active"},
+		"inactive":           {auth: "Bearer unknown"},
+		"expired":            {auth: "Bearer expired"},
+		"other issuer":       {auth: "Bearer other"},
+		"scope not granted":  {auth: "Bearer active", oo: []OptionFunc{Scopes("read", "admin")}},
+		"audience not valid": {auth: "Bearer active", oo: []OptionFunc{Audience("web")}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, err := New(p.srv.URL, append([]OptionFunc{ClientCredentials("client", "secret")}, tt.oo...)...)
+			require.NoError(t, err)
+			a.now = func() time.Time { return testNow }
+
+			ctx, authenticated, err := a.AuthenticateContext(request(t, tt.auth))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.authenticated, authenticated)
+			if !tt.authenticated {
+				assert.Nil(t, ctx)
+				return
+			}
+			claims, ok := ClaimsFromContext(ctx)
+			require.True(t, ok)
+			assert.Equal(t, "user", claims.Subject())
+		})
+	}
+}
+
+func TestAuthenticator_Cache(t *testing.T) {
+	p := newProvider(t)
+	defer p.srv.Close()
+
+	a, err := New(p.srv.URL, ClientCredentials("client", "secret"))
+	require.NoError(t, err)
+	a.now = func() time.Time { return testNow }
+
+	for i := 0; i < 3; i++ {
+		ok, err := a.Authenticate(request(t, "Bearer active"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = a.Authenticate(request(t, "Bearer unknown"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.discoveries))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.introspections))
+
+	// responses are not cached beyond the expiration of the token
+	mc := newMemoryCache()
+	mc.now = func() time.Time { return testNow }
+	a.cache = mc
+	ok, err := a.Authenticate(request(t, "Bearer expiring"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	mc.now = func() time.Time { return testNow.Add(time.Second) }
+	_, err = a.Authenticate(request(t, "Bearer expiring"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&p.introspections))
+}
+
+func TestAuthenticator_ProviderErrors(t *testing.T) {
+	p := newProvider(t)
+	defer p.srv.Close()
+	atomic.StoreInt32(&p.discoveryCode, http.StatusServiceUnavailable)
+
+	now := testNow
+	a, err := New(p.srv.URL, ClientCredentials("client", "other"))
+	require.NoError(t, err)
+	a.now = func() time.Time { return now }
+
+	ok, err := a.Authenticate(request(t, "Bearer active"))
+	assert.EqualError(t, err, "failed to fetch discovery document: unexpected status code 503")
+	assert.False(t, ok)
+
+	// discovery is not retried before the minimum interval
+	_, err = a.Authenticate(request(t, "Bearer active"))
+	assert.EqualError(t, err, "failed to fetch discovery document: unexpected status code 503")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.discoveries))
+
+	atomic.StoreInt32(&p.discoveryCode, http.StatusOK)
+	now = now.Add(minDiscoveryRetry)
+	ok, err = a.Authenticate(request(t, "Bearer active"))
+	assert.EqualError(t, err, "failed to introspect token: unexpected status code 401")
+	assert.False(t, ok)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.discoveries))
+}
+
+func TestAuthenticator_DiscoveryIssuerMismatch(t *testing.T) {
+	p := newProvider(t)
+	defer p.srv.Close()
+	p.issuer = "https://other.example.com"
+
+	a, err := New(p.srv.URL, ClientCredentials("client", "secret"))
+	require.NoError(t, err)
+
+	_, err = a.Authenticate(request(t, "Bearer active"))
+	assert.EqualError(t, err, `issuer "https://other.example.com" of the discovery document does not match`)
+}
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/beatlabs/patron/cache"
+)
+
+// OptionFunc definition for configuring the authenticator in a functional way.
+type OptionFunc func(*Authenticator) error
+
+// ClientCredentials option for authenticating to the introspection endpoint of the provider.
+func ClientCredentials(id, secret string) OptionFunc {
+	return func(a *Authenticator) error {
+		if id == "" {
+			return errors.New("client id is empty")
+		}
+		if secret == "" {
+			return errors.New("client secret is empty")
+		}
+		a.clientID = id
+		a.clientSecret = secret
+		return nil
+	}
+}
+
+// Audience option for validating that the "aud" claim of the tokens contains the audience.
+func Audience(aud string) OptionFunc {
+	return func(a *Authenticator) error {
+		if aud == "" {
+			return errors.New("audience is empty")
+		}
+		a.audience = aud
+		return nil
+	}
+}
+
+// Scopes option for validating that all the scopes are granted to the tokens.
+func Scopes(scopes ...string) OptionFunc {
+	return func(a *Authenticator) error {
+		if len(scopes) == 0 {
+			return errors.New("scopes are empty")
+		}
+		a.scopes = scopes
+		return nil
+	}
+}
+
+// CacheTTL option for setting how long the introspection responses are cached, which defaults to one minute.
+// Responses of active tokens are never cached beyond the expiration of the token.
+func CacheTTL(ttl time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		if ttl <= 0 {
+			return errors.New("cache TTL must be positive")
+		}
+		a.cacheTTL = ttl
+		return nil
+	}
+}
+
+// Cache option for storing the introspection responses in the provided cache, e.g. in order to share them between instances,
+// instead of the default in-memory cache. The tokens are not stored in the cache, only their hashes.
+func Cache(c cache.TTLCache) OptionFunc {
+	return func(a *Authenticator) error {
+		if c == nil {
+			return errors.New("cache is nil")
+		}
+		a.cache = c
+		return nil
+	}
+}
+
+// Timeout option for setting the timeout of the requests to the provider, which defaults to 10 seconds.
+func Timeout(timeout time.Duration) OptionFunc {
+	return func(a *Authenticator) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		a.cl.Timeout = timeout
+		return nil
+	}
+}
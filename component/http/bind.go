@@ -0,0 +1,301 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	bindSourcePath   = "path"
+	bindSourceQuery  = "query"
+	bindSourceHeader = "header"
+	bindSourceBody   = "body"
+	validateTag      = "validate"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldError describes a field of a request which could not be bound or is not valid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is the payload of the 400 Bad Request returned by Bind.
+type ValidationErrors struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Bind decodes the request into the struct pointed to by v and validates it.
+// The body is decoded first with the decoder of the request, if there is one, and then the fields tagged with
+// `path:"name"`, `query:"name"` or `header:"name"` are set from the path parameters, the query string and the headers.
+// Supported field types are strings, booleans, numbers, durations, pointers to them, and slices of them for query parameters.
+//
+// Fields are validated with the `validate` tag, which is a comma-separated list of the following rules:
+// required, for a non-zero value; min=n and max=n, for the value of numbers or the length of strings, slices and maps;
+// oneof=a b c, for one of the space-separated values. Rules other than required are not applied to zero values,
+// so pointers can be used for optional fields which have to be validated when they are provided.
+//
+// Values which cannot be bound or are not valid result in an *Error with a 400 Bad Request and a ValidationErrors payload,
+// which can be returned by the processor as is. Other errors, e.g. invalid tags, are programming errors.
+func (r *Request) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind target should be a non-nil pointer to a struct")
+	}
+
+	var fieldErrs []FieldError
+	if r.Raw != nil && r.decode != nil {
+		if err := r.decode(r.Raw, v); err != nil && !errors.Is(err, io.EOF) {
+			fieldErrs = append(fieldErrs, FieldError{Source: bindSourceBody, Message: err.Error()})
+		}
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name, source, values := r.bindValues(sf)
+		if source != "" && len(values) > 0 {
+			if err := setField(rv.Field(i), values); err != nil {
+				var convErr *conversionError
+				if !errors.As(err, &convErr) {
+					return fmt.Errorf("failed to bind field %s: %w", sf.Name, err)
+				}
+				fieldErrs = append(fieldErrs, FieldError{Field: name, Source: source, Message: err.Error()})
+				continue
+			}
+		}
+
+		rules, ok := sf.Tag.Lookup(validateTag)
+		if !ok {
+			continue
+		}
+		if source == "" {
+			name, source = bodyFieldName(sf), bindSourceBody
+		}
+		msg, err := validateField(rv.Field(i), rules)
+		if err != nil {
+			return fmt.Errorf("failed to validate field %s: %w", sf.Name, err)
+		}
+		if msg != "" {
+			fieldErrs = append(fieldErrs, FieldError{Field: name, Source: source, Message: msg})
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		return NewValidationErrorWithPayload(ValidationErrors{Errors: fieldErrs})
+	}
+	return nil
+}
+
+// bindValues returns the name, the source and the values of a field tagged with a source.
+func (r *Request) bindValues(sf reflect.StructField) (string, string, []string) {
+	if name, ok := sf.Tag.Lookup(bindSourcePath); ok {
+		if r.params != nil {
+			return name, bindSourcePath, valueOf(r.params, name)
+		}
+		return name, bindSourcePath, valueOf(r.Fields, name)
+	}
+	if name, ok := sf.Tag.Lookup(bindSourceQuery); ok {
+		if r.query != nil {
+			return name, bindSourceQuery, r.query[name]
+		}
+		return name, bindSourceQuery, valueOf(r.Fields, name)
+	}
+	if name, ok := sf.Tag.Lookup(bindSourceHeader); ok {
+		return name, bindSourceHeader, valueOf(r.Headers, strings.ToUpper(name))
+	}
+	return "", "", nil
+}
+
+func valueOf(m map[string]string, key string) []string {
+	v, ok := m[key]
+	if !ok {
+		return nil
+	}
+	return []string{v}
+}
+
+func bodyFieldName(sf reflect.StructField) string {
+	if tag, ok := sf.Tag.Lookup("json"); ok {
+		if name := strings.Split(tag, ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// conversionError is returned when a value cannot be converted to the type of its field, which is a failure of the request.
+type conversionError struct {
+	value string
+	kind  string
+}
+
+func (e *conversionError) Error() string {
+	return fmt.Sprintf("value %q is not a valid %s", e.value, e.kind)
+}
+
+func setField(fv reflect.Value, values []string) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, val := range values {
+			if err := setValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), values[0]); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	default:
+		return setValue(fv, values[0])
+	}
+}
+
+func setValue(fv reflect.Value, val string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return &conversionError{value: val, kind: "duration"}
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return &conversionError{value: val, kind: "boolean"}
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{value: val, kind: "integer"}
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{value: val, kind: "unsigned integer"}
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return &conversionError{value: val, kind: "number"}
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("type %s is not supported", fv.Type())
+	}
+	return nil
+}
+
+// validateField applies the rules to the value of a field, returning a message if it is not valid.
+func validateField(fv reflect.Value, rules string) (string, error) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+
+		if name == "required" {
+			if fv.IsZero() {
+				return "is required", nil
+			}
+			continue
+		}
+
+		// the other rules apply only to values which are present, i.e. non-zero or non-nil pointers
+		if fv.IsZero() {
+			continue
+		}
+		val := fv
+		if val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		switch name {
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid %s rule %q", name, rule)
+			}
+			n, isLen, err := measure(val)
+			if err != nil {
+				return "", err
+			}
+			if name == "min" && n < limit {
+				return limitMessage("at least", arg, isLen), nil
+			}
+			if name == "max" && n > limit {
+				return limitMessage("at most", arg, isLen), nil
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if len(options) == 0 {
+				return "", fmt.Errorf("invalid oneof rule %q", rule)
+			}
+			s := fmt.Sprint(val.Interface())
+			if !containsString(options, s) {
+				return fmt.Sprintf("should be one of %s", strings.Join(options, ", ")), nil
+			}
+		default:
+			return "", fmt.Errorf("validation rule %q is not supported", name)
+		}
+	}
+	return "", nil
+}
+
+// measure returns the value of numbers, or the length of strings, slices and maps.
+func measure(v reflect.Value) (float64, bool, error) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, nil
+	default:
+		return 0, false, fmt.Errorf("type %s cannot be measured", v.Type())
+	}
+}
+
+func limitMessage(bound, arg string, isLen bool) string {
+	if isLen {
+		return fmt.Sprintf("should have a length of %s %s", bound, arg)
+	}
+	return fmt.Sprintf("should be %s %s", bound, arg)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindTarget struct {
+	ID       int           `path:"id" validate:"required,min=1"`
+	Page     *uint         `query:"page" validate:"max=100"`
+	Sort     string        `query:"sort" validate:"oneof=asc desc"`
+	Tags     []string      `query:"tag" validate:"max=2"`
+	Timeout  time.Duration `query:"timeout"`
+	Debug    bool          `query:"debug"`
+	TenantID string        `header:"X-Tenant-ID" validate:"required"`
+	Name     string        `json:"name" validate:"required,min=3"`
+	Score    float64       `json:"score" validate:"min=0,max=1"`
+	internal string
+}
+
+func TestRequest_Bind(t *testing.T) {
+	page := uint(2)
+
+	tests := map[string]struct {
+		fields      map[string]string
+		headers     map[string]string
+		body        string
+		expected    bindTarget
+		expectedErr []FieldError
+	}{
+		"success": {
+			fields:   map[string]string{"id": "1", "page": "2", "sort": "asc", "tag": "a", "timeout": "1s", "debug": "true"},
+			headers:  map[string]string{"X-TENANT-ID": "tenant"},
+			body:     `{"name":"patron","score":0.5}`,
+			expected: bindTarget{ID: 1, Page: &page, Sort: "asc", Tags: []string{"a"}, Timeout: time.Second, Debug: true, TenantID: "tenant", Name: "patron", Score: 0.5},
+		},
+		"conversion errors": {
+			fields:  map[string]string{"id": "one", "page": "-1", "timeout": "1", "debug": "yes"},
+			headers: map[string]string{"X-TENANT-ID": "tenant"},
+			body:    `{"name":"patron"}`,
+			expectedErr: []FieldError{
+				{Field: "id", Source: "path", Message: `value "one" is not a valid integer`},
+				{Field: "page", Source: "query", Message: `value "-1" is not a valid unsigned integer`},
+				{Field: "timeout", Source: "query", Message: `value "1" is not a valid duration`},
+				{Field: "debug", Source: "query", Message: `value "yes" is not a valid boolean`},
+			},
+		},
+		"validation errors": {
+			fields: map[string]string{"id": "0", "page": "101", "sort": "up"},
+			body:   `{"name":"pa","score":2}`,
+			expectedErr: []FieldError{
+				{Field: "id", Source: "path", Message: "is required"},
+				{Field: "page", Source: "query", Message: "should be at most 100"},
+				{Field: "sort", Source: "query", Message: "should be one of asc, desc"},
+				{Field: "X-Tenant-ID", Source: "header", Message: "is required"},
+				{Field: "name", Source: "body", Message: "should have a length of at least 3"},
+				{Field: "score", Source: "body", Message: "should be at most 1"},
+			},
+		},
+		"invalid body": {
+			fields:  map[string]string{"id": "1"},
+			headers: map[string]string{"X-TENANT-ID": "tenant"},
+			body:    `{"name":`,
+			expectedErr: []FieldError{
+				{Source: "body", Message: "unexpected EOF"},
+				{Field: "name", Source: "body", Message: "is required"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := NewRequest(tt.fields, strings.NewReader(tt.body), tt.headers, json.Decode)
+			var got bindTarget
+			err := req.Bind(&got)
+			if tt.expectedErr != nil {
+				var httpErr *Error
+				require.ErrorAs(t, err, &httpErr)
+				assert.Equal(t, http.StatusBadRequest, httpErr.code)
+				assert.Equal(t, ValidationErrors{Errors: tt.expectedErr}, httpErr.payload)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRequest_Bind_Errors(t *testing.T) {
+	type unsupported struct {
+		Value map[string]string `query:"value"`
+	}
+	type invalidRule struct {
+		Value int `query:"value" validate:"min=a"`
+	}
+	type unknownRule struct {
+		Value int `query:"value" validate:"email"`
+	}
+
+	tests := map[string]struct {
+		target interface{}
+		err    string
+	}{
+		"not a pointer":    {target: bindTarget{}, err: "bind target should be a non-nil pointer to a struct"},
+		"not a struct":     {target: new(string), err: "bind target should be a non-nil pointer to a struct"},
+		"unsupported type": {target: &unsupported{}, err: "failed to bind field Value: type map[string]string is not supported"},
+		"invalid rule":     {target: &invalidRule{}, err: `failed to validate field Value: invalid min rule "min=a"`},
+		"unknown rule":     {target: &unknownRule{}, err: `failed to validate field Value: validation rule "email" is not supported`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := NewRequest(map[string]string{"value": "1"}, nil, nil, nil)
+			assert.EqualError(t, req.Bind(tt.target), tt.err)
+		})
+	}
+}
+
+func TestRequest_Bind_Route(t *testing.T) {
+	type params struct {
+		ID   string   `path:"id"`
+		Tags []string `query:"id"`
+	}
+	var got params
+	proc := func(_ context.Context, req *Request) (*Response, error) {
+		if err := req.Bind(&got); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	rb := NewRoutesBuilder().Append(NewGetRouteBuilder("/users/:id", proc))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+	srv := httptest.NewServer(cmp.createHTTPServer().Handler)
+	defer srv.Close()
+
+	rsp, err := srv.Client().Get(srv.URL + "/users/1?id=a&id=b")
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, rsp.StatusCode)
+	assert.Equal(t, params{ID: "1", Tags: []string{"a", "b"}}, got)
+}
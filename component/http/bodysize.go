@@ -0,0 +1,45 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// NewMaxBodySizeMiddleware creates a MiddlewareFunc that rejects the requests with a body larger than the limit
+// with a 413 Request Entity Too Large, before their body is decoded.
+// Requests of unknown length, e.g. chunked ones, are buffered up to the limit in order to be checked.
+func NewMaxBodySizeMiddleware(limit int64) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				rejectBodySize(w, r, limit)
+				return
+			}
+			if r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+				b, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+				if err != nil {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				if int64(len(b)) > limit {
+					rejectBodySize(w, r, limit)
+					return
+				}
+				_ = r.Body.Close()
+				r.Body = ioutil.NopCloser(bytes.NewReader(b))
+				r.ContentLength = int64(len(b))
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rejectBodySize(w http.ResponseWriter, r *http.Request, limit int64) {
+	log.FromContext(r.Context()).Debugf("request body of %s %s exceeds the limit of %d bytes", r.Method, r.URL.Path, limit)
+	http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+}
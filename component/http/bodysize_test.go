@@ -0,0 +1,82 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaxBodySizeMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		body         string
+		chunked      bool
+		expectedCode int
+		expectedBody string
+	}{
+		"empty body":                  {expectedCode: http.StatusOK},
+		"body within the limit":       {body: "0123456789", expectedCode: http.StatusOK, expectedBody: "0123456789"},
+		"body over the limit":         {body: "0123456789a", expectedCode: http.StatusRequestEntityTooLarge, expectedBody: "Request Entity Too Large\n"},
+		"chunked body within limit":   {body: "0123456789", chunked: true, expectedCode: http.StatusOK, expectedBody: "0123456789"},
+		"chunked body over the limit": {body: "0123456789a", chunked: true, expectedCode: http.StatusRequestEntityTooLarge, expectedBody: "Request Entity Too Large\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rsp := httptest.NewRecorder()
+			hnd := NewMaxBodySizeMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				_, _ = w.Write(b)
+			}))
+			hnd.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+		})
+	}
+}
+
+func TestComponent_MaxBodySize(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}
+	rb := NewRoutesBuilder().
+		Append(NewRawRouteBuilder("/default", echo).MethodPost()).
+		Append(NewRawRouteBuilder("/large", echo).MethodPost().WithMaxBodySize(20))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithMaxBodySize(10).Create()
+	require.NoError(t, err)
+	hnd := cmp.createHTTPServer().Handler
+
+	tests := map[string]struct {
+		path         string
+		body         string
+		expectedCode int
+	}{
+		"component limit":          {path: "/default", body: "0123456789", expectedCode: http.StatusOK},
+		"over the component limit": {path: "/default", body: "0123456789a", expectedCode: http.StatusRequestEntityTooLarge},
+		"route limit":              {path: "/large", body: "0123456789a", expectedCode: http.StatusOK},
+		"over the route limit":     {path: "/large", body: "0123456789abcdefghijk", expectedCode: http.StatusRequestEntityTooLarge},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+
+	_, err = NewBuilder().WithMaxBodySize(0).Create()
+	assert.EqualError(t, err, "max body size must be positive\n")
+	_, err = NewRawRouteBuilder("/", echo).MethodPost().WithMaxBodySize(-1).Build()
+	assert.EqualError(t, err, "max body size must be positive\n")
+}
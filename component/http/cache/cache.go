@@ -2,8 +2,8 @@
 package cache
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"hash/crc32"
 	"net/http"
 	"strconv"
 	"strings"
@@ -28,6 +28,11 @@ const (
 	HeaderCacheControl = "Cache-Control"
 	// HeaderETagHeader is the constant representing the Etag http header
 	HeaderETagHeader = "Etag"
+	// HeaderLastModified is the constant representing the Last-Modified http header
+	HeaderLastModified = "Last-Modified"
+
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
 
 	controlMinFresh     = "min-fresh"
 	controlNoCache      = "no-cache"
@@ -215,6 +220,7 @@ func save(path, key string, rsp *response, cache cache.TTLCache, maxAge time.Dur
 // addResponseHeaders adds the appropriate headers according to the response conditions
 func addResponseHeaders(now int64, header http.Header, rsp *response, maxAge int64) {
 	header.Set(HeaderETagHeader, rsp.Etag)
+	header.Set(HeaderLastModified, time.Unix(rsp.LastValid, 0).UTC().Format(http.TimeFormat))
 	header.Set(HeaderCacheControl, createCacheControlHeader(maxAge, now-rsp.LastValid))
 	if rsp.Warning != "" && rsp.FromCache {
 		header.Set(headerWarning, rsp.Warning)
@@ -313,8 +319,43 @@ func hasNoAgeConfig(minAge, maxFresh int64) bool {
 	return minAge == 0 && maxFresh == 0
 }
 
-func generateETag(key []byte, t int) string {
-	return fmt.Sprintf("%d-%d", crc32.ChecksumIEEE(key), t)
+// generateETag generates a strong ETag from the payload, so that it changes only when the payload does.
+func generateETag(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// notModified checks the conditional headers of the request against the ETag and the Last-Modified headers of the response,
+// as per https://tools.ietf.org/html/rfc7232#section-6. If-Modified-Since is ignored when If-None-Match is present.
+func notModified(reqHeader, rspHeader http.Header) bool {
+	if inm := reqHeader.Get(headerIfNoneMatch); inm != "" {
+		etag := rspHeader.Get(HeaderETagHeader)
+		return etag != "" && etagMatches(inm, etag)
+	}
+	if ims := reqHeader.Get(headerIfModifiedSince); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		lastModified, err := http.ParseTime(rspHeader.Get(HeaderLastModified))
+		if err != nil {
+			return false
+		}
+		return !lastModified.After(since)
+	}
+	return false
+}
+
+// etagMatches uses the weak comparison of If-None-Match, which ignores the weak indicator of the ETags.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
 }
 
 func createCacheControlHeader(ttl, lastValid int64) string {
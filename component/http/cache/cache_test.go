@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -1595,6 +1596,46 @@ func TestCache_WithForceCacheHeaders(t *testing.T) {
 	assertCache(t, args)
 }
 
+func TestGenerateETag(t *testing.T) {
+	etag := generateETag([]byte("payload"))
+	assert.Equal(t, `"239f59ed55e737c77147cf55ad0c1b03"`, etag)
+	assert.Equal(t, etag, generateETag([]byte("payload")))
+	assert.NotEqual(t, etag, generateETag([]byte("other payload")))
+}
+
+func TestNotModified(t *testing.T) {
+	lastModified := time.Unix(1000, 0).UTC()
+	rspHeader := http.Header{}
+	rspHeader.Set(HeaderETagHeader, `"abc"`)
+	rspHeader.Set(HeaderLastModified, lastModified.Format(http.TimeFormat))
+
+	tests := map[string]struct {
+		header   map[string]string
+		expected bool
+	}{
+		"no conditional headers":          {expected: false},
+		"matching etag":                   {header: map[string]string{headerIfNoneMatch: `"abc"`}, expected: true},
+		"matching weak etag":              {header: map[string]string{headerIfNoneMatch: `W/"abc"`}, expected: true},
+		"matching etag in list":           {header: map[string]string{headerIfNoneMatch: `"xyz", "abc"`}, expected: true},
+		"any etag":                        {header: map[string]string{headerIfNoneMatch: "*"}, expected: true},
+		"different etag":                  {header: map[string]string{headerIfNoneMatch: `"xyz"`}, expected: false},
+		"not modified since":              {header: map[string]string{headerIfModifiedSince: lastModified.Format(http.TimeFormat)}, expected: true},
+		"modified since":                  {header: map[string]string{headerIfModifiedSince: lastModified.Add(-time.Second).Format(http.TimeFormat)}, expected: false},
+		"invalid modified since":          {header: map[string]string{headerIfModifiedSince: "yesterday"}, expected: false},
+		"etag takes precedence over date": {header: map[string]string{headerIfNoneMatch: `"xyz"`, headerIfModifiedSince: lastModified.Format(http.TimeFormat)}, expected: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			reqHeader := http.Header{}
+			propagateHeaders(tt.header, reqHeader)
+			assert.Equal(t, tt.expected, notModified(reqHeader, rspHeader))
+		})
+	}
+
+	assert.False(t, notModified(http.Header{headerIfNoneMatch: []string{"*"}}, http.Header{}))
+	assert.False(t, notModified(http.Header{headerIfModifiedSince: []string{lastModified.Format(http.TimeFormat)}}, http.Header{}))
+}
+
 func assertCache(t *testing.T, args [][]testArgs) {
 	monitor = &testMetrics{}
 
@@ -1613,7 +1654,7 @@ func assertCache(t *testing.T, args [][]testArgs) {
 					Bytes:  []byte(strconv.Itoa(i * 10 * int(request.timeInstance))),
 					Header: make(map[string][]string),
 				},
-				Etag:      generateETag([]byte{}, int(now)),
+				Etag:      generateETag([]byte(strconv.Itoa(i * 10 * int(request.timeInstance)))),
 				LastValid: request.timeInstance,
 			}
 			return response
@@ -1740,6 +1781,17 @@ func (t *testingCache) Get(key string) (interface{}, bool, error) {
 	return r.v, ok, nil
 }
 
+func (t *testingCache) RemoveMatching(_ context.Context, pattern string) (int, error) {
+	removed := 0
+	for k := range t.cache {
+		if cache.MatchPattern(pattern, k) {
+			delete(t.cache, k)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 func (t *testingCache) Purge() error {
 	for k := range t.cache {
 		_ = t.Remove(k)
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -43,6 +44,29 @@ func NewRouteCache(ttlCache cache.TTLCache, age Age) (*RouteCache, []error) {
 	}, errs
 }
 
+// Purge removes the cached responses whose keys match the glob-style pattern, returning their number.
+// The key of a cached response is the path and the raw query of the request separated by a colon, e.g. /users/1:page=2,
+// so all the responses of the paths starting with /users/ are matched by /users/*. See cache.MatchPattern for the syntax.
+// The cache of the route has to implement cache.PatternCache.
+func (rc *RouteCache) Purge(ctx context.Context, pattern string) (int, error) {
+	if pattern == "" {
+		return 0, errors.New("pattern is empty")
+	}
+	pc, ok := rc.cache.(cache.PatternCache)
+	if !ok {
+		return 0, fmt.Errorf("cache %T does not support removing keys by pattern", rc.cache)
+	}
+	return pc.RemoveMatching(ctx, pattern)
+}
+
+// PurgePath removes the cached responses of a path, for any query.
+func (rc *RouteCache) PurgePath(ctx context.Context, path string) (int, error) {
+	if path == "" {
+		return 0, errors.New("path is empty")
+	}
+	return rc.Purge(ctx, cache.EscapePattern(path)+":*")
+}
+
 // Age defines the route cache life-time boundaries for cached objects
 type Age struct {
 	// Min adds a minimum age threshold for the client controlled cache responses.
@@ -116,7 +140,8 @@ func (rw *responseReadWriter) WriteHeader(statusCode int) {
 	rw.statusCode = statusCode
 }
 
-// Handler will wrap the handler func with the route cache abstraction
+// Handler will wrap the handler func with the route cache abstraction.
+// Conditional requests with If-None-Match or If-Modified-Since headers matching the response are answered with a 304 Not Modified.
 func Handler(w http.ResponseWriter, r *http.Request, rc *RouteCache, httpHandler http.Handler) error {
 	req := toCacheHandlerRequest(r)
 	response, err := handler(httpExecutor(w, r, func(writer http.ResponseWriter, request *http.Request) {
@@ -128,6 +153,10 @@ func Handler(w http.ResponseWriter, r *http.Request, rc *RouteCache, httpHandler
 	for k, h := range response.Header {
 		w.Header().Set(k, h[0])
 	}
+	if notModified(r.Header, response.Header) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
 	if i, err := w.Write(response.Bytes); err != nil {
 		return fmt.Errorf("could not Write cache processor result into Response %d: %w", i, err)
 	}
@@ -151,7 +180,7 @@ func httpExecutor(_ http.ResponseWriter, request *http.Request, hnd http.Handler
 					Header: rw.Header(),
 				},
 				LastValid: now,
-				Etag:      generateETag([]byte(key), time.Now().Nanosecond()),
+				Etag:      generateETag(payload),
 			}
 		}
 		return &response{Err: err}
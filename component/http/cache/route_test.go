@@ -1,9 +1,15 @@
 package cache
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/beatlabs/patron/cache"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestResponseReadWriter_Header(t *testing.T) {
@@ -54,3 +60,75 @@ func TestResponseReadWriter_ReadAllEmpty(t *testing.T) {
 	assert.Equal(t, 0, len(b))
 	assert.Equal(t, "", string(b))
 }
+
+func TestHandler_ConditionalRequests(t *testing.T) {
+	NowSeconds = func() int64 { return 1000 }
+	c := newTestingCache()
+	c.instant = NowSeconds
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+	require.Empty(t, errs)
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	})
+
+	rsp := httptest.NewRecorder()
+	require.NoError(t, Handler(rsp, httptest.NewRequest(http.MethodGet, "/path", nil), rc, hnd))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "body", rsp.Body.String())
+	etag := rsp.Header().Get(HeaderETagHeader)
+	assert.Equal(t, generateETag([]byte("body")), etag)
+	lastModified := rsp.Header().Get(HeaderLastModified)
+	assert.Equal(t, "Thu, 01 Jan 1970 00:16:40 GMT", lastModified)
+
+	tests := map[string]struct {
+		header       string
+		value        string
+		expectedCode int
+		expectedBody string
+	}{
+		"matching etag":      {header: headerIfNoneMatch, value: etag, expectedCode: http.StatusNotModified},
+		"different etag":     {header: headerIfNoneMatch, value: `"other"`, expectedCode: http.StatusOK, expectedBody: "body"},
+		"not modified since": {header: headerIfModifiedSince, value: lastModified, expectedCode: http.StatusNotModified},
+		"modified since":     {header: headerIfModifiedSince, value: "Thu, 01 Jan 1970 00:16:39 GMT", expectedCode: http.StatusOK, expectedBody: "body"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/path", nil)
+			req.Header.Set(tt.header, tt.value)
+			rsp := httptest.NewRecorder()
+			require.NoError(t, Handler(rsp, req, rc, hnd))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			assert.Equal(t, etag, rsp.Header().Get(HeaderETagHeader))
+		})
+	}
+}
+
+func TestRouteCache_Purge(t *testing.T) {
+	c := newTestingCache()
+	c.instant = func() int64 { return 0 }
+	for _, k := range []string{"/users/1:", "/users/1:page=2", "/users/10:", "/orders/1:"} {
+		require.NoError(t, c.Set(k, "value"))
+	}
+	rc, errs := NewRouteCache(c, Age{Max: 10 * time.Second})
+	require.Empty(t, errs)
+
+	n, err := rc.PurgePath(context.Background(), "/users/1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = rc.Purge(context.Background(), "/users/*")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, c.cache, 1)
+
+	_, err = rc.Purge(context.Background(), "")
+	assert.EqualError(t, err, "pattern is empty")
+	_, err = rc.PurgePath(context.Background(), "")
+	assert.EqualError(t, err, "path is empty")
+
+	rc, errs = NewRouteCache(struct{ cache.TTLCache }{c}, Age{Max: 10 * time.Second})
+	require.Empty(t, errs)
+	_, err = rc.Purge(context.Background(), "*")
+	assert.EqualError(t, err, "cache struct { cache.TTLCache } does not support removing keys by pattern")
+}
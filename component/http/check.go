@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	checkTimeout    = 5 * time.Second
+	checkStatusOK   = "ok"
+	checkStatusFail = "failed"
+)
+
+// CheckFunc checks a dependency of the service, e.g. a Kafka broker, a database or a downstream HTTP service,
+// returning an error if it is not available. The context is cancelled after the check timeout of 5 seconds.
+type CheckFunc func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// checkResult is the result of a check, whose error is logged instead of being sent,
+// since the endpoints of the checks are public and the errors may disclose the internals of the service.
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"-"`
+}
+
+type checksReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+func appendCheck(checks []namedCheck, kind, name string, check CheckFunc) ([]namedCheck, error) {
+	if name == "" {
+		return checks, fmt.Errorf("%s check name is empty", kind)
+	}
+	if check == nil {
+		return checks, fmt.Errorf("%s check %s is nil", kind, name)
+	}
+	for _, c := range checks {
+		if c.name == name {
+			return checks, fmt.Errorf("%s check %s is already registered", kind, name)
+		}
+	}
+	return append(checks, namedCheck{name: name, check: check}), nil
+}
+
+// ReadinessChecks are named readiness checks, validated and run like the ones of the HTTP component,
+// so that other components, e.g. the gRPC health service, share them.
+type ReadinessChecks struct {
+	checks []namedCheck
+}
+
+// Add registers a named check, returning an error if the name is empty or already registered, or the check is nil.
+func (rc *ReadinessChecks) Add(name string, check CheckFunc) error {
+	checks, err := appendCheck(rc.checks, "readiness", name, check)
+	if err != nil {
+		return err
+	}
+	rc.checks = checks
+	return nil
+}
+
+// Run runs the checks concurrently, within the check timeout, returning an error with the failing ones sorted by name.
+func (rc ReadinessChecks) Run(ctx context.Context) error {
+	results, passed := runChecks(ctx, rc.checks)
+	if passed {
+		return nil
+	}
+	names := make([]string, 0, len(results))
+	for name, res := range results {
+		if res.Status != checkStatusOK {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("check %s failed: %s", name, results[name].Error))
+	}
+	return errors.New(strings.Join(msgs, ", "))
+}
+
+// runChecks runs the checks concurrently, returning their results and whether all of them passed.
+// Checks which do not return within the timeout fail, without waiting for them.
+func runChecks(ctx context.Context, checks []namedCheck) (map[string]checkResult, bool) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make(map[string]checkResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for _, c := range checks {
+		go func(c namedCheck) {
+			defer wg.Done()
+			chErr := make(chan error, 1)
+			go func() { chErr <- c.check(ctx) }()
+
+			var err error
+			select {
+			case err = <-chErr:
+			case <-ctx.Done():
+				err = errors.New("check timed out")
+			}
+
+			res := checkResult{Status: checkStatusOK}
+			if err != nil {
+				res = checkResult{Status: checkStatusFail, Error: err.Error()}
+			}
+			mu.Lock()
+			results[c.name] = res
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.Status != checkStatusOK {
+			return results, false
+		}
+	}
+	return results, true
+}
+
+// writeChecksReport writes the results of the checks as JSON, with a 503 Service Unavailable if the status is not ok.
+func writeChecksReport(w http.ResponseWriter, r *http.Request, ok bool, okStatus, failStatus string, checks []namedCheck) {
+	results, passed := runChecks(r.Context(), checks)
+	report := checksReport{Status: okStatus, Checks: results}
+	code := http.StatusOK
+	if !ok || !passed {
+		report.Status = failStatus
+		code = http.StatusServiceUnavailable
+		for name, res := range results {
+			if res.Status != checkStatusOK {
+				log.FromContext(r.Context()).Warnf("%s check %s failed: %s", failStatus, name, res.Error)
+			}
+		}
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(encoding.ContentTypeHeader, patronjson.TypeCharset)
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
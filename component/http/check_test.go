@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_appendCheck(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+
+	checks, err := appendCheck(nil, "readiness", "db", ok)
+	require.NoError(t, err)
+	assert.Len(t, checks, 1)
+
+	tests := map[string]struct {
+		name  string
+		check CheckFunc
+		err   string
+	}{
+		"empty name": {name: "", check: ok, err: "readiness check name is empty"},
+		"nil check":  {name: "kafka", check: nil, err: "readiness check kafka is nil"},
+		"duplicate":  {name: "db", check: ok, err: "readiness check db is already registered"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := appendCheck(checks, "readiness", tt.name, tt.check)
+			assert.EqualError(t, err, tt.err)
+			assert.Len(t, got, 1)
+		})
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	var rc ReadinessChecks
+	require.NoError(t, rc.Add("db", func(context.Context) error { return nil }))
+	assert.NoError(t, rc.Run(context.Background()))
+	assert.EqualError(t, rc.Add("db", func(context.Context) error { return nil }), "readiness check db is already registered")
+
+	require.NoError(t, rc.Add("kafka", func(context.Context) error { return errors.New("broker is down") }))
+	require.NoError(t, rc.Add("cache", func(context.Context) error { return errors.New("connection refused") }))
+	assert.EqualError(t, rc.Run(context.Background()), "check cache failed: connection refused, check kafka failed: broker is down")
+}
+
+func Test_readyCheckRoute_Checks(t *testing.T) {
+	ok := namedCheck{name: "db", check: func(context.Context) error { return nil }}
+	failed := namedCheck{name: "kafka", check: func(context.Context) error { return errors.New("no brokers available") }}
+
+	tests := map[string]struct {
+		rcf          ReadyCheckFunc
+		checks       []namedCheck
+		expectedCode int
+		expected     checksReport
+	}{
+		"ready": {
+			rcf: DefaultReadyCheck, checks: []namedCheck{ok}, expectedCode: http.StatusOK,
+			expected: checksReport{Status: "ready", Checks: map[string]checkResult{"db": {Status: "ok"}}},
+		},
+		"failed check": {
+			rcf: DefaultReadyCheck, checks: []namedCheck{ok, failed}, expectedCode: http.StatusServiceUnavailable,
+			expected: checksReport{Status: "not_ready", Checks: map[string]checkResult{
+				"db":    {Status: "ok"},
+				"kafka": {Status: "failed"},
+			}},
+		},
+		"not ready func": {
+			rcf: func() ReadyStatus { return NotReady }, checks: []namedCheck{ok}, expectedCode: http.StatusServiceUnavailable,
+			expected: checksReport{Status: "not_ready", Checks: map[string]checkResult{"db": {Status: "ok"}}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := readyCheckRoute(tt.rcf, tt.checks...).Build()
+			require.NoError(t, err)
+			rsp := httptest.NewRecorder()
+			r.handler(rsp, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, "application/json; charset=utf-8", rsp.Header().Get("Content-Type"))
+			var got checksReport
+			require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &got))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func Test_aliveCheckRoute_Checks(t *testing.T) {
+	failed := namedCheck{name: "deadlock", check: func(context.Context) error { return errors.New("worker is stuck") }}
+	r, err := aliveCheckRoute(DefaultAliveCheck, failed).Build()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	r.handler(rsp, httptest.NewRequest(http.MethodGet, "/alive", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+	assert.JSONEq(t, `{"status":"unresponsive","checks":{"deadlock":{"status":"failed"}}}`, rsp.Body.String())
+}
+
+func Test_runChecks_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	stuck := namedCheck{name: "stuck", check: func(context.Context) error {
+		<-release
+		return nil
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, passed := runChecks(ctx, []namedCheck{stuck})
+	assert.False(t, passed)
+	assert.Equal(t, checkResult{Status: "failed", Error: "check timed out"}, results["stuck"])
+}
+
+func TestBuilder_WithChecks(t *testing.T) {
+	check := func(context.Context) error { return nil }
+	cb := NewBuilder().WithReadinessCheck("db", check).WithLivenessCheck("worker", check)
+	assert.Empty(t, cb.errors)
+	assert.Len(t, cb.readinessChecks, 1)
+	assert.Len(t, cb.livenessChecks, 1)
+
+	cb = NewBuilder().WithReadinessCheck("db", check).WithReadinessCheck("db", check).WithLivenessCheck("", check)
+	assert.Len(t, cb.errors, 2)
+	assert.EqualError(t, cb.errors[0], "readiness check db is already registered")
+	assert.EqualError(t, cb.errors[1], "liveness check name is empty")
+}
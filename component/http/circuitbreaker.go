@@ -0,0 +1,39 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+)
+
+// NewCircuitBreakerMiddleware creates a MiddlewareFunc which sheds the load of a route with a 503 Service Unavailable,
+// without calling the next handlers, while the circuit breaker is open. Responses with a 5xx status code count as failures.
+// The state of the circuit breaker is exposed by the reliability_circuit_breaker_state metric, labeled by its name.
+func NewCircuitBreakerMiddleware(cb *circuitbreaker.CircuitBreaker, retryTimeout time.Duration) MiddlewareFunc {
+	retryAfter := strconv.Itoa(int(math.Ceil(retryTimeout.Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := cb.Execute(func() (interface{}, error) {
+				rw := newResponseWriter(w, false)
+				next.ServeHTTP(rw, r)
+				if rw.Status() >= http.StatusInternalServerError {
+					return nil, errors.New(http.StatusText(rw.Status()))
+				}
+				return nil, nil
+			})
+			var openErr *circuitbreaker.OpenError
+			if !errors.As(err, &openErr) {
+				return
+			}
+			log.FromContext(r.Context()).Debugf("circuit of %s %s is open, shedding request", r.Method, r.URL.Path)
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, "Circuit is open", http.StatusServiceUnavailable)
+		})
+	}
+}
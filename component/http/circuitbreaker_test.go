@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCircuitBreakerMiddleware(t *testing.T) {
+	cb, err := circuitbreaker.New("GET /breaker", circuitbreaker.Setting{FailureThreshold: 1, RetryTimeout: 1500 * time.Millisecond})
+	require.NoError(t, err)
+	calls := 0
+	code := http.StatusOK
+	hnd := NewCircuitBreakerMiddleware(cb, 1500*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(code)
+	}))
+
+	// the steps run in order, since they change the state of the circuit breaker
+	tests := []struct {
+		name          string
+		code          int
+		expectedCode  int
+		expectedCalls int
+	}{
+		{name: "closed", code: http.StatusOK, expectedCode: http.StatusOK, expectedCalls: 1},
+		{name: "client errors are not failures", code: http.StatusNotFound, expectedCode: http.StatusNotFound, expectedCalls: 2},
+		{name: "server error opens the circuit", code: http.StatusBadGateway, expectedCode: http.StatusBadGateway, expectedCalls: 3},
+		{name: "open", code: http.StatusOK, expectedCode: http.StatusServiceUnavailable, expectedCalls: 3},
+	}
+	for _, tt := range tests {
+		code = tt.code
+		rsp := httptest.NewRecorder()
+		hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/breaker", nil))
+		assert.Equal(t, tt.expectedCode, rsp.Code, tt.name)
+		assert.Equal(t, tt.expectedCalls, calls, tt.name)
+	}
+
+	rsp := httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/breaker", nil))
+	assert.Equal(t, "2", rsp.Header().Get("Retry-After"))
+	assert.Equal(t, 1.0, circuitBreakerState(t, "GET /breaker"))
+}
+
+func circuitBreakerState(t *testing.T, name string) float64 {
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	for _, mf := range mfs {
+		if mf.GetName() != "reliability_circuit_breaker_state" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "name" && lp.GetValue() == name {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	require.Fail(t, "circuit breaker state metric not found")
+	return 0
+}
+
+func TestRouteBuilder_WithCircuitBreaker(t *testing.T) {
+	_, err := NewRawRouteBuilder("/breaker", func(http.ResponseWriter, *http.Request) {}).MethodGet().
+		WithCircuitBreaker(circuitbreaker.Setting{RetrySuccessThreshold: 2, MaxRetryExecutionThreshold: 1}).Build()
+	assert.EqualError(t, err, "failed to create circuit breaker: max retry has to be greater than the retry threshold")
+
+	route, err := NewRawRouteBuilder("/breaker", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).MethodPost().WithCircuitBreaker(circuitbreaker.Setting{FailureThreshold: 1, RetryTimeout: time.Minute}).Build()
+	require.NoError(t, err)
+	hnd := MiddlewareChain(route.handler, route.middlewares...)
+
+	rsp := httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/breaker", nil))
+	assert.Equal(t, http.StatusInternalServerError, rsp.Code)
+	rsp = httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/breaker", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+	assert.Equal(t, "60", rsp.Header().Get("Retry-After"))
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	coalesceMetricsInit sync.Once
+	coalesceMetric      *prometheus.CounterVec
+)
+
+func initCoalesceMetrics() {
+	coalesceMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "coalesced_requests_total",
+			Help:      "Total number of HTTP requests which were served with the response of a concurrent identical request.",
+		},
+		[]string{"method", "path"},
+	)
+	prometheus.MustRegister(coalesceMetric)
+}
+
+// defaultCoalesceHeaders are the headers of the requests which the responses commonly depend on.
+var defaultCoalesceHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language", "Authorization", "Cookie"}
+
+// CoalescingOptions configures the request coalescing middleware.
+type CoalescingOptions struct {
+	// Headers of the requests which are identical only if they have the same values for them, along with the same URL.
+	// They default to Accept, Accept-Encoding, Accept-Language, Authorization and Cookie.
+	Headers []string
+}
+
+// NewCoalescingMiddleware creates a MiddlewareFunc that collapses the concurrent identical GET requests of a route into a single
+// execution of the handler, whose response is sent to all of them, e.g. for hot endpoints. Requests are identical when they
+// have the same URL and the same values for the headers of the options. The coalesced requests are counted per route.
+// The response of the handler is buffered, so it is not suitable for streaming routes. Waiting requests which are cancelled
+// or time out get a 503 Service Unavailable, and if the request of the leader is cancelled, the waiting ones are handled on their own.
+func NewCoalescingMiddleware(path string, opts CoalescingOptions) (MiddlewareFunc, error) {
+	headers := opts.Headers
+	if len(headers) == 0 {
+		headers = defaultCoalesceHeaders
+	}
+	for _, h := range headers {
+		if h == "" {
+			return nil, errors.New("coalescing header is empty")
+		}
+	}
+
+	// register Prometheus metrics on first use
+	coalesceMetricsInit.Do(initCoalesceMetrics)
+
+	g := &coalesceGroup{calls: make(map[string]*coalesceCall)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := coalesceKey(r, headers)
+			call, leader := g.join(key)
+			if !leader {
+				select {
+				case <-r.Context().Done():
+					// the request is cancelled or timed out while waiting, regardless of the leader
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				case <-call.done:
+				}
+				if call.rec == nil {
+					// the handler panicked for the leader, or its request was cancelled, so the request is handled on its own
+					next.ServeHTTP(w, r)
+					return
+				}
+				coalesceMetric.WithLabelValues(r.Method, path).Inc()
+				call.rec.writeTo(w)
+				return
+			}
+
+			rec := &coalesceRecorder{header: make(http.Header)}
+			completed := false
+			defer func() {
+				if !completed {
+					g.finish(key, call, nil)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			completed = true
+			if r.Context().Err() != nil {
+				// the response may be the result of the cancellation of the leader, which is not shared
+				g.finish(key, call, nil)
+			} else {
+				g.finish(key, call, rec)
+			}
+			rec.writeTo(w)
+		})
+	}, nil
+}
+
+func coalesceKey(r *http.Request, headers []string) string {
+	var sb strings.Builder
+	sb.WriteString(r.URL.RequestURI())
+	for _, h := range headers {
+		sb.WriteByte('\n')
+		sb.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return sb.String()
+}
+
+// coalesceGroup tracks the in-flight executions of the handler of a route, by the key of their requests.
+type coalesceGroup struct {
+	mu    sync.Mutex
+	calls map[string]*coalesceCall
+}
+
+type coalesceCall struct {
+	done chan struct{}
+	// rec is the response of the handler, which is nil if the handler panicked or the request of the leader was cancelled.
+	rec *coalesceRecorder
+}
+
+// join returns the in-flight call of the key, or starts a new one, in which case the request is the leader.
+func (g *coalesceGroup) join(key string) (*coalesceCall, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+	c := &coalesceCall{done: make(chan struct{})}
+	g.calls[key] = c
+	return c, true
+}
+
+// finish publishes the response of the leader to the waiting requests, which is nil if the handler panicked
+// or the request of the leader was cancelled.
+func (g *coalesceGroup) finish(key string, c *coalesceCall, rec *coalesceRecorder) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	c.rec = rec
+	close(c.done)
+}
+
+// coalesceRecorder buffers the response of a handler, in order to send it to all the coalesced requests.
+type coalesceRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (rec *coalesceRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *coalesceRecorder) Write(b []byte) (int, error) {
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+func (rec *coalesceRecorder) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+// writeTo writes the buffered response, which is shared by the coalesced requests, so it is not modified.
+func (rec *coalesceRecorder) writeTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for k, vv := range rec.header {
+		dst[k] = append([]string(nil), vv...)
+	}
+	code := rec.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	_, _ = w.Write(rec.body.Bytes())
+}
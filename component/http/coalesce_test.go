@@ -0,0 +1,221 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCoalescingMiddleware(t *testing.T) {
+	_, err := NewCoalescingMiddleware("/hot", CoalescingOptions{Headers: []string{""}})
+	assert.EqualError(t, err, "coalescing header is empty")
+
+	var executions int32
+	release := make(chan struct{})
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&executions, 1)
+		<-release
+		w.Header().Set("X-Key", r.URL.RawQuery+r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("hot"))
+	})
+
+	tests := map[string]struct {
+		requests           []*http.Request
+		expectedExecutions int32
+	}{
+		"identical requests": {
+			requests: []*http.Request{
+				httptest.NewRequest(http.MethodGet, "/hot?a=1", nil),
+				httptest.NewRequest(http.MethodGet, "/hot?a=1", nil),
+				httptest.NewRequest(http.MethodGet, "/hot?a=1", nil),
+			},
+			expectedExecutions: 1,
+		},
+		"different queries": {
+			requests: []*http.Request{
+				httptest.NewRequest(http.MethodGet, "/hot?a=1", nil),
+				httptest.NewRequest(http.MethodGet, "/hot?a=2", nil),
+			},
+			expectedExecutions: 2,
+		},
+		"different authorization": {
+			requests: []*http.Request{
+				withHeader(httptest.NewRequest(http.MethodGet, "/hot", nil), "Authorization", "a"),
+				withHeader(httptest.NewRequest(http.MethodGet, "/hot", nil), "Authorization", "b"),
+			},
+			expectedExecutions: 2,
+		},
+		"not a GET": {
+			requests: []*http.Request{
+				httptest.NewRequest(http.MethodHead, "/hot", nil),
+				httptest.NewRequest(http.MethodHead, "/hot", nil),
+			},
+			expectedExecutions: 2,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewCoalescingMiddleware("/hot", CoalescingOptions{})
+			require.NoError(t, err)
+			coalesceMetric.Reset()
+			atomic.StoreInt32(&executions, 0)
+			release = make(chan struct{})
+
+			rsps := make([]*httptest.ResponseRecorder, len(tt.requests))
+			wg := sync.WaitGroup{}
+			for i, req := range tt.requests {
+				rsps[i] = httptest.NewRecorder()
+				wg.Add(1)
+				go func(rsp *httptest.ResponseRecorder, req *http.Request) {
+					defer wg.Done()
+					mw(hnd).ServeHTTP(rsp, req)
+				}(rsps[i], req)
+			}
+			require.Eventually(t, func() bool {
+				return atomic.LoadInt32(&executions) == tt.expectedExecutions
+			}, time.Second, time.Millisecond)
+			// the rest of the requests wait for the in-flight execution
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, tt.expectedExecutions, atomic.LoadInt32(&executions))
+			for i, rsp := range rsps {
+				assert.Equal(t, http.StatusAccepted, rsp.Code)
+				assert.Equal(t, "hot", rsp.Body.String())
+				assert.Equal(t, tt.requests[i].URL.RawQuery+tt.requests[i].Header.Get("Authorization"), rsp.Header().Get("X-Key"))
+			}
+			coalesced := float64(len(tt.requests)) - float64(tt.expectedExecutions)
+			if tt.requests[0].Method != http.MethodGet {
+				coalesced = 0
+			}
+			assert.Equal(t, coalesced, testutil.ToFloat64(coalesceMetric.WithLabelValues(http.MethodGet, "/hot")))
+		})
+	}
+}
+
+func TestNewCoalescingMiddleware_Panic(t *testing.T) {
+	var executions int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&executions, 1) == 1 {
+			close(started)
+			<-release
+			panic("failure")
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	mw, err := NewCoalescingMiddleware("/panic", CoalescingOptions{})
+	require.NoError(t, err)
+
+	panicked := make(chan interface{})
+	go func() {
+		defer func() {
+			panicked <- recover()
+		}()
+		mw(hnd).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+	<-started
+
+	rsp := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		mw(hnd).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "failure", <-panicked)
+	<-done
+	assert.Equal(t, "ok", rsp.Body.String(), "the waiting request is handled on its own")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+}
+
+func TestNewCoalescingMiddleware_Cancellation(t *testing.T) {
+	var executions int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&executions, 1) == 1 {
+			close(started)
+			<-release
+			http.Error(w, r.Context().Err().Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	mw, err := NewCoalescingMiddleware("/cancel", CoalescingOptions{})
+	require.NoError(t, err)
+
+	leaderCtx, leaderCancel := context.WithCancel(context.Background())
+	leaderDone := make(chan struct{})
+	go func() {
+		mw(hnd).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cancel", nil).WithContext(leaderCtx))
+		close(leaderDone)
+	}()
+	<-started
+
+	followerCtx, followerCancel := context.WithCancel(context.Background())
+	cancelled := httptest.NewRecorder()
+	cancelledDone := make(chan struct{})
+	go func() {
+		mw(hnd).ServeHTTP(cancelled, httptest.NewRequest(http.MethodGet, "/cancel", nil).WithContext(followerCtx))
+		close(cancelledDone)
+	}()
+	followerCancel()
+	select {
+	case <-cancelledDone:
+	case <-time.After(time.Second):
+		t.Fatal("the cancelled request waits for the leader")
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, cancelled.Code)
+
+	rsp := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		mw(hnd).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/cancel", nil))
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	leaderCancel()
+	close(release)
+
+	<-leaderDone
+	<-done
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "ok", rsp.Body.String(), "the cancellation of the leader is not shared")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+}
+
+func TestRouteBuilder_WithCoalescing(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return NewResponse("ok"), nil }
+
+	_, err := NewPostRouteBuilder("/", proc).WithCoalescing(CoalescingOptions{}).Build()
+	assert.EqualError(t, err, "cannot apply coalescing to a route with any method other than GET")
+	_, err = NewGetRouteBuilder("/", proc).WithCoalescing(CoalescingOptions{Headers: []string{""}}).Build()
+	assert.EqualError(t, err, "coalescing header is empty")
+
+	route, err := NewGetRouteBuilder("/", proc).WithCoalescing(CoalescingOptions{}).Build()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	MiddlewareChain(route.handler, route.middlewares...).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, `"ok"`, rsp.Body.String())
+}
+
+func withHeader(req *http.Request, key, value string) *http.Request {
+	req.Header.Set(key, value)
+	return req
+}
@@ -2,15 +2,22 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/beatlabs/patron/component/http/auth"
+	"github.com/beatlabs/patron/encoding/json"
 	patronErrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
-	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
@@ -31,94 +38,346 @@ var (
 
 // Component implementation of HTTP.
 type Component struct {
-	ac                  AliveCheckFunc
-	rc                  ReadyCheckFunc
-	httpPort            int
-	httpReadTimeout     time.Duration
-	httpWriteTimeout    time.Duration
-	deflateLevel        int
-	uncompressedPaths   []string
-	shutdownGracePeriod time.Duration
+	ac                    AliveCheckFunc
+	rc                    ReadyCheckFunc
+	httpPort              int
+	httpReadTimeout       time.Duration
+	httpReadHeaderTimeout time.Duration
+	httpWriteTimeout      time.Duration
+	httpIdleTimeout       time.Duration
+	maxHeaderBytes        int
+	deflateLevel          int
+	uncompressedPaths     []string
+	shutdownGracePeriod   time.Duration
 	sync.Mutex
 	routes      []Route
 	middlewares []MiddlewareFunc
 	certFile    string
 	keyFile     string
+	tlsConfig   *tls.Config
+	h2c         bool
+	http2       *HTTP2Options
+	noSniff     bool
+	maxBodySize int64
+	errEncoder  ErrorEncoder
+	jsonFast    *json.FastPath
+	panicHook   PanicHook
+	accessLog   *AccessLogOptions
+	unixSocket  string
+	listeners   []listener
+	notFound    http.Handler
+	notAllowed  http.Handler
+	buckets     []float64
+	deadline    MiddlewareFunc
+	values      []contextValue
+	router      RouterOptions
 }
 
-// Run starts the HTTP server.
+// Run starts the HTTP server, along with the servers of the additional listeners.
 func (c *Component) Run(ctx context.Context) error {
 	c.Lock()
 	log.Debug("applying tracing to routes")
-	chFail := make(chan error)
-	srv := c.createHTTPServer()
-	go c.listenAndServe(srv, chFail)
+	servers := c.createServers()
+	chFail := make(chan error, len(servers))
+	for _, srv := range servers {
+		go c.listenAndServe(srv, chFail)
+	}
 	c.Unlock()
 
 	select {
 	case <-ctx.Done():
 		log.Info("shutting down HTTP component")
-		tctx, cancel := context.WithTimeout(context.Background(), c.shutdownGracePeriod)
-		defer cancel()
-		return srv.Shutdown(tctx)
+		return c.shutdown(servers)
 	case err := <-chFail:
+		for _, srv := range servers {
+			_ = srv.Close()
+		}
 		return err
 	}
 }
 
-func (c *Component) listenAndServe(srv *http.Server, ch chan<- error) {
-	if c.certFile != "" && c.keyFile != "" {
-		log.Debugf("HTTPS component listening on port %d", c.httpPort)
-		ch <- srv.ListenAndServeTLS(c.certFile, c.keyFile)
+// shutdown shuts the servers down gracefully, closing the connections with requests still in flight after the grace period,
+// which are logged without returning an error.
+func (c *Component) shutdown(servers []*server) error {
+	tctx, cancel := context.WithTimeout(context.Background(), c.shutdownGracePeriod)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var ee []error
+	exceeded := false
+	active := 0
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *server) {
+			defer wg.Done()
+			err := srv.Shutdown(tctx)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if !errors.Is(err, context.DeadlineExceeded) {
+				ee = append(ee, err)
+				return
+			}
+			// the requests still in flight after the grace period are cut off by closing their connections
+			exceeded = true
+			active += srv.conns.active()
+			if err := srv.Close(); err != nil {
+				log.Errorf("failed to close HTTP server: %v", err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+
+	// the cut off requests are reported in the logs only, so that the component returns as it did before the grace period
+	if exceeded {
+		log.Errorf("HTTP component shutdown grace period of %v exceeded, closed %d connections with in-flight requests", c.shutdownGracePeriod, active)
+	}
+	if len(ee) == 1 {
+		return ee[0]
+	}
+	return patronErrors.Aggregate(ee...)
+}
+
+// connTracker keeps the state of the connections of a server, in order to report the requests cut off by a forced shutdown.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+func trackConnections(srv *http.Server) *connTracker {
+	ct := &connTracker{conns: make(map[net.Conn]http.ConnState)}
+	srv.ConnState = ct.track
+	return ct
+}
+
+func (ct *connTracker) track(conn net.Conn, state http.ConnState) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(ct.conns, conn)
+	default:
+		ct.conns[conn] = state
+	}
+}
+
+func (ct *connTracker) active() int {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	n := 0
+	for _, state := range ct.conns {
+		if state == http.StateActive {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *Component) listenAndServe(srv *server, ch chan<- error) {
+	ln, err := listen(srv.network, srv.address)
+	if err != nil {
+		ch <- err
+		return
+	}
+
+	if c.tlsConfig != nil || (c.certFile != "" && c.keyFile != "") {
+		if c.http2 != nil {
+			if err := http2.ConfigureServer(srv.Server, c.http2.server()); err != nil {
+				_ = ln.Close()
+				ch <- err
+				return
+			}
+		}
+		log.Debugf("HTTPS component listening on %s %s", srv.network, srv.address)
+		ch <- srv.ServeTLS(ln, c.certFile, c.keyFile)
+		return
 	}
 
-	log.Debugf("HTTP component listening on port %d", c.httpPort)
-	ch <- srv.ListenAndServe()
+	log.Debugf("HTTP component listening on %s %s", srv.network, srv.address)
+	ch <- srv.Serve(ln)
+}
+
+// createServers creates the server of the component, listening on its port or unix socket, and the servers of the additional listeners.
+func (c *Component) createServers() []*server {
+	network, address := networkTCP, fmt.Sprintf(":%d", c.httpPort)
+	if c.unixSocket != "" {
+		network, address = networkUnix, c.unixSocket
+	}
+	servers := []*server{newServer(c.createHTTPServer(), network, address)}
+	for _, l := range c.listeners {
+		log.Debugf("adding %d routes to listener %s %s", len(l.routes), l.network, l.address)
+		srv := c.createServer(l.routes, l.middlewares)
+		srv.Addr = l.address
+		servers = append(servers, newServer(srv, l.network, l.address))
+	}
+	return servers
 }
 
 func (c *Component) createHTTPServer() *http.Server {
 	log.Debugf("adding %d routes", len(c.routes))
-	router := httprouter.New()
-	for _, route := range c.routes {
-		if len(route.middlewares) > 0 {
-			h := MiddlewareChain(route.handler, route.middlewares...)
-			router.Handler(route.method, route.path, h)
-		} else {
-			router.HandlerFunc(route.method, route.path, route.handler)
+	c.middlewares = append(c.middlewares, NewCompressionMiddleware(c.deflateLevel, c.uncompressedPaths...))
+	srv := c.createServer(c.routes, c.middlewares)
+	srv.Addr = fmt.Sprintf(":%d", c.httpPort)
+	return srv
+}
+
+// createServer creates a server for the routes, with the middlewares and the features of the component which apply to all the listeners.
+func (c *Component) createServer(routes []Route, mm []MiddlewareFunc) *http.Server {
+	router := newRouter(c.router)
+	router.NotFound = unmatchedHandler(notFoundPath, c.notFound, http.HandlerFunc(http.NotFound))
+	// the Allow header is set by the router before calling the handler
+	router.MethodNotAllowed = unmatchedHandler(methodNotAllowedPath, c.notAllowed, http.HandlerFunc(defaultMethodNotAllowedHandler))
+	for _, route := range routes {
+		middlewares := route.middlewares
+		if c.noSniff && route.encoded {
+			// raw routes are responsible for their own content type, so they are left untouched
+			middlewares = append([]MiddlewareFunc{NewNoSniffMiddleware()}, middlewares...)
+		}
+		if c.maxBodySize > 0 && route.maxBodySize == 0 {
+			// the limit of the component applies to the routes without their own one
+			middlewares = append([]MiddlewareFunc{NewMaxBodySizeMiddleware(c.maxBodySize)}, middlewares...)
 		}
+		if c.accessLog != nil && !route.accessLog {
+			// the options are validated when the component is created
+			accessLog, _ := NewAccessLogMiddleware(route.path, *c.accessLog)
+			middlewares = append([]MiddlewareFunc{accessLog}, middlewares...)
+		}
+		// panics of the routes are recovered per route, in order to label the metric with the path of the route
+		middlewares = append([]MiddlewareFunc{newRecoveryMiddleware(route.path, c.panicHook)}, middlewares...)
+		router.Handler(route.method, route.path, MiddlewareChain(route.handler, middlewares...))
 
 		log.Debugf("added route %s %s", route.method, route.path)
 	}
+	for path, cors := range corsPreflightRoutes(routes) {
+		router.Handler(http.MethodOptions, path, cors(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})))
+		log.Debugf("added CORS preflight route %s %s", http.MethodOptions, path)
+	}
 	// Add first the recovery middleware to ensure that no panic occur.
-	routerAfterMiddleware := MiddlewareChain(router, NewRecoveryMiddleware())
-	c.middlewares = append(c.middlewares, NewCompressionMiddleware(c.deflateLevel, c.uncompressedPaths...))
-	routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, c.middlewares...)
+	routerAfterMiddleware := MiddlewareChain(newPathMatcher(router, routes, c.router), newRecoveryMiddleware("", c.panicHook))
+	routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, mm...)
+	if len(c.values) > 0 {
+		// the context values are available to all the middlewares, and can be constructed from the propagated headers
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newContextValuesMiddleware(c.values))
+	}
+	// the propagated headers are available to all the middlewares
+	routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newHeaderPropagationMiddleware())
+	if c.tlsConfig != nil && c.tlsConfig.ClientCAs != nil {
+		// the identity of the client is available to all the middlewares
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newClientIdentityMiddleware())
+	}
+	if c.errEncoder != nil {
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newErrorEncoderMiddleware(c.errEncoder))
+	}
+	if c.jsonFast != nil {
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newJSONFastPathMiddleware(c.jsonFast))
+	}
+	if c.buckets != nil {
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, newLatencyHistogramMiddleware(c.buckets))
+	}
+	if c.deadline != nil {
+		// the deadline of the caller applies to all the middlewares
+		routerAfterMiddleware = MiddlewareChain(routerAfterMiddleware, c.deadline)
+	}
+	if c.h2c {
+		opts := HTTP2Options{}
+		if c.http2 != nil {
+			opts = *c.http2
+		}
+		h2s := opts.server()
+		// the HTTP/2 connections are hijacked from the server, so its idle timeout does not apply to them
+		h2s.IdleTimeout = c.httpIdleTimeout
+		routerAfterMiddleware = h2c.NewHandler(routerAfterMiddleware, h2s)
+	}
 
 	return &http.Server{
-		Addr:         fmt.Sprintf(":%d", c.httpPort),
-		ReadTimeout:  c.httpReadTimeout,
-		WriteTimeout: c.httpWriteTimeout,
-		IdleTimeout:  httpIdleTimeout,
-		Handler:      routerAfterMiddleware,
+		ReadTimeout:       c.httpReadTimeout,
+		ReadHeaderTimeout: c.httpReadHeaderTimeout,
+		WriteTimeout:      c.httpWriteTimeout,
+		IdleTimeout:       c.httpIdleTimeout,
+		MaxHeaderBytes:    c.maxHeaderBytes,
+		Handler:           routerAfterMiddleware,
+		TLSConfig:         c.tlsConfig,
+	}
+}
+
+// corsPreflightRoutes returns the CORS middleware of each path with CORS routes, which has no OPTIONS route,
+// since preflight requests are not routed to the routes themselves. If the routes of a path have different CORS options,
+// the ones of the first route are used.
+func corsPreflightRoutes(routes []Route) map[string]MiddlewareFunc {
+	options := make(map[string]bool)
+	for _, route := range routes {
+		if route.method == http.MethodOptions {
+			options[route.path] = true
+		}
+	}
+	preflight := make(map[string]MiddlewareFunc)
+	for _, route := range routes {
+		if route.cors == nil || options[route.path] {
+			continue
+		}
+		if _, ok := preflight[route.path]; !ok {
+			preflight[route.path] = route.cors
+		}
 	}
+	return preflight
 }
 
 // Builder gathers all required and optional properties, in order
 // to construct an HTTP component.
 type Builder struct {
-	ac                  AliveCheckFunc
-	rc                  ReadyCheckFunc
-	httpPort            int
-	httpReadTimeout     time.Duration
-	httpWriteTimeout    time.Duration
-	deflateLevel        int
-	uncompressedPaths   []string
-	shutdownGracePeriod time.Duration
-	routesBuilder       *RoutesBuilder
-	middlewares         []MiddlewareFunc
-	certFile            string
-	keyFile             string
-	errors              []error
+	ac                    AliveCheckFunc
+	rc                    ReadyCheckFunc
+	readinessChecks       []namedCheck
+	livenessChecks        []namedCheck
+	httpPort              int
+	httpReadTimeout       time.Duration
+	httpReadHeaderTimeout time.Duration
+	httpWriteTimeout      time.Duration
+	httpIdleTimeout       time.Duration
+	maxHeaderBytes        int
+	deflateLevel          int
+	uncompressedPaths     []string
+	shutdownGracePeriod   time.Duration
+	routesBuilder         *RoutesBuilder
+	middlewares           []MiddlewareFunc
+	certFile              string
+	keyFile               string
+	tlsConfig             *tls.Config
+	clientCAs             *x509.CertPool
+	clientAuth            tls.ClientAuthType
+	h2c                   bool
+	http2                 *HTTP2Options
+	noSniff               bool
+	maxBodySize           int64
+	errEncoder            ErrorEncoder
+	jsonFast              *json.FastPath
+	panicHook             PanicHook
+	accessLog             *AccessLogOptions
+	unixSocket            string
+	listeners             []listenerBuilder
+	adminPort             int
+	adminMws              []MiddlewareFunc
+	profiling             ProfilingOptions
+	toggleables           map[string]*ToggleableMiddleware
+	toggling              bool
+	togglingAuth          auth.Authenticator
+	openAPITitle          string
+	openAPIVersion        string
+	cachePurging          bool
+	cachePurgingMws       []MiddlewareFunc
+	notFound              http.Handler
+	notAllowed            http.Handler
+	buckets               []float64
+	registerer            prometheus.Registerer
+	deadline              MiddlewareFunc
+	values                []contextValue
+	router                RouterOptions
+	errors                []error
 }
 
 // NewBuilder initiates the HTTP component builder chain.
@@ -132,6 +391,7 @@ func NewBuilder() *Builder {
 		httpPort:            httpPort,
 		httpReadTimeout:     httpReadTimeout,
 		httpWriteTimeout:    httpWriteTimeout,
+		httpIdleTimeout:     httpIdleTimeout,
 		deflateLevel:        deflateLevel,
 		uncompressedPaths:   []string{"/metrics", "/alive", "/ready"},
 		shutdownGracePeriod: shutdownGracePeriod,
@@ -153,6 +413,255 @@ func (cb *Builder) WithSSL(c, k string) *Builder {
 	return cb
 }
 
+// WithTLSConfig sets the TLS config of the HTTP component, in order to enable TLS.
+// The certificates can be provided either in the config or with WithSSL.
+func (cb *Builder) WithTLSConfig(cfg *tls.Config) *Builder {
+	if cfg == nil {
+		cb.errors = append(cb.errors, errors.New("TLS config is nil"))
+	} else {
+		log.Debug("setting TLS config")
+		cb.tlsConfig = cfg.Clone()
+	}
+
+	return cb
+}
+
+// WithClientCertificates enables mutual TLS, verifying the certificates of the clients with the CA pool.
+// The mode has to verify the certificates, i.e. either tls.RequireAndVerifyClientCert or tls.VerifyClientCertIfGiven.
+// The identity of the clients with a verified certificate is available with ClientIdentityFromContext.
+func (cb *Builder) WithClientCertificates(pool *x509.CertPool, mode tls.ClientAuthType) *Builder {
+	if pool == nil {
+		cb.errors = append(cb.errors, errors.New("client CA pool is nil"))
+	}
+	if mode != tls.RequireAndVerifyClientCert && mode != tls.VerifyClientCertIfGiven {
+		cb.errors = append(cb.errors, fmt.Errorf("client auth mode %v does not verify client certificates", mode))
+	}
+	log.Debug("setting client certificate verification")
+	cb.clientCAs = pool
+	cb.clientAuth = mode
+	return cb
+}
+
+// WithH2C enables HTTP/2 over cleartext (h2c) on the non-TLS listener, e.g. for gRPC-gateway or service mesh traffic.
+// With TLS, HTTP/2 is negotiated anyway, so h2c cannot be enabled.
+func (cb *Builder) WithH2C() *Builder {
+	log.Debug("enabling h2c")
+	cb.h2c = true
+	return cb
+}
+
+// WithHTTP2Options tunes the HTTP/2 connections, which are served either with TLS or with h2c.
+func (cb *Builder) WithHTTP2Options(opts HTTP2Options) *Builder {
+	if err := opts.validate(); err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting HTTP/2 options %+v", opts)
+		cb.http2 = &opts
+	}
+
+	return cb
+}
+
+// WithNoSniff sets the X-Content-Type-Options header to nosniff on all responses encoded by patron,
+// in order to prevent browsers from MIME-sniffing them. Routes created with the raw route builder are not affected.
+func (cb *Builder) WithNoSniff() *Builder {
+	log.Debug("setting nosniff on encoded responses")
+	cb.noSniff = true
+	return cb
+}
+
+// WithMaxBodySize rejects the requests with a body larger than the limit in bytes with a 413 Request Entity Too Large,
+// in order to protect the service from exhausting its memory while decoding them.
+// Routes can override the limit with RouteBuilder.WithMaxBodySize.
+func (cb *Builder) WithMaxBodySize(limit int64) *Builder {
+	if limit <= 0 {
+		cb.errors = append(cb.errors, errors.New("max body size must be positive"))
+	} else {
+		log.Debugf("setting max body size to %d bytes", limit)
+		cb.maxBodySize = limit
+	}
+	return cb
+}
+
+// WithErrorEncoder sets the error encoder mapping the errors returned by the processors of all the routes to responses,
+// e.g. domain errors to the proper status codes, instead of 500 Internal Server Error.
+func (cb *Builder) WithErrorEncoder(enc ErrorEncoder) *Builder {
+	if enc == nil {
+		cb.errors = append(cb.errors, errors.New("error encoder is nil"))
+	} else {
+		log.Debug("setting error encoder")
+		cb.errEncoder = enc
+	}
+	return cb
+}
+
+// WithJSONCodec decodes the JSON requests and encodes the JSON responses of all the routes with the fast path of the codec,
+// e.g. json.Standard or an adapter of jsoniter or sonic, instead of streaming the requests through a new decoder.
+// Unlike the default decoding, trailing data after the JSON value of a request is an error.
+func (cb *Builder) WithJSONCodec(c json.Codec) *Builder {
+	fp, err := json.NewFastPath(c)
+	if err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debug("setting JSON codec")
+		cb.jsonFast = fp
+	}
+	return cb
+}
+
+// WithNotFoundHandler sets the handler of the requests which match no route, e.g. in order to respond with the JSON error schema of the service,
+// instead of the default plain text 404 Not Found. The requests are counted in the route metrics with the not_found path label.
+func (cb *Builder) WithNotFoundHandler(h http.HandlerFunc) *Builder {
+	if h == nil {
+		cb.errors = append(cb.errors, errors.New("not found handler is nil"))
+	} else {
+		log.Debug("setting not found handler")
+		cb.notFound = h
+	}
+	return cb
+}
+
+// WithMethodNotAllowedHandler sets the handler of the requests which match the path of a route, but not its method,
+// instead of the default plain text 405 Method Not Allowed. The Allow header is set before the handler is called.
+// The requests are counted in the route metrics with the method_not_allowed path label.
+func (cb *Builder) WithMethodNotAllowedHandler(h http.HandlerFunc) *Builder {
+	if h == nil {
+		cb.errors = append(cb.errors, errors.New("method not allowed handler is nil"))
+	} else {
+		log.Debug("setting method not allowed handler")
+		cb.notAllowed = h
+	}
+	return cb
+}
+
+// WithHistogramBuckets sets the buckets of the latency histogram of the routes, instead of the default ones.
+// Routes can override the buckets with RouteBuilder.WithHistogramBuckets.
+func (cb *Builder) WithHistogramBuckets(buckets ...float64) *Builder {
+	if err := validateBuckets(buckets); err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting histogram buckets %v", buckets)
+		cb.buckets = append([]float64(nil), buckets...)
+	}
+	return cb
+}
+
+// WithMetricsRegisterer registers the metrics of the component with the registerer, in addition to the default registry.
+// The metrics endpoint serves the metrics of the registerer as well, if it is a prometheus.Gatherer, e.g. a prometheus.Registry.
+// Since the metrics are shared by all the HTTP components, they are registered with the registerers of all of them.
+func (cb *Builder) WithMetricsRegisterer(reg prometheus.Registerer) *Builder {
+	if reg == nil {
+		cb.errors = append(cb.errors, errors.New("metrics registerer is nil"))
+	} else {
+		log.Debug("setting metrics registerer")
+		cb.registerer = reg
+	}
+	return cb
+}
+
+// WithDeadlinePropagation sets the deadline of the context of the requests to the timeout in their headers, e.g. X-Request-Timeout or grpc-timeout,
+// so that the calls to the downstream services made with the context inherit the budget of the caller.
+func (cb *Builder) WithDeadlinePropagation(opts DeadlineOptions) *Builder {
+	deadline, err := NewDeadlineMiddleware(opts)
+	if err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debug("setting deadline propagation")
+		cb.deadline = deadline
+	}
+	return cb
+}
+
+// WithContextValue adds a value to the context of all the requests under the key, e.g. a database connection pool,
+// so that the processors and middlewares do not rely on package-level variables.
+func (cb *Builder) WithContextValue(key, val interface{}) *Builder {
+	return cb.WithContextConstructor(key, func(*http.Request) (interface{}, func(), error) {
+		return val, nil, nil
+	})
+}
+
+// WithContextConstructor adds a value constructed for each request to its context under the key,
+// e.g. a request-scoped database session or the tenant of the request. The constructors are called in the order they are added,
+// so that a constructor can use the values of the previous ones.
+func (cb *Builder) WithContextConstructor(key interface{}, ctor ContextConstructor) *Builder {
+	cv, err := newContextValue(key, ctor)
+	if err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting context value %v", key)
+		cb.values = append(cb.values, cv)
+	}
+	return cb
+}
+
+// WithRouterOptions sets how the requests whose path differs from the path of a route by a trailing slash or by the case are matched,
+// which are redirected to the path of the route by default.
+func (cb *Builder) WithRouterOptions(opts RouterOptions) *Builder {
+	if err := opts.validate(); err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting router options %+v", opts)
+		cb.router = opts
+	}
+	return cb
+}
+
+// WithPanicHook sets a hook which is called for the panics recovered while handling requests, before the 500 Internal Server Error is returned.
+func (cb *Builder) WithPanicHook(hook PanicHook) *Builder {
+	if hook == nil {
+		cb.errors = append(cb.errors, errors.New("panic hook is nil"))
+	} else {
+		log.Debug("setting panic hook")
+		cb.panicHook = hook
+	}
+	return cb
+}
+
+// WithAccessLog logs a structured entry for each request of the routes, unless they have their own access log.
+func (cb *Builder) WithAccessLog(opts AccessLogOptions) *Builder {
+	if _, err := NewAccessLogMiddleware("", opts); err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debug("setting access log")
+		cb.accessLog = &opts
+	}
+	return cb
+}
+
+// WithOpenAPI serves an OpenAPI 3.0 document of the routes of the routes builder at /openapi.json.
+// Routes can be described further with RouteBuilder.WithOpenAPI.
+func (cb *Builder) WithOpenAPI(title, version string) *Builder {
+	if title == "" {
+		cb.errors = append(cb.errors, errors.New("OpenAPI title is empty"))
+	}
+	if version == "" {
+		cb.errors = append(cb.errors, errors.New("OpenAPI version is empty"))
+	}
+	log.Debugf("setting OpenAPI document %s %s", title, version)
+	cb.openAPITitle = title
+	cb.openAPIVersion = version
+	return cb
+}
+
+// WithProfiling configures the profiling endpoints, i.e. the pprof endpoints, which are served by default,
+// and the expvar and log level endpoints, which are opt-in, optionally protecting them with an authenticator.
+func (cb *Builder) WithProfiling(opts ProfilingOptions) *Builder {
+	log.Debugf("setting profiling options, pprof disabled: %t, expvar enabled: %t, log level enabled: %t", opts.DisablePprof, opts.ExpVars, opts.LogLevel)
+	cb.profiling = opts
+	return cb
+}
+
+// WithRouteCachePurging serves DELETE /cache, which purges the cached responses of the routes with a route cache,
+// either of a path, e.g. /cache?path=/users/1, or matching a pattern, e.g. /cache?pattern=/users/*.
+// The caches of the routes have to implement cache.PatternCache. Middlewares, e.g. for authentication,
+// can be provided, since the endpoint should not be publicly available.
+func (cb *Builder) WithRouteCachePurging(mm ...MiddlewareFunc) *Builder {
+	log.Debug("setting route cache purging")
+	cb.cachePurging = true
+	cb.cachePurgingMws = mm
+	return cb
+}
+
 // WithRoutesBuilder adds routes builder to the HTTP component.
 func (cb *Builder) WithRoutesBuilder(rb *RoutesBuilder) *Builder {
 	if rb == nil {
@@ -176,6 +685,45 @@ func (cb *Builder) WithMiddlewares(mm ...MiddlewareFunc) *Builder {
 	return cb
 }
 
+// WithToggleableMiddlewares adds middlewares to the HTTP component which can be enabled or disabled at runtime.
+// The state of the middlewares can be changed programmatically, or with the admin endpoints under /middlewares
+// when they are enabled with WithMiddlewareToggling.
+func (cb *Builder) WithToggleableMiddlewares(tt ...*ToggleableMiddleware) *Builder {
+	if len(tt) == 0 {
+		cb.errors = append(cb.errors, errors.New("empty list of toggleable middlewares provided"))
+		return cb
+	}
+
+	if cb.toggleables == nil {
+		cb.toggleables = make(map[string]*ToggleableMiddleware, len(tt))
+	}
+	for _, tm := range tt {
+		if tm == nil {
+			cb.errors = append(cb.errors, errors.New("nil toggleable middleware provided"))
+			continue
+		}
+		if _, ok := cb.toggleables[tm.Name()]; ok {
+			cb.errors = append(cb.errors, fmt.Errorf("toggleable middleware %s is duplicate", tm.Name()))
+			continue
+		}
+		log.Debugf("setting toggleable middleware %s", tm.Name())
+		cb.toggleables[tm.Name()] = tm
+		cb.middlewares = append(cb.middlewares, tm.Middleware())
+	}
+
+	return cb
+}
+
+// WithMiddlewareToggling serves the admin endpoints under /middlewares, which list the toggleable middlewares
+// and enable or disable them. Since they can disable e.g. an authentication middleware, the endpoints are protected
+// with the authenticator, which can only be omitted when they are served on a dedicated port with WithAdminPort.
+func (cb *Builder) WithMiddlewareToggling(a auth.Authenticator) *Builder {
+	log.Debug("setting middleware toggling endpoints")
+	cb.toggling = true
+	cb.togglingAuth = a
+	return cb
+}
+
 // WithReadTimeout sets the Read Timeout for the HTTP component.
 func (cb *Builder) WithReadTimeout(rt time.Duration) *Builder {
 	if rt <= 0*time.Second {
@@ -200,6 +748,44 @@ func (cb *Builder) WithWriteTimeout(wt time.Duration) *Builder {
 	return cb
 }
 
+// WithReadHeaderTimeout sets the Read Header Timeout for the HTTP component, which bounds the time for reading
+// the headers of a request, e.g. against slow clients holding connections open. It defaults to the Read Timeout.
+func (cb *Builder) WithReadHeaderTimeout(rht time.Duration) *Builder {
+	if rht <= 0*time.Second {
+		cb.errors = append(cb.errors, errors.New("negative or zero read header timeout provided"))
+	} else {
+		log.Debug("setting read header timeout")
+		cb.httpReadHeaderTimeout = rht
+	}
+
+	return cb
+}
+
+// WithIdleTimeout sets the Idle Timeout for the HTTP component, after which keep-alive connections without requests are closed.
+func (cb *Builder) WithIdleTimeout(it time.Duration) *Builder {
+	if it <= 0*time.Second {
+		cb.errors = append(cb.errors, errors.New("negative or zero idle timeout provided"))
+	} else {
+		log.Debug("setting idle timeout")
+		cb.httpIdleTimeout = it
+	}
+
+	return cb
+}
+
+// WithMaxHeaderBytes sets the maximum size of the headers of a request for the HTTP component, including the request line.
+// It defaults to http.DefaultMaxHeaderBytes.
+func (cb *Builder) WithMaxHeaderBytes(size int) *Builder {
+	if size <= 0 {
+		cb.errors = append(cb.errors, errors.New("negative or zero max header bytes provided"))
+	} else {
+		log.Debug("setting max header bytes")
+		cb.maxHeaderBytes = size
+	}
+
+	return cb
+}
+
 // WithDeflateLevel sets the level of compression for Deflate; based on https://golang.org/pkg/compress/flate/
 // Levels range from 1 (BestSpeed) to 9 (BestCompression); higher levels typically run slower but compress more.
 // Level 0 (NoCompression) does not attempt any compression; it only adds the necessary DEFLATE framing.
@@ -241,6 +827,54 @@ func (cb *Builder) WithShutdownGracePeriod(gp time.Duration) *Builder {
 	return cb
 }
 
+// WithUnixSocket sets the path of a unix domain socket, which the HTTP component listens on instead of its port,
+// e.g. for a sidecar proxy on the same host.
+func (cb *Builder) WithUnixSocket(path string) *Builder {
+	if path == "" {
+		cb.errors = append(cb.errors, errors.New("unix socket path is empty"))
+	} else {
+		log.Debugf("setting unix socket %s", path)
+		cb.unixSocket = path
+	}
+
+	return cb
+}
+
+// WithListener adds a listener on a network address, e.g. "tcp" and ":50001" or "unix" and "/run/service.sock",
+// which serves the routes of the routes builder with its own middlewares, instead of the ones of the component.
+// The TLS settings, timeouts and the features applied to all the routes, e.g. the max body size and the panic recovery,
+// are shared with the listener of the component.
+func (cb *Builder) WithListener(network, address string, rb *RoutesBuilder, mm ...MiddlewareFunc) *Builder {
+	if err := validateNetwork(network); err != nil {
+		cb.errors = append(cb.errors, err)
+	}
+	if address == "" {
+		cb.errors = append(cb.errors, errors.New("listener address is empty"))
+	}
+	if rb == nil {
+		cb.errors = append(cb.errors, errors.New("route builder is nil"))
+	}
+	log.Debugf("adding listener %s %s", network, address)
+	cb.listeners = append(cb.listeners, listenerBuilder{network: network, address: address, routesBuilder: rb, middlewares: mm})
+
+	return cb
+}
+
+// WithAdminPort serves the metrics, profiling, health and middleware toggling endpoints on a dedicated port, e.g. an internal one,
+// instead of the port of the component, so that they are not exposed through the same listener and middlewares as the routes.
+// Middlewares, e.g. for authentication, can be provided for the admin endpoints.
+func (cb *Builder) WithAdminPort(p int, mm ...MiddlewareFunc) *Builder {
+	if p <= 0 || p > 65535 {
+		cb.errors = append(cb.errors, errors.New("invalid admin port provided"))
+	} else {
+		log.Debugf("setting admin port %d", p)
+		cb.adminPort = p
+		cb.adminMws = mm
+	}
+
+	return cb
+}
+
 // WithPort sets the port used by the HTTP component.
 func (cb *Builder) WithPort(p int) *Builder {
 	if p <= 0 || p > 65535 {
@@ -277,34 +911,182 @@ func (cb *Builder) WithReadyCheckFunc(rcf ReadyCheckFunc) *Builder {
 	return cb
 }
 
+// WithReadinessCheck registers a named check of a dependency, e.g. a database, which is run on every request to /ready.
+// If any check fails, /ready responds with a 503 Service Unavailable, and with a JSON breakdown of the results in any case.
+func (cb *Builder) WithReadinessCheck(name string, check CheckFunc) *Builder {
+	checks, err := appendCheck(cb.readinessChecks, "readiness", name, check)
+	if err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting readiness check %s", name)
+		cb.readinessChecks = checks
+	}
+
+	return cb
+}
+
+// WithLivenessCheck registers a named check, which is run on every request to /alive.
+// If any check fails, /alive responds with a 503 Service Unavailable, and with a JSON breakdown of the results in any case.
+// Since an orchestrator restarts services which are not alive, dependencies are usually checked for readiness instead.
+func (cb *Builder) WithLivenessCheck(name string, check CheckFunc) *Builder {
+	checks, err := appendCheck(cb.livenessChecks, "liveness", name, check)
+	if err != nil {
+		cb.errors = append(cb.errors, err)
+	} else {
+		log.Debugf("setting liveness check %s", name)
+		cb.livenessChecks = checks
+	}
+
+	return cb
+}
+
 // Create constructs the HTTP component by applying the gathered properties.
 func (cb *Builder) Create() (*Component, error) {
 	if len(cb.errors) > 0 {
 		return nil, patronErrors.Aggregate(cb.errors...)
 	}
 
-	for _, rb := range profilingRoutes() {
+	tlsConfig, err := cb.createTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cb.h2c && (tlsConfig != nil || (cb.certFile != "" && cb.keyFile != "")) {
+		return nil, errors.New("h2c cannot be enabled with TLS")
+	}
+
+	if cb.openAPITitle != "" {
+		// only the routes of the routes builder are documented, not the default ones
+		doc := newOpenAPIDocument(cb.openAPITitle, cb.openAPIVersion, cb.routesBuilder.routes)
+		rb, err := openAPIRoute(doc)
+		if err != nil {
+			return nil, err
+		}
 		cb.routesBuilder.Append(rb)
 	}
 
-	routes, err := cb.routesBuilder.Append(aliveCheckRoute(cb.ac)).Append(readyCheckRoute(cb.rc)).
-		Append(metricRoute()).Build()
+	// the admin endpoints are served along with the routes, unless there is an admin port
+	adminRoutesBuilder := cb.routesBuilder
+	if cb.adminPort > 0 {
+		if cb.adminPort == cb.httpPort {
+			return nil, errors.New("admin port should be different from the HTTP port")
+		}
+		adminRoutesBuilder = NewRoutesBuilder()
+	}
+
+	for _, rb := range profilingRoutes(cb.profiling) {
+		adminRoutesBuilder.Append(rb)
+	}
+	for _, rb := range logLevelRoutes(cb.profiling) {
+		adminRoutesBuilder.Append(rb)
+	}
+
+	if cb.cachePurging {
+		cb.routesBuilder.Append(cachePurgeRoute(routeCaches(cb.routesBuilder.routes), cb.cachePurgingMws...))
+	}
+
+	for _, r := range cb.routesBuilder.routes {
+		if r.payloadLog == nil {
+			continue
+		}
+		if _, ok := cb.toggleables[r.payloadLog.Name()]; ok {
+			return nil, fmt.Errorf("toggleable middleware %s is duplicate", r.payloadLog.Name())
+		}
+		if cb.toggleables == nil {
+			cb.toggleables = make(map[string]*ToggleableMiddleware)
+		}
+		cb.toggleables[r.payloadLog.Name()] = r.payloadLog
+	}
+
+	if cb.toggling {
+		if cb.togglingAuth == nil && cb.adminPort == 0 {
+			return nil, errors.New("middleware toggling endpoints require an authenticator or an admin port")
+		}
+		for _, rb := range toggleableMiddlewareRoutes(cb.toggleables, cb.togglingAuth) {
+			adminRoutesBuilder.Append(rb)
+		}
+	}
+
+	adminRoutesBuilder.Append(aliveCheckRoute(cb.ac, cb.livenessChecks...)).Append(readyCheckRoute(cb.rc, cb.readinessChecks...)).
+		Append(metricRoute(cb.registerer))
+
+	listeners := make([]listener, 0, len(cb.listeners)+1)
+	if cb.adminPort > 0 {
+		routes, err := adminRoutesBuilder.Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build admin routes: %w", err)
+		}
+		listeners = append(listeners, listener{network: networkTCP, address: fmt.Sprintf(":%d", cb.adminPort), routes: routes, middlewares: cb.adminMws})
+	}
+	for _, lb := range cb.listeners {
+		routes, err := lb.routesBuilder.Build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build routes of listener %s %s: %w", lb.network, lb.address, err)
+		}
+		listeners = append(listeners, listener{network: lb.network, address: lb.address, routes: routes, middlewares: lb.middlewares})
+	}
+
+	routes, err := cb.routesBuilder.Build()
 	if err != nil {
 		return nil, err
 	}
 
+	if cb.registerer != nil {
+		if err := registerMetrics(cb.registerer); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Component{
-		ac:                  cb.ac,
-		rc:                  cb.rc,
-		httpPort:            cb.httpPort,
-		httpReadTimeout:     cb.httpReadTimeout,
-		httpWriteTimeout:    cb.httpWriteTimeout,
-		deflateLevel:        cb.deflateLevel,
-		uncompressedPaths:   cb.uncompressedPaths,
-		shutdownGracePeriod: cb.shutdownGracePeriod,
-		routes:              routes,
-		middlewares:         cb.middlewares,
-		certFile:            cb.certFile,
-		keyFile:             cb.keyFile,
+		ac:                    cb.ac,
+		rc:                    cb.rc,
+		httpPort:              cb.httpPort,
+		httpReadTimeout:       cb.httpReadTimeout,
+		httpReadHeaderTimeout: cb.httpReadHeaderTimeout,
+		httpWriteTimeout:      cb.httpWriteTimeout,
+		httpIdleTimeout:       cb.httpIdleTimeout,
+		maxHeaderBytes:        cb.maxHeaderBytes,
+		deflateLevel:          cb.deflateLevel,
+		uncompressedPaths:     cb.uncompressedPaths,
+		shutdownGracePeriod:   cb.shutdownGracePeriod,
+		routes:                routes,
+		middlewares:           cb.middlewares,
+		certFile:              cb.certFile,
+		keyFile:               cb.keyFile,
+		tlsConfig:             tlsConfig,
+		h2c:                   cb.h2c,
+		http2:                 cb.http2,
+		noSniff:               cb.noSniff,
+		maxBodySize:           cb.maxBodySize,
+		errEncoder:            cb.errEncoder,
+		jsonFast:              cb.jsonFast,
+		panicHook:             cb.panicHook,
+		accessLog:             cb.accessLog,
+		unixSocket:            cb.unixSocket,
+		listeners:             listeners,
+		notFound:              cb.notFound,
+		notAllowed:            cb.notAllowed,
+		buckets:               cb.buckets,
+		deadline:              cb.deadline,
+		values:                cb.values,
+		router:                cb.router,
 	}, nil
 }
+
+func (cb *Builder) createTLSConfig() (*tls.Config, error) {
+	hasCertFiles := cb.certFile != "" && cb.keyFile != ""
+	if cb.tlsConfig == nil && cb.clientCAs == nil {
+		return nil, nil
+	}
+	cfg := cb.tlsConfig
+	if cfg == nil {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if !hasCertFiles && len(cfg.Certificates) == 0 && cfg.GetCertificate == nil {
+		return nil, errors.New("TLS requires a certificate, either in the TLS config or with the cert and key files")
+	}
+	if cb.clientCAs != nil {
+		cfg.ClientCAs = cb.clientCAs
+		cfg.ClientAuth = cb.clientAuth
+	}
+	return cfg, nil
+}
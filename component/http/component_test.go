@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -51,6 +52,40 @@ func TestComponent_ListenAndServeTLS_DefaultRoutes_Shutdown(t *testing.T) {
 	assert.True(t, <-done)
 }
 
+func TestComponent_Run_ShutdownGracePeriodExceeded(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	chStarted := make(chan struct{})
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/", func(http.ResponseWriter, *http.Request) {
+		close(chStarted)
+		<-release
+	}).MethodGet())
+	s, err := NewBuilder().WithRoutesBuilder(rb).WithPort(50015).WithShutdownGracePeriod(50 * time.Millisecond).Create()
+	require.NoError(t, err)
+	chDone := make(chan error)
+	ctx, cnl := context.WithCancel(context.Background())
+	go func() {
+		chDone <- s.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	go func() {
+		rsp, err := http.Get("http://localhost:50015/")
+		if err == nil {
+			_ = rsp.Body.Close()
+		}
+	}()
+	<-chStarted
+	cnl()
+
+	select {
+	case err := <-chDone:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "component did not return after the shutdown grace period")
+	}
+}
+
 func TestComponent_ListenAndServeTLS_FailsInvalidCerts(t *testing.T) {
 	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/", func(http.ResponseWriter, *http.Request) {}).MethodGet())
 	s, err := NewBuilder().WithRoutesBuilder(rb).WithSSL("testdata/server.pem", "testdata/server.pem").Create()
@@ -71,6 +106,62 @@ func Test_createHTTPServer(t *testing.T) {
 	assert.Equal(t, 10*time.Second, s.WriteTimeout)
 }
 
+func TestBuilder_ServerTimeouts(t *testing.T) {
+	cmp, err := NewBuilder().Create()
+	require.NoError(t, err)
+	s := cmp.createHTTPServer()
+	assert.Equal(t, time.Duration(0), s.ReadHeaderTimeout, "the read timeout applies to the headers")
+	assert.Equal(t, httpIdleTimeout, s.IdleTimeout)
+	assert.Equal(t, 0, s.MaxHeaderBytes, "the default max header bytes apply")
+
+	cmp, err = NewBuilder().WithReadHeaderTimeout(2 * time.Second).WithIdleTimeout(90 * time.Second).WithMaxHeaderBytes(8192).Create()
+	require.NoError(t, err)
+	s = cmp.createHTTPServer()
+	assert.Equal(t, 2*time.Second, s.ReadHeaderTimeout)
+	assert.Equal(t, 90*time.Second, s.IdleTimeout)
+	assert.Equal(t, 8192, s.MaxHeaderBytes)
+
+	_, err = NewBuilder().WithReadHeaderTimeout(0).WithIdleTimeout(-time.Second).WithMaxHeaderBytes(0).Create()
+	assert.EqualError(t, err, "negative or zero read header timeout provided\nnegative or zero idle timeout provided\n"+
+		"negative or zero max header bytes provided\n")
+}
+
+func Test_createHTTPServer_NoSniff(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return NewResponse("ok"), nil }
+	raw := func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("<html></html>")) }
+
+	tests := map[string]struct {
+		noSniff  bool
+		path     string
+		expected string
+	}{
+		"encoded route with nosniff":    {noSniff: true, path: "/encoded", expected: "nosniff"},
+		"raw route with nosniff":        {noSniff: true, path: "/raw", expected: ""},
+		"encoded route without nosniff": {noSniff: false, path: "/encoded", expected: ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rb := NewRoutesBuilder().
+				Append(NewGetRouteBuilder("/encoded", proc)).
+				Append(NewRawRouteBuilder("/raw", raw).MethodGet())
+			b := NewBuilder().WithRoutesBuilder(rb)
+			if tt.noSniff {
+				b.WithNoSniff()
+			}
+			cmp, err := b.Create()
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			require.NoError(t, err)
+			rsp := httptest.NewRecorder()
+			cmp.createHTTPServer().Handler.ServeHTTP(rsp, req)
+
+			assert.Equal(t, http.StatusOK, rsp.Code)
+			assert.Equal(t, tt.expected, rsp.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
+
 func TestBuilder_WithShutdownGracePeriod(t *testing.T) {
 	testCases := map[string]struct {
 		gp     time.Duration
@@ -176,3 +267,36 @@ func Test_createHTTPServerUsingBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilder_WithErrorEncoder(t *testing.T) {
+	_, err := NewBuilder().WithErrorEncoder(nil).Create()
+	assert.EqualError(t, err, "error encoder is nil\n")
+
+	rb := NewRoutesBuilder().Append(NewGetRouteBuilder("/", func(context.Context, *Request) (*Response, error) {
+		return nil, errors.New("conflict")
+	}))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithErrorEncoder(func(context.Context, error) *Response {
+		return NewResponseWithCode(http.StatusConflict, nil)
+	}).Create()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	cmp.createHTTPServer().Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusConflict, rsp.Code)
+}
+
+func TestBuilder_WithJSONCodec(t *testing.T) {
+	_, err := NewBuilder().WithJSONCodec(nil).Create()
+	assert.EqualError(t, err, "codec is nil\n")
+
+	rb := NewRoutesBuilder().Append(NewGetRouteBuilder("/", func(context.Context, *Request) (*Response, error) {
+		return NewResponse("value"), nil
+	}))
+	c := &countingCodec{}
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithJSONCodec(c).Create()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	cmp.createHTTPServer().Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, `"value"`, rsp.Body.String())
+	assert.Equal(t, 1, c.marshaled)
+}
@@ -0,0 +1,336 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+const brotliHeader = "br"
+
+// compressedContentTypes are not compressed again, since they are already compressed.
+// Event streams are not compressed either, so that every event reaches the client as soon as it is flushed.
+var compressedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/*", "audio/*", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2", "application/x-xz",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/zstd",
+	sseContentType,
+}
+
+// CompressionOptions configures a compression middleware.
+type CompressionOptions struct {
+	// Algorithms are the supported compression algorithms in order of preference, which is used when the client
+	// accepts several of them with the same quality value. Defaults to br, gzip and deflate.
+	Algorithms []string
+	// GzipLevel is the compression level of gzip, between -2 (Huffman only) and 9. Zero uses the default level.
+	GzipLevel int
+	// BrotliLevel is the compression level of brotli, between 1 and 11. Zero uses the default level.
+	BrotliLevel int
+	// DeflateLevel is the compression level of deflate, between -2 (Huffman only) and 9. Zero uses the default level.
+	DeflateLevel int
+	// MinSize is the minimum size of a response body in bytes to be compressed. Smaller bodies are sent as is.
+	MinSize int
+	// ExcludedContentTypes are not compressed, in addition to the types which are already compressed, e.g. image/png.
+	// A type can match any subtype with a wildcard, e.g. video/*.
+	ExcludedContentTypes []string
+	// IgnoreRoutes are the path prefixes of routes whose responses are not compressed.
+	IgnoreRoutes []string
+}
+
+type compressionConfig struct {
+	algorithms    []string
+	levels        map[string]int
+	minSize       int
+	excludedTypes []string
+	ignoreRoutes  []string
+}
+
+// NewCompressionMiddlewareWithOptions creates a middleware which compresses response bodies with brotli, gzip or deflate,
+// negotiated with the Accept-Encoding header of the request by quality value and then by the preference of the options.
+// Responses are sent uncompressed when the client accepts none of the algorithms, when they are smaller than the minimum size,
+// when they already have a content encoding, or when their content type is already compressed or excluded.
+func NewCompressionMiddlewareWithOptions(opts CompressionOptions) (MiddlewareFunc, error) {
+	cfg, err := newCompressionConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ignore(cfg.ignoreRoutes, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", encoding.AcceptEncodingHeader)
+			algorithm := negotiateCompression(r.Header.Get(encoding.AcceptEncodingHeader), cfg.algorithms)
+			if algorithm == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressionResponseWriter{ResponseWriter: w, cfg: cfg, algorithm: algorithm}
+			defer func() {
+				if err := cw.Close(); err != nil {
+					msgErr := fmt.Sprintf("failed to close %s compression writer: %v", algorithm, err)
+					if isErrConnectionReset(err) {
+						log.Info(msgErr)
+					} else {
+						log.Error(msgErr)
+					}
+				}
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}, nil
+}
+
+func newCompressionConfig(opts CompressionOptions) (*compressionConfig, error) {
+	algorithms := opts.Algorithms
+	if len(algorithms) == 0 {
+		algorithms = []string{brotliHeader, gzipHeader, deflateHeader}
+	}
+	for _, a := range algorithms {
+		if a != brotliHeader && a != gzipHeader && a != deflateHeader {
+			return nil, fmt.Errorf("compression algorithm %q is not supported", a)
+		}
+	}
+
+	levels := map[string]int{
+		brotliHeader:  brotli.DefaultCompression,
+		gzipHeader:    gzip.DefaultCompression,
+		deflateHeader: flate.DefaultCompression,
+	}
+	if opts.BrotliLevel != 0 {
+		if opts.BrotliLevel < brotli.BestSpeed || opts.BrotliLevel > brotli.BestCompression {
+			return nil, fmt.Errorf("brotli level %d is invalid", opts.BrotliLevel)
+		}
+		levels[brotliHeader] = opts.BrotliLevel
+	}
+	if opts.GzipLevel != 0 {
+		if opts.GzipLevel < gzip.HuffmanOnly || opts.GzipLevel > gzip.BestCompression {
+			return nil, fmt.Errorf("gzip level %d is invalid", opts.GzipLevel)
+		}
+		levels[gzipHeader] = opts.GzipLevel
+	}
+	if opts.DeflateLevel != 0 {
+		if opts.DeflateLevel < flate.HuffmanOnly || opts.DeflateLevel > flate.BestCompression {
+			return nil, fmt.Errorf("deflate level %d is invalid", opts.DeflateLevel)
+		}
+		levels[deflateHeader] = opts.DeflateLevel
+	}
+
+	if opts.MinSize < 0 {
+		return nil, errors.New("minimum size must not be negative")
+	}
+
+	excludedTypes := make([]string, 0, len(compressedContentTypes)+len(opts.ExcludedContentTypes))
+	excludedTypes = append(excludedTypes, compressedContentTypes...)
+	for _, ct := range opts.ExcludedContentTypes {
+		excludedTypes = append(excludedTypes, strings.ToLower(ct))
+	}
+
+	return &compressionConfig{
+		algorithms:    algorithms,
+		levels:        levels,
+		minSize:       opts.MinSize,
+		excludedTypes: excludedTypes,
+		ignoreRoutes:  opts.IgnoreRoutes,
+	}, nil
+}
+
+// negotiateCompression returns the algorithm with the highest quality value in the Accept-Encoding header,
+// preferring the algorithms in the provided order. The wildcard matches the algorithms not listed in the header.
+// An empty string is returned when none of the algorithms is accepted.
+func negotiateCompression(header string, algorithms []string) string {
+	if header == "" {
+		return ""
+	}
+
+	weights := make(map[string]float64)
+	for _, a := range strings.Split(header, ",") {
+		algAndWeight := strings.SplitN(a, ";", 2)
+		algorithm := strings.ToLower(strings.TrimSpace(algAndWeight[0]))
+		weight := 1.0
+		if len(algAndWeight) == 2 {
+			weight = parseWeight(strings.TrimSpace(algAndWeight[1]))
+		}
+		weights[algorithm] = weight
+	}
+
+	selected := ""
+	best := 0.0
+	for _, a := range algorithms {
+		weight, ok := weights[a]
+		if !ok {
+			weight, ok = weights[anythingHeader]
+		}
+		if ok && weight > best {
+			selected, best = a, weight
+		}
+	}
+	return selected
+}
+
+func (cfg *compressionConfig) compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, ct := range cfg.excludedTypes {
+		if ct == mt || (strings.HasSuffix(ct, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(ct, "*"))) {
+			return false
+		}
+	}
+	return true
+}
+
+// compressionResponseWriter buffers the response body until it reaches the minimum size,
+// so that the decision to compress is taken once the size, the content type and the content encoding are known.
+type compressionResponseWriter struct {
+	http.ResponseWriter
+	cfg        *compressionConfig
+	algorithm  string
+	statusCode int
+	buf        []byte
+	decided    bool
+	writer     io.WriteCloser
+}
+
+func (w *compressionResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode != 0 {
+		return
+	}
+	w.statusCode = statusCode
+	if !bodyAllowedForStatus(statusCode) {
+		// no body allowed so there is nothing to compress
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressionResponseWriter) Write(data []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.writer != nil {
+			return w.writer.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.cfg.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// decide writes the header and the buffered body, compressing it if requested and allowed by the response.
+func (w *compressionResponseWriter) decide(compress bool) error {
+	w.decided = true
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	h := w.ResponseWriter.Header()
+	if compress && h.Get(encoding.ContentEncodingHeader) == "" {
+		ct := h.Get(encoding.ContentTypeHeader)
+		if ct == "" {
+			ct = http.DetectContentType(w.buf)
+			h.Set(encoding.ContentTypeHeader, ct)
+		}
+		if w.cfg.compressible(ct) {
+			w.writer = w.newWriter()
+		}
+	}
+	if w.writer != nil {
+		h.Set(encoding.ContentEncodingHeader, w.algorithm)
+		h.Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressionResponseWriter) newWriter() io.WriteCloser {
+	level := w.cfg.levels[w.algorithm]
+	switch w.algorithm {
+	case brotliHeader:
+		return brotli.NewWriterLevel(w.ResponseWriter, level)
+	case gzipHeader:
+		// the level is validated when the middleware is created
+		gw, _ := gzip.NewWriterLevel(w.ResponseWriter, level)
+		return gw
+	default:
+		fw, _ := flate.NewWriter(w.ResponseWriter, level)
+		return fw
+	}
+}
+
+// Flush sends any buffered data to the client, compressing it first if needed.
+// Flushing a response which has not reached the minimum size yet compresses it, since more data is expected.
+func (w *compressionResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(len(w.buf) > 0)
+	}
+	if fl, ok := w.writer.(interface{ Flush() error }); ok {
+		_ = fl.Flush()
+	}
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, e.g. for websockets, if the underlying writer supports it.
+func (w *compressionResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.decided = true
+	return hj.Hijack()
+}
+
+// Close writes the buffered body, if it has not reached the minimum size, or finishes the compressed body.
+func (w *compressionResponseWriter) Close() error {
+	if !w.decided {
+		if w.statusCode == 0 && len(w.buf) == 0 {
+			// nothing was written, so the server writes the default response
+			return nil
+		}
+		// the body did not reach the minimum size, otherwise it would have been decided when written
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.writer != nil {
+		return w.writer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCompressionMiddlewareWithOptions_Errors(t *testing.T) {
+	tests := map[string]struct {
+		opts CompressionOptions
+		err  string
+	}{
+		"unsupported algorithm": {opts: CompressionOptions{Algorithms: []string{"zstd"}}, err: `compression algorithm "zstd" is not supported`},
+		"invalid brotli level":  {opts: CompressionOptions{BrotliLevel: 12}, err: "brotli level 12 is invalid"},
+		"invalid gzip level":    {opts: CompressionOptions{GzipLevel: 10}, err: "gzip level 10 is invalid"},
+		"invalid deflate level": {opts: CompressionOptions{DeflateLevel: -3}, err: "deflate level -3 is invalid"},
+		"negative minimum size": {opts: CompressionOptions{MinSize: -1}, err: "minimum size must not be negative"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewCompressionMiddlewareWithOptions(tt.opts)
+			assert.EqualError(t, err, tt.err)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func Test_negotiateCompression(t *testing.T) {
+	algorithms := []string{brotliHeader, gzipHeader, deflateHeader}
+
+	tests := map[string]struct {
+		header   string
+		expected string
+	}{
+		"empty":                  {header: "", expected: ""},
+		"identity":               {header: "identity", expected: ""},
+		"unsupported":            {header: "compress, zstd", expected: ""},
+		"single":                 {header: "gzip", expected: gzipHeader},
+		"preference":             {header: "deflate, gzip, br", expected: brotliHeader},
+		"quality":                {header: "br;q=0.5, gzip;q=0.8, deflate;q=0.1", expected: gzipHeader},
+		"case and spaces":        {header: " GZIP ; q=0.9 , Deflate", expected: deflateHeader},
+		"wildcard":               {header: "*", expected: brotliHeader},
+		"wildcard with excluded": {header: "br;q=0, *;q=0.5", expected: gzipHeader},
+		"all excluded":           {header: "br;q=0, gzip;q=0, deflate;q=0", expected: ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateCompression(tt.header, algorithms))
+		})
+	}
+}
+
+func TestNewCompressionMiddlewareWithOptions(t *testing.T) {
+	body := strings.Repeat("patron ", 100)
+
+	tests := map[string]struct {
+		opts             CompressionOptions
+		path             string
+		acceptEncoding   string
+		contentType      string
+		contentEncoding  string
+		status           int
+		body             string
+		expectedEncoding string
+	}{
+		"brotli":                {acceptEncoding: "gzip, br", body: body, expectedEncoding: brotliHeader},
+		"gzip":                  {acceptEncoding: "gzip", body: body, expectedEncoding: gzipHeader},
+		"deflate":               {acceptEncoding: "deflate", body: body, expectedEncoding: deflateHeader},
+		"levels":                {opts: CompressionOptions{BrotliLevel: 11, GzipLevel: 1}, acceptEncoding: "gzip;q=0.9, br", body: body, expectedEncoding: brotliHeader},
+		"preference":            {opts: CompressionOptions{Algorithms: []string{gzipHeader, brotliHeader}}, acceptEncoding: "br, gzip", body: body, expectedEncoding: gzipHeader},
+		"no accept encoding":    {body: body},
+		"not accepted":          {opts: CompressionOptions{Algorithms: []string{gzipHeader}}, acceptEncoding: "br", body: body},
+		"below minimum size":    {opts: CompressionOptions{MinSize: 1024}, acceptEncoding: "gzip", body: body},
+		"reaching minimum size": {opts: CompressionOptions{MinSize: 700}, acceptEncoding: "gzip", body: body, expectedEncoding: gzipHeader},
+		"compressed type":       {acceptEncoding: "gzip", contentType: "image/png", body: body},
+		"wildcard type":         {acceptEncoding: "gzip", contentType: "video/mp4", body: body},
+		"excluded type":         {opts: CompressionOptions{ExcludedContentTypes: []string{"Application/PDF"}}, acceptEncoding: "gzip", contentType: "application/pdf", body: body},
+		"event stream":          {acceptEncoding: "gzip", contentType: sseContentType, body: body},
+		"already encoded":       {acceptEncoding: "gzip", contentEncoding: "zstd", body: body},
+		"ignored route":         {opts: CompressionOptions{IgnoreRoutes: []string{"/metrics"}}, path: "/metrics", acceptEncoding: "gzip", body: body},
+		"no content":            {acceptEncoding: "gzip", status: http.StatusNoContent},
+		"empty body":            {acceptEncoding: "gzip", status: http.StatusOK},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewCompressionMiddlewareWithOptions(tt.opts)
+			require.NoError(t, err)
+			hnd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tt.contentEncoding)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// written in chunks, so that the minimum size is reached across writes
+				for i := 0; i < len(tt.body); i += 100 {
+					_, err := w.Write([]byte(tt.body[i : i+100]))
+					require.NoError(t, err)
+				}
+			}))
+
+			path := tt.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rc := httptest.NewRecorder()
+			hnd.ServeHTTP(rc, req)
+
+			expectedStatus := tt.status
+			if expectedStatus == 0 {
+				expectedStatus = http.StatusOK
+			}
+			assert.Equal(t, expectedStatus, rc.Code)
+			if tt.path == "" {
+				assert.Equal(t, []string{"Accept-Encoding"}, rc.Header().Values("Vary"))
+			}
+			if tt.expectedEncoding == "" {
+				if tt.contentEncoding == "" {
+					assert.Empty(t, rc.Header().Get("Content-Encoding"))
+				}
+				assert.Equal(t, tt.body, rc.Body.String())
+				return
+			}
+
+			assert.Equal(t, tt.expectedEncoding, rc.Header().Get("Content-Encoding"))
+			assert.Equal(t, "text/plain; charset=utf-8", rc.Header().Get("Content-Type"))
+			assert.Less(t, rc.Body.Len(), len(tt.body))
+			assert.Equal(t, tt.body, decompress(t, tt.expectedEncoding, rc.Body))
+		})
+	}
+}
+
+func TestNewCompressionMiddlewareWithOptions_Flush(t *testing.T) {
+	mw, err := NewCompressionMiddlewareWithOptions(CompressionOptions{MinSize: 1024})
+	require.NoError(t, err)
+	hnd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("second"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rc := httptest.NewRecorder()
+	hnd.ServeHTTP(rc, req)
+
+	assert.True(t, rc.Flushed)
+	assert.Equal(t, gzipHeader, rc.Header().Get("Content-Encoding"))
+	assert.Equal(t, "firstsecond", decompress(t, gzipHeader, rc.Body))
+}
+
+func decompress(t *testing.T, algorithm string, r io.Reader) string {
+	var rd io.Reader
+	switch algorithm {
+	case brotliHeader:
+		rd = brotli.NewReader(r)
+	case gzipHeader:
+		gr, err := gzip.NewReader(r)
+		require.NoError(t, err)
+		rd = gr
+	default:
+		rd = flate.NewReader(r)
+	}
+	b, err := ioutil.ReadAll(rd)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+)
+
+// ContextConstructor constructs a value for the context of a request, e.g. a request-scoped database session or the tenant of the request,
+// so that the processors and middlewares do not rely on package-level variables. The release function, if not nil,
+// is called after the request is handled, e.g. for closing the session. An error fails the request,
+// with the status code, headers and payload of an *Error, or else with a 500 Internal Server Error.
+type ContextConstructor func(r *http.Request) (val interface{}, release func(), err error)
+
+type contextValue struct {
+	key  interface{}
+	ctor ContextConstructor
+}
+
+func newContextValue(key interface{}, ctor ContextConstructor) (contextValue, error) {
+	if key == nil {
+		return contextValue{}, errors.New("context key is nil")
+	}
+	if ctor == nil {
+		return contextValue{}, errors.New("context constructor is nil")
+	}
+	return contextValue{key: key, ctor: ctor}, nil
+}
+
+// writeError writes the error with its status code, headers and payload, encoded like the response of the processor,
+// or else with JSON.
+func writeError(w http.ResponseWriter, r *http.Request, httpErr *Error) {
+	ct, _, enc, err := determineEncoding(r.Header)
+	if err != nil {
+		ct, enc = json.TypeCharset, json.Encode
+	}
+	prepareResponse(w, ct)
+	handleError(log.FromContext(r.Context()), w, enc, httpErr)
+}
+
+// newContextValuesMiddleware adds the values to the context of the requests, constructing them in order,
+// so that a constructor can use the values of the previous ones, and releasing them in reverse order.
+func newContextValuesMiddleware(values []contextValue) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			releases := make([]func(), 0, len(values))
+			defer func() {
+				for i := len(releases) - 1; i >= 0; i-- {
+					releases[i]()
+				}
+			}()
+
+			for _, cv := range values {
+				val, release, err := cv.ctor(r)
+				if err != nil {
+					var httpErr *Error
+					if errors.As(err, &httpErr) {
+						writeError(w, r, httpErr)
+						return
+					}
+					log.FromContext(r.Context()).Errorf("failed to construct context value %v: %v", cv.key, err)
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+				if release != nil {
+					releases = append(releases, release)
+				}
+				r = r.WithContext(context.WithValue(r.Context(), cv.key, val))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
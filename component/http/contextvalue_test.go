@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantKey struct{}
+
+type sessionKey struct{}
+
+type poolKey struct{}
+
+func TestBuilder_WithContextConstructor(t *testing.T) {
+	ctor := func(*http.Request) (interface{}, func(), error) { return nil, nil, nil }
+	_, err := NewBuilder().WithContextValue(nil, "value").WithContextConstructor(tenantKey{}, nil).WithContextConstructor(nil, ctor).Create()
+	assert.EqualError(t, err, "context key is nil\ncontext constructor is nil\ncontext key is nil\n")
+}
+
+func TestComponent_ContextValues(t *testing.T) {
+	var released []string
+	tenant := func(r *http.Request) (interface{}, func(), error) {
+		switch r.Header.Get("X-Tenant") {
+		case "":
+			return nil, nil, NewValidationErrorWithPayload(map[string]string{"error": "tenant is missing"}).WithHeaders(map[string]string{"X-Error": "tenant"})
+		case "failing":
+			return nil, nil, errors.New("TEST")
+		}
+		return r.Header.Get("X-Tenant"), func() { released = append(released, "tenant") }, nil
+	}
+	session := func(r *http.Request) (interface{}, func(), error) {
+		// the values of the previous constructors are available
+		return "session of " + r.Context().Value(tenantKey{}).(string), func() { released = append(released, "session") }, nil
+	}
+	proc := func(ctx context.Context, _ *Request) (*Response, error) {
+		return NewResponse([]interface{}{ctx.Value(poolKey{}), ctx.Value(tenantKey{}), ctx.Value(sessionKey{})}), nil
+	}
+
+	cmp, err := NewBuilder().
+		WithRoutesBuilder(NewRoutesBuilder().Append(NewGetRouteBuilder("/values", proc))).
+		WithContextValue(poolKey{}, "pool").
+		WithContextConstructor(tenantKey{}, tenant).
+		WithContextConstructor(sessionKey{}, session).
+		Create()
+	require.NoError(t, err)
+	srv := cmp.createServers()[0]
+
+	tests := map[string]struct {
+		tenant           string
+		expectedCode     int
+		expectedBody     string
+		expectedReleased []string
+	}{
+		"success":            {tenant: "acme", expectedCode: http.StatusOK, expectedBody: `["pool","acme","session of acme"]`, expectedReleased: []string{"session", "tenant"}},
+		"HTTP error":         {expectedCode: http.StatusBadRequest, expectedBody: `{"error":"tenant is missing"}`},
+		"constructor failed": {tenant: "failing", expectedCode: http.StatusInternalServerError, expectedBody: "Internal Server Error\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			released = nil
+			req := httptest.NewRequest(http.MethodGet, "/values", nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant", tt.tenant)
+			}
+			rsp := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			assert.Equal(t, tt.expectedReleased, released)
+			if tt.expectedCode == http.StatusBadRequest {
+				assert.Equal(t, "application/json; charset=utf-8", rsp.Header().Get("Content-Type"))
+				assert.Equal(t, "tenant", rsp.Header().Get("X-Error"))
+			}
+		})
+	}
+}
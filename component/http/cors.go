@@ -0,0 +1,247 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corsOriginHeader           = "Origin"
+	corsRequestMethodHeader    = "Access-Control-Request-Method"
+	corsRequestHeadersHeader   = "Access-Control-Request-Headers"
+	corsAllowOriginHeader      = "Access-Control-Allow-Origin"
+	corsAllowMethodsHeader     = "Access-Control-Allow-Methods"
+	corsAllowHeadersHeader     = "Access-Control-Allow-Headers"
+	corsAllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	corsExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	corsMaxAgeHeader           = "Access-Control-Max-Age"
+	corsAnyOrigin              = "*"
+)
+
+var (
+	corsDefaultMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	// headers which are always allowed by browsers, without being listed in the preflight response
+	corsSimpleHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type"}
+)
+
+// CORSOptions configures the cross-origin resource sharing of the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins are either exact origins, e.g. "https://example.com", origins with a single wildcard,
+	// e.g. "https://*.example.com", or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedOriginPatterns are regular expressions, which have to match the whole origin.
+	AllowedOriginPatterns []string
+	// AllowedMethods default to GET, HEAD and POST.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed besides the simple ones, or "*" for any header.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to the browser besides the simple ones.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or authorization headers, which is not possible for any origin.
+	AllowCredentials bool
+	// MaxAge is the duration for which the browser caches the preflight response; it is omitted if zero.
+	MaxAge time.Duration
+}
+
+type corsWildcard struct {
+	prefix string
+	suffix string
+}
+
+type cors struct {
+	anyOrigin        bool
+	origins          map[string]bool
+	wildcards        []corsWildcard
+	patterns         []*regexp.Regexp
+	methods          []string
+	anyHeader        bool
+	headers          map[string]bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// NewCORSMiddleware creates a MiddlewareFunc that implements cross-origin resource sharing.
+// Preflight requests are answered by the middleware with a 204 No Content, or a 403 Forbidden if the origin,
+// the method or the headers are not allowed. Actual requests from allowed origins get the CORS headers added to their response,
+// while requests from other origins are served without them, so that the browser blocks the response.
+// The middleware can be used globally, with the HTTP component's WithMiddlewares, or per route, with the route builder's WithCORS.
+func NewCORSMiddleware(opts CORSOptions) (MiddlewareFunc, error) {
+	c, err := newCORS(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.middleware, nil
+}
+
+func newCORS(opts CORSOptions) (*cors, error) {
+	if len(opts.AllowedOrigins) == 0 && len(opts.AllowedOriginPatterns) == 0 {
+		return nil, errors.New("allowed origins are empty")
+	}
+
+	c := &cors{
+		origins:          make(map[string]bool),
+		methods:          corsDefaultMethods,
+		headers:          make(map[string]bool),
+		exposedHeaders:   strings.Join(opts.ExposedHeaders, ", "),
+		allowCredentials: opts.AllowCredentials,
+	}
+
+	for _, origin := range opts.AllowedOrigins {
+		switch strings.Count(origin, "*") {
+		case 0:
+			c.origins[strings.ToLower(origin)] = true
+		case 1:
+			if origin == corsAnyOrigin {
+				c.anyOrigin = true
+				continue
+			}
+			i := strings.Index(origin, "*")
+			c.wildcards = append(c.wildcards, corsWildcard{prefix: strings.ToLower(origin[:i]), suffix: strings.ToLower(origin[i+1:])})
+		default:
+			return nil, fmt.Errorf("origin %q has more than one wildcard", origin)
+		}
+	}
+	for _, pattern := range opts.AllowedOriginPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin pattern %q: %w", pattern, err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	if c.anyOrigin && c.allowCredentials {
+		return nil, errors.New("credentials cannot be allowed for any origin")
+	}
+
+	if len(opts.AllowedMethods) > 0 {
+		c.methods = make([]string, 0, len(opts.AllowedMethods))
+		for _, m := range opts.AllowedMethods {
+			c.methods = append(c.methods, strings.ToUpper(m))
+		}
+	}
+
+	for _, h := range corsSimpleHeaders {
+		c.headers[strings.ToLower(h)] = true
+	}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[strings.ToLower(h)] = true
+	}
+
+	if opts.MaxAge < 0 {
+		return nil, errors.New("max age must not be negative")
+	}
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+
+	return c, nil
+}
+
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(corsOriginHeader)
+		if r.Method == http.MethodOptions && r.Header.Get(corsRequestMethodHeader) != "" {
+			c.handlePreflight(w, r, origin)
+			return
+		}
+
+		w.Header().Add("Vary", corsOriginHeader)
+		if origin != "" && c.allowsOrigin(origin) {
+			c.setOriginHeaders(w, origin)
+			if c.exposedHeaders != "" {
+				w.Header().Set(corsExposeHeadersHeader, c.exposedHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request, origin string) {
+	w.Header().Add("Vary", corsOriginHeader)
+	w.Header().Add("Vary", corsRequestMethodHeader)
+	w.Header().Add("Vary", corsRequestHeadersHeader)
+
+	if origin == "" || !c.allowsOrigin(origin) || !c.allowsMethod(r.Header.Get(corsRequestMethodHeader)) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	reqHeaders := r.Header.Get(corsRequestHeadersHeader)
+	if !c.allowsHeaders(reqHeaders) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	c.setOriginHeaders(w, origin)
+	w.Header().Set(corsAllowMethodsHeader, strings.Join(c.methods, ", "))
+	if reqHeaders != "" {
+		w.Header().Set(corsAllowHeadersHeader, reqHeaders)
+	}
+	if c.maxAge != "" {
+		w.Header().Set(corsMaxAgeHeader, c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) setOriginHeaders(w http.ResponseWriter, origin string) {
+	if c.anyOrigin {
+		w.Header().Set(corsAllowOriginHeader, corsAnyOrigin)
+	} else {
+		w.Header().Set(corsAllowOriginHeader, origin)
+	}
+	if c.allowCredentials {
+		w.Header().Set(corsAllowCredentialsHeader, "true")
+	}
+}
+
+func (c *cors) allowsOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if c.origins[lower] {
+		return true
+	}
+	for _, wc := range c.wildcards {
+		if len(lower) > len(wc.prefix)+len(wc.suffix) && strings.HasPrefix(lower, wc.prefix) && strings.HasSuffix(lower, wc.suffix) {
+			return true
+		}
+	}
+	for _, re := range c.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowsMethod(method string) bool {
+	method = strings.ToUpper(method)
+	for _, m := range c.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) allowsHeaders(headers string) bool {
+	if c.anyHeader || headers == "" {
+		return true
+	}
+	for _, h := range strings.Split(headers, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && !c.headers[h] {
+			return false
+		}
+	}
+	return true
+}
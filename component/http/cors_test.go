@@ -0,0 +1,188 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCORSMiddleware_Errors(t *testing.T) {
+	tests := map[string]struct {
+		opts CORSOptions
+		err  string
+	}{
+		"no origins":             {opts: CORSOptions{}, err: "allowed origins are empty"},
+		"two wildcards":          {opts: CORSOptions{AllowedOrigins: []string{"https://*.*.com"}}, err: `origin "https://*.*.com" has more than one wildcard`},
+		"invalid pattern":        {opts: CORSOptions{AllowedOriginPatterns: []string{"("}}, err: "invalid origin pattern \"(\": error parsing regexp: missing closing ): `^(?:()$`"},
+		"credentials any origin": {opts: CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}, err: "credentials cannot be allowed for any origin"},
+		"negative max age":       {opts: CORSOptions{AllowedOrigins: []string{"*"}, MaxAge: -time.Second}, err: "max age must not be negative"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewCORSMiddleware(tt.opts)
+			assert.EqualError(t, err, tt.err)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestNewCORSMiddleware(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins:        []string{"https://example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []string{`https://app-[0-9]+\.example\.net`},
+		AllowedMethods:        []string{"get", "put"},
+		AllowedHeaders:        []string{"Authorization"},
+		ExposedHeaders:        []string{"X-Total", "X-Page"},
+		AllowCredentials:      true,
+		MaxAge:                time.Hour,
+	}
+	mw, err := NewCORSMiddleware(opts)
+	require.NoError(t, err)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	hnd := mw(next)
+
+	tests := map[string]struct {
+		method         string
+		origin         string
+		reqMethod      string
+		reqHeaders     string
+		expectedCode   int
+		expectedOrigin string
+		preflight      bool
+	}{
+		"no origin":                  {method: http.MethodGet, expectedCode: http.StatusAccepted},
+		"exact origin":               {method: http.MethodGet, origin: "https://example.com", expectedCode: http.StatusAccepted, expectedOrigin: "https://example.com"},
+		"wildcard origin":            {method: http.MethodGet, origin: "https://api.example.org", expectedCode: http.StatusAccepted, expectedOrigin: "https://api.example.org"},
+		"wildcard without subdomain": {method: http.MethodGet, origin: "https://.example.org", expectedCode: http.StatusAccepted},
+		"pattern origin":             {method: http.MethodGet, origin: "https://app-12.example.net", expectedCode: http.StatusAccepted, expectedOrigin: "https://app-12.example.net"},
+		"pattern partial match":      {method: http.MethodGet, origin: "https://app-12.example.net.evil.com", expectedCode: http.StatusAccepted},
+		"disallowed origin":          {method: http.MethodGet, origin: "https://evil.com", expectedCode: http.StatusAccepted},
+		"preflight": {method: http.MethodOptions, origin: "https://example.com", reqMethod: http.MethodPut, reqHeaders: "authorization, content-type",
+			expectedCode: http.StatusNoContent, expectedOrigin: "https://example.com", preflight: true},
+		"preflight disallowed origin":  {method: http.MethodOptions, origin: "https://evil.com", reqMethod: http.MethodPut, expectedCode: http.StatusForbidden},
+		"preflight disallowed method":  {method: http.MethodOptions, origin: "https://example.com", reqMethod: http.MethodDelete, expectedCode: http.StatusForbidden},
+		"preflight disallowed headers": {method: http.MethodOptions, origin: "https://example.com", reqMethod: http.MethodPut, reqHeaders: "X-Custom", expectedCode: http.StatusForbidden},
+		"options without preflight":    {method: http.MethodOptions, origin: "https://example.com", expectedCode: http.StatusAccepted, expectedOrigin: "https://example.com"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.origin != "" {
+				req.Header.Set(corsOriginHeader, tt.origin)
+			}
+			if tt.reqMethod != "" {
+				req.Header.Set(corsRequestMethodHeader, tt.reqMethod)
+			}
+			if tt.reqHeaders != "" {
+				req.Header.Set(corsRequestHeadersHeader, tt.reqHeaders)
+			}
+			rc := httptest.NewRecorder()
+			hnd.ServeHTTP(rc, req)
+
+			assert.Equal(t, tt.expectedCode, rc.Code)
+			assert.Contains(t, rc.Header().Values("Vary"), corsOriginHeader)
+			assert.Equal(t, tt.expectedOrigin, rc.Header().Get(corsAllowOriginHeader))
+			if tt.expectedOrigin == "" {
+				assert.Empty(t, rc.Header().Get(corsAllowCredentialsHeader))
+				return
+			}
+			assert.Equal(t, "true", rc.Header().Get(corsAllowCredentialsHeader))
+			if tt.preflight {
+				assert.Equal(t, "GET, PUT", rc.Header().Get(corsAllowMethodsHeader))
+				assert.Equal(t, tt.reqHeaders, rc.Header().Get(corsAllowHeadersHeader))
+				assert.Equal(t, "3600", rc.Header().Get(corsMaxAgeHeader))
+				assert.Empty(t, rc.Header().Get(corsExposeHeadersHeader))
+			} else {
+				assert.Equal(t, "X-Total, X-Page", rc.Header().Get(corsExposeHeadersHeader))
+				assert.Empty(t, rc.Header().Get(corsAllowMethodsHeader))
+			}
+		})
+	}
+}
+
+func TestNewCORSMiddleware_AnyOrigin(t *testing.T) {
+	mw, err := NewCORSMiddleware(CORSOptions{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}})
+	require.NoError(t, err)
+	hnd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set(corsOriginHeader, "https://example.com")
+	req.Header.Set(corsRequestMethodHeader, http.MethodPost)
+	req.Header.Set(corsRequestHeadersHeader, "X-Custom")
+	rc := httptest.NewRecorder()
+	hnd.ServeHTTP(rc, req)
+
+	assert.Equal(t, http.StatusNoContent, rc.Code)
+	assert.Equal(t, "*", rc.Header().Get(corsAllowOriginHeader))
+	assert.Equal(t, "GET, HEAD, POST", rc.Header().Get(corsAllowMethodsHeader))
+	assert.Equal(t, "X-Custom", rc.Header().Get(corsAllowHeadersHeader))
+	assert.Empty(t, rc.Header().Get(corsMaxAgeHeader))
+}
+
+func TestRouteBuilder_WithCORS(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+
+	rb := NewGetRouteBuilder("/", proc).WithCORS(CORSOptions{})
+	assert.Len(t, rb.errors, 1)
+	assert.EqualError(t, rb.errors[0], "allowed origins are empty")
+
+	rb = NewGetRouteBuilder("/", proc).WithCORS(CORSOptions{AllowedOrigins: []string{"https://example.com"}})
+	assert.Len(t, rb.errors, 0)
+	route, err := rb.Build()
+	require.NoError(t, err)
+	assert.NotNil(t, route.cors)
+}
+
+func TestComponent_CORSPreflightRoutes(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return NewResponse("ok"), nil }
+	opts := CORSOptions{AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{http.MethodGet, http.MethodPost}}
+	rb := NewRoutesBuilder().
+		Append(NewGetRouteBuilder("/cors", proc).WithCORS(opts)).
+		Append(NewPostRouteBuilder("/cors", proc).WithCORS(opts)).
+		Append(NewGetRouteBuilder("/custom", proc).WithCORS(opts)).
+		Append(NewOptionsRouteBuilder("/custom", proc)).
+		Append(NewGetRouteBuilder("/plain", proc))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+	srv := httptest.NewServer(cmp.createHTTPServer().Handler)
+	defer srv.Close()
+
+	tests := map[string]struct {
+		path           string
+		expectedCode   int
+		expectedOrigin string
+	}{
+		"cors route":         {path: "/cors", expectedCode: http.StatusNoContent, expectedOrigin: "https://example.com"},
+		"user options route": {path: "/custom", expectedCode: http.StatusOK},
+		"route without cors": {path: "/plain", expectedCode: http.StatusOK},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodOptions, srv.URL+tt.path, nil)
+			require.NoError(t, err)
+			req.Header.Set(corsOriginHeader, "https://example.com")
+			req.Header.Set(corsRequestMethodHeader, http.MethodPost)
+			rsp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			_ = rsp.Body.Close()
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedOrigin, rsp.Header.Get(corsAllowOriginHeader))
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/cors", nil)
+	require.NoError(t, err)
+	req.Header.Set(corsOriginHeader, "https://example.com")
+	rsp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	_ = rsp.Body.Close()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, "https://example.com", rsp.Header.Get(corsAllowOriginHeader))
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	csrfDefaultCookieName = "csrf_token"
+	csrfDefaultHeaderName = "X-CSRF-Token"
+	csrfDefaultMaxAge     = 12 * time.Hour
+	csrfTokenSize         = 32
+)
+
+type csrfTokenKey struct{}
+
+// CSRFOptions configures the double-submit cookie CSRF protection.
+type CSRFOptions struct {
+	// CookieName defaults to "csrf_token".
+	CookieName string
+	// HeaderName is the header of the requests carrying the token, and of the responses providing it. It defaults to "X-CSRF-Token".
+	HeaderName string
+	// CookiePath defaults to "/".
+	CookiePath string
+	// CookieDomain is omitted if empty, which restricts the cookie to the host of the service.
+	CookieDomain string
+	// Secure restricts the cookie to HTTPS, which is required for SameSite None.
+	Secure bool
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+	// MaxAge of the cookie, which defaults to 12 hours.
+	MaxAge time.Duration
+}
+
+// CSRFToken is the response of the CSRF token route.
+type CSRFToken struct {
+	Token string `json:"token"`
+}
+
+type csrf struct {
+	cookieName string
+	headerName string
+	path       string
+	domain     string
+	secure     bool
+	sameSite   http.SameSite
+	maxAge     time.Duration
+}
+
+// NewCSRFMiddleware creates a MiddlewareFunc which protects browser-facing routes from cross-site request forgery with a double-submit cookie.
+// Safe requests, i.e. GET, HEAD, OPTIONS and TRACE, get a random token in a cookie, unless they already have one,
+// and the token in the header of the response. Unsafe requests are rejected with a 403 Forbidden, unless they carry the token
+// of their cookie in the header, which other sites cannot read and therefore cannot send.
+// The middleware can be used globally, with the HTTP component's WithMiddlewares, or per route, with the route builder's WithMiddlewares.
+func NewCSRFMiddleware(opts CSRFOptions) (MiddlewareFunc, error) {
+	c, err := newCSRF(opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.middleware, nil
+}
+
+// NewCSRFTokenRouteBuilder creates a GET route which returns the CSRF token, and sets its cookie if the request has none,
+// e.g. for single-page applications getting the token before their first unsafe request.
+func NewCSRFTokenRouteBuilder(path string, opts CSRFOptions) *RouteBuilder {
+	c, err := newCSRF(opts)
+	if err != nil {
+		rb := NewRawRouteBuilder(path, func(http.ResponseWriter, *http.Request) {}).MethodGet()
+		rb.errors = append(rb.errors, err)
+		return rb
+	}
+
+	return NewRawRouteBuilder(path, func(w http.ResponseWriter, r *http.Request) {
+		token, err := c.ensureToken(w, r)
+		if err != nil {
+			log.FromContext(r.Context()).Errorf("failed to generate CSRF token: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		b, err := json.Encode(CSRFToken{Token: token})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set(encoding.ContentTypeHeader, json.TypeCharset)
+		_, _ = w.Write(b)
+	}).MethodGet()
+}
+
+// CSRFTokenFromContext returns the CSRF token of the request, which is set by the CSRF middleware on safe requests,
+// e.g. for embedding it in server-side rendered pages, for the scripts sending the unsafe requests.
+func CSRFTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(csrfTokenKey{}).(string)
+	return token, ok
+}
+
+func newCSRF(opts CSRFOptions) (*csrf, error) {
+	c := &csrf{
+		cookieName: opts.CookieName,
+		headerName: opts.HeaderName,
+		path:       opts.CookiePath,
+		domain:     opts.CookieDomain,
+		secure:     opts.Secure,
+		sameSite:   opts.SameSite,
+		maxAge:     opts.MaxAge,
+	}
+	if c.cookieName == "" {
+		c.cookieName = csrfDefaultCookieName
+	}
+	if c.headerName == "" {
+		c.headerName = csrfDefaultHeaderName
+	}
+	if c.path == "" {
+		c.path = "/"
+	}
+	if c.sameSite == http.SameSiteDefaultMode || c.sameSite == 0 {
+		c.sameSite = http.SameSiteLaxMode
+	}
+	if c.sameSite == http.SameSiteNoneMode && !c.secure {
+		return nil, errors.New("same site none requires a secure cookie")
+	}
+	if c.maxAge < 0 {
+		return nil, errors.New("max age must not be negative")
+	}
+	if c.maxAge == 0 {
+		c.maxAge = csrfDefaultMaxAge
+	}
+	return c, nil
+}
+
+func (c *csrf) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			token, err := c.ensureToken(w, r)
+			if err != nil {
+				log.FromContext(r.Context()).Errorf("failed to generate CSRF token: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfTokenKey{}, token)))
+			return
+		}
+
+		w.Header().Add("Vary", "Cookie")
+		if !c.valid(r) {
+			log.FromContext(r.Context()).Debugf("CSRF token of %s %s is missing or invalid", r.Method, r.URL.Path)
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *csrf) valid(r *http.Request) bool {
+	cookie, ok := c.cookieToken(r)
+	if !ok {
+		return false
+	}
+	header := r.Header.Get(c.headerName)
+	return header != "" && subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func (c *csrf) cookieToken(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(c.cookieName)
+	if err != nil {
+		return "", false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(b) != csrfTokenSize {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// ensureToken returns the token of the cookie of the request, or a new one, which is set in the cookie of the response,
+// and provides it in the header of the response.
+func (c *csrf) ensureToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	w.Header().Add("Vary", "Cookie")
+	token, ok := c.cookieToken(r)
+	if !ok {
+		b := make([]byte, csrfTokenSize)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		token = base64.RawURLEncoding.EncodeToString(b)
+		http.SetCookie(w, &http.Cookie{
+			Name:     c.cookieName,
+			Value:    token,
+			Path:     c.path,
+			Domain:   c.domain,
+			MaxAge:   int(c.maxAge.Seconds()),
+			Secure:   c.secure,
+			HttpOnly: true,
+			SameSite: c.sameSite,
+		})
+	}
+	w.Header().Set(c.headerName, token)
+	return token, nil
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCSRFMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		opts        CSRFOptions
+		expectedErr string
+	}{
+		"defaults":                   {opts: CSRFOptions{}},
+		"same site none and secure":  {opts: CSRFOptions{SameSite: http.SameSiteNoneMode, Secure: true}},
+		"same site none, not secure": {opts: CSRFOptions{SameSite: http.SameSiteNoneMode}, expectedErr: "same site none requires a secure cookie"},
+		"negative max age":           {opts: CSRFOptions{MaxAge: -time.Second}, expectedErr: "max age must not be negative"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewCSRFMiddleware(tt.opts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, mw)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, mw)
+			}
+		})
+	}
+}
+
+func TestCSRF_middleware(t *testing.T) {
+	mw, err := NewCSRFMiddleware(CSRFOptions{Secure: true, SameSite: http.SameSiteStrictMode, MaxAge: time.Hour})
+	require.NoError(t, err)
+	var ctxToken string
+	hnd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxToken, _ = CSRFTokenFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// a safe request gets the token in a cookie and in the header
+	rsp := httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rsp.Code)
+	cookies := rsp.Result().Cookies()
+	require.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.Equal(t, "csrf_token", cookie.Name)
+	assert.Equal(t, "/", cookie.Path)
+	assert.Equal(t, 3600, cookie.MaxAge)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	token := rsp.Header().Get("X-CSRF-Token")
+	assert.Equal(t, cookie.Value, token)
+	assert.Equal(t, token, ctxToken)
+
+	// a safe request with the cookie keeps its token
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rsp = httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, req)
+	assert.Empty(t, rsp.Result().Cookies())
+	assert.Equal(t, token, rsp.Header().Get("X-CSRF-Token"))
+
+	tests := map[string]struct {
+		cookie       *http.Cookie
+		header       string
+		expectedCode int
+	}{
+		"valid":            {cookie: cookie, header: token, expectedCode: http.StatusOK},
+		"missing cookie":   {header: token, expectedCode: http.StatusForbidden},
+		"missing header":   {cookie: cookie, expectedCode: http.StatusForbidden},
+		"different tokens": {cookie: cookie, header: token[1:] + "A", expectedCode: http.StatusForbidden},
+		"malformed cookie": {cookie: &http.Cookie{Name: "csrf_token", Value: "token"}, header: "token", expectedCode: http.StatusForbidden},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			rsp := httptest.NewRecorder()
+			hnd.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
+func TestNewCSRFTokenRouteBuilder(t *testing.T) {
+	_, err := NewCSRFTokenRouteBuilder("/csrf", CSRFOptions{MaxAge: -time.Second}).Build()
+	assert.EqualError(t, err, "max age must not be negative\n")
+
+	route, err := NewCSRFTokenRouteBuilder("/csrf", CSRFOptions{CookieName: "xsrf", HeaderName: "X-XSRF-Token"}).Build()
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, route.method)
+	rsp := httptest.NewRecorder()
+	route.handler(rsp, httptest.NewRequest(http.MethodGet, "/csrf", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	cookies := rsp.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "xsrf", cookies[0].Name)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.Equal(t, cookies[0].Value, rsp.Header().Get("X-XSRF-Token"))
+	assert.JSONEq(t, `{"token":"`+cookies[0].Value+`"}`, rsp.Body.String())
+	assert.Equal(t, "no-store", rsp.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	// RequestTimeoutHeader is the header of the timeout of a request, as a Go duration, e.g. 1.5s or 300ms.
+	RequestTimeoutHeader = "X-Request-Timeout"
+	// GRPCTimeoutHeader is the header of the timeout of a gRPC request, e.g. 300m for 300 milliseconds.
+	GRPCTimeoutHeader = "Grpc-Timeout"
+)
+
+// DeadlineOptions of the propagation of the deadline of the callers from the headers of their requests.
+type DeadlineOptions struct {
+	// Headers of the timeout, which default to X-Request-Timeout and grpc-timeout. The first one present is used.
+	// The values of grpc-timeout are in the gRPC format, while the values of any other header are Go durations.
+	Headers []string
+	// Max caps the timeouts of the callers, if positive.
+	Max time.Duration
+}
+
+// NewDeadlineMiddleware creates a MiddlewareFunc that sets the deadline of the context of the requests to the timeout
+// in their headers, so that the calls to the downstream services inherit the budget of the caller.
+// Invalid or non-positive timeouts are ignored.
+func NewDeadlineMiddleware(opts DeadlineOptions) (MiddlewareFunc, error) {
+	if opts.Max < 0 {
+		return nil, errors.New("max deadline must not be negative")
+	}
+	headers := opts.Headers
+	if len(headers) == 0 {
+		headers = []string{RequestTimeoutHeader, GRPCTimeoutHeader}
+	}
+	for _, h := range headers {
+		if h == "" {
+			return nil, errors.New("deadline header is empty")
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := requestTimeout(r.Header, headers)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if opts.Max > 0 && timeout > opts.Max {
+				timeout = opts.Max
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+// requestTimeout returns the timeout of the first of the headers present in the request.
+func requestTimeout(h http.Header, headers []string) (time.Duration, bool) {
+	for _, name := range headers {
+		val := h.Get(name)
+		if val == "" {
+			continue
+		}
+		var timeout time.Duration
+		var err error
+		if strings.EqualFold(name, GRPCTimeoutHeader) {
+			timeout, err = parseGRPCTimeout(val)
+		} else {
+			timeout, err = time.ParseDuration(val)
+		}
+		if err != nil || timeout <= 0 {
+			log.Debugf("ignoring invalid timeout %q of header %s", val, name)
+			return 0, false
+		}
+		return timeout, true
+	}
+	return 0, false
+}
+
+// parseGRPCTimeout parses a timeout in the gRPC format, i.e. at most 8 digits followed by the unit,
+// which is one of H (hours), M (minutes), S (seconds), m (milliseconds), u (microseconds) and n (nanoseconds).
+func parseGRPCTimeout(val string) (time.Duration, error) {
+	if len(val) < 2 || len(val) > 9 {
+		return 0, fmt.Errorf("invalid gRPC timeout %q", val)
+	}
+	var unit time.Duration
+	switch val[len(val)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, fmt.Errorf("invalid gRPC timeout unit of %q", val)
+	}
+	n, err := strconv.ParseInt(val[:len(val)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid gRPC timeout %q", val)
+	}
+	return time.Duration(n) * unit, nil
+}
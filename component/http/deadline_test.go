@@ -0,0 +1,113 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDeadlineMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		opts        DeadlineOptions
+		headers     map[string]string
+		expected    time.Duration
+		expectedErr string
+	}{
+		"request timeout":          {headers: map[string]string{"X-Request-Timeout": "1.5s"}, expected: 1500 * time.Millisecond},
+		"gRPC timeout":             {headers: map[string]string{"grpc-timeout": "300m"}, expected: 300 * time.Millisecond},
+		"first header present":     {headers: map[string]string{"X-Request-Timeout": "2s", "grpc-timeout": "1S"}, expected: 2 * time.Second},
+		"capped timeout":           {opts: DeadlineOptions{Max: time.Second}, headers: map[string]string{"X-Request-Timeout": "1m"}, expected: time.Second},
+		"custom header":            {opts: DeadlineOptions{Headers: []string{"X-Timeout"}}, headers: map[string]string{"X-Timeout": "5s", "X-Request-Timeout": "1s"}, expected: 5 * time.Second},
+		"no header":                {},
+		"invalid timeout":          {headers: map[string]string{"X-Request-Timeout": "soon"}},
+		"negative timeout":         {headers: map[string]string{"X-Request-Timeout": "-1s"}},
+		"invalid gRPC timeout":     {headers: map[string]string{"grpc-timeout": "1s"}},
+		"negative max":             {opts: DeadlineOptions{Max: -time.Second}, expectedErr: "max deadline must not be negative"},
+		"empty header":             {opts: DeadlineOptions{Headers: []string{""}}, expectedErr: "deadline header is empty"},
+		"header of other protocol": {opts: DeadlineOptions{Headers: []string{"X-Timeout"}}, headers: map[string]string{"X-Request-Timeout": "1s"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewDeadlineMiddleware(tt.opts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var deadline time.Time
+			var hasDeadline bool
+			handler := mw(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				deadline, hasDeadline = r.Context().Deadline()
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			start := time.Now()
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.expected == 0 {
+				assert.False(t, hasDeadline)
+				return
+			}
+			require.True(t, hasDeadline)
+			assert.WithinDuration(t, start.Add(tt.expected), deadline, 100*time.Millisecond)
+		})
+	}
+}
+
+func Test_parseGRPCTimeout(t *testing.T) {
+	tests := map[string]struct {
+		val         string
+		expected    time.Duration
+		expectedErr string
+	}{
+		"hours":        {val: "1H", expected: time.Hour},
+		"minutes":      {val: "2M", expected: 2 * time.Minute},
+		"seconds":      {val: "3S", expected: 3 * time.Second},
+		"milliseconds": {val: "4m", expected: 4 * time.Millisecond},
+		"microseconds": {val: "5u", expected: 5 * time.Microsecond},
+		"nanoseconds":  {val: "6n", expected: 6 * time.Nanosecond},
+		"no unit":      {val: "1", expectedErr: `invalid gRPC timeout "1"`},
+		"invalid unit": {val: "1s", expectedErr: `invalid gRPC timeout unit of "1s"`},
+		"no digits":    {val: "xS", expectedErr: `invalid gRPC timeout "xS"`},
+		"too long":     {val: "123456789S", expectedErr: `invalid gRPC timeout "123456789S"`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := parseGRPCTimeout(tt.val)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestBuilder_WithDeadlinePropagation(t *testing.T) {
+	_, err := NewBuilder().WithDeadlinePropagation(DeadlineOptions{Max: -1}).Create()
+	assert.EqualError(t, err, "max deadline must not be negative\n")
+
+	var hasDeadline bool
+	cmp, err := NewBuilder().
+		WithRoutesBuilder(NewRoutesBuilder().Append(NewRawRouteBuilder("/deadline", func(_ http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}).MethodGet())).
+		WithDeadlinePropagation(DeadlineOptions{}).
+		Create()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/deadline", nil)
+	req.Header.Set(RequestTimeoutHeader, "1s")
+	cmp.createServers()[0].Handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, hasDeadline)
+}
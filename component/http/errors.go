@@ -1,10 +1,17 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
 
+type errorEncoderKey struct{}
+
+// ErrorEncoder maps an error returned by a processor to a response, e.g. a domain error to a 409 Conflict with a payload.
+// Returning nil falls back to the default handling of the error. Responses without a status code are sent with a 500 Internal Server Error.
+type ErrorEncoder func(ctx context.Context, err error) *Response
+
 // Error defines an abstract struct that can represent several types of HTTP errors.
 type Error struct {
 	code    int
@@ -85,3 +92,20 @@ func NewError() *Error {
 func NewErrorWithCodeAndPayload(code int, payload interface{}) *Error {
 	return &Error{code: code, payload: payload}
 }
+
+// newErrorEncoderMiddleware makes the error encoder available to the handlers of the processors.
+func newErrorEncoderMiddleware(enc ErrorEncoder) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), errorEncoderKey{}, enc)))
+		})
+	}
+}
+
+func errorEncoderFromContext(ctx context.Context) ErrorEncoder {
+	enc, ok := ctx.Value(errorEncoderKey{}).(ErrorEncoder)
+	if !ok {
+		return nil
+	}
+	return enc
+}
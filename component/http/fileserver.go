@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+)
+
+// FileServerOptionFunc definition for configuring a file server in a functional way.
+type FileServerOptionFunc func(*fileServer) error
+
+type fileServer struct {
+	maxAges       map[string]time.Duration
+	defaultMaxAge time.Duration
+	hasMaxAge     bool
+	listing       bool
+	precompressed bool
+}
+
+// precompressedEncodings are the encodings of the precompressed assets, in order of preference.
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{encoding: brotliHeader, extension: ".br"},
+	{encoding: gzipHeader, extension: ".gz"},
+}
+
+// FileServerMaxAge option for setting the Cache-Control max-age of the assets with the extensions, e.g. ".js",
+// or of all the assets without a max-age for their extension, when no extensions are provided.
+func FileServerMaxAge(maxAge time.Duration, extensions ...string) FileServerOptionFunc {
+	return func(fs *fileServer) error {
+		if maxAge < 0 {
+			return errors.New("max age should be zero or positive")
+		}
+		if len(extensions) == 0 {
+			fs.defaultMaxAge = maxAge
+			fs.hasMaxAge = true
+			return nil
+		}
+		for _, ext := range extensions {
+			if !strings.HasPrefix(ext, ".") {
+				return fmt.Errorf("extension %q should start with a dot", ext)
+			}
+			fs.maxAges[strings.ToLower(ext)] = maxAge
+		}
+		return nil
+	}
+}
+
+// FileServerDirectoryListing option for listing the files of the directories, instead of serving the fallback file.
+// Directories with an index.html file are served with it.
+func FileServerDirectoryListing() FileServerOptionFunc {
+	return func(fs *fileServer) error {
+		fs.listing = true
+		return nil
+	}
+}
+
+// FileServerPrecompressed option for serving the precompressed .br or .gz version of an asset, when it exists
+// and the client accepts its encoding.
+func FileServerPrecompressed() FileServerOptionFunc {
+	return func(fs *fileServer) error {
+		fs.precompressed = true
+		return nil
+	}
+}
+
+// serve serves an asset, with cache headers and range requests support, or lists a directory.
+func (fs *fileServer) serve(w http.ResponseWriter, r *http.Request, assetPath string, info os.FileInfo) {
+	// reject directory traversal, like http.ServeFile does
+	if containsDotDot(r.URL.Path) {
+		http.Error(w, "invalid URL path", http.StatusBadRequest)
+		return
+	}
+
+	if info.IsDir() {
+		http.ServeFile(w, r, assetPath)
+		return
+	}
+
+	if maxAge, ok := fs.maxAge(assetPath); ok {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	}
+
+	servedPath := assetPath
+	if fs.precompressed {
+		w.Header().Add("Vary", encoding.AcceptEncodingHeader)
+		if p, enc, pInfo, ok := precompressedAsset(r, assetPath); ok {
+			servedPath, info = p, pInfo
+			w.Header().Set(encoding.ContentEncodingHeader, enc)
+			if ct := mime.TypeByExtension(filepath.Ext(assetPath)); ct != "" {
+				w.Header().Set(encoding.ContentTypeHeader, ct)
+			}
+		}
+	}
+
+	f, err := os.Open(servedPath) //nolint:gosec
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// the ETag is derived from the size and the modification time of the served file, which identify its version cheaply
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	// ServeContent handles the conditional and range requests
+	http.ServeContent(w, r, assetPath, info.ModTime(), f)
+}
+
+func (fs *fileServer) maxAge(assetPath string) (time.Duration, bool) {
+	if maxAge, ok := fs.maxAges[strings.ToLower(filepath.Ext(assetPath))]; ok {
+		return maxAge, true
+	}
+	return fs.defaultMaxAge, fs.hasMaxAge
+}
+
+// precompressedAsset returns the precompressed version of an asset, negotiated with the Accept-Encoding header of the request.
+func precompressedAsset(r *http.Request, assetPath string) (string, string, os.FileInfo, bool) {
+	accepted := r.Header.Get(encoding.AcceptEncodingHeader)
+	if accepted == "" {
+		return "", "", nil, false
+	}
+	var available []string
+	infos := make(map[string]os.FileInfo)
+	for _, pe := range precompressedEncodings {
+		info, err := os.Stat(assetPath + pe.extension)
+		if err == nil && !info.IsDir() {
+			available = append(available, pe.encoding)
+			infos[pe.encoding] = info
+		}
+	}
+	negotiated := negotiateCompression(accepted, available)
+	for _, pe := range precompressedEncodings {
+		if pe.encoding == negotiated {
+			return assetPath + pe.extension, pe.encoding, infos[pe.encoding], true
+		}
+	}
+	return "", "", nil, false
+}
+
+func containsDotDot(v string) bool {
+	if !strings.Contains(v, "..") {
+		return false
+	}
+	for _, ent := range strings.FieldsFunc(v, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if ent == ".." {
+			return true
+		}
+	}
+	return false
+}
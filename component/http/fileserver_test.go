@@ -0,0 +1,132 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileServer_Options(t *testing.T) {
+	tests := map[string]struct {
+		oo          []FileServerOptionFunc
+		expectedErr string
+	}{
+		"success":           {oo: []FileServerOptionFunc{FileServerMaxAge(time.Hour, ".js"), FileServerDirectoryListing(), FileServerPrecompressed()}},
+		"negative max age":  {oo: []FileServerOptionFunc{FileServerMaxAge(-time.Hour)}, expectedErr: "max age should be zero or positive\n"},
+		"invalid extension": {oo: []FileServerOptionFunc{FileServerMaxAge(time.Hour, "js")}, expectedErr: "extension \"js\" should start with a dot\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := NewFileServer("/frontend/*path", "testdata", "testdata/index.html", tt.oo...).Build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewFileServer_Serve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "assets")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "css"), 0o750))
+	writeAsset(t, dir, "app.js", "0123456789")
+	writeAsset(t, dir, "app.js.br", "brotli")
+	writeAsset(t, dir, "app.js.gz", "gzip")
+	writeAsset(t, dir, "index.html", "fallback")
+	writeAsset(t, dir, "css/style.css", "style")
+
+	newRouter := func(oo ...FileServerOptionFunc) *httprouter.Router {
+		route, err := NewFileServer("/frontend/*path", dir, filepath.Join(dir, "index.html"), oo...).Build()
+		require.NoError(t, err)
+		router := httprouter.New()
+		router.HandlerFunc(route.method, route.path, route.handler)
+		return router
+	}
+	serve := func(router *httprouter.Router, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rsp := httptest.NewRecorder()
+		router.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	t.Run("cache headers", func(t *testing.T) {
+		router := newRouter(FileServerMaxAge(time.Minute), FileServerMaxAge(time.Hour, ".js"))
+		rsp := serve(router, "/frontend/app.js", nil)
+		assert.Equal(t, http.StatusOK, rsp.Code)
+		assert.Equal(t, "public, max-age=3600", rsp.Header().Get("Cache-Control"))
+		assert.Equal(t, "0123456789", rsp.Body.String())
+		etag := rsp.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		rsp = serve(router, "/frontend/css/style.css", nil)
+		assert.Equal(t, "public, max-age=60", rsp.Header().Get("Cache-Control"))
+
+		rsp = serve(router, "/frontend/app.js", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rsp.Code)
+	})
+
+	t.Run("range request", func(t *testing.T) {
+		rsp := serve(newRouter(), "/frontend/app.js", map[string]string{"Range": "bytes=2-5"})
+		assert.Equal(t, http.StatusPartialContent, rsp.Code)
+		assert.Equal(t, "bytes 2-5/10", rsp.Header().Get("Content-Range"))
+		assert.Equal(t, "2345", rsp.Body.String())
+		assert.Empty(t, rsp.Header().Get("Cache-Control"))
+	})
+
+	t.Run("precompressed", func(t *testing.T) {
+		router := newRouter(FileServerPrecompressed())
+		tests := map[string]struct {
+			acceptEncoding   string
+			expectedEncoding string
+			expectedBody     string
+		}{
+			"brotli":       {acceptEncoding: "gzip, br", expectedEncoding: "br", expectedBody: "brotli"},
+			"gzip":         {acceptEncoding: "gzip", expectedEncoding: "gzip", expectedBody: "gzip"},
+			"weighted":     {acceptEncoding: "br;q=0.5, gzip", expectedEncoding: "gzip", expectedBody: "gzip"},
+			"not accepted": {acceptEncoding: "deflate", expectedBody: "0123456789"},
+			"none":         {expectedBody: "0123456789"},
+		}
+		for name, tt := range tests {
+			tt := tt
+			t.Run(name, func(t *testing.T) {
+				rsp := serve(router, "/frontend/app.js", map[string]string{encoding.AcceptEncodingHeader: tt.acceptEncoding})
+				assert.Equal(t, http.StatusOK, rsp.Code)
+				assert.Equal(t, tt.expectedEncoding, rsp.Header().Get(encoding.ContentEncodingHeader))
+				assert.Equal(t, tt.expectedBody, rsp.Body.String())
+				assert.Contains(t, rsp.Header().Get(encoding.ContentTypeHeader), "javascript")
+				assert.Equal(t, encoding.AcceptEncodingHeader, rsp.Header().Get("Vary"))
+			})
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		rsp := serve(newRouter(), "/frontend/css/", nil)
+		assert.Equal(t, "fallback", rsp.Body.String())
+
+		rsp = serve(newRouter(FileServerDirectoryListing()), "/frontend/css/", nil)
+		assert.Equal(t, http.StatusOK, rsp.Code)
+		assert.Contains(t, rsp.Body.String(), `<a href="style.css">style.css</a>`)
+	})
+}
+
+func writeAsset(t *testing.T, dir, name, content string) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beatlabs/patron/component/http/auth"
+)
+
+// RouteGroup appends routes to a RoutesBuilder, sharing a path prefix, middlewares and optionally an authenticator.
+type RouteGroup struct {
+	rb            *RoutesBuilder
+	prefix        string
+	middlewares   []MiddlewareFunc
+	authenticator auth.Authenticator
+	version       string
+	acceptVersion bool
+}
+
+// Group creates a group of routes whose paths are prefixed with the provided prefix, e.g. "/api/v1",
+// and whose middlewares are preceded by the provided ones.
+// The routes appended to the group are added to the routes builder.
+func (rb *RoutesBuilder) Group(prefix string, mm ...MiddlewareFunc) *RouteGroup {
+	if err := validateGroupPrefix(prefix); err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	return &RouteGroup{rb: rb, prefix: strings.TrimSuffix(prefix, "/"), middlewares: mm}
+}
+
+// Group creates a nested group, whose prefix and middlewares follow the ones of the group.
+// The authenticator of the group is inherited, unless the nested group sets its own.
+func (g *RouteGroup) Group(prefix string, mm ...MiddlewareFunc) *RouteGroup {
+	if err := validateGroupPrefix(prefix); err != nil {
+		g.rb.errors = append(g.rb.errors, err)
+	}
+	middlewares := make([]MiddlewareFunc, 0, len(g.middlewares)+len(mm))
+	middlewares = append(middlewares, g.middlewares...)
+	middlewares = append(middlewares, mm...)
+	return &RouteGroup{
+		rb:            g.rb,
+		prefix:        g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares:   middlewares,
+		authenticator: g.authenticator,
+		version:       g.version,
+		acceptVersion: g.acceptVersion,
+	}
+}
+
+// WithAuth sets the authenticator of the routes appended to the group afterwards,
+// unless a route sets its own.
+func (g *RouteGroup) WithAuth(auth auth.Authenticator) *RouteGroup {
+	if auth == nil {
+		g.rb.errors = append(g.rb.errors, errors.New("authenticator is nil"))
+	}
+	g.authenticator = auth
+	return g
+}
+
+// Append a route to the group, prefixing its path and adding the middlewares and the authenticator of the group.
+func (g *RouteGroup) Append(builder *RouteBuilder) *RouteGroup {
+	builder.path = g.prefix + builder.path
+	if len(g.middlewares) > 0 {
+		middlewares := make([]MiddlewareFunc, 0, len(g.middlewares)+len(builder.middlewares))
+		middlewares = append(middlewares, g.middlewares...)
+		builder.middlewares = append(middlewares, builder.middlewares...)
+	}
+	if builder.authenticator == nil {
+		builder.authenticator = g.authenticator
+	}
+	if g.version != "" {
+		builder.version = g.version
+		builder.acceptVersion = g.acceptVersion
+	}
+	g.rb.Append(builder)
+	return g
+}
+
+func validateGroupPrefix(prefix string) error {
+	if prefix == "" {
+		return errors.New("group prefix is empty")
+	}
+	if !strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("group prefix %s should start with /", prefix)
+	}
+	return nil
+}
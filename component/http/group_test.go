@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutesBuilder_Group(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+
+	rb := NewRoutesBuilder()
+	v1 := rb.Group("/api/v1/", tagMiddleware("v1\n")).WithAuth(&MockAuthenticator{success: true})
+	v1.Append(NewGetRouteBuilder("/users", proc)).
+		Append(NewPostRouteBuilder("/users", proc).WithMiddlewares(tagMiddleware("route\n"))).
+		Append(NewGetRouteBuilder("/public", proc).WithAuth(&MockAuthenticator{success: false}))
+	v1.Group("/admin", tagMiddleware("admin\n")).Append(NewGetRouteBuilder("/", proc))
+	rb.Append(NewGetRouteBuilder("/health", proc))
+
+	routes, err := rb.Build()
+	require.NoError(t, err)
+	require.Len(t, routes, 5)
+
+	tests := []struct {
+		method       string
+		path         string
+		expectedBody string
+		expectedCode int
+	}{
+		{method: http.MethodGet, path: "/api/v1/users", expectedBody: "v1\n", expectedCode: http.StatusOK},
+		{method: http.MethodPost, path: "/api/v1/users", expectedBody: "v1\nroute\n", expectedCode: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/public", expectedBody: "Unauthorized\n", expectedCode: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/api/v1/admin/", expectedBody: "v1\nadmin\n", expectedCode: http.StatusOK},
+		{method: http.MethodGet, path: "/health", expectedBody: "", expectedCode: http.StatusNoContent},
+	}
+	for i, tt := range tests {
+		assert.Equal(t, tt.method, routes[i].method)
+		assert.Equal(t, tt.path, routes[i].path)
+
+		rc := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		MiddlewareChain(routes[i].handler, routes[i].middlewares...).ServeHTTP(rc, req)
+		assert.Equal(t, tt.expectedCode, rc.Code, tt.path)
+		assert.Equal(t, tt.expectedBody, rc.Body.String(), tt.path)
+	}
+}
+
+func TestRoutesBuilder_Group_Errors(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+
+	rb := NewRoutesBuilder()
+	rb.Group("").Append(NewGetRouteBuilder("/users", proc))
+	rb.Group("api").Group("v1").WithAuth(nil)
+
+	routes, err := rb.Build()
+	assert.Nil(t, routes)
+	assert.EqualError(t, err, "group prefix is empty\ngroup prefix api should start with /\ngroup prefix v1 should start with /\nauthenticator is nil\n")
+}
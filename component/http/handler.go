@@ -1,29 +1,58 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
 	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/encoding/xml"
 	"github.com/beatlabs/patron/log"
 	"github.com/julienschmidt/httprouter"
 )
 
-func handler(hnd ProcessorFunc) http.HandlerFunc {
+var errNotAcceptable = errors.New("accept header not acceptable")
+
+type jsonFastPathKey struct{}
+
+// handler creates a handler for the processor. When accepted content types are provided,
+// the encoding is negotiated within them, otherwise JSON and protobuf are supported.
+func handler(hnd ProcessorFunc, accepts ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ct, dec, enc, err := determineEncoding(r.Header)
+		var ct string
+		var dec encoding.DecodeFunc
+		var enc encoding.EncodeFunc
+		var err error
+		if len(accepts) > 0 {
+			ct, dec, enc, err = negotiateEncoding(r, accepts)
+		} else {
+			ct, dec, enc, err = determineEncoding(r.Header)
+		}
 		if err != nil {
-			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			code := http.StatusUnsupportedMediaType
+			if errors.Is(err, errNotAcceptable) {
+				code = http.StatusNotAcceptable
+			}
+			http.Error(w, http.StatusText(code), code)
 			return
 		}
+		if fp := jsonFastPathFromContext(r.Context()); fp != nil {
+			dec, enc = jsonFastPathEncoding(fp, dec, enc)
+		}
 		prepareResponse(w, ct)
 
 		f := extractFields(r)
-		for k, v := range ExtractParams(r) {
+		params := ExtractParams(r)
+		for k, v := range params {
 			f[k] = v
 		}
 
@@ -38,20 +67,69 @@ func handler(hnd ProcessorFunc) http.HandlerFunc {
 		h := extractHeaders(r.Header)
 
 		req := NewRequest(f, r.Body, h, dec)
+		req.typeFactory = typeFactoryFromContext(r.Context())
+		req.params = params
+		req.query = r.URL.Query()
 
 		rsp, err := hnd(ctx, req)
 		if err != nil {
-			handleError(logger, w, enc, err)
-			return
+			var errRsp *Response
+			if errEnc := errorEncoderFromContext(ctx); errEnc != nil {
+				errRsp = errEnc(ctx, err)
+			}
+			if errRsp == nil {
+				handleError(logger, w, enc, err)
+				return
+			}
+			if errRsp.code == 0 {
+				errRsp.code = http.StatusInternalServerError
+			}
+			rsp = errRsp
 		}
 
-		err = handleSuccess(w, r, rsp, enc)
+		switch {
+		case rsp != nil && rsp.sse != nil:
+			err = handleSSE(ctx, w, rsp)
+		case rsp != nil && rsp.stream != nil:
+			handleStream(logger, w, r, rsp, enc)
+		default:
+			err = handleSuccess(w, r, rsp, enc)
+		}
 		if err != nil {
 			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		}
 	}
 }
 
+// newJSONFastPathMiddleware makes the JSON fast path of the component available to the handlers of the processors.
+func newJSONFastPathMiddleware(fp *json.FastPath) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jsonFastPathKey{}, fp)))
+		})
+	}
+}
+
+func jsonFastPathFromContext(ctx context.Context) *json.FastPath {
+	fp, ok := ctx.Value(jsonFastPathKey{}).(*json.FastPath)
+	if !ok {
+		return nil
+	}
+	return fp
+}
+
+// jsonFastPathEncoding replaces the default JSON decoder and encoder determined for the request with the ones of the fast path,
+// keeping the other encodings, as well as the JSON ones registered instead of the default ones.
+func jsonFastPathEncoding(fp *json.FastPath, dec encoding.DecodeFunc, enc encoding.EncodeFunc) (encoding.DecodeFunc, encoding.EncodeFunc) {
+	if dec != nil && reflect.ValueOf(dec).Pointer() == reflect.ValueOf(json.Decode).Pointer() {
+		dec = fp.Decode
+	}
+	if enc != nil && reflect.ValueOf(enc).Pointer() == reflect.ValueOf(json.Encode).Pointer() {
+		enc = fp.Encode
+	}
+	return dec, enc
+}
+
 func determineEncoding(h http.Header) (string, encoding.DecodeFunc, encoding.EncodeFunc, error) {
 	cth, cok := h[encoding.ContentTypeHeader]
 	ach, aok := h[encoding.AcceptHeader]
@@ -66,17 +144,16 @@ func determineEncoding(h http.Header) (string, encoding.DecodeFunc, encoding.Enc
 	var ct string
 
 	if cok {
-		switch cth[0] {
-		case "*/*", json.Type, json.TypeCharset:
+		if isMultipartFormData(cth[0]) {
+			// the parts are read with the multipart helpers of the request, while the response is encoded with JSON
 			enc = json.Encode
-			dec = json.Decode
 			ct = json.TypeCharset
-		case protobuf.Type, protobuf.TypeGoogle:
-			enc = protobuf.Encode
-			dec = protobuf.Decode
-			ct = protobuf.Type
-		default:
-			return "", nil, nil, errors.New("content type Header not supported")
+		} else {
+			var err error
+			ct, dec, enc, err = getSingleHeaderEncoding(cth[0])
+			if err != nil {
+				return "", nil, nil, errors.New("content type Header not supported")
+			}
 		}
 	}
 
@@ -101,25 +178,132 @@ func determineEncoding(h http.Header) (string, encoding.DecodeFunc, encoding.Enc
 	return ct, dec, enc, nil
 }
 
-func getSingleHeaderEncoding(header string) (string, encoding.DecodeFunc, encoding.EncodeFunc, error) {
-	var enc encoding.EncodeFunc
-	var dec encoding.DecodeFunc
+// negotiateEncoding determines the encoding of a route which accepts only the provided media types.
+// The decoder is determined by the content type of the request, which has to be one of the accepted media types,
+// while the encoder is determined by the accept header, restricted to the accepted media types, in the order of their quality values,
+// excluding the ones with a quality value of 0. Without an accept header, the response is encoded like the request or, without a content type either,
+// with the first accepted media type.
+func negotiateEncoding(r *http.Request, accepts []string) (string, encoding.DecodeFunc, encoding.EncodeFunc, error) {
 	var ct string
+	var dec encoding.DecodeFunc
+	var enc encoding.EncodeFunc
+	var err error
 
-	switch header {
-	case "*/*", json.Type, json.TypeCharset:
-		enc = json.Encode
-		dec = json.Decode
-		ct = json.TypeCharset
-	case protobuf.Type, protobuf.TypeGoogle:
-		enc = protobuf.Encode
-		dec = protobuf.Decode
-		ct = protobuf.Type
-	default:
+	if cth := r.Header.Get(encoding.ContentTypeHeader); cth != "" {
+		mt, _, err := mime.ParseMediaType(cth)
+		if err != nil || !containsMediaType(accepts, mt) {
+			return "", nil, nil, fmt.Errorf("content type %s not accepted", cth)
+		}
+		ct, dec, enc, err = getSingleHeaderEncoding(mt)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	} else {
+		if r.ContentLength != 0 {
+			return "", nil, nil, errors.New("content type header is missing")
+		}
+		ct, dec, enc, err = getSingleHeaderEncoding(accepts[0])
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
+
+	ach := r.Header.Get(encoding.AcceptHeader)
+	if ach == "" {
+		return ct, dec, enc, nil
+	}
+
+	ranges, excluded := parseAccept(ach)
+	for _, mt := range ranges {
+		candidates := []string{mt}
+		if mt == "*/*" {
+			candidates = accepts
+		}
+		for _, c := range candidates {
+			if excluded[c] || !containsMediaType(accepts, c) {
+				continue
+			}
+			act, _, aenc, err := getSingleHeaderEncoding(c)
+			if err == nil {
+				return act, dec, aenc, nil
+			}
+		}
+	}
+	return "", nil, nil, errNotAcceptable
+}
+
+// parseAccept returns the media ranges of an accept header, ordered by their quality values, along with the ones
+// with a quality value of 0, which are not acceptable. Media ranges with an invalid quality value are ignored.
+func parseAccept(header string) ([]string, map[string]bool) {
+	type mediaRange struct {
+		mt string
+		q  float64
+	}
+	var ranges []mediaRange
+	excluded := make(map[string]bool)
+	for _, v := range getMultiValueHeaders(header) {
+		mt, params, err := mime.ParseMediaType(v)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qv, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(qv, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q == 0 {
+			excluded[mt] = true
+			continue
+		}
+		ranges = append(ranges, mediaRange{mt: mt, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	mts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		mts = append(mts, r.mt)
+	}
+	return mts, excluded
+}
+
+func containsMediaType(mm []string, mt string) bool {
+	for _, m := range mm {
+		if m == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// getSingleHeaderEncoding returns the encoding registered for the media type, along with the content type of the encoded responses.
+func getSingleHeaderEncoding(header string) (string, encoding.DecodeFunc, encoding.EncodeFunc, error) {
+	if header == "*/*" {
+		header = json.Type
+	}
+
+	enc, dec, ok := encoding.Lookup(header)
+	if !ok {
 		return "", nil, nil, errors.New("accept header not supported")
 	}
 
-	return ct, dec, enc, nil
+	return responseContentType(header), dec, enc, nil
+}
+
+// responseContentType returns the content type of the responses encoded for the media type.
+func responseContentType(header string) string {
+	mt, _, _ := mime.ParseMediaType(header)
+	switch mt {
+	case json.Type:
+		return json.TypeCharset
+	case protobuf.TypeGoogle:
+		return protobuf.Type
+	case xml.Type:
+		return xml.TypeCharset
+	default:
+		return mt
+	}
 }
 
 func getMultiValueHeaders(header string) []string {
@@ -165,21 +349,52 @@ func handleSuccess(w http.ResponseWriter, r *http.Request, rsp *Response, enc en
 		return nil
 	}
 
+	if rsp.location != "" {
+		return handleRedirect(w, rsp)
+	}
+
+	// headers have to be propagated before writing the status code, otherwise they are ignored
+	if rsp.Payload == nil {
+		propagateHeaders(rsp.Header, w.Header())
+		if rsp.code == 0 {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(rsp.code)
+		}
+		return nil
+	}
+
 	p, err := enc(rsp.Payload)
 	if err != nil {
 		return err
 	}
 
-	if r.Method == http.MethodPost {
+	propagateHeaders(rsp.Header, w.Header())
+
+	switch {
+	case rsp.code != 0:
+		w.WriteHeader(rsp.code)
+	case r.Method == http.MethodPost:
 		w.WriteHeader(http.StatusCreated)
 	}
 
-	propagateHeaders(rsp.Header, w.Header())
-
 	_, err = w.Write(p)
 	return err
 }
 
+func handleRedirect(w http.ResponseWriter, rsp *Response) error {
+	if !isRedirect(rsp.code) {
+		return fmt.Errorf("status code %d is not a valid redirect status code", rsp.code)
+	}
+
+	propagateHeaders(rsp.Header, w.Header())
+	// a redirect has no body, so there is no content to declare
+	w.Header().Del(encoding.ContentTypeHeader)
+	w.Header().Set("Location", rsp.location)
+	w.WriteHeader(rsp.code)
+	return nil
+}
+
 func handleError(logger log.Logger, w http.ResponseWriter, enc encoding.EncodeFunc, err error) {
 	// Assert error to type Error in order to leverage the code and Payload values that such errors contain.
 	if err, ok := err.(*Error); ok {
@@ -3,17 +3,26 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/msgpack"
 	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/encoding/xml"
+	"github.com/beatlabs/patron/encoding/yaml"
 	"github.com/beatlabs/patron/log"
 	"github.com/julienschmidt/httprouter"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,7 +74,11 @@ func Test_determineEncoding(t *testing.T) {
 		{"missing content Header, defaults json", args{req: request(t, "", json.TypeCharset)}, json.Decode, json.Encode, json.TypeCharset, false},
 		{"missing headers, defaults json", args{req: request(t, "", "")}, json.Decode, json.Encode, json.TypeCharset, false},
 		{"accept */*, defaults to json", args{req: request(t, json.TypeCharset, "*/*")}, json.Decode, json.Encode, json.TypeCharset, false},
-		{"wrong content", args{req: request(t, "application/xml", json.TypeCharset)}, nil, nil, json.TypeCharset, true},
+		{"wrong content", args{req: request(t, "text/csv", json.TypeCharset)}, nil, nil, json.TypeCharset, true},
+		{"success xml", args{req: request(t, xml.Type, xml.TypeText)}, xml.Decode, xml.Encode, xml.TypeText, false},
+		{"success xml, missing accept", args{req: request(t, xml.TypeCharset, "")}, xml.Decode, xml.Encode, xml.TypeCharset, false},
+		{"success msgpack", args{req: request(t, msgpack.Type, msgpack.TypeLegacy)}, msgpack.Decode, msgpack.Encode, msgpack.TypeLegacy, false},
+		{"success yaml", args{req: request(t, json.Type, "text/csv, "+yaml.Type)}, json.Decode, yaml.Encode, yaml.Type, false},
 		{"multi-value accept", args{req: request(t, json.TypeCharset, "application/json, */*")}, json.Decode, json.Encode, json.TypeCharset, false},
 	}
 	for _, tt := range tests {
@@ -86,6 +99,96 @@ func Test_determineEncoding(t *testing.T) {
 	}
 }
 
+func Test_negotiateEncoding(t *testing.T) {
+	tests := map[string]struct {
+		accepts       []string
+		contentType   string
+		accept        string
+		body          string
+		ct            string
+		expectedErr   string
+		notAcceptable bool
+	}{
+		"accepted content type":            {accepts: []string{json.Type}, contentType: json.TypeCharset, body: "{}", ct: json.TypeCharset},
+		"accepted content type and accept": {accepts: []string{json.Type, protobuf.Type}, contentType: json.Type, accept: protobuf.Type, body: "{}", ct: protobuf.Type},
+		"unsupported content type": {accepts: []string{json.Type}, contentType: protobuf.Type, body: "{}",
+			expectedErr: "content type application/x-protobuf not accepted"},
+		"missing content type with body": {accepts: []string{json.Type}, body: "{}", expectedErr: "content type header is missing"},
+		"missing headers without body":   {accepts: []string{protobuf.Type, json.Type}, ct: protobuf.Type},
+		"accept any":                     {accepts: []string{protobuf.Type}, accept: "*/*", ct: protobuf.Type},
+		"accept with parameters":         {accepts: []string{json.Type}, accept: "application/json;q=0.9", ct: json.TypeCharset},
+		"multi-value accept":             {accepts: []string{json.Type}, accept: "application/xml, application/json", ct: json.TypeCharset},
+		"not acceptable": {accepts: []string{json.Type}, accept: protobuf.Type,
+			expectedErr: "accept header not acceptable", notAcceptable: true},
+		"quality values":      {accepts: []string{json.Type, protobuf.Type}, accept: "application/json;q=0.5, application/x-protobuf", ct: protobuf.Type},
+		"quality value of 0":  {accepts: []string{json.Type, protobuf.Type}, accept: "application/x-protobuf;q=0, */*;q=0.1", ct: json.TypeCharset},
+		"any excluding first": {accepts: []string{json.Type, protobuf.Type}, accept: "*/*, application/json;q=0", ct: protobuf.Type},
+		"only excluded": {accepts: []string{json.Type}, accept: "application/json;q=0",
+			expectedErr: "accept header not acceptable", notAcceptable: true},
+		"invalid quality value": {accepts: []string{json.Type}, accept: "application/json;q=abc",
+			expectedErr: "accept header not acceptable", notAcceptable: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			if tt.contentType != "" {
+				req.Header.Set(encoding.ContentTypeHeader, tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set(encoding.AcceptHeader, tt.accept)
+			}
+
+			ct, dec, enc, err := negotiateEncoding(req, tt.accepts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, tt.notAcceptable, errors.Is(err, errNotAcceptable))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, dec)
+			assert.NotNil(t, enc)
+			assert.Equal(t, tt.ct, ct)
+		})
+	}
+}
+
+func Test_handler_Accepts(t *testing.T) {
+	proc := func(_ context.Context, req *Request) (*Response, error) {
+		var v map[string]string
+		if err := req.Decode(&v); err != nil {
+			return nil, err
+		}
+		return NewResponse(v), nil
+	}
+	hnd := handler(proc, json.Type)
+
+	tests := map[string]struct {
+		contentType  string
+		accept       string
+		expectedCode int
+	}{
+		"accepted":       {contentType: json.Type, expectedCode: http.StatusCreated},
+		"not accepted":   {contentType: protobuf.Type, expectedCode: http.StatusUnsupportedMediaType},
+		"not acceptable": {contentType: json.Type, accept: protobuf.Type, expectedCode: http.StatusNotAcceptable},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"key":"val"}`))
+			req.Header.Set(encoding.ContentTypeHeader, tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set(encoding.AcceptHeader, tt.accept)
+			}
+			rsp := httptest.NewRecorder()
+			hnd(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
 func Test_getMultiValueHeaders(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -173,6 +276,10 @@ func Test_handleSuccess(t *testing.T) {
 		{"GET OK success", args{req: get, rsp: jsonRsp, enc: json.Encode}, http.StatusOK, false},
 		{"POST Created success", args{req: post, rsp: jsonRsp, enc: json.Encode}, http.StatusCreated, false},
 		{"Encode failure", args{req: post, rsp: jsonEncodeFailRsp, enc: json.Encode}, http.StatusCreated, true},
+		{"GET nil payload success", args{req: get, rsp: NewResponse(nil), enc: nil}, http.StatusNoContent, false},
+		{"POST nil payload success", args{req: post, rsp: NewResponse(nil), enc: nil}, http.StatusNoContent, false},
+		{"POST with code success", args{req: post, rsp: NewResponseWithCode(http.StatusAccepted, "ok"), enc: json.Encode}, http.StatusAccepted, false},
+		{"POST with code and nil payload success", args{req: post, rsp: NewResponseWithCode(http.StatusAccepted, nil), enc: nil}, http.StatusAccepted, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -189,6 +296,55 @@ func Test_handleSuccess(t *testing.T) {
 	}
 }
 
+func Test_handleSuccess_Headers(t *testing.T) {
+	post, err := http.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, err)
+	r := NewResponse("created")
+	r.Header["X-Custom"] = "value"
+
+	rsp := httptest.NewRecorder()
+	require.NoError(t, handleSuccess(rsp, post, r, json.Encode))
+
+	assert.Equal(t, http.StatusCreated, rsp.Code)
+	assert.Equal(t, "value", rsp.Header().Get("X-Custom"))
+}
+
+func Test_handleSuccess_Redirect(t *testing.T) {
+	get, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		code        int
+		expectedErr string
+	}{
+		"moved permanently":  {code: http.StatusMovedPermanently},
+		"found":              {code: http.StatusFound},
+		"temporary redirect": {code: http.StatusTemporaryRedirect},
+		"permanent redirect": {code: http.StatusPermanentRedirect},
+		"invalid code":       {code: http.StatusOK, expectedErr: "status code 200 is not a valid redirect status code"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			rsp.Header().Set(encoding.ContentTypeHeader, json.TypeCharset)
+			// the encoder must never be called for redirects
+			enc := func(interface{}) ([]byte, error) { return nil, errors.New("encoder should not be called") }
+
+			err := handleSuccess(rsp, get, NewResponse("payload").WithRedirect(tt.code, "/new"), enc)
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.code, rsp.Code)
+			assert.Equal(t, "/new", rsp.Header().Get("Location"))
+			assert.Empty(t, rsp.Header().Get(encoding.ContentTypeHeader))
+			assert.Empty(t, rsp.Body.String())
+		})
+	}
+}
+
 func Test_handleError(t *testing.T) {
 	type args struct {
 		err error
@@ -287,6 +443,102 @@ func Test_handler(t *testing.T) {
 	}
 }
 
+func Test_handler_ErrorEncoder(t *testing.T) {
+	errConflict := errors.New("order already exists")
+	errEnc := func(_ context.Context, err error) *Response {
+		switch {
+		case errors.Is(err, errConflict):
+			return NewResponseWithCode(http.StatusConflict, map[string]string{"error": err.Error()})
+		case err.Error() == "no code":
+			return NewResponse(map[string]string{"error": err.Error()})
+		default:
+			return nil
+		}
+	}
+
+	tests := map[string]struct {
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		"mapped error":        {err: fmt.Errorf("failed to create: %w", errConflict), expectedCode: http.StatusConflict, expectedBody: `{"error":"failed to create: order already exists"}`},
+		"mapped without code": {err: errors.New("no code"), expectedCode: http.StatusInternalServerError, expectedBody: `{"error":"no code"}`},
+		"patron error":        {err: NewNotFoundError(), expectedCode: http.StatusNotFound, expectedBody: `"Not Found"`},
+		"unmapped error":      {err: errors.New("failure"), expectedCode: http.StatusInternalServerError, expectedBody: "Internal Server Error\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			hnd := handler(func(context.Context, *Request) (*Response, error) {
+				return nil, tt.err
+			})
+			rsp := httptest.NewRecorder()
+			newErrorEncoderMiddleware(errEnc)(hnd).ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+		})
+	}
+}
+
+type countingCodec struct {
+	marshaled   int
+	unmarshaled int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshaled++
+	return json.Standard.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshaled++
+	return json.Standard.Unmarshal(data, v)
+}
+
+func Test_handler_JSONFastPath(t *testing.T) {
+	tests := map[string]struct {
+		contentType         string
+		accept              string
+		expectedMarshaled   int
+		expectedUnmarshaled int
+	}{
+		"json":        {contentType: json.TypeCharset, expectedMarshaled: 1, expectedUnmarshaled: 1},
+		"no headers":  {expectedMarshaled: 1, expectedUnmarshaled: 1},
+		"accept json": {accept: json.Type, expectedMarshaled: 1, expectedUnmarshaled: 1},
+		"xml":         {contentType: xml.Type, accept: xml.Type},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := &countingCodec{}
+			fp, err := json.NewFastPath(c)
+			require.NoError(t, err)
+			hnd := handler(func(_ context.Context, req *Request) (*Response, error) {
+				var v string
+				require.NoError(t, req.Decode(&v))
+				return NewResponse(v), nil
+			})
+
+			body := `"value"`
+			if tt.contentType == xml.Type {
+				body = "<string>value</string>"
+			}
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set(encoding.ContentTypeHeader, tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set(encoding.AcceptHeader, tt.accept)
+			}
+			rsp := httptest.NewRecorder()
+			newJSONFastPathMiddleware(fp)(hnd).ServeHTTP(rsp, req)
+			assert.Equal(t, http.StatusCreated, rsp.Code)
+			assert.Equal(t, tt.expectedMarshaled, c.marshaled)
+			assert.Equal(t, tt.expectedUnmarshaled, c.unmarshaled)
+		})
+	}
+}
+
 func Test_prepareResponse(t *testing.T) {
 	rsp := httptest.NewRecorder()
 	prepareResponse(rsp, json.TypeCharset)
@@ -346,6 +598,43 @@ func Test_fileserverHandler(t *testing.T) {
 	}
 }
 
+func Test_fileserverObservability(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	router := httprouter.New()
+	path := "/observed/*path"
+	route, err := NewFileServer(path, "testdata", "testdata/index.html").WithTrace().Build()
+	require.NoError(t, err)
+	router.Handler(route.method, route.path, MiddlewareChain(route.handler, route.middlewares...))
+
+	tests := map[string]struct {
+		path             string
+		expectedNotFound float64
+	}{
+		"existing asset": {path: "/observed/existing.html", expectedNotFound: 0},
+		"missing asset":  {path: "/observed/missing-file", expectedNotFound: 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mtr.Reset()
+			fileServerAssetNotFoundMetric.Reset()
+			httpStatusTracingHandledMetric.Reset()
+
+			req, err := http.NewRequest(http.MethodGet, tt.path, nil)
+			require.NoError(t, err)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			// the route pattern is used instead of the asset path
+			assert.Equal(t, 1.0, testutil.ToFloat64(httpStatusTracingHandledMetric.WithLabelValues(http.MethodGet, path, "200", "")))
+			assert.Equal(t, tt.expectedNotFound, testutil.ToFloat64(fileServerAssetNotFoundMetric.WithLabelValues(path)))
+			require.Len(t, mtr.FinishedSpans(), 1)
+			assert.Equal(t, opName(http.MethodGet, path), mtr.FinishedSpans()[0].OperationName)
+		})
+	}
+}
+
 func Test_extractParamsRawRoute(t *testing.T) {
 	r, err := http.NewRequest(http.MethodGet, "/users/42/status/online", nil)
 	assert.NoError(t, err)
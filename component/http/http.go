@@ -3,8 +3,10 @@ package http
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/beatlabs/patron/encoding"
 )
@@ -14,10 +16,13 @@ type Header map[string]string
 
 // Request definition of the sync request model.
 type Request struct {
-	Fields  map[string]string
-	Raw     io.Reader
-	Headers Header
-	decode  encoding.DecodeFunc
+	Fields      map[string]string
+	Raw         io.Reader
+	Headers     Header
+	decode      encoding.DecodeFunc
+	typeFactory TypeFactory
+	params      map[string]string
+	query       url.Values
 }
 
 // NewRequest creates a new request.
@@ -26,21 +31,64 @@ func NewRequest(f map[string]string, r io.Reader, h map[string]string, d encodin
 }
 
 // Decode the raw data by using the provided decoder.
+// For routes with polymorphic decoding, decoding into a pointer to an empty interface
+// stores a value of the type resolved for the request, which can be used in a type switch.
+// Multipart requests have no decoder, since their parts are read with MultipartReader or FormFile.
 func (r *Request) Decode(v interface{}) error {
+	if r.decode == nil {
+		return errors.New("request has no decoder")
+	}
+	if r.typeFactory != nil {
+		if target, ok := v.(*interface{}); ok {
+			val := r.typeFactory()
+			if err := r.decode(r.Raw, val); err != nil {
+				return err
+			}
+			*target = val
+			return nil
+		}
+	}
 	return r.decode(r.Raw, v)
 }
 
 // Response definition of the sync Response model.
 type Response struct {
-	Payload interface{}
-	Header  Header
+	Payload  interface{}
+	Header   Header
+	code     int
+	location string
+	sse      *sseResponse
+	stream   *streamResponse
 }
 
 // NewResponse creates a new Response.
+// The status code of the response is determined by the request method and the payload;
+// 201 for POST requests, 204 when the payload is nil and 200 otherwise.
 func NewResponse(p interface{}) *Response {
 	return &Response{Payload: p, Header: make(map[string]string)}
 }
 
+// NewResponseWithCode creates a new Response with an explicit status code.
+func NewResponseWithCode(code int, p interface{}) *Response {
+	return &Response{Payload: p, Header: make(map[string]string), code: code}
+}
+
+// WithRedirect turns the Response into a redirect to the provided location.
+// Supported status codes are 301, 302, 303, 307 and 308. The payload of a redirect is never encoded.
+func (r *Response) WithRedirect(code int, location string) *Response {
+	r.code = code
+	r.location = location
+	return r
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
 // ProcessorFunc definition of a function type for processing sync requests.
 type ProcessorFunc func(context.Context, *Request) (*Response, error)
 
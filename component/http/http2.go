@@ -0,0 +1,34 @@
+package http
+
+import (
+	"fmt"
+
+	"golang.org/x/net/http2"
+)
+
+const (
+	minHTTP2FrameSize = 1 << 14
+	maxHTTP2FrameSize = 1<<24 - 1
+)
+
+// HTTP2Options tunes the HTTP/2 connections of the HTTP component. Zero values keep the defaults of the HTTP/2 server.
+type HTTP2Options struct {
+	// MaxConcurrentStreams is the number of concurrent streams each client may have open.
+	MaxConcurrentStreams uint32
+	// MaxReadFrameSize is the largest frame the server is willing to read, between 16KB and 16MB.
+	MaxReadFrameSize uint32
+}
+
+func (o HTTP2Options) validate() error {
+	if o.MaxReadFrameSize != 0 && (o.MaxReadFrameSize < minHTTP2FrameSize || o.MaxReadFrameSize > maxHTTP2FrameSize) {
+		return fmt.Errorf("HTTP/2 max read frame size should be between %d and %d", minHTTP2FrameSize, maxHTTP2FrameSize)
+	}
+	return nil
+}
+
+func (o HTTP2Options) server() *http2.Server {
+	return &http2.Server{
+		MaxConcurrentStreams: o.MaxConcurrentStreams,
+		MaxReadFrameSize:     o.MaxReadFrameSize,
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+func TestBuilder_WithHTTP2(t *testing.T) {
+	tests := map[string]struct {
+		builder     *Builder
+		expectedErr string
+	}{
+		"success": {
+			builder: NewBuilder().WithH2C().WithHTTP2Options(HTTP2Options{MaxConcurrentStreams: 100, MaxReadFrameSize: 1 << 20}),
+		},
+		"too small max read frame size": {
+			builder:     NewBuilder().WithHTTP2Options(HTTP2Options{MaxReadFrameSize: 1024}),
+			expectedErr: "HTTP/2 max read frame size should be between 16384 and 16777215\n",
+		},
+		"too big max read frame size": {
+			builder:     NewBuilder().WithHTTP2Options(HTTP2Options{MaxReadFrameSize: 1 << 24}),
+			expectedErr: "HTTP/2 max read frame size should be between 16384 and 16777215\n",
+		},
+		"h2c with TLS": {
+			builder:     NewBuilder().WithH2C().WithSSL("testdata/server.pem", "testdata/server.key"),
+			expectedErr: "h2c cannot be enabled with TLS",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cmp, err := tt.builder.Create()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, cmp)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, cmp.h2c)
+				assert.Equal(t, &HTTP2Options{MaxConcurrentStreams: 100, MaxReadFrameSize: 1 << 20}, cmp.http2)
+			}
+		})
+	}
+}
+
+func TestComponent_H2C(t *testing.T) {
+	var proto string
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/proto", func(_ http.ResponseWriter, r *http.Request) {
+		proto = r.Proto
+	}).MethodGet())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithH2C().WithHTTP2Options(HTTP2Options{MaxConcurrentStreams: 10}).Create()
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(cmp.createHTTPServer().Handler)
+	defer ts.Close()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	rsp, err := client.Get(ts.URL + "/proto")
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, "HTTP/2.0", proto)
+
+	rsp, err = http.Get(ts.URL + "/proto")
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, "HTTP/1.1", proto)
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"net/http"
 	"testing"
 
 	"github.com/beatlabs/patron/encoding/json"
@@ -30,3 +31,16 @@ func TestNewResponse(t *testing.T) {
 	assert.NotNil(t, rsp)
 	assert.IsType(t, "test", rsp.Payload)
 }
+
+func TestNewResponseWithCode(t *testing.T) {
+	rsp := NewResponseWithCode(http.StatusAccepted, "test")
+	assert.Equal(t, http.StatusAccepted, rsp.code)
+	assert.Equal(t, "test", rsp.Payload)
+	assert.NotNil(t, rsp.Header)
+}
+
+func TestResponse_WithRedirect(t *testing.T) {
+	rsp := NewResponse(nil).WithRedirect(http.StatusFound, "/new")
+	assert.Equal(t, http.StatusFound, rsp.code)
+	assert.Equal(t, "/new", rsp.location)
+}
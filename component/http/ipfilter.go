@@ -0,0 +1,146 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// IPFilterOptions configures which clients are allowed by the IP filter middleware.
+// The entries are either IP addresses, e.g. "10.0.0.1", or CIDR ranges, e.g. "10.0.0.0/8".
+type IPFilterOptions struct {
+	// Allow restricts the requests to the clients in the list, when it is not empty.
+	Allow []string
+	// Deny rejects the requests of the clients in the list, taking precedence over Allow.
+	Deny []string
+	// TrustedProxies are the proxies whose X-Forwarded-For header is honoured for determining the IP of the client.
+	// The header is ignored for the requests of other peers, since it can be forged by the caller.
+	TrustedProxies []string
+}
+
+type ipFilter struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	proxies []*net.IPNet
+}
+
+// NewIPFilterMiddleware creates a MiddlewareFunc which rejects the requests of the clients which are denied,
+// or not allowed, with a 403 Forbidden. The middleware can be used globally, with the HTTP component's WithMiddlewares,
+// or per route, with the route builder's WithIPFilter.
+func NewIPFilterMiddleware(opts IPFilterOptions) (MiddlewareFunc, error) {
+	f, err := newIPFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return f.middleware, nil
+}
+
+func newIPFilter(opts IPFilterOptions) (*ipFilter, error) {
+	if len(opts.Allow) == 0 && len(opts.Deny) == 0 {
+		return nil, errors.New("allowed and denied IPs are empty")
+	}
+	allow, err := parseIPNets(opts.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseIPNets(opts.Deny)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parseIPNets(opts.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &ipFilter{allow: allow, deny: deny, proxies: proxies}, nil
+}
+
+func parseIPNets(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "/") {
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP %q", entry)
+		}
+		bits := 8 * net.IPv4len
+		if ip.To4() == nil {
+			bits = 8 * net.IPv6len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP of the peer, or, when the peer is a trusted proxy, the rightmost IP of the X-Forwarded-For header
+// which is not a trusted proxy, since the entries on its left can be forged by the caller.
+func (f *ipFilter) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !containsIP(f.proxies, ip) {
+		return ip
+	}
+
+	var forwarded []string
+	for _, v := range r.Header.Values(forwardedForHeader) {
+		forwarded = append(forwarded, strings.Split(v, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		fip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if fip == nil {
+			// the chain cannot be followed further than a malformed entry
+			return ip
+		}
+		ip = fip
+		if !containsIP(f.proxies, ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+func (f *ipFilter) allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+func (f *ipFilter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := f.clientIP(r)
+		if !f.allowed(ip) {
+			log.FromContext(r.Context()).Debugf("request of client %s to %s is forbidden by the IP filter", ip, r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIPFilterMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		opts        IPFilterOptions
+		expectedErr string
+	}{
+		"success":               {opts: IPFilterOptions{Allow: []string{"10.0.0.0/8", "::1"}, Deny: []string{"10.0.0.1"}, TrustedProxies: []string{"192.168.0.1"}}},
+		"empty":                 {opts: IPFilterOptions{TrustedProxies: []string{"192.168.0.1"}}, expectedErr: "allowed and denied IPs are empty"},
+		"invalid allowed CIDR":  {opts: IPFilterOptions{Allow: []string{"10.0.0.0/33"}}, expectedErr: `invalid CIDR "10.0.0.0/33": invalid CIDR address: 10.0.0.0/33`},
+		"invalid denied IP":     {opts: IPFilterOptions{Deny: []string{"10.0.0"}}, expectedErr: `invalid IP "10.0.0"`},
+		"invalid trusted proxy": {opts: IPFilterOptions{Allow: []string{"10.0.0.1"}, TrustedProxies: []string{"proxy"}}, expectedErr: `invalid IP "proxy"`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewIPFilterMiddleware(tt.opts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, mw)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, mw)
+			}
+		})
+	}
+}
+
+func TestIPFilter_middleware(t *testing.T) {
+	opts := IPFilterOptions{
+		Allow:          []string{"10.0.0.0/8", "2001:db8::/32"},
+		Deny:           []string{"10.0.0.13"},
+		TrustedProxies: []string{"192.168.0.0/24"},
+	}
+	mw, err := NewIPFilterMiddleware(opts)
+	require.NoError(t, err)
+	hnd := mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := map[string]struct {
+		remoteAddr   string
+		forwarded    []string
+		expectedCode int
+	}{
+		"allowed":                                {remoteAddr: "10.1.2.3:1234", expectedCode: http.StatusOK},
+		"allowed IPv6":                           {remoteAddr: "[2001:db8::1]:1234", expectedCode: http.StatusOK},
+		"not allowed":                            {remoteAddr: "172.16.0.1:1234", expectedCode: http.StatusForbidden},
+		"denied":                                 {remoteAddr: "10.0.0.13:1234", expectedCode: http.StatusForbidden},
+		"forwarded by untrusted peer":            {remoteAddr: "172.16.0.1:1234", forwarded: []string{"10.1.2.3"}, expectedCode: http.StatusForbidden},
+		"forwarded by trusted proxy":             {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.1.2.3"}, expectedCode: http.StatusOK},
+		"forwarded through trusted proxies":      {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.1.2.3, 192.168.0.2"}, expectedCode: http.StatusOK},
+		"forwarded in multiple headers":          {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.1.2.3", "192.168.0.2"}, expectedCode: http.StatusOK},
+		"forged entry left of the client":        {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.1.2.3, 172.16.0.1"}, expectedCode: http.StatusForbidden},
+		"forwarded denied client":                {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.0.0.13"}, expectedCode: http.StatusForbidden},
+		"trusted proxy without forwarded header": {remoteAddr: "192.168.0.1:1234", expectedCode: http.StatusForbidden},
+		"malformed forwarded entry":              {remoteAddr: "192.168.0.1:1234", forwarded: []string{"10.1.2.3, unknown"}, expectedCode: http.StatusForbidden},
+		"malformed remote address":               {remoteAddr: "unknown", expectedCode: http.StatusForbidden},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add(forwardedForHeader, v)
+			}
+			rsp := httptest.NewRecorder()
+			hnd.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
+
+func TestRouteBuilder_WithIPFilter(t *testing.T) {
+	_, err := NewRawRouteBuilder("/", func(http.ResponseWriter, *http.Request) {}).MethodGet().WithIPFilter(IPFilterOptions{}).Build()
+	assert.EqualError(t, err, "allowed and denied IPs are empty\n")
+
+	route, err := NewRawRouteBuilder("/", func(http.ResponseWriter, *http.Request) {}).MethodGet().
+		WithIPFilter(IPFilterOptions{Deny: []string{"192.0.2.1"}}).Build()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	// the remote address of the test requests is 192.0.2.1
+	MiddlewareChain(route.handler, route.middlewares...).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rsp.Code)
+}
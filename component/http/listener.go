@@ -0,0 +1,64 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+)
+
+const (
+	networkTCP  = "tcp"
+	networkTCP4 = "tcp4"
+	networkTCP6 = "tcp6"
+	networkUnix = "unix"
+)
+
+// listener of the HTTP component, additional to the one on the port of the component,
+// which serves its own routes with its own middlewares.
+type listener struct {
+	network     string
+	address     string
+	routes      []Route
+	middlewares []MiddlewareFunc
+}
+
+type listenerBuilder struct {
+	network       string
+	address       string
+	routesBuilder *RoutesBuilder
+	middlewares   []MiddlewareFunc
+}
+
+// server of a listener of the HTTP component.
+type server struct {
+	*http.Server
+	network string
+	address string
+	conns   *connTracker
+}
+
+func newServer(srv *http.Server, network, address string) *server {
+	return &server{Server: srv, network: network, address: address, conns: trackConnections(srv)}
+}
+
+func validateNetwork(network string) error {
+	switch network {
+	case networkTCP, networkTCP4, networkTCP6, networkUnix:
+		return nil
+	default:
+		return fmt.Errorf("network %s is not supported", network)
+	}
+}
+
+func listen(network, address string) (net.Listener, error) {
+	if network == networkUnix {
+		// a socket left over by a process which did not shut down gracefully prevents listening on its path
+		if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(address); err != nil {
+				return nil, fmt.Errorf("failed to remove stale unix socket %s: %w", address, err)
+			}
+		}
+	}
+	return net.Listen(network, address)
+}
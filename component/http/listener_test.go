@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_WithListener(t *testing.T) {
+	tests := map[string]struct {
+		network     string
+		address     string
+		rb          *RoutesBuilder
+		expectedErr string
+	}{
+		"success":             {network: "tcp", address: ":50031", rb: NewRoutesBuilder()},
+		"unsupported network": {network: "udp", address: ":50031", rb: NewRoutesBuilder(), expectedErr: "network udp is not supported\n"},
+		"empty address":       {network: "unix", rb: NewRoutesBuilder(), expectedErr: "listener address is empty\n"},
+		"nil routes builder":  {network: "tcp", address: ":50031", expectedErr: "route builder is nil\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := NewBuilder().WithListener(tt.network, tt.address, tt.rb).Create()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuilder_WithUnixSocket(t *testing.T) {
+	_, err := NewBuilder().WithUnixSocket("").Create()
+	assert.EqualError(t, err, "unix socket path is empty\n")
+}
+
+func TestComponent_Run_Listeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listeners")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	socket := filepath.Join(dir, "http.sock")
+
+	hello := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}
+	admin := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Listener", "admin")
+			next.ServeHTTP(w, r)
+		})
+	}
+	public := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Listener", "public")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	cmp, err := NewBuilder().
+		WithRoutesBuilder(NewRoutesBuilder().Append(NewRawRouteBuilder("/public", hello).MethodGet())).
+		WithMiddlewares(public).
+		WithUnixSocket(socket).
+		WithListener("tcp", "localhost:50032", NewRoutesBuilder().Append(NewRawRouteBuilder("/admin", hello).MethodGet()), admin).
+		Create()
+	require.NoError(t, err)
+
+	ctx, cnl := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	tests := map[string]struct {
+		client           *http.Client
+		url              string
+		expectedCode     int
+		expectedListener string
+	}{
+		"unix socket":                 {client: unixClient, url: "http://unix/public", expectedCode: http.StatusOK, expectedListener: "public"},
+		"unix socket, default routes": {client: unixClient, url: "http://unix/alive", expectedCode: http.StatusOK, expectedListener: "public"},
+		"listener":                    {client: http.DefaultClient, url: "http://localhost:50032/admin", expectedCode: http.StatusOK, expectedListener: "admin"},
+		"listener, other routes":      {client: http.DefaultClient, url: "http://localhost:50032/public", expectedCode: http.StatusNotFound, expectedListener: "admin"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rsp, err := tt.client.Get(tt.url)
+			require.NoError(t, err)
+			defer func() {
+				_ = rsp.Body.Close()
+			}()
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedListener, rsp.Header.Get("X-Listener"))
+		})
+	}
+
+	cnl()
+	assert.NoError(t, <-chDone)
+	_, err = os.Stat(socket)
+	assert.True(t, os.IsNotExist(err), "the socket is removed on shutdown")
+}
+
+func TestComponent_Run_ListenerFails(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:50033")
+	require.NoError(t, err)
+	defer func() {
+		_ = ln.Close()
+	}()
+
+	cmp, err := NewBuilder().WithPort(50034).WithListener("tcp", "localhost:50033", NewRoutesBuilder()).Create()
+	require.NoError(t, err)
+	assert.Error(t, cmp.Run(context.Background()))
+}
+
+func TestBuilder_WithAdminPort(t *testing.T) {
+	hello := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}
+	adminHeader := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Admin", "true")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	_, err := NewBuilder().WithAdminPort(0).Create()
+	assert.EqualError(t, err, "invalid admin port provided\n")
+	_, err = NewBuilder().WithPort(50035).WithAdminPort(50035).Create()
+	assert.EqualError(t, err, "admin port should be different from the HTTP port")
+
+	cmp, err := NewBuilder().
+		WithRoutesBuilder(NewRoutesBuilder().Append(NewRawRouteBuilder("/hello", hello).MethodGet())).
+		WithPort(50035).
+		WithAdminPort(50036, adminHeader).
+		Create()
+	require.NoError(t, err)
+
+	servers := cmp.createServers()
+	require.Len(t, servers, 2)
+	assert.Equal(t, ":50036", servers[1].address)
+
+	tests := map[string]struct {
+		path              string
+		expectedCode      int
+		expectedAdminCode int
+	}{
+		"route":   {path: "/hello", expectedCode: http.StatusOK, expectedAdminCode: http.StatusNotFound},
+		"metrics": {path: "/metrics", expectedCode: http.StatusNotFound, expectedAdminCode: http.StatusOK},
+		"alive":   {path: "/alive", expectedCode: http.StatusNotFound, expectedAdminCode: http.StatusOK},
+		"ready":   {path: "/ready", expectedCode: http.StatusNotFound, expectedAdminCode: http.StatusOK},
+		"pprof":   {path: "/debug/pprof/", expectedCode: http.StatusNotFound, expectedAdminCode: http.StatusOK},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			servers[0].Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Empty(t, rsp.Header().Get("X-Admin"))
+
+			rsp = httptest.NewRecorder()
+			servers[1].Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expectedAdminCode, rsp.Code)
+			assert.Equal(t, "true", rsp.Header().Get("X-Admin"), "the admin middlewares are applied")
+		})
+	}
+}
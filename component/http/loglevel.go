@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+)
+
+const logLevelPath = "/debug/loglevel"
+
+// LogLevel of the logger, which is returned and accepted by the log level endpoint.
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// logLevelRoutes returns the routes for getting and changing the log level at runtime, e.g. for getting debug logs
+// of a running service without restarting it, when they are enabled in the profiling options.
+func logLevelRoutes(opts ProfilingOptions) []*RouteBuilder {
+	if !opts.LogLevel {
+		return nil
+	}
+
+	get := func(ctx context.Context, _ *Request) (*Response, error) {
+		return NewResponse(LogLevel{Level: string(log.FromContext(ctx).Level())}), nil
+	}
+
+	set := func(ctx context.Context, req *Request) (*Response, error) {
+		var ll LogLevel
+		if err := req.Decode(&ll); err != nil {
+			return nil, NewValidationErrorWithPayload(err.Error())
+		}
+		lvl, err := log.ParseLevel(ll.Level)
+		if err != nil {
+			return nil, NewValidationErrorWithPayload(err.Error())
+		}
+		if err := log.SetLevel(lvl); err != nil {
+			return nil, NewErrorWithCodeAndPayload(http.StatusNotImplemented, err.Error())
+		}
+		log.FromContext(ctx).Infof("log level changed to %q", lvl)
+		return NewResponse(ll), nil
+	}
+
+	rr := []*RouteBuilder{
+		NewGetRouteBuilder(logLevelPath, get),
+		NewPutRouteBuilder(logLevelPath, set),
+	}
+	if opts.Authenticator != nil {
+		for _, rb := range rr {
+			rb.WithAuth(opts.Authenticator)
+		}
+	}
+	return rr
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponent_LogLevel(t *testing.T) {
+	cmp, err := NewBuilder().Create()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	cmp.createServers()[0].Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, logLevelPath, nil))
+	assert.Equal(t, http.StatusNotFound, rsp.Code, "the log level endpoints are opt-in")
+
+	cmp, err = NewBuilder().WithProfiling(ProfilingOptions{LogLevel: true}).Create()
+	require.NoError(t, err)
+	handler := cmp.createServers()[0].Handler
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, logLevelPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		rsp := httptest.NewRecorder()
+		handler.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	rsp = do(http.MethodPut, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusNotImplemented, rsp.Code, "the default logger does not support changing the level")
+
+	require.NoError(t, log.Setup(std.New(ioutil.Discard, log.InfoLevel, nil)))
+
+	// the cases run in order, since they change the global logger
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		expectedCode  int
+		expectedLevel log.Level
+	}{
+		{name: "get", method: http.MethodGet, expectedCode: http.StatusOK, expectedLevel: log.InfoLevel},
+		{name: "set", method: http.MethodPut, body: `{"level":"debug"}`, expectedCode: http.StatusOK, expectedLevel: log.DebugLevel},
+		{name: "invalid level", method: http.MethodPut, body: `{"level":"verbose"}`, expectedCode: http.StatusBadRequest, expectedLevel: log.DebugLevel},
+		{name: "invalid body", method: http.MethodPut, body: `{`, expectedCode: http.StatusBadRequest, expectedLevel: log.DebugLevel},
+		{name: "get after set", method: http.MethodGet, expectedCode: http.StatusOK, expectedLevel: log.DebugLevel},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rsp := do(tt.method, tt.body)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.JSONEq(t, `{"level":"`+string(tt.expectedLevel)+`"}`, rsp.Body.String())
+			}
+			assert.Equal(t, tt.expectedLevel, log.FromContext(context.Background()).Level())
+		})
+	}
+}
@@ -1,9 +1,207 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	fileServerMetricsInit         sync.Once
+	fileServerAssetNotFoundMetric *prometheus.CounterVec
 )
 
-func metricRoute() *RouteBuilder {
-	return NewRawRouteBuilder("/metrics", promhttp.Handler().ServeHTTP).MethodGet()
+type latencyHistogramKey struct{}
+
+// latencyHistograms collects the latency histograms of the routes, which share their name and labels,
+// but have different buckets for the routes and components with their own ones.
+type latencyHistograms struct {
+	sync.Mutex
+	opts   prometheus.HistogramOpts
+	labels []string
+	vecs   map[string]*prometheus.HistogramVec
+	// series holds the histogram of each series, since a series can be collected only once,
+	// e.g. a path served by components with different buckets.
+	series map[string]*prometheus.HistogramVec
+}
+
+func newLatencyHistograms(opts prometheus.HistogramOpts, labels []string) *latencyHistograms {
+	return &latencyHistograms{
+		opts:   opts,
+		labels: labels,
+		vecs:   make(map[string]*prometheus.HistogramVec),
+		series: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// observe the latency in the histogram, unless the series has been observed in another histogram first.
+func (lh *latencyHistograms) observe(v *prometheus.HistogramVec, d time.Duration, lvs ...string) {
+	key := strings.Join(lvs, " ")
+	lh.Lock()
+	owner, ok := lh.series[key]
+	if !ok {
+		owner = v
+		lh.series[key] = v
+	}
+	lh.Unlock()
+	owner.WithLabelValues(lvs...).Observe(d.Seconds())
+}
+
+// vec returns the histogram with the buckets, or with the default ones if the buckets are nil.
+func (lh *latencyHistograms) vec(buckets []float64) *prometheus.HistogramVec {
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	key := fmt.Sprint(buckets)
+
+	lh.Lock()
+	defer lh.Unlock()
+	v, ok := lh.vecs[key]
+	if !ok {
+		opts := lh.opts
+		opts.Buckets = buckets
+		v = prometheus.NewHistogramVec(opts, lh.labels)
+		lh.vecs[key] = v
+	}
+	return v
+}
+
+// Describe implements prometheus.Collector. All the histograms have the same description.
+func (lh *latencyHistograms) Describe(ch chan<- *prometheus.Desc) {
+	lh.vec(nil).Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (lh *latencyHistograms) Collect(ch chan<- prometheus.Metric) {
+	lh.Lock()
+	defer lh.Unlock()
+	for _, v := range lh.vecs {
+		v.Collect(ch)
+	}
+}
+
+// Reset deletes the observations of all the histograms.
+func (lh *latencyHistograms) Reset() {
+	lh.Lock()
+	defer lh.Unlock()
+	for _, v := range lh.vecs {
+		v.Reset()
+	}
+	lh.series = make(map[string]*prometheus.HistogramVec)
+}
+
+// newLatencyHistogramMiddleware provides the latency histogram with the buckets of the component to the request observers of the routes
+// without their own buckets.
+func newLatencyHistogramMiddleware(buckets []float64) MiddlewareFunc {
+	httpStatusTracingInit.Do(initHTTPServerMetrics)
+	latency := httpStatusTracingLatencyMetric.vec(buckets)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), latencyHistogramKey{}, latency)))
+		})
+	}
+}
+
+func latencyHistogramFromContext(ctx context.Context) *prometheus.HistogramVec {
+	if v, ok := ctx.Value(latencyHistogramKey{}).(*prometheus.HistogramVec); ok {
+		return v
+	}
+	return httpStatusTracingLatencyMetric.vec(nil)
+}
+
+func validateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return errors.New("histogram buckets are empty")
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return errors.New("histogram buckets must be in increasing order")
+		}
+	}
+	return nil
+}
+
+// componentMetrics returns the metrics of the component, which are created if they are not in use yet.
+func componentMetrics() []prometheus.Collector {
+	httpStatusTracingInit.Do(initHTTPServerMetrics)
+	fileServerMetricsInit.Do(initFileServerMetrics)
+	proxyMetricsInit.Do(initProxyMetrics)
+	rateLimitMetricsInit.Do(initRateLimitMetrics)
+	panicMetricsInit.Do(initPanicMetrics)
+	timeoutMetricsInit.Do(initTimeoutMetrics)
+	coalesceMetricsInit.Do(initCoalesceMetrics)
+	return []prometheus.Collector{
+		httpStatusTracingHandledMetric, httpStatusTracingLatencyMetric, fileServerAssetNotFoundMetric,
+		proxyUpstreamMetric, rateLimitLimitedMetric, panicMetric, timeoutMetric, coalesceMetric,
+	}
+}
+
+// registerMetrics registers the metrics of the component with the registerer, in addition to the default registry.
+// The metrics are shared by all the components, so they may be registered with the registerer already.
+func registerMetrics(reg prometheus.Registerer) error {
+	for _, c := range componentMetrics() {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return fmt.Errorf("failed to register metrics: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// metricRoute serves the metrics of the default registry, along with the ones of the registerer, if it is a gatherer as well.
+func metricRoute(reg prometheus.Registerer) *RouteBuilder {
+	handler := promhttp.Handler()
+	if g, ok := reg.(prometheus.Gatherer); ok && g != prometheus.DefaultGatherer {
+		handler = promhttp.HandlerFor(uniqueGatherers{prometheus.DefaultGatherer, g}, promhttp.HandlerOpts{})
+	}
+	return NewRawRouteBuilder("/metrics", handler.ServeHTTP).MethodGet()
+}
+
+// uniqueGatherers gathers the metric families of the gatherers, skipping the ones gathered already,
+// e.g. the metrics of the component, which are registered with the default registry as well as with the registerer.
+type uniqueGatherers []prometheus.Gatherer
+
+// Gather implements prometheus.Gatherer.
+func (gg uniqueGatherers) Gather() ([]*dto.MetricFamily, error) {
+	var mfs []*dto.MetricFamily
+	seen := make(map[string]struct{})
+	for _, g := range gg {
+		gmfs, err := g.Gather()
+		if err != nil {
+			return nil, err
+		}
+		for _, mf := range gmfs {
+			if _, ok := seen[mf.GetName()]; ok {
+				continue
+			}
+			seen[mf.GetName()] = struct{}{}
+			mfs = append(mfs, mf)
+		}
+	}
+	sort.Slice(mfs, func(i, j int) bool { return mfs[i].GetName() < mfs[j].GetName() })
+	return mfs, nil
+}
+
+func initFileServerMetrics() {
+	fileServerAssetNotFoundMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "file_server_asset_not_found_total",
+			Help:      "Total number of file server requests for assets that do not exist, served with the fallback file.",
+		},
+		[]string{"path"},
+	)
+	prometheus.MustRegister(fileServerAssetNotFoundMetric)
 }
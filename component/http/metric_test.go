@@ -2,15 +2,114 @@ package http
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_metricRoute(t *testing.T) {
-	route, err := metricRoute().Build()
+	route, err := metricRoute(nil).Build()
 	assert.NoError(t, err)
 	assert.Equal(t, http.MethodGet, route.method)
 	assert.Equal(t, "/metrics", route.path)
 	assert.NotNil(t, route.handler)
 }
+
+func Test_validateBuckets(t *testing.T) {
+	tests := map[string]struct {
+		buckets     []float64
+		expectedErr string
+	}{
+		"success":          {buckets: []float64{0.1, 0.5, 1}},
+		"empty":            {expectedErr: "histogram buckets are empty"},
+		"not increasing":   {buckets: []float64{0.1, 1, 0.5}, expectedErr: "histogram buckets must be in increasing order"},
+		"duplicate bucket": {buckets: []float64{0.1, 0.1}, expectedErr: "histogram buckets must be in increasing order"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := validateBuckets(tt.buckets)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuilder_WithMetricsRegisterer(t *testing.T) {
+	_, err := NewBuilder().WithMetricsRegisterer(nil).WithHistogramBuckets().Create()
+	assert.EqualError(t, err, "metrics registerer is nil\nhistogram buckets are empty\n")
+}
+
+func TestComponent_HistogramBuckets(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	hello := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}
+	cmp, err := NewBuilder().
+		WithRoutesBuilder(NewRoutesBuilder().
+			Append(NewRawRouteBuilder("/buckets/component", hello).MethodGet()).
+			Append(NewRawRouteBuilder("/buckets/route", hello).MethodGet().WithHistogramBuckets(5, 10, 20))).
+		WithHistogramBuckets(0.01, 0.1).
+		WithMetricsRegisterer(reg).
+		Create()
+	require.NoError(t, err)
+	srv := cmp.createServers()[0]
+
+	for _, path := range []string{"/buckets/component", "/buckets/route"} {
+		rsp := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rsp.Code)
+	}
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	buckets := make(map[string][]float64)
+	for _, mf := range mfs {
+		if mf.GetName() != "component_http_handled_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() != "path" {
+					continue
+				}
+				for _, b := range m.GetHistogram().GetBucket() {
+					buckets[lp.GetValue()] = append(buckets[lp.GetValue()], b.GetUpperBound())
+				}
+			}
+		}
+	}
+	assert.Equal(t, []float64{0.01, 0.1}, buckets["/buckets/component"])
+	assert.Equal(t, []float64{5, 10, 20}, buckets["/buckets/route"])
+
+	rsp := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Contains(t, rsp.Body.String(), `component_http_handled_seconds_bucket{method="GET",path="/buckets/route",status_code="200",version="",le="5"} 1`)
+	assert.Contains(t, rsp.Body.String(), "go_goroutines", "the metrics of the default registry are served as well")
+	assert.Equal(t, 1, strings.Count(rsp.Body.String(), "# TYPE component_http_handled_seconds histogram"),
+		"the metrics registered with both registries are served once")
+
+	mfs, err = prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	assert.True(t, hasMetricFamily(mfs, "component_http_handled_seconds"), "the metrics are kept in the default registry")
+	require.NoError(t, registerMetrics(reg), "registering the metrics again succeeds")
+}
+
+func hasMetricFamily(mfs []*dto.MetricFamily, name string) bool {
+	for _, mf := range mfs {
+		if mf.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
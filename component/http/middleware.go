@@ -4,13 +4,12 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"net/url"
-	"runtime/debug"
 	"sort"
 	"strconv"
 	"strings"
@@ -41,6 +40,9 @@ const (
 	deflateHeader  = "deflate"
 	identityHeader = "identity"
 	anythingHeader = "*"
+
+	contentTypeOptionsHeader = "X-Content-Type-Options"
+	noSniff                  = "nosniff"
 )
 
 type responseWriter struct {
@@ -54,7 +56,7 @@ type responseWriter struct {
 var (
 	httpStatusTracingInit          sync.Once
 	httpStatusTracingHandledMetric *prometheus.CounterVec
-	httpStatusTracingLatencyMetric *prometheus.HistogramVec
+	httpStatusTracingLatencyMetric *latencyHistograms
 )
 
 func newResponseWriter(w http.ResponseWriter, capturePayload bool) *responseWriter {
@@ -97,39 +99,47 @@ func (w *responseWriter) WriteHeader(code int) {
 	w.statusHeaderWritten = true
 }
 
+// Flush sends any buffered data to the client, if supported by the internal responseWriter.
+func (w *responseWriter) Flush() {
+	if fl, ok := w.writer.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
 // MiddlewareFunc type declaration of middleware func.
 type MiddlewareFunc func(next http.Handler) http.Handler
 
 // NewRecoveryMiddleware creates a MiddlewareFunc that ensures recovery and no panic.
 func NewRecoveryMiddleware() MiddlewareFunc {
+	return newRecoveryMiddleware("", nil)
+}
+
+// NewNoSniffMiddleware creates a MiddlewareFunc that sets the X-Content-Type-Options header to nosniff,
+// which prevents browsers from MIME-sniffing a response away from the declared content type.
+func NewNoSniffMiddleware() MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if r := recover(); r != nil {
-					var err error
-					switch x := r.(type) {
-					case string:
-						err = errors.New(x)
-					case error:
-						err = x
-					default:
-						err = errors.New("unknown panic")
-					}
-					_ = err
-					log.Errorf("recovering from an error: %v: %s", err, string(debug.Stack()))
-					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				}
-			}()
+			w.Header().Set(contentTypeOptionsHeader, noSniff)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
 // NewAuthMiddleware creates a MiddlewareFunc that implements authentication using an Authenticator.
-func NewAuthMiddleware(auth auth.Authenticator) MiddlewareFunc {
+func NewAuthMiddleware(authenticator auth.Authenticator) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authenticated, err := auth.Authenticate(r)
+			var authenticated bool
+			var err error
+			if ca, ok := authenticator.(auth.ContextAuthenticator); ok {
+				var ctx context.Context
+				ctx, authenticated, err = ca.AuthenticateContext(r)
+				if err == nil && authenticated {
+					r = r.WithContext(ctx)
+				}
+			} else {
+				authenticated, err = authenticator.Authenticate(r)
+			}
 			if err != nil {
 				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 				return
@@ -170,17 +180,17 @@ func initHTTPServerMetrics() {
 			Name:      "handled_total",
 			Help:      "Total number of HTTP responses served by the server.",
 		},
-		[]string{"method", "path", "status_code"},
+		[]string{"method", "path", "status_code", "version"},
 	)
 	prometheus.MustRegister(httpStatusTracingHandledMetric)
-	httpStatusTracingLatencyMetric = prometheus.NewHistogramVec(
+	httpStatusTracingLatencyMetric = newLatencyHistograms(
 		prometheus.HistogramOpts{
 			Namespace: "component",
 			Subsystem: "http",
 			Name:      "handled_seconds",
 			Help:      "Latency of a completed HTTP response served by the server.",
 		},
-		[]string{"method", "path", "status_code"})
+		[]string{"method", "path", "status_code", "version"})
 	prometheus.MustRegister(httpStatusTracingLatencyMetric)
 }
 
@@ -188,9 +198,20 @@ func initHTTPServerMetrics() {
 // metrics are exposed via Prometheus.
 // This middleware is enabled by default.
 func NewRequestObserverMiddleware(method, path string) MiddlewareFunc {
+	return newRequestObserverMiddleware(method, path, "", nil)
+}
+
+// newRequestObserverMiddleware creates a request observer of a route of an API version, if any, which observes the latency
+// with the histogram buckets provided, or else with the ones of the component, or else with the default ones.
+func newRequestObserverMiddleware(method, path, version string, buckets []float64) MiddlewareFunc {
 	// register Promethus metrics on first use
 	httpStatusTracingInit.Do(initHTTPServerMetrics)
 
+	var latency *prometheus.HistogramVec
+	if buckets != nil {
+		latency = httpStatusTracingLatencyMetric.vec(buckets)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			now := time.Now()
@@ -199,8 +220,12 @@ func NewRequestObserverMiddleware(method, path string) MiddlewareFunc {
 
 			// collect metrics about HTTP server-side handling and latency
 			status := strconv.Itoa(lw.Status())
-			httpStatusTracingHandledMetric.WithLabelValues(method, path, status).Inc()
-			httpStatusTracingLatencyMetric.WithLabelValues(method, path, status).Observe(time.Since(now).Seconds())
+			httpStatusTracingHandledMetric.WithLabelValues(method, path, status, version).Inc()
+			histogram := latency
+			if histogram == nil {
+				histogram = latencyHistogramFromContext(r.Context())
+			}
+			httpStatusTracingLatencyMetric.observe(histogram, time.Since(now), method, path, status, version)
 		})
 	}
 }
@@ -400,6 +425,13 @@ func (w *dynamicCompressionResponseWriter) WriteHeader(statusCode int) {
 			return
 		}
 
+		if w.ResponseWriter.Header().Get(encoding.ContentTypeHeader) == sseContentType {
+			// event streams are not compressed, so that every event reaches the client as soon as it is flushed
+			w.writer = w.ResponseWriter
+			w.ResponseWriter.WriteHeader(statusCode)
+			return
+		}
+
 		switch w.Encoding {
 		case gzipHeader:
 			w.writer = gzip.NewWriter(w.ResponseWriter)
@@ -432,6 +464,16 @@ func (w *dynamicCompressionResponseWriter) Write(data []byte) (int, error) {
 	return w.writer.Write(data)
 }
 
+// Flush sends any buffered data to the client, compressing it first if needed.
+func (w *dynamicCompressionResponseWriter) Flush() {
+	if fl, ok := w.writer.(interface{ Flush() error }); ok {
+		_ = fl.Flush()
+	}
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
 func (w *dynamicCompressionResponseWriter) Close() error {
 	if rc, ok := w.writer.(io.Closer); ok {
 		return rc.Close()
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -120,6 +121,46 @@ func TestMiddlewares(t *testing.T) {
 	}
 }
 
+type ctxKey struct{}
+
+type mockContextAuthenticator struct {
+	MockAuthenticator
+}
+
+func (mo mockContextAuthenticator) AuthenticateContext(r *http.Request) (context.Context, bool, error) {
+	ok, err := mo.Authenticate(r)
+	return context.WithValue(r.Context(), ctxKey{}, "value"), ok, err
+}
+
+func TestNewAuthMiddleware_ContextAuthenticator(t *testing.T) {
+	var got interface{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context().Value(ctxKey{})
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	tests := map[string]struct {
+		auth         mockContextAuthenticator
+		expectedCode int
+		expectedVal  interface{}
+	}{
+		"success":      {auth: mockContextAuthenticator{MockAuthenticator{success: true}}, expectedCode: http.StatusAccepted, expectedVal: "value"},
+		"unauthorized": {auth: mockContextAuthenticator{MockAuthenticator{success: false}}, expectedCode: http.StatusUnauthorized},
+		"error":        {auth: mockContextAuthenticator{MockAuthenticator{err: errors.New("auth error")}}, expectedCode: http.StatusInternalServerError},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got = nil
+			rc := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/index", nil)
+			require.NoError(t, err)
+			NewAuthMiddleware(tt.auth)(next).ServeHTTP(rc, req)
+			assert.Equal(t, tt.expectedCode, rc.Code)
+			assert.Equal(t, tt.expectedVal, got)
+		})
+	}
+}
+
 // TestSpanLogError tests whether an HTTP handler with a tracing middleware adds a log event in case of we return an error.
 func TestSpanLogError(t *testing.T) {
 	mtr := mocktracer.New()
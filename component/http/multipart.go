@@ -0,0 +1,160 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+)
+
+const (
+	multipartFormData = "multipart/form-data"
+	// maxFormValueSize is the maximum size of the values of the form fields, which are read in memory.
+	maxFormValueSize = 1 << 20
+)
+
+// UploadedFile describes a file of a multipart form, which has been streamed to a writer or to disk.
+type UploadedFile struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Size        int64
+	// Path of the file on disk, when it has been saved with SaveFormFile.
+	Path string
+}
+
+// MultipartReader returns a reader of the parts of a multipart request, e.g. multipart/form-data, for streaming them one by one.
+// The parts are read from the body of the request, so they can be read only once.
+// Requests which are not multipart result in an *Error with a 415 Unsupported Media Type.
+func (r *Request) MultipartReader() (*multipart.Reader, error) {
+	mt, params, err := mime.ParseMediaType(r.Headers[strings.ToUpper(encoding.ContentTypeHeader)])
+	if err != nil || !strings.HasPrefix(mt, "multipart/") {
+		return nil, NewErrorWithCodeAndPayload(http.StatusUnsupportedMediaType, "request is not multipart")
+	}
+	boundary, ok := params["boundary"]
+	if !ok {
+		return nil, NewValidationErrorWithPayload("multipart request has no boundary")
+	}
+	if r.Raw == nil {
+		return nil, errors.New("request has no body")
+	}
+	return multipart.NewReader(r.Raw, boundary), nil
+}
+
+// FormFile streams the content of the first file of the form field to the writer, reading at most maxSize bytes.
+// The values of the form fields preceding the file are added to the fields of the request.
+// Since the body is streamed, a single file can be read with FormFile; MultipartReader should be used for reading several files.
+//
+// Files larger than maxSize result in an *Error with a 413 Request Entity Too Large and a missing file
+// results in an *Error with a 400 Bad Request, which can be returned by the processor as is.
+func (r *Request) FormFile(field string, w io.Writer, maxSize int64) (*UploadedFile, error) {
+	if w == nil {
+		return nil, errors.New("writer is nil")
+	}
+	if maxSize <= 0 {
+		return nil, errors.New("max size must be positive")
+	}
+
+	part, err := r.nextFormFile(field)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = part.Close()
+	}()
+
+	// one more byte is read, in order to detect files exceeding the limit
+	n, err := io.Copy(w, io.LimitReader(part, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file of form field %s: %w", field, err)
+	}
+	if n > maxSize {
+		return nil, NewErrorWithCodeAndPayload(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file of form field %s exceeds the limit of %d bytes", field, maxSize))
+	}
+
+	return &UploadedFile{
+		Field:       field,
+		Filename:    part.FileName(),
+		ContentType: part.Header.Get(encoding.ContentTypeHeader),
+		Size:        n,
+	}, nil
+}
+
+// SaveFormFile streams the content of the first file of the form field to a new temporary file in the directory,
+// or in the default directory for temporary files when dir is empty. The path of the file is returned and
+// removing it, when it is no longer needed, is the responsibility of the caller.
+// The file is removed if it cannot be read completely, e.g. when it exceeds maxSize. See FormFile for the details.
+func (r *Request) SaveFormFile(field, dir string, maxSize int64) (*UploadedFile, error) {
+	f, err := ioutil.TempFile(dir, "upload-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	uf, err := r.FormFile(field, f, maxSize)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+	uf.Path = f.Name()
+	return uf, nil
+}
+
+func isMultipartFormData(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mt == multipartFormData
+}
+
+// nextFormFile skips the parts of the form up to the file of the field, adding the values of the form fields to the fields of the request.
+func (r *Request) nextFormFile(field string) (*multipart.Part, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, NewValidationErrorWithPayload(fmt.Sprintf("form field %s has no file", field))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read multipart request: %w", err)
+		}
+
+		if part.FormName() == field && part.FileName() != "" {
+			return part, nil
+		}
+		if part.FileName() == "" && part.FormName() != "" {
+			if err := r.readFormValue(part); err != nil {
+				return nil, err
+			}
+		}
+		_ = part.Close()
+	}
+}
+
+func (r *Request) readFormValue(part *multipart.Part) error {
+	b, err := ioutil.ReadAll(io.LimitReader(part, maxFormValueSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read form field %s: %w", part.FormName(), err)
+	}
+	if len(b) > maxFormValueSize {
+		return NewErrorWithCodeAndPayload(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("form field %s exceeds the limit of %d bytes", part.FormName(), maxFormValueSize))
+	}
+	if r.Fields == nil {
+		r.Fields = make(map[string]string)
+	}
+	r.Fields[part.FormName()] = string(b)
+	return nil
+}
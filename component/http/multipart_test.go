@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultipartRequest(t *testing.T, values map[string]string, field, filename, content string) *Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range values {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	if field != "" {
+		fw, err := mw.CreateFormFile(field, filename)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	return NewRequest(map[string]string{}, body, map[string]string{"CONTENT-TYPE": mw.FormDataContentType()}, nil)
+}
+
+func TestRequest_FormFile(t *testing.T) {
+	tests := map[string]struct {
+		req          *Request
+		maxSize      int64
+		expectedCode int
+		expectedErr  string
+	}{
+		"success":        {req: newMultipartRequest(t, map[string]string{"name": "avatar"}, "file", "a.txt", "content"), maxSize: 7},
+		"too large":      {req: newMultipartRequest(t, nil, "file", "a.txt", "content"), maxSize: 6, expectedCode: http.StatusRequestEntityTooLarge},
+		"missing file":   {req: newMultipartRequest(t, nil, "other", "a.txt", "content"), maxSize: 7, expectedCode: http.StatusBadRequest},
+		"not multipart":  {req: NewRequest(nil, strings.NewReader("{}"), map[string]string{"CONTENT-TYPE": "application/json"}, nil), maxSize: 7, expectedCode: http.StatusUnsupportedMediaType},
+		"no boundary":    {req: NewRequest(nil, strings.NewReader(""), map[string]string{"CONTENT-TYPE": "multipart/form-data"}, nil), maxSize: 7, expectedCode: http.StatusBadRequest},
+		"invalid size":   {req: newMultipartRequest(t, nil, "file", "a.txt", "content"), expectedErr: "max size must be positive"},
+		"malformed body": {req: NewRequest(nil, strings.NewReader("--x\r\ninvalid\r\n\r\n"), map[string]string{"CONTENT-TYPE": "multipart/form-data; boundary=x"}, nil), maxSize: 7, expectedErr: `failed to read multipart request: malformed MIME header: missing colon: "invalid"`},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			uf, err := tt.req.FormFile("file", buf, tt.maxSize)
+			if tt.expectedCode != 0 {
+				var httpErr *Error
+				require.True(t, errors.As(err, &httpErr))
+				assert.Equal(t, tt.expectedCode, httpErr.code)
+				return
+			}
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "content", buf.String())
+			assert.Equal(t, &UploadedFile{Field: "file", Filename: "a.txt", ContentType: "application/octet-stream", Size: 7}, uf)
+			assert.Equal(t, "avatar", tt.req.Fields["name"])
+		})
+	}
+}
+
+func TestRequest_SaveFormFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uploads")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	uf, err := newMultipartRequest(t, nil, "file", "a.txt", "content").SaveFormFile("file", dir, 10)
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(uf.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+
+	_, err = newMultipartRequest(t, nil, "file", "a.txt", "content").SaveFormFile("file", dir, 1)
+	assert.Error(t, err)
+	ff, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, ff, 1, "the file exceeding the limit is removed")
+}
+
+func Test_handler_Multipart(t *testing.T) {
+	var uploaded string
+	proc := func(_ context.Context, req *Request) (*Response, error) {
+		buf := &bytes.Buffer{}
+		uf, err := req.FormFile("file", buf, 1024)
+		if err != nil {
+			return nil, err
+		}
+		uploaded = buf.String()
+		return NewResponse(uf), nil
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "a.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("content"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(encoding.ContentTypeHeader, mw.FormDataContentType())
+	rsp := httptest.NewRecorder()
+	handler(proc)(rsp, req)
+
+	assert.Equal(t, http.StatusCreated, rsp.Code)
+	assert.Equal(t, "content", uploaded)
+	assert.Equal(t, "application/json; charset=utf-8", rsp.Header().Get(encoding.ContentTypeHeader))
+	assert.Contains(t, rsp.Body.String(), `"Filename":"a.txt"`)
+}
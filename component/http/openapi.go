@@ -0,0 +1,253 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	patronjson "github.com/beatlabs/patron/encoding/json"
+)
+
+const (
+	openAPIPath    = "/openapi.json"
+	openAPIVersion = "3.0.3"
+)
+
+// OpenAPIOperation describes a route in the OpenAPI document of the HTTP component.
+// Request and Responses hold sample values, e.g. the zero value of a struct, whose types are used
+// to derive the schemas of the request and response bodies. Responses are keyed by status code.
+type OpenAPIOperation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     interface{}
+	Responses   map[int]interface{}
+}
+
+type openAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Info    openAPIInfo                            `json:"info"`
+	Paths   map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// newOpenAPIDocument creates the OpenAPI document of the routes.
+// Routes without OpenAPI metadata are documented with their path parameters and a default response.
+func newOpenAPIDocument(title, version string, routes []Route) openAPIDocument {
+	doc := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: title, Version: version},
+		Paths:   make(map[string]map[string]openAPIOperation),
+	}
+
+	for _, route := range routes {
+		path, params := openAPIPathParameters(route.path)
+		op := openAPIOperation{
+			Parameters: params,
+			Responses:  make(map[string]openAPIResponse),
+		}
+
+		contentTypes := route.accepts
+		if len(contentTypes) == 0 {
+			contentTypes = []string{patronjson.Type}
+		}
+
+		if meta := route.openAPI; meta != nil {
+			op.Summary = meta.Summary
+			op.Description = meta.Description
+			op.Tags = meta.Tags
+			if meta.Request != nil {
+				op.RequestBody = &openAPIRequestBody{
+					Required: true,
+					Content:  openAPIContent(contentTypes, meta.Request),
+				}
+			}
+			for code, v := range meta.Responses {
+				rsp := openAPIResponse{Description: http.StatusText(code)}
+				if v != nil {
+					rsp.Content = openAPIContent(contentTypes, v)
+				}
+				op.Responses[strconv.Itoa(code)] = rsp
+			}
+		}
+		if len(op.Responses) == 0 {
+			op.Responses["default"] = openAPIResponse{Description: "default response"}
+		}
+
+		if _, ok := doc.Paths[path]; !ok {
+			doc.Paths[path] = make(map[string]openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.method)] = op
+	}
+
+	return doc
+}
+
+// openAPIPathParameters converts the named and catch-all parameters of a route path, e.g. /users/:id,
+// to the templated form of OpenAPI, e.g. /users/{id}, and returns the path parameters.
+func openAPIPathParameters(path string) (string, []openAPIParameter) {
+	var params []openAPIParameter
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if len(s) < 2 || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		name := s[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, openAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &openAPISchema{Type: "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func openAPIContent(contentTypes []string, v interface{}) map[string]openAPIMediaType {
+	schema := openAPISchemaOf(reflect.TypeOf(v), make(map[reflect.Type]bool))
+	content := make(map[string]openAPIMediaType, len(contentTypes))
+	for _, ct := range contentTypes {
+		content[ct] = openAPIMediaType{Schema: schema}
+	}
+	return content
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPISchemaOf derives the schema of a type, using the JSON tags of struct fields as property names.
+// Recursive types are described as plain objects once a cycle is detected.
+func openAPISchemaOf(t reflect.Type, visiting map[reflect.Type]bool) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: openAPISchemaOf(t.Elem(), visiting)}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: openAPISchemaOf(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &openAPISchema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+		openAPIStructProperties(t, schema.Properties, visiting)
+		return schema
+	default:
+		// interfaces and any other kinds accept any value
+		return &openAPISchema{}
+	}
+}
+
+func openAPIStructProperties(t reflect.Type, properties map[string]*openAPISchema, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// fields of embedded structs are promoted, like in encoding/json
+				openAPIStructProperties(ft, properties, visiting)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = openAPISchemaOf(f.Type, visiting)
+	}
+}
+
+func openAPIRoute(doc openAPIDocument) (*RouteBuilder, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+
+	return NewRawRouteBuilder(openAPIPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", patronjson.TypeCharset)
+		_, _ = w.Write(b)
+	}).MethodGet(), nil
+}
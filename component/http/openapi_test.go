@@ -0,0 +1,135 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIAudit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type openAPIUser struct {
+	openAPIAudit
+	ID       int64             `json:"id"`
+	Name     string            `json:"name,omitempty"`
+	Score    float64           `json:"score"`
+	Active   bool              `json:"active"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Avatar   []byte            `json:"avatar"`
+	Friends  []*openAPIUser    `json:"friends"`
+	Secret   string            `json:"-"`
+	internal string
+	Untagged int32
+}
+
+func Test_openAPISchemaOf(t *testing.T) {
+	got := openAPISchemaOf(reflect.TypeOf(&openAPIUser{}), make(map[reflect.Type]bool))
+
+	expected := &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"created_at": {Type: "string", Format: "date-time"},
+			"id":         {Type: "integer", Format: "int64"},
+			"name":       {Type: "string"},
+			"score":      {Type: "number", Format: "double"},
+			"active":     {Type: "boolean"},
+			"tags":       {Type: "array", Items: &openAPISchema{Type: "string"}},
+			"labels":     {Type: "object", AdditionalProperties: &openAPISchema{Type: "string"}},
+			"avatar":     {Type: "string", Format: "byte"},
+			"friends":    {Type: "array", Items: &openAPISchema{Type: "object"}},
+			"Untagged":   {Type: "integer", Format: "int32"},
+		},
+	}
+	assert.Equal(t, expected, got)
+}
+
+func Test_openAPIPathParameters(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		expected string
+		params   []string
+	}{
+		"static":    {path: "/users", expected: "/users"},
+		"named":     {path: "/users/:id/orders/:order", expected: "/users/{id}/orders/{order}", params: []string{"id", "order"}},
+		"catch-all": {path: "/files/*path", expected: "/files/{path}", params: []string{"path"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, params := openAPIPathParameters(tt.path)
+			assert.Equal(t, tt.expected, got)
+			require.Len(t, params, len(tt.params))
+			for i, p := range params {
+				assert.Equal(t, tt.params[i], p.Name)
+				assert.Equal(t, "path", p.In)
+				assert.True(t, p.Required)
+			}
+		})
+	}
+}
+
+func TestBuilder_WithOpenAPI(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+	raw := func(http.ResponseWriter, *http.Request) {}
+
+	rb := NewRoutesBuilder().
+		Append(NewGetRouteBuilder("/users/:id", proc).WithOpenAPI(OpenAPIOperation{
+			Summary:   "Get a user",
+			Tags:      []string{"users"},
+			Responses: map[int]interface{}{http.StatusOK: openAPIUser{}, http.StatusNotFound: nil},
+		})).
+		Append(NewPostRouteBuilder("/users", proc).Accepts(patronjson.Type, protobuf.Type).WithOpenAPI(OpenAPIOperation{
+			Request:   openAPIUser{},
+			Responses: map[int]interface{}{http.StatusCreated: nil},
+		})).
+		Append(NewRawRouteBuilder("/raw", raw).MethodGet())
+
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithOpenAPI("users", "1.0.0").Create()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, openAPIPath, nil)
+	rsp := httptest.NewRecorder()
+	cmp.createHTTPServer().Handler.ServeHTTP(rsp, req)
+	require.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, patronjson.TypeCharset, rsp.Header().Get("Content-Type"))
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &doc))
+	assert.Equal(t, openAPIVersion, doc.OpenAPI)
+	assert.Equal(t, openAPIInfo{Title: "users", Version: "1.0.0"}, doc.Info)
+	// only the routes of the routes builder are documented
+	assert.Len(t, doc.Paths, 3)
+
+	get := doc.Paths["/users/{id}"]["get"]
+	assert.Equal(t, "Get a user", get.Summary)
+	assert.Equal(t, []string{"users"}, get.Tags)
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Nil(t, get.RequestBody)
+	assert.Equal(t, "object", get.Responses["200"].Content[patronjson.Type].Schema.Type)
+	assert.Equal(t, openAPIResponse{Description: "Not Found"}, get.Responses["404"])
+
+	post := doc.Paths["/users"]["post"]
+	require.NotNil(t, post.RequestBody)
+	assert.Len(t, post.RequestBody.Content, 2)
+	assert.Contains(t, post.RequestBody.Content, protobuf.Type)
+	assert.Equal(t, openAPIResponse{Description: "Created"}, post.Responses["201"])
+
+	assert.Equal(t, openAPIResponse{Description: "default response"}, doc.Paths["/raw"]["get"].Responses["default"])
+}
+
+func TestBuilder_WithOpenAPI_Errors(t *testing.T) {
+	_, err := NewBuilder().WithOpenAPI("", "").Create()
+	assert.EqualError(t, err, "OpenAPI title is empty\nOpenAPI version is empty\n")
+}
@@ -0,0 +1,251 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	defaultPayloadLogMaxBytes = 1024
+	redactedValue             = "[REDACTED]"
+)
+
+// RedactFunc returns whether the value of a JSON field is sensitive, e.g. a password, and should not be logged.
+type RedactFunc func(field string) bool
+
+// RedactFields returns a RedactFunc for the JSON fields with the given names, compared case-insensitively.
+func RedactFields(fields ...string) RedactFunc {
+	ff := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		ff[strings.ToLower(f)] = struct{}{}
+	}
+	return func(field string) bool {
+		_, ok := ff[strings.ToLower(field)]
+		return ok
+	}
+}
+
+// PayloadLogOptions configures the payload log middleware.
+type PayloadLogOptions struct {
+	// MaxBytes of the request and response bodies which are logged, defaulting to 1KB. Longer bodies are truncated.
+	MaxBytes int
+	// Redact the values of the sensitive fields of JSON bodies, at any depth, in the logs.
+	// The bodies of other content types are logged as they are.
+	Redact RedactFunc
+	// Enabled sets whether the payloads are logged from the start, when set on a route.
+	Enabled bool
+}
+
+// NewPayloadLogMiddleware creates a MiddlewareFunc that logs an info entry with the request and response bodies
+// for the requests of the route, through the logger of the request context, e.g. for debugging a route in production.
+// The bodies are logged up to the max bytes, with the values of the sensitive JSON fields redacted.
+func NewPayloadLogMiddleware(path string, opts PayloadLogOptions) (MiddlewareFunc, error) {
+	if opts.MaxBytes < 0 {
+		return nil, errors.New("payload log max bytes must not be negative")
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultPayloadLogMaxBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				// the head of the body is read upfront, so that it is logged even if the handler does not read it
+				head, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(maxBytes)+1))
+				r.Body = &payloadLogBody{Reader: io.MultiReader(bytes.NewReader(head), r.Body), Closer: r.Body}
+				if err != nil {
+					log.FromContext(r.Context()).Warnf("failed to read request body for payload log: %v", err)
+				}
+				reqBody = head
+			}
+
+			pw := &payloadLogWriter{ResponseWriter: w, status: http.StatusOK, maxBytes: maxBytes}
+			next.ServeHTTP(pw, r)
+
+			entry := map[string]interface{}{
+				"method": r.Method,
+				"path":   path,
+				"status": pw.status,
+			}
+			addPayload(entry, "request", reqBody, r.Header.Get(encoding.ContentTypeHeader), maxBytes, opts.Redact)
+			addPayload(entry, "response", pw.body.Bytes(), pw.Header().Get(encoding.ContentTypeHeader), maxBytes, opts.Redact)
+			log.FromContext(r.Context()).Sub(entry).Info("payload")
+		})
+	}, nil
+}
+
+func addPayload(entry map[string]interface{}, prefix string, body []byte, contentType string, maxBytes int, redact RedactFunc) {
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > maxBytes {
+		body = body[:maxBytes]
+		entry[prefix+"Truncated"] = true
+	}
+	if redact != nil && isJSON(contentType) {
+		entry[prefix+"Body"] = redactJSON(body, redact)
+		return
+	}
+	entry[prefix+"Body"] = string(body)
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+type jsonContainer struct {
+	object bool
+	// n is the number of keys and values written in the container.
+	n int
+}
+
+// redactJSON rewrites a JSON document token by token, replacing the values of the sensitive fields.
+// A truncated document is rewritten up to the last complete token, so that the values of its sensitive fields are never logged.
+func redactJSON(data []byte, redact RedactFunc) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	buf := &bytes.Buffer{}
+	var stack []jsonContainer
+	writeSeparator := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := &stack[len(stack)-1]
+		switch {
+		case top.object && top.n%2 == 1:
+			buf.WriteByte(':')
+		case top.n > 0:
+			buf.WriteByte(',')
+		}
+		top.n++
+	}
+	redactNext := false
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return buf.String()
+		}
+
+		if redactNext {
+			redactNext = false
+			writeSeparator()
+			buf.WriteString(`"` + redactedValue + `"`)
+			if d, ok := tok.(json.Delim); ok && (d == '{' || d == '[') {
+				if !skipJSONValue(dec) {
+					return buf.String()
+				}
+			}
+			continue
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{', '[':
+				writeSeparator()
+				stack = append(stack, jsonContainer{object: v == '{'})
+			default:
+				stack = stack[:len(stack)-1]
+			}
+			buf.WriteRune(rune(v))
+		case string:
+			isKey := len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].n%2 == 0
+			writeSeparator()
+			b, _ := json.Marshal(v)
+			buf.Write(b)
+			if isKey && redact(v) {
+				redactNext = true
+			}
+		case json.Number:
+			writeSeparator()
+			buf.WriteString(v.String())
+		case bool:
+			writeSeparator()
+			if v {
+				buf.WriteString("true")
+			} else {
+				buf.WriteString("false")
+			}
+		case nil:
+			writeSeparator()
+			buf.WriteString("null")
+		}
+	}
+}
+
+// skipJSONValue skips the tokens of an object or array whose opening delimiter has been read.
+func skipJSONValue(dec *json.Decoder) bool {
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return true
+}
+
+type payloadLogBody struct {
+	io.Reader
+	io.Closer
+}
+
+// payloadLogWriter records the status code and the head of the body of a response.
+type payloadLogWriter struct {
+	http.ResponseWriter
+	status      int
+	maxBytes    int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *payloadLogWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *payloadLogWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	// one byte more than the max is kept, in order to know whether the body is truncated
+	if rem := w.maxBytes + 1 - w.body.Len(); rem > 0 {
+		if len(b) < rem {
+			rem = len(b)
+		}
+		w.body.Write(b[:rem])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client, if supported by the internal ResponseWriter.
+func (w *payloadLogWriter) Flush() {
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/log/std"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPayloadLogMiddleware(t *testing.T) {
+	hnd := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(encoding.ContentTypeHeader, r.Header.Get(encoding.ContentTypeHeader))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(b)
+	})
+
+	tests := map[string]struct {
+		opts             PayloadLogOptions
+		contentType      string
+		body             string
+		expectedFields   []string
+		unexpectedFields []string
+		expectedErr      string
+	}{
+		"plain text": {
+			contentType:    "text/plain",
+			body:           "hello",
+			expectedFields: []string{"method=POST", "path=/users/:id", "status=201", "requestBody=hello", "responseBody=hello"},
+		},
+		"no body": {
+			expectedFields:   []string{"status=201"},
+			unexpectedFields: []string{"requestBody=", "responseBody="},
+		},
+		"truncated": {
+			opts:           PayloadLogOptions{MaxBytes: 3},
+			contentType:    "text/plain",
+			body:           "hello",
+			expectedFields: []string{"requestBody=hel ", "requestTruncated=true", "responseBody=hel ", "responseTruncated=true"},
+		},
+		"redacted": {
+			opts:        PayloadLogOptions{Redact: RedactFields("password", "Token")},
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"john","password":"secret","nested":[{"token":{"a":1}},true,null]}`,
+			expectedFields: []string{
+				`requestBody={"name":"john","password":"[REDACTED]","nested":[{"token":"[REDACTED]"},true,null]}`,
+			},
+			unexpectedFields: []string{"secret", `"a":1`},
+		},
+		"redacted and truncated": {
+			opts:             PayloadLogOptions{MaxBytes: 30, Redact: RedactFields("password")},
+			contentType:      "application/json",
+			body:             `{"name":"john","password":"secret"}`,
+			expectedFields:   []string{`requestBody={"name":"john","password" `, "requestTruncated=true"},
+			unexpectedFields: []string{"secr"},
+		},
+		"not redacted if not json": {
+			opts:           PayloadLogOptions{Redact: RedactFields("password")},
+			contentType:    "text/plain",
+			body:           `{"password":"secret"}`,
+			expectedFields: []string{`requestBody={"password":"secret"}`},
+		},
+		"negative max bytes": {
+			opts:        PayloadLogOptions{MaxBytes: -1},
+			expectedErr: "payload log max bytes must not be negative",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			mw, err := NewPayloadLogMiddleware("/users/:id", tt.opts)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(tt.body))
+			req.Header.Set(encoding.ContentTypeHeader, tt.contentType)
+			req = req.WithContext(log.WithContext(req.Context(), std.New(&buf, log.InfoLevel, nil)))
+			rsp := httptest.NewRecorder()
+			mw(hnd).ServeHTTP(rsp, req)
+
+			assert.Equal(t, tt.body, rsp.Body.String(), "the handler reads the whole body")
+			entry := buf.String()
+			assert.True(t, strings.HasSuffix(entry, "payload\n"))
+			for _, f := range tt.expectedFields {
+				assert.Contains(t, entry, f)
+			}
+			for _, f := range tt.unexpectedFields {
+				assert.NotContains(t, entry, f)
+			}
+		})
+	}
+}
+
+func TestRouteBuilder_WithPayloadLog(t *testing.T) {
+	proc := func(_ http.ResponseWriter, _ *http.Request) {}
+
+	_, err := NewRawRouteBuilder("/", proc).MethodGet().WithPayloadLog("", PayloadLogOptions{}).Build()
+	assert.EqualError(t, err, "middleware name is empty\n")
+	_, err = NewRawRouteBuilder("/", proc).MethodGet().WithPayloadLog("root", PayloadLogOptions{MaxBytes: -1}).Build()
+	assert.EqualError(t, err, "payload log max bytes must not be negative\n")
+
+	_, err = NewBuilder().WithRoutesBuilder(NewRoutesBuilder().
+		Append(NewRawRouteBuilder("/a", proc).MethodGet().WithPayloadLog("debug", PayloadLogOptions{})).
+		Append(NewRawRouteBuilder("/b", proc).MethodGet().WithPayloadLog("debug", PayloadLogOptions{}))).
+		Create()
+	assert.EqualError(t, err, "toggleable middleware debug is duplicate")
+
+	cmp, err := NewBuilder().WithRoutesBuilder(NewRoutesBuilder().
+		Append(NewRawRouteBuilder("/users", proc).MethodPost().WithPayloadLog("users", PayloadLogOptions{}))).
+		WithMiddlewareToggling(MockAuthenticator{success: true}).
+		Create()
+	require.NoError(t, err)
+	hnd := cmp.createServers()[0].Handler
+
+	post := func() string {
+		var buf bytes.Buffer
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("hello"))
+		req = req.WithContext(log.WithContext(req.Context(), std.New(&buf, log.InfoLevel, nil)))
+		hnd.ServeHTTP(httptest.NewRecorder(), req)
+		return buf.String()
+	}
+
+	assert.NotContains(t, post(), "payload", "the payload log is disabled by default")
+
+	rsp := httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/middlewares/users/enable", nil))
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	assert.Contains(t, post(), "requestBody=hello")
+
+	rsp = httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/middlewares/users/disable", nil))
+	require.Equal(t, http.StatusNoContent, rsp.Code)
+	assert.NotContains(t, post(), "payload")
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/beatlabs/patron/log"
+)
+
+// maxDiscriminatorBodySize is the size of the largest body which the JSON field discriminator buffers.
+const maxDiscriminatorBodySize = 1 << 20
+
+var errDiscriminatorBodyTooLarge = fmt.Errorf("body is larger than %d bytes", maxDiscriminatorBodySize)
+
+type typeFactoryKey struct{}
+
+// TypeFactory creates a new instance of the target type of a polymorphic payload, e.g. func() interface{} { return &Created{} }.
+type TypeFactory func() interface{}
+
+// Discriminator resolves the value of a request that identifies the type of its payload.
+type Discriminator func(r *http.Request) (string, error)
+
+// HeaderDiscriminator resolves the type of the payload from the value of a request header.
+func HeaderDiscriminator(header string) Discriminator {
+	return func(r *http.Request) (string, error) {
+		val := r.Header.Get(header)
+		if val == "" {
+			return "", fmt.Errorf("header %s is missing", header)
+		}
+		return val, nil
+	}
+}
+
+// JSONFieldDiscriminator resolves the type of the payload from a top-level string field of a JSON body.
+// The body is buffered, in order to be decoded afterwards into the resolved type, up to 1 MiB,
+// and larger bodies are rejected with a 413 Request Entity Too Large.
+func JSONFieldDiscriminator(field string) Discriminator {
+	return func(r *http.Request) (string, error) {
+		if r.Body == nil {
+			return "", errors.New("body is empty")
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(r.Body, maxDiscriminatorBodySize+1))
+		if err != nil {
+			return "", fmt.Errorf("failed to read body: %w", err)
+		}
+		if len(b) > maxDiscriminatorBodySize {
+			return "", errDiscriminatorBodyTooLarge
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return "", fmt.Errorf("failed to decode body: %w", err)
+		}
+		raw, ok := fields[field]
+		if !ok {
+			return "", fmt.Errorf("field %s is missing", field)
+		}
+		var val string
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return "", fmt.Errorf("field %s is not a string: %w", field, err)
+		}
+		return val, nil
+	}
+}
+
+// NewPolymorphicDecodingMiddleware creates a MiddlewareFunc that resolves the type of the request payload
+// using the discriminator and the registered type factories. The resolved type is used by Request.Decode,
+// when decoding into a pointer to an empty interface.
+// Requests with a missing or unknown discriminator are rejected with a 400 Bad Request, and the ones with a body
+// too large for the discriminator with a 413 Request Entity Too Large.
+func NewPolymorphicDecodingMiddleware(d Discriminator, types map[string]TypeFactory) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			val, err := d(r)
+			if err != nil {
+				log.FromContext(r.Context()).Debugf("failed to resolve payload type: %v", err)
+				code := http.StatusBadRequest
+				if errors.Is(err, errDiscriminatorBodyTooLarge) {
+					code = http.StatusRequestEntityTooLarge
+				}
+				http.Error(w, fmt.Sprintf("failed to resolve payload type: %v", err), code)
+				return
+			}
+			f, ok := types[val]
+			if !ok {
+				http.Error(w, fmt.Sprintf("unknown payload type %q", val), http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), typeFactoryKey{}, f)))
+		})
+	}
+}
+
+func typeFactoryFromContext(ctx context.Context) TypeFactory {
+	f, ok := ctx.Value(typeFactoryKey{}).(TypeFactory)
+	if !ok {
+		return nil
+	}
+	return f
+}
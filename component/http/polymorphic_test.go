@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type created struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type deleted struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func polymorphicTypes() map[string]TypeFactory {
+	return map[string]TypeFactory{
+		"created": func() interface{} { return &created{} },
+		"deleted": func() interface{} { return &deleted{} },
+	}
+}
+
+func TestHeaderDiscriminator(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	_, err := HeaderDiscriminator("X-Type")(req)
+	assert.EqualError(t, err, "header X-Type is missing")
+
+	req.Header.Set("X-Type", "created")
+	got, err := HeaderDiscriminator("X-Type")(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "created", got)
+}
+
+func TestJSONFieldDiscriminator(t *testing.T) {
+	tests := map[string]struct {
+		body        string
+		expected    string
+		expectedErr string
+	}{
+		"success":          {body: `{"type":"created","id":"1"}`, expected: "created"},
+		"missing field":    {body: `{"id":"1"}`, expectedErr: "field type is missing"},
+		"non string field": {body: `{"type":1}`, expectedErr: "field type is not a string: json: cannot unmarshal number into Go value of type string"},
+		"invalid body":     {body: `{`, expectedErr: "failed to decode body: unexpected end of JSON input"},
+		"body too large":   {body: `{"type":"created","id":"` + strings.Repeat("1", maxDiscriminatorBodySize) + `"}`, expectedErr: "body is larger than 1048576 bytes"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			got, err := JSONFieldDiscriminator("type")(req)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestNewPolymorphicDecodingMiddleware_BodyTooLarge(t *testing.T) {
+	mw := NewPolymorphicDecodingMiddleware(JSONFieldDiscriminator("type"), polymorphicTypes())
+	body := `{"type":"created","id":"` + strings.Repeat("1", maxDiscriminatorBodySize) + `"}`
+	rsp := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("the request reaches the handler")
+	})).ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rsp.Code)
+}
+
+func TestRouteBuilder_WithPolymorphicDecoding(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+	raw := func(http.ResponseWriter, *http.Request) {}
+
+	tests := map[string]struct {
+		rb          *RouteBuilder
+		expectedErr string
+	}{
+		"success": {
+			rb: NewPostRouteBuilder("/", proc).WithPolymorphicDecoding(HeaderDiscriminator("X-Type"), polymorphicTypes()),
+		},
+		"nil discriminator": {
+			rb:          NewPostRouteBuilder("/", proc).WithPolymorphicDecoding(nil, polymorphicTypes()),
+			expectedErr: "discriminator is nil\n",
+		},
+		"empty types": {
+			rb:          NewPostRouteBuilder("/", proc).WithPolymorphicDecoding(HeaderDiscriminator("X-Type"), nil),
+			expectedErr: "type factories are empty\n",
+		},
+		"nil type factory": {
+			rb: NewPostRouteBuilder("/", proc).
+				WithPolymorphicDecoding(HeaderDiscriminator("X-Type"), map[string]TypeFactory{"created": nil}),
+			expectedErr: "type factory for created is nil\n",
+		},
+		"raw route": {
+			rb:          NewRawRouteBuilder("/", raw).MethodPost().WithPolymorphicDecoding(HeaderDiscriminator("X-Type"), polymorphicTypes()),
+			expectedErr: "polymorphic decoding is not supported on raw routes",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := tt.rb.Build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPolymorphicDecoding(t *testing.T) {
+	var got interface{}
+	proc := func(_ context.Context, req *Request) (*Response, error) {
+		got = nil
+		if err := req.Decode(&got); err != nil {
+			return nil, NewValidationErrorWithPayload(err.Error())
+		}
+		return nil, nil
+	}
+
+	route, err := NewPostRouteBuilder("/events", proc).
+		WithPolymorphicDecoding(JSONFieldDiscriminator("type"), polymorphicTypes()).Build()
+	require.NoError(t, err)
+	h := MiddlewareChain(route.handler, route.middlewares...)
+
+	tests := map[string]struct {
+		body         string
+		expectedCode int
+		expected     interface{}
+	}{
+		"created":      {body: `{"type":"created","id":"1"}`, expectedCode: http.StatusNoContent, expected: &created{Type: "created", ID: "1"}},
+		"deleted":      {body: `{"type":"deleted","reason":"gdpr"}`, expectedCode: http.StatusNoContent, expected: &deleted{Type: "deleted", Reason: "gdpr"}},
+		"unknown type": {body: `{"type":"updated"}`, expectedCode: http.StatusBadRequest},
+		"missing type": {body: `{"id":"1"}`, expectedCode: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(tt.body))
+			req.Header.Set(encoding.ContentTypeHeader, json.Type)
+			rsp := httptest.NewRecorder()
+			h.ServeHTTP(rsp, req)
+
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRequest_Decode_WithoutTypeFactory(t *testing.T) {
+	req := NewRequest(nil, strings.NewReader(`{"type":"created","id":"1"}`), nil, json.Decode)
+	var got created
+	require.NoError(t, req.Decode(&got))
+	assert.Equal(t, created{Type: "created", ID: "1"}, got)
+}
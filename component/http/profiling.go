@@ -1,11 +1,43 @@
 package http
 
 import (
+	"expvar"
 	"net/http"
 	"net/http/pprof"
+
+	"github.com/beatlabs/patron/component/http/auth"
 )
 
-func profilingRoutes() []*RouteBuilder {
+// ProfilingOptions of the profiling endpoints of the HTTP component, which expose details of the process
+// and are a common security finding when they are publicly reachable.
+type ProfilingOptions struct {
+	// DisablePprof removes the pprof endpoints under /debug/pprof, which are served by default.
+	DisablePprof bool
+	// ExpVars serves the variables published with the expvar package at /debug/vars.
+	ExpVars bool
+	// LogLevel serves the endpoints for getting and changing the log level at /debug/loglevel.
+	LogLevel bool
+	// Authenticator protects the profiling endpoints, when provided.
+	Authenticator auth.Authenticator
+}
+
+func profilingRoutes(opts ProfilingOptions) []*RouteBuilder {
+	var rr []*RouteBuilder
+	if !opts.DisablePprof {
+		rr = append(rr, pprofRoutes()...)
+	}
+	if opts.ExpVars {
+		rr = append(rr, NewRawRouteBuilder("/debug/vars", expvar.Handler().ServeHTTP).MethodGet())
+	}
+	if opts.Authenticator != nil {
+		for _, rb := range rr {
+			rb.WithAuth(opts.Authenticator)
+		}
+	}
+	return rr
+}
+
+func pprofRoutes() []*RouteBuilder {
 	return []*RouteBuilder{
 		NewRawRouteBuilder("/debug/pprof/", profIndex).MethodGet(),
 		NewRawRouteBuilder("/debug/pprof/allocs/", pprofAllocsIndex).MethodGet(),
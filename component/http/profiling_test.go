@@ -7,12 +7,13 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_PprofHandlers(t *testing.T) {
 	mux := http.NewServeMux()
 
-	rr := profilingRoutes()
+	rr := profilingRoutes(ProfilingOptions{})
 
 	for _, r := range rr {
 		route, err := r.Build()
@@ -48,3 +49,30 @@ func Test_PprofHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilder_WithProfiling(t *testing.T) {
+	tests := map[string]struct {
+		opts         ProfilingOptions
+		path         string
+		expectedCode int
+	}{
+		"pprof by default":       {path: "/debug/pprof/", expectedCode: http.StatusOK},
+		"pprof disabled":         {opts: ProfilingOptions{DisablePprof: true}, path: "/debug/pprof/", expectedCode: http.StatusNotFound},
+		"expvar disabled":        {path: "/debug/vars", expectedCode: http.StatusNotFound},
+		"expvar enabled":         {opts: ProfilingOptions{ExpVars: true}, path: "/debug/vars", expectedCode: http.StatusOK},
+		"pprof authenticated":    {opts: ProfilingOptions{Authenticator: &MockAuthenticator{success: true}}, path: "/debug/pprof/", expectedCode: http.StatusOK},
+		"pprof unauthenticated":  {opts: ProfilingOptions{Authenticator: &MockAuthenticator{}}, path: "/debug/pprof/", expectedCode: http.StatusUnauthorized},
+		"expvar unauthenticated": {opts: ProfilingOptions{ExpVars: true, Authenticator: &MockAuthenticator{}}, path: "/debug/vars", expectedCode: http.StatusUnauthorized},
+		"metrics not protected":  {opts: ProfilingOptions{Authenticator: &MockAuthenticator{}}, path: "/metrics", expectedCode: http.StatusOK},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cmp, err := NewBuilder().WithProfiling(tt.opts).Create()
+			require.NoError(t, err)
+			rsp := httptest.NewRecorder()
+			cmp.createHTTPServer().Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/beatlabs/patron/correlation"
+)
+
+// newHeaderPropagationMiddleware lifts the headers declared with correlation.PropagateHeaders into the context of the requests,
+// so that the clients inject them into the downstream requests and messages.
+func newHeaderPropagationMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := correlation.ContextWithHeaders(r.Context(), r.Header.Get)
+			if ctx != r.Context() {
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
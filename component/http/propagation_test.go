@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponent_PropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id", "Accept-Language")
+
+	var propagated map[string]string
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/", func(w http.ResponseWriter, r *http.Request) {
+		propagated = correlation.HeadersFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}).MethodGet())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-Id", "tenant")
+	req.Header.Set("X-Other", "other")
+	rsp := httptest.NewRecorder()
+	cmp.createServers()[0].Handler.ServeHTTP(rsp, req)
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, map[string]string{"X-Tenant-Id": "tenant"}, propagated)
+}
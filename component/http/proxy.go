@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const proxyComponent = "http-proxy"
+
+var (
+	proxyMetricsInit    sync.Once
+	proxyUpstreamMetric *prometheus.HistogramVec
+)
+
+// ProxyOptionFunc definition for configuring a reverse proxy route in a functional way.
+type ProxyOptionFunc func(*proxy) error
+
+type proxy struct {
+	path          string
+	target        *url.URL
+	transport     http.RoundTripper
+	stripPrefix   string
+	setHeaders    map[string]string
+	removeHeaders []string
+	retries       int
+	retryDelay    time.Duration
+}
+
+// ProxyTransport option for setting the transport of the requests to the upstream.
+func ProxyTransport(rt http.RoundTripper) ProxyOptionFunc {
+	return func(p *proxy) error {
+		if rt == nil {
+			return errors.New("transport must be supplied")
+		}
+		p.transport = rt
+		return nil
+	}
+}
+
+// ProxyStripPrefix option for removing a prefix from the path of the requests, before it is joined with the path of the target.
+func ProxyStripPrefix(prefix string) ProxyOptionFunc {
+	return func(p *proxy) error {
+		if prefix == "" {
+			return errors.New("prefix is empty")
+		}
+		p.stripPrefix = prefix
+		return nil
+	}
+}
+
+// ProxySetHeaders option for setting headers of the requests to the upstream, replacing the ones of the client.
+func ProxySetHeaders(headers map[string]string) ProxyOptionFunc {
+	return func(p *proxy) error {
+		if len(headers) == 0 {
+			return errors.New("headers are empty")
+		}
+		p.setHeaders = headers
+		return nil
+	}
+}
+
+// ProxyRemoveHeaders option for removing headers of the client from the requests to the upstream, e.g. Cookie.
+func ProxyRemoveHeaders(headers ...string) ProxyOptionFunc {
+	return func(p *proxy) error {
+		if len(headers) == 0 {
+			return errors.New("headers are empty")
+		}
+		p.removeHeaders = headers
+		return nil
+	}
+}
+
+// ProxyRetry option for retrying the idempotent requests without a body, when the upstream fails
+// or responds with a 502 Bad Gateway, 503 Service Unavailable or 504 Gateway Timeout.
+func ProxyRetry(retries int, delay time.Duration) ProxyOptionFunc {
+	return func(p *proxy) error {
+		if retries <= 0 {
+			return errors.New("retries should be positive")
+		}
+		if delay < 0 {
+			return errors.New("retry delay should be zero or positive")
+		}
+		p.retries = retries
+		p.retryDelay = delay
+		return nil
+	}
+}
+
+// NewProxyRouteBuilder constructor, which forwards the requests of the route to the target URL with a reverse proxy.
+// The path of the request is joined with the path of the target, so several paths are proxied with a catch-all path, e.g. /api/*path.
+// The requests to the upstream are traced and their latency is observed per route.
+func NewProxyRouteBuilder(path, targetURL string, oo ...ProxyOptionFunc) *RouteBuilder {
+	var ee []error
+
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		ee = append(ee, fmt.Errorf("invalid target URL: %w", err))
+	} else if target.Scheme == "" || target.Host == "" {
+		ee = append(ee, fmt.Errorf("target URL %s should be absolute", targetURL))
+	}
+
+	p := &proxy{path: path, target: target, transport: http.DefaultTransport}
+	for _, o := range oo {
+		if err := o(p); err != nil {
+			ee = append(ee, err)
+		}
+	}
+
+	// register Prometheus metrics on first use
+	proxyMetricsInit.Do(initProxyMetrics)
+
+	rp := &httputil.ReverseProxy{
+		Director:  p.direct,
+		Transport: roundTripperFunc(p.roundTrip),
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.FromContext(r.Context()).Errorf("failed to proxy %s %s to %s: %v", r.Method, r.URL.Path, p.target.Host, err)
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		},
+	}
+	rb := NewRawRouteBuilder(path, rp.ServeHTTP)
+	rb.errors = append(rb.errors, ee...)
+	return rb
+}
+
+func initProxyMetrics() {
+	proxyUpstreamMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "proxy_upstream_seconds",
+			Help:      "Latency of the requests of reverse proxy routes to their upstream.",
+		},
+		[]string{"method", "path", "status_code"},
+	)
+	prometheus.MustRegister(proxyUpstreamMetric)
+}
+
+// direct rewrites the request of the client to a request to the upstream.
+func (p *proxy) direct(r *http.Request) {
+	path := r.URL.Path
+	if p.stripPrefix != "" {
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, p.stripPrefix), "/")
+	}
+	r.URL.Scheme = p.target.Scheme
+	r.URL.Host = p.target.Host
+	r.URL.Path = singleJoiningSlash(p.target.Path, path)
+	r.URL.RawPath = ""
+	if p.target.RawQuery != "" && r.URL.RawQuery != "" {
+		r.URL.RawQuery = p.target.RawQuery + "&" + r.URL.RawQuery
+	} else if p.target.RawQuery != "" {
+		r.URL.RawQuery = p.target.RawQuery
+	}
+	r.Host = p.target.Host
+
+	for _, h := range p.removeHeaders {
+		r.Header.Del(h)
+	}
+	for k, v := range p.setHeaders {
+		r.Header.Set(k, v)
+	}
+	r.Header.Set(correlation.HeaderID, getOrSetCorrelationID(r.Header))
+	if _, ok := r.Header["User-Agent"]; !ok {
+		// prevents the default user agent of the transport
+		r.Header.Set("User-Agent", "")
+	}
+}
+
+// roundTrip sends the request to the upstream in a span, retrying it if allowed.
+func (p *proxy) roundTrip(r *http.Request) (*http.Response, error) {
+	sp, ctx := opentracing.StartSpanFromContext(r.Context(), opName(r.Method, p.path), ext.SpanKindRPCClient)
+	defer sp.Finish()
+	ext.HTTPMethod.Set(sp, r.Method)
+	ext.HTTPUrl.Set(sp, r.URL.String())
+	ext.Component.Set(sp, proxyComponent)
+	sp.SetTag(trace.VersionTag, trace.Version)
+	r = r.WithContext(ctx)
+	if err := sp.Tracer().Inject(sp.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header)); err != nil {
+		log.FromContext(ctx).Errorf("failed to inject tracing headers: %v", err)
+	}
+
+	retries := 0
+	if isIdempotent(r.Method) && (r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0) {
+		retries = p.retries
+	}
+
+	var rsp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		rsp, err = p.transport.RoundTrip(r)
+		status := "error"
+		if err == nil {
+			status = strconv.Itoa(rsp.StatusCode)
+		}
+		proxyUpstreamMetric.WithLabelValues(r.Method, p.path, status).Observe(time.Since(start).Seconds())
+
+		if attempt == retries || !retryable(rsp, err) || ctx.Err() != nil {
+			break
+		}
+		if rsp != nil {
+			_ = rsp.Body.Close()
+		}
+		log.FromContext(ctx).Debugf("retrying %s %s to %s, attempt %d", r.Method, r.URL.Path, p.target.Host, attempt+1)
+		if p.retryDelay > 0 {
+			if err = waitRetry(ctx, p.retryDelay); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		ext.Error.Set(sp, true)
+		return nil, err
+	}
+	ext.HTTPStatusCode.Set(sp, uint16(rsp.StatusCode))
+	ext.Error.Set(sp, rsp.StatusCode >= http.StatusInternalServerError)
+	return rsp, nil
+}
+
+// waitRetry waits for the delay before retrying, returning the error of the context if it is done first.
+func waitRetry(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryable(rsp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch rsp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
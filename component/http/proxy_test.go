@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProxyRouteBuilder_Errors(t *testing.T) {
+	tests := map[string]struct {
+		target      string
+		oo          []ProxyOptionFunc
+		expectedErr string
+	}{
+		"relative target":     {target: "/api", expectedErr: "target URL /api should be absolute\n"},
+		"invalid target":      {target: "http://[::1", expectedErr: `invalid target URL: parse "http://[::1": missing ']' in host` + "\n"},
+		"nil transport":       {target: "http://localhost", oo: []ProxyOptionFunc{ProxyTransport(nil)}, expectedErr: "transport must be supplied\n"},
+		"empty prefix":        {target: "http://localhost", oo: []ProxyOptionFunc{ProxyStripPrefix("")}, expectedErr: "prefix is empty\n"},
+		"empty set headers":   {target: "http://localhost", oo: []ProxyOptionFunc{ProxySetHeaders(nil)}, expectedErr: "headers are empty\n"},
+		"empty rm headers":    {target: "http://localhost", oo: []ProxyOptionFunc{ProxyRemoveHeaders()}, expectedErr: "headers are empty\n"},
+		"invalid retries":     {target: "http://localhost", oo: []ProxyOptionFunc{ProxyRetry(0, 0)}, expectedErr: "retries should be positive\n"},
+		"invalid retry delay": {target: "http://localhost", oo: []ProxyOptionFunc{ProxyRetry(1, -time.Second)}, expectedErr: "retry delay should be zero or positive\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			_, err := NewProxyRouteBuilder("/api/*path", tt.target, tt.oo...).MethodGet().Build()
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestNewProxyRouteBuilder(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" && atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("X-Upstream-Path", r.URL.Path)
+		w.Header().Set("X-Upstream-Token", r.Header.Get("X-Token"))
+		w.Header().Set("X-Upstream-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Upstream-Correlation", r.Header.Get(correlation.HeaderID))
+		w.Header().Set("X-Upstream-Trace", r.Header.Get("Mockpfx-Ids-Traceid"))
+		b, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer upstream.Close()
+
+	oo := []ProxyOptionFunc{
+		ProxyStripPrefix("/api"),
+		ProxySetHeaders(map[string]string{"X-Token": "secret"}),
+		ProxyRemoveHeaders("Cookie"),
+		ProxyRetry(2, 0),
+	}
+	rb := NewRoutesBuilder().
+		Append(NewProxyRouteBuilder("/api/*path", upstream.URL+"/v1", oo...).MethodGet()).
+		Append(NewProxyRouteBuilder("/api/*path", upstream.URL+"/v1", oo...).MethodPost())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+	hnd := cmp.createHTTPServer().Handler
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set(correlation.HeaderID, "123")
+	rsp := httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, req)
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.Equal(t, "/v1/users/1", rsp.Header().Get("X-Upstream-Path"))
+	assert.Equal(t, "secret", rsp.Header().Get("X-Upstream-Token"))
+	assert.Empty(t, rsp.Header().Get("X-Upstream-Cookie"))
+	assert.Equal(t, "123", rsp.Header().Get("X-Upstream-Correlation"))
+	assert.NotEmpty(t, rsp.Header().Get("X-Upstream-Trace"))
+	spans := mtr.FinishedSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /api/*path", spans[0].OperationName)
+	assert.Equal(t, proxyComponent, spans[0].Tag("component"))
+
+	rsp = httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodPost, "/api/users?fail=true", strings.NewReader("body")))
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.Code, "requests with a body are not retried")
+
+	atomic.StoreInt32(&calls, 0)
+	rsp = httptest.NewRecorder()
+	hnd.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/api/users?fail=true", nil))
+	assert.Equal(t, http.StatusOK, rsp.Code, "idempotent requests are retried")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestNewProxyRouteBuilder_UpstreamDown(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	upstream.Close()
+
+	route, err := NewProxyRouteBuilder("/api/*path", upstream.URL).MethodGet().Build()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	route.handler(rsp, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	assert.Equal(t, http.StatusBadGateway, rsp.Code)
+}
+
+func TestNewProxyRouteBuilder_RetryCancelled(t *testing.T) {
+	var calls int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	route, err := NewProxyRouteBuilder("/api/*path", upstream.URL, ProxyRetry(3, time.Hour)).MethodGet().Build()
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	rsp := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		route.handler(rsp, httptest.NewRequest(http.MethodGet, "/api/users", nil).WithContext(ctx))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the retry delay is not cancelled with the request")
+	}
+	assert.Equal(t, http.StatusBadGateway, rsp.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
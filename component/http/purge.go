@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+
+	httpcache "github.com/beatlabs/patron/component/http/cache"
+)
+
+const cachePath = "/cache"
+
+type cachePurgeResult struct {
+	Purged int `json:"purged"`
+}
+
+// cachePurgeRoute creates a route purging the cached responses of the route caches
+// matching either the path or the pattern query parameter.
+func cachePurgeRoute(caches []*httpcache.RouteCache, mm ...MiddlewareFunc) *RouteBuilder {
+	purge := func(ctx context.Context, req *Request) (*Response, error) {
+		path, pattern := req.Fields["path"], req.Fields["pattern"]
+		if (path == "") == (pattern == "") {
+			return nil, NewValidationErrorWithPayload("either the path or the pattern query parameter should be provided")
+		}
+
+		purged := 0
+		for _, rc := range caches {
+			var n int
+			var err error
+			if path != "" {
+				n, err = rc.PurgePath(ctx, path)
+			} else {
+				n, err = rc.Purge(ctx, pattern)
+			}
+			if err != nil {
+				return nil, err
+			}
+			purged += n
+		}
+		return NewResponse(cachePurgeResult{Purged: purged}), nil
+	}
+
+	rb := NewDeleteRouteBuilder(cachePath, purge)
+	if len(mm) > 0 {
+		rb.WithMiddlewares(mm...)
+	}
+	return rb
+}
+
+// routeCaches returns the distinct route caches of the routes.
+func routeCaches(routes []Route) []*httpcache.RouteCache {
+	var caches []*httpcache.RouteCache
+	seen := make(map[*httpcache.RouteCache]bool)
+	for _, r := range routes {
+		if r.routeCache == nil || seen[r.routeCache] {
+			continue
+		}
+		seen[r.routeCache] = true
+		caches = append(caches, r.routeCache)
+	}
+	return caches
+}
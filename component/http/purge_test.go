@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httpcache "github.com/beatlabs/patron/component/http/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_WithRouteCachePurging(t *testing.T) {
+	cc := newTestingCache()
+	cc.instant = httpcache.NowSeconds
+	proc := func(_ context.Context, req *Request) (*Response, error) {
+		return NewResponse(req.Fields["id"]), nil
+	}
+	rb := NewRoutesBuilder().
+		Append(NewGetRouteBuilder("/users/:id", proc).WithRouteCache(cc, httpcache.Age{Max: time.Minute})).
+		Append(NewGetRouteBuilder("/orders/:id", proc).WithRouteCache(cc, httpcache.Age{Max: time.Minute}))
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Purge", "true")
+			next.ServeHTTP(w, r)
+		})
+	}
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithRouteCachePurging(mw).Create()
+	require.NoError(t, err)
+	srv := httptest.NewServer(cmp.createHTTPServer().Handler)
+	defer srv.Close()
+
+	for _, path := range []string{"/users/1", "/users/1?page=2", "/users/2", "/orders/1"} {
+		rsp, err := srv.Client().Get(srv.URL + path)
+		require.NoError(t, err)
+		_ = rsp.Body.Close()
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+	}
+	require.Equal(t, 4, cc.size())
+
+	tests := []struct {
+		query          string
+		expectedCode   int
+		expectedPurged int
+		expectedSize   int
+	}{
+		{query: "", expectedCode: http.StatusBadRequest, expectedSize: 4},
+		{query: "?path=/users/1&pattern=*", expectedCode: http.StatusBadRequest, expectedSize: 4},
+		{query: "?path=/users/1", expectedCode: http.StatusOK, expectedPurged: 2, expectedSize: 2},
+		{query: "?pattern=/users/*", expectedCode: http.StatusOK, expectedPurged: 1, expectedSize: 1},
+		{query: "?pattern=/users/*", expectedCode: http.StatusOK, expectedPurged: 0, expectedSize: 1},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodDelete, srv.URL+cachePath+tt.query, nil)
+		require.NoError(t, err)
+		rsp, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expectedCode, rsp.StatusCode, tt.query)
+		assert.Equal(t, "true", rsp.Header.Get("X-Purge"))
+		if tt.expectedCode == http.StatusOK {
+			var got cachePurgeResult
+			require.NoError(t, json.NewDecoder(rsp.Body).Decode(&got))
+			assert.Equal(t, tt.expectedPurged, got.Purged, tt.query)
+		}
+		_ = rsp.Body.Close()
+		assert.Equal(t, tt.expectedSize, cc.size(), tt.query)
+	}
+}
+
+func TestBuilder_WithRouteCachePurging_Disabled(t *testing.T) {
+	cmp, err := NewBuilder().Create()
+	require.NoError(t, err)
+	for _, r := range cmp.routes {
+		assert.NotEqual(t, cachePath, r.path)
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"hash/fnv"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitMaxKeys bounds the number of limiters kept by a keyed rate limiting middleware,
+	// the least recently used ones being evicted, which resets their limit.
+	rateLimitMaxKeys = 10000
+	// rateLimitNewKeysBurst is the number of callers whose key is not known yet which can get a limiter at once,
+	// while the rest of them get one at a rate which does not cycle the limiters faster than once a minute.
+	rateLimitNewKeysBurst = 100
+	// rateLimitBuckets is the number of buckets the keys are hashed into for the metrics,
+	// in order to keep the cardinality of the labels bounded.
+	rateLimitBuckets = 16
+)
+
+var (
+	rateLimitMetricsInit   sync.Once
+	rateLimitLimitedMetric *prometheus.CounterVec
+)
+
+// KeyExtractor returns the key of the caller of a request, e.g. an API key or an IP address,
+// which is used to rate limit each caller separately.
+type KeyExtractor func(*http.Request) string
+
+// IPKeyExtractor returns the IP address of the remote end of the connection.
+// Headers set by proxies, e.g. X-Forwarded-For, are not taken into account, since they can be forged by the caller.
+func IPKeyExtractor(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// HeaderKeyExtractor returns a KeyExtractor which uses the value of the provided header, e.g. "Authorization" for API keys.
+// The header is not verified by the extractor, so a route with an authenticator should rather key on the authenticated
+// caller, e.g. the subject of the claims of the request context.
+func HeaderKeyExtractor(header string) KeyExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+func initRateLimitMetrics() {
+	rateLimitLimitedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "rate_limited_total",
+			Help:      "Total number of HTTP requests rejected by a keyed rate limit, by bucket of the caller key.",
+		},
+		[]string{"path", "bucket"},
+	)
+	prometheus.MustRegister(rateLimitLimitedMetric)
+}
+
+// NewKeyedRateLimitingMiddleware creates a MiddlewareFunc that adds a rate limit to a route for each caller,
+// as identified by the key extractor. Requests with an empty key share a single limit.
+// The callers whose key is not known yet share a global limit until they get their own, so that a caller cannot escape
+// the limit by changing the key of every request, e.g. a forged API key, or evict the limiters of the rest of the callers.
+// Rejected requests are counted per path and per bucket of the key, since labeling the metric with the keys themselves
+// would create a potentially huge number of metric objects.
+func NewKeyedRateLimitingMiddleware(path string, limit rate.Limit, burst int, extractor KeyExtractor) MiddlewareFunc {
+	newKeys := rate.NewLimiter(rate.Every(time.Minute/rateLimitMaxKeys), rateLimitNewKeysBurst)
+	return newKeyedRateLimitingMiddleware(path, limit, burst, extractor, newKeys)
+}
+
+func newKeyedRateLimitingMiddleware(path string, limit rate.Limit, burst int, extractor KeyExtractor, newKeys *rate.Limiter) MiddlewareFunc {
+	// register Prometheus metrics on first use
+	rateLimitMetricsInit.Do(initRateLimitMetrics)
+
+	// the size is a positive constant, so creating the cache cannot fail
+	limiters, _ := lru.New(rateLimitMaxKeys)
+	var mu sync.Mutex
+
+	allow := func(key string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if l, ok := limiters.Get(key); ok {
+			return l.(*rate.Limiter).Allow()
+		}
+		if !newKeys.Allow() {
+			return false
+		}
+		l := rate.NewLimiter(limit, burst)
+		limiters.Add(key, l)
+		return l.Allow()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := extractor(r)
+			if !allow(key) {
+				log.Debug("Limiting requests...")
+				rateLimitLimitedMetric.WithLabelValues(path, rateLimitBucket(key)).Inc()
+				http.Error(w, "Requests greater than limit", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitBucket(key string) string {
+	if key == "" {
+		return "none"
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return strconv.Itoa(int(h.Sum32() % rateLimitBuckets))
+}
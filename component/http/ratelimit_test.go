@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestIPKeyExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "10.0.0.2")
+	assert.Equal(t, "10.0.0.1", IPKeyExtractor(req))
+
+	req.RemoteAddr = "10.0.0.1"
+	assert.Equal(t, "10.0.0.1", IPKeyExtractor(req))
+}
+
+func TestHeaderKeyExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Apikey 123")
+	assert.Equal(t, "Apikey 123", HeaderKeyExtractor("Authorization")(req))
+}
+
+func TestNewKeyedRateLimitingMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	hnd := NewKeyedRateLimitingMiddleware("/keyed", 1, 1, HeaderKeyExtractor("Authorization"))(next)
+
+	serve := func(key string) int {
+		rc := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/keyed", nil)
+		if key != "" {
+			req.Header.Set("Authorization", key)
+		}
+		hnd.ServeHTTP(rc, req)
+		return rc.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, serve("a"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("a"))
+	// every key has its own limit
+	assert.Equal(t, http.StatusAccepted, serve("b"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("b"))
+	// requests without a key share a limit
+	assert.Equal(t, http.StatusAccepted, serve(""))
+	assert.Equal(t, http.StatusTooManyRequests, serve(""))
+
+	expected := 1.0
+	if rateLimitBucket("a") == rateLimitBucket("b") {
+		expected = 2.0
+	}
+	require.Equal(t, expected, testutil.ToFloat64(rateLimitLimitedMetric.WithLabelValues("/keyed", rateLimitBucket("a"))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rateLimitLimitedMetric.WithLabelValues("/keyed", "none")))
+}
+
+func TestNewKeyedRateLimitingMiddleware_NewKeys(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	hnd := newKeyedRateLimitingMiddleware("/keyed-new", 10, 10, HeaderKeyExtractor("Authorization"), rate.NewLimiter(rate.Every(time.Hour), 2))(next)
+
+	serve := func(key string) int {
+		rc := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/keyed-new", nil)
+		req.Header.Set("Authorization", key)
+		hnd.ServeHTTP(rc, req)
+		return rc.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, serve("a"))
+	assert.Equal(t, http.StatusAccepted, serve("b"))
+	// a caller changing its key is limited by the global limit of the new keys
+	assert.Equal(t, http.StatusTooManyRequests, serve("c"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("d"))
+	// the known callers keep their own limit
+	assert.Equal(t, http.StatusAccepted, serve("a"))
+	assert.Equal(t, http.StatusAccepted, serve("b"))
+}
+
+func Test_rateLimitBucket(t *testing.T) {
+	assert.Equal(t, "none", rateLimitBucket(""))
+	assert.Equal(t, rateLimitBucket("key"), rateLimitBucket("key"))
+	for _, key := range []string{"a", "b", "c", "10.0.0.1"} {
+		assert.Contains(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14", "15"}, rateLimitBucket(key))
+	}
+}
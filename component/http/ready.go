@@ -17,9 +17,15 @@ const (
 // ReadyCheckFunc defines a function type for implementing a readiness check.
 type ReadyCheckFunc func() ReadyStatus
 
-func readyCheckRoute(rcf ReadyCheckFunc) *RouteBuilder {
+// readyCheckRoute reports the status of the readiness check. With registered checks,
+// it reports their results as well, and the service is ready only if all of them pass.
+func readyCheckRoute(rcf ReadyCheckFunc, checks ...namedCheck) *RouteBuilder {
 
 	f := func(w http.ResponseWriter, r *http.Request) {
+		if len(checks) > 0 {
+			writeChecksReport(w, r, rcf() != NotReady, "ready", "not_ready", checks)
+			return
+		}
 		switch rcf() {
 		case Ready:
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	panicMetricsInit sync.Once
+	panicMetric      *prometheus.CounterVec
+)
+
+// PanicHook is called with the request, the recovered panic as an error and the stack trace,
+// before the 500 Internal Server Error is returned, e.g. for alerting.
+type PanicHook func(r *http.Request, err error, stack []byte)
+
+func initPanicMetrics() {
+	panicMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "panic_total",
+			Help:      "Total number of panics recovered while handling HTTP requests.",
+		},
+		[]string{"method", "path"},
+	)
+	prometheus.MustRegister(panicMetric)
+}
+
+// newRecoveryMiddleware creates a MiddlewareFunc that recovers from panics, labeling the metric with the path of the route,
+// which is empty for the panics happening outside of the routes.
+func newRecoveryMiddleware(path string, hook PanicHook) MiddlewareFunc {
+	// register Prometheus metrics on first use
+	panicMetricsInit.Do(initPanicMetrics)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					// the connection is aborted on purpose, as the server does itself
+					panic(p)
+				}
+				var err error
+				switch x := p.(type) {
+				case string:
+					err = errors.New(x)
+				case error:
+					err = x
+				default:
+					err = fmt.Errorf("unknown panic: %v", x)
+				}
+				stack := debug.Stack()
+
+				panicMetric.WithLabelValues(r.Method, path).Inc()
+				logger := log.Sub(map[string]interface{}{correlation.ID: getOrSetCorrelationID(r.Header)})
+				logger.Errorf("recovering from an error: %v: %s", err, string(stack))
+				if hook != nil {
+					hook(r, err, stack)
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
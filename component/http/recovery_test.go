@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beatlabs/patron/correlation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecoveryMiddleware_Hook(t *testing.T) {
+	tests := map[string]struct {
+		panic       interface{}
+		expectedErr string
+	}{
+		"string": {panic: "string panic", expectedErr: "string panic"},
+		"error":  {panic: errors.New("error panic"), expectedErr: "error panic"},
+		"other":  {panic: 42, expectedErr: "unknown panic: 42"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var hookErr error
+			var hookStack []byte
+			mw := newRecoveryMiddleware("/recovery", func(_ *http.Request, err error, stack []byte) {
+				hookErr = err
+				hookStack = stack
+			})
+			before := testutil.ToFloat64(panicMetric.WithLabelValues(http.MethodGet, "/recovery"))
+
+			rsp := httptest.NewRecorder()
+			mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				panic(tt.panic)
+			})).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/recovery/1", nil))
+
+			assert.Equal(t, http.StatusInternalServerError, rsp.Code)
+			assert.EqualError(t, hookErr, tt.expectedErr)
+			assert.NotEmpty(t, hookStack)
+			assert.Equal(t, before+1, testutil.ToFloat64(panicMetric.WithLabelValues(http.MethodGet, "/recovery")))
+		})
+	}
+}
+
+func TestNewRecoveryMiddleware_AbortHandler(t *testing.T) {
+	mw := newRecoveryMiddleware("/recovery", nil)
+	hnd := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		hnd.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recovery", nil))
+	})
+}
+
+func TestBuilder_WithPanicHook(t *testing.T) {
+	_, err := NewBuilder().WithPanicHook(nil).Create()
+	assert.EqualError(t, err, "panic hook is nil\n")
+
+	var corID string
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/panic/:id", func(http.ResponseWriter, *http.Request) {
+		panic("route panic")
+	}).MethodGet())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).WithPanicHook(func(r *http.Request, _ error, _ []byte) {
+		corID = r.Header.Get(correlation.HeaderID)
+	}).Create()
+	require.NoError(t, err)
+	before := testutil.ToFloat64(panicMetric.WithLabelValues(http.MethodGet, "/panic/:id"))
+
+	req := httptest.NewRequest(http.MethodGet, "/panic/1", nil)
+	req.Header.Set(correlation.HeaderID, "123")
+	rsp := httptest.NewRecorder()
+	cmp.createHTTPServer().Handler.ServeHTTP(rsp, req)
+	assert.Equal(t, http.StatusInternalServerError, rsp.Code)
+	assert.Equal(t, "123", corID)
+	assert.Equal(t, before+1, testutil.ToFloat64(panicMetric.WithLabelValues(http.MethodGet, "/panic/:id")))
+}
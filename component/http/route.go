@@ -3,14 +3,17 @@ package http
 import (
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/beatlabs/patron/cache"
 	"github.com/beatlabs/patron/component/http/auth"
 	httpcache "github.com/beatlabs/patron/component/http/cache"
 	errs "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
 	"golang.org/x/time/rate"
 )
 
@@ -20,6 +23,22 @@ type Route struct {
 	method      string
 	handler     http.HandlerFunc
 	middlewares []MiddlewareFunc
+	// encoded is set for routes whose responses are encoded by patron from a processor's Response.
+	encoded bool
+	accepts []string
+	openAPI *OpenAPIOperation
+	// cors is set for routes with CORS, in order to answer their preflight requests.
+	cors MiddlewareFunc
+	// routeCache is set for routes with a route cache, in order to purge it.
+	routeCache *httpcache.RouteCache
+	// maxBodySize is set for routes with their own body size limit, which overrides the one of the component.
+	maxBodySize int64
+	// accessLog is set for routes with their own access log, which overrides the one of the component.
+	accessLog bool
+	// acceptVersion is set for routes of an API version selected by the Accept header, which share their path with the other versions.
+	acceptVersion string
+	// payloadLog is set for routes with a payload log, in order to toggle it at runtime.
+	payloadLog *ToggleableMiddleware
 }
 
 // Path returns route path value.
@@ -48,10 +67,30 @@ type RouteBuilder struct {
 	path          string
 	jaegerTrace   bool
 	rateLimiter   *rate.Limiter
+	keyedLimit    rate.Limit
+	keyedBurst    int
+	keyExtractor  KeyExtractor
 	middlewares   []MiddlewareFunc
 	authenticator auth.Authenticator
 	handler       http.HandlerFunc
+	processor     ProcessorFunc
+	accepts       []string
 	routeCache    *httpcache.RouteCache
+	encoded       bool
+	discriminator Discriminator
+	types         map[string]TypeFactory
+	openAPI       *OpenAPIOperation
+	cors          MiddlewareFunc
+	ipFilter      MiddlewareFunc
+	timeout       time.Duration
+	breaker       *circuitbreaker.Setting
+	maxBodySize   int64
+	accessLog     *AccessLogOptions
+	payloadLog    *ToggleableMiddleware
+	coalescing    *CoalescingOptions
+	buckets       []float64
+	version       string
+	acceptVersion bool
 	errors        []error
 }
 
@@ -68,6 +107,110 @@ func (rb *RouteBuilder) WithRateLimiting(limit float64, burst int) *RouteBuilder
 	return rb
 }
 
+// WithKeyedRateLimiting enables route rate limiting for each caller separately, as identified by the key extractor,
+// e.g. IPKeyExtractor or HeaderKeyExtractor. The limit is applied after the authentication of the route, so the extractor
+// can key on the authenticated caller, e.g. the claims the authenticator added to the request context.
+func (rb *RouteBuilder) WithKeyedRateLimiting(limit float64, burst int, extractor KeyExtractor) *RouteBuilder {
+	if extractor == nil {
+		rb.errors = append(rb.errors, errors.New("key extractor is nil"))
+	}
+	rb.keyedLimit = rate.Limit(limit)
+	rb.keyedBurst = burst
+	rb.keyExtractor = extractor
+	return rb
+}
+
+// WithCORS enables cross-origin resource sharing for the route.
+// Preflight requests for the path of the route are answered as well, unless an OPTIONS route is registered for it.
+func (rb *RouteBuilder) WithCORS(opts CORSOptions) *RouteBuilder {
+	c, err := NewCORSMiddleware(opts)
+	if err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	rb.cors = c
+	return rb
+}
+
+// WithIPFilter rejects the requests of the route from clients which are denied, or not allowed, with a 403 Forbidden.
+func (rb *RouteBuilder) WithIPFilter(opts IPFilterOptions) *RouteBuilder {
+	f, err := NewIPFilterMiddleware(opts)
+	if err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	rb.ipFilter = f
+	return rb
+}
+
+// WithTimeout sets a deadline for handling the requests of the route, after which their context is cancelled
+// and they get a 503 Service Unavailable. The responses of the route are buffered, so it is not suitable for streaming routes.
+func (rb *RouteBuilder) WithTimeout(d time.Duration) *RouteBuilder {
+	if d <= 0 {
+		rb.errors = append(rb.errors, errors.New("timeout must be positive"))
+	}
+	rb.timeout = d
+	return rb
+}
+
+// WithCircuitBreaker sheds the load of the route with a 503 Service Unavailable, while the circuit breaker opened by its
+// failing requests is open, e.g. for routes depending on a flaky downstream. Responses with a 5xx status code count as failures.
+// The circuit breaker is named after the method and the path of the route.
+func (rb *RouteBuilder) WithCircuitBreaker(set circuitbreaker.Setting) *RouteBuilder {
+	rb.breaker = &set
+	return rb
+}
+
+// WithMaxBodySize rejects the requests of the route with a body larger than the limit in bytes with a 413 Request Entity Too Large.
+// It overrides the limit set on the HTTP component.
+func (rb *RouteBuilder) WithMaxBodySize(limit int64) *RouteBuilder {
+	if limit <= 0 {
+		rb.errors = append(rb.errors, errors.New("max body size must be positive"))
+	}
+	rb.maxBodySize = limit
+	return rb
+}
+
+// WithAccessLog logs a structured entry for each request of the route, e.g. with a sample rate for high-QPS routes.
+// It overrides the access log set on the HTTP component.
+func (rb *RouteBuilder) WithAccessLog(opts AccessLogOptions) *RouteBuilder {
+	rb.accessLog = &opts
+	return rb
+}
+
+// WithPayloadLog logs the request and response bodies of the route, e.g. for debugging it in production.
+// The payload log is a toggleable middleware with the given name, which can be enabled or disabled at runtime
+// with the admin endpoints under /middlewares, when they are served with Builder.WithMiddlewareToggling.
+func (rb *RouteBuilder) WithPayloadLog(name string, opts PayloadLogOptions) *RouteBuilder {
+	mw, err := NewPayloadLogMiddleware(rb.path, opts)
+	if err != nil {
+		rb.errors = append(rb.errors, err)
+		return rb
+	}
+	tm, err := NewToggleableMiddleware(name, mw, opts.Enabled)
+	if err != nil {
+		rb.errors = append(rb.errors, err)
+		return rb
+	}
+	rb.payloadLog = tm
+	return rb
+}
+
+// WithCoalescing collapses the concurrent identical requests of a GET route into a single execution of its handler,
+// whose response is sent to all of them, e.g. for hot endpoints.
+func (rb *RouteBuilder) WithCoalescing(opts CoalescingOptions) *RouteBuilder {
+	rb.coalescing = &opts
+	return rb
+}
+
+// WithHistogramBuckets sets the buckets of the latency histogram of the route, e.g. for routes much slower or faster than the rest.
+// It overrides the buckets set on the HTTP component.
+func (rb *RouteBuilder) WithHistogramBuckets(buckets ...float64) *RouteBuilder {
+	if err := validateBuckets(buckets); err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	rb.buckets = append([]float64(nil), buckets...)
+	return rb
+}
+
 // WithMiddlewares adds middlewares.
 func (rb *RouteBuilder) WithMiddlewares(mm ...MiddlewareFunc) *RouteBuilder {
 	if len(mm) == 0 {
@@ -86,6 +229,57 @@ func (rb *RouteBuilder) WithAuth(auth auth.Authenticator) *RouteBuilder {
 	return rb
 }
 
+// Accepts restricts the content types of the requests of the route to the provided ones, e.g. "application/json".
+// Requests with any other content type are rejected with a 415 Unsupported Media Type before decoding.
+// The response encoding is negotiated within the same content types, defaulting to the first one,
+// and requests accepting none of them are rejected with a 406 Not Acceptable.
+func (rb *RouteBuilder) Accepts(types ...string) *RouteBuilder {
+	if len(types) == 0 {
+		rb.errors = append(rb.errors, errors.New("accepted content types are empty"))
+	}
+	accepts := make([]string, 0, len(types))
+	for _, t := range types {
+		mt, _, err := mime.ParseMediaType(t)
+		if err != nil {
+			rb.errors = append(rb.errors, fmt.Errorf("invalid content type %q: %w", t, err))
+			continue
+		}
+		if _, _, _, err := getSingleHeaderEncoding(mt); err != nil || mt == "*/*" {
+			rb.errors = append(rb.errors, fmt.Errorf("content type %s is not supported", t))
+			continue
+		}
+		accepts = append(accepts, mt)
+	}
+	rb.accepts = accepts
+	return rb
+}
+
+// WithOpenAPI attaches metadata to the route, which is used in the OpenAPI document of the HTTP component.
+func (rb *RouteBuilder) WithOpenAPI(op OpenAPIOperation) *RouteBuilder {
+	rb.openAPI = &op
+	return rb
+}
+
+// WithPolymorphicDecoding registers the types of the payloads accepted by the route, identified by a discriminator.
+// Decoding the request into a pointer to an empty interface produces a value of the resolved type.
+// Requests with a missing or unknown discriminator are rejected with a 400 Bad Request.
+func (rb *RouteBuilder) WithPolymorphicDecoding(d Discriminator, types map[string]TypeFactory) *RouteBuilder {
+	if d == nil {
+		rb.errors = append(rb.errors, errors.New("discriminator is nil"))
+	}
+	if len(types) == 0 {
+		rb.errors = append(rb.errors, errors.New("type factories are empty"))
+	}
+	for k, f := range types {
+		if f == nil {
+			rb.errors = append(rb.errors, fmt.Errorf("type factory for %s is nil", k))
+		}
+	}
+	rb.discriminator = d
+	rb.types = types
+	return rb
+}
+
 // WithRouteCache adds a cache to the corresponding route
 func (rb *RouteBuilder) WithRouteCache(cache cache.TTLCache, ageBounds httpcache.Age) *RouteBuilder {
 
@@ -174,17 +368,81 @@ func (rb *RouteBuilder) Build() (Route, error) {
 
 	// uses a custom Patron metric for HTTP responses (with complete status code)
 	// it does not use Jaeger/OpenTracing
-	middlewares = append(middlewares, NewRequestObserverMiddleware(rb.method, rb.path))
+	middlewares = append(middlewares, newRequestObserverMiddleware(rb.method, rb.path, rb.version, rb.buckets))
+
+	if rb.accessLog != nil {
+		accessLog, err := NewAccessLogMiddleware(rb.path, *rb.accessLog)
+		if err != nil {
+			return Route{}, err
+		}
+		middlewares = append(middlewares, accessLog)
+	}
+	if rb.payloadLog != nil {
+		middlewares = append(middlewares, rb.payloadLog.Middleware())
+	}
 
+	// CORS comes before rate limiting and authentication, so that their rejections can be read by the browser
+	if rb.cors != nil {
+		middlewares = append(middlewares, rb.cors)
+	}
+	// the IP filter comes right after CORS, so that the rejected requests do not count against the limits of the route
+	if rb.ipFilter != nil {
+		middlewares = append(middlewares, rb.ipFilter)
+	}
+	// the circuit breaker comes before the timeout, so that the timed out requests count as failures
+	if rb.breaker != nil {
+		cb, err := circuitbreaker.New(rb.method+" "+rb.path, *rb.breaker)
+		if err != nil {
+			return Route{}, fmt.Errorf("failed to create circuit breaker: %w", err)
+		}
+		middlewares = append(middlewares, NewCircuitBreakerMiddleware(cb, rb.breaker.RetryTimeout))
+	}
+	// the timeout comes right after CORS, the IP filter and the circuit breaker, so that it covers the whole handling of the request
+	if rb.timeout > 0 {
+		middlewares = append(middlewares, NewTimeoutMiddleware(rb.method, rb.path, rb.timeout))
+	}
+	if rb.maxBodySize > 0 {
+		middlewares = append(middlewares, NewMaxBodySizeMiddleware(rb.maxBodySize))
+	}
 	if rb.rateLimiter != nil {
 		middlewares = append(middlewares, NewRateLimitingMiddleware(rb.rateLimiter))
 	}
 	if rb.authenticator != nil {
 		middlewares = append(middlewares, NewAuthMiddleware(rb.authenticator))
 	}
+	// keyed rate limiting comes after authentication, so that the callers are keyed by their authenticated identity
+	// and the unauthenticated requests do not get a limit of their own
+	if rb.keyExtractor != nil {
+		middlewares = append(middlewares, NewKeyedRateLimitingMiddleware(rb.path, rb.keyedLimit, rb.keyedBurst, rb.keyExtractor))
+	}
 	if len(rb.middlewares) > 0 {
 		middlewares = append(middlewares, rb.middlewares...)
 	}
+	hnd := rb.handler
+	if len(rb.accepts) > 0 {
+		if !rb.encoded {
+			return Route{}, errors.New("accepted content types are not supported on raw routes")
+		}
+		hnd = handler(rb.processor, rb.accepts...)
+	}
+	if rb.discriminator != nil {
+		if !rb.encoded {
+			return Route{}, errors.New("polymorphic decoding is not supported on raw routes")
+		}
+		middlewares = append(middlewares, NewPolymorphicDecodingMiddleware(rb.discriminator, rb.types))
+	}
+	// coalescing comes after the rest of the middlewares, so that every request is authenticated and limited on its own,
+	// and right before the cache, so that the requests missing it are coalesced
+	if rb.coalescing != nil {
+		if rb.method != http.MethodGet {
+			return Route{}, errors.New("cannot apply coalescing to a route with any method other than GET")
+		}
+		coalescing, err := NewCoalescingMiddleware(rb.path, *rb.coalescing)
+		if err != nil {
+			return Route{}, err
+		}
+		middlewares = append(middlewares, coalescing)
+	}
 	// cache middleware is always last, so that it caches only the headers of the handler
 	if rb.routeCache != nil {
 		if rb.method != http.MethodGet {
@@ -193,16 +451,32 @@ func (rb *RouteBuilder) Build() (Route, error) {
 		middlewares = append(middlewares, NewCachingMiddleware(rb.routeCache))
 	}
 
-	return Route{
+	route := Route{
 		path:        rb.path,
 		method:      rb.method,
-		handler:     rb.handler,
+		handler:     hnd,
 		middlewares: middlewares,
-	}, nil
+		encoded:     rb.encoded,
+		accepts:     rb.accepts,
+		openAPI:     rb.openAPI,
+		cors:        rb.cors,
+		routeCache:  rb.routeCache,
+		maxBodySize: rb.maxBodySize,
+		accessLog:   rb.accessLog != nil,
+		payloadLog:  rb.payloadLog,
+	}
+	if rb.acceptVersion {
+		route.acceptVersion = rb.version
+	}
+	return route, nil
 }
 
 // NewFileServer constructor.
-func NewFileServer(path string, assetsDir string, fallbackPath string) *RouteBuilder {
+// File server routes are observed like any other route, using the route pattern as the metric label
+// and, when WithTrace is used, as the span operation name, instead of the path of each asset.
+// Requests for assets that do not exist are served with the fallback file and counted separately.
+// Assets are served with support for range and conditional requests, and an ETag header.
+func NewFileServer(path string, assetsDir string, fallbackPath string, oo ...FileServerOptionFunc) *RouteBuilder {
 	var ee []error
 
 	if path == "" {
@@ -231,16 +505,28 @@ func NewFileServer(path string, assetsDir string, fallbackPath string) *RouteBui
 		}
 	}
 
+	fs := &fileServer{maxAges: make(map[string]time.Duration)}
+	for _, o := range oo {
+		if err := o(fs); err != nil {
+			ee = append(ee, err)
+		}
+	}
+
+	// register Prometheus metrics on first use
+	fileServerMetricsInit.Do(initFileServerMetrics)
+
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		params := ExtractParams(r)
 
 		// get the absolute path to prevent directory traversal
-		path := fmt.Sprintf("%s%s", assetsDir, params["path"])
+		assetPath := fmt.Sprintf("%s%s", assetsDir, params["path"])
 
 		// check whether a file exists at the given path
-		info, err := os.Stat(path)
-		if os.IsNotExist(err) || info.IsDir() {
+		info, err := os.Stat(assetPath)
+		if os.IsNotExist(err) || (err == nil && info.IsDir() && !fs.listing) {
 			// file does not exist, serve index.html
+			// the route pattern is used as a label, in order to keep the metric cardinality bounded
+			fileServerAssetNotFoundMetric.WithLabelValues(path).Inc()
 			http.ServeFile(w, r, fallbackPath)
 			return
 		} else if err != nil {
@@ -250,8 +536,8 @@ func NewFileServer(path string, assetsDir string, fallbackPath string) *RouteBui
 			return
 		}
 
-		// otherwise, use server the specific file directly from the filesystem.
-		http.ServeFile(w, r, path)
+		// otherwise, serve the specific file, or list the directory, directly from the filesystem.
+		fs.serve(w, r, assetPath, info)
 	}
 
 	return &RouteBuilder{path: path, errors: ee, handler: handler, method: http.MethodGet}
@@ -272,6 +558,22 @@ func NewRawRouteBuilder(path string, handler http.HandlerFunc) *RouteBuilder {
 	return &RouteBuilder{path: path, errors: ee, handler: handler}
 }
 
+// NewHandlerRouteBuilder constructor, which mounts an existing http.Handler, e.g. a chi or gorilla router,
+// as a raw route getting the tracing, metrics and middlewares of patron.
+// Routers handling several paths can be mounted with a catch-all path, e.g. /legacy/*path.
+func NewHandlerRouteBuilder(method, path string, handler http.Handler) *RouteBuilder {
+	var hnd http.HandlerFunc
+	if handler != nil {
+		hnd = handler.ServeHTTP
+	}
+	rb := NewRawRouteBuilder(path, hnd)
+	if method == "" {
+		rb.errors = append(rb.errors, errors.New("method is empty"))
+	}
+	rb.method = method
+	return rb
+}
+
 // NewRouteBuilder constructor.
 func NewRouteBuilder(path string, processor ProcessorFunc) *RouteBuilder {
 
@@ -285,7 +587,7 @@ func NewRouteBuilder(path string, processor ProcessorFunc) *RouteBuilder {
 		ee = append(ee, errors.New("processor is nil"))
 	}
 
-	return &RouteBuilder{path: path, errors: ee, handler: handler(processor)}
+	return &RouteBuilder{path: path, errors: ee, handler: handler(processor), processor: processor, encoded: true}
 }
 
 // NewGetRouteBuilder constructor
@@ -361,10 +663,14 @@ func (rb *RoutesBuilder) Append(builder *RouteBuilder) *RoutesBuilder {
 
 // Build the routes.
 func (rb *RoutesBuilder) Build() ([]Route, error) {
+	routes, err := combineAcceptVersions(rb.routes)
+	if err != nil {
+		rb.errors = append(rb.errors, err)
+	}
 
-	duplicates := make(map[string]struct{}, len(rb.routes))
+	duplicates := make(map[string]struct{}, len(routes))
 
-	for _, r := range rb.routes {
+	for _, r := range routes {
 		key := strings.ToLower(r.method + "-" + r.path)
 		_, ok := duplicates[key]
 		if ok {
@@ -378,7 +684,7 @@ func (rb *RoutesBuilder) Build() ([]Route, error) {
 		return nil, errs.Aggregate(rb.errors...)
 	}
 
-	return rb.routes, nil
+	return routes, nil
 }
 
 // NewRoutesBuilder constructor.
@@ -555,6 +555,17 @@ func (t *testingCache) SetTTL(key string, value interface{}, ttl time.Duration)
 	return nil
 }
 
+func (t *testingCache) RemoveMatching(_ context.Context, pattern string) (int, error) {
+	removed := 0
+	for k := range t.cache {
+		if cache.MatchPattern(pattern, k) {
+			delete(t.cache, k)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 func (t *testingCache) size() int {
 	return len(t.cache)
 }
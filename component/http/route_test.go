@@ -10,6 +10,8 @@ import (
 
 	"github.com/beatlabs/patron/component/http/auth"
 	"github.com/beatlabs/patron/component/http/cache"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/protobuf"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -170,6 +172,38 @@ func TestRouteBuilder_WithRateLimiting(t *testing.T) {
 
 }
 
+func TestRouteBuilder_WithKeyedRateLimiting(t *testing.T) {
+	mockHandler := func(http.ResponseWriter, *http.Request) {}
+	rb := NewRawRouteBuilder("/", mockHandler).WithKeyedRateLimiting(1, 1, IPKeyExtractor)
+	assert.Len(t, rb.errors, 0)
+	assert.NotNil(t, rb.keyExtractor)
+
+	rb = NewRawRouteBuilder("/", mockHandler).WithKeyedRateLimiting(1, 1, nil)
+	assert.Len(t, rb.errors, 1)
+	assert.EqualError(t, rb.errors[0], "key extractor is nil")
+}
+
+func TestRouteBuilder_WithKeyedRateLimiting_Auth(t *testing.T) {
+	var keys []interface{}
+	extractor := func(r *http.Request) string {
+		keys = append(keys, r.Context().Value(ctxKey{}))
+		return "caller"
+	}
+	serve := func(a MockAuthenticator) int {
+		route, err := NewRawRouteBuilder("/keyed-auth", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusAccepted) }).
+			MethodGet().WithAuth(mockContextAuthenticator{a}).WithKeyedRateLimiting(1, 1, extractor).Build()
+		require.NoError(t, err)
+		rc := httptest.NewRecorder()
+		MiddlewareChain(route.Handler(), route.Middlewares()...).ServeHTTP(rc, httptest.NewRequest(http.MethodGet, "/keyed-auth", nil))
+		return rc.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(MockAuthenticator{success: false}))
+	assert.Empty(t, keys, "unauthenticated requests are not keyed")
+	assert.Equal(t, http.StatusAccepted, serve(MockAuthenticator{success: true}))
+	assert.Equal(t, []interface{}{"value"}, keys, "the key is extracted from the authenticated request")
+}
+
 func TestRouteBuilder_WithRouteCacheNil(t *testing.T) {
 	rb := NewRawRouteBuilder("/", func(writer http.ResponseWriter, request *http.Request) {}).
 		WithRouteCache(nil, cache.Age{Max: 1})
@@ -178,6 +212,50 @@ func TestRouteBuilder_WithRouteCacheNil(t *testing.T) {
 	assert.EqualError(t, rb.errors[0], "route cache is nil")
 }
 
+func TestRouteBuilder_Accepts(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) { return nil, nil }
+	raw := func(http.ResponseWriter, *http.Request) {}
+
+	tests := map[string]struct {
+		rb          *RouteBuilder
+		expected    []string
+		expectedErr string
+	}{
+		"success": {
+			rb:       NewPostRouteBuilder("/", proc).Accepts(patronjson.TypeCharset, protobuf.Type),
+			expected: []string{patronjson.Type, protobuf.Type},
+		},
+		"empty": {
+			rb:          NewPostRouteBuilder("/", proc).Accepts(),
+			expectedErr: "accepted content types are empty\n",
+		},
+		"unsupported": {
+			rb:          NewPostRouteBuilder("/", proc).Accepts("text/csv"),
+			expectedErr: "content type text/csv is not supported\n",
+		},
+		"invalid": {
+			rb:          NewPostRouteBuilder("/", proc).Accepts(""),
+			expectedErr: "invalid content type \"\": mime: no media type\n",
+		},
+		"raw route": {
+			rb:          NewRawRouteBuilder("/", raw).MethodPost().Accepts(patronjson.Type),
+			expectedErr: "accepted content types are not supported on raw routes",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			route, err := tt.rb.Build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, route.handler)
+			assert.Equal(t, tt.expected, tt.rb.accepts)
+		})
+	}
+}
+
 func TestRouteBuilder_Build(t *testing.T) {
 	mockAuth := &MockAuthenticator{}
 	mockProcessor := func(context.Context, *Request) (*Response, error) { return nil, nil }
@@ -241,6 +319,46 @@ func TestNewRawRouteBuilder(t *testing.T) {
 	}
 }
 
+func TestNewHandlerRouteBuilder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/legacy/users", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	tests := map[string]struct {
+		method      string
+		path        string
+		handler     http.Handler
+		expectedErr string
+	}{
+		"success":         {method: http.MethodGet, path: "/legacy/*path", handler: mux},
+		"invalid method":  {method: "", path: "/legacy/*path", handler: mux, expectedErr: "method is empty"},
+		"invalid path":    {method: http.MethodGet, path: "", handler: mux, expectedErr: "path is empty"},
+		"invalid handler": {method: http.MethodGet, path: "/legacy/*path", handler: nil, expectedErr: "handler is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rb := NewHandlerRouteBuilder(tt.method, tt.path, tt.handler)
+
+			if tt.expectedErr != "" {
+				assert.Len(t, rb.errors, 1)
+				assert.EqualError(t, rb.errors[0], tt.expectedErr)
+				return
+			}
+			assert.Len(t, rb.errors, 0)
+			route, err := rb.Build()
+			require.NoError(t, err)
+			assert.Equal(t, http.MethodGet, route.Method())
+
+			cmp, err := NewBuilder().WithRoutesBuilder(NewRoutesBuilder().Append(rb)).Create()
+			require.NoError(t, err)
+			rsp := httptest.NewRecorder()
+			cmp.createHTTPServer().Handler.ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/legacy/users", nil))
+			assert.Equal(t, http.StatusAccepted, rsp.Code)
+		})
+	}
+}
+
 func TestNewRouteBuilder(t *testing.T) {
 	mockProcessor := func(context.Context, *Request) (*Response, error) { return nil, nil }
 	type args struct {
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// TrailingSlash defines how the requests whose path differs from the path of a route only by a trailing slash are handled.
+type TrailingSlash int
+
+const (
+	// TrailingSlashRedirect redirects the requests to the path of the route, which is the default.
+	TrailingSlashRedirect TrailingSlash = iota
+	// TrailingSlashStrict does not match the requests, which get a 404 Not Found.
+	TrailingSlashStrict
+	// TrailingSlashIgnore serves the requests with the route, as if their path was the path of the route.
+	TrailingSlashIgnore
+)
+
+// PathCase defines how the requests whose path matches the path of a route only case-insensitively, or after cleaning it,
+// e.g. removing duplicate slashes and dot segments, are handled.
+type PathCase int
+
+const (
+	// PathCaseRedirect redirects the requests to the path of the route, which is the default.
+	PathCaseRedirect PathCase = iota
+	// PathCaseStrict does not match the requests, which get a 404 Not Found.
+	PathCaseStrict
+	// PathCaseInsensitive serves the requests with the route, as if their path was the path of the route.
+	PathCaseInsensitive
+)
+
+// RouterOptions of the matching of the requests to the routes, e.g. for migrating existing APIs which rely on a different matching.
+// Redirects are sent with a 301 Moved Permanently for GET requests and with a 307 Temporary Redirect otherwise.
+type RouterOptions struct {
+	TrailingSlash TrailingSlash
+	PathCase      PathCase
+}
+
+func (o RouterOptions) validate() error {
+	if o.TrailingSlash < TrailingSlashRedirect || o.TrailingSlash > TrailingSlashIgnore {
+		return errors.New("invalid trailing slash option")
+	}
+	if o.PathCase < PathCaseRedirect || o.PathCase > PathCaseInsensitive {
+		return errors.New("invalid path case option")
+	}
+	return nil
+}
+
+// newRouter creates a router, which matches the requests according to the options.
+func newRouter(opts RouterOptions) *httprouter.Router {
+	router := httprouter.New()
+	router.RedirectTrailingSlash = opts.TrailingSlash == TrailingSlashRedirect
+	router.RedirectFixedPath = opts.PathCase == PathCaseRedirect
+	return router
+}
+
+// pathMatcher serves the requests whose path matches the path of a route, ignoring the trailing slash or the case according to the options,
+// with the route, as if their path was the path of the route.
+type pathMatcher struct {
+	router *httprouter.Router
+	// probe is a router with the paths of the routes, which finds the path of the route matching a request by redirecting it.
+	probe *httprouter.Router
+}
+
+// newPathMatcher wraps the router in a path matcher, if the options ignore the trailing slash or the case.
+func newPathMatcher(router *httprouter.Router, routes []Route, opts RouterOptions) http.Handler {
+	if opts.TrailingSlash != TrailingSlashIgnore && opts.PathCase != PathCaseInsensitive {
+		return router
+	}
+
+	probe := httprouter.New()
+	probe.RedirectTrailingSlash = opts.TrailingSlash == TrailingSlashIgnore
+	probe.RedirectFixedPath = opts.PathCase == PathCaseInsensitive
+	probe.HandleMethodNotAllowed = false
+	probe.HandleOPTIONS = false
+	noop := func(http.ResponseWriter, *http.Request, httprouter.Params) {}
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.method + " " + route.path
+		if registered[key] {
+			continue
+		}
+		registered[key] = true
+		probe.Handle(route.method, route.path, noop)
+	}
+	return &pathMatcher{router: router, probe: probe}
+}
+
+func (pm *pathMatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, _, _ := pm.router.Lookup(r.Method, r.URL.Path); h == nil {
+		// the probe sets the path of the route on the request it redirects
+		pr := r.Clone(r.Context())
+		rw := &probeWriter{header: make(http.Header)}
+		pm.probe.ServeHTTP(rw, pr)
+		if rw.code == http.StatusMovedPermanently || rw.code == http.StatusTemporaryRedirect {
+			r = r.Clone(r.Context())
+			r.URL.Path = pr.URL.Path
+			r.URL.RawPath = ""
+		}
+	}
+	pm.router.ServeHTTP(w, r)
+}
+
+// probeWriter records the status code of the responses of the probe.
+type probeWriter struct {
+	header http.Header
+	code   int
+}
+
+func (pw *probeWriter) Header() http.Header {
+	return pw.header
+}
+
+func (pw *probeWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (pw *probeWriter) WriteHeader(code int) {
+	pw.code = code
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_WithRouterOptions(t *testing.T) {
+	_, err := NewBuilder().WithRouterOptions(RouterOptions{TrailingSlash: 3, PathCase: -1}).Create()
+	assert.EqualError(t, err, "invalid trailing slash option\n")
+	_, err = NewBuilder().WithRouterOptions(RouterOptions{PathCase: 3}).Create()
+	assert.EqualError(t, err, "invalid path case option\n")
+}
+
+func TestComponent_RouterOptions(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		params := ExtractParams(r)
+		_, _ = w.Write([]byte(r.URL.Path + " " + params["id"]))
+	}
+	rb := func() *RoutesBuilder {
+		return NewRoutesBuilder().
+			Append(NewRawRouteBuilder("/users", echo).MethodGet()).
+			Append(NewRawRouteBuilder("/users/:id", echo).MethodGet()).
+			Append(NewRawRouteBuilder("/orders/", echo).MethodPost())
+	}
+
+	tests := map[string]struct {
+		opts             RouterOptions
+		method           string
+		path             string
+		expectedCode     int
+		expectedLocation string
+		expectedBody     string
+	}{
+		"exact match": {method: http.MethodGet, path: "/users", expectedCode: http.StatusOK, expectedBody: "/users "},
+		"default trailing slash redirect": {
+			method: http.MethodGet, path: "/users/", expectedCode: http.StatusMovedPermanently, expectedLocation: "/users",
+		},
+		"default trailing slash redirect of POST": {
+			method: http.MethodPost, path: "/orders", expectedCode: http.StatusTemporaryRedirect, expectedLocation: "/orders/",
+		},
+		"default case redirect": {
+			method: http.MethodGet, path: "/USERS", expectedCode: http.StatusMovedPermanently, expectedLocation: "/users",
+		},
+		"strict slash": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashStrict}, method: http.MethodGet, path: "/users/", expectedCode: http.StatusNotFound,
+		},
+		"ignored slash": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore}, method: http.MethodGet, path: "/users/", expectedCode: http.StatusOK, expectedBody: "/users ",
+		},
+		"ignored slash of POST": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore}, method: http.MethodPost, path: "/orders", expectedCode: http.StatusOK, expectedBody: "/orders/ ",
+		},
+		"strict case": {
+			opts: RouterOptions{PathCase: PathCaseStrict}, method: http.MethodGet, path: "/USERS", expectedCode: http.StatusNotFound,
+		},
+		"case insensitive": {
+			opts: RouterOptions{PathCase: PathCaseInsensitive}, method: http.MethodGet, path: "/USERS", expectedCode: http.StatusOK, expectedBody: "/users ",
+		},
+		"case insensitive with parameter": {
+			opts: RouterOptions{PathCase: PathCaseInsensitive}, method: http.MethodGet, path: "/Users/AbC", expectedCode: http.StatusOK, expectedBody: "/users/AbC AbC",
+		},
+		"case insensitive with strict slash": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashStrict, PathCase: PathCaseInsensitive}, method: http.MethodGet, path: "/USERS/", expectedCode: http.StatusNotFound,
+		},
+		"case insensitive with ignored slash": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore, PathCase: PathCaseInsensitive}, method: http.MethodGet, path: "/USERS/", expectedCode: http.StatusOK, expectedBody: "/users ",
+		},
+		"ignored slash with case redirect": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore}, method: http.MethodGet, path: "/USERS", expectedCode: http.StatusMovedPermanently, expectedLocation: "/users",
+		},
+		"not found": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore, PathCase: PathCaseInsensitive}, method: http.MethodGet, path: "/missing", expectedCode: http.StatusNotFound,
+		},
+		"method not allowed": {
+			opts: RouterOptions{TrailingSlash: TrailingSlashIgnore, PathCase: PathCaseInsensitive}, method: http.MethodDelete, path: "/users", expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cmp, err := NewBuilder().WithRoutesBuilder(rb()).WithRouterOptions(tt.opts).Create()
+			require.NoError(t, err)
+
+			rsp := httptest.NewRecorder()
+			cmp.createServers()[0].Handler.ServeHTTP(rsp, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedLocation, rsp.Header().Get("Location"))
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			}
+		})
+	}
+}
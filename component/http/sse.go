@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	sseContentType = "text/event-stream"
+	// DefaultSSEHeartbeat is the recommended interval of heartbeats, which keeps idle connections alive through proxies.
+	DefaultSSEHeartbeat = 15 * time.Second
+)
+
+// SSEEvent definition of a server-sent event.
+// Multi-line data is sent as multiple data lines, which are joined again by the client.
+// The ID and the event type are single-line fields, so they cannot contain line breaks.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// SSEFunc pushes events to the stream until it returns.
+// The context is cancelled when the client disconnects, in which case the function should return.
+type SSEFunc func(ctx context.Context, stream *SSEStream) error
+
+type sseResponse struct {
+	fn        SSEFunc
+	heartbeat time.Duration
+}
+
+// NewSSEResponse creates a response which streams server-sent events, pushed by the provided function.
+// A heartbeat comment is sent at the provided interval while the stream is open; a zero interval disables heartbeats.
+// Since the stream is kept open until the function returns, the write timeout of the HTTP component has to be set accordingly.
+func NewSSEResponse(fn SSEFunc, heartbeat time.Duration) *Response {
+	rsp := NewResponse(nil)
+	rsp.sse = &sseResponse{fn: fn, heartbeat: heartbeat}
+	return rsp
+}
+
+// SSEStream pushes server-sent events to the client, flushing every event.
+// It is safe for concurrent use.
+type SSEStream struct {
+	ctx context.Context
+	mu  sync.Mutex
+	w   http.ResponseWriter
+	fl  http.Flusher
+}
+
+// Send an event to the client. It returns an error if the client has disconnected or the event could not be written,
+// or if the ID or the event type contain line breaks, which would inject fields or events into the stream.
+func (s *SSEStream) Send(evt SSEEvent) error {
+	if strings.ContainsAny(evt.ID, "\r\n\x00") {
+		return errors.New("event ID contains line breaks or NUL characters")
+	}
+	if strings.ContainsAny(evt.Event, "\r\n") {
+		return errors.New("event type contains line breaks")
+	}
+
+	var sb strings.Builder
+	if evt.ID != "" {
+		sb.WriteString("id: " + evt.ID + "\n")
+	}
+	if evt.Event != "" {
+		sb.WriteString("event: " + evt.Event + "\n")
+	}
+	if evt.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(evt.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range splitSSELines(evt.Data) {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+
+	return s.write(sb.String())
+}
+
+// Comment sends a comment to the client, which is ignored by it, e.g. for keeping the connection alive.
+func (s *SSEStream) Comment(comment string) error {
+	var sb strings.Builder
+	for _, line := range splitSSELines(comment) {
+		sb.WriteString(": " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return s.write(sb.String())
+}
+
+// splitSSELines splits the value on the line breaks of the protocol, i.e. CRLF, LF and CR.
+func splitSSELines(v string) []string {
+	return strings.Split(strings.ReplaceAll(strings.ReplaceAll(v, "\r\n", "\n"), "\r", "\n"), "\n")
+}
+
+func (s *SSEStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ctx.Err(); err != nil {
+		return fmt.Errorf("stream is closed: %w", err)
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	s.fl.Flush()
+	return nil
+}
+
+func handleSSE(ctx context.Context, w http.ResponseWriter, rsp *Response) error {
+	fl, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("response writer does not support flushing")
+	}
+
+	w.Header().Set(encoding.ContentTypeHeader, sseContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	propagateHeaders(rsp.Header, w.Header())
+	w.WriteHeader(http.StatusOK)
+	fl.Flush()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := &SSEStream{ctx: ctx, w: w, fl: fl}
+
+	var wg sync.WaitGroup
+	if rsp.sse.heartbeat > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(rsp.sse.heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := stream.Comment("heartbeat"); err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	err := rsp.sse.fn(ctx, stream)
+	// the stream has to be closed before the handler returns, since the response writer cannot be used afterwards
+	stream.mu.Lock()
+	cancel()
+	stream.mu.Unlock()
+	wg.Wait()
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.FromContext(ctx).Errorf("failed to stream server-sent events: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEStream_Send(t *testing.T) {
+	tests := map[string]struct {
+		evt      SSEEvent
+		expected string
+	}{
+		"data only":   {evt: SSEEvent{Data: "hello"}, expected: "data: hello\n\n"},
+		"multi-line":  {evt: SSEEvent{Data: "hello\nworld"}, expected: "data: hello\ndata: world\n\n"},
+		"cr and crlf": {evt: SSEEvent{Data: "a\rb\r\nc"}, expected: "data: a\ndata: b\ndata: c\n\n"},
+		"all fields": {
+			evt:      SSEEvent{ID: "1", Event: "greeting", Data: "hello", Retry: 2 * time.Second},
+			expected: "id: 1\nevent: greeting\nretry: 2000\ndata: hello\n\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			stream := &SSEStream{ctx: context.Background(), w: rsp, fl: rsp}
+			require.NoError(t, stream.Send(tt.evt))
+			assert.Equal(t, tt.expected, rsp.Body.String())
+			assert.True(t, rsp.Flushed)
+		})
+	}
+}
+
+func TestSSEStream_Send_LineBreaks(t *testing.T) {
+	tests := map[string]struct {
+		evt    SSEEvent
+		expErr string
+	}{
+		"id with lf":    {evt: SSEEvent{ID: "1\ndata: injected", Data: "hello"}, expErr: "event ID contains line breaks or NUL characters"},
+		"id with cr":    {evt: SSEEvent{ID: "1\r", Data: "hello"}, expErr: "event ID contains line breaks or NUL characters"},
+		"id with nul":   {evt: SSEEvent{ID: "1\x00", Data: "hello"}, expErr: "event ID contains line breaks or NUL characters"},
+		"event with lf": {evt: SSEEvent{Event: "greeting\n\nevent: other", Data: "hello"}, expErr: "event type contains line breaks"},
+		"event with cr": {evt: SSEEvent{Event: "greeting\r", Data: "hello"}, expErr: "event type contains line breaks"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			stream := &SSEStream{ctx: context.Background(), w: rsp, fl: rsp}
+			assert.EqualError(t, stream.Send(tt.evt), tt.expErr)
+			assert.Empty(t, rsp.Body.String())
+		})
+	}
+}
+
+func TestSSEStream_Comment(t *testing.T) {
+	rsp := httptest.NewRecorder()
+	stream := &SSEStream{ctx: context.Background(), w: rsp, fl: rsp}
+	require.NoError(t, stream.Comment("first\r\ndata: second"))
+	assert.Equal(t, ": first\n: data: second\n\n", rsp.Body.String())
+}
+
+func TestSSEStream_SendClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rsp := httptest.NewRecorder()
+	stream := &SSEStream{ctx: ctx, w: rsp, fl: rsp}
+	assert.EqualError(t, stream.Send(SSEEvent{Data: "hello"}), "stream is closed: context canceled")
+	assert.Empty(t, rsp.Body.String())
+}
+
+func TestNewSSEResponse(t *testing.T) {
+	chDisconnected := make(chan struct{})
+	proc := func(context.Context, *Request) (*Response, error) {
+		return NewSSEResponse(func(ctx context.Context, stream *SSEStream) error {
+			for {
+				if err := stream.Send(SSEEvent{Event: "tick", Data: "data"}); err != nil {
+					close(chDisconnected)
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					close(chDisconnected)
+					return ctx.Err()
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+		}, 5*time.Millisecond), nil
+	}
+
+	rb := NewRoutesBuilder().Append(NewGetRouteBuilder("/events", proc))
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+	srv := httptest.NewServer(cmp.createHTTPServer().Handler)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rsp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer func() { _ = rsp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, sseContentType, rsp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", rsp.Header.Get("Cache-Control"))
+	assert.Empty(t, rsp.Header.Get("Content-Encoding"))
+
+	var events, heartbeats int
+	sc := bufio.NewScanner(rsp.Body)
+	for sc.Scan() && (events < 2 || heartbeats < 1) {
+		switch {
+		case sc.Text() == "event: tick":
+			events++
+		case strings.HasPrefix(sc.Text(), ": heartbeat"):
+			heartbeats++
+		}
+	}
+
+	cancel()
+	select {
+	case <-chDisconnected:
+	case <-time.After(time.Second):
+		assert.Fail(t, "client disconnect was not detected")
+	}
+}
+
+func Test_handleSSE_NoFlusher(t *testing.T) {
+	rsp := NewSSEResponse(func(context.Context, *SSEStream) error { return nil }, 0)
+	err := handleSSE(context.Background(), struct{ http.ResponseWriter }{httptest.NewRecorder()}, rsp)
+	assert.Equal(t, errors.New("response writer does not support flushing"), err)
+}
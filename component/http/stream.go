@@ -0,0 +1,82 @@
+package http
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/beatlabs/patron/encoding"
+	"github.com/beatlabs/patron/log"
+)
+
+// StreamFunc writes the body of a streaming response. The writer implements http.Flusher,
+// so that the chunks written so far can be sent to the client right away.
+type StreamFunc func(w io.Writer) error
+
+type streamResponse struct {
+	contentType string
+	fn          StreamFunc
+}
+
+// NewStreamResponse creates a response whose body is written by the provided function as it is produced,
+// with chunked transfer encoding, instead of being encoded from a payload and buffered in memory, e.g. for large exports.
+// If the function fails before writing anything, the error is handled like the ones of the processor,
+// otherwise the connection is aborted, so that the client does not mistake the partial body for a complete one.
+func NewStreamResponse(contentType string, fn StreamFunc) *Response {
+	rsp := NewResponse(nil)
+	rsp.stream = &streamResponse{contentType: contentType, fn: fn}
+	return rsp
+}
+
+// streamWriter writes the headers of the response on the first write, so that an error can still be sent before it.
+type streamWriter struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	rsp     *Response
+	written bool
+}
+
+func (sw *streamWriter) writeHeader() {
+	if sw.written {
+		return
+	}
+	sw.written = true
+	sw.w.Header().Set(encoding.ContentTypeHeader, sw.rsp.stream.contentType)
+	propagateHeaders(sw.rsp.Header, sw.w.Header())
+	switch {
+	case sw.rsp.code != 0:
+		sw.w.WriteHeader(sw.rsp.code)
+	case sw.r.Method == http.MethodPost:
+		sw.w.WriteHeader(http.StatusCreated)
+	default:
+		sw.w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	sw.writeHeader()
+	return sw.w.Write(p)
+}
+
+// Flush sends the chunks written so far to the client.
+func (sw *streamWriter) Flush() {
+	sw.writeHeader()
+	if fl, ok := sw.w.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+func handleStream(logger log.Logger, w http.ResponseWriter, r *http.Request, rsp *Response, enc encoding.EncodeFunc) {
+	sw := &streamWriter{w: w, r: r, rsp: rsp}
+	err := rsp.stream.fn(sw)
+	if err == nil {
+		sw.writeHeader()
+		return
+	}
+	if !sw.written {
+		handleError(logger, w, enc, err)
+		return
+	}
+	logger.Errorf("failed to stream response: %v", err)
+	// the status code has been sent already, so the connection is aborted in order to signal the failure to the client
+	panic(http.ErrAbortHandler)
+}
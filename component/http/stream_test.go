@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_handler_Stream(t *testing.T) {
+	lines := func(w io.Writer) error {
+		for i := 0; i < 3; i++ {
+			if _, err := fmt.Fprintf(w, "line %d\n", i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := map[string]struct {
+		method       string
+		rsp          *Response
+		err          error
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		"success": {
+			method: http.MethodGet, rsp: NewStreamResponse("text/csv", lines),
+			expectedCode: http.StatusOK, expectedType: "text/csv", expectedBody: "line 0\nline 1\nline 2\n",
+		},
+		"post": {
+			method: http.MethodPost, rsp: NewStreamResponse("text/csv", lines),
+			expectedCode: http.StatusCreated, expectedType: "text/csv", expectedBody: "line 0\nline 1\nline 2\n",
+		},
+		"explicit code": {
+			method: http.MethodGet, rsp: func() *Response {
+				rsp := NewStreamResponse("text/csv", lines)
+				rsp.code = http.StatusPartialContent
+				return rsp
+			}(),
+			expectedCode: http.StatusPartialContent, expectedType: "text/csv", expectedBody: "line 0\nline 1\nline 2\n",
+		},
+		"empty body": {
+			method: http.MethodGet, rsp: NewStreamResponse("text/csv", func(io.Writer) error { return nil }),
+			expectedCode: http.StatusOK, expectedType: "text/csv",
+		},
+		"error before writing": {
+			method: http.MethodGet, rsp: NewStreamResponse("text/csv", func(io.Writer) error { return errors.New("TEST") }),
+			expectedCode: http.StatusInternalServerError, expectedType: "text/plain; charset=utf-8", expectedBody: "Internal Server Error\n",
+		},
+		"HTTP error before writing": {
+			method: http.MethodGet, rsp: NewStreamResponse("text/csv", func(io.Writer) error { return NewNotFoundError() }),
+			expectedCode: http.StatusNotFound, expectedType: "application/json; charset=utf-8", expectedBody: `"Not Found"`,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			proc := func(context.Context, *Request) (*Response, error) {
+				return tt.rsp, nil
+			}
+			rsp := httptest.NewRecorder()
+			handler(proc)(rsp, httptest.NewRequest(tt.method, "/stream", nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedType, rsp.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+		})
+	}
+}
+
+func Test_handler_Stream_ErrorAfterWriting(t *testing.T) {
+	proc := func(context.Context, *Request) (*Response, error) {
+		return NewStreamResponse("text/csv", func(w io.Writer) error {
+			_, _ = w.Write([]byte("partial"))
+			return errors.New("TEST")
+		}), nil
+	}
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler(proc)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	})
+}
+
+func TestStreamResponse_Chunked(t *testing.T) {
+	chunk := strings.Repeat("a", 1024)
+	proc := func(context.Context, *Request) (*Response, error) {
+		return NewStreamResponse("text/plain", func(w io.Writer) error {
+			for i := 0; i < 100; i++ {
+				if _, err := w.Write([]byte(chunk)); err != nil {
+					return err
+				}
+				w.(http.Flusher).Flush()
+			}
+			return nil
+		}), nil
+	}
+	srv := httptest.NewServer(handler(proc))
+	defer srv.Close()
+
+	rsp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, []string{"chunked"}, rsp.TransferEncoding)
+	b, err := ioutil.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	assert.Len(t, b, 100*1024)
+}
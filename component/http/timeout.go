@@ -0,0 +1,142 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/encoding"
+	patronjson "github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	timeoutMetricsInit sync.Once
+	timeoutMetric      *prometheus.CounterVec
+)
+
+func initTimeoutMetrics() {
+	timeoutMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "http",
+			Name:      "route_timeouts_total",
+			Help:      "Total number of HTTP requests which exceeded the timeout of their route.",
+		},
+		[]string{"method", "path"},
+	)
+	prometheus.MustRegister(timeoutMetric)
+}
+
+type timeoutReport struct {
+	Error   string `json:"error"`
+	Timeout string `json:"timeout"`
+}
+
+// NewTimeoutMiddleware creates a MiddlewareFunc that cancels the context of the requests of a route after the timeout.
+// Requests which are not handled in time get a 503 Service Unavailable with a JSON body, and are counted per route.
+// The response of the handler is buffered, so that it can be discarded when the timeout is exceeded.
+func NewTimeoutMiddleware(method, path string, timeout time.Duration) MiddlewareFunc {
+	// register Prometheus metrics on first use
+	timeoutMetricsInit.Do(initTimeoutMetrics)
+
+	body, _ := json.Marshal(timeoutReport{Error: "request timeout exceeded", Timeout: timeout.String()})
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, h: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.flush()
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if r.Context().Err() != nil {
+					// the client went away, so there is no one to respond to
+					return
+				}
+				timeoutMetric.WithLabelValues(method, path).Inc()
+				log.FromContext(r.Context()).Warnf("%s %s exceeded the route timeout of %v", method, path, timeout)
+				w.Header().Set(encoding.ContentTypeHeader, patronjson.TypeCharset)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write(body)
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response of a handler, which is discarded if the timeout is exceeded.
+type timeoutWriter struct {
+	w        http.ResponseWriter
+	h        http.Header
+	buf      bytes.Buffer
+	mu       sync.Mutex
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	tw.code = code
+}
+
+// flush writes the buffered response, it has to be called with the lock held.
+func (tw *timeoutWriter) flush() {
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.code)
+	_, _ = tw.w.Write(tw.buf.Bytes())
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTimeoutMiddleware(t *testing.T) {
+	cancelled := make(chan bool, 1)
+	tests := map[string]struct {
+		handler      http.HandlerFunc
+		expectedCode int
+		expectedBody string
+		expectedType string
+	}{
+		"handled in time": {
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: "created",
+			expectedType: "text/plain",
+		},
+		"timeout exceeded": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				cancelled <- true
+				_, err := w.Write([]byte("late"))
+				assert.Equal(t, http.ErrHandlerTimeout, err)
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"error":"request timeout exceeded","timeout":"10ms"}`,
+			expectedType: "application/json; charset=utf-8",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rsp := httptest.NewRecorder()
+			mw := NewTimeoutMiddleware(http.MethodGet, "/timeout", 10*time.Millisecond)
+			mw(tt.handler).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/timeout", nil))
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			assert.Equal(t, tt.expectedType, rsp.Header().Get("Content-Type"))
+		})
+	}
+	assert.True(t, <-cancelled)
+}
+
+func TestNewTimeoutMiddleware_Panic(t *testing.T) {
+	mw := NewTimeoutMiddleware(http.MethodGet, "/timeout", time.Second)
+	hnd := mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("error")
+	}))
+	assert.PanicsWithValue(t, "error", func() {
+		hnd.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/timeout", nil))
+	})
+}
+
+func TestRouteBuilder_WithTimeout(t *testing.T) {
+	_, err := NewRawRouteBuilder("/timeout", func(http.ResponseWriter, *http.Request) {}).MethodGet().WithTimeout(0).Build()
+	assert.EqualError(t, err, "timeout must be positive\n")
+
+	route, err := NewGetRouteBuilder("/timeout", func(ctx context.Context, _ *Request) (*Response, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}).WithTimeout(10 * time.Millisecond).Build()
+	require.NoError(t, err)
+	rsp := httptest.NewRecorder()
+	MiddlewareChain(route.handler, route.middlewares...).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/timeout", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rsp.Code)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+)
+
+type clientIdentityKey struct{}
+
+// ClientIdentity is the identity of a client which authenticated with a certificate verified by the server.
+type ClientIdentity struct {
+	CommonName     string
+	DNSNames       []string
+	EmailAddresses []string
+	// URIs contain e.g. SPIFFE IDs.
+	URIs        []string
+	Certificate *x509.Certificate
+}
+
+// ClientIdentityFromContext returns the identity of the client of the request, when it authenticated with a verified certificate.
+func ClientIdentityFromContext(ctx context.Context) (ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey{}).(ClientIdentity)
+	return id, ok
+}
+
+// newClientIdentityMiddleware adds the identity of the client to the context of requests with a verified client certificate.
+func newClientIdentityMiddleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cert := r.TLS.VerifiedChains[0][0]
+			id := ClientIdentity{
+				CommonName:     cert.Subject.CommonName,
+				DNSNames:       cert.DNSNames,
+				EmailAddresses: cert.EmailAddresses,
+				Certificate:    cert,
+			}
+			for _, u := range cert.URIs {
+				id.URIs = append(id.URIs, u.String())
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, id)))
+		})
+	}
+}
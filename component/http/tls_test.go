@@ -0,0 +1,172 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPKI struct {
+	pool   *x509.CertPool
+	server tls.Certificate
+	client tls.Certificate
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, tmpl *x509.Certificate) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+
+	spiffeID, err := url.Parse("spiffe://example.org/ns/default/sa/client")
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return testPKI{
+		pool: pool,
+		server: issue(2, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "server"},
+			DNSNames:    []string{"localhost"},
+			IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}),
+		client: issue(3, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "client"},
+			DNSNames:    []string{"client.example.org"},
+			URIs:        []*url.URL{spiffeID},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}),
+	}
+}
+
+func TestBuilder_WithTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	tests := map[string]struct {
+		builder     *Builder
+		expectedErr string
+	}{
+		"nil TLS config": {
+			builder:     NewBuilder().WithTLSConfig(nil),
+			expectedErr: "TLS config is nil\n",
+		},
+		"nil client CA pool": {
+			builder:     NewBuilder().WithSSL("testdata/server.pem", "testdata/server.key").WithClientCertificates(nil, tls.RequireAndVerifyClientCert),
+			expectedErr: "client CA pool is nil\n",
+		},
+		"client auth mode without verification": {
+			builder:     NewBuilder().WithSSL("testdata/server.pem", "testdata/server.key").WithClientCertificates(pki.pool, tls.RequestClientCert),
+			expectedErr: "client auth mode RequestClientCert does not verify client certificates\n",
+		},
+		"missing certificate": {
+			builder:     NewBuilder().WithClientCertificates(pki.pool, tls.RequireAndVerifyClientCert),
+			expectedErr: "TLS requires a certificate, either in the TLS config or with the cert and key files",
+		},
+		"certificate files": {
+			builder: NewBuilder().WithSSL("testdata/server.pem", "testdata/server.key").WithClientCertificates(pki.pool, tls.VerifyClientCertIfGiven),
+		},
+		"certificate in TLS config": {
+			builder: NewBuilder().WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{pki.server}}).
+				WithClientCertificates(pki.pool, tls.RequireAndVerifyClientCert),
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cmp, err := tt.builder.Create()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, cmp)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, cmp.tlsConfig)
+				assert.Equal(t, pki.pool, cmp.tlsConfig.ClientCAs)
+			}
+		})
+	}
+}
+
+func TestComponent_MutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	var identity ClientIdentity
+	var hasIdentity bool
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/identity", func(_ http.ResponseWriter, r *http.Request) {
+		identity, hasIdentity = ClientIdentityFromContext(r.Context())
+	}).MethodGet())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).
+		WithTLSConfig(&tls.Config{Certificates: []tls.Certificate{pki.server}, MinVersion: tls.VersionTLS12}).
+		WithClientCertificates(pki.pool, tls.RequireAndVerifyClientCert).Create()
+	require.NoError(t, err)
+
+	srv := cmp.createHTTPServer()
+	ts := httptest.NewUnstartedServer(srv.Handler)
+	ts.TLS = srv.TLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      pki.pool,
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+	}
+
+	rsp, err := client(pki.client).Get(ts.URL + "/identity")
+	require.NoError(t, err)
+	require.NoError(t, rsp.Body.Close())
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.True(t, hasIdentity)
+	assert.Equal(t, "client", identity.CommonName)
+	assert.Equal(t, []string{"client.example.org"}, identity.DNSNames)
+	assert.Equal(t, []string{"spiffe://example.org/ns/default/sa/client"}, identity.URIs)
+	assert.NotNil(t, identity.Certificate)
+
+	_, err = client().Get(ts.URL + "/identity")
+	assert.Error(t, err)
+}
+
+func TestClientIdentityMiddleware_NoTLS(t *testing.T) {
+	var hasIdentity bool
+	hnd := newClientIdentityMiddleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_, hasIdentity = ClientIdentityFromContext(r.Context())
+	}))
+	hnd.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, hasIdentity)
+}
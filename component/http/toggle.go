@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/beatlabs/patron/component/http/auth"
+)
+
+const middlewaresPath = "/middlewares"
+
+// ToggleableMiddleware is a named middleware which can be enabled or disabled at runtime, e.g. for feature flagging.
+// When disabled, the middleware is a pass-through to the next handler.
+//
+// Toggling is safe for concurrent use while requests are being served, since the state is read atomically on every request.
+// A request that has already passed through the middleware is not affected by a change; the new state applies
+// to all requests reaching the middleware after the change.
+type ToggleableMiddleware struct {
+	name    string
+	mw      MiddlewareFunc
+	enabled int32
+}
+
+// ToggleableMiddlewareState describes the state of a toggleable middleware.
+type ToggleableMiddlewareState struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// NewToggleableMiddleware creates a named middleware which can be enabled or disabled at runtime.
+func NewToggleableMiddleware(name string, mw MiddlewareFunc, enabled bool) (*ToggleableMiddleware, error) {
+	if name == "" {
+		return nil, errors.New("middleware name is empty")
+	}
+	if mw == nil {
+		return nil, errors.New("middleware is nil")
+	}
+
+	tm := &ToggleableMiddleware{name: name, mw: mw}
+	if enabled {
+		tm.Enable()
+	}
+	return tm, nil
+}
+
+// Name returns the name of the middleware.
+func (tm *ToggleableMiddleware) Name() string {
+	return tm.name
+}
+
+// Enabled returns whether the middleware is enabled.
+func (tm *ToggleableMiddleware) Enabled() bool {
+	return atomic.LoadInt32(&tm.enabled) == 1
+}
+
+// Enable the middleware.
+func (tm *ToggleableMiddleware) Enable() {
+	atomic.StoreInt32(&tm.enabled, 1)
+}
+
+// Disable the middleware, which turns it into a pass-through.
+func (tm *ToggleableMiddleware) Disable() {
+	atomic.StoreInt32(&tm.enabled, 0)
+}
+
+// Middleware returns the MiddlewareFunc which delegates to the wrapped middleware only while enabled.
+func (tm *ToggleableMiddleware) Middleware() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		wrapped := tm.mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tm.Enabled() {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func toggleableMiddlewareRoutes(mm map[string]*ToggleableMiddleware, a auth.Authenticator) []*RouteBuilder {
+	list := func(_ context.Context, _ *Request) (*Response, error) {
+		states := make([]ToggleableMiddlewareState, 0, len(mm))
+		for _, tm := range mm {
+			states = append(states, ToggleableMiddlewareState{Name: tm.Name(), Enabled: tm.Enabled()})
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+		return NewResponse(states), nil
+	}
+
+	toggle := func(enable bool) ProcessorFunc {
+		return func(_ context.Context, req *Request) (*Response, error) {
+			tm, ok := mm[req.Fields["name"]]
+			if !ok {
+				return nil, NewNotFoundError()
+			}
+			if enable {
+				tm.Enable()
+			} else {
+				tm.Disable()
+			}
+			return nil, nil
+		}
+	}
+
+	rr := []*RouteBuilder{
+		NewGetRouteBuilder(middlewaresPath, list),
+		NewPostRouteBuilder(middlewaresPath+"/:name/enable", toggle(true)),
+		NewPostRouteBuilder(middlewaresPath+"/:name/disable", toggle(false)),
+	}
+	if a != nil {
+		for _, rb := range rr {
+			rb.WithAuth(a)
+		}
+	}
+	return rr
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Audit", "true")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestNewToggleableMiddleware(t *testing.T) {
+	type args struct {
+		name string
+		mw   MiddlewareFunc
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":        {args: args{name: "audit", mw: headerMiddleware}},
+		"missing name":   {args: args{name: "", mw: headerMiddleware}, expectedErr: "middleware name is empty"},
+		"nil middleware": {args: args{name: "audit", mw: nil}, expectedErr: "middleware is nil"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewToggleableMiddleware(tt.args.name, tt.args.mw, true)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.args.name, got.Name())
+				assert.True(t, got.Enabled())
+			}
+		})
+	}
+}
+
+func TestToggleableMiddleware_Middleware(t *testing.T) {
+	tm, err := NewToggleableMiddleware("audit", headerMiddleware, false)
+	require.NoError(t, err)
+	h := MiddlewareChain(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), tm.Middleware())
+
+	serve := func() string {
+		rsp := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		h.ServeHTTP(rsp, req)
+		assert.Equal(t, http.StatusOK, rsp.Code)
+		return rsp.Header().Get("X-Audit")
+	}
+
+	assert.Empty(t, serve())
+	tm.Enable()
+	assert.Equal(t, "true", serve())
+	tm.Disable()
+	assert.Empty(t, serve())
+}
+
+func TestComponent_ToggleableMiddlewareRoutes(t *testing.T) {
+	audit, err := NewToggleableMiddleware("audit", headerMiddleware, false)
+	require.NoError(t, err)
+	other, err := NewToggleableMiddleware("other", headerMiddleware, true)
+	require.NoError(t, err)
+
+	_, err = NewBuilder().WithToggleableMiddlewares(audit, other).WithMiddlewareToggling(nil).Create()
+	assert.EqualError(t, err, "middleware toggling endpoints require an authenticator or an admin port")
+	_, err = NewBuilder().WithToggleableMiddlewares(audit, other).WithMiddlewareToggling(nil).WithAdminPort(50001).Create()
+	assert.NoError(t, err)
+
+	authenticator := &MockAuthenticator{success: true}
+	cmp, err := NewBuilder().WithToggleableMiddlewares(audit, other).WithMiddlewareToggling(authenticator).Create()
+	require.NoError(t, err)
+	handler := cmp.createHTTPServer().Handler
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		rsp := httptest.NewRecorder()
+		handler.ServeHTTP(rsp, req)
+		return rsp
+	}
+
+	authenticator.success = false
+	rsp := serve(http.MethodPost, "/middlewares/other/disable")
+	assert.Equal(t, http.StatusUnauthorized, rsp.Code)
+	assert.True(t, other.Enabled())
+	authenticator.success = true
+
+	rsp = serve(http.MethodGet, "/middlewares")
+	assert.Equal(t, http.StatusOK, rsp.Code)
+	assert.JSONEq(t, `[{"name":"audit","enabled":false},{"name":"other","enabled":true}]`, rsp.Body.String())
+
+	rsp = serve(http.MethodPost, "/middlewares/audit/enable")
+	assert.Equal(t, http.StatusNoContent, rsp.Code)
+	assert.True(t, audit.Enabled())
+
+	rsp = serve(http.MethodPost, "/middlewares/other/disable")
+	assert.Equal(t, http.StatusNoContent, rsp.Code)
+	assert.False(t, other.Enabled())
+
+	rsp = serve(http.MethodPost, "/middlewares/missing/enable")
+	assert.Equal(t, http.StatusNotFound, rsp.Code)
+}
+
+func TestBuilder_WithToggleableMiddlewares(t *testing.T) {
+	tm, err := NewToggleableMiddleware("audit", headerMiddleware, true)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		tt          []*ToggleableMiddleware
+		expectedErr string
+	}{
+		"success":   {tt: []*ToggleableMiddleware{tm}},
+		"empty":     {tt: nil, expectedErr: "empty list of toggleable middlewares provided"},
+		"nil":       {tt: []*ToggleableMiddleware{nil}, expectedErr: "nil toggleable middleware provided"},
+		"duplicate": {tt: []*ToggleableMiddleware{tm, tm}, expectedErr: "toggleable middleware audit is duplicate"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmp, err := NewBuilder().WithToggleableMiddlewares(tt.tt...).Create()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr+"\n")
+				assert.Nil(t, cmp)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, cmp.middlewares, 1)
+				paths := make([]string, 0, len(cmp.routes))
+				for _, r := range cmp.routes {
+					paths = append(paths, r.path)
+				}
+				assert.NotContains(t, paths, "/middlewares", "the toggling endpoints are opt-in")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+)
+
+const (
+	// notFoundPath is the path label of the metrics of the requests which match no route.
+	notFoundPath = "not_found"
+	// methodNotAllowedPath is the path label of the metrics of the requests which match the path of a route, but not its method.
+	methodNotAllowedPath = "method_not_allowed"
+	// otherMethod is the method label of the metrics of the unmatched requests with a non-standard method.
+	otherMethod = "OTHER"
+)
+
+// unmatchedHandler wraps the handler of the requests which match no route with the request observer,
+// under a distinct path label, since labelling them with the requested paths would make the cardinality of the metrics unbounded.
+// The default handler is used if none is provided.
+func unmatchedHandler(path string, h http.Handler, def http.Handler) http.Handler {
+	if h == nil {
+		h = def
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewRequestObserverMiddleware(metricMethod(r.Method), path)(h).ServeHTTP(w, r)
+	})
+}
+
+// metricMethod returns the method label of an unmatched request, which is bounded to the standard methods.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
+
+func defaultMethodNotAllowedHandler(w http.ResponseWriter, _ *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
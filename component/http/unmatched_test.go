@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_WithUnmatchedHandlers(t *testing.T) {
+	_, err := NewBuilder().WithNotFoundHandler(nil).WithMethodNotAllowedHandler(nil).Create()
+	assert.EqualError(t, err, "not found handler is nil\nmethod not allowed handler is nil\n")
+}
+
+func TestComponent_UnmatchedHandlers(t *testing.T) {
+	hello := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}
+	jsonError := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"error":"` + http.StatusText(code) + `"}`))
+		}
+	}
+	rb := func() *RoutesBuilder {
+		return NewRoutesBuilder().Append(NewRawRouteBuilder("/hello", hello).MethodGet())
+	}
+
+	defaultCmp, err := NewBuilder().WithRoutesBuilder(rb()).Create()
+	require.NoError(t, err)
+	customCmp, err := NewBuilder().WithRoutesBuilder(rb()).
+		WithNotFoundHandler(jsonError(http.StatusNotFound)).
+		WithMethodNotAllowedHandler(jsonError(http.StatusMethodNotAllowed)).
+		Create()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		cmp            *Component
+		method         string
+		path           string
+		expectedCode   int
+		expectedBody   string
+		expectedAllow  string
+		expectedMethod string
+		expectedLabel  string
+	}{
+		"default not found": {
+			cmp: defaultCmp, method: http.MethodGet, path: "/missing", expectedCode: http.StatusNotFound,
+			expectedBody: "404 page not found\n", expectedMethod: http.MethodGet, expectedLabel: notFoundPath,
+		},
+		"default method not allowed": {
+			cmp: defaultCmp, method: http.MethodPost, path: "/hello", expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: "Method Not Allowed\n", expectedAllow: "GET, OPTIONS", expectedMethod: http.MethodPost, expectedLabel: methodNotAllowedPath,
+		},
+		"custom not found": {
+			cmp: customCmp, method: http.MethodGet, path: "/missing", expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"Not Found"}`, expectedMethod: http.MethodGet, expectedLabel: notFoundPath,
+		},
+		"custom method not allowed": {
+			cmp: customCmp, method: http.MethodDelete, path: "/hello", expectedCode: http.StatusMethodNotAllowed,
+			expectedBody: `{"error":"Method Not Allowed"}`, expectedAllow: "GET, OPTIONS", expectedMethod: http.MethodDelete, expectedLabel: methodNotAllowedPath,
+		},
+		"non-standard method": {
+			cmp: customCmp, method: "PURGE", path: "/missing", expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"Not Found"}`, expectedMethod: otherMethod, expectedLabel: notFoundPath,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			httpStatusTracingHandledMetric.Reset()
+			srv := tt.cmp.createServers()[0]
+			rsp := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rsp, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+			assert.Equal(t, tt.expectedAllow, rsp.Header().Get("Allow"))
+			assert.Equal(t, 1.0, testutil.ToFloat64(httpStatusTracingHandledMetric.WithLabelValues(tt.expectedMethod, tt.expectedLabel, strconv.Itoa(tt.expectedCode), "")))
+		})
+	}
+}
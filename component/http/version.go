@@ -0,0 +1,152 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/beatlabs/patron/encoding"
+)
+
+const versionParam = "version"
+
+// Version creates a group of the routes of an API version, e.g. "v2", whose paths are prefixed with the version, e.g. "/v2/users",
+// and whose middlewares are preceded by the provided ones. The version is the version label of the metrics of the routes.
+func (rb *RoutesBuilder) Version(version string, mm ...MiddlewareFunc) *RouteGroup {
+	if err := validateVersion(version); err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	g := rb.Group("/"+version, mm...)
+	g.version = version
+	return g
+}
+
+// AcceptVersion creates a group of the routes of an API version, e.g. "v2", which is selected by the Accept header of the requests,
+// instead of their path, so that the routes of all the versions share their paths. The version is requested either with
+// the version parameter of a media type, e.g. "application/json; version=v2", or with the suffix of a vendor media type,
+// e.g. "application/vnd.example.v2+json". Requests without a version are served by the version appended first for the path,
+// while requests for an unknown version get a 406 Not Acceptable.
+// The version is the version label of the metrics of the routes.
+func (rb *RoutesBuilder) AcceptVersion(version string, mm ...MiddlewareFunc) *RouteGroup {
+	if err := validateVersion(version); err != nil {
+		rb.errors = append(rb.errors, err)
+	}
+	return &RouteGroup{rb: rb, middlewares: mm, version: version, acceptVersion: true}
+}
+
+func validateVersion(version string) error {
+	if version == "" {
+		return errors.New("version is empty")
+	}
+	if strings.ContainsAny(version, "/+.;, ") {
+		return fmt.Errorf("version %s contains invalid characters", version)
+	}
+	return nil
+}
+
+// combineAcceptVersions combines the routes of the versions of each method and path selected by the Accept header into a single route,
+// which dispatches the requests to the route of the requested version.
+func combineAcceptVersions(routes []Route) ([]Route, error) {
+	versioned := make(map[string][]Route)
+	combined := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		if r.acceptVersion == "" {
+			combined = append(combined, r)
+			continue
+		}
+		key := strings.ToLower(r.method + "-" + r.path)
+		if _, ok := versioned[key]; !ok {
+			// the combined route takes the place of the version appended first
+			combined = append(combined, Route{path: r.path, method: r.method, acceptVersion: key})
+		}
+		versioned[key] = append(versioned[key], r)
+	}
+
+	for i, r := range combined {
+		if r.acceptVersion == "" {
+			continue
+		}
+		vr, err := newVersionedRoute(versioned[r.acceptVersion])
+		if err != nil {
+			return nil, err
+		}
+		combined[i] = vr
+	}
+	return combined, nil
+}
+
+func newVersionedRoute(routes []Route) (Route, error) {
+	handlers := make(map[string]http.Handler, len(routes))
+	for _, r := range routes {
+		if _, ok := handlers[r.acceptVersion]; ok {
+			return Route{}, fmt.Errorf("route with key %s and version %s is duplicate", strings.ToLower(r.method+"-"+r.path), r.acceptVersion)
+		}
+		handlers[r.acceptVersion] = MiddlewareChain(r.handler, r.middlewares...)
+	}
+	def := handlers[routes[0].acceptVersion]
+
+	// the features applied by the component to each route follow the version appended first
+	route := routes[0]
+	route.middlewares = nil
+	route.acceptVersion = ""
+	route.handler = func(w http.ResponseWriter, r *http.Request) {
+		version, ok := requestedVersion(r.Header.Get(encoding.AcceptHeader), handlers)
+		if !ok {
+			def.ServeHTTP(w, r)
+			return
+		}
+		h, ok := handlers[version]
+		if !ok {
+			http.Error(w, fmt.Sprintf("version %s is not supported", version), http.StatusNotAcceptable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}
+	return route, nil
+}
+
+// requestedVersion returns the version requested in the Accept header, preferring the versions which are served, if several are requested.
+func requestedVersion(accept string, served map[string]http.Handler) (string, bool) {
+	var requested []string
+	for _, mr := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(mr))
+		if err != nil {
+			continue
+		}
+		if v, ok := params[versionParam]; ok {
+			requested = append(requested, v)
+			continue
+		}
+		if v, ok := vendorVersion(mt); ok {
+			requested = append(requested, v)
+		}
+	}
+	if len(requested) == 0 {
+		return "", false
+	}
+	for _, v := range requested {
+		if _, ok := served[v]; ok {
+			return v, true
+		}
+	}
+	return requested[0], true
+}
+
+// vendorVersion returns the version of a vendor media type, e.g. v2 of application/vnd.example.v2+json.
+func vendorVersion(mediaType string) (string, bool) {
+	i := strings.Index(mediaType, "/")
+	subtype := mediaType[i+1:]
+	if !strings.HasPrefix(subtype, "vnd.") {
+		return "", false
+	}
+	if j := strings.Index(subtype, "+"); j >= 0 {
+		subtype = subtype[:j]
+	}
+	parts := strings.Split(subtype, ".")
+	if len(parts) < 3 {
+		return "", false
+	}
+	return parts[len(parts)-1], true
+}
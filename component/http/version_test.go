@@ -0,0 +1,128 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func versionHandler(version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(version))
+	}
+}
+
+func TestRoutesBuilder_Version(t *testing.T) {
+	rb := NewRoutesBuilder()
+	rb.Version("v1").Append(NewRawRouteBuilder("/versioned", versionHandler("v1")).MethodGet())
+	rb.Version("v2").Append(NewRawRouteBuilder("/versioned", versionHandler("v2")).MethodGet())
+	routes, err := rb.Build()
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	assert.Equal(t, "/v1/versioned", routes[0].path)
+	assert.Equal(t, "/v2/versioned", routes[1].path)
+
+	httpStatusTracingHandledMetric.Reset()
+	rsp := httptest.NewRecorder()
+	MiddlewareChain(routes[1].handler, routes[1].middlewares...).ServeHTTP(rsp, httptest.NewRequest(http.MethodGet, "/v2/versioned", nil))
+	assert.Equal(t, "v2", rsp.Body.String())
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpStatusTracingHandledMetric.WithLabelValues(http.MethodGet, "/v2/versioned", "200", "v2")))
+}
+
+func TestRoutesBuilder_Version_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		version     string
+		expectedErr string
+	}{
+		"empty version":   {expectedErr: "version is empty\n"},
+		"invalid version": {version: "v1.2", expectedErr: "version v1.2 contains invalid characters\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			rb := NewRoutesBuilder()
+			rb.Version(tt.version)
+			_, err := rb.Build()
+			assert.EqualError(t, err, tt.expectedErr)
+
+			rb = NewRoutesBuilder()
+			rb.AcceptVersion(tt.version)
+			_, err = rb.Build()
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestRoutesBuilder_AcceptVersion(t *testing.T) {
+	rb := NewRoutesBuilder().Append(NewRawRouteBuilder("/unversioned", versionHandler("none")).MethodGet())
+	rb.AcceptVersion("v1").Append(NewRawRouteBuilder("/versioned", versionHandler("v1")).MethodGet())
+	rb.AcceptVersion("v2").Append(NewRawRouteBuilder("/versioned", versionHandler("v2")).MethodGet()).
+		Append(NewRawRouteBuilder("/versioned", versionHandler("v2 post")).MethodPost())
+	cmp, err := NewBuilder().WithRoutesBuilder(rb).Create()
+	require.NoError(t, err)
+	srv := cmp.createServers()[0]
+
+	tests := map[string]struct {
+		method       string
+		path         string
+		accept       string
+		expectedCode int
+		expectedBody string
+	}{
+		"version parameter":         {method: http.MethodGet, path: "/versioned", accept: "application/json; version=v2", expectedCode: http.StatusOK, expectedBody: "v2"},
+		"vendor media type":         {method: http.MethodGet, path: "/versioned", accept: "application/vnd.example.v2+json", expectedCode: http.StatusOK, expectedBody: "v2"},
+		"served version preferred":  {method: http.MethodGet, path: "/versioned", accept: "application/json; version=v3, application/json; version=v1", expectedCode: http.StatusOK, expectedBody: "v1"},
+		"no version":                {method: http.MethodGet, path: "/versioned", accept: "application/json", expectedCode: http.StatusOK, expectedBody: "v1"},
+		"no accept header":          {method: http.MethodGet, path: "/versioned", expectedCode: http.StatusOK, expectedBody: "v1"},
+		"unknown version":           {method: http.MethodGet, path: "/versioned", accept: "application/json; version=v3", expectedCode: http.StatusNotAcceptable, expectedBody: "version v3 is not supported\n"},
+		"single version of method":  {method: http.MethodPost, path: "/versioned", accept: "application/json; version=v2", expectedCode: http.StatusOK, expectedBody: "v2 post"},
+		"unknown version of method": {method: http.MethodPost, path: "/versioned", accept: "application/json; version=v1", expectedCode: http.StatusNotAcceptable, expectedBody: "version v1 is not supported\n"},
+		"unversioned route":         {method: http.MethodGet, path: "/unversioned", accept: "application/json; version=v2", expectedCode: http.StatusOK, expectedBody: "none"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rsp := httptest.NewRecorder()
+			srv.Handler.ServeHTTP(rsp, req)
+			assert.Equal(t, tt.expectedCode, rsp.Code)
+			assert.Equal(t, tt.expectedBody, rsp.Body.String())
+		})
+	}
+}
+
+func TestRoutesBuilder_AcceptVersion_Duplicate(t *testing.T) {
+	rb := NewRoutesBuilder()
+	rb.AcceptVersion("v1").Append(NewRawRouteBuilder("/versioned", versionHandler("v1")).MethodGet())
+	rb.AcceptVersion("v1").Append(NewRawRouteBuilder("/versioned", versionHandler("v1")).MethodGet())
+	_, err := rb.Build()
+	assert.EqualError(t, err, "route with key get-/versioned and version v1 is duplicate\n")
+}
+
+func Test_vendorVersion(t *testing.T) {
+	tests := map[string]struct {
+		mediaType       string
+		expectedVersion string
+		expectedOK      bool
+	}{
+		"vendor with suffix":     {mediaType: "application/vnd.example.v2+json", expectedVersion: "v2", expectedOK: true},
+		"vendor without suffix":  {mediaType: "application/vnd.example.v2", expectedVersion: "v2", expectedOK: true},
+		"vendor without version": {mediaType: "application/vnd.example+json"},
+		"not vendor":             {mediaType: "application/json"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			version, ok := vendorVersion(tt.mediaType)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedVersion, version)
+		})
+	}
+}
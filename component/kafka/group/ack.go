@@ -0,0 +1,78 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/opentracing/opentracing-go"
+)
+
+type ackStatus int
+
+const (
+	ackPending ackStatus = iota
+	ackAcked
+	ackNacked
+)
+
+var (
+	errNotAcknowledged = errors.New("message was not acknowledged")
+)
+
+// ackMessage is a message which is acknowledged by the processor, with the kafka.ManualCommitStrategy.
+// Only the first acknowledgment of the message counts.
+type ackMessage struct {
+	msg kafka.Message
+
+	mu     sync.Mutex
+	status ackStatus
+	err    error
+}
+
+// Context will contain the context to be used for processing.
+func (m *ackMessage) Context() context.Context {
+	return m.msg.Context()
+}
+
+// Message will contain the raw Kafka message.
+func (m *ackMessage) Message() *sarama.ConsumerMessage {
+	return m.msg.Message()
+}
+
+// Span contains the tracing span of this message.
+func (m *ackMessage) Span() opentracing.Span {
+	return m.msg.Span()
+}
+
+// Ack acknowledges the successful processing of the message, so that its offset is committed.
+func (m *ackMessage) Ack() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status == ackPending {
+		m.status = ackAcked
+	}
+}
+
+// Nack negatively acknowledges the message, so that it is handled by the failure strategy with the error.
+func (m *ackMessage) Nack(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status != ackPending {
+		return
+	}
+	if err == nil {
+		err = patronfailure.ErrNACKed
+	}
+	m.status = ackNacked
+	m.err = err
+}
+
+func (m *ackMessage) result() (ackStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status, m.err
+}
@@ -0,0 +1,95 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckMessage(t *testing.T) {
+	msg := saramaConsumerMessage("value", &sarama.RecordHeader{})
+	m := &ackMessage{msg: kafka.NewMessage(context.Background(), nil, msg)}
+	assert.Equal(t, msg, m.Message())
+	assert.NotNil(t, m.Context())
+	status, err := m.result()
+	assert.Equal(t, ackPending, status)
+	assert.NoError(t, err)
+
+	m.Ack()
+	m.Nack(errProcess)
+	status, err = m.result()
+	assert.Equal(t, ackAcked, status, "only the first acknowledgment counts")
+	assert.NoError(t, err)
+
+	m = &ackMessage{msg: kafka.NewMessage(context.Background(), nil, msg)}
+	m.Nack(nil)
+	status, err = m.result()
+	assert.Equal(t, ackNacked, status)
+	assert.Equal(t, patronfailure.ErrNACKed, err)
+}
+
+func TestHandler_ConsumeClaim_ManualCommit(t *testing.T) {
+	tests := map[string]struct {
+		procErr        error
+		expectedErrors []string
+	}{
+		"processing success": {expectedErrors: []string{"INVALID", "message was not acknowledged"}},
+		"processing error":   {procErr: errProcess, expectedErrors: []string{"INVALID", "PROC ERROR"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			proc := func(btc kafka.Batch) error {
+				mm := btc.Messages()
+				mm[0].(kafka.Acknowledger).Ack()
+				mm[1].(kafka.Acknowledger).Nack(errors.New("INVALID"))
+				return tt.procErr
+			}
+			producer := &mockProducer{}
+			h := newConsumerHandler(ctx, name, "grp", proc, kafka.DeadLetterStrategy, 3, time.Hour, kafka.ManualCommitStrategy, 0,
+				0, nil, &deadLetter{topic: "dlq", producer: producer}, nil, nil, rebalanceHooks{})
+
+			ch := make(chan *sarama.ConsumerMessage, 3)
+			ch <- saramaConsumerMessage("1", &sarama.RecordHeader{})
+			ch <- saramaConsumerMessage("2", &sarama.RecordHeader{})
+			ch <- saramaConsumerMessage("3", &sarama.RecordHeader{})
+			close(ch)
+			session := &mockConsumerSession{}
+			require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+			assert.Equal(t, 3, session.marked)
+			assert.Equal(t, 1, session.commits)
+
+			require.Len(t, producer.messages, 2, "the messages which are not acknowledged are handled by the failure strategy")
+			for i, expected := range tt.expectedErrors {
+				assert.Equal(t, sarama.ByteEncoder([]string{"2", "3"}[i]), producer.messages[i].Value)
+				assert.Equal(t, expected, headerValue(producer.messages[i].Headers, kafka.DeadLetterErrorHeader))
+			}
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_ManualCommit_Exit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	proc := func(btc kafka.Batch) error {
+		btc.Messages()[0].(kafka.Acknowledger).Nack(errors.New("INVALID"))
+		return nil
+	}
+	h := newConsumerHandler(ctx, "exit", "grp", proc, kafka.ExitStrategy, 1, time.Hour, kafka.ManualCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+	ch := make(chan *sarama.ConsumerMessage, 1)
+	ch <- saramaConsumerMessage("1", &sarama.RecordHeader{})
+	session := &mockConsumerSession{}
+	assert.EqualError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}), "INVALID")
+	assert.Equal(t, 0, session.marked)
+	assert.Equal(t, 0, session.commits)
+}
@@ -0,0 +1,133 @@
+package group
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// backpressureInterval is the interval at which the backpressure is checked again while it pauses the consumption.
+const backpressureInterval = 100 * time.Millisecond
+
+var consumerPaused *prometheus.GaugeVec
+
+func init() {
+	consumerPaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "consumer_paused",
+			Help:      "Consumer state, classified by consumer name, which is 1 when the consumption is paused and 0 otherwise",
+		},
+		[]string{"name"},
+	)
+
+	prometheus.MustRegister(consumerPaused)
+}
+
+// backpressure decides whether the consumption is paused, either explicitly or because the processing cannot keep up.
+type backpressure struct {
+	// messages passed to the processor which have not been processed yet, accessed atomically
+	inFlight    int64
+	maxInFlight int64
+	breakers    []*circuitbreaker.CircuitBreaker
+
+	name string
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+	// whether the consumption was paused due to the backpressure the last time it was checked
+	throttled bool
+}
+
+func newBackpressure(name string) *backpressure {
+	return &backpressure{name: name, resumed: make(chan struct{})}
+}
+
+func (b *backpressure) pause() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused {
+		return
+	}
+	b.paused = true
+	b.setGauge()
+	log.Infof("kafka component %s paused", b.name)
+}
+
+func (b *backpressure) resume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.paused {
+		return
+	}
+	b.paused = false
+	close(b.resumed)
+	b.resumed = make(chan struct{})
+	b.setGauge()
+	log.Infof("kafka component %s resumed", b.name)
+}
+
+func (b *backpressure) isPaused() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.paused || b.throttled
+}
+
+// track adds the number of messages passed to the processor, or subtracts them once they are processed.
+func (b *backpressure) track(messages int) {
+	atomic.AddInt64(&b.inFlight, int64(messages))
+}
+
+// state returns whether the consumption is paused, along with a channel which is ready once the state has to be checked again.
+func (b *backpressure) state() (bool, <-chan struct{}) {
+	throttled := b.throttle()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if throttled != b.throttled {
+		b.throttled = throttled
+		if throttled {
+			log.Warnf("kafka component %s paused due to backpressure", b.name)
+		} else {
+			log.Infof("kafka component %s resumed after backpressure", b.name)
+		}
+		b.setGauge()
+	}
+
+	if b.paused {
+		return true, b.resumed
+	}
+	if throttled {
+		recheck := make(chan struct{})
+		time.AfterFunc(backpressureInterval, func() { close(recheck) })
+		return true, recheck
+	}
+	return false, nil
+}
+
+func (b *backpressure) setGauge() {
+	if b.paused || b.throttled {
+		consumerPaused.WithLabelValues(b.name).Set(1)
+		return
+	}
+	consumerPaused.WithLabelValues(b.name).Set(0)
+}
+
+// throttle returns true if the messages in flight have reached their maximum, or if any of the circuit breakers is open.
+func (b *backpressure) throttle() bool {
+	if b.maxInFlight > 0 && atomic.LoadInt64(&b.inFlight) >= b.maxInFlight {
+		return true
+	}
+	for _, cb := range b.breakers {
+		if cb.IsOpen() {
+			return true
+		}
+	}
+	return false
+}
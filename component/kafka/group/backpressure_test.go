@@ -0,0 +1,168 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	c := &Component{backpressure: newBackpressure("name")}
+	assert.EqualError(t, MaxInFlight(0)(c), "max in-flight messages should be a positive number")
+	require.NoError(t, MaxInFlight(100)(c))
+	assert.Equal(t, int64(100), c.backpressure.maxInFlight)
+}
+
+func TestPauseOnOpenCircuit(t *testing.T) {
+	c := &Component{backpressure: newBackpressure("name")}
+	assert.EqualError(t, PauseOnOpenCircuit(nil)(c), "circuit breaker is nil")
+	cb := openCircuitBreaker(t, "pause-on-open-circuit")
+	require.NoError(t, PauseOnOpenCircuit(cb)(c))
+	assert.Equal(t, []*circuitbreaker.CircuitBreaker{cb}, c.backpressure.breakers)
+}
+
+func TestComponent_PauseResume(t *testing.T) {
+	c, err := New("pause-resume", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig())
+	require.NoError(t, err)
+	assert.False(t, c.Paused())
+
+	c.Pause()
+	c.Pause()
+	assert.True(t, c.Paused())
+	assert.Equal(t, 1.0, testutil.ToFloat64(consumerPaused.WithLabelValues("pause-resume")))
+	paused, resumed := c.backpressure.state()
+	assert.True(t, paused)
+
+	c.Resume()
+	assert.False(t, c.Paused())
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumerPaused.WithLabelValues("pause-resume")))
+	_, ok := <-resumed
+	assert.False(t, ok, "the paused consumers are notified")
+	c.Resume()
+}
+
+func Test_backpressure_state(t *testing.T) {
+	tests := map[string]struct {
+		maxInFlight    int64
+		inFlight       int
+		breakers       []*circuitbreaker.CircuitBreaker
+		expectedPaused bool
+	}{
+		"no backpressure":         {},
+		"in flight below maximum": {maxInFlight: 10, inFlight: 9},
+		"in flight at maximum":    {maxInFlight: 10, inFlight: 10, expectedPaused: true},
+		"unlimited in flight":     {inFlight: 100},
+		"open circuit":            {breakers: []*circuitbreaker.CircuitBreaker{closedCircuitBreaker(t, "closed"), openCircuitBreaker(t, "open")}, expectedPaused: true},
+		"closed circuit":          {breakers: []*circuitbreaker.CircuitBreaker{closedCircuitBreaker(t, "closed")}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			b := newBackpressure(name)
+			b.maxInFlight = tt.maxInFlight
+			b.breakers = tt.breakers
+			b.track(tt.inFlight)
+
+			paused, recheck := b.state()
+			assert.Equal(t, tt.expectedPaused, paused)
+			assert.Equal(t, tt.expectedPaused, b.isPaused())
+			if !tt.expectedPaused {
+				assert.Nil(t, recheck)
+				return
+			}
+			select {
+			case <-recheck:
+			case <-time.After(time.Second):
+				assert.Fail(t, "the backpressure is checked again after the interval")
+			}
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_Pause(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	proc := &mockProcessor{}
+	bp := newBackpressure("pause")
+	bp.pause()
+	h := newConsumerHandler(ctx, "pause", "grp", proc.Process, kafka.ExitStrategy, 1, time.Hour, kafka.BatchCommitStrategy, 0, 0, nil, nil, nil, bp, rebalanceHooks{})
+
+	ch := make(chan *sarama.ConsumerMessage)
+	session := &mockConsumerSession{}
+	chDone := make(chan error)
+	go func() {
+		chDone <- h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	}()
+
+	select {
+	case ch <- saramaConsumerMessage("value", &sarama.RecordHeader{}):
+		assert.Fail(t, "the messages are not consumed while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	bp.resume()
+	ch <- saramaConsumerMessage("value", &sarama.RecordHeader{})
+	close(ch)
+	assert.NoError(t, <-chDone)
+	assert.Equal(t, 1, proc.GetExecs())
+	assert.Equal(t, 1, session.marked)
+}
+
+func TestHandler_ConsumeClaim_MaxInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	unblock := make(chan struct{})
+	proc := func(kafka.Batch) error {
+		<-unblock
+		return nil
+	}
+	bp := newBackpressure("max-in-flight")
+	bp.maxInFlight = 1
+	h := newConsumerHandler(ctx, "max-in-flight", "grp", proc, kafka.SkipStrategy, 1, time.Hour, kafka.BatchCommitStrategy,
+		10*time.Millisecond, 0, nil, nil, nil, bp, rebalanceHooks{})
+
+	ch := make(chan *sarama.ConsumerMessage)
+	session := &mockConsumerSession{}
+	chDone := make(chan error)
+	go func() {
+		chDone <- h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	}()
+
+	// the processing of the message is abandoned after the timeout, but it is still in flight until the processor returns
+	ch <- saramaConsumerMessage("value", &sarama.RecordHeader{})
+	select {
+	case ch <- saramaConsumerMessage("value", &sarama.RecordHeader{}):
+		assert.Fail(t, "the messages are not consumed while the processing is in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	paused, _ := bp.state()
+	assert.True(t, paused, "the other claims are paused while the processing is in flight")
+
+	close(unblock)
+	ch <- saramaConsumerMessage("value", &sarama.RecordHeader{})
+	close(ch)
+	assert.NoError(t, <-chDone)
+	assert.Equal(t, 2, session.marked)
+	assert.False(t, bp.isPaused())
+}
+
+func closedCircuitBreaker(t *testing.T, name string) *circuitbreaker.CircuitBreaker {
+	cb, err := circuitbreaker.New(name, circuitbreaker.Setting{FailureThreshold: 1, RetryTimeout: time.Hour, RetrySuccessThreshold: 1, MaxRetryExecutionThreshold: 1})
+	require.NoError(t, err)
+	return cb
+}
+
+func openCircuitBreaker(t *testing.T, name string) *circuitbreaker.CircuitBreaker {
+	cb := closedCircuitBreaker(t, name)
+	_, err := cb.Execute(func() (interface{}, error) { return nil, errors.New("downstream error") })
+	require.Error(t, err)
+	return cb
+}
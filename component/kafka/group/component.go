@@ -6,11 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
+	patronfailure "github.com/beatlabs/patron/component/failure"
 	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/component/shutdown"
 	"github.com/beatlabs/patron/correlation"
 	patronErrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/internal/validation"
@@ -28,6 +31,8 @@ const (
 	messageProcessed  = "processed"
 	messageErrored    = "errored"
 	messageSkipped    = "skipped"
+	messageRetried    = "retried"
+	messageFiltered   = "filtered"
 )
 
 const (
@@ -142,6 +147,7 @@ func New(name, group string, brokers, topics []string, proc kafka.BatchProcessor
 		batchTimeout: defaultBatchTimeout,
 		failStrategy: defaultFailureStrategy,
 		saramaConfig: saramaCfg,
+		backpressure: newBackpressure(name),
 	}
 
 	for _, optionFunc := range oo {
@@ -151,6 +157,26 @@ func New(name, group string, brokers, topics []string, proc kafka.BatchProcessor
 		}
 	}
 
+	if cmp.failStrategy == kafka.DeadLetterStrategy && cmp.deadLetter == nil {
+		return nil, errors.New("dead letter strategy requires a dead letter topic")
+	}
+
+	if cmp.failure != nil {
+		if cmp.retryTopics != nil {
+			return nil, errors.New("failure strategy cannot be combined with retry topics")
+		}
+		if len(cmp.failureOptions) > 0 {
+			return nil, fmt.Errorf("failure strategy cannot be combined with %s", strings.Join(cmp.failureOptions, ", "))
+		}
+		if patronfailure.Declares(cmp.failure, patronfailure.DeadLetter) && cmp.deadLetter == nil {
+			return nil, errors.New("failure strategy requires a dead letter topic")
+		}
+	}
+
+	if cmp.commitStrategy == kafka.MessageCommitStrategy && cmp.workers > 1 {
+		return nil, errors.New("key ordered concurrency cannot be combined with the message commit strategy")
+	}
+
 	return cmp, nil
 }
 
@@ -163,27 +189,69 @@ type Component struct {
 	saramaConfig *sarama.Config
 	proc         kafka.BatchProcessorFunc
 	failStrategy kafka.FailStrategy
-	batchSize    uint
-	batchTimeout time.Duration
-	retries      uint
-	retryWait    time.Duration
-	commitSync   bool
+	failure      patronfailure.Strategy
+	// the options of the failure handling which are replaced by the failure strategy, which were set
+	failureOptions []string
+	batchSize      uint
+	batchTimeout   time.Duration
+	retries        uint
+	retryWait      time.Duration
+	commitStrategy kafka.CommitStrategy
+	processTimeout time.Duration
+	workers        uint
+	filter         kafka.FilterFunc
+	deadLetter     *deadLetter
+	retryTopics    *retryTopics
+	lagInterval    time.Duration
+	backpressure   *backpressure
+	rebalance      rebalanceHooks
 }
 
 // Run starts the consumer processing loop to process messages from Kafka.
 func (c *Component) Run(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	if c.lagInterval > 0 {
+		go c.monitorLag(ctx)
+	}
 	return c.processing(ctx)
 }
 
+// Pause stops consuming messages until Resume is called, without leaving the consumer group.
+// The messages which have already been received are still processed.
+func (c *Component) Pause() {
+	c.backpressure.pause()
+}
+
+// Resume continues consuming messages after Pause.
+// The consumption stays paused while the backpressure options pause it.
+func (c *Component) Resume() {
+	c.backpressure.resume()
+}
+
+// Paused returns true if the consumption is paused, either with Pause or due to the backpressure options.
+func (c *Component) Paused() bool {
+	return c.backpressure.isPaused()
+}
+
+// consumedTopics returns the topics of the component along with their retry topics.
+func (c *Component) consumedTopics() []string {
+	if c.retryTopics == nil {
+		return c.topics
+	}
+	return append(append([]string{}, c.topics...), c.retryTopics.names...)
+}
+
 func (c *Component) processing(ctx context.Context) error {
 	var componentError error
 
 	retries := int(c.retries)
 	for i := 0; i <= retries; i++ {
 		handler := newConsumerHandler(ctx, c.name, c.group, c.proc, c.failStrategy, c.batchSize,
-			c.batchTimeout, c.commitSync)
+			c.batchTimeout, c.commitStrategy, c.processTimeout, c.workers, c.filter, c.deadLetter, c.retryTopics, c.backpressure, c.rebalance)
+		handler.failure = c.failure
+
+		topics := c.consumedTopics()
 
 		client, err := sarama.NewConsumerGroup(c.brokers, c.group, c.saramaConfig)
 		componentError = err
@@ -192,7 +260,7 @@ func (c *Component) processing(ctx context.Context) error {
 		}
 
 		if client != nil {
-			log.Debugf("consuming messages from topics '%#v' using group '%s'", c.topics, c.group)
+			log.Debugf("consuming messages from topics '%#v' using group '%s'", topics, c.group)
 			for {
 				// check if context was cancelled or deadline exceeded, signaling that the consumer should stop
 				if ctx.Err() != nil {
@@ -203,7 +271,7 @@ func (c *Component) processing(ctx context.Context) error {
 				// `Consume` should be called inside an infinite loop, when a
 				// server-side rebalance happens, the consumer session will need to be
 				// recreated to get the new claims
-				err := client.Consume(ctx, c.topics, handler)
+				err := client.Consume(ctx, topics, handler)
 				componentError = err
 				if err != nil {
 					log.Errorf("error from kafka consumer: %v", err)
@@ -223,6 +291,11 @@ func (c *Component) processing(ctx context.Context) error {
 
 		consumerErrorsInc(c.name)
 
+		// the component is not retried once the failure strategy stops it
+		if c.failure != nil && handler.err != nil {
+			return handler.err
+		}
+
 		if c.retries > 0 {
 			if handler.processedMessages {
 				i = 0
@@ -260,9 +333,9 @@ type consumerHandler struct {
 	name  string
 	group string
 
-	// buffer
-	batchSize int
-	ticker    *time.Ticker
+	// buffer of every claim, which is processed once it is full, or once its first message has waited for the batch timeout
+	batchSize    int
+	batchTimeout time.Duration
 
 	// callback
 	proc kafka.BatchProcessorFunc
@@ -270,12 +343,35 @@ type consumerHandler struct {
 	// failures strategy
 	failStrategy kafka.FailStrategy
 
-	// committing after every batch
-	commitSync bool
+	// decisions about the messages that failed processing, which replace the failures strategy when set
+	failure patronfailure.Strategy
 
-	// lock to protect buffer operation
-	mu     sync.RWMutex
-	msgBuf []*sarama.ConsumerMessage
+	// committing of the offsets of the processed messages
+	commitStrategy kafka.CommitStrategy
+
+	// deadline for processing a batch, disabled when zero
+	processTimeout time.Duration
+
+	// number of groups of keys the batches are split into and processed concurrently, disabled when not greater than 1
+	workers int
+
+	// skipping of the messages before they are processed, disabled when nil
+	filter kafka.FilterFunc
+
+	// publishing of the failed messages of the dead letter strategy
+	deadLetter *deadLetter
+
+	// publishing of the failed messages to the retry topics, disabled when nil
+	retryTopics *retryTopics
+
+	// pausing of the consumption, disabled when nil
+	backpressure *backpressure
+
+	// callbacks of the partition assignment and revocation
+	rebalance rebalanceHooks
+
+	// lock to protect the processing state, which is shared by the claims
+	mu sync.Mutex
 
 	// processing error
 	err error
@@ -285,56 +381,140 @@ type consumerHandler struct {
 }
 
 func newConsumerHandler(ctx context.Context, name, group string, processorFunc kafka.BatchProcessorFunc,
-	fs kafka.FailStrategy, batchSize uint, batchTimeout time.Duration, commitSync bool) *consumerHandler {
-
+	fs kafka.FailStrategy, batchSize uint, batchTimeout time.Duration, commitStrategy kafka.CommitStrategy, processTimeout time.Duration,
+	workers uint, filter kafka.FilterFunc, deadLetter *deadLetter, retryTopics *retryTopics, backpressure *backpressure, rebalance rebalanceHooks) *consumerHandler {
 	return &consumerHandler{
-		ctx:          ctx,
-		name:         name,
-		group:        group,
-		batchSize:    int(batchSize),
-		ticker:       time.NewTicker(batchTimeout),
-		msgBuf:       make([]*sarama.ConsumerMessage, 0, batchSize),
-		mu:           sync.RWMutex{},
-		proc:         processorFunc,
-		failStrategy: fs,
-		commitSync:   commitSync,
+		ctx:            ctx,
+		name:           name,
+		group:          group,
+		batchSize:      int(batchSize),
+		batchTimeout:   batchTimeout,
+		proc:           processorFunc,
+		failStrategy:   fs,
+		commitStrategy: commitStrategy,
+		processTimeout: processTimeout,
+		workers:        int(workers),
+		filter:         filter,
+		deadLetter:     deadLetter,
+		retryTopics:    retryTopics,
+		backpressure:   backpressure,
+		rebalance:      rebalance,
 	}
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
-func (c *consumerHandler) Setup(sarama.ConsumerGroupSession) error {
+func (c *consumerHandler) Setup(session sarama.ConsumerGroupSession) error {
+	log.Infof("kafka component %s assigned partitions %v", c.name, session.Claims())
+	if c.rebalance.assigned == nil {
+		return nil
+	}
+	if err := c.rebalance.assigned(session.Context(), session.Claims()); err != nil {
+		return fmt.Errorf("partitions assigned callback failed: %w", err)
+	}
 	return nil
 }
 
-// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited
-func (c *consumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
+// Cleanup is run at the end of a session, once all ConsumeClaim goroutines have exited,
+// so the messages of the revoked partitions have already been processed.
+func (c *consumerHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	log.Infof("kafka component %s revoked partitions %v", c.name, session.Claims())
+	// the marked offsets are committed before the partitions are handed off
+	session.Commit()
+	if c.rebalance.revoked == nil {
+		return nil
+	}
+	if err := c.rebalance.revoked(c.ctx, session.Claims()); err != nil {
+		return fmt.Errorf("partitions revoked callback failed: %w", err)
+	}
 	return nil
 }
 
+// claimBuffer buffers the messages of a claim, so that the batches of a partition are processed, and wait for their retries,
+// independently of the other partitions of the consumer.
+type claimBuffer struct {
+	// lock to protect buffer operation
+	mu   sync.Mutex
+	msgs []*sarama.ConsumerMessage
+	// filtered messages received after the first buffered message, which are marked along with the buffer
+	filtered []*sarama.ConsumerMessage
+	// receipt of the first buffered message, and the timer of the batch timeout since then
+	start time.Time
+	timer *time.Timer
+}
+
+func (c *consumerHandler) newClaimBuffer() *claimBuffer {
+	timer := time.NewTimer(c.batchTimeout)
+	timer.Stop()
+	return &claimBuffer{
+		msgs:  make([]*sarama.ConsumerMessage, 0, c.batchSize),
+		timer: timer,
+	}
+}
+
+// fail sets the processing error, which stops the consumer, and returns it.
+func (c *consumerHandler) fail(err error) error {
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	return err
+}
+
 // ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages().
 func (c *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	buf := c.newClaimBuffer()
+	defer buf.timer.Stop()
+
 	for {
+		messages := claim.Messages()
+		var resumed <-chan struct{}
+		if c.backpressure != nil {
+			var paused bool
+			if paused, resumed = c.backpressure.state(); paused {
+				// the client stops fetching once its buffer is full
+				messages = nil
+			}
+		}
+
 		select {
-		case msg, ok := <-claim.Messages():
+		case msg, ok := <-messages:
 			if ok {
 				log.Debugf("message claimed: value = %s, timestamp = %v, topic = %s", string(msg.Value), msg.Timestamp, msg.Topic)
 				topicPartitionOffsetDiffGaugeSet(c.group, msg.Topic, msg.Partition, claim.HighWaterMarkOffset(), msg.Offset)
 				messageStatusCountInc(messageReceived, c.group, msg.Topic)
-				err := c.insertMessage(session, msg)
+				if c.skip(session, buf, msg) {
+					continue
+				}
+				due, err := c.waitForRetry(session, buf, msg)
+				if err != nil || !due {
+					return err
+				}
+				err = c.insertMessage(session, buf, msg)
 				if err != nil {
 					return err
 				}
 			} else {
 				log.Debug("messages channel closed")
-				return nil
+				// the buffered messages are processed while the session is still active, so that their offsets are marked
+				buf.mu.Lock()
+				err := c.flush(session, buf)
+				buf.mu.Unlock()
+				return err
 			}
-		case <-c.ticker.C:
-			c.mu.Lock()
-			err := c.flush(session)
-			c.mu.Unlock()
+		case <-buf.timer.C:
+			buf.mu.Lock()
+			err := c.flushExpired(session, buf)
+			buf.mu.Unlock()
 			if err != nil {
 				return err
 			}
+		case <-resumed:
+		case <-session.Context().Done():
+			// the claim is revoked, e.g. on a rebalance, so the buffered messages are processed before it is handed off,
+			// while the messages which have not been received yet are left to the next owner of the partition
+			buf.mu.Lock()
+			err := c.flush(session, buf)
+			buf.mu.Unlock()
+			return err
 		case <-c.ctx.Done():
 			if c.ctx.Err() != context.Canceled {
 				log.Infof("closing consumer: %v", c.ctx.Err())
@@ -344,53 +524,237 @@ func (c *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 	}
 }
 
-func (c *consumerHandler) flush(session sarama.ConsumerGroupSession) error {
-	if len(c.msgBuf) > 0 {
-		messages := make([]kafka.Message, 0, len(c.msgBuf))
-		for _, msg := range c.msgBuf {
-			messageStatusCountInc(messageProcessed, c.group, msg.Topic)
-			ctx, sp := c.getContextWithCorrelation(msg)
-			messages = append(messages, kafka.NewMessage(ctx, sp, msg))
+func (c *consumerHandler) flush(session sarama.ConsumerGroupSession, buf *claimBuffer) error {
+	if len(buf.msgs) > 0 {
+		if c.commitStrategy == kafka.MessageCommitStrategy {
+			// every message is committed right after it is processed, before the next one is processed
+			for i := range buf.msgs {
+				if err := c.processMessages(session, buf.msgs[i:i+1]); err != nil {
+					return err
+				}
+				session.Commit()
+			}
+		} else if err := c.processMessages(session, buf.msgs); err != nil {
+			return err
 		}
 
-		btc := kafka.NewBatch(messages)
-		err := c.proc(btc)
-		if err != nil {
-			if c.ctx.Err() == context.Canceled {
-				return fmt.Errorf("context was cancelled after processing error: %w", err)
+		for _, msg := range buf.filtered {
+			session.MarkMessage(msg, "")
+		}
+
+		if c.commitStrategy == kafka.BatchCommitStrategy || c.commitStrategy == kafka.ManualCommitStrategy ||
+			(c.commitStrategy == kafka.MessageCommitStrategy && len(buf.filtered) > 0) {
+			session.Commit()
+		}
+
+		buf.msgs = buf.msgs[:0]
+		buf.filtered = buf.filtered[:0]
+	}
+
+	return nil
+}
+
+// processContext returns the context of the processing, which is the drain context of the service, if any, so that the batch
+// in flight when the service is terminated is processed until the shutdown deadline instead of being cancelled right away.
+func (c *consumerHandler) processContext() context.Context {
+	return shutdown.DrainFromContext(c.ctx)
+}
+
+// processMessages processes the messages, handles the ones which failed with the retry topics and the failure strategy,
+// and marks their offsets.
+func (c *consumerHandler) processMessages(session sarama.ConsumerGroupSession, msgs []*sarama.ConsumerMessage) error {
+	ctx := c.processContext()
+	if c.processTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.processTimeout)
+		defer cancel()
+	}
+
+	messages := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		messageStatusCountInc(messageProcessed, c.group, msg.Topic)
+		msgCtx, sp := c.getContextWithCorrelation(ctx, msg)
+		m := kafka.NewMessage(msgCtx, sp, msg)
+		if c.commitStrategy == kafka.ManualCommitStrategy {
+			m = &ackMessage{msg: m}
+		}
+		messages = append(messages, m)
+	}
+
+	groups := keyGroups(messages, c.workers)
+	errs := runConcurrently(groups, func(group []kafka.Message) error {
+		return c.process(ctx, kafka.NewBatch(group))
+	})
+	var ff []failure
+	for i, err := range errs {
+		if err != nil && c.ctx.Err() == context.Canceled {
+			return fmt.Errorf("context was cancelled after processing error: %w", err)
+		}
+		ff = append(ff, c.failures(groups[i], err)...)
+	}
+	for _, f := range ff {
+		if c.failure != nil {
+			err := c.handleFailure(session, f)
+			if err != nil {
+				return err
 			}
-			err := c.executeFailureStrategy(messages, err)
+			continue
+		}
+		failed, retryErr := c.retry(f.messages, f.err)
+		if retryErr != nil {
+			return retryErr
+		}
+		if len(failed) > 0 {
+			err := c.executeFailureStrategy(failed, f.err, c.failStrategy)
 			if err != nil {
 				return err
 			}
 		}
+	}
 
-		c.processedMessages = true
-		for _, m := range messages {
-			trace.SpanSuccess(m.Span())
-			session.MarkMessage(m.Message(), "")
+	c.mu.Lock()
+	c.processedMessages = true
+	c.mu.Unlock()
+	for _, m := range messages {
+		trace.SpanSuccess(m.Span())
+		session.MarkMessage(m.Message(), "")
+	}
+	return nil
+}
+
+// failure of processing messages, which is handled by the retry topics and the failure strategy.
+type failure struct {
+	messages []kafka.Message
+	err      error
+}
+
+// failures returns the messages which failed processing along with their error. With the kafka.ManualCommitStrategy,
+// these are the messages which were not acknowledged, otherwise all the messages of a batch which failed processing.
+func (c *consumerHandler) failures(messages []kafka.Message, err error) []failure {
+	if c.commitStrategy != kafka.ManualCommitStrategy {
+		if err == nil {
+			return nil
 		}
+		return []failure{{messages: messages, err: err}}
+	}
 
-		if c.commitSync {
-			session.Commit()
+	var ff []failure
+	for _, m := range messages {
+		status, nackErr := m.(*ackMessage).result()
+		switch {
+		case status == ackAcked:
+			continue
+		case status == ackNacked:
+			ff = append(ff, failure{messages: []kafka.Message{m}, err: nackErr})
+		case err != nil:
+			ff = append(ff, failure{messages: []kafka.Message{m}, err: err})
+		default:
+			ff = append(ff, failure{messages: []kafka.Message{m}, err: errNotAcknowledged})
 		}
+	}
+	return ff
+}
 
-		c.msgBuf = c.msgBuf[:0]
+// process runs the processor function on the batch. When a process timeout is set, the contexts of the messages
+// are cancelled once the deadline is exceeded, and the processing is abandoned with a timeout error, so that a processor
+// which does not honour the context does not block the partition, and the messages are not marked as successful.
+// The result of a processor which returns after the deadline is discarded.
+func (c *consumerHandler) process(ctx context.Context, btc kafka.Batch) error {
+	if c.processTimeout <= 0 {
+		return c.runProc(btc)
 	}
 
-	return nil
+	chErr := make(chan error, 1)
+	go func() {
+		chErr <- c.runProc(btc)
+	}()
+
+	select {
+	case err := <-chErr:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			log.Errorf("processing of batch exceeded timeout of %v", c.processTimeout)
+			return fmt.Errorf("processing exceeded timeout of %v: %w", c.processTimeout, ctx.Err())
+		}
+		return ctx.Err()
+	}
 }
 
-func (c *consumerHandler) executeFailureStrategy(messages []kafka.Message, err error) error {
-	switch c.failStrategy {
+// runProc runs the processor function, tracking the messages in flight until it returns, even if the processing was abandoned.
+func (c *consumerHandler) runProc(btc kafka.Batch) error {
+	if c.backpressure == nil {
+		return c.proc(btc)
+	}
+	messages := len(btc.Messages())
+	c.backpressure.track(messages)
+	defer c.backpressure.track(-messages)
+	return c.proc(btc)
+}
+
+// retry publishes the messages that failed processing to the retry topics, if they are set, returning the messages
+// which have exhausted their retries and have to be handled by the failure strategy.
+func (c *consumerHandler) retry(messages []kafka.Message, err error) ([]kafka.Message, error) {
+	if c.retryTopics == nil {
+		return messages, nil
+	}
+	retried, exhausted, retryErr := c.retryTopics.publish(c.ctx, messages, err)
+	if retryErr != nil {
+		log.Errorf("could not publish message(s) to the retry topics: %v", retryErr)
+		return nil, c.fail(retryErr)
+	}
+	if len(retried) > 0 {
+		log.Errorf("could not process message(s) so publishing them to the retry topics with error: %v", err)
+	}
+	for _, m := range retried {
+		trace.SpanError(m.Span())
+		messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
+		messageStatusCountInc(messageRetried, c.group, m.Message().Topic)
+	}
+	return exhausted, nil
+}
+
+// waitForRetry waits until a message consumed from a retry topic is due, after processing the buffered messages so that
+// they do not wait along. Only the partition of the retry topic waits, and the wait ends as soon as the claim is revoked,
+// e.g. on a rebalance, leaving the message to the next owner of the partition. It returns false if the consumer stopped
+// or the claim was revoked in the meantime.
+func (c *consumerHandler) waitForRetry(session sarama.ConsumerGroupSession, buf *claimBuffer, msg *sarama.ConsumerMessage) (bool, error) {
+	if c.retryTopics == nil {
+		return true, nil
+	}
+	wait := c.retryTopics.wait(msg)
+	if wait <= 0 {
+		return true, nil
+	}
+
+	buf.mu.Lock()
+	err := c.flush(session, buf)
+	buf.mu.Unlock()
+	if err != nil {
+		return false, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true, nil
+	case <-c.ctx.Done():
+		return false, nil
+	case <-session.Context().Done():
+		return false, nil
+	}
+}
+
+func (c *consumerHandler) executeFailureStrategy(messages []kafka.Message, err error, fs kafka.FailStrategy) error {
+	switch fs {
 	case kafka.ExitStrategy:
 		for _, m := range messages {
 			trace.SpanError(m.Span())
 			messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
 		}
 		log.Errorf("could not process message(s)")
-		c.err = err
-		return err
+		return c.fail(err)
 	case kafka.SkipStrategy:
 		for _, m := range messages {
 			trace.SpanError(m.Span())
@@ -398,29 +762,179 @@ func (c *consumerHandler) executeFailureStrategy(messages []kafka.Message, err e
 			messageStatusCountInc(messageSkipped, c.group, m.Message().Topic)
 		}
 		log.Errorf("could not process message(s) so skipping with error: %v", err)
+	case kafka.DeadLetterStrategy:
+		for _, m := range messages {
+			trace.SpanError(m.Span())
+			messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
+		}
+		log.Errorf("could not process message(s) so publishing them to the dead letter topic with error: %v", err)
+		if dlErr := c.deadLetter.publish(c.ctx, messages, err); dlErr != nil {
+			log.Errorf("could not publish message(s) to the dead letter topic: %v", dlErr)
+			return c.fail(dlErr)
+		}
 	default:
 		log.Errorf("unknown failure strategy executed")
-		return fmt.Errorf("unknown failure strategy: %v", c.failStrategy)
+		return fmt.Errorf("unknown failure strategy: %v", fs)
 	}
 	return nil
 }
 
-func (c *consumerHandler) getContextWithCorrelation(msg *sarama.ConsumerMessage) (context.Context, opentracing.Span) {
+// failStrategies maps the actions of the failure strategies to the failures strategies which execute them.
+var failStrategies = map[patronfailure.Action]kafka.FailStrategy{
+	patronfailure.Stop:       kafka.ExitStrategy,
+	patronfailure.Skip:       kafka.SkipStrategy,
+	patronfailure.DeadLetter: kafka.DeadLetterStrategy,
+}
+
+// handleFailure applies the decisions of the failure strategy to the messages which failed processing. While the strategy
+// decides to retry them, the messages are processed again after the delay, which blocks only the partition of their claim
+// in the meantime, since every claim processes its own buffer.
+func (c *consumerHandler) handleFailure(session sarama.ConsumerGroupSession, f failure) error {
+	messages, err := f.messages, f.err
+	for attempt := uint(1); ; attempt++ {
+		d := c.failure.Decide(attempt, err)
+		if d.Action != patronfailure.Retry {
+			fs, ok := failStrategies[d.Action]
+			switch {
+			case !ok:
+				return c.fail(fmt.Errorf("unknown failure action: %v", d.Action))
+			case fs == kafka.DeadLetterStrategy && c.deadLetter == nil:
+				return c.fail(errors.New("dead letter action requires a dead letter topic"))
+			}
+			return c.executeFailureStrategy(messages, err, fs)
+		}
+
+		log.Errorf("could not process message(s) so retrying them in %v with error: %v", d.Delay, err)
+		for _, m := range messages {
+			trace.SpanError(m.Span())
+			messageStatusCountInc(messageErrored, c.group, m.Message().Topic)
+			messageStatusCountInc(messageRetried, c.group, m.Message().Topic)
+		}
+		if !c.wait(session, d.Delay) {
+			return fmt.Errorf("consumer stopped before retrying processing error: %w", err)
+		}
+
+		messages, err = c.reprocess(messages)
+		if len(messages) == 0 {
+			return nil
+		}
+		if err != nil && c.ctx.Err() == context.Canceled {
+			return fmt.Errorf("context was cancelled after processing error: %w", err)
+		}
+	}
+}
+
+// wait waits for the delay, returning false if the consumer stopped in the meantime.
+func (c *consumerHandler) wait(session sarama.ConsumerGroupSession, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-c.ctx.Done():
+		return false
+	case <-session.Context().Done():
+		return false
+	}
+}
+
+// reprocess processes the messages again in a new attempt, with new spans, returning the messages which failed
+// along with the first of their errors.
+func (c *consumerHandler) reprocess(messages []kafka.Message) ([]kafka.Message, error) {
+	ctx := c.processContext()
+	if c.processTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.processTimeout)
+		defer cancel()
+	}
+
+	attempt := make([]kafka.Message, 0, len(messages))
+	for _, m := range messages {
+		msgCtx, sp := c.getContextWithCorrelation(ctx, m.Message())
+		am := kafka.NewMessage(msgCtx, sp, m.Message())
+		if c.commitStrategy == kafka.ManualCommitStrategy {
+			am = &ackMessage{msg: am}
+		}
+		attempt = append(attempt, am)
+	}
+
+	var failed []kafka.Message
+	var failedErr error
+	for _, f := range c.failures(attempt, c.process(ctx, kafka.NewBatch(attempt))) {
+		failed = append(failed, f.messages...)
+		if failedErr == nil {
+			failedErr = f.err
+		}
+	}
+
+	succeeded := make(map[kafka.Message]bool, len(attempt))
+	for _, m := range attempt {
+		succeeded[m] = true
+	}
+	for _, m := range failed {
+		succeeded[m] = false
+	}
+	for _, m := range attempt {
+		if succeeded[m] {
+			trace.SpanSuccess(m.Span())
+		}
+	}
+	return failed, failedErr
+}
+
+func (c *consumerHandler) getContextWithCorrelation(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, opentracing.Span) {
 	corID := getCorrelationID(msg.Headers)
 
-	sp, ctxCh := trace.ConsumerSpan(c.ctx, trace.ComponentOpName(consumerComponent, msg.Topic),
-		consumerComponent, corID, mapHeader(msg.Headers))
+	hdr := mapHeader(msg.Headers)
+	sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, msg.Topic),
+		consumerComponent, corID, hdr)
 	ctxCh = correlation.ContextWithID(ctxCh, corID)
+	ctxCh = correlation.ContextWithHeaders(ctxCh, func(header string) string { return hdr[header] })
 	ctxCh = log.WithContext(ctxCh, log.Sub(map[string]interface{}{correlation.ID: corID}))
 	return ctxCh, sp
 }
 
-func (c *consumerHandler) insertMessage(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.msgBuf = append(c.msgBuf, msg)
-	if len(c.msgBuf) >= c.batchSize {
-		return c.flush(session)
+// flushExpired processes the buffer once its first message has waited for the batch timeout.
+// The timer may fire for a batch which was already processed, in which case it is rearmed for the current one.
+func (c *consumerHandler) flushExpired(session sarama.ConsumerGroupSession, buf *claimBuffer) error {
+	if len(buf.msgs) == 0 {
+		return nil
+	}
+	if wait := c.batchTimeout - time.Since(buf.start); wait > 0 {
+		buf.timer.Reset(wait)
+		return nil
+	}
+	return c.flush(session, buf)
+}
+
+// skip returns true if the message is skipped by the filter. The offset of a skipped message is marked right away when no messages
+// are buffered, otherwise along with the buffered messages, so that it is not committed before the messages preceding it are processed.
+func (c *consumerHandler) skip(session sarama.ConsumerGroupSession, buf *claimBuffer, msg *sarama.ConsumerMessage) bool {
+	if c.filter == nil || c.filter(msg.Headers, msg.Key) {
+		return false
+	}
+	messageStatusCountInc(messageFiltered, c.group, msg.Topic)
+
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	if len(buf.msgs) == 0 {
+		session.MarkMessage(msg, "")
+		return true
+	}
+	buf.filtered = append(buf.filtered, msg)
+	return true
+}
+
+func (c *consumerHandler) insertMessage(session sarama.ConsumerGroupSession, buf *claimBuffer, msg *sarama.ConsumerMessage) error {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	if len(buf.msgs) == 0 {
+		buf.start = time.Now()
+		buf.timer.Reset(c.batchTimeout)
+	}
+	buf.msgs = append(buf.msgs, msg)
+	if len(buf.msgs) >= c.batchSize {
+		return c.flush(session, buf)
 	}
 	return nil
 }
@@ -4,16 +4,21 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/component/shutdown"
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/encoding"
 	"github.com/beatlabs/patron/encoding/json"
 	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -101,6 +106,11 @@ func TestNew(t *testing.T) {
 			args:    args{name: "name", group: "grp", brokers: []string{"localhost:9092"}, topics: []string{"topicone"}, p: proc.Process, batchSize: 1, batchTimeout: -2, retryWait: 2, saramaCfg: saramaCfg},
 			wantErr: true,
 		},
+		{
+			name:    "failed, dead letter strategy without topic",
+			args:    args{name: "name", group: "grp", brokers: []string{"localhost:9092"}, topics: []string{"topicone"}, p: proc.Process, batchSize: 1, batchTimeout: time.Second, retryWait: 2, fs: kafka.DeadLetterStrategy, saramaCfg: saramaCfg},
+			wantErr: true,
+		},
 		{
 			name:    "failed, no sarama configuration",
 			args:    args{name: "name", group: "grp", brokers: []string{"localhost:9092"}, topics: []string{"topicone"}, p: proc.Process, batchSize: 10, batchTimeout: time.Second, retryWait: 2, saramaCfg: nil},
@@ -197,18 +207,39 @@ func (m *mockConsumerClaim) Partition() int32           { return 0 }
 func (m *mockConsumerClaim) InitialOffset() int64       { return 0 }
 func (m *mockConsumerClaim) HighWaterMarkOffset() int64 { return 1 }
 
-type mockConsumerSession struct{}
+type mockConsumerSession struct {
+	mu      sync.Mutex
+	marked  int
+	offsets []int64
+	commits int
+	claims  map[string][]int32
+	ctx     context.Context
+}
 
-func (m *mockConsumerSession) Claims() map[string][]int32 { return nil }
+func (m *mockConsumerSession) Claims() map[string][]int32 { return m.claims }
 func (m *mockConsumerSession) MemberID() string           { return "" }
 func (m *mockConsumerSession) GenerationID() int32        { return 0 }
 func (m *mockConsumerSession) MarkOffset(string, int32, int64, string) {
 }
-func (m *mockConsumerSession) Commit() {}
+func (m *mockConsumerSession) Commit() {
+	m.mu.Lock()
+	m.commits++
+	m.mu.Unlock()
+}
 func (m *mockConsumerSession) ResetOffset(string, int32, int64, string) {
 }
-func (m *mockConsumerSession) MarkMessage(*sarama.ConsumerMessage, string) {}
-func (m *mockConsumerSession) Context() context.Context                    { return context.Background() }
+func (m *mockConsumerSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.marked++
+	m.offsets = append(m.offsets, msg.Offset)
+}
+func (m *mockConsumerSession) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
 
 func TestHandler_ConsumeClaim(t *testing.T) {
 
@@ -280,7 +311,7 @@ func TestHandler_ConsumeClaim(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 			h := newConsumerHandler(ctx, tt.name, "grp", tt.proc.Process, tt.failStrategy, tt.batchSize,
-				10*time.Millisecond, true)
+				10*time.Millisecond, kafka.BatchCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
 
 			ch := make(chan *sarama.ConsumerMessage, len(tt.msgs))
 			for _, m := range tt.msgs {
@@ -301,6 +332,159 @@ func TestHandler_ConsumeClaim(t *testing.T) {
 	}
 }
 
+func TestHandler_ConsumeClaim_ProcessTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	neverReturns := func(kafka.Batch) error {
+		<-release
+		return nil
+	}
+
+	tests := map[string]struct {
+		proc         kafka.BatchProcessorFunc
+		failStrategy kafka.FailStrategy
+		expectedErr  string
+		expectMarked int
+	}{
+		"timeout with exit strategy": {
+			proc:         neverReturns,
+			failStrategy: kafka.ExitStrategy,
+			expectedErr:  "processing exceeded timeout of 10ms: context deadline exceeded",
+		},
+		"timeout with skip strategy": {
+			proc:         neverReturns,
+			failStrategy: kafka.SkipStrategy,
+			expectMarked: 1,
+		},
+		"success within timeout": {
+			proc:         func(kafka.Batch) error { return nil },
+			failStrategy: kafka.ExitStrategy,
+			expectMarked: 1,
+		},
+		"failure within timeout": {
+			proc:         func(kafka.Batch) error { return errProcess },
+			failStrategy: kafka.ExitStrategy,
+			expectedErr:  errProcess.Error(),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h := newConsumerHandler(ctx, name, "grp", tt.proc, tt.failStrategy, 1, 10*time.Millisecond, kafka.BatchCommitStrategy,
+				10*time.Millisecond, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+			msgs := saramaConsumerMessages(json.Type)
+			ch := make(chan *sarama.ConsumerMessage, len(msgs))
+			for _, m := range msgs {
+				ch <- m
+			}
+			close(ch)
+			session := &mockConsumerSession{}
+			err := h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectMarked, session.marked)
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_ProcessTimeout_LateSuccess(t *testing.T) {
+	var returned int32
+	late := func(kafka.Batch) error {
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&returned, 1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := newConsumerHandler(ctx, "name", "grp", late, kafka.ExitStrategy, 1, 10*time.Millisecond, kafka.BatchCommitStrategy,
+		10*time.Millisecond, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+	msgs := saramaConsumerMessages(json.Type)
+	ch := make(chan *sarama.ConsumerMessage, len(msgs))
+	for _, m := range msgs {
+		ch <- m
+	}
+	close(ch)
+	session := &mockConsumerSession{}
+	err := h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+
+	assert.EqualError(t, err, "processing exceeded timeout of 10ms: context deadline exceeded")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&returned), "the processing is not awaited after the timeout")
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&returned) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, session.marked, "the result of the processor returning after the timeout is discarded")
+}
+
+func TestHandler_ConsumeClaim_Batching(t *testing.T) {
+	tests := map[string]struct {
+		batchSize     uint
+		batchTimeout  time.Duration
+		interval      time.Duration
+		closeClaim    bool
+		expectedSizes []int
+	}{
+		"full batches":                      {batchSize: 2, batchTimeout: time.Hour, closeClaim: true, expectedSizes: []int{2, 2, 1}},
+		"batch timeout since first message": {batchSize: 10, batchTimeout: 50 * time.Millisecond, expectedSizes: []int{5}},
+		"zero batch timeout":                {batchSize: 10, interval: 10 * time.Millisecond, expectedSizes: []int{1, 1, 1, 1, 1}},
+		"remaining messages on close":       {batchSize: 10, batchTimeout: time.Hour, closeClaim: true, expectedSizes: []int{5}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var mu sync.Mutex
+			var sizes []int
+			var waited time.Duration
+			first := time.Now()
+			proc := func(btc kafka.Batch) error {
+				mu.Lock()
+				defer mu.Unlock()
+				sizes = append(sizes, len(btc.Messages()))
+				waited = time.Since(first)
+				return nil
+			}
+			h := newConsumerHandler(ctx, name, "grp", proc, kafka.ExitStrategy, tt.batchSize, tt.batchTimeout, kafka.BatchCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+			ch := make(chan *sarama.ConsumerMessage)
+			session := &mockConsumerSession{}
+			chDone := make(chan error)
+			go func() {
+				chDone <- h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+			}()
+			for i := 0; i < 5; i++ {
+				ch <- saramaConsumerMessage("value", &sarama.RecordHeader{})
+				time.Sleep(tt.interval)
+			}
+			if tt.closeClaim {
+				close(ch)
+			} else {
+				assert.Eventually(t, func() bool {
+					mu.Lock()
+					defer mu.Unlock()
+					return len(sizes) == len(tt.expectedSizes)
+				}, time.Second, time.Millisecond)
+				cancel()
+			}
+			assert.NoError(t, <-chDone)
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, tt.expectedSizes, sizes)
+			assert.Equal(t, 5, session.marked)
+			if tt.batchTimeout == 50*time.Millisecond {
+				assert.GreaterOrEqual(t, int64(waited), int64(tt.batchTimeout), "the batch is processed once the first message has waited for the timeout")
+			}
+		})
+	}
+}
+
 func saramaConsumerMessages(ct string) []*sarama.ConsumerMessage {
 	return []*sarama.ConsumerMessage{
 		saramaConsumerMessage("value", &sarama.RecordHeader{
@@ -352,3 +536,100 @@ func Test_getCorrelationID(t *testing.T) {
 	})
 	assert.NotEqual(t, emptyCorID, got)
 }
+
+func TestHandler_getContextWithCorrelation(t *testing.T) {
+	defer correlation.PropagateHeaders()
+	correlation.PropagateHeaders("X-Tenant-Id")
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+	producerSp := mtr.StartSpan("producer")
+	hdr := opentracing.TextMapCarrier{}
+	require.NoError(t, mtr.Inject(producerSp.Context(), opentracing.TextMap, hdr))
+
+	msg := saramaConsumerMessage("value", &sarama.RecordHeader{Key: []byte(correlation.HeaderID), Value: []byte("corID")})
+	msg.Headers = append(msg.Headers, &sarama.RecordHeader{Key: []byte("X-Tenant-Id"), Value: []byte("tenant")})
+	for k, v := range hdr {
+		msg.Headers = append(msg.Headers, &sarama.RecordHeader{Key: []byte(k), Value: []byte(v)})
+	}
+
+	h := newConsumerHandler(context.Background(), "name", "grp", nil, kafka.ExitStrategy, 1, time.Second, kafka.AutoCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+	ctx, sp := h.getContextWithCorrelation(context.Background(), msg)
+	assert.Equal(t, "corID", correlation.IDFromContext(ctx))
+	assert.Equal(t, map[string]string{"X-Tenant-Id": "tenant"}, correlation.HeadersFromContext(ctx))
+	assert.Equal(t, producerSp.(*mocktracer.MockSpan).SpanContext.TraceID, sp.(*mocktracer.MockSpan).SpanContext.TraceID,
+		"the consumer span joins the trace of the producer")
+}
+
+func TestHandler_ConsumeClaim_MessageCommitStrategy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &mockConsumerSession{}
+	var sizes []int
+	proc := func(btc kafka.Batch) error {
+		sizes = append(sizes, len(btc.Messages()))
+		if string(btc.Messages()[0].Message().Value) == "2" {
+			assert.Equal(t, 1, session.commits, "the previous message is committed before the next one is processed")
+			return errProcess
+		}
+		return nil
+	}
+	h := newConsumerHandler(ctx, "message", "grp", proc, kafka.ExitStrategy, 3, time.Hour, kafka.MessageCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+	ch := make(chan *sarama.ConsumerMessage, 3)
+	for i, value := range []string{"1", "2", "3"} {
+		msg := saramaConsumerMessage(value, &sarama.RecordHeader{})
+		msg.Offset = int64(i)
+		ch <- msg
+	}
+	close(ch)
+	err := h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	assert.EqualError(t, err, "PROC ERROR")
+	assert.Equal(t, []int{1, 1}, sizes)
+	assert.Equal(t, []int64{0}, session.offsets)
+	assert.Equal(t, 1, session.commits)
+}
+
+func TestHandler_ConsumeClaim_CommitStrategy(t *testing.T) {
+	tests := map[string]struct {
+		strategy        kafka.CommitStrategy
+		expectedCommits int
+	}{
+		"auto":    {strategy: kafka.AutoCommitStrategy, expectedCommits: 0},
+		"message": {strategy: kafka.MessageCommitStrategy, expectedCommits: 5},
+		"batch":   {strategy: kafka.BatchCommitStrategy, expectedCommits: 3},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			proc := &mockProcessor{}
+			h := newConsumerHandler(ctx, name, "grp", proc.Process, kafka.ExitStrategy, 2, time.Hour, tt.strategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+			ch := make(chan *sarama.ConsumerMessage, 5)
+			for i := 0; i < 5; i++ {
+				ch <- saramaConsumerMessage("value", &sarama.RecordHeader{})
+			}
+			close(ch)
+			session := &mockConsumerSession{}
+			require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+			assert.Equal(t, 5, session.marked)
+			assert.Equal(t, tt.expectedCommits, session.commits)
+		})
+	}
+}
+
+func TestConsumerHandler_processContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := consumerHandler{ctx: ctx}
+	assert.Equal(t, ctx, h.processContext())
+
+	drain, cancelDrain := context.WithCancel(context.Background())
+	defer cancelDrain()
+	h = consumerHandler{ctx: shutdown.ContextWithDrain(ctx, drain)}
+	cancel()
+	assert.NoError(t, h.processContext().Err(), "the batch in flight is processed in the drain context")
+	cancelDrain()
+	assert.Error(t, h.processContext().Err())
+}
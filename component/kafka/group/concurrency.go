@@ -0,0 +1,58 @@
+package group
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/beatlabs/patron/component/kafka"
+)
+
+// keyGroups splits the messages into at most the number of workers groups, so that the messages with the same key
+// are in the same group, in the order they were consumed. The messages without a key are spread by their offset.
+func keyGroups(messages []kafka.Message, workers int) [][]kafka.Message {
+	if workers <= 1 || len(messages) <= 1 {
+		return [][]kafka.Message{messages}
+	}
+
+	buckets := make([][]kafka.Message, workers)
+	for _, m := range messages {
+		msg := m.Message()
+		var i int
+		if len(msg.Key) == 0 {
+			i = int(msg.Offset % int64(workers))
+		} else {
+			h := fnv.New32a()
+			_, _ = h.Write(msg.Key)
+			i = int(h.Sum32() % uint32(workers))
+		}
+		buckets[i] = append(buckets[i], m)
+	}
+
+	groups := make([][]kafka.Message, 0, workers)
+	for _, b := range buckets {
+		if len(b) > 0 {
+			groups = append(groups, b)
+		}
+	}
+	return groups
+}
+
+// runConcurrently runs the function for every group in its own goroutine, returning their errors in the order of the groups.
+func runConcurrently(groups [][]kafka.Message, f func([]kafka.Message) error) []error {
+	errs := make([]error, len(groups))
+	if len(groups) == 1 {
+		errs[0] = f(groups[0])
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(groups))
+	for i, g := range groups {
+		go func(i int, g []kafka.Message) {
+			defer wg.Done()
+			errs[i] = f(g)
+		}(i, g)
+	}
+	wg.Wait()
+	return errs
+}
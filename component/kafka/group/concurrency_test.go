@@ -0,0 +1,117 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keyGroups(t *testing.T) {
+	msg := func(key string, offset int64) kafka.Message {
+		m := &sarama.ConsumerMessage{Offset: offset}
+		if key != "" {
+			m.Key = []byte(key)
+		}
+		return kafka.NewMessage(context.Background(), opentracing.NoopTracer{}.StartSpan("test"), m)
+	}
+	messages := []kafka.Message{msg("a", 0), msg("b", 1), msg("a", 2), msg("c", 3), msg("b", 4), msg("", 5), msg("", 6)}
+
+	tests := map[string]struct {
+		workers        int
+		expectedGroups int
+	}{
+		"disabled":     {workers: 0, expectedGroups: 1},
+		"one worker":   {workers: 1, expectedGroups: 1},
+		"two workers":  {workers: 2, expectedGroups: 2},
+		"many workers": {workers: 100, expectedGroups: 5},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			groups := keyGroups(messages, tt.workers)
+			assert.Len(t, groups, tt.expectedGroups)
+
+			var count int
+			groupOfKey := make(map[string]int)
+			for i, g := range groups {
+				var lastOffset int64 = -1
+				for _, m := range g {
+					count++
+					assert.Greater(t, m.Message().Offset, lastOffset, "the messages of a group are in the order they were consumed")
+					lastOffset = m.Message().Offset
+					if len(m.Message().Key) == 0 {
+						continue
+					}
+					key := string(m.Message().Key)
+					if gi, ok := groupOfKey[key]; ok {
+						assert.Equal(t, gi, i, "the messages with the same key are in the same group")
+					}
+					groupOfKey[key] = i
+				}
+			}
+			assert.Equal(t, len(messages), count)
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_KeyOrderedConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	processed := make(map[string][]string)
+	started := make(chan struct{}, 2)
+	unblock := make(chan struct{})
+	errKey := errors.New("KEY ERROR")
+	proc := func(btc kafka.Batch) error {
+		started <- struct{}{}
+		<-unblock
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range btc.Messages() {
+			key := string(m.Message().Key)
+			processed[key] = append(processed[key], string(m.Message().Value))
+			if key == "b" {
+				return errKey
+			}
+		}
+		return nil
+	}
+	h := newConsumerHandler(ctx, "key-ordered", "grp", proc, kafka.SkipStrategy, 4, time.Hour, kafka.BatchCommitStrategy,
+		0, 2, nil, nil, nil, nil, rebalanceHooks{})
+
+	// the keys a and b are hashed to different workers
+	ch := make(chan *sarama.ConsumerMessage, 4)
+	for i, key := range []string{"a", "b", "a", "b"} {
+		msg := saramaConsumerMessage(string(rune('1'+i)), &sarama.RecordHeader{})
+		msg.Key = []byte(key)
+		ch <- msg
+	}
+	close(ch)
+	session := &mockConsumerSession{}
+	chDone := make(chan error)
+	go func() {
+		chDone <- h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			require.Fail(t, "the groups of keys are processed concurrently")
+		}
+	}
+	close(unblock)
+	require.NoError(t, <-chDone)
+	assert.Equal(t, map[string][]string{"a": {"1", "3"}, "b": {"2"}}, processed)
+	assert.Equal(t, 4, session.marked, "the failed group is handled by the failure strategy")
+	assert.Equal(t, 1, session.commits)
+}
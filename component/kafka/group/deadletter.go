@@ -0,0 +1,100 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	deadLetterReasonError   = "error"
+	deadLetterReasonTimeout = "timeout"
+)
+
+var deadLetterMessages *prometheus.CounterVec
+
+func init() {
+	deadLetterMessages = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "dead_letter_messages",
+			Help:      "Messages published to the dead letter topic, classified by the topic they were consumed from and the reason",
+		}, []string{"topic", "reason"},
+	)
+
+	prometheus.MustRegister(deadLetterMessages)
+}
+
+// Producer publishes the messages to the retry and the dead letter topics, e.g. the sync producer of the client/kafka/v2 package.
+type Producer interface {
+	SendBatch(ctx context.Context, messages []*sarama.ProducerMessage) error
+}
+
+type deadLetter struct {
+	topic    string
+	producer Producer
+}
+
+// publish publishes the messages which failed processing with the error to the dead letter topic.
+func (d *deadLetter) publish(ctx context.Context, messages []kafka.Message, err error) error {
+	reason := deadLetterReason(err)
+	pp := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := m.Message()
+		pp = append(pp, producerMessage(d.topic, msg,
+			sarama.RecordHeader{Key: []byte(kafka.DeadLetterErrorHeader), Value: []byte(err.Error())},
+			sarama.RecordHeader{Key: []byte(kafka.DeadLetterReasonHeader), Value: []byte(reason)},
+			sarama.RecordHeader{Key: []byte(kafka.DeadLetterTopicHeader), Value: []byte(msg.Topic)},
+			sarama.RecordHeader{Key: []byte(kafka.DeadLetterPartitionHeader), Value: []byte(strconv.FormatInt(int64(msg.Partition), 10))},
+			sarama.RecordHeader{Key: []byte(kafka.DeadLetterOffsetHeader), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		))
+	}
+
+	if err := d.producer.SendBatch(ctx, pp); err != nil {
+		return fmt.Errorf("failed to publish to dead letter topic %s: %w", d.topic, err)
+	}
+
+	for _, m := range messages {
+		deadLetterMessages.WithLabelValues(m.Message().Topic, reason).Inc()
+	}
+	return nil
+}
+
+// producerMessage creates a message for the topic with the key, the value and the headers of the consumed message,
+// where the given headers replace the ones with the same key.
+func producerMessage(topic string, msg *sarama.ConsumerMessage, hh ...sarama.RecordHeader) *sarama.ProducerMessage {
+	replaced := make(map[string]struct{}, len(hh))
+	for _, h := range hh {
+		replaced[string(h.Key)] = struct{}{}
+	}
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+len(hh))
+	for _, h := range msg.Headers {
+		if _, ok := replaced[string(h.Key)]; !ok {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers, hh...)
+
+	pm := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		pm.Key = sarama.ByteEncoder(msg.Key)
+	}
+	return pm
+}
+
+func deadLetterReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return deadLetterReasonTimeout
+	}
+	return deadLetterReasonError
+}
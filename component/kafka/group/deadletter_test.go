@@ -0,0 +1,120 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProducer struct {
+	mu       sync.Mutex
+	err      error
+	messages []*sarama.ProducerMessage
+}
+
+func (p *mockProducer) SendBatch(_ context.Context, messages []*sarama.ProducerMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, messages...)
+	return nil
+}
+
+func headerValue(hh []sarama.RecordHeader, key string) string {
+	for _, h := range hh {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func TestHandler_ConsumeClaim_DeadLetter(t *testing.T) {
+	stuck := func(btc kafka.Batch) error {
+		<-btc.Messages()[0].Context().Done()
+		return nil
+	}
+
+	tests := map[string]struct {
+		proc           kafka.BatchProcessorFunc
+		processTimeout time.Duration
+		producerErr    error
+		expectedErr    string
+		expectedError  string
+		expectedReason string
+	}{
+		"processing error": {
+			proc:           func(kafka.Batch) error { return errProcess },
+			expectedError:  "PROC ERROR",
+			expectedReason: deadLetterReasonError,
+		},
+		"processing timeout": {
+			proc:           stuck,
+			processTimeout: 10 * time.Millisecond,
+			expectedError:  "processing exceeded timeout of 10ms: context deadline exceeded",
+			expectedReason: deadLetterReasonTimeout,
+		},
+		"publishing error": {
+			proc:        func(kafka.Batch) error { return errProcess },
+			producerErr: errors.New("broker down"),
+			expectedErr: "failed to publish to dead letter topic dlq: broker down",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			deadLetterMessages.Reset()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			producer := &mockProducer{err: tt.producerErr}
+			h := newConsumerHandler(ctx, name, "grp", tt.proc, kafka.DeadLetterStrategy, 2, time.Hour, kafka.BatchCommitStrategy,
+				tt.processTimeout, 0, nil, &deadLetter{topic: "dlq", producer: producer}, nil, nil, rebalanceHooks{})
+
+			msgs := []*sarama.ConsumerMessage{
+				saramaConsumerMessage("1", &sarama.RecordHeader{Key: []byte(correlation.HeaderID), Value: []byte("123")}),
+				saramaConsumerMessage("2", &sarama.RecordHeader{Key: []byte(correlation.HeaderID), Value: []byte("456")}),
+			}
+			msgs[1].Offset = 1
+			ch := make(chan *sarama.ConsumerMessage, len(msgs))
+			for _, m := range msgs {
+				ch <- m
+			}
+			close(ch)
+			session := &mockConsumerSession{}
+			err := h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, 0, session.marked, "the offsets are not committed when publishing fails")
+				assert.Empty(t, producer.messages)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, session.marked)
+			require.Len(t, producer.messages, 2)
+			for i, pm := range producer.messages {
+				assert.Equal(t, "dlq", pm.Topic)
+				assert.Equal(t, sarama.ByteEncoder("key"), pm.Key)
+				assert.Equal(t, sarama.ByteEncoder(msgs[i].Value), pm.Value)
+				assert.Equal(t, string(msgs[i].Headers[0].Value), headerValue(pm.Headers, correlation.HeaderID))
+				assert.Equal(t, tt.expectedError, headerValue(pm.Headers, kafka.DeadLetterErrorHeader))
+				assert.Equal(t, tt.expectedReason, headerValue(pm.Headers, kafka.DeadLetterReasonHeader))
+				assert.Equal(t, "TEST_TOPIC", headerValue(pm.Headers, kafka.DeadLetterTopicHeader))
+				assert.Equal(t, "0", headerValue(pm.Headers, kafka.DeadLetterPartitionHeader))
+			}
+			assert.Equal(t, "0", headerValue(producer.messages[0].Headers, kafka.DeadLetterOffsetHeader))
+			assert.Equal(t, "1", headerValue(producer.messages[1].Headers, kafka.DeadLetterOffsetHeader))
+			assert.Equal(t, 2.0, testutil.ToFloat64(deadLetterMessages.WithLabelValues("TEST_TOPIC", tt.expectedReason)))
+		})
+	}
+}
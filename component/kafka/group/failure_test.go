@@ -0,0 +1,214 @@
+package group
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailure(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Failure(nil)(c), "failure strategy is nil")
+	require.NoError(t, Failure(patronfailure.SkipStrategy())(c))
+	assert.NotNil(t, c.failure)
+
+	producer := &mockProducer{}
+	_, err := New("name", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig(),
+		Failure(patronfailure.SkipStrategy()), RetryTopics(producer, time.Second))
+	assert.EqualError(t, err, "failure strategy cannot be combined with retry topics")
+
+	_, err = New("name", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig(),
+		Failure(patronfailure.SkipStrategy()), FailureStrategy(kafka.SkipStrategy), Retries(1), RetryWait(time.Second))
+	assert.EqualError(t, err, "failure strategy cannot be combined with fail strategy, retries, retry wait")
+
+	_, err = New("name", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig(),
+		Failure(patronfailure.DeadLetterStrategy()))
+	assert.EqualError(t, err, "failure strategy requires a dead letter topic")
+
+	_, err = New("name", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig(),
+		Failure(patronfailure.DeadLetterStrategy()), DeadLetterTopic("dlq", producer))
+	assert.NoError(t, err)
+}
+
+func TestHandler_ConsumeClaim_Failure(t *testing.T) {
+	retry := func(exhausted patronfailure.Action) patronfailure.Strategy {
+		s, err := patronfailure.RetryStrategy(3, patronfailure.ConstantBackoff(time.Millisecond), exhausted)
+		require.NoError(t, err)
+		return s
+	}
+	tests := map[string]struct {
+		strategy        patronfailure.Strategy
+		failures        int
+		deadLetter      bool
+		expectedExecs   int
+		expectedMarked  int
+		expectedDLQ     int
+		expectedErr     string
+		expectedRetried float64
+		expectedSkipped float64
+	}{
+		"retry succeeds": {
+			strategy: retry(patronfailure.Stop), failures: 2, expectedExecs: 3, expectedMarked: 1, expectedRetried: 2,
+		},
+		"retries exhausted to dead letter": {
+			strategy: retry(patronfailure.DeadLetter), failures: 5, deadLetter: true, expectedExecs: 3, expectedMarked: 1, expectedDLQ: 1, expectedRetried: 2,
+		},
+		"retries exhausted to stop": {
+			strategy: retry(patronfailure.Stop), failures: 5, expectedExecs: 3, expectedErr: "PROC ERROR", expectedRetried: 2,
+		},
+		"skip": {
+			strategy: patronfailure.SkipStrategy(), failures: 1, expectedExecs: 1, expectedMarked: 1, expectedSkipped: 1,
+		},
+		"dead letter without topic": {
+			strategy: patronfailure.DeadLetterStrategy(), failures: 1, expectedExecs: 1, expectedErr: "dead letter action requires a dead letter topic",
+		},
+		"unknown action": {
+			strategy: patronfailure.StrategyFunc(func(uint, error) patronfailure.Decision {
+				return patronfailure.Decision{Action: patronfailure.Action(9)}
+			}),
+			failures: 1, expectedExecs: 1, expectedErr: "unknown failure action: unknown(9)",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			execs := 0
+			proc := func(kafka.Batch) error {
+				execs++
+				if execs <= tt.failures {
+					return errProcess
+				}
+				return nil
+			}
+			producer := &mockProducer{}
+			var dl *deadLetter
+			if tt.deadLetter {
+				dl = &deadLetter{topic: "dlq", producer: producer}
+			}
+			h := newConsumerHandler(ctx, name, "grp", proc, kafka.ExitStrategy, 1, time.Hour, kafka.BatchCommitStrategy, 0, 0, nil, dl, nil, nil, rebalanceHooks{})
+			h.failure = tt.strategy
+
+			ch := make(chan *sarama.ConsumerMessage, 1)
+			msg := saramaConsumerMessage("value", &sarama.RecordHeader{})
+			ch <- msg
+			close(ch)
+			retried := messageStatus.WithLabelValues(messageRetried, "grp", msg.Topic)
+			skipped := messageStatus.WithLabelValues(messageSkipped, "grp", msg.Topic)
+			retriedBefore, skippedBefore := testutil.ToFloat64(retried), testutil.ToFloat64(skipped)
+
+			session := &mockConsumerSession{}
+			err := h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedExecs, execs)
+			assert.Equal(t, tt.expectedMarked, session.marked)
+			assert.Len(t, producer.messages, tt.expectedDLQ)
+			assert.Equal(t, tt.expectedRetried, testutil.ToFloat64(retried)-retriedBefore)
+			assert.Equal(t, tt.expectedSkipped, testutil.ToFloat64(skipped)-skippedBefore)
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_Failure_ManualCommit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var attempts [][]string
+	proc := func(btc kafka.Batch) error {
+		var values []string
+		for _, m := range btc.Messages() {
+			value := string(m.Message().Value)
+			values = append(values, value)
+			if value == "1" || len(attempts) > 0 {
+				m.(kafka.Acknowledger).Ack()
+			}
+		}
+		attempts = append(attempts, values)
+		return nil
+	}
+	s, err := patronfailure.RetryStrategy(2, patronfailure.ConstantBackoff(time.Millisecond), patronfailure.Stop)
+	require.NoError(t, err)
+	h := newConsumerHandler(ctx, "manual", "grp", proc, kafka.ExitStrategy, 2, time.Hour, kafka.ManualCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+	h.failure = s
+
+	ch := make(chan *sarama.ConsumerMessage, 2)
+	ch <- saramaConsumerMessage("1", &sarama.RecordHeader{})
+	ch <- saramaConsumerMessage("2", &sarama.RecordHeader{})
+	close(ch)
+	session := &mockConsumerSession{}
+	require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+	assert.Equal(t, [][]string{{"1", "2"}, {"2"}}, attempts, "only the messages which were not acknowledged are retried")
+	assert.Equal(t, 2, session.marked)
+}
+
+type mockPartitionClaim struct {
+	mockConsumerClaim
+	partition int32
+}
+
+func (m *mockPartitionClaim) Partition() int32 { return m.partition }
+
+func TestHandler_ConsumeClaim_Failure_OtherPartitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failed := make(chan struct{})
+	var once sync.Once
+	proc := func(btc kafka.Batch) error {
+		if btc.Messages()[0].Message().Partition == 0 {
+			once.Do(func() { close(failed) })
+			return errProcess
+		}
+		return nil
+	}
+	s, err := patronfailure.RetryStrategy(2, patronfailure.ConstantBackoff(time.Hour), patronfailure.Stop)
+	require.NoError(t, err)
+	h := newConsumerHandler(ctx, "partitions", "grp", proc, kafka.ExitStrategy, 1, time.Hour, kafka.BatchCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+	h.failure = s
+
+	claim := func(partition int32) *mockPartitionClaim {
+		ch := make(chan *sarama.ConsumerMessage, 1)
+		msg := saramaConsumerMessage("value", &sarama.RecordHeader{})
+		msg.Partition = partition
+		msg.Offset = int64(partition)
+		ch <- msg
+		close(ch)
+		return &mockPartitionClaim{mockConsumerClaim: mockConsumerClaim{ch: ch, proc: &mockProcessor{}}, partition: partition}
+	}
+	session := &mockConsumerSession{}
+
+	chRetrying := make(chan error)
+	go func() {
+		chRetrying <- h.ConsumeClaim(session, claim(0))
+	}()
+	<-failed
+
+	// the message of partition 0 waits for its retry, while partition 1 keeps consuming
+	chDone := make(chan error)
+	go func() {
+		chDone <- h.ConsumeClaim(session, claim(1))
+	}()
+	select {
+	case err := <-chDone:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("partition 1 is blocked by the retry of partition 0")
+	}
+	session.mu.Lock()
+	assert.Equal(t, []int64{1}, session.offsets)
+	session.mu.Unlock()
+
+	cancel()
+	assert.EqualError(t, <-chRetrying, "consumer stopped before retrying processing error: PROC ERROR")
+}
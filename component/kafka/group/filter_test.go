@@ -0,0 +1,53 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ConsumeClaim_Filter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var processed []string
+	proc := func(btc kafka.Batch) error {
+		for _, m := range btc.Messages() {
+			processed = append(processed, string(m.Message().Key))
+		}
+		return nil
+	}
+	filter := func(headers []*sarama.RecordHeader, key []byte) bool {
+		for _, h := range headers {
+			if string(h.Key) == "type" && string(h.Value) != "order" {
+				return false
+			}
+		}
+		return string(key) != "skip"
+	}
+	h := newConsumerHandler(ctx, "filter", "grp", proc, kafka.ExitStrategy, 3, time.Hour, kafka.BatchCommitStrategy,
+		0, 0, filter, nil, nil, nil, rebalanceHooks{})
+
+	ch := make(chan *sarama.ConsumerMessage, 6)
+	for i, key := range []string{"skip", "a", "skip", "b", "payment", "c"} {
+		msg := saramaConsumerMessage("value", &sarama.RecordHeader{Key: []byte("type"), Value: []byte("order")})
+		if key == "payment" {
+			msg.Headers[0].Value = []byte("payment")
+		}
+		msg.Key = []byte(key)
+		msg.Offset = int64(i)
+		ch <- msg
+	}
+	close(ch)
+	session := &mockConsumerSession{}
+	require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+
+	assert.Equal(t, []string{"a", "b", "c"}, processed)
+	// the skipped messages received after the first buffered one are marked along with the buffer
+	assert.Equal(t, []int64{0, 1, 3, 5, 2, 4}, session.offsets)
+	assert.Equal(t, 1, session.commits)
+}
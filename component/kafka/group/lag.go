@@ -0,0 +1,92 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var consumerLag *prometheus.GaugeVec
+
+func init() {
+	consumerLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: subsystem,
+			Name:      "consumer_lag",
+			Help:      "Difference between the high watermark and the committed offset of the consumer group, classified by group, topic and partition",
+		},
+		[]string{"group", "topic", "partition"},
+	)
+
+	prometheus.MustRegister(consumerLag)
+}
+
+// monitorLag updates the consumer lag metrics at the interval, until the context is done.
+func (c *Component) monitorLag(ctx context.Context) {
+	client, err := sarama.NewClient(c.brokers, c.saramaConfig)
+	if err != nil {
+		log.Errorf("failed to create client for the consumer lag metrics of kafka component %s: %v", c.name, err)
+		return
+	}
+	defer func() { _ = client.Close() }()
+
+	ticker := time.NewTicker(c.lagInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := updateLag(client, c.group, c.consumedTopics()); err != nil {
+				log.Warnf("failed to update the consumer lag metrics of kafka component %s: %v", c.name, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// updateLag sets the lag of the partitions of the topics which have an offset committed by the consumer group.
+func updateLag(client sarama.Client, group string, topics []string) error {
+	req := &sarama.OffsetFetchRequest{ConsumerGroup: group, Version: 1}
+	partitions := make(map[string][]int32, len(topics))
+	for _, topic := range topics {
+		pp, err := client.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("failed to get partitions of topic %s: %w", topic, err)
+		}
+		partitions[topic] = pp
+		for _, p := range pp {
+			req.AddPartition(topic, p)
+		}
+	}
+
+	coordinator, err := client.Coordinator(group)
+	if err != nil {
+		return fmt.Errorf("failed to get coordinator of consumer group %s: %w", group, err)
+	}
+	rsp, err := coordinator.FetchOffset(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch offsets of consumer group %s: %w", group, err)
+	}
+
+	for topic, pp := range partitions {
+		for _, p := range pp {
+			block := rsp.GetBlock(topic, p)
+			if block == nil || block.Err != sarama.ErrNoError || block.Offset < 0 {
+				// no offset committed yet
+				continue
+			}
+			high, err := client.GetOffset(topic, p, sarama.OffsetNewest)
+			if err != nil {
+				return fmt.Errorf("failed to get high watermark of topic %s partition %d: %w", topic, p, err)
+			}
+			consumerLag.WithLabelValues(group, topic, strconv.FormatInt(int64(p), 10)).Set(float64(high - block.Offset))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package group
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLagMetrics(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, LagMetrics(0)(c), "lag metrics interval should be a positive number")
+	require.NoError(t, LagMetrics(time.Second)(c))
+	assert.Equal(t, time.Second, c.lagInterval)
+}
+
+func Test_updateLag(t *testing.T) {
+	consumerLag.Reset()
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("topic", 0, broker.BrokerID()).
+			SetLeader("topic", 1, broker.BrokerID()).
+			SetLeader("topic", 2, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "grp", broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("grp", "topic", 0, 90, "", sarama.ErrNoError).
+			SetOffset("grp", "topic", 1, 100, "", sarama.ErrNoError).
+			SetOffset("grp", "topic", 2, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).SetVersion(1).
+			SetOffset("topic", 0, sarama.OffsetNewest, 100).
+			SetOffset("topic", 1, sarama.OffsetNewest, 100).
+			SetOffset("topic", 2, sarama.OffsetNewest, 100),
+	})
+
+	cfg := sarama.NewConfig()
+	cfg.Metadata.Retry.Max = 0
+	client, err := sarama.NewClient([]string{broker.Addr()}, cfg)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	require.NoError(t, updateLag(client, "grp", []string{"topic"}))
+	assert.Equal(t, 10.0, testutil.ToFloat64(consumerLag.WithLabelValues("grp", "topic", "0")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(consumerLag.WithLabelValues("grp", "topic", "1")))
+	assert.Equal(t, 2, testutil.CollectAndCount(consumerLag), "the partitions without a committed offset have no lag")
+
+	assert.Error(t, updateLag(client, "grp", []string{"missing"}))
+}
@@ -6,8 +6,11 @@ import (
 	"time"
 
 	"github.com/Shopify/sarama"
+	patronfailure "github.com/beatlabs/patron/component/failure"
 	"github.com/beatlabs/patron/component/kafka"
+	"github.com/beatlabs/patron/internal/kafka/security"
 	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/reliability/circuitbreaker"
 )
 
 // OptionFunc definition for configuring the component in a functional way.
@@ -18,21 +21,108 @@ type OptionFunc func(*Component) error
 // the failed message.
 // The kafka.SkipStrategy will skip the message on failure. If a client wants to retry a message before failing then
 // this needs to be handled in the kafka.BatchProcessorFunc.
+// The kafka.DeadLetterStrategy will publish the message to the topic set with the DeadLetterTopic option on failure.
 func FailureStrategy(fs kafka.FailStrategy) OptionFunc {
 	return func(c *Component) error {
-		if fs > kafka.SkipStrategy || fs < kafka.ExitStrategy {
+		if fs > kafka.DeadLetterStrategy || fs < kafka.ExitStrategy {
 			return errors.New("invalid failure strategy provided")
 		}
 		c.failStrategy = fs
+		c.failureOptions = append(c.failureOptions, "fail strategy")
+		return nil
+	}
+}
+
+// Failure sets the strategy which decides how the messages that failed processing are handled, in place of the FailureStrategy.
+// The messages to retry are processed again after the delay of the decision, which blocks their partitions in the meantime,
+// and the failure.DeadLetter action publishes the messages to the topic set with the DeadLetterTopic option, which is required
+// when the strategy declares the action. The failure.Stop action stops the component, which is not retried.
+// The strategy cannot be combined with the FailureStrategy, Retries, RetryWait and RetryTopics options.
+func Failure(s patronfailure.Strategy) OptionFunc {
+	return func(c *Component) error {
+		if s == nil {
+			return errors.New("failure strategy is nil")
+		}
+		c.failure = s
+		return nil
+	}
+}
+
+// DeadLetterTopic sets the topic and the producer used by the kafka.DeadLetterStrategy to publish the messages that
+// failed processing. The published messages keep the key, the value and the headers of the original ones, and they contain
+// the error metadata in the headers defined in the kafka package, e.g. kafka.DeadLetterErrorHeader.
+// The producer of the client/kafka/v2 package can be used, e.g. the one created with v2.New(brokers, cfg).Create().
+func DeadLetterTopic(topic string, producer Producer) OptionFunc {
+	return func(c *Component) error {
+		if topic == "" {
+			return errors.New("dead letter topic is empty")
+		}
+		if producer == nil {
+			return errors.New("dead letter producer is nil")
+		}
+		c.deadLetter = &deadLetter{topic: topic, producer: producer}
+		return nil
+	}
+}
+
+// RetryTopics sets the delays of the retry topics of the messages that failed processing, e.g. 5s and 1m, so that
+// failures do not block the partitions. A message that fails processing is published to the retry topic of its next attempt,
+// e.g. orders.retry.5s and then orders.retry.1m, which the component consumes after waiting for the delay since the message
+// was published. The messages that fail the last attempt are handled by the failure strategy, e.g. the kafka.DeadLetterStrategy.
+// The retry topics have to exist in the broker, and the messages keep the key, the value and the headers of the original
+// ones, and contain the retry metadata in the headers defined in the kafka package, e.g. kafka.RetryAttemptHeader.
+func RetryTopics(producer Producer, delays ...time.Duration) OptionFunc {
+	return func(c *Component) error {
+		rt, err := newRetryTopics(c.topics, producer, delays)
+		if err != nil {
+			return err
+		}
+		c.retryTopics = rt
+		return nil
+	}
+}
+
+// LagMetrics enables the consumer lag metrics, which are updated at the interval with the difference between the
+// high watermark and the offset committed by the consumer group for each partition of the consumed topics.
+func LagMetrics(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("lag metrics interval should be a positive number")
+		}
+		c.lagInterval = interval
+		return nil
+	}
+}
+
+// SASL enables the SASL authentication with the brokers, using the mechanism, which is either sarama.SASLTypePlaintext,
+// sarama.SASLTypeSCRAMSHA256 or sarama.SASLTypeSCRAMSHA512, and the credentials of the user.
+func SASL(mechanism sarama.SASLMechanism, user, password string) OptionFunc {
+	return func(c *Component) error {
+		return security.SASL(c.saramaConfig, mechanism, user, password)
+	}
+}
+
+// TLS enables TLS, verifying the certificates of the brokers with the CA, or else with the system roots if the CA file is empty.
+// The client certificate is presented to the brokers if the cert and key files are provided.
+func TLS(caFile, certFile, keyFile string) OptionFunc {
+	return func(c *Component) error {
+		return security.TLS(c.saramaConfig, caFile, certFile, keyFile)
+	}
+}
+
+// TLSInsecureSkipVerify enables TLS without verifying the certificates of the brokers, which should only be used for testing.
+func TLSInsecureSkipVerify() OptionFunc {
+	return func(c *Component) error {
+		security.TLSInsecureSkipVerify(c.saramaConfig)
 		return nil
 	}
 }
 
 // CheckTopic checks whether the component-configured topics exist in the broker.
+// The broker is accessed with the Sarama configuration of the component, so the SASL and TLS options have to precede it.
 func CheckTopic() OptionFunc {
 	return func(c *Component) error {
-		saramaConf := sarama.NewConfig()
-		client, err := sarama.NewClient(c.brokers, saramaConf)
+		client, err := sarama.NewClient(c.brokers, c.saramaConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create client: %w", err)
 		}
@@ -64,6 +154,7 @@ func CheckTopic() OptionFunc {
 func Retries(count uint) OptionFunc {
 	return func(c *Component) error {
 		c.retries = count
+		c.failureOptions = append(c.failureOptions, "retries")
 		return nil
 	}
 }
@@ -75,11 +166,12 @@ func RetryWait(interval time.Duration) OptionFunc {
 			return errors.New("retry wait time should be a positive number")
 		}
 		c.retryWait = interval
+		c.failureOptions = append(c.failureOptions, "retry wait")
 		return nil
 	}
 }
 
-// BatchSize sets the message batch size the component should process at once.
+// BatchSize sets the message batch size the component should process at once, for every partition.
 func BatchSize(size uint) OptionFunc {
 	return func(c *Component) error {
 		if size == 0 {
@@ -90,8 +182,9 @@ func BatchSize(size uint) OptionFunc {
 	}
 }
 
-// BatchTimeout sets the message batch timeout. If the desired batch size is not reached and if the timeout elapses
-// without new messages coming in, the messages in the buffer would get processed as a batch.
+// BatchTimeout sets the maximum time the messages wait in the buffer for the batch to fill up. If the desired batch size
+// is not reached within the timeout since the first message of the batch was received, the buffered messages get processed as a batch.
+// A zero timeout processes the buffered messages as soon as possible.
 func BatchTimeout(timeout time.Duration) OptionFunc {
 	return func(c *Component) error {
 		if timeout < 0 {
@@ -102,14 +195,128 @@ func BatchTimeout(timeout time.Duration) OptionFunc {
 	}
 }
 
-// CommitSync instructs the consumer to commit offsets in a blocking operation after processing every batch of messages
+// CommitSync instructs the consumer to commit offsets in a blocking operation after processing every batch of messages,
+// which is the same as the kafka.BatchCommitStrategy.
 func CommitSync() OptionFunc {
+	return CommitStrategy(kafka.BatchCommitStrategy)
+}
+
+// CommitStrategy sets the strategy for committing the offsets of the processed messages, which is the kafka.AutoCommitStrategy by default.
+// The kafka.MessageCommitStrategy processes the messages one at a time and commits the offset of every message in a blocking
+// operation right after it is processed, while the kafka.BatchCommitStrategy commits the offsets after every batch.
+// With the kafka.ManualCommitStrategy, the processor acknowledges every message with kafka.Acknowledger, or Nack in order to handle it
+// with the failure strategy, and the offsets are committed after every batch.
+func CommitStrategy(cs kafka.CommitStrategy) OptionFunc {
 	return func(c *Component) error {
-		if c.saramaConfig != nil && c.saramaConfig.Consumer.Offsets.AutoCommit.Enable {
+		if cs > kafka.ManualCommitStrategy || cs < kafka.AutoCommitStrategy {
+			return errors.New("invalid commit strategy provided")
+		}
+		if cs != kafka.AutoCommitStrategy && c.saramaConfig != nil && c.saramaConfig.Consumer.Offsets.AutoCommit.Enable {
 			// redundant commits warning
-			log.Warn("consumer is set to commit offsets after processing each batch and auto-commit is enabled")
+			log.Warn("consumer is set to commit offsets after processing and auto-commit is enabled")
+		}
+		c.commitStrategy = cs
+		return nil
+	}
+}
+
+// AutoCommitInterval enables the auto-commit of the Sarama configuration, which commits the marked offsets periodically at the interval.
+func AutoCommitInterval(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("auto-commit interval should be a positive number")
+		}
+		c.saramaConfig.Consumer.Offsets.AutoCommit.Enable = true
+		c.saramaConfig.Consumer.Offsets.AutoCommit.Interval = interval
+		return nil
+	}
+}
+
+// ProcessTimeout sets the deadline for processing a batch of messages. The context of the messages is cancelled
+// once the deadline is exceeded, and the processing is abandoned and treated as a failure which is handled by the failure strategy,
+// even if the processor does not honour the context and keeps running.
+// With the kafka.ExitStrategy, the offsets of a timed-out batch are not marked, so the messages are not committed as successful.
+func ProcessTimeout(timeout time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if timeout <= 0 {
+			return errors.New("process timeout should be a positive number")
+		}
+		c.processTimeout = timeout
+		return nil
+	}
+}
+
+// Filter sets a predicate on the headers and the key of the messages, so that the messages it returns false for are skipped
+// before they are processed, e.g. the messages of other types on a shared topic. The skipped messages are committed along with
+// the processed ones, without creating their spans or passing them to the kafka.BatchProcessorFunc.
+func Filter(f kafka.FilterFunc) OptionFunc {
+	return func(c *Component) error {
+		if f == nil {
+			return errors.New("filter is nil")
+		}
+		c.filter = f
+		return nil
+	}
+}
+
+// KeyOrderedConcurrency splits every batch by the key of its messages into at most the number of workers batches,
+// which are processed concurrently, so that the messages with the same key are still processed in order.
+// The messages without a key are spread by their offset. The batch size has to be greater than 1 for the batches to be split,
+// and the kafka.BatchProcessorFunc has to be safe for concurrent use.
+func KeyOrderedConcurrency(workers uint) OptionFunc {
+	return func(c *Component) error {
+		if workers == 0 {
+			return errors.New("workers should be a positive number")
+		}
+		c.workers = workers
+		return nil
+	}
+}
+
+// MaxInFlight pauses the consumption while the number of messages which are being processed has reached the maximum,
+// including the ones of the batches abandoned after the ProcessTimeout, so that a stuck processor does not pile up work.
+func MaxInFlight(messages uint) OptionFunc {
+	return func(c *Component) error {
+		if messages == 0 {
+			return errors.New("max in-flight messages should be a positive number")
+		}
+		c.backpressure.maxInFlight = int64(messages)
+		return nil
+	}
+}
+
+// PauseOnOpenCircuit pauses the consumption while the circuit breaker of a downstream dependency is open.
+// The consumption resumes once the circuit is half-open, so that the processing can probe the dependency.
+func PauseOnOpenCircuit(cb *circuitbreaker.CircuitBreaker) OptionFunc {
+	return func(c *Component) error {
+		if cb == nil {
+			return errors.New("circuit breaker is nil")
+		}
+		c.backpressure.breakers = append(c.backpressure.breakers, cb)
+		return nil
+	}
+}
+
+// OnPartitionsAssigned sets the callback which is called once partitions are assigned to the consumer, before their messages are consumed.
+// Its context is cancelled once the partitions are revoked. An error fails the consumer group session.
+func OnPartitionsAssigned(f RebalanceFunc) OptionFunc {
+	return func(c *Component) error {
+		if f == nil {
+			return errors.New("partitions assigned callback is nil")
+		}
+		c.rebalance.assigned = f
+		return nil
+	}
+}
+
+// OnPartitionsRevoked sets the callback which is called once partitions are revoked from the consumer, after the messages
+// received from them have been processed and their offsets have been committed. An error fails the consumer group session.
+func OnPartitionsRevoked(f RebalanceFunc) OptionFunc {
+	return func(c *Component) error {
+		if f == nil {
+			return errors.New("partitions revoked callback is nil")
 		}
-		c.commitSync = true
+		c.rebalance.revoked = f
 		return nil
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Shopify/sarama"
 	"github.com/beatlabs/patron/component/kafka"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,6 +23,9 @@ func TestFailureStrategy(t *testing.T) {
 		"success-skip": {
 			args: args{strategy: kafka.SkipStrategy},
 		},
+		"success-dead-letter": {
+			args: args{strategy: kafka.DeadLetterStrategy},
+		},
 		"invalid strategy": {
 			args:        args{strategy: -1},
 			expectedErr: "invalid failure strategy provided",
@@ -41,6 +45,44 @@ func TestFailureStrategy(t *testing.T) {
 	}
 }
 
+func TestDeadLetterTopic(t *testing.T) {
+	tests := map[string]struct {
+		topic       string
+		producer    Producer
+		expectedErr string
+	}{
+		"success":        {topic: "dlq", producer: &mockProducer{}},
+		"empty topic":    {producer: &mockProducer{}, expectedErr: "dead letter topic is empty"},
+		"missing client": {topic: "dlq", expectedErr: "dead letter producer is nil"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Component{}
+			err := DeadLetterTopic(tt.topic, tt.producer)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &deadLetter{topic: tt.topic, producer: tt.producer}, c.deadLetter)
+			}
+		})
+	}
+}
+
+func TestSecurityOptions(t *testing.T) {
+	c := &Component{saramaConfig: sarama.NewConfig()}
+	assert.NoError(t, SASL(sarama.SASLTypeSCRAMSHA256, "user", "pass")(c))
+	assert.NoError(t, TLSInsecureSkipVerify()(c))
+	assert.NoError(t, TLS("", "", "")(c))
+	assert.True(t, c.saramaConfig.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256), c.saramaConfig.Net.SASL.Mechanism)
+	assert.True(t, c.saramaConfig.Net.TLS.Enable)
+	assert.True(t, c.saramaConfig.Net.TLS.Config.InsecureSkipVerify)
+
+	assert.EqualError(t, SASL(sarama.SASLTypePlaintext, "", "pass")(c), "SASL user is empty")
+	assert.EqualError(t, TLS("", "", "key.pem")(c), "TLS client certificate requires both a cert and a key file")
+}
+
 func TestRetries(t *testing.T) {
 	c := &Component{}
 	err := Retries(20)(c)
@@ -137,3 +179,72 @@ func TestBatchTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessTimeout(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, ProcessTimeout(0)(c), "process timeout should be a positive number")
+	assert.NoError(t, ProcessTimeout(5*time.Second)(c))
+	assert.Equal(t, 5*time.Second, c.processTimeout)
+}
+
+func TestFilter(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Filter(nil)(c), "filter is nil")
+	assert.NoError(t, Filter(func([]*sarama.RecordHeader, []byte) bool { return true })(c))
+	assert.NotNil(t, c.filter)
+}
+
+func TestKeyOrderedConcurrency(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, KeyOrderedConcurrency(0)(c), "workers should be a positive number")
+	assert.NoError(t, KeyOrderedConcurrency(4)(c))
+	assert.Equal(t, uint(4), c.workers)
+}
+
+func TestCommitStrategy(t *testing.T) {
+	tests := map[string]struct {
+		strategy    kafka.CommitStrategy
+		expectedErr string
+	}{
+		"auto":          {strategy: kafka.AutoCommitStrategy},
+		"message":       {strategy: kafka.MessageCommitStrategy},
+		"batch":         {strategy: kafka.BatchCommitStrategy},
+		"manual":        {strategy: kafka.ManualCommitStrategy},
+		"invalid":       {strategy: -1, expectedErr: "invalid commit strategy provided"},
+		"invalid upper": {strategy: kafka.ManualCommitStrategy + 1, expectedErr: "invalid commit strategy provided"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := &Component{saramaConfig: sarama.NewConfig()}
+			err := CommitStrategy(tt.strategy)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.strategy, c.commitStrategy)
+		})
+	}
+}
+
+func TestCommitStrategy_KeyOrderedConcurrency(t *testing.T) {
+	_, err := New("name", "grp", []string{"broker"}, []string{"topic"}, func(kafka.Batch) error { return nil }, sarama.NewConfig(),
+		CommitStrategy(kafka.MessageCommitStrategy), KeyOrderedConcurrency(2))
+	assert.EqualError(t, err, "key ordered concurrency cannot be combined with the message commit strategy")
+}
+
+func TestCommitSync(t *testing.T) {
+	c := &Component{}
+	assert.NoError(t, CommitSync()(c))
+	assert.Equal(t, kafka.BatchCommitStrategy, c.commitStrategy)
+}
+
+func TestAutoCommitInterval(t *testing.T) {
+	c := &Component{saramaConfig: sarama.NewConfig()}
+	c.saramaConfig.Consumer.Offsets.AutoCommit.Enable = false
+	assert.EqualError(t, AutoCommitInterval(0)(c), "auto-commit interval should be a positive number")
+	assert.NoError(t, AutoCommitInterval(5*time.Second)(c))
+	assert.True(t, c.saramaConfig.Consumer.Offsets.AutoCommit.Enable)
+	assert.Equal(t, 5*time.Second, c.saramaConfig.Consumer.Offsets.AutoCommit.Interval)
+}
@@ -0,0 +1,12 @@
+package group
+
+import "context"
+
+// RebalanceFunc is called with the partitions of every topic which are assigned to, or revoked from, the consumer on a rebalance,
+// e.g. in order to load or flush the state kept for the partitions.
+type RebalanceFunc func(ctx context.Context, claims map[string][]int32) error
+
+type rebalanceHooks struct {
+	assigned RebalanceFunc
+	revoked  RebalanceFunc
+}
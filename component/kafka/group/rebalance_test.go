@@ -0,0 +1,90 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnPartitions(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, OnPartitionsAssigned(nil)(c), "partitions assigned callback is nil")
+	assert.EqualError(t, OnPartitionsRevoked(nil)(c), "partitions revoked callback is nil")
+	f := func(context.Context, map[string][]int32) error { return nil }
+	require.NoError(t, OnPartitionsAssigned(f)(c))
+	require.NoError(t, OnPartitionsRevoked(f)(c))
+	assert.NotNil(t, c.rebalance.assigned)
+	assert.NotNil(t, c.rebalance.revoked)
+}
+
+func TestHandler_SetupCleanup(t *testing.T) {
+	claims := map[string][]int32{"topic": {0, 1}}
+	errCallback := errors.New("CALLBACK ERROR")
+	tests := map[string]struct {
+		err         error
+		expectedErr string
+	}{
+		"success": {},
+		"failure": {err: errCallback, expectedErr: "CALLBACK ERROR"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var assigned, revoked map[string][]int32
+			hooks := rebalanceHooks{
+				assigned: func(_ context.Context, cc map[string][]int32) error {
+					assigned = cc
+					return tt.err
+				},
+				revoked: func(_ context.Context, cc map[string][]int32) error {
+					revoked = cc
+					return tt.err
+				},
+			}
+			h := newConsumerHandler(context.Background(), name, "grp", nil, kafka.ExitStrategy, 1, time.Second,
+				kafka.AutoCommitStrategy, 0, 0, nil, nil, nil, nil, hooks)
+			session := &mockConsumerSession{claims: claims}
+
+			setupErr, cleanupErr := h.Setup(session), h.Cleanup(session)
+			assert.Equal(t, claims, assigned)
+			assert.Equal(t, claims, revoked)
+			assert.Equal(t, 1, session.commits, "the marked offsets are committed before the partitions are handed off")
+			if tt.expectedErr != "" {
+				assert.EqualError(t, setupErr, "partitions assigned callback failed: "+tt.expectedErr)
+				assert.EqualError(t, cleanupErr, "partitions revoked callback failed: "+tt.expectedErr)
+				return
+			}
+			assert.NoError(t, setupErr)
+			assert.NoError(t, cleanupErr)
+		})
+	}
+}
+
+func TestHandler_ConsumeClaim_Revoked(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	proc := &mockProcessor{}
+	h := newConsumerHandler(ctx, "revoked", "grp", proc.Process, kafka.ExitStrategy, 10, time.Hour,
+		kafka.AutoCommitStrategy, 0, 0, nil, nil, nil, nil, rebalanceHooks{})
+
+	sessionCtx, revoke := context.WithCancel(context.Background())
+	session := &mockConsumerSession{ctx: sessionCtx}
+	ch := make(chan *sarama.ConsumerMessage)
+	chDone := make(chan error)
+	go func() {
+		chDone <- h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	}()
+	ch <- saramaConsumerMessage("1", &sarama.RecordHeader{})
+	ch <- saramaConsumerMessage("2", &sarama.RecordHeader{})
+
+	revoke()
+	assert.NoError(t, <-chDone)
+	assert.Equal(t, 2, proc.GetExecs(), "the buffered messages are processed before the partition is handed off")
+	assert.Equal(t, 2, session.marked)
+}
@@ -0,0 +1,118 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+)
+
+// retryTopic is a topic where the messages of a topic are retried after a delay.
+type retryTopic struct {
+	// topic the messages were originally consumed from
+	topic string
+	// attempt of the messages consumed from the retry topic, starting from 1
+	attempt int
+	delay   time.Duration
+}
+
+type retryTopics struct {
+	producer Producer
+	delays   []time.Duration
+	// retry topics by name
+	topics map[string]retryTopic
+	names  []string
+}
+
+func newRetryTopics(topics []string, producer Producer, delays []time.Duration) (*retryTopics, error) {
+	if producer == nil {
+		return nil, errors.New("retry producer is nil")
+	}
+	if len(delays) == 0 {
+		return nil, errors.New("retry delays are empty")
+	}
+
+	rt := &retryTopics{
+		producer: producer,
+		delays:   delays,
+		topics:   make(map[string]retryTopic, len(topics)*len(delays)),
+	}
+	for i, delay := range delays {
+		if delay <= 0 {
+			return nil, errors.New("retry delay should be a positive number")
+		}
+		for _, topic := range topics {
+			name := retryTopicName(topic, delay)
+			if _, ok := rt.topics[name]; ok {
+				return nil, fmt.Errorf("duplicate retry delay %v provided", delay)
+			}
+			rt.topics[name] = retryTopic{topic: topic, attempt: i + 1, delay: delay}
+			rt.names = append(rt.names, name)
+		}
+	}
+	return rt, nil
+}
+
+// retryTopicName returns the name of the retry topic of the topic with the delay, e.g. orders.retry.5s or orders.retry.1h30m.
+func retryTopicName(topic string, delay time.Duration) string {
+	d := delay.String()
+	if strings.HasSuffix(d, "m0s") {
+		d = d[:len(d)-2]
+	}
+	if strings.HasSuffix(d, "h0m") {
+		d = d[:len(d)-2]
+	}
+	return topic + ".retry." + d
+}
+
+// wait returns how long a message consumed from a retry topic has to wait before it is processed, which is until the delay
+// of the topic has passed since the message was published to it. The wait never exceeds the delay, e.g. when the clock
+// of the producer is ahead, and a message without a timestamp waits for the whole delay.
+func (r *retryTopics) wait(msg *sarama.ConsumerMessage) time.Duration {
+	rt, ok := r.topics[msg.Topic]
+	if !ok {
+		return 0
+	}
+	if msg.Timestamp.IsZero() {
+		return rt.delay
+	}
+	if wait := time.Until(msg.Timestamp.Add(rt.delay)); wait < rt.delay {
+		return wait
+	}
+	return rt.delay
+}
+
+// publish publishes the messages which failed processing to the retry topic of their next attempt,
+// returning the retried messages and the ones which have exhausted their retries.
+func (r *retryTopics) publish(ctx context.Context, messages []kafka.Message, err error) (retried, exhausted []kafka.Message, _ error) {
+	pp := make([]*sarama.ProducerMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := m.Message()
+		topic, attempt := msg.Topic, 0
+		if rt, ok := r.topics[msg.Topic]; ok {
+			topic, attempt = rt.topic, rt.attempt
+		}
+		if attempt >= len(r.delays) {
+			exhausted = append(exhausted, m)
+			continue
+		}
+		retried = append(retried, m)
+		pp = append(pp, producerMessage(retryTopicName(topic, r.delays[attempt]), msg,
+			sarama.RecordHeader{Key: []byte(kafka.RetryErrorHeader), Value: []byte(err.Error())},
+			sarama.RecordHeader{Key: []byte(kafka.RetryAttemptHeader), Value: []byte(strconv.Itoa(attempt + 1))},
+			sarama.RecordHeader{Key: []byte(kafka.RetryTopicHeader), Value: []byte(topic)},
+		))
+	}
+
+	if len(pp) > 0 {
+		if err := r.producer.SendBatch(ctx, pp); err != nil {
+			return nil, nil, fmt.Errorf("failed to publish to retry topics: %w", err)
+		}
+	}
+	return retried, exhausted, nil
+}
@@ -0,0 +1,150 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/beatlabs/patron/component/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTopics(t *testing.T) {
+	tests := map[string]struct {
+		producer      Producer
+		delays        []time.Duration
+		expectedNames []string
+		expectedErr   string
+	}{
+		"success": {
+			producer:      &mockProducer{},
+			delays:        []time.Duration{5 * time.Second, time.Minute},
+			expectedNames: []string{"one.retry.5s", "two.retry.5s", "one.retry.1m", "two.retry.1m"},
+		},
+		"missing producer":  {delays: []time.Duration{time.Second}, expectedErr: "retry producer is nil"},
+		"missing delays":    {producer: &mockProducer{}, expectedErr: "retry delays are empty"},
+		"zero delay":        {producer: &mockProducer{}, delays: []time.Duration{0}, expectedErr: "retry delay should be a positive number"},
+		"duplicate delay":   {producer: &mockProducer{}, delays: []time.Duration{time.Second, time.Second}, expectedErr: "duplicate retry delay 1s provided"},
+		"negative delay":    {producer: &mockProducer{}, delays: []time.Duration{time.Second, -time.Second}, expectedErr: "retry delay should be a positive number"},
+		"delays in seconds": {producer: &mockProducer{}, delays: []time.Duration{90 * time.Second}, expectedNames: []string{"one.retry.1m30s", "two.retry.1m30s"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Component{topics: []string{"one", "two"}}
+			err := RetryTopics(tt.producer, tt.delays...)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, c.retryTopics)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedNames, c.retryTopics.names)
+		})
+	}
+}
+
+func Test_retryTopicName(t *testing.T) {
+	tests := map[time.Duration]string{
+		500 * time.Millisecond:         "orders.retry.500ms",
+		5 * time.Second:                "orders.retry.5s",
+		time.Minute:                    "orders.retry.1m",
+		90 * time.Second:               "orders.retry.1m30s",
+		time.Hour:                      "orders.retry.1h",
+		time.Hour + 30*time.Minute:     "orders.retry.1h30m",
+		time.Hour + 30*time.Second:     "orders.retry.1h0m30s",
+		24*time.Hour + 10*time.Minute:  "orders.retry.24h10m",
+		10*time.Minute + 5*time.Second: "orders.retry.10m5s",
+	}
+	for delay, expected := range tests {
+		assert.Equal(t, expected, retryTopicName("orders", delay))
+	}
+}
+
+func TestRetryTopics_wait(t *testing.T) {
+	rt, err := newRetryTopics([]string{"TEST_TOPIC"}, &mockProducer{}, []time.Duration{time.Minute})
+	require.NoError(t, err)
+	msg := func(topic string, timestamp time.Time) *sarama.ConsumerMessage {
+		return &sarama.ConsumerMessage{Topic: topic, Timestamp: timestamp}
+	}
+
+	assert.Equal(t, time.Duration(0), rt.wait(msg("TEST_TOPIC", time.Time{})), "the messages of the original topics do not wait")
+	assert.Equal(t, time.Minute, rt.wait(msg("TEST_TOPIC.retry.1m", time.Time{})), "a message without a timestamp waits for the delay")
+	assert.Equal(t, time.Minute, rt.wait(msg("TEST_TOPIC.retry.1m", time.Now().Add(time.Hour))), "the wait is capped to the delay")
+	assert.LessOrEqual(t, int64(rt.wait(msg("TEST_TOPIC.retry.1m", time.Now().Add(-30*time.Second)))), int64(30*time.Second))
+	assert.LessOrEqual(t, int64(rt.wait(msg("TEST_TOPIC.retry.1m", time.Now().Add(-time.Hour)))), int64(0))
+}
+
+func TestHandler_ConsumeClaim_RetryTopics_Revoked(t *testing.T) {
+	producer := &mockProducer{}
+	rt, err := newRetryTopics([]string{"TEST_TOPIC"}, producer, []time.Duration{time.Hour})
+	require.NoError(t, err)
+	h := newConsumerHandler(context.Background(), "retry-revoked", "grp", func(kafka.Batch) error { return nil }, kafka.ExitStrategy, 1, time.Hour,
+		kafka.BatchCommitStrategy, 0, 0, nil, nil, rt, nil, rebalanceHooks{})
+
+	retried := saramaConsumerMessage("1", &sarama.RecordHeader{})
+	retried.Topic = "TEST_TOPIC.retry.1h"
+	retried.Timestamp = time.Now()
+	ch := make(chan *sarama.ConsumerMessage, 1)
+	ch <- retried
+	sessionCtx, revoke := context.WithCancel(context.Background())
+	session := &mockConsumerSession{ctx: sessionCtx}
+	time.AfterFunc(20*time.Millisecond, revoke)
+
+	start := time.Now()
+	require.NoError(t, h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}}))
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "the wait ends once the claim is revoked")
+	assert.Equal(t, 0, session.marked, "the message is left to the next owner of the partition")
+}
+
+func TestHandler_ConsumeClaim_RetryTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	producer := &mockProducer{}
+	rt, err := newRetryTopics([]string{"TEST_TOPIC"}, producer, []time.Duration{50 * time.Millisecond, time.Minute})
+	require.NoError(t, err)
+	proc := func(kafka.Batch) error { return errProcess }
+	h := newConsumerHandler(ctx, "retry", "grp", proc, kafka.DeadLetterStrategy, 1, time.Hour, kafka.BatchCommitStrategy, 0,
+		0, nil, &deadLetter{topic: "dlq", producer: producer}, rt, nil, rebalanceHooks{})
+
+	first := saramaConsumerMessage("1", &sarama.RecordHeader{Key: []byte("X-HEADER"), Value: []byte("1")})
+	retried := saramaConsumerMessage("2", &sarama.RecordHeader{Key: []byte(kafka.RetryAttemptHeader), Value: []byte("1")})
+	retried.Topic = "TEST_TOPIC.retry.50ms"
+	retried.Timestamp = time.Now()
+	last := saramaConsumerMessage("3", &sarama.RecordHeader{Key: []byte(kafka.RetryAttemptHeader), Value: []byte("2")})
+	last.Topic = "TEST_TOPIC.retry.1m"
+	last.Timestamp = time.Now().Add(-time.Minute)
+
+	ch := make(chan *sarama.ConsumerMessage, 3)
+	ch <- first
+	ch <- retried
+	ch <- last
+	close(ch)
+	session := &mockConsumerSession{}
+	start := time.Now()
+	err = h.ConsumeClaim(session, &mockConsumerClaim{ch: ch, proc: &mockProcessor{}})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(50*time.Millisecond), "the retried message waits for the delay")
+	assert.Equal(t, 3, session.marked)
+
+	require.Len(t, producer.messages, 3)
+	assert.Equal(t, "TEST_TOPIC.retry.50ms", producer.messages[0].Topic)
+	assert.Equal(t, "1", headerValue(producer.messages[0].Headers, "X-HEADER"))
+	assert.Equal(t, "1", headerValue(producer.messages[0].Headers, kafka.RetryAttemptHeader))
+	assert.Equal(t, "TEST_TOPIC", headerValue(producer.messages[0].Headers, kafka.RetryTopicHeader))
+	assert.Equal(t, "PROC ERROR", headerValue(producer.messages[0].Headers, kafka.RetryErrorHeader))
+
+	assert.Equal(t, "TEST_TOPIC.retry.1m", producer.messages[1].Topic)
+	assert.Equal(t, "2", headerValue(producer.messages[1].Headers, kafka.RetryAttemptHeader))
+	attempts := 0
+	for _, h := range producer.messages[1].Headers {
+		if string(h.Key) == kafka.RetryAttemptHeader {
+			attempts++
+		}
+	}
+	assert.Equal(t, 1, attempts, "the retry headers are replaced")
+
+	assert.Equal(t, "dlq", producer.messages[2].Topic, "the messages which exhausted their retries are handled by the failure strategy")
+	assert.Equal(t, "TEST_TOPIC.retry.1m", headerValue(producer.messages[2].Headers, kafka.DeadLetterTopicHeader))
+}
@@ -19,11 +19,60 @@ const (
 	ExitStrategy FailStrategy = iota
 	// SkipStrategy commits the offset of messages that failed processing, and continues processing.
 	SkipStrategy
+	// DeadLetterStrategy publishes the messages that failed processing to a dead letter topic, commits their offsets,
+	// and continues processing. If the messages cannot be published, their offsets are not committed and the application exits.
+	DeadLetterStrategy
+)
+
+// CommitStrategy type definition.
+type CommitStrategy int
+
+const (
+	// AutoCommitStrategy marks the offsets of the processed messages, which are committed periodically by the client
+	// if the auto-commit of the Sarama configuration is enabled.
+	AutoCommitStrategy CommitStrategy = iota
+	// MessageCommitStrategy processes the messages one at a time, committing the offset of every message in a blocking
+	// operation right after it is processed.
+	MessageCommitStrategy
+	// BatchCommitStrategy commits the offsets of every processed batch of messages in a blocking operation.
+	BatchCommitStrategy
+	// ManualCommitStrategy commits the offsets of the messages acknowledged by the processor with Acknowledger.Ack after every batch,
+	// in a blocking operation. The messages which are negatively acknowledged with Nack, or not acknowledged at all,
+	// are handled by the failure strategy.
+	ManualCommitStrategy
+)
+
+// Headers added to the messages published to the dead letter topic, next to their original headers.
+const (
+	// DeadLetterErrorHeader contains the processing error of the message.
+	DeadLetterErrorHeader = "dead-letter-error"
+	// DeadLetterReasonHeader contains the reason of the failure, which is either "error" or "timeout".
+	DeadLetterReasonHeader = "dead-letter-reason"
+	// DeadLetterTopicHeader contains the topic the message was consumed from.
+	DeadLetterTopicHeader = "dead-letter-topic"
+	// DeadLetterPartitionHeader contains the partition the message was consumed from.
+	DeadLetterPartitionHeader = "dead-letter-partition"
+	// DeadLetterOffsetHeader contains the offset of the message in the partition it was consumed from.
+	DeadLetterOffsetHeader = "dead-letter-offset"
+)
+
+// Headers added to the messages published to the retry topics, next to their original headers.
+const (
+	// RetryErrorHeader contains the processing error of the last attempt of the message.
+	RetryErrorHeader = "retry-error"
+	// RetryAttemptHeader contains the number of the retry attempt of the message, starting from 1.
+	RetryAttemptHeader = "retry-attempt"
+	// RetryTopicHeader contains the topic the message was originally consumed from.
+	RetryTopicHeader = "retry-topic"
 )
 
 // BatchProcessorFunc definition of a batch async processor function.
 type BatchProcessorFunc func(Batch) error
 
+// FilterFunc definition of a predicate on the headers and the key of a message, which returns false if the message has to be skipped,
+// e.g. the messages of other types on a shared topic.
+type FilterFunc func(headers []*sarama.RecordHeader, key []byte) bool
+
 // Message interface for wrapping messages that are handled by the kafka component.
 type Message interface {
 	// Context will contain the context to be used for processing.
@@ -35,6 +84,15 @@ type Message interface {
 	Span() opentracing.Span
 }
 
+// Acknowledger is implemented by the messages passed to the processor with the ManualCommitStrategy,
+// which are acknowledged explicitly, e.g. msg.(kafka.Acknowledger).Ack().
+type Acknowledger interface {
+	// Ack acknowledges the successful processing of the message.
+	Ack()
+	// Nack negatively acknowledges the message with the processing error.
+	Nack(err error)
+}
+
 // NewMessage initializes a new message which is an implementation of the kafka Message interface
 func NewMessage(ctx context.Context, sp opentracing.Span, msg *sarama.ConsumerMessage) Message {
 	return &message{
@@ -0,0 +1,206 @@
+// Package pubsub provides a native consumer for Google Cloud Pub/Sub.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/beatlabs/patron/component/shutdown"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultRetries   = 10
+	defaultRetryWait = time.Second
+
+	consumerComponent = "pubsub-consumer"
+
+	ackMessageState     messageState = "ACK"
+	nackMessageState    messageState = "NACK"
+	fetchedMessageState messageState = "FETCHED"
+)
+
+// ProcessorFunc definition of a async processor.
+type ProcessorFunc func(context.Context, Message)
+
+type messageState string
+
+var (
+	messageAge     *prometheus.GaugeVec
+	messageCounter *prometheus.CounterVec
+)
+
+func init() {
+	messageAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "pubsub",
+			Name:      "message_age",
+			Help:      "Message age based on the publish time of the message",
+		},
+		[]string{"subscription"},
+	)
+	prometheus.MustRegister(messageAge)
+	messageCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "pubsub",
+			Name:      "message_counter",
+			Help:      "Message counter",
+		},
+		[]string{"subscription", "state", "hasError"},
+	)
+	prometheus.MustRegister(messageCounter)
+}
+
+type retry struct {
+	count uint
+	wait  time.Duration
+}
+
+// Component implementation of a async component.
+type Component struct {
+	name         string
+	subscription *pubsub.Subscription
+	proc         ProcessorFunc
+	retry        retry
+}
+
+// New creates a new component with support for functional configuration.
+func New(name, subscriptionID string, client *pubsub.Client, proc ProcessorFunc, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("component name is empty")
+	}
+
+	if subscriptionID == "" {
+		return nil, errors.New("subscription ID is empty")
+	}
+
+	if client == nil {
+		return nil, errors.New("Pub/Sub client is nil")
+	}
+
+	if proc == nil {
+		return nil, errors.New("process function is nil")
+	}
+
+	cmp := &Component{
+		name:         name,
+		subscription: client.Subscription(subscriptionID),
+		proc:         proc,
+		retry: retry{
+			count: defaultRetries,
+			wait:  defaultRetryWait,
+		},
+	}
+
+	for _, optionFunc := range oo {
+		err := optionFunc(cmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// Run starts receiving the messages of the subscription until the context is done, when the messages in flight are processed
+// until the shutdown deadline, if any, before the component returns.
+func (c *Component) Run(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+	pctx := shutdown.DrainFromContext(ctx)
+
+	retries := c.retry.count
+	for {
+		start := time.Now()
+		err := c.subscription.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
+			c.process(pctx, msg)
+		})
+		if ctx.Err() != nil {
+			logger.Info("context cancellation received. exiting...")
+			return nil
+		}
+		if err == nil {
+			err = errors.New("receiving stopped unexpectedly")
+		}
+		// the retries are reset once the component has received messages for longer than the retry wait
+		if time.Since(start) > c.retry.wait {
+			retries = c.retry.count
+		}
+		if retries == 0 {
+			return fmt.Errorf("failed to receive messages of subscription %s: %w", c.subscription.ID(), err)
+		}
+		retries--
+		logger.Errorf("failed to receive messages: %v, sleeping for %v", err, c.retry.wait)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.retry.wait):
+		}
+	}
+}
+
+// process passes the message to the processor, and does not acknowledge it if the processor neither acknowledged
+// nor did not acknowledge it, so that it is delivered again.
+func (c *Component) process(ctx context.Context, msg *pubsub.Message) {
+	subscription := c.subscription.ID()
+	messageCountInc(subscription, fetchedMessageState, 1)
+	observeMessageAge(subscription, msg.PublishTime)
+
+	m := c.createMessage(ctx, msg)
+	c.proc(ctx, m)
+	if m.settle() {
+		log.FromContext(m.ctx).Warnf("message %s of subscription %s was not acknowledged by the processor", m.ID(), subscription)
+		m.nack()
+	}
+}
+
+func (c *Component) createMessage(ctx context.Context, msg *pubsub.Message) *message {
+	corID := getCorrelationID(msg.Attributes)
+
+	sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.subscription.ID()),
+		consumerComponent, corID, msg.Attributes)
+	if msg.OrderingKey != "" {
+		sp.SetTag("ordering_key", msg.OrderingKey)
+	}
+
+	ctxCh = correlation.ContextWithID(ctxCh, corID)
+	ctxCh = correlation.ContextWithHeaders(ctxCh, func(header string) string { return msg.Attributes[header] })
+	fields := map[string]interface{}{correlation.ID: corID}
+	if msg.OrderingKey != "" {
+		fields["orderingKey"] = msg.OrderingKey
+	}
+	ctxCh = log.WithContext(ctxCh, log.Sub(fields))
+
+	return &message{
+		ctx:          ctxCh,
+		subscription: c.subscription.ID(),
+		msg:          msg,
+		span:         sp,
+	}
+}
+
+func observeMessageAge(subscription string, published time.Time) {
+	if published.IsZero() {
+		return
+	}
+	messageAge.WithLabelValues(subscription).Set(time.Now().UTC().Sub(published).Seconds())
+}
+
+func messageCountInc(subscription string, state messageState, count int) {
+	messageCounter.WithLabelValues(subscription, string(state), "false").Add(float64(count))
+}
+
+func getCorrelationID(attributes map[string]string) string {
+	if corID := attributes[correlation.HeaderID]; corID != "" {
+		return corID
+	}
+	return uuid.New().String()
+}
@@ -0,0 +1,282 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/beatlabs/patron/component/shutdown"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+const (
+	testTopic        = "topic"
+	testSubscription = "subscription"
+	fullTopic        = "projects/project/topics/" + testTopic
+)
+
+func TestNew(t *testing.T) {
+	srv, client := newTestClient(t)
+	createSubscription(t, client, false)
+	defer func() { _ = srv.Close() }()
+
+	proc := func(context.Context, Message) {}
+
+	tests := map[string]struct {
+		name           string
+		subscriptionID string
+		client         *pubsub.Client
+		proc           ProcessorFunc
+		options        []OptionFunc
+		expectedErr    string
+	}{
+		"success": {
+			name:           "name",
+			subscriptionID: testSubscription,
+			client:         client,
+			proc:           proc,
+			options:        []OptionFunc{Retries(5)},
+		},
+		"missing name": {
+			subscriptionID: testSubscription,
+			client:         client,
+			proc:           proc,
+			expectedErr:    "component name is empty",
+		},
+		"missing subscription ID": {
+			name:        "name",
+			client:      client,
+			proc:        proc,
+			expectedErr: "subscription ID is empty",
+		},
+		"missing client": {
+			name:           "name",
+			subscriptionID: testSubscription,
+			proc:           proc,
+			expectedErr:    "Pub/Sub client is nil",
+		},
+		"missing process function": {
+			name:           "name",
+			subscriptionID: testSubscription,
+			client:         client,
+			expectedErr:    "process function is nil",
+		},
+		"option error": {
+			name:           "name",
+			subscriptionID: testSubscription,
+			client:         client,
+			proc:           proc,
+			options:        []OptionFunc{RetryWait(0)},
+			expectedErr:    "retry wait time should be a positive number",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.name, tt.subscriptionID, tt.client, tt.proc, tt.options...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.subscriptionID, got.subscription.ID())
+				assert.Equal(t, uint(5), got.retry.count)
+			}
+		})
+	}
+}
+
+func TestComponent_Run(t *testing.T) {
+	mtr := mocktracer.New()
+	opentracing.SetGlobalTracer(mtr)
+	defer mtr.Reset()
+
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	createSubscription(t, client, false)
+
+	ackID := srv.Publish(fullTopic, []byte("ack"), map[string]string{correlation.HeaderID: "corID"})
+	nackID := srv.Publish(fullTopic, []byte("nack"), nil)
+
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	corIDs := make(map[string]string)
+	proc := func(_ context.Context, msg Message) {
+		mu.Lock()
+		bodies[msg.ID()] = string(msg.Body())
+		corIDs[msg.ID()] = correlation.IDFromContext(msg.Context())
+		mu.Unlock()
+		if string(msg.Body()) == "ack" {
+			msg.ACK()
+			return
+		}
+		msg.NACK()
+		// the message is settled once
+		msg.ACK()
+	}
+
+	cmp, err := New("name", testSubscription, client, proc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return srv.Message(ackID).Acks == 1 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-chDone)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "ack", bodies[ackID])
+	assert.Equal(t, "nack", bodies[nackID])
+	assert.Equal(t, "corID", corIDs[ackID])
+	assert.NotEmpty(t, corIDs[nackID], "a correlation ID is generated for the messages without one")
+	assert.Equal(t, 0, srv.Message(nackID).Acks)
+	assert.NotEmpty(t, mtr.FinishedSpans())
+}
+
+func TestComponent_Run_NotSettled(t *testing.T) {
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	createSubscription(t, client, false)
+
+	id := srv.Publish(fullTopic, []byte("body"), nil)
+
+	processed := make(chan struct{}, 10)
+	proc := func(_ context.Context, msg Message) {
+		processed <- struct{}{}
+	}
+
+	cmp, err := New("name", testSubscription, client, proc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	// the message is not acknowledged after the processor returns, so that it is delivered again
+	<-processed
+	<-processed
+	cancel()
+	require.NoError(t, <-chDone)
+	assert.Equal(t, 0, srv.Message(id).Acks)
+}
+
+func TestComponent_Run_OrderingKey(t *testing.T) {
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	createSubscription(t, client, true)
+
+	for _, body := range []string{"1", "2", "3"} {
+		srv.PublishOrdered(fullTopic, []byte(body), nil, "key")
+	}
+
+	var mu sync.Mutex
+	var processed, inFlight, maxInFlight int
+	keys := make(map[string]struct{})
+	proc := func(_ context.Context, msg Message) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		keys[msg.OrderingKey()] = struct{}{}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		processed++
+		mu.Unlock()
+		msg.ACK()
+	}
+
+	cmp, err := New("name", testSubscription, client, proc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processed == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-chDone)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, maxInFlight, "the messages with the same ordering key are processed one at a time")
+	assert.Equal(t, map[string]struct{}{"key": {}}, keys)
+}
+
+func TestComponent_Run_Drain(t *testing.T) {
+	srv, client := newTestClient(t)
+	defer func() { _ = srv.Close() }()
+	createSubscription(t, client, false)
+
+	id := srv.Publish(fullTopic, []byte("body"), nil)
+
+	started := make(chan struct{})
+	proc := func(ctx context.Context, msg Message) {
+		close(started)
+		// the message is processed in the drain context, which outlives the context of the component
+		time.Sleep(50 * time.Millisecond)
+		if ctx.Err() == nil {
+			msg.ACK()
+		}
+	}
+
+	cmp, err := New("name", testSubscription, client, proc, MaxOutstandingMessages(1))
+	require.NoError(t, err)
+
+	drain, cancelDrain := context.WithCancel(context.Background())
+	defer cancelDrain()
+	ctx, cancel := context.WithCancel(shutdown.ContextWithDrain(context.Background(), drain))
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	<-started
+	cancel()
+	require.NoError(t, <-chDone)
+	assert.Equal(t, 1, srv.Message(id).Acks)
+}
+
+func newTestClient(t *testing.T) (*pstest.Server, *pubsub.Client) {
+	srv := pstest.NewServer()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	client, err := pubsub.NewClient(context.Background(), "project", option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return srv, client
+}
+
+func createSubscription(t *testing.T, client *pubsub.Client, ordered bool) {
+	ctx := context.Background()
+	topic, err := client.CreateTopic(ctx, testTopic)
+	require.NoError(t, err)
+	_, err = client.CreateSubscription(ctx, testSubscription, pubsub.SubscriptionConfig{
+		Topic:                 topic,
+		EnableMessageOrdering: ordered,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/beatlabs/patron/trace"
+	"github.com/opentracing/opentracing-go"
+)
+
+// Message interface for Google Cloud Pub/Sub message.
+type Message interface {
+	// Context will contain the context to be used for processing.
+	// Each context will have a logger setup which can be used to create a logger from context.
+	Context() context.Context
+	// ID of the message.
+	ID() string
+	// Body of the message.
+	Body() []byte
+	// OrderingKey of the message, which is empty for the messages published without one.
+	OrderingKey() string
+	// Message will contain the raw Pub/Sub message.
+	Message() *pubsub.Message
+	// Span contains the tracing span of this message.
+	Span() opentracing.Span
+	// ACK acknowledges the message and completes the tracing span.
+	ACK()
+	// NACK does not acknowledge the message, so that it is delivered again, along with the messages with the same ordering key
+	// which follow it when the subscription has message ordering enabled, and completes the tracing span.
+	NACK()
+}
+
+type message struct {
+	ctx          context.Context
+	subscription string
+	msg          *pubsub.Message
+	span         opentracing.Span
+
+	mu sync.Mutex
+	// whether the message was acknowledged or not acknowledged
+	settled bool
+}
+
+func (m *message) Context() context.Context {
+	return m.ctx
+}
+
+func (m *message) ID() string {
+	return m.msg.ID
+}
+
+func (m *message) Body() []byte {
+	return m.msg.Data
+}
+
+func (m *message) OrderingKey() string {
+	return m.msg.OrderingKey
+}
+
+func (m *message) Message() *pubsub.Message {
+	return m.msg
+}
+
+func (m *message) Span() opentracing.Span {
+	return m.span
+}
+
+func (m *message) ACK() {
+	if !m.settle() {
+		return
+	}
+	m.msg.Ack()
+	messageCountInc(m.subscription, ackMessageState, 1)
+	trace.SpanSuccess(m.span)
+}
+
+func (m *message) NACK() {
+	if !m.settle() {
+		return
+	}
+	m.nack()
+}
+
+func (m *message) nack() {
+	m.msg.Nack()
+	messageCountInc(m.subscription, nackMessageState, 1)
+	trace.SpanSuccess(m.span)
+}
+
+// settle marks the message as acknowledged or not acknowledged, and returns false if it was already settled.
+func (m *message) settle() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.settled {
+		return false
+	}
+	m.settled = true
+	return true
+}
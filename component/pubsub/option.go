@@ -0,0 +1,76 @@
+package pubsub
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	minAckDeadline = 10 * time.Second
+	maxAckDeadline = 10 * time.Minute
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// AckDeadlineExtension extends the ack deadline of the messages while they are processed, so that the slow messages
+// are not delivered again to another consumer, without setting a large ack deadline on the subscription.
+// The ack deadline is extended by up to the period at a time, until the messages are acknowledged or not acknowledged,
+// or until their deadline has been extended for the max duration since they were received, when they are delivered again.
+// The period should be between 10 seconds and 10 minutes, and the max duration should not be less than the period.
+// By default, the deadline is extended for up to 60 minutes.
+func AckDeadlineExtension(period, max time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if period < minAckDeadline || period > maxAckDeadline {
+			return errors.New("ack deadline extension period should be between 10 seconds and 10 minutes")
+		}
+		if max < period {
+			return errors.New("max ack deadline extension should not be less than the period")
+		}
+		c.subscription.ReceiveSettings.MaxExtensionPeriod = period
+		c.subscription.ReceiveSettings.MaxExtension = max
+		return nil
+	}
+}
+
+// MaxOutstandingMessages sets the maximum number of messages which are received and not acknowledged or not acknowledged yet,
+// which is also the maximum number of messages processed concurrently, and it is 1000 by default.
+func MaxOutstandingMessages(count uint) OptionFunc {
+	return func(c *Component) error {
+		if count == 0 {
+			return errors.New("max outstanding messages should be a positive number")
+		}
+		c.subscription.ReceiveSettings.MaxOutstandingMessages = int(count)
+		return nil
+	}
+}
+
+// Pollers sets the number of goroutines receiving messages from the subscription concurrently, which is 10 by default.
+func Pollers(count uint) OptionFunc {
+	return func(c *Component) error {
+		if count == 0 {
+			return errors.New("pollers should be a positive number")
+		}
+		c.subscription.ReceiveSettings.NumGoroutines = int(count)
+		return nil
+	}
+}
+
+// Retries sets the error retries of the component.
+func Retries(count uint) OptionFunc {
+	return func(c *Component) error {
+		c.retry.count = count
+		return nil
+	}
+}
+
+// RetryWait sets the wait period for the component retry.
+func RetryWait(interval time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if interval <= 0 {
+			return errors.New("retry wait time should be a positive number")
+		}
+		c.retry.wait = interval
+		return nil
+	}
+}
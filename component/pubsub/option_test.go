@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAckDeadlineExtension(t *testing.T) {
+	tests := map[string]struct {
+		period      time.Duration
+		max         time.Duration
+		expectedErr string
+	}{
+		"success": {
+			period: 30 * time.Second,
+			max:    15 * time.Minute,
+		},
+		"period too short": {
+			period:      5 * time.Second,
+			max:         15 * time.Minute,
+			expectedErr: "ack deadline extension period should be between 10 seconds and 10 minutes",
+		},
+		"period too long": {
+			period:      11 * time.Minute,
+			max:         15 * time.Minute,
+			expectedErr: "ack deadline extension period should be between 10 seconds and 10 minutes",
+		},
+		"max less than period": {
+			period:      30 * time.Second,
+			max:         20 * time.Second,
+			expectedErr: "max ack deadline extension should not be less than the period",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Component{subscription: &pubsub.Subscription{}}
+			err := AckDeadlineExtension(tt.period, tt.max)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.period, c.subscription.ReceiveSettings.MaxExtensionPeriod)
+				assert.Equal(t, tt.max, c.subscription.ReceiveSettings.MaxExtension)
+			}
+		})
+	}
+}
+
+func TestMaxOutstandingMessages(t *testing.T) {
+	c := &Component{subscription: &pubsub.Subscription{}}
+	assert.EqualError(t, MaxOutstandingMessages(0)(c), "max outstanding messages should be a positive number")
+	assert.NoError(t, MaxOutstandingMessages(5)(c))
+	assert.Equal(t, 5, c.subscription.ReceiveSettings.MaxOutstandingMessages)
+}
+
+func TestPollers(t *testing.T) {
+	c := &Component{subscription: &pubsub.Subscription{}}
+	assert.EqualError(t, Pollers(0)(c), "pollers should be a positive number")
+	assert.NoError(t, Pollers(2)(c))
+	assert.Equal(t, 2, c.subscription.ReceiveSettings.NumGoroutines)
+}
+
+func TestRetries(t *testing.T) {
+	c := &Component{}
+	assert.NoError(t, Retries(5)(c))
+	assert.Equal(t, uint(5), c.retry.count)
+}
+
+func TestRetryWait(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, RetryWait(0)(c), "retry wait time should be a positive number")
+	assert.NoError(t, RetryWait(time.Second)(c))
+	assert.Equal(t, time.Second, c.retry.wait)
+}
@@ -0,0 +1,26 @@
+// Package shutdown provides support for draining the in-flight work of the components when the service is terminated.
+package shutdown
+
+import (
+	"context"
+)
+
+type drainContextKey struct{}
+
+var drainKey = drainContextKey{}
+
+// ContextWithDrain sets the context in which a component drains its in-flight work to the context of the component.
+// The drain context outlives the context of the component, which is done as soon as the service is terminated,
+// and is done once the deadline for draining is exceeded.
+func ContextWithDrain(ctx, drain context.Context) context.Context {
+	return context.WithValue(ctx, drainKey, drain)
+}
+
+// DrainFromContext returns the context in which a component drains its in-flight work, e.g. the messages it is processing,
+// once its context is done. If no drain context is set, the context itself is returned, so the work is cancelled along with it.
+func DrainFromContext(ctx context.Context) context.Context {
+	if drain, ok := ctx.Value(drainKey).(context.Context); ok {
+		return drain
+	}
+	return ctx
+}
@@ -0,0 +1,22 @@
+package shutdown
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Equal(t, ctx, DrainFromContext(ctx))
+
+	drain, cancelDrain := context.WithCancel(context.Background())
+	defer cancelDrain()
+	ctx = ContextWithDrain(ctx, drain)
+	cancel()
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, DrainFromContext(ctx).Err(), "the drain context outlives the context of the component")
+	cancelDrain()
+	assert.Error(t, DrainFromContext(ctx).Err())
+}
@@ -6,11 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/component/shutdown"
 	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/trace"
@@ -23,6 +27,8 @@ const (
 	defaultRetries       = 10
 	defaultRetryWait     = time.Second
 	defaultMaxMessages   = 3
+	defaultPollers       = 1
+	defaultWorkers       = 1
 )
 
 // ProcessorFunc definition of a async processor.
@@ -35,6 +41,12 @@ const (
 	sqsAttributeApproximateNumberOfMessagesDelayed    = "ApproximateNumberOfMessagesDelayed"
 	sqsAttributeApproximateNumberOfMessagesNotVisible = "ApproximateNumberOfMessagesNotVisible"
 	sqsAttributeSentTimestamp                         = "SentTimestamp"
+	sqsAttributeMessageGroupID                        = "MessageGroupId"
+	sqsAttributeMessageDeduplicationID                = "MessageDeduplicationId"
+	sqsAttributeSequenceNumber                        = "SequenceNumber"
+	sqsAttributeApproximateReceiveCount               = "ApproximateReceiveCount"
+
+	fifoSuffix = ".fifo"
 
 	sqsMessageAttributeAll = "All"
 
@@ -43,6 +55,14 @@ const (
 	ackMessageState     messageState = "ACK"
 	nackMessageState    messageState = "NACK"
 	fetchedMessageState messageState = "FETCHED"
+	// the visibility of the messages was extended while they were processed
+	extendedMessageState messageState = "EXTENDED"
+	// the message was moved from the dead letter queue back to the queue
+	redrivenMessageState messageState = "REDRIVEN"
+	// the message was handled by the failure strategy
+	retriedMessageState      messageState = "RETRIED"
+	skippedMessageState      messageState = "SKIPPED"
+	deadLetteredMessageState messageState = "DEAD_LETTERED"
 )
 
 var (
@@ -108,6 +128,20 @@ type Component struct {
 	proc  ProcessorFunc
 	stats stats
 	retry retry
+
+	visibility visibility
+	// whether the SNS notifications are unwrapped
+	unwrapSNS bool
+
+	concurrency concurrency
+	inFlight    *inFlight
+
+	deadLetter queue
+
+	// the decisions about the messages which are not acknowledged, which are left in the queue when nil
+	failure patronfailure.Strategy
+	// the notifications of the failure strategy stopping the component
+	stop chan error
 }
 
 // New creates a new component with support for functional configuration.
@@ -140,6 +174,7 @@ func New(name, queueName string, sqsAPI sqsiface.SQSAPI, proc ProcessorFunc, oo
 		queue: queue{
 			name: queueName,
 			url:  aws.StringValue(out.QueueUrl),
+			fifo: strings.HasSuffix(queueName, fifoSuffix),
 		},
 		api: sqsAPI,
 		cfg: config{
@@ -153,6 +188,11 @@ func New(name, queueName string, sqsAPI sqsiface.SQSAPI, proc ProcessorFunc, oo
 			count: defaultRetries,
 			wait:  defaultRetryWait,
 		},
+		concurrency: concurrency{
+			pollers: defaultPollers,
+			workers: defaultWorkers,
+		},
+		stop: make(chan error, 1),
 	}
 
 	for _, optionFunc := range oo {
@@ -162,14 +202,52 @@ func New(name, queueName string, sqsAPI sqsiface.SQSAPI, proc ProcessorFunc, oo
 		}
 	}
 
+	if cmp.visibility.enabled() {
+		cmp.cfg.visibilityTimeout = aws.Int64(int64(cmp.visibility.timeout / time.Second))
+	}
+
+	// by default, a batch is received only when a worker is available to process it
+	if cmp.concurrency.maxInFlight == 0 {
+		cmp.concurrency.maxInFlight = int64(cmp.concurrency.workers) * *cmp.cfg.maxMessages
+	}
+	if cmp.concurrency.maxInFlight < *cmp.cfg.maxMessages {
+		return nil, errors.New("max in-flight messages should not be less than the max messages")
+	}
+	cmp.inFlight = newInFlight(queueName, cmp.concurrency.maxInFlight)
+
+	if patronfailure.Declares(cmp.failure, patronfailure.DeadLetter) && cmp.deadLetter.name == "" {
+		return nil, errors.New("failure strategy requires a dead letter queue")
+	}
+
+	if cmp.deadLetter.name != "" {
+		out, err := sqsAPI.GetQueueUrlWithContext(context.Background(), &sqs.GetQueueUrlInput{
+			QueueName: aws.String(cmp.deadLetter.name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letter queue URL: %w", err)
+		}
+		cmp.deadLetter.url = aws.StringValue(out.QueueUrl)
+	}
+
 	return cmp, nil
 }
 
-// Run starts the consumer processing loop messages.
+// Run starts the consumer processing loop messages, until the context is done or the failure strategy stops the component.
 func (c *Component) Run(ctx context.Context) error {
-	chErr := make(chan error)
-
-	go c.consume(ctx, chErr)
+	chErr := make(chan error, c.concurrency.pollers)
+
+	batches := make(chan batch)
+	var workers sync.WaitGroup
+	for i := uint(0); i < c.concurrency.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.process(ctx, batches)
+		}()
+	}
+	for i := uint(0); i < c.concurrency.pollers; i++ {
+		go c.consume(ctx, batches, chErr)
+	}
 
 	tickerStats := time.NewTicker(c.stats.interval)
 	defer tickerStats.Stop()
@@ -177,25 +255,33 @@ func (c *Component) Run(ctx context.Context) error {
 		select {
 		case err := <-chErr:
 			return err
+		case err := <-c.stop:
+			return err
 		case <-ctx.Done():
 			log.FromContext(ctx).Info("context cancellation received. exiting...")
+			// the batches in flight are processed until the shutdown deadline, if any, before the component returns
+			workers.Wait()
 			return nil
 		case <-tickerStats.C:
 			err := c.report(ctx, c.api, c.queue.url)
 			if err != nil {
 				log.FromContext(ctx).Errorf("failed to report sqsAPI stats: %v", err)
 			}
+			err = c.reportDeadLetterQueue(ctx)
+			if err != nil {
+				log.FromContext(ctx).Errorf("failed to report dead letter queue stats: %v", err)
+			}
 		}
 	}
 }
 
-func (c *Component) consume(ctx context.Context, chErr chan error) {
+func (c *Component) consume(ctx context.Context, batches chan<- batch, chErr chan<- error) {
 	logger := log.FromContext(ctx)
 
 	retries := c.retry.count
 
 	for {
-		if ctx.Err() != nil {
+		if err := c.inFlight.acquire(ctx, *c.cfg.maxMessages); err != nil {
 			return
 		}
 		logger.Debugf("consume: polling SQS sqsAPI %s for %d messages", c.queue.name, *c.cfg.maxMessages)
@@ -204,14 +290,13 @@ func (c *Component) consume(ctx context.Context, chErr chan error) {
 			MaxNumberOfMessages: c.cfg.maxMessages,
 			WaitTimeSeconds:     c.cfg.pollWaitSeconds,
 			VisibilityTimeout:   c.cfg.visibilityTimeout,
-			AttributeNames: aws.StringSlice([]string{
-				sqsAttributeSentTimestamp,
-			}),
+			AttributeNames:      aws.StringSlice(c.attributeNames()),
 			MessageAttributeNames: aws.StringSlice([]string{
 				sqsMessageAttributeAll,
 			}),
 		})
 		if err != nil {
+			c.inFlight.release(*c.cfg.maxMessages)
 			logger.Errorf("failed to receive messages: %v, sleeping for %v", err, c.retry.wait)
 			time.Sleep(c.retry.wait)
 			retries--
@@ -222,6 +307,8 @@ func (c *Component) consume(ctx context.Context, chErr chan error) {
 			return
 		}
 		retries = c.retry.count
+		received := time.Now()
+		c.inFlight.release(*c.cfg.maxMessages - int64(len(output.Messages)))
 
 		if ctx.Err() != nil {
 			return
@@ -234,38 +321,80 @@ func (c *Component) consume(ctx context.Context, chErr chan error) {
 			continue
 		}
 
-		btc := c.createBatch(ctx, output)
+		btc := c.createBatch(shutdown.DrainFromContext(ctx), output)
+		btc.received = received
 
-		c.proc(ctx, btc)
+		select {
+		case <-ctx.Done():
+			return
+		case batches <- btc:
+		}
 	}
 }
 
+// process passes the received batches to the processor, extending the visibility of their messages while they are processed.
+func (c *Component) process(ctx context.Context, batches <-chan batch) {
+	pctx := shutdown.DrainFromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case btc := <-batches:
+			stop := c.extendVisibility(pctx, btc.received, btc.pending)
+			c.proc(pctx, btc)
+			stop()
+			c.inFlight.release(int64(len(btc.messages)))
+		}
+	}
+}
+
+// attributeNames returns the attributes of the received messages, which include the message group ID, the deduplication ID
+// and the sequence number of the messages of FIFO queues, and the receive count of the messages handled by the failure strategy.
+func (c *Component) attributeNames() []string {
+	names := []string{sqsAttributeSentTimestamp}
+	if c.queue.fifo {
+		names = append(names, sqsAttributeMessageGroupID, sqsAttributeMessageDeduplicationID, sqsAttributeSequenceNumber)
+	}
+	if c.failure != nil {
+		names = append(names, sqsAttributeApproximateReceiveCount)
+	}
+	return names
+}
+
 func (c *Component) createBatch(ctx context.Context, output *sqs.ReceiveMessageOutput) batch {
 	btc := batch{
 		ctx:      ctx,
 		queue:    c.queue,
 		sqsAPI:   c.api,
 		messages: make([]Message, 0, len(output.Messages)),
+		pending:  newPending(output.Messages),
 	}
 
 	for _, msg := range output.Messages {
 		observerMessageAge(c.queue.name, msg.Attributes)
+		if c.unwrapSNS {
+			unwrapSNS(msg)
+		}
 
 		corID := getCorrelationID(msg.MessageAttributes)
 
+		hdr := mapHeader(msg.MessageAttributes)
 		sp, ctxCh := trace.ConsumerSpan(ctx, trace.ComponentOpName(consumerComponent, c.queue.name),
-			consumerComponent, corID, mapHeader(msg.MessageAttributes))
+			consumerComponent, corID, hdr)
 
 		ctxCh = correlation.ContextWithID(ctxCh, corID)
+		ctxCh = correlation.ContextWithHeaders(ctxCh, func(header string) string { return hdr[header] })
 		logger := log.Sub(map[string]interface{}{correlation.ID: corID})
 		ctxCh = log.WithContext(ctxCh, logger)
 
 		btc.messages = append(btc.messages, message{
-			ctx:   ctxCh,
-			queue: c.queue,
-			api:   c.api,
-			msg:   msg,
-			span:  sp,
+			ctx:     ctxCh,
+			queue:   c.queue,
+			api:     c.api,
+			msg:     msg,
+			span:    sp,
+			pending: btc.pending,
+			failure: failureConfig{strategy: c.failure, deadLetter: c.deadLetter, stop: c.stop},
 		})
 	}
 
@@ -197,3 +197,15 @@ func (sp stubProcessor) process(_ context.Context, b Batch) {
 	_, err := b.ACK()
 	require.NoError(sp.t, err)
 }
+
+func TestComponent_FIFO(t *testing.T) {
+	cmp, err := New("name", "queue.fifo", stubSQSAPI{}, stubProcessor{t: t}.process)
+	require.NoError(t, err)
+	assert.True(t, cmp.queue.fifo)
+	assert.Equal(t, []string{"SentTimestamp", "MessageGroupId", "MessageDeduplicationId", "SequenceNumber"}, cmp.attributeNames())
+
+	cmp, err = New("name", "queue", stubSQSAPI{}, stubProcessor{t: t}.process)
+	require.NoError(t, err)
+	assert.False(t, cmp.queue.fifo)
+	assert.Equal(t, []string{"SentTimestamp"}, cmp.attributeNames())
+}
@@ -0,0 +1,162 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// redriveMaxMessages is the maximum number of messages which are received from the dead letter queue at once.
+const redriveMaxMessages = 10
+
+var deadLetterQueueSize *prometheus.GaugeVec
+
+func init() {
+	deadLetterQueueSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "sqs",
+			Name:      "dead_letter_queue_size",
+			Help:      "Size of the dead letter queue reported by AWS, classified by the source queue",
+		},
+		[]string{"queue"},
+	)
+	prometheus.MustRegister(deadLetterQueueSize)
+}
+
+// reportDeadLetterQueue reports the size of the dead letter queue, if it is configured.
+func (c *Component) reportDeadLetterQueue(ctx context.Context) error {
+	if c.deadLetter.url == "" {
+		return nil
+	}
+	rsp, err := c.api.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		AttributeNames: aws.StringSlice([]string{sqsAttributeApproximateNumberOfMessages}),
+		QueueUrl:       aws.String(c.deadLetter.url),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get the attributes of dead letter queue %s: %w", c.deadLetter.name, err)
+	}
+	size, err := getAttributeFloat64(rsp.Attributes, sqsAttributeApproximateNumberOfMessages)
+	if err != nil {
+		return err
+	}
+	deadLetterQueueSize.WithLabelValues(c.queue.name).Set(size)
+	return nil
+}
+
+// Redrive moves the messages of the dead letter queue back to the queue of the component, at most rate messages per second,
+// until the dead letter queue is empty or the context is done, returning the number of the messages which were moved.
+// The messages keep their body and their attributes, and the messages which fail to be sent stay in the dead letter queue.
+func (c *Component) Redrive(ctx context.Context, rate uint) (int, error) {
+	if c.deadLetter.url == "" {
+		return 0, errors.New("dead letter queue is not configured")
+	}
+	if rate == 0 {
+		return 0, errors.New("rate should be a positive number")
+	}
+
+	maxMessages := int64(redriveMaxMessages)
+	if int64(rate) < maxMessages {
+		maxMessages = int64(rate)
+	}
+
+	start := time.Now()
+	redriven, failed := 0, 0
+	for {
+		output, err := c.api.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(c.deadLetter.url),
+			MaxNumberOfMessages:   aws.Int64(maxMessages),
+			AttributeNames:        aws.StringSlice(c.attributeNames()),
+			MessageAttributeNames: aws.StringSlice([]string{sqsMessageAttributeAll}),
+		})
+		if err != nil {
+			return redriven, fmt.Errorf("failed to receive messages from dead letter queue %s: %w", c.deadLetter.name, err)
+		}
+		if len(output.Messages) == 0 {
+			break
+		}
+
+		sent, err := c.redrive(ctx, output.Messages)
+		if err != nil {
+			return redriven, err
+		}
+		redriven += sent
+		failed += len(output.Messages) - sent
+		messageCountInc(c.queue.name, redrivenMessageState, sent)
+
+		// wait until the messages which were received so far are within the rate
+		wait := time.Duration(float64(redriven+failed)/float64(rate)*float64(time.Second)) - time.Since(start)
+		if wait <= 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return redriven, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	if failed > 0 {
+		return redriven, fmt.Errorf("failed to redrive %d messages from dead letter queue %s", failed, c.deadLetter.name)
+	}
+	return redriven, nil
+}
+
+// redrive sends the messages to the queue, and deletes the ones which were sent from the dead letter queue.
+func (c *Component) redrive(ctx context.Context, messages []*sqs.Message) (int, error) {
+	entries := make([]*sqs.SendMessageBatchRequestEntry, 0, len(messages))
+	for _, msg := range messages {
+		entry := &sqs.SendMessageBatchRequestEntry{
+			Id:                aws.String(aws.StringValue(msg.MessageId)),
+			MessageBody:       msg.Body,
+			MessageAttributes: msg.MessageAttributes,
+		}
+		if c.queue.fifo {
+			entry.MessageGroupId = msg.Attributes[sqsAttributeMessageGroupID]
+			entry.MessageDeduplicationId = msg.Attributes[sqsAttributeMessageDeduplicationID]
+		}
+		entries = append(entries, entry)
+	}
+
+	output, err := c.api.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
+		Entries:  entries,
+		QueueUrl: aws.String(c.queue.url),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to send messages to queue %s: %w", c.queue.name, err)
+	}
+	for _, fail := range output.Failed {
+		log.FromContext(ctx).Errorf("failed to redrive message %s to queue %s: %s", aws.StringValue(fail.Id), c.queue.name, aws.StringValue(fail.Message))
+	}
+	if len(output.Successful) == 0 {
+		return 0, nil
+	}
+
+	handles := make(map[string]*string, len(messages))
+	for _, msg := range messages {
+		handles[aws.StringValue(msg.MessageId)] = msg.ReceiptHandle
+	}
+	deletes := make([]*sqs.DeleteMessageBatchRequestEntry, 0, len(output.Successful))
+	for _, suc := range output.Successful {
+		deletes = append(deletes, &sqs.DeleteMessageBatchRequestEntry{Id: suc.Id, ReceiptHandle: handles[aws.StringValue(suc.Id)]})
+	}
+	deleted, err := c.api.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+		Entries:  deletes,
+		QueueUrl: aws.String(c.deadLetter.url),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete the redriven messages from dead letter queue %s: %w", c.deadLetter.name, err)
+	}
+	// the messages which fail to be deleted are received again from the dead letter queue, and they are duplicated in the queue
+	for _, fail := range deleted.Failed {
+		log.FromContext(ctx).Errorf("failed to delete redriven message %s from dead letter queue %s: %s",
+			aws.StringValue(fail.Id), c.deadLetter.name, aws.StringValue(fail.Message))
+	}
+	return len(output.Successful), nil
+}
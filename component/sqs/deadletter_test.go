@@ -0,0 +1,183 @@
+package sqs
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, DeadLetterQueue("")(c), "dead letter queue name is empty")
+	require.NoError(t, DeadLetterQueue("queue-dlq")(c))
+	assert.Equal(t, "queue-dlq", c.deadLetter.name)
+
+	api := newRedriveSQSAPI(0)
+	cmp, err := New("name", "queue", api, stubProcessor{t: t}.process, DeadLetterQueue("queue-dlq"))
+	require.NoError(t, err)
+	assert.Equal(t, "url/queue-dlq", cmp.deadLetter.url)
+
+}
+
+func TestComponent_reportDeadLetterQueue(t *testing.T) {
+	cmp, err := New("name", "report-queue", newRedriveSQSAPI(0), stubProcessor{t: t}.process)
+	require.NoError(t, err)
+	assert.NoError(t, cmp.reportDeadLetterQueue(context.Background()), "nothing is reported without a dead letter queue")
+
+	cmp, err = New("name", "report-queue", newRedriveSQSAPI(0), stubProcessor{t: t}.process, DeadLetterQueue("report-queue-dlq"))
+	require.NoError(t, err)
+	require.NoError(t, cmp.reportDeadLetterQueue(context.Background()))
+	assert.Equal(t, 1.0, testutil.ToFloat64(deadLetterQueueSize.WithLabelValues("report-queue")))
+}
+
+func TestComponent_Redrive(t *testing.T) {
+	tests := map[string]struct {
+		messages         int
+		failed           map[string]bool
+		rate             uint
+		configured       bool
+		expectedRedriven int
+		expectedLeft     int
+		expectedErr      string
+	}{
+		"success":             {messages: 15, rate: 1000, configured: true, expectedRedriven: 15},
+		"empty":               {rate: 1000, configured: true},
+		"partial failure":     {messages: 3, failed: map[string]bool{"1": true}, rate: 1000, configured: true, expectedRedriven: 2, expectedLeft: 1, expectedErr: "failed to redrive 1 messages from dead letter queue queue-dlq"},
+		"missing rate":        {rate: 0, configured: true, expectedErr: "rate should be a positive number"},
+		"missing dead letter": {rate: 1000, expectedErr: "dead letter queue is not configured"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			api := newRedriveSQSAPI(tt.messages)
+			api.failed = tt.failed
+			var oo []OptionFunc
+			if tt.configured {
+				oo = append(oo, DeadLetterQueue("queue-dlq"))
+			}
+			cmp, err := New("name", "queue", api, stubProcessor{t: t}.process, oo...)
+			require.NoError(t, err)
+
+			redriven, err := cmp.Redrive(context.Background(), tt.rate)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedRedriven, redriven)
+			assert.Len(t, api.sent, tt.expectedRedriven)
+			assert.Len(t, api.deadLetters, tt.expectedLeft)
+			for _, msg := range api.sent {
+				assert.Equal(t, "body", aws.StringValue(msg.MessageBody))
+				assert.Equal(t, "value", aws.StringValue(msg.MessageAttributes["key"].StringValue))
+			}
+		})
+	}
+}
+
+func TestComponent_Redrive_Rate(t *testing.T) {
+	api := newRedriveSQSAPI(4)
+	cmp, err := New("name", "queue", api, stubProcessor{t: t}.process, DeadLetterQueue("queue-dlq"))
+	require.NoError(t, err)
+
+	start := time.Now()
+	redriven, err := cmp.Redrive(context.Background(), 20)
+	require.NoError(t, err)
+	assert.Equal(t, 4, redriven)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(200*time.Millisecond), "4 messages are redriven in 200ms at 20 messages per second")
+
+	api = newRedriveSQSAPI(4)
+	cmp, err = New("name", "queue", api, stubProcessor{t: t}.process, DeadLetterQueue("queue-dlq"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	redriven, err = cmp.Redrive(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, redriven, "a message is redriven per second")
+}
+
+// redriveSQSAPI is a queue with a dead letter queue, whose received messages are not received again until they are deleted.
+type redriveSQSAPI struct {
+	stubSQSAPI
+	mu          sync.Mutex
+	deadLetters []*sqs.Message
+	received    map[string]bool
+	failed      map[string]bool
+	sent        []*sqs.SendMessageBatchRequestEntry
+}
+
+func newRedriveSQSAPI(messages int) *redriveSQSAPI {
+	api := &redriveSQSAPI{received: make(map[string]bool)}
+	for i := 0; i < messages; i++ {
+		id := strconv.Itoa(i)
+		api.deadLetters = append(api.deadLetters, &sqs.Message{
+			MessageId:         aws.String(id),
+			ReceiptHandle:     aws.String("handle-" + id),
+			Body:              aws.String("body"),
+			MessageAttributes: map[string]*sqs.MessageAttributeValue{"key": {DataType: aws.String("String"), StringValue: aws.String("value")}},
+		})
+	}
+	return api
+}
+
+// nolint
+func (s *redriveSQSAPI) GetQueueUrlWithContext(_ aws.Context, input *sqs.GetQueueUrlInput, _ ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("url/" + aws.StringValue(input.QueueName))}, nil
+}
+
+func (s *redriveSQSAPI) ReceiveMessageWithContext(_ aws.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	output := &sqs.ReceiveMessageOutput{}
+	for _, msg := range s.deadLetters {
+		if int64(len(output.Messages)) == aws.Int64Value(input.MaxNumberOfMessages) {
+			break
+		}
+		if !s.received[aws.StringValue(msg.MessageId)] {
+			s.received[aws.StringValue(msg.MessageId)] = true
+			output.Messages = append(output.Messages, msg)
+		}
+	}
+	return output, nil
+}
+
+func (s *redriveSQSAPI) SendMessageBatchWithContext(_ aws.Context, input *sqs.SendMessageBatchInput, _ ...request.Option) (*sqs.SendMessageBatchOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		if s.failed[aws.StringValue(entry.Id)] {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{Id: entry.Id, Message: aws.String("ERROR")})
+			continue
+		}
+		s.sent = append(s.sent, entry)
+		output.Successful = append(output.Successful, &sqs.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+func (s *redriveSQSAPI) DeleteMessageBatchWithContext(_ aws.Context, input *sqs.DeleteMessageBatchInput, _ ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := make(map[string]bool, len(input.Entries))
+	for _, entry := range input.Entries {
+		deleted[aws.StringValue(entry.ReceiptHandle)] = true
+	}
+	left := s.deadLetters[:0]
+	for _, msg := range s.deadLetters {
+		if !deleted[aws.StringValue(msg.ReceiptHandle)] {
+			left = append(left, msg)
+		}
+	}
+	s.deadLetters = left
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
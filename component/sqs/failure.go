@@ -0,0 +1,113 @@
+package sqs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/log"
+)
+
+// errStopped is the error of the component when the failure strategy stops it.
+var errStopped = errors.New("component stopped by the failure strategy")
+
+// failureConfig configures the handling of the messages which are not acknowledged.
+type failureConfig struct {
+	// the decisions about the messages which are not acknowledged, which are left in the queue when nil
+	strategy patronfailure.Strategy
+	// the queue of the messages of the failure.DeadLetter action
+	deadLetter queue
+	// the notifications of the failure strategy stopping the component
+	stop chan<- error
+}
+
+// attempt returns the number of times the message has been received, which is the number of its attempt.
+func attempt(msg *sqs.Message) uint {
+	count, err := strconv.ParseUint(aws.StringValue(msg.Attributes[sqsAttributeApproximateReceiveCount]), 10, 64)
+	if err != nil || count == 0 {
+		return 1
+	}
+	return uint(count)
+}
+
+// decide handles the message which is not acknowledged according to the decision of the failure strategy about the processing
+// error. A message to retry becomes visible again after the delay, and a message which stops the component is left in the queue.
+// The component is stopped as well when the decision cannot be applied.
+func (m message) decide(err error) {
+	if m.failure.strategy == nil {
+		return
+	}
+	n := attempt(m.msg)
+	d := m.failure.strategy.Decide(n, err)
+	switch d.Action {
+	case patronfailure.Retry:
+		delay := d.Delay / time.Second
+		if delay > twelveHoursInSeconds {
+			delay = twelveHoursInSeconds
+		}
+		_, err := m.api.ChangeMessageVisibilityWithContext(m.ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(m.queue.url),
+			ReceiptHandle:     m.msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(int64(delay)),
+		})
+		m.count(retriedMessageState, err)
+	case patronfailure.Skip:
+		m.count(skippedMessageState, m.delete())
+	case patronfailure.DeadLetter:
+		if m.failure.deadLetter.url == "" {
+			m.stop(errors.New("dead letter action requires a dead letter queue"))
+			return
+		}
+		m.count(deadLetteredMessageState, m.sendToDeadLetter())
+	case patronfailure.Stop:
+		m.stop(fmt.Errorf("message %s failed processing on attempt %d: %v", m.ID(), n, err))
+	default:
+		m.stop(fmt.Errorf("unknown failure action: %v", d.Action))
+	}
+}
+
+// stop stops the component with the error, leaving the message in the queue.
+func (m message) stop(err error) {
+	select {
+	case m.failure.stop <- fmt.Errorf("%w: %v", errStopped, err):
+	default:
+	}
+}
+
+func (m message) delete() error {
+	_, err := m.api.DeleteMessageWithContext(m.ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(m.queue.url),
+		ReceiptHandle: m.msg.ReceiptHandle,
+	})
+	return err
+}
+
+// sendToDeadLetter sends the message to the dead letter queue, with its body and attributes, and deletes it from the queue.
+func (m message) sendToDeadLetter() error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:          aws.String(m.failure.deadLetter.url),
+		MessageBody:       m.msg.Body,
+		MessageAttributes: m.msg.MessageAttributes,
+	}
+	if m.failure.deadLetter.fifo {
+		input.MessageGroupId = m.msg.Attributes[sqsAttributeMessageGroupID]
+		input.MessageDeduplicationId = m.msg.Attributes[sqsAttributeMessageDeduplicationID]
+	}
+	if _, err := m.api.SendMessageWithContext(m.ctx, input); err != nil {
+		return fmt.Errorf("failed to send message to dead letter queue %s: %w", m.failure.deadLetter.name, err)
+	}
+	return m.delete()
+}
+
+func (m message) count(state messageState, err error) {
+	if err != nil {
+		messageCountErrorInc(m.queue.name, state, 1)
+		log.FromContext(m.ctx).Errorf("failed to handle message %s of queue %s with state %s: %v", m.ID(), m.queue.name, state, err)
+		return
+	}
+	messageCountInc(m.queue.name, state, 1)
+}
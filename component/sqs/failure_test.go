@@ -0,0 +1,202 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	patronfailure "github.com/beatlabs/patron/component/failure"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failureSQSAPI struct {
+	sqsiface.SQSAPI
+	sendErr     error
+	visibility  []*sqs.ChangeMessageVisibilityInput
+	deleted     []*sqs.DeleteMessageInput
+	deadLetters []*sqs.SendMessageInput
+}
+
+func (f *failureSQSAPI) ChangeMessageVisibilityWithContext(_ aws.Context, input *sqs.ChangeMessageVisibilityInput, _ ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.visibility = append(f.visibility, input)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *failureSQSAPI) DeleteMessageWithContext(_ aws.Context, input *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	f.deleted = append(f.deleted, input)
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *failureSQSAPI) SendMessageWithContext(_ aws.Context, input *sqs.SendMessageInput, _ ...request.Option) (*sqs.SendMessageOutput, error) {
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	f.deadLetters = append(f.deadLetters, input)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+// nolint
+func (f *failureSQSAPI) GetQueueUrlWithContext(_ aws.Context, input *sqs.GetQueueUrlInput, _ ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String("url/" + aws.StringValue(input.QueueName))}, nil
+}
+
+func (f *failureSQSAPI) ReceiveMessageWithContext(ctx aws.Context, _ *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * time.Millisecond):
+	}
+	return &sqs.ReceiveMessageOutput{}, nil
+}
+
+func TestFailure(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Failure(nil)(c), "failure strategy is nil")
+	require.NoError(t, Failure(patronfailure.SkipStrategy())(c))
+	assert.NotNil(t, c.failure)
+	assert.Equal(t, []string{sqsAttributeSentTimestamp, sqsAttributeApproximateReceiveCount}, c.attributeNames())
+}
+
+func Test_attempt(t *testing.T) {
+	assert.Equal(t, uint(1), attempt(&sqs.Message{}))
+	assert.Equal(t, uint(1), attempt(&sqs.Message{Attributes: map[string]*string{sqsAttributeApproximateReceiveCount: aws.String("0")}}))
+	assert.Equal(t, uint(3), attempt(&sqs.Message{Attributes: map[string]*string{sqsAttributeApproximateReceiveCount: aws.String("3")}}))
+}
+
+func Test_message_NACK_Failure(t *testing.T) {
+	retry, err := patronfailure.RetryStrategy(3, patronfailure.ConstantBackoff(30*time.Second), patronfailure.DeadLetter)
+	require.NoError(t, err)
+	tests := map[string]struct {
+		strategy           patronfailure.Strategy
+		receiveCount       string
+		deadLetter         queue
+		sendErr            error
+		expectedState      messageState
+		expectedVisibility int64
+		expectedDeleted    bool
+		expectedDeadLetter bool
+		expectedStop       string
+		expectedError      bool
+	}{
+		"no strategy": {},
+		"retry": {
+			strategy: retry, receiveCount: "1", expectedState: retriedMessageState, expectedVisibility: 30,
+		},
+		"retries exhausted": {
+			strategy: retry, receiveCount: "3", deadLetter: queue{name: "queue-dlq.fifo", url: "url/queue-dlq.fifo", fifo: true},
+			expectedState: deadLetteredMessageState, expectedDeleted: true, expectedDeadLetter: true,
+		},
+		"skip": {
+			strategy: patronfailure.SkipStrategy(), expectedState: skippedMessageState, expectedDeleted: true,
+		},
+		"dead letter without queue": {
+			strategy:     patronfailure.DeadLetterStrategy(),
+			expectedStop: "component stopped by the failure strategy: dead letter action requires a dead letter queue",
+		},
+		"dead letter failure": {
+			strategy: patronfailure.DeadLetterStrategy(), deadLetter: queue{name: "queue-dlq", url: "url/queue-dlq"}, sendErr: errors.New("AWS FAILURE"),
+			expectedState: deadLetteredMessageState, expectedError: true,
+		},
+		"stop": {
+			strategy:     patronfailure.StopStrategy(),
+			expectedStop: "component stopped by the failure strategy: message 1 failed processing on attempt 1: message was negatively acknowledged",
+		},
+		"unknown action": {
+			strategy: patronfailure.StrategyFunc(func(uint, error) patronfailure.Decision {
+				return patronfailure.Decision{Action: patronfailure.Action(42)}
+			}),
+			expectedStop: "component stopped by the failure strategy: unknown failure action: unknown(42)",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			api := &failureSQSAPI{sendErr: tt.sendErr}
+			stop := make(chan error, 1)
+			msg := createMessage(api, "1")
+			msg.msg.Body = aws.String("body")
+			msg.msg.Attributes = map[string]*string{
+				sqsAttributeApproximateReceiveCount: aws.String(tt.receiveCount),
+				sqsAttributeMessageGroupID:          aws.String("group"),
+			}
+			msg.failure = failureConfig{strategy: tt.strategy, deadLetter: tt.deadLetter, stop: stop}
+			hasError := "false"
+			if tt.expectedError {
+				hasError = "true"
+			}
+			counter := messageCounter.WithLabelValues(queueName, string(tt.expectedState), hasError)
+			before := testutil.ToFloat64(counter)
+
+			msg.NACK()
+
+			if tt.expectedState != "" {
+				assert.Equal(t, 1.0, testutil.ToFloat64(counter)-before)
+			}
+			if tt.expectedVisibility > 0 {
+				require.Len(t, api.visibility, 1)
+				assert.Equal(t, tt.expectedVisibility, aws.Int64Value(api.visibility[0].VisibilityTimeout))
+			} else {
+				assert.Empty(t, api.visibility)
+			}
+			assert.Equal(t, tt.expectedDeleted, len(api.deleted) == 1)
+			if tt.expectedDeadLetter {
+				require.Len(t, api.deadLetters, 1)
+				assert.Equal(t, "url/queue-dlq.fifo", aws.StringValue(api.deadLetters[0].QueueUrl))
+				assert.Equal(t, "body", aws.StringValue(api.deadLetters[0].MessageBody))
+				assert.Equal(t, "group", aws.StringValue(api.deadLetters[0].MessageGroupId))
+			} else {
+				assert.Empty(t, api.deadLetters)
+			}
+			if tt.expectedStop != "" {
+				assert.EqualError(t, <-stop, tt.expectedStop)
+			} else {
+				assert.Empty(t, stop)
+			}
+		})
+	}
+}
+
+func Test_message_NACKWithError(t *testing.T) {
+	var decided error
+	strategy := patronfailure.StrategyFunc(func(_ uint, err error) patronfailure.Decision {
+		decided = err
+		return patronfailure.Decision{Action: patronfailure.Skip}
+	})
+	api := &failureSQSAPI{}
+	msg := createMessage(api, "1")
+	msg.failure = failureConfig{strategy: strategy}
+
+	var nacker ErrorNACKer = msg
+	nacker.NACKWithError(errors.New("processing error"))
+	assert.EqualError(t, decided, "processing error")
+	assert.Len(t, api.deleted, 1)
+
+	msg.NACK()
+	assert.Equal(t, patronfailure.ErrNACKed, decided)
+}
+
+func TestNew_Failure(t *testing.T) {
+	api := &failureSQSAPI{}
+	_, err := New("name", "queue", api, stubProcessor{t: t}.process, Failure(patronfailure.DeadLetterStrategy()))
+	assert.EqualError(t, err, "failure strategy requires a dead letter queue")
+	_, err = New("name", "queue", api, stubProcessor{t: t}.process, Failure(patronfailure.DeadLetterStrategy()), DeadLetterQueue("queue-dlq"))
+	assert.NoError(t, err)
+	_, err = New("name", "queue", api, stubProcessor{t: t}.process, Failure(patronfailure.StopStrategy()))
+	assert.NoError(t, err)
+}
+
+func TestComponent_Run_FailureStop(t *testing.T) {
+	cmp, err := New("name", "queue", &failureSQSAPI{}, stubProcessor{t: t}.process, Failure(patronfailure.StopStrategy()))
+	require.NoError(t, err)
+	cmp.stop <- errStopped
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Equal(t, errStopped, cmp.Run(ctx))
+}
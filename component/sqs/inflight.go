@@ -0,0 +1,82 @@
+package sqs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var messagesInFlight *prometheus.GaugeVec
+
+func init() {
+	messagesInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "sqs",
+			Name:      "messages_in_flight",
+			Help:      "Messages which are received and not processed yet, classified by queue",
+		},
+		[]string{"queue"},
+	)
+	prometheus.MustRegister(messagesInFlight)
+}
+
+// concurrency configures the receiving and the processing of the messages.
+type concurrency struct {
+	// the number of goroutines receiving messages
+	pollers uint
+	// the number of batches processed concurrently
+	workers uint
+	// the maximum number of messages received and not processed yet
+	maxInFlight int64
+}
+
+// inFlight limits the messages which are received and not processed yet, so that the pollers wait for the processing
+// to catch up before receiving more messages.
+type inFlight struct {
+	queue string
+	max   int64
+
+	mu       sync.Mutex
+	count    int64
+	released chan struct{}
+}
+
+func newInFlight(queue string, max int64) *inFlight {
+	return &inFlight{queue: queue, max: max, released: make(chan struct{})}
+}
+
+// acquire waits until there is room for the messages, or until the context is done.
+func (f *inFlight) acquire(ctx context.Context, messages int64) error {
+	for {
+		f.mu.Lock()
+		if f.count+messages <= f.max {
+			f.count += messages
+			messagesInFlight.WithLabelValues(f.queue).Set(float64(f.count))
+			f.mu.Unlock()
+			return nil
+		}
+		released := f.released
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// release makes room for the messages which are processed, or which were not received.
+func (f *inFlight) release(messages int64) {
+	if messages == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count -= messages
+	messagesInFlight.WithLabelValues(f.queue).Set(float64(f.count))
+	close(f.released)
+	f.released = make(chan struct{})
+}
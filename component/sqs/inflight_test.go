@@ -0,0 +1,158 @@
+package sqs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/component/shutdown"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Concurrency(t *testing.T) {
+	cmp, err := New("name", queueName, stubSQSAPI{}, stubProcessor{t: t}.process, MaxMessages(10), Concurrency(3))
+	require.NoError(t, err)
+	assert.Equal(t, concurrency{pollers: 1, workers: 3, maxInFlight: 30}, cmp.concurrency, "a batch is received when a worker is available by default")
+
+	_, err = New("name", queueName, stubSQSAPI{}, stubProcessor{t: t}.process, MaxMessages(10), MaxInFlight(5))
+	assert.EqualError(t, err, "max in-flight messages should not be less than the max messages")
+}
+
+func Test_inFlight(t *testing.T) {
+	f := newInFlight("in-flight-queue", 10)
+	require.NoError(t, f.acquire(context.Background(), 8))
+	assert.Equal(t, 8.0, testutil.ToFloat64(messagesInFlight.WithLabelValues("in-flight-queue")))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- f.acquire(context.Background(), 4)
+	}()
+	select {
+	case <-acquired:
+		assert.Fail(t, "the messages are acquired only when there is room for them")
+	case <-time.After(20 * time.Millisecond):
+	}
+	f.release(1)
+	select {
+	case <-acquired:
+		assert.Fail(t, "the messages are acquired only when there is room for them")
+	case <-time.After(20 * time.Millisecond):
+	}
+	f.release(1)
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, 10.0, testutil.ToFloat64(messagesInFlight.WithLabelValues("in-flight-queue")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, f.acquire(ctx, 1))
+}
+
+func TestComponent_Run_Concurrency(t *testing.T) {
+	api := &countingSQSAPI{}
+	unblock := make(chan struct{})
+	var mu sync.Mutex
+	processing := 0
+	proc := func(_ context.Context, b Batch) {
+		mu.Lock()
+		processing++
+		mu.Unlock()
+		<-unblock
+		_, err := b.ACK()
+		require.NoError(t, err)
+	}
+	cmp, err := New("name", "concurrency-queue", api, proc, MaxMessages(2), Pollers(2), Concurrency(2), MaxInFlight(6))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return processing == 2
+	}, time.Second, 5*time.Millisecond, "the batches are processed concurrently")
+	// two batches are processed and a third one waits for a worker, so there is no room for more messages
+	assert.Eventually(t, func() bool { return api.receives() == 3 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 3, api.receives())
+	assert.Equal(t, 6.0, testutil.ToFloat64(messagesInFlight.WithLabelValues("concurrency-queue")))
+
+	close(unblock)
+	cancel()
+	assert.NoError(t, <-chDone)
+}
+
+func TestComponent_Run_Drain(t *testing.T) {
+	api := &countingSQSAPI{}
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var procErr error
+	proc := func(ctx context.Context, b Batch) {
+		select {
+		case started <- struct{}{}:
+		default:
+			return
+		}
+		<-release
+		procErr = ctx.Err()
+		_, err := b.ACK()
+		require.NoError(t, err)
+	}
+	cmp, err := New("name", "drain-queue", api, proc, MaxMessages(2), Concurrency(1))
+	require.NoError(t, err)
+
+	drain, cancelDrain := context.WithCancel(context.Background())
+	defer cancelDrain()
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(shutdown.ContextWithDrain(ctx, drain))
+	}()
+
+	<-started
+	cancel()
+	select {
+	case <-chDone:
+		assert.Fail(t, "component returned before draining the batch in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-chDone)
+	assert.NoError(t, procErr, "the batch in flight is processed in the drain context")
+}
+
+// countingSQSAPI receives two messages with every request, and counts the requests.
+type countingSQSAPI struct {
+	stubSQSAPI
+	mu    sync.Mutex
+	count int
+}
+
+func (s *countingSQSAPI) ReceiveMessageWithContext(aws.Context, *sqs.ReceiveMessageInput, ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	return &sqs.ReceiveMessageOutput{Messages: []*sqs.Message{
+		{MessageId: aws.String("1"), Body: aws.String("body"), ReceiptHandle: aws.String("1")},
+		{MessageId: aws.String("2"), Body: aws.String("body"), ReceiptHandle: aws.String("2")},
+	}}, nil
+}
+
+func (s *countingSQSAPI) DeleteMessageBatchWithContext(aws.Context, *sqs.DeleteMessageBatchInput, ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (s *countingSQSAPI) receives() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
@@ -2,10 +2,13 @@ package sqs
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	patronfailure "github.com/beatlabs/patron/component/failure"
 	"github.com/beatlabs/patron/trace"
 	"github.com/opentracing/opentracing-go"
 )
@@ -25,24 +28,37 @@ type Message interface {
 	Span() opentracing.Span
 	// ACK deletes the message from the queue and completes the tracing span.
 	ACK() error
-	// NACK leaves the message in the queue and completes the tracing span.
+	// NACK leaves the message in the queue, or handles it according to the failure strategy of the component,
+	// and completes the tracing span.
 	NACK()
 }
 
+// ErrorNACKer is implemented by the messages of the component, which can be negatively acknowledged along with the
+// processing error, e.g. msg.(sqs.ErrorNACKer).NACKWithError(err), so that the failure strategy decides with it.
+type ErrorNACKer interface {
+	// NACKWithError negatively acknowledges the message like NACK, passing the processing error to the failure strategy.
+	NACKWithError(err error)
+}
+
 // Batch interface for multiple AWS SQS messages.
 type Batch interface {
 	// Messages of the batch.
 	Messages() []Message
 	// ACK deletes all messages from SQS with a single call and completes the all the message tracing spans.
+	// The messages which were acknowledged or not acknowledged individually are skipped, so that the processor can NACK
+	// the failed messages and ACK the rest of the batch, with only the failed ones returning to the queue.
 	// In case the action will not manage to ACK all the messages, a slice of the failed messages will be returned.
 	ACK() ([]Message, error)
-	// NACK leaves all messages in the queue and completes the all the message tracing spans.
+	// NACK leaves all messages in the queue and completes the all the message tracing spans,
+	// skipping the messages which were acknowledged or not acknowledged individually.
 	NACK()
 }
 
 type queue struct {
 	name string
 	url  string
+	// whether the queue is a FIFO queue, whose name ends with .fifo
+	fifo bool
 }
 
 type message struct {
@@ -51,6 +67,10 @@ type message struct {
 	api   sqsiface.SQSAPI
 	msg   *sqs.Message
 	span  opentracing.Span
+	// the messages of the batch which are not settled yet
+	pending *pending
+	// handling of the messages which are not acknowledged
+	failure failureConfig
 }
 
 func (m message) Context() context.Context {
@@ -74,6 +94,7 @@ func (m message) Message() *sqs.Message {
 }
 
 func (m message) ACK() error {
+	m.pending.settle(m.ID(), false)
 	_, err := m.api.DeleteMessageWithContext(m.ctx, &sqs.DeleteMessageInput{
 		QueueUrl:      aws.String(m.queue.url),
 		ReceiptHandle: m.msg.ReceiptHandle,
@@ -89,6 +110,22 @@ func (m message) ACK() error {
 }
 
 func (m message) NACK() {
+	m.NACKWithError(nil)
+}
+
+func (m message) NACKWithError(err error) {
+	if err == nil {
+		err = patronfailure.ErrNACKed
+	}
+	m.pending.settle(m.ID(), true)
+	messageCountInc(m.queue.name, nackMessageState, 1)
+	m.decide(err)
+	trace.SpanSuccess(m.span)
+}
+
+// leave leaves the message in the queue without the failure strategy, so that it is received again.
+func (m message) leave() {
+	m.pending.settle(m.ID(), true)
 	messageCountInc(m.queue.name, nackMessageState, 1)
 	trace.SpanSuccess(m.span)
 }
@@ -98,18 +135,27 @@ type batch struct {
 	queue    queue
 	sqsAPI   sqsiface.SQSAPI
 	messages []Message
+	pending  *pending
+	// the time the messages were received
+	received time.Time
 }
 
 func (b batch) ACK() ([]Message, error) {
-	entries := make([]*sqs.DeleteMessageBatchRequestEntry, 0, len(b.messages))
-	msgMap := make(map[string]Message, len(b.messages))
+	b.hold()
+	messages := b.unsettled()
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	entries := make([]*sqs.DeleteMessageBatchRequestEntry, 0, len(messages))
+	msgMap := make(map[string]Message, len(messages))
 
-	for _, msg := range b.messages {
+	for _, msg := range messages {
 		entries = append(entries, &sqs.DeleteMessageBatchRequestEntry{
 			Id:            aws.String(msg.ID()),
 			ReceiptHandle: msg.Message().ReceiptHandle,
 		})
 		msgMap[msg.ID()] = msg
+		b.pending.settle(msg.ID(), false)
 	}
 
 	output, err := b.sqsAPI.DeleteMessageBatchWithContext(b.ctx, &sqs.DeleteMessageBatchInput{
@@ -117,8 +163,8 @@ func (b batch) ACK() ([]Message, error) {
 		QueueUrl: aws.String(b.queue.url),
 	})
 	if err != nil {
-		messageCountErrorInc(b.queue.name, ackMessageState, len(b.messages))
-		for _, msg := range b.messages {
+		messageCountErrorInc(b.queue.name, ackMessageState, len(messages))
+		for _, msg := range messages {
 			trace.SpanError(msg.Span())
 		}
 		return nil, err
@@ -147,11 +193,94 @@ func (b batch) ACK() ([]Message, error) {
 }
 
 func (b batch) NACK() {
-	for _, msg := range b.messages {
+	for _, msg := range b.unsettled() {
 		msg.NACK()
 	}
 }
 
+// hold does not acknowledge the messages of a FIFO queue which follow a not acknowledged message of the same message group,
+// so that the messages of the group are received again in order.
+func (b batch) hold() {
+	if !b.queue.fifo {
+		return
+	}
+	failed := make(map[string]struct{})
+	for _, msg := range b.messages {
+		group := aws.StringValue(msg.Message().Attributes[sqsAttributeMessageGroupID])
+		if _, ok := failed[group]; ok {
+			if b.pending.has(msg.ID()) {
+				msg.(message).leave()
+			}
+			continue
+		}
+		if b.pending.nacked(msg.ID()) {
+			failed[group] = struct{}{}
+		}
+	}
+}
+
+// unsettled returns the messages of the batch which have not been acknowledged or not acknowledged individually.
+func (b batch) unsettled() []Message {
+	messages := make([]Message, 0, len(b.messages))
+	for _, msg := range b.messages {
+		if b.pending.has(msg.ID()) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// pending tracks the messages of a batch which have not been acknowledged or not acknowledged yet, so that the batch
+// acknowledges only them, and only their visibility is extended.
+type pending struct {
+	mu       sync.Mutex
+	messages map[string]*sqs.Message
+	nacks    map[string]struct{}
+}
+
+func newPending(messages []*sqs.Message) *pending {
+	p := &pending{messages: make(map[string]*sqs.Message, len(messages)), nacks: make(map[string]struct{})}
+	for _, msg := range messages {
+		p.messages[aws.StringValue(msg.MessageId)] = msg
+	}
+	return p
+}
+
+// settle marks the message as acknowledged or not acknowledged. It is safe to call on a nil pending.
+func (p *pending) settle(id string, nacked bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.messages, id)
+	if nacked {
+		p.nacks[id] = struct{}{}
+	}
+}
+
+// has returns true if the message is not settled yet, which is always the case for a nil pending.
+func (p *pending) has(id string) bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.messages[id]
+	return ok
+}
+
+// nacked returns true if the message was not acknowledged, which is never the case for a nil pending.
+func (p *pending) nacked(id string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.nacks[id]
+	return ok
+}
+
 func (b batch) Messages() []Message {
 	return b.messages
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -370,3 +371,82 @@ func (s stubSQSAPI) ReceiveMessageWithContext(aws.Context, *sqs.ReceiveMessageIn
 		},
 	}, nil
 }
+
+func Test_batch_PartialFailure(t *testing.T) {
+	defer mockTracer.Reset()
+
+	sqsAPI := &deleteBatchSQSAPI{}
+	btc := batch{
+		ctx:    context.Background(),
+		queue:  queue{name: queueName, url: queueURL},
+		sqsAPI: sqsAPI,
+	}
+	msgs := []message{createMessage(sqsAPI, "1"), createMessage(sqsAPI, "2"), createMessage(sqsAPI, "3")}
+	btc.pending = newPending([]*sqs.Message{msgs[0].msg, msgs[1].msg, msgs[2].msg})
+	for _, msg := range msgs {
+		msg.pending = btc.pending
+		btc.messages = append(btc.messages, msg)
+	}
+
+	require.NoError(t, btc.messages[0].ACK())
+	btc.messages[1].NACK()
+	failed, err := btc.ACK()
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, []string{"3"}, sqsAPI.deleted, "only the messages which were not settled individually are deleted")
+
+	failed, err = btc.ACK()
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Len(t, sqsAPI.deleted, 1, "the settled batch is not deleted again")
+	btc.NACK()
+	assert.Len(t, mockTracer.FinishedSpans(), 3, "the spans of the settled messages are not completed again")
+}
+
+type deleteBatchSQSAPI struct {
+	stubSQSAPI
+	deleted []string
+}
+
+func (s *deleteBatchSQSAPI) DeleteMessageBatchWithContext(_ aws.Context, input *sqs.DeleteMessageBatchInput,
+	_ ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		s.deleted = append(s.deleted, aws.StringValue(entry.Id))
+		output.Successful = append(output.Successful, &sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+func Test_batch_ACK_FIFO(t *testing.T) {
+	defer mockTracer.Reset()
+
+	sqsAPI := &deleteBatchSQSAPI{}
+	btc := batch{
+		ctx:    context.Background(),
+		queue:  queue{name: queueName, url: queueURL, fifo: true},
+		sqsAPI: sqsAPI,
+	}
+	groups := map[string]string{"1": "a", "2": "b", "3": "a", "4": "b", "5": "a"}
+	received := make([]*sqs.Message, 0, len(groups))
+	msgs := make([]message, 0, len(groups))
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		msg := createMessage(sqsAPI, id)
+		msg.msg.Attributes = map[string]*string{sqsAttributeMessageGroupID: aws.String(groups[id])}
+		received = append(received, msg.msg)
+		msgs = append(msgs, msg)
+	}
+	btc.pending = newPending(received)
+	for _, msg := range msgs {
+		msg.pending = btc.pending
+		btc.messages = append(btc.messages, msg)
+	}
+
+	btc.messages[2].NACK()
+	failed, err := btc.ACK()
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, []string{"1", "2", "4"}, sqsAPI.deleted, "the messages which follow a failed message of their group are not acknowledged")
+	assert.True(t, btc.pending.nacked("5"))
+	assert.Len(t, mockTracer.FinishedSpans(), 5)
+}
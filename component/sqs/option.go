@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	patronfailure "github.com/beatlabs/patron/component/failure"
 )
 
 const twelveHoursInSeconds = 43200
@@ -51,6 +53,100 @@ func VisibilityTimeout(visibilityTimeout int64) OptionFunc {
 	}
 }
 
+// VisibilityExtension extends the visibility timeout of the messages while they are processed, so that the slow messages
+// are not received again by another consumer, without setting a large static visibility timeout.
+// The messages are received with the timeout, which replaces the one set with VisibilityTimeout, and their visibility is extended
+// by the timeout at every half of it, until they are acknowledged or not acknowledged, or until they have been invisible
+// for the max duration since they were received, when they become visible again.
+// The timeout is truncated to seconds, and it should be at least 2 seconds, while the max duration should not be less than the timeout
+// and not more than 12 hours.
+func VisibilityExtension(timeout, max time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if timeout < 2*time.Second {
+			return errors.New("visibility timeout should be at least 2 seconds")
+		}
+		if max < timeout || max > twelveHoursInSeconds*time.Second {
+			return fmt.Errorf("max visibility should be between the visibility timeout and %d seconds", twelveHoursInSeconds)
+		}
+		timeout = timeout.Truncate(time.Second)
+		c.visibility = visibility{timeout: timeout, max: max, interval: timeout / 2}
+		return nil
+	}
+}
+
+// UnwrapSNS option for unwrapping the SNS notifications, which are delivered to the queue in a JSON envelope when the raw message
+// delivery of the subscription is disabled. The processor receives the original message, whose attributes, including the tracing
+// and the propagated headers, are added to the message attributes. The messages which are not SNS notifications are left as is.
+func UnwrapSNS() OptionFunc {
+	return func(c *Component) error {
+		c.unwrapSNS = true
+		return nil
+	}
+}
+
+// Pollers sets the number of goroutines receiving messages from the queue concurrently, which is 1 by default.
+func Pollers(count uint) OptionFunc {
+	return func(c *Component) error {
+		if count == 0 {
+			return errors.New("pollers should be a positive number")
+		}
+		c.concurrency.pollers = count
+		return nil
+	}
+}
+
+// Concurrency sets the number of batches which are processed concurrently, which is 1 by default.
+// The messages of each batch are still passed to a single call of the process function.
+func Concurrency(workers uint) OptionFunc {
+	return func(c *Component) error {
+		if workers == 0 {
+			return errors.New("concurrency should be a positive number")
+		}
+		c.concurrency.workers = workers
+		return nil
+	}
+}
+
+// MaxInFlight sets the maximum number of messages which are received and not processed yet, so that the pollers wait for the
+// processing to catch up before receiving more messages. It should not be less than the max messages, and by default
+// a batch is received only when a worker is available to process it.
+func MaxInFlight(count uint) OptionFunc {
+	return func(c *Component) error {
+		if count == 0 {
+			return errors.New("max in-flight messages should be a positive number")
+		}
+		c.concurrency.maxInFlight = int64(count)
+		return nil
+	}
+}
+
+// DeadLetterQueue sets the name of the dead letter queue of the queue, e.g. the target of its redrive policy,
+// whose size is reported along with the stats of the queue, and whose messages can be moved back to the queue with Redrive.
+func DeadLetterQueue(name string) OptionFunc {
+	return func(c *Component) error {
+		if name == "" {
+			return errors.New("dead letter queue name is empty")
+		}
+		c.deadLetter = queue{name: name}
+		return nil
+	}
+}
+
+// Failure sets the strategy which decides how the messages which are not acknowledged are handled, according to
+// the number of times they have been received and the processing error passed to ErrorNACKer.NACKWithError. A message to retry
+// becomes visible again after the delay of the decision, and the failure.DeadLetter action moves the message to the queue
+// of the DeadLetterQueue option, which it requires. The messages of a FIFO queue which are held after a failed message of their group are not
+// handled by the strategy.
+func Failure(s patronfailure.Strategy) OptionFunc {
+	return func(c *Component) error {
+		if s == nil {
+			return errors.New("failure strategy is nil")
+		}
+		c.failure = s
+		return nil
+	}
+}
+
 // QueueStatsInterval sets the interval at which we retrieve AWS SQS stats.
 func QueueStatsInterval(interval time.Duration) OptionFunc {
 	return func(c *Component) error {
@@ -176,3 +176,41 @@ func TestRetryWait(t *testing.T) {
 		})
 	}
 }
+
+func TestVisibilityExtension(t *testing.T) {
+	tests := map[string]struct {
+		timeout     time.Duration
+		max         time.Duration
+		expectedErr string
+	}{
+		"success":            {timeout: 30 * time.Second, max: 5 * time.Minute},
+		"truncated timeout":  {timeout: 30*time.Second + time.Millisecond, max: 5 * time.Minute},
+		"short timeout":      {timeout: time.Second, max: 5 * time.Minute, expectedErr: "visibility timeout should be at least 2 seconds"},
+		"max below timeout":  {timeout: 30 * time.Second, max: 10 * time.Second, expectedErr: "max visibility should be between the visibility timeout and 43200 seconds"},
+		"max above 12 hours": {timeout: 30 * time.Second, max: 13 * time.Hour, expectedErr: "max visibility should be between the visibility timeout and 43200 seconds"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			c := &Component{}
+			err := VisibilityExtension(tt.timeout, tt.max)(c)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, visibility{timeout: 30 * time.Second, max: 5 * time.Minute, interval: 15 * time.Second}, c.visibility)
+		})
+	}
+}
+
+func TestConcurrencyOptions(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Pollers(0)(c), "pollers should be a positive number")
+	assert.EqualError(t, Concurrency(0)(c), "concurrency should be a positive number")
+	assert.EqualError(t, MaxInFlight(0)(c), "max in-flight messages should be a positive number")
+	assert.NoError(t, Pollers(2)(c))
+	assert.NoError(t, Concurrency(4)(c))
+	assert.NoError(t, MaxInFlight(40)(c))
+	assert.Equal(t, concurrency{pollers: 2, workers: 4, maxInFlight: 40}, c.concurrency)
+}
@@ -0,0 +1,63 @@
+package sqs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/log"
+)
+
+const (
+	snsNotificationType        = "Notification"
+	snsAttributeDataTypeBinary = "Binary"
+)
+
+// snsEnvelope is the JSON envelope of the SNS notifications delivered to SQS when the raw message delivery is disabled.
+type snsEnvelope struct {
+	Type              string
+	MessageID         string `json:"MessageId"`
+	TopicArn          string
+	Message           string
+	MessageAttributes map[string]snsAttribute
+}
+
+type snsAttribute struct {
+	Type  string
+	Value string
+}
+
+// unwrapSNS replaces the body of an SNS notification with the original message, and adds the attributes of the original message
+// to the message attributes, without replacing the ones which are already set. A message which is not an SNS notification is left as is.
+func unwrapSNS(msg *sqs.Message) {
+	var env snsEnvelope
+	if err := json.Unmarshal([]byte(aws.StringValue(msg.Body)), &env); err != nil || env.Type != snsNotificationType || env.TopicArn == "" {
+		return
+	}
+
+	msg.Body = aws.String(env.Message)
+	if len(env.MessageAttributes) == 0 {
+		return
+	}
+	if msg.MessageAttributes == nil {
+		msg.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(env.MessageAttributes))
+	}
+	for key, attr := range env.MessageAttributes {
+		if _, ok := msg.MessageAttributes[key]; ok {
+			continue
+		}
+		value := &sqs.MessageAttributeValue{DataType: aws.String(attr.Type)}
+		if attr.Type == snsAttributeDataTypeBinary {
+			data, err := base64.StdEncoding.DecodeString(attr.Value)
+			if err != nil {
+				log.Warnf("failed to decode binary attribute %s of SNS message %s: %v", key, env.MessageID, err)
+				continue
+			}
+			value.BinaryValue = data
+		} else {
+			value.StringValue = aws.String(attr.Value)
+		}
+		msg.MessageAttributes[key] = value
+	}
+}
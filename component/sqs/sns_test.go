@@ -0,0 +1,87 @@
+package sqs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/correlation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snsNotification = `{
+  "Type": "Notification",
+  "MessageId": "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+  "TopicArn": "arn:aws:sns:eu-west-1:123456789012:orders",
+  "Message": "{\"key\":\"value\"}",
+  "Timestamp": "2021-11-12T12:00:00.000Z",
+  "MessageAttributes": {
+    "X-Correlation-Id": {"Type": "String", "Value": "corID"},
+    "X-Tenant": {"Type": "String", "Value": "acme"},
+    "priority": {"Type": "Number", "Value": "1"},
+    "signature": {"Type": "Binary", "Value": "c2lnbmF0dXJl"},
+    "invalid": {"Type": "Binary", "Value": "!"},
+    "existing": {"Type": "String", "Value": "envelope"}
+  }
+}`
+
+func Test_unwrapSNS(t *testing.T) {
+	tests := map[string]struct {
+		body               string
+		expectedBody       string
+		expectedAttributes map[string]*sqs.MessageAttributeValue
+	}{
+		"notification": {
+			body:         snsNotification,
+			expectedBody: `{"key":"value"}`,
+			expectedAttributes: map[string]*sqs.MessageAttributeValue{
+				"X-Correlation-Id": {DataType: aws.String("String"), StringValue: aws.String("corID")},
+				"X-Tenant":         {DataType: aws.String("String"), StringValue: aws.String("acme")},
+				"priority":         {DataType: aws.String("Number"), StringValue: aws.String("1")},
+				"signature":        {DataType: aws.String("Binary"), BinaryValue: []byte("signature")},
+				"existing":         {DataType: aws.String("String"), StringValue: aws.String("message")},
+			},
+		},
+		"not JSON": {
+			body:               "body",
+			expectedBody:       "body",
+			expectedAttributes: map[string]*sqs.MessageAttributeValue{"existing": {DataType: aws.String("String"), StringValue: aws.String("message")}},
+		},
+		"not a notification": {
+			body:               `{"Type":"Order","Message":"message"}`,
+			expectedBody:       `{"Type":"Order","Message":"message"}`,
+			expectedAttributes: map[string]*sqs.MessageAttributeValue{"existing": {DataType: aws.String("String"), StringValue: aws.String("message")}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			msg := &sqs.Message{
+				Body:              aws.String(tt.body),
+				MessageAttributes: map[string]*sqs.MessageAttributeValue{"existing": {DataType: aws.String("String"), StringValue: aws.String("message")}},
+			}
+			unwrapSNS(msg)
+			assert.Equal(t, tt.expectedBody, aws.StringValue(msg.Body))
+			assert.Equal(t, tt.expectedAttributes, msg.MessageAttributes)
+		})
+	}
+}
+
+func TestComponent_createBatch_UnwrapSNS(t *testing.T) {
+	correlation.PropagateHeaders("X-Tenant")
+	defer correlation.PropagateHeaders()
+
+	cmp, err := New("name", queueName, stubSQSAPI{}, stubProcessor{t: t}.process, UnwrapSNS())
+	require.NoError(t, err)
+
+	btc := cmp.createBatch(context.Background(), &sqs.ReceiveMessageOutput{
+		Messages: []*sqs.Message{{MessageId: aws.String("1"), Body: aws.String(snsNotification)}},
+	})
+	require.Len(t, btc.messages, 1)
+	msg := btc.messages[0]
+	assert.Equal(t, []byte(`{"key":"value"}`), msg.Body())
+	assert.Equal(t, "corID", correlation.IDFromContext(msg.Context()))
+	assert.Equal(t, map[string]string{"X-Tenant": "acme"}, correlation.HeadersFromContext(msg.Context()))
+}
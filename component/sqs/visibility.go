@@ -0,0 +1,104 @@
+package sqs
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/beatlabs/patron/log"
+)
+
+// visibility configures the extension of the visibility timeout of the messages while they are processed.
+type visibility struct {
+	// the visibility timeout the messages are received with, and which is set again on every extension
+	timeout time.Duration
+	// the maximum time the messages are kept invisible since they were received
+	max time.Duration
+	// the interval between the extensions, which is half the timeout
+	interval time.Duration
+}
+
+func (v visibility) enabled() bool {
+	return v.timeout > 0
+}
+
+// entries returns the entries which set the visibility timeout of the pending messages.
+func (p *pending) entries(timeout int64) []*sqs.ChangeMessageVisibilityBatchRequestEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entries := make([]*sqs.ChangeMessageVisibilityBatchRequestEntry, 0, len(p.messages))
+	for id, msg := range p.messages {
+		entries = append(entries, &sqs.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                aws.String(id),
+			ReceiptHandle:     msg.ReceiptHandle,
+			VisibilityTimeout: aws.Int64(timeout),
+		})
+	}
+	return entries
+}
+
+// extendVisibility extends the visibility timeout of the pending messages of the batch at every interval,
+// until the returned function is called once the batch is processed, or until the messages have been invisible
+// for the maximum time since they were received, when they become visible again.
+func (c *Component) extendVisibility(ctx context.Context, received time.Time, p *pending) func() {
+	if !c.visibility.enabled() {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(c.visibility.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				timeout := c.visibility.max - time.Since(received)
+				if timeout > c.visibility.timeout {
+					timeout = c.visibility.timeout
+				}
+				if timeout < time.Second {
+					log.FromContext(ctx).Warnf("the messages of queue %s reached the maximum visibility of %v", c.queue.name, c.visibility.max)
+					return
+				}
+				c.changeVisibility(ctx, p.entries(int64(timeout/time.Second)))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (c *Component) changeVisibility(ctx context.Context, entries []*sqs.ChangeMessageVisibilityBatchRequestEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	output, err := c.api.ChangeMessageVisibilityBatchWithContext(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+		Entries:  entries,
+		QueueUrl: aws.String(c.queue.url),
+	})
+	if err != nil {
+		messageCountErrorInc(c.queue.name, extendedMessageState, len(entries))
+		log.FromContext(ctx).Errorf("failed to extend the visibility of %d messages of queue %s: %v", len(entries), c.queue.name, err)
+		return
+	}
+	if len(output.Successful) > 0 {
+		messageCountInc(c.queue.name, extendedMessageState, len(output.Successful))
+	}
+	if len(output.Failed) > 0 {
+		messageCountErrorInc(c.queue.name, extendedMessageState, len(output.Failed))
+		for _, fail := range output.Failed {
+			log.FromContext(ctx).Errorf("failed to extend the visibility of message %s of queue %s: %s",
+				aws.StringValue(fail.Id), c.queue.name, aws.StringValue(fail.Message))
+		}
+	}
+}
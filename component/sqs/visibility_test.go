@@ -0,0 +1,135 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_VisibilityExtension(t *testing.T) {
+	cmp, err := New("name", queueName, stubSQSAPI{}, stubProcessor{t: t}.process,
+		VisibilityTimeout(10), VisibilityExtension(30*time.Second, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, aws.Int64(30), cmp.cfg.visibilityTimeout, "the messages are received with the visibility timeout of the extension")
+}
+
+func TestComponent_extendVisibility(t *testing.T) {
+	api := &visibilitySQSAPI{}
+	cmp, err := New("name", queueName, api, stubProcessor{t: t}.process, VisibilityExtension(2*time.Second, time.Minute))
+	require.NoError(t, err)
+	cmp.visibility.interval = 10 * time.Millisecond
+
+	p := newPending([]*sqs.Message{
+		{MessageId: aws.String("1"), ReceiptHandle: aws.String("handle-1")},
+		{MessageId: aws.String("2"), ReceiptHandle: aws.String("handle-2")},
+	})
+	stop := cmp.extendVisibility(context.Background(), time.Now(), p)
+	assert.Eventually(t, func() bool { return len(api.extended()) > 0 }, time.Second, 5*time.Millisecond)
+
+	msg := createMessage(nil, "1")
+	msg.pending = p
+	msg.NACK()
+	calls := len(api.extended())
+	assert.Eventually(t, func() bool { return len(api.extended()) > calls }, time.Second, 5*time.Millisecond)
+	stop()
+
+	extended := api.extended()
+	assert.Equal(t, []string{"handle-2"}, extended[len(extended)-1], "the settled messages are not extended")
+	calls = len(extended)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, api.extended(), calls, "the visibility is not extended once the batch is processed")
+	for _, input := range api.inputs {
+		assert.Equal(t, aws.Int64(2), input.Entries[0].VisibilityTimeout)
+	}
+}
+
+func TestComponent_extendVisibility_MaxReached(t *testing.T) {
+	api := &visibilitySQSAPI{}
+	cmp, err := New("name", queueName, api, stubProcessor{t: t}.process, VisibilityExtension(2*time.Second, 3*time.Second))
+	require.NoError(t, err)
+	cmp.visibility.interval = 10 * time.Millisecond
+
+	p := newPending([]*sqs.Message{{MessageId: aws.String("1"), ReceiptHandle: aws.String("handle-1")}})
+	// the messages were received long enough ago that less than the visibility timeout is left
+	stop := cmp.extendVisibility(context.Background(), time.Now().Add(-1500*time.Millisecond), p)
+	assert.Eventually(t, func() bool { return len(api.extended()) > 0 }, time.Second, 5*time.Millisecond)
+	time.Sleep(time.Second)
+	calls := len(api.extended())
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, api.extended(), calls, "the visibility is not extended beyond the max duration")
+	stop()
+	assert.Equal(t, aws.Int64(1), api.inputs[0].Entries[0].VisibilityTimeout)
+}
+
+func TestComponent_changeVisibility_Error(t *testing.T) {
+	api := &visibilitySQSAPI{err: errors.New("AWS FAILURE")}
+	cmp, err := New("name", queueName, api, stubProcessor{t: t}.process, VisibilityExtension(2*time.Second, time.Minute))
+	require.NoError(t, err)
+
+	p := newPending([]*sqs.Message{{MessageId: aws.String("1"), ReceiptHandle: aws.String("handle-1")}})
+	cmp.changeVisibility(context.Background(), p.entries(2))
+	assert.Len(t, api.extended(), 1)
+	cmp.changeVisibility(context.Background(), nil)
+	assert.Len(t, api.extended(), 1, "no request is made without pending messages")
+}
+
+func TestBatch_ACK_Settles(t *testing.T) {
+	p := newPending([]*sqs.Message{{MessageId: aws.String("1")}, {MessageId: aws.String("2")}})
+	msg1 := createMessage(nil, "1")
+	msg2 := createMessage(nil, "2")
+	btc := batch{
+		ctx:      context.Background(),
+		queue:    queue{name: queueName, url: queueURL},
+		sqsAPI:   &stubSQSAPI{succeededMessage: msg1, failedMessage: msg2},
+		messages: []Message{msg1, msg2},
+		pending:  p,
+	}
+	_, err := btc.ACK()
+	require.NoError(t, err)
+	assert.Empty(t, p.entries(1))
+}
+
+type visibilitySQSAPI struct {
+	stubSQSAPI
+	err    error
+	mu     sync.Mutex
+	inputs []*sqs.ChangeMessageVisibilityBatchInput
+}
+
+func (s *visibilitySQSAPI) ChangeMessageVisibilityBatchWithContext(_ aws.Context, input *sqs.ChangeMessageVisibilityBatchInput,
+	_ ...request.Option) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputs = append(s.inputs, input)
+	if s.err != nil {
+		return nil, s.err
+	}
+	output := &sqs.ChangeMessageVisibilityBatchOutput{}
+	for _, entry := range input.Entries {
+		output.Successful = append(output.Successful, &sqs.ChangeMessageVisibilityBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+// extended returns the receipt handles of the messages of every request.
+func (s *visibilitySQSAPI) extended() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	extended := make([][]string, 0, len(s.inputs))
+	for _, input := range s.inputs {
+		handles := make([]string, 0, len(input.Entries))
+		for _, entry := range input.Entries {
+			handles = append(handles, aws.StringValue(entry.ReceiptHandle))
+		}
+		extended = append(extended, handles)
+	}
+	return extended
+}
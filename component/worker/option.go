@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/beatlabs/patron/component/failure"
+)
+
+// OptionFunc definition for configuring the component in a functional way.
+type OptionFunc func(*Component) error
+
+// Workers option for setting the number of workers which pull and process the items concurrently.
+func Workers(count uint) OptionFunc {
+	return func(c *Component) error {
+		if count == 0 {
+			return errors.New("workers should be a positive number")
+		}
+		c.workers = count
+		return nil
+	}
+}
+
+// MaxRestarts option for limiting the number of times a worker is restarted in a row, without processing any item
+// in between, after which the component fails. A worker is never restarted when the max restarts is zero.
+func MaxRestarts(restarts uint) OptionFunc {
+	return func(c *Component) error {
+		c.restart.limited = true
+		c.restart.max = restarts
+		return nil
+	}
+}
+
+// RestartBackoff option for setting the delay before a worker is restarted, given the number of its restarts in a row,
+// e.g. failure.ConstantBackoff(time.Second).
+func RestartBackoff(backoff failure.Backoff) OptionFunc {
+	return func(c *Component) error {
+		if backoff == nil {
+			return errors.New("restart backoff is nil")
+		}
+		c.restart.backoff = backoff
+		return nil
+	}
+}
+
+// ShutdownTimeout option for setting how long the items in progress are waited for once the component is stopped.
+func ShutdownTimeout(timeout time.Duration) OptionFunc {
+	return func(c *Component) error {
+		if timeout <= 0 {
+			return errors.New("shutdown timeout should be a positive number")
+		}
+		c.shutdownTimeout = timeout
+		return nil
+	}
+}
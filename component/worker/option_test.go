@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkers(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, Workers(0)(c), "workers should be a positive number")
+	require.NoError(t, Workers(4)(c))
+	assert.Equal(t, uint(4), c.workers)
+}
+
+func TestRestartOptions(t *testing.T) {
+	c := &Component{}
+	require.NoError(t, MaxRestarts(0)(c))
+	assert.True(t, c.restart.limited)
+	assert.Equal(t, uint(0), c.restart.max)
+
+	assert.EqualError(t, RestartBackoff(nil)(c), "restart backoff is nil")
+	require.NoError(t, RestartBackoff(failure.ConstantBackoff(time.Second))(c))
+	assert.Equal(t, time.Second, c.restart.backoff(3))
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	c := &Component{}
+	assert.EqualError(t, ShutdownTimeout(0)(c), "shutdown timeout should be a positive number")
+	require.NoError(t, ShutdownTimeout(time.Second)(c))
+	assert.Equal(t, time.Second, c.shutdownTimeout)
+}
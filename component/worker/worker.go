@@ -0,0 +1,283 @@
+// Package worker provides a component which processes items continuously with a pool of supervised workers,
+// which pull the items from a source function, e.g. a database table or an in-memory queue, instead of a broker.
+//
+// A worker which fails, because the source returned an error or the source or the processor panicked, is restarted
+// after a backoff, and the component fails once a worker has failed more than the max restarts in a row.
+// Once the context of the component is done, the workers stop pulling items, and the items in progress are waited for
+// up to the shutdown timeout, after which their context is cancelled.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/patron/component/failure"
+	"github.com/beatlabs/patron/correlation"
+	patronerrors "github.com/beatlabs/patron/errors"
+	"github.com/beatlabs/patron/log"
+	"github.com/beatlabs/patron/trace"
+	"github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	workerComponent = "worker"
+
+	defaultWorkers         = 1
+	defaultShutdownTimeout = 30 * time.Second
+
+	processedItem = "processed"
+	failedItem    = "failed"
+)
+
+var defaultRestartBackoff = failure.ExponentialBackoff(time.Second, time.Minute)
+
+// errPanic marks the errors of the processor panicking, which fail the worker.
+var errPanic = errors.New("processor panicked")
+
+var (
+	itemCount      *prometheus.CounterVec
+	itemDuration   *prometheus.HistogramVec
+	workerRestarts *prometheus.CounterVec
+	activeWorkers  *prometheus.GaugeVec
+)
+
+func init() {
+	itemCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "worker",
+			Name:      "items",
+			Help:      "Items handled by the workers (processed, failed), classified by component and status",
+		},
+		[]string{"component", "status"},
+	)
+	prometheus.MustRegister(itemCount)
+	itemDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "component",
+			Subsystem: "worker",
+			Name:      "item_duration_seconds",
+			Help:      "Duration of the processing of the items, classified by component",
+		},
+		[]string{"component"},
+	)
+	prometheus.MustRegister(itemDuration)
+	workerRestarts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "component",
+			Subsystem: "worker",
+			Name:      "restarts",
+			Help:      "Restarts of the workers which failed, classified by component",
+		},
+		[]string{"component"},
+	)
+	prometheus.MustRegister(workerRestarts)
+	activeWorkers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "component",
+			Subsystem: "worker",
+			Name:      "active_workers",
+			Help:      "Workers which are running, classified by component",
+		},
+		[]string{"component"},
+	)
+	prometheus.MustRegister(activeWorkers)
+}
+
+// SourceFunc definition of the function which returns the next item to process, blocking until one is available
+// or the context is done. An error fails the worker, which is restarted according to the restart policy.
+type SourceFunc func(ctx context.Context) (interface{}, error)
+
+// ProcessorFunc definition of the function which processes an item, whose error fails the item but not the worker.
+type ProcessorFunc func(ctx context.Context, item interface{}) error
+
+type restartPolicy struct {
+	// whether the restarts are limited to the max restarts
+	limited bool
+	max     uint
+	backoff failure.Backoff
+}
+
+// Component implementation of a worker component, which processes the items of the source with a pool of workers.
+type Component struct {
+	name            string
+	source          SourceFunc
+	proc            ProcessorFunc
+	workers         uint
+	restart         restartPolicy
+	shutdownTimeout time.Duration
+}
+
+// New creates a new component with support for functional configuration.
+// By default, the component has a single worker, which is restarted without a limit with an exponential backoff
+// from 1 second up to 1 minute, and the shutdown timeout is 30 seconds.
+func New(name string, source SourceFunc, proc ProcessorFunc, oo ...OptionFunc) (*Component, error) {
+	if name == "" {
+		return nil, errors.New("component name is empty")
+	}
+
+	if source == nil {
+		return nil, errors.New("source function is nil")
+	}
+
+	if proc == nil {
+		return nil, errors.New("process function is nil")
+	}
+
+	cmp := &Component{
+		name:            name,
+		source:          source,
+		proc:            proc,
+		workers:         defaultWorkers,
+		restart:         restartPolicy{backoff: defaultRestartBackoff},
+		shutdownTimeout: defaultShutdownTimeout,
+	}
+
+	for _, optionFunc := range oo {
+		err := optionFunc(cmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// Run starts the workers, which process the items of the source until the context is done or a worker has exhausted
+// its restarts, and then waits for the items in progress up to the shutdown timeout.
+func (c *Component) Run(ctx context.Context) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	// the items are not cancelled along with the component, so that they can finish gracefully
+	procCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chErr := make(chan error, c.workers)
+	var wg sync.WaitGroup
+	for i := uint(0); i < c.workers; i++ {
+		wg.Add(1)
+		go func(id uint) {
+			defer wg.Done()
+			if err := c.supervise(ctx, procCtx, id); err != nil {
+				chErr <- err
+			}
+		}(i)
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.FromContext(ctx).Info("context cancellation received. exiting...")
+	case err = <-chErr:
+		stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(c.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return err
+	case <-timer.C:
+		return patronerrors.Aggregate(err,
+			fmt.Errorf("workers of component %s did not finish within the shutdown timeout of %v", c.name, c.shutdownTimeout))
+	}
+}
+
+// supervise runs the worker and restarts it once it fails, after the backoff of the number of its restarts in a row,
+// returning an error once it has failed more than the max restarts.
+func (c *Component) supervise(ctx, procCtx context.Context, id uint) error {
+	var restarts uint
+	for {
+		processed, err := c.work(ctx, procCtx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if processed {
+			restarts = 0
+		}
+		if c.restart.limited && restarts >= c.restart.max {
+			return fmt.Errorf("worker %d of component %s failed after %d restarts: %w", id, c.name, restarts, err)
+		}
+
+		restarts++
+		delay := c.restart.backoff(restarts)
+		log.Errorf("worker %d of component %s failed, restarting in %v: %v", id, c.name, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		workerRestarts.WithLabelValues(c.name).Inc()
+	}
+}
+
+// work pulls the items of the source and processes them until the context is done or the worker fails,
+// returning whether any item was processed along with the failure.
+func (c *Component) work(ctx, procCtx context.Context) (processed bool, err error) {
+	activeWorkers.WithLabelValues(c.name).Inc()
+	defer activeWorkers.WithLabelValues(c.name).Dec()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("source panicked: %v", r)
+		}
+	}()
+
+	for {
+		item, srcErr := c.source(ctx)
+		if srcErr != nil {
+			return processed, fmt.Errorf("failed to get item from source: %w", srcErr)
+		}
+		if err := c.process(procCtx, item); err != nil {
+			return processed, err
+		}
+		processed = true
+	}
+}
+
+// process processes the item with its own span, correlation ID and logger, returning an error only if the processor panicked.
+func (c *Component) process(ctx context.Context, item interface{}) error {
+	corID := uuid.New().String()
+	sp, ctx := trace.ChildSpan(ctx, trace.ComponentOpName(workerComponent, c.name), workerComponent,
+		opentracing.Tag{Key: correlation.ID, Value: corID})
+	ctx = correlation.ContextWithID(ctx, corID)
+	ctx = log.WithContext(ctx, log.Sub(map[string]interface{}{correlation.ID: corID}))
+
+	start := time.Now()
+	err := c.execute(ctx, item)
+	itemDuration.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	trace.SpanComplete(sp, err)
+
+	if err != nil {
+		itemCount.WithLabelValues(c.name, failedItem).Inc()
+		log.FromContext(ctx).Errorf("failed to process item of component %s: %v", c.name, err)
+		if errors.Is(err, errPanic) {
+			return err
+		}
+		return nil
+	}
+	itemCount.WithLabelValues(c.name, processedItem).Inc()
+	return nil
+}
+
+// execute calls the processor, returning an error wrapping errPanic if it panicked.
+func (c *Component) execute(ctx context.Context, item interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", errPanic, r)
+		}
+	}()
+	return c.proc(ctx, item)
+}
@@ -0,0 +1,226 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/patron/component/failure"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mockTracer = mocktracer.New()
+
+func TestMain(m *testing.M) {
+	opentracing.SetGlobalTracer(mockTracer)
+	code := m.Run()
+	os.Exit(code)
+}
+
+// channelSource returns a source of the items of the channel.
+func channelSource(ch <-chan interface{}) SourceFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		select {
+		case item := <-ch:
+			return item, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	source := channelSource(nil)
+	proc := func(context.Context, interface{}) error { return nil }
+	tests := map[string]struct {
+		name        string
+		source      SourceFunc
+		proc        ProcessorFunc
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":          {name: "name", source: source, proc: proc, oo: []OptionFunc{Workers(2)}},
+		"missing name":     {source: source, proc: proc, expectedErr: "component name is empty"},
+		"missing source":   {name: "name", proc: proc, expectedErr: "source function is nil"},
+		"missing function": {name: "name", source: source, expectedErr: "process function is nil"},
+		"option failure":   {name: "name", source: source, proc: proc, oo: []OptionFunc{Workers(0)}, expectedErr: "workers should be a positive number"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.name, tt.source, tt.proc, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint(2), got.workers)
+			assert.False(t, got.restart.limited)
+			assert.Equal(t, defaultShutdownTimeout, got.shutdownTimeout)
+		})
+	}
+}
+
+func TestComponent_Run(t *testing.T) {
+	mockTracer.Reset()
+	defer mockTracer.Reset()
+	items := make(chan interface{})
+	processed := make(chan interface{})
+	proc := func(_ context.Context, item interface{}) error {
+		defer func() { processed <- item }()
+		if item == "invalid" {
+			return errors.New("invalid item")
+		}
+		return nil
+	}
+	cmp, err := New("run", channelSource(items), proc, Workers(2))
+	require.NoError(t, err)
+	processedBefore := testutil.ToFloat64(itemCount.WithLabelValues("run", processedItem))
+	failedBefore := testutil.ToFloat64(itemCount.WithLabelValues("run", failedItem))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chDone := make(chan error)
+	go func() {
+		chDone <- cmp.Run(ctx)
+	}()
+
+	for _, item := range []string{"first", "invalid", "second"} {
+		items <- item
+		assert.Equal(t, item, <-processed, "a failed item does not fail the worker")
+	}
+	assert.Eventually(t, func() bool { return testutil.ToFloat64(activeWorkers.WithLabelValues("run")) == 2 }, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-chDone)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(itemCount.WithLabelValues("run", processedItem))-processedBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(itemCount.WithLabelValues("run", failedItem))-failedBefore)
+	assert.Equal(t, 0.0, testutil.ToFloat64(activeWorkers.WithLabelValues("run")))
+	errored := map[interface{}]int{}
+	for _, sp := range mockTracer.FinishedSpans() {
+		if sp.OperationName == "worker run" {
+			errored[sp.Tag("error")]++
+		}
+	}
+	assert.Equal(t, map[interface{}]int{false: 2, true: 1}, errored, "every item has its own span")
+}
+
+func TestComponent_Run_Restart(t *testing.T) {
+	tests := map[string]struct {
+		source      SourceFunc
+		proc        ProcessorFunc
+		expectedErr string
+	}{
+		"source error": {
+			source:      func(context.Context) (interface{}, error) { return nil, errors.New("source error") },
+			proc:        func(context.Context, interface{}) error { return nil },
+			expectedErr: "worker 0 of component %s failed after 2 restarts: failed to get item from source: source error",
+		},
+		"source panic": {
+			source:      func(context.Context) (interface{}, error) { panic("source panic") },
+			proc:        func(context.Context, interface{}) error { return nil },
+			expectedErr: "worker 0 of component %s failed after 2 restarts: source panicked: source panic",
+		},
+		"processor panic": {
+			source:      func(context.Context) (interface{}, error) { return "item", nil },
+			proc:        func(context.Context, interface{}) error { panic("processor panic") },
+			expectedErr: "worker 0 of component %s failed after 2 restarts: processor panicked: processor panic",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var backoffs []uint
+			backoff := func(restarts uint) time.Duration {
+				backoffs = append(backoffs, restarts)
+				return time.Millisecond
+			}
+			cmp, err := New(name, tt.source, tt.proc, MaxRestarts(2), RestartBackoff(backoff))
+			require.NoError(t, err)
+			restartsBefore := testutil.ToFloat64(workerRestarts.WithLabelValues(name))
+
+			err = cmp.Run(context.Background())
+			assert.EqualError(t, err, fmt.Sprintf(tt.expectedErr, name))
+			assert.Equal(t, []uint{1, 2}, backoffs)
+			assert.Equal(t, 2.0, testutil.ToFloat64(workerRestarts.WithLabelValues(name))-restartsBefore)
+		})
+	}
+}
+
+func TestComponent_Run_RestartsReset(t *testing.T) {
+	var pulls int32
+	source := func(context.Context) (interface{}, error) {
+		// every other pull fails, so the worker processes an item between its failures
+		if atomic.AddInt32(&pulls, 1)%2 == 0 {
+			return nil, errors.New("source error")
+		}
+		return "item", nil
+	}
+	cmp, err := New("restarts-reset", source, func(context.Context, interface{}) error { return nil },
+		MaxRestarts(1), RestartBackoff(failure.ConstantBackoff(time.Millisecond)))
+	require.NoError(t, err)
+
+	restartsBefore := testutil.ToFloat64(workerRestarts.WithLabelValues("restarts-reset"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, cmp.Run(ctx), "the restarts in a row are reset once the worker processes an item")
+	assert.Greater(t, testutil.ToFloat64(workerRestarts.WithLabelValues("restarts-reset"))-restartsBefore, 1.0)
+}
+
+func TestComponent_Run_Shutdown(t *testing.T) {
+	tests := map[string]struct {
+		duration    time.Duration
+		expectedErr string
+	}{
+		"items finish gracefully": {duration: 50 * time.Millisecond},
+		"shutdown timeout":        {duration: time.Hour, expectedErr: "workers of component shutdown did not finish within the shutdown timeout of 100ms\n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			items := make(chan interface{}, 1)
+			items <- "item"
+			started := make(chan struct{})
+			var finished, cancelled int32
+			proc := func(ctx context.Context, _ interface{}) error {
+				close(started)
+				select {
+				case <-time.After(tt.duration):
+					atomic.StoreInt32(&finished, 1)
+				case <-ctx.Done():
+					atomic.StoreInt32(&cancelled, 1)
+				}
+				return nil
+			}
+			cmp, err := New("shutdown", channelSource(items), proc, ShutdownTimeout(100*time.Millisecond))
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			chDone := make(chan error)
+			go func() {
+				chDone <- cmp.Run(ctx)
+			}()
+			<-started
+			cancel()
+
+			err = <-chDone
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Eventually(t, func() bool { return atomic.LoadInt32(&cancelled) == 1 }, time.Second, time.Millisecond,
+					"the items are cancelled after the shutdown timeout")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&finished), "the items in progress are not cancelled along with the component")
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package correlation
+
+import (
+	"context"
+	"sync"
+)
+
+type headersContextKey struct{}
+
+var (
+	propagatedMu sync.RWMutex
+	propagated   []string
+)
+
+// PropagateHeaders declares the inbound headers, e.g. a tenant ID, a locale or feature flags, which the HTTP and gRPC components
+// lift into the context, and which the HTTP and gRPC clients and the messaging producers inject into the outbound requests and messages,
+// along with the correlation ID. It replaces the headers declared before.
+func PropagateHeaders(headers ...string) {
+	propagatedMu.Lock()
+	defer propagatedMu.Unlock()
+	propagated = append([]string(nil), headers...)
+}
+
+// PropagatedHeaders returns the headers declared for propagation.
+func PropagatedHeaders() []string {
+	propagatedMu.RLock()
+	defer propagatedMu.RUnlock()
+	return append([]string(nil), propagated...)
+}
+
+// ContextWithHeaders lifts the values of the headers declared for propagation into the context, with get returning the value of a header,
+// or an empty string if it is missing. The context is returned as is if none of the headers is found.
+func ContextWithHeaders(ctx context.Context, get func(header string) string) context.Context {
+	propagatedMu.RLock()
+	defer propagatedMu.RUnlock()
+	if len(propagated) == 0 {
+		return ctx
+	}
+
+	var hh map[string]string
+	for _, header := range propagated {
+		v := get(header)
+		if v == "" {
+			continue
+		}
+		if hh == nil {
+			hh = make(map[string]string, len(propagated))
+		}
+		hh[header] = v
+	}
+	if hh == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, headersContextKey{}, hh)
+}
+
+// HeadersFromContext returns the headers lifted into the context for propagation, which must not be modified.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	hh, _ := ctx.Value(headersContextKey{}).(map[string]string)
+	return hh
+}
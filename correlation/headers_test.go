@@ -0,0 +1,35 @@
+package correlation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithHeaders(t *testing.T) {
+	defer PropagateHeaders()
+
+	inbound := map[string]string{"X-Tenant-Id": "tenant", "Accept-Language": "el", "X-Other": "other"}
+	get := func(header string) string {
+		return inbound[header]
+	}
+
+	tests := map[string]struct {
+		headers  []string
+		expected map[string]string
+	}{
+		"none declared":   {},
+		"none found":      {headers: []string{"X-Feature-Flags"}},
+		"declared, found": {headers: []string{"X-Tenant-Id", "Accept-Language", "X-Feature-Flags"}, expected: map[string]string{"X-Tenant-Id": "tenant", "Accept-Language": "el"}},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			PropagateHeaders(tt.headers...)
+			assert.Equal(t, tt.headers, PropagatedHeaders())
+			ctx := ContextWithHeaders(context.Background(), get)
+			assert.Equal(t, tt.expected, HeadersFromContext(ctx))
+		})
+	}
+}
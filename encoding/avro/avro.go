@@ -0,0 +1,228 @@
+// Package avro is a concrete implementation of the encoding abstractions for Avro, which uses the wire format of the
+// Confluent Schema Registry, where the Avro binary data is prefixed with a magic byte and the ID of its schema in the registry.
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	patronhttp "github.com/beatlabs/patron/client/http"
+	"github.com/beatlabs/patron/encoding"
+	"github.com/hamba/avro"
+)
+
+const (
+	// Type definition.
+	Type string = "application/avro"
+
+	registryContentType = "application/vnd.schemaregistry.v1+json"
+	magicByte           = 0
+	headerSize          = 5
+)
+
+// Registry is a client of the Confluent Schema Registry, which encodes and decodes Avro messages in its wire format.
+// The schemas are cached by their ID and subject, so that the registry is only requested once for each of them.
+type Registry struct {
+	url      string
+	cl       patronhttp.Client
+	user     string
+	password string
+
+	mu      sync.RWMutex
+	schemas map[int]avro.Schema
+	ids     map[string]int
+}
+
+// New creates a client of the schema registry at the URL, e.g. http://localhost:8081.
+func New(registryURL string, oo ...OptionFunc) (*Registry, error) {
+	if registryURL == "" {
+		return nil, errors.New("schema registry URL is required")
+	}
+	if _, err := url.Parse(registryURL); err != nil {
+		return nil, fmt.Errorf("invalid schema registry URL: %w", err)
+	}
+
+	r := &Registry{
+		url:     strings.TrimSuffix(registryURL, "/"),
+		schemas: make(map[int]avro.Schema),
+		ids:     make(map[string]int),
+	}
+
+	for _, o := range oo {
+		if err := o(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.cl == nil {
+		cl, err := patronhttp.New()
+		if err != nil {
+			return nil, err
+		}
+		r.cl = cl
+	}
+
+	return r, nil
+}
+
+// Register registers the schema under the subject, e.g. orders-value, returning its ID.
+// A schema which is already registered under the subject returns the ID of the existing one.
+func (r *Registry) Register(ctx context.Context, subject, schema string) (int, error) {
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return r.register(ctx, subject, s)
+}
+
+func (r *Registry) register(ctx context.Context, subject string, s avro.Schema) (int, error) {
+	key := subject + "/" + s.String()
+	r.mu.RLock()
+	id, ok := r.ids[key]
+	r.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"schema": s.String()})
+	if err != nil {
+		return 0, err
+	}
+	var rsp struct {
+		ID int `json:"id"`
+	}
+	err = r.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, &rsp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to register schema under subject %s: %w", subject, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids[key] = rsp.ID
+	r.schemas[rsp.ID] = s
+	return rsp.ID, nil
+}
+
+// schema returns the schema with the ID.
+func (r *Registry) schema(ctx context.Context, id int) (avro.Schema, error) {
+	r.mu.RLock()
+	s, ok := r.schemas[id]
+	r.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	var rsp struct {
+		Schema string `json:"schema"`
+	}
+	err := r.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &rsp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema %d: %w", id, err)
+	}
+	s, err = avro.Parse(rsp.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[id] = s
+	return s, nil
+}
+
+func (r *Registry) do(ctx context.Context, method, path string, body []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(encoding.AcceptHeader, registryContentType)
+	if body != nil {
+		req.Header.Set(encoding.ContentTypeHeader, registryContentType)
+	}
+	if r.user != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	rsp, err := r.cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return err
+	}
+	if rsp.StatusCode != http.StatusOK {
+		var rspErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &rspErr) == nil && rspErr.Message != "" {
+			return fmt.Errorf("schema registry responded with status %d: %s", rsp.StatusCode, rspErr.Message)
+		}
+		return fmt.Errorf("schema registry responded with status %d", rsp.StatusCode)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Encoder returns an encoder of the schema, which is registered under the subject once the first value is encoded.
+// The values are structs with avro tags, e.g. `avro:"name"`, or maps, and their nullable fields are pointers.
+func (r *Registry) Encoder(subject, schema string) (encoding.EncodeFunc, error) {
+	if subject == "" {
+		return nil, errors.New("subject is required")
+	}
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	return func(v interface{}) ([]byte, error) {
+		id, err := r.register(context.Background(), subject, s)
+		if err != nil {
+			return nil, err
+		}
+		data, err := avro.Marshal(s, v)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, headerSize, headerSize+len(data))
+		buf[0] = magicByte
+		binary.BigEndian.PutUint32(buf[1:headerSize], uint32(id))
+		return append(buf, data...), nil
+	}, nil
+}
+
+// Decode an Avro input in the form of a reader.
+func (r *Registry) Decode(data io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	return r.DecodeRaw(b, v)
+}
+
+// DecodeRaw an Avro input in the form of a byte slice, with the schema of the ID it is prefixed with.
+func (r *Registry) DecodeRaw(data []byte, v interface{}) error {
+	if len(data) < headerSize {
+		return errors.New("avro data is shorter than the header")
+	}
+	if data[0] != magicByte {
+		return fmt.Errorf("unknown magic byte %d", data[0])
+	}
+	s, err := r.schema(context.Background(), int(binary.BigEndian.Uint32(data[1:headerSize])))
+	if err != nil {
+		return err
+	}
+	return avro.Unmarshal(s, data[headerSize:], v)
+}
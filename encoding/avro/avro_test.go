@@ -0,0 +1,206 @@
+package avro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    {"name": "name", "type": "string"},
+    {"name": "email", "type": ["null", "string"], "default": null},
+    {"name": "age", "type": "int"}
+  ]
+}`
+
+type user struct {
+	Name  string  `avro:"name"`
+	Email *string `avro:"email"`
+	Age   int     `avro:"age"`
+}
+
+// registryServer is a schema registry which assigns IDs to the schemas starting from 1.
+type registryServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	schemas  []string
+	requests int
+}
+
+func newRegistryServer(t *testing.T) *registryServer {
+	rs := &registryServer{}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		rs.requests++
+		w.Header().Set("Content-Type", registryContentType)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subjects/users-value/versions":
+			var req struct {
+				Schema string `json:"schema"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			rs.schemas = append(rs.schemas, req.Schema)
+			_, _ = w.Write([]byte(`{"id":` + strconv.Itoa(len(rs.schemas)) + `}`))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/schemas/ids/"):
+			id := strings.TrimPrefix(r.URL.Path, "/schemas/ids/")
+			if id != "1" || len(rs.schemas) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error_code":40403,"message":"Schema not found"}`))
+				return
+			}
+			rsp, err := json.Marshal(map[string]string{"schema": rs.schemas[0]})
+			require.NoError(t, err)
+			_, _ = w.Write(rsp)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+func (rs *registryServer) requestCount() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.requests
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		url         string
+		oo          []OptionFunc
+		expectedErr string
+	}{
+		"success":          {url: "http://localhost:8081/", oo: []OptionFunc{Client(http.DefaultClient), BasicAuth("key", "secret")}},
+		"missing URL":      {expectedErr: "schema registry URL is required"},
+		"invalid URL":      {url: "http://local host:8081", expectedErr: `invalid schema registry URL: parse "http://local host:8081": invalid character " " in host name`},
+		"nil client":       {url: "http://localhost:8081", oo: []OptionFunc{Client(nil)}, expectedErr: "HTTP client is nil"},
+		"missing user":     {url: "http://localhost:8081", oo: []OptionFunc{BasicAuth("", "secret")}, expectedErr: "user is empty"},
+		"missing password": {url: "http://localhost:8081", oo: []OptionFunc{BasicAuth("key", "")}, expectedErr: "password is empty"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.url, tt.oo...)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "http://localhost:8081", got.url)
+			assert.NotNil(t, got.cl)
+		})
+	}
+}
+
+func TestRegistry_EncodeDecode(t *testing.T) {
+	rs := newRegistryServer(t)
+	r, err := New(rs.URL)
+	require.NoError(t, err)
+
+	enc, err := r.Encoder("users-value", userSchema)
+	require.NoError(t, err)
+	email := "john@example.com"
+	data, err := enc(user{Name: "John", Email: &email, Age: 42})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0, 1}, data[:headerSize], "the data is prefixed with the magic byte and the schema ID")
+	_, err = enc(&user{Name: "Jane", Age: 24})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rs.requestCount(), "the schema is registered once")
+
+	// a new registry has to get the schema by its ID
+	r, err = New(rs.URL)
+	require.NoError(t, err)
+	var got user
+	require.NoError(t, r.DecodeRaw(data, &got))
+	assert.Equal(t, user{Name: "John", Email: &email, Age: 42}, got)
+
+	var native map[string]interface{}
+	require.NoError(t, r.Decode(bytes.NewReader(data), &native))
+	assert.Equal(t, map[string]interface{}{"name": "John", "email": "john@example.com", "age": 42}, native)
+	assert.Equal(t, 2, rs.requestCount(), "the schema is cached by its ID")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	rs := newRegistryServer(t)
+	r, err := New(rs.URL)
+	require.NoError(t, err)
+
+	id, err := r.Register(context.Background(), "users-value", userSchema)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+	id, err = r.Register(context.Background(), "users-value", userSchema)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+	assert.Equal(t, 1, rs.requestCount())
+
+	_, err = r.Register(context.Background(), "orders-value", userSchema)
+	assert.EqualError(t, err, "failed to register schema under subject orders-value: schema registry responded with status 500")
+	_, err = r.Register(context.Background(), "users-value", "{")
+	assert.Error(t, err)
+}
+
+func TestRegistry_DecodeRaw_Errors(t *testing.T) {
+	rs := newRegistryServer(t)
+	r, err := New(rs.URL)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		data        []byte
+		expectedErr string
+	}{
+		"short data":         {data: []byte{0, 0, 0}, expectedErr: "avro data is shorter than the header"},
+		"unknown magic byte": {data: []byte{1, 0, 0, 0, 1}, expectedErr: "unknown magic byte 1"},
+		"unknown schema":     {data: []byte{0, 0, 0, 0, 2}, expectedErr: "failed to get schema 2: schema registry responded with status 404: Schema not found"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			var got user
+			assert.EqualError(t, r.DecodeRaw(tt.data, &got), tt.expectedErr)
+		})
+	}
+}
+
+func TestRegistry_Encoder_Errors(t *testing.T) {
+	r, err := New("http://localhost:8081")
+	require.NoError(t, err)
+
+	_, err = r.Encoder("", userSchema)
+	assert.EqualError(t, err, "subject is required")
+	_, err = r.Encoder("users-value", `{"type": "unknown"}`)
+	assert.Error(t, err)
+}
+
+func TestRegistry_BasicAuth(t *testing.T) {
+	var user, password string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		_, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"id":7}`))
+	}))
+	defer srv.Close()
+	r, err := New(srv.URL, BasicAuth("key", "secret"))
+	require.NoError(t, err)
+
+	id, err := r.Register(context.Background(), "users-value", userSchema)
+	require.NoError(t, err)
+	assert.Equal(t, 7, id)
+	assert.Equal(t, "key", user)
+	assert.Equal(t, "secret", password)
+}
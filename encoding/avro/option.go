@@ -0,0 +1,36 @@
+package avro
+
+import (
+	"errors"
+
+	patronhttp "github.com/beatlabs/patron/client/http"
+)
+
+// OptionFunc definition for configuring the registry in a functional way.
+type OptionFunc func(*Registry) error
+
+// Client option for setting the HTTP client of the registry requests, which is a traced client by default.
+func Client(cl patronhttp.Client) OptionFunc {
+	return func(r *Registry) error {
+		if cl == nil {
+			return errors.New("HTTP client is nil")
+		}
+		r.cl = cl
+		return nil
+	}
+}
+
+// BasicAuth option for authenticating the registry requests with the user and password, e.g. an API key and secret.
+func BasicAuth(user, password string) OptionFunc {
+	return func(r *Registry) error {
+		if user == "" {
+			return errors.New("user is empty")
+		}
+		if password == "" {
+			return errors.New("password is empty")
+		}
+		r.user = user
+		r.password = password
+		return nil
+	}
+}
@@ -2,8 +2,11 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"sync"
 )
 
 const (
@@ -11,8 +14,82 @@ const (
 	Type string = "application/json"
 	// TypeCharset JSON definition with charset.
 	TypeCharset string = "application/json; charset=utf-8"
+
+	// maxPooledBufferSize is the capacity above which the buffers are not returned to the pool,
+	// so that a few large payloads do not keep their memory allocated.
+	maxPooledBufferSize = 64 << 10
 )
 
+// Codec is a JSON implementation, e.g. an adapter of jsoniter or sonic, which replaces encoding/json in a FastPath.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type standardCodec struct{}
+
+func (standardCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (standardCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Standard is the Codec of encoding/json, which enables the fast path without a third-party implementation.
+// encoding/json pools its encoding state already, so with it the fast path only saves the decoders of the inputs.
+var Standard Codec = standardCodec{}
+
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// FastPath decodes and encodes JSON with a codec, reading the inputs into pooled buffers and decoding them as a whole,
+// instead of streaming them through a new decoder for every call. Unlike Decode, trailing data after the JSON value is an error.
+// The outputs of the encoding are the ones of the codec, since they are owned by the callers.
+type FastPath struct {
+	codec Codec
+}
+
+// NewFastPath creates a fast path with the codec, e.g. Standard or an adapter of jsoniter or sonic.
+func NewFastPath(c Codec) (*FastPath, error) {
+	if c == nil {
+		return nil, errors.New("codec is nil")
+	}
+	return &FastPath{codec: c}, nil
+}
+
+// Decode a JSON input in the form of a reader, through a pooled buffer.
+func (fp *FastPath) Decode(data io.Reader, v interface{}) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufPool.Put(buf)
+		}
+	}()
+	if _, err := buf.ReadFrom(data); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		// the same error as the one of the default decoding
+		return io.EOF
+	}
+	return fp.codec.Unmarshal(buf.Bytes(), v)
+}
+
+// DecodeRaw a JSON input in the form of a byte slice.
+func (fp *FastPath) DecodeRaw(data []byte, v interface{}) error {
+	return fp.codec.Unmarshal(data, v)
+}
+
+// Encode a model to JSON.
+func (fp *FastPath) Encode(v interface{}) ([]byte, error) {
+	return fp.codec.Marshal(v)
+}
+
 // Decode a JSON input in the form of a read.
 func Decode(data io.Reader, v interface{}) error {
 	return json.NewDecoder(data).Decode(v)
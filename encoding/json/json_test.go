@@ -2,9 +2,13 @@ package json
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncodeDecode(t *testing.T) {
@@ -19,3 +23,92 @@ func TestEncodeDecode(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "string", data)
 }
+
+type mockCodec struct {
+	marshaled   bool
+	unmarshaled bool
+}
+
+func (m *mockCodec) Marshal(v interface{}) ([]byte, error) {
+	m.marshaled = true
+	return Standard.Marshal(v)
+}
+
+func (m *mockCodec) Unmarshal(data []byte, v interface{}) error {
+	m.unmarshaled = true
+	return Standard.Unmarshal(data, v)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("TEST")
+}
+
+func TestNewFastPath(t *testing.T) {
+	_, err := NewFastPath(nil)
+	assert.EqualError(t, err, "codec is nil")
+}
+
+func TestFastPath(t *testing.T) {
+	c := &mockCodec{}
+	fp, err := NewFastPath(c)
+	require.NoError(t, err)
+
+	j, err := fp.Encode(map[string]string{"key": "value"})
+	require.NoError(t, err)
+	assert.True(t, c.marshaled)
+
+	var data map[string]string
+	require.NoError(t, fp.Decode(bytes.NewReader(j), &data))
+	assert.True(t, c.unmarshaled)
+	assert.Equal(t, map[string]string{"key": "value"}, data)
+
+	c.unmarshaled = false
+	require.NoError(t, fp.DecodeRaw(j, &data))
+	assert.True(t, c.unmarshaled)
+
+	assert.Equal(t, io.EOF, fp.Decode(strings.NewReader(""), &data))
+	assert.EqualError(t, fp.Decode(failingReader{}, &data), "TEST")
+	assert.Error(t, fp.Decode(strings.NewReader(`{"key":"value"} {}`), &data), "trailing data is an error")
+
+	c.marshaled = false
+	_, err = Encode("string")
+	require.NoError(t, err)
+	assert.False(t, c.marshaled, "the package functions do not use the codec")
+}
+
+type benchmarkPayload struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Tags    []string          `json:"tags"`
+	Count   int               `json:"count"`
+	Enabled bool              `json:"enabled"`
+	Labels  map[string]string `json:"labels"`
+}
+
+var benchmarkJSON = []byte(`{"id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","name":"benchmark","tags":["a","b","c"],` +
+	`"count":42,"enabled":true,"labels":{"env":"production","team":"platform"}}`)
+
+func benchmarkDecode(b *testing.B, decode func(io.Reader, interface{}) error) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var p benchmarkPayload
+		if err := decode(bytes.NewReader(benchmarkJSON), &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	benchmarkDecode(b, Decode)
+}
+
+func BenchmarkDecode_FastPath(b *testing.B) {
+	fp, err := NewFastPath(Standard)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkDecode(b, fp.Decode)
+}
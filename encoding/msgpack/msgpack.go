@@ -0,0 +1,30 @@
+// Package msgpack is a concrete implementation of the encoding abstractions.
+package msgpack
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// Type MessagePack definition.
+	Type string = "application/msgpack"
+	// TypeLegacy MessagePack definition of the unregistered media type, which is still widely used.
+	TypeLegacy string = "application/x-msgpack"
+)
+
+// Decode a MessagePack input in the form of a reader.
+func Decode(data io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(data).Decode(v)
+}
+
+// DecodeRaw a MessagePack input in the form of a byte slice.
+func DecodeRaw(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// Encode a model to MessagePack.
+func Encode(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
@@ -0,0 +1,71 @@
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"sync"
+
+	"github.com/beatlabs/patron/encoding/json"
+	"github.com/beatlabs/patron/encoding/msgpack"
+	"github.com/beatlabs/patron/encoding/protobuf"
+	"github.com/beatlabs/patron/encoding/xml"
+	"github.com/beatlabs/patron/encoding/yaml"
+)
+
+type codec struct {
+	enc EncodeFunc
+	dec DecodeFunc
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]codec{
+		json.Type:           {enc: json.Encode, dec: json.Decode},
+		protobuf.Type:       {enc: protobuf.Encode, dec: protobuf.Decode},
+		protobuf.TypeGoogle: {enc: protobuf.Encode, dec: protobuf.Decode},
+		xml.Type:            {enc: xml.Encode, dec: xml.Decode},
+		xml.TypeText:        {enc: xml.Encode, dec: xml.Decode},
+		msgpack.Type:        {enc: msgpack.Encode, dec: msgpack.Decode},
+		msgpack.TypeLegacy:  {enc: msgpack.Encode, dec: msgpack.Decode},
+		yaml.Type:           {enc: yaml.Encode, dec: yaml.Decode},
+		yaml.TypeLegacy:     {enc: yaml.Encode, dec: yaml.Decode},
+		yaml.TypeText:       {enc: yaml.Encode, dec: yaml.Decode},
+	}
+)
+
+// Register the encoder and decoder of a content type, e.g. "application/cbor", replacing the ones already registered for it.
+// The parameters of the content type are ignored. JSON, protobuf, XML, MessagePack and YAML are registered by default.
+func Register(contentType string, enc EncodeFunc, dec DecodeFunc) error {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+	if enc == nil {
+		return errors.New("encoder is nil")
+	}
+	if dec == nil {
+		return errors.New("decoder is nil")
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[mt] = codec{enc: enc, dec: dec}
+	return nil
+}
+
+// Lookup returns the encoder and decoder registered for a content type, ignoring its parameters, e.g. the charset.
+func Lookup(contentType string) (EncodeFunc, DecodeFunc, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[mt]
+	if !ok {
+		return nil, nil, false
+	}
+	return c.enc, c.dec, true
+}
@@ -0,0 +1,78 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	enc := func(v interface{}) ([]byte, error) {
+		return []byte(v.(string)), nil
+	}
+	dec := func(data io.Reader, v interface{}) error {
+		b, err := ioutil.ReadAll(data)
+		*(v.(*string)) = string(b)
+		return err
+	}
+
+	tests := map[string]struct {
+		contentType string
+		enc         EncodeFunc
+		dec         DecodeFunc
+		expectedErr string
+	}{
+		"success":              {contentType: "application/x-custom; version=1", enc: enc, dec: dec},
+		"invalid content type": {contentType: "", enc: enc, dec: dec, expectedErr: `invalid content type "": mime: no media type`},
+		"missing encoder":      {contentType: "application/x-custom", dec: dec, expectedErr: "encoder is nil"},
+		"missing decoder":      {contentType: "application/x-custom", enc: enc, expectedErr: "decoder is nil"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			err := Register(tt.contentType, tt.enc, tt.dec)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			gotEnc, gotDec, ok := Lookup("application/x-custom")
+			require.True(t, ok)
+			b, err := gotEnc("value")
+			require.NoError(t, err)
+			var v string
+			require.NoError(t, gotDec(bytes.NewReader(b), &v))
+			assert.Equal(t, "value", v)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		found       bool
+	}{
+		"json":              {contentType: "application/json", found: true},
+		"json with charset": {contentType: "application/json; charset=utf-8", found: true},
+		"protobuf":          {contentType: "application/x-protobuf", found: true},
+		"xml":               {contentType: "text/xml", found: true},
+		"msgpack":           {contentType: "application/msgpack", found: true},
+		"yaml":              {contentType: "application/x-yaml", found: true},
+		"unknown":           {contentType: "text/csv"},
+		"invalid":           {contentType: ";"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			enc, dec, ok := Lookup(tt.contentType)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.found, enc != nil)
+			assert.Equal(t, tt.found, dec != nil)
+		})
+	}
+}
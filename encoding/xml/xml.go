@@ -0,0 +1,31 @@
+// Package xml is a concrete implementation of the encoding abstractions.
+package xml
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const (
+	// Type XML definition.
+	Type string = "application/xml"
+	// TypeText XML definition of the text media type.
+	TypeText string = "text/xml"
+	// TypeCharset XML definition with charset.
+	TypeCharset string = "application/xml; charset=utf-8"
+)
+
+// Decode a XML input in the form of a reader.
+func Decode(data io.Reader, v interface{}) error {
+	return xml.NewDecoder(data).Decode(v)
+}
+
+// DecodeRaw a XML input in the form of a byte slice.
+func DecodeRaw(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+// Encode a model to XML.
+func Encode(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
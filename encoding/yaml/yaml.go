@@ -0,0 +1,32 @@
+// Package yaml is a concrete implementation of the encoding abstractions.
+package yaml
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Type YAML definition.
+	Type string = "application/yaml"
+	// TypeLegacy YAML definition of the unregistered media type, which is still widely used.
+	TypeLegacy string = "application/x-yaml"
+	// TypeText YAML definition of the text media type.
+	TypeText string = "text/yaml"
+)
+
+// Decode a YAML input in the form of a reader.
+func Decode(data io.Reader, v interface{}) error {
+	return yaml.NewDecoder(data).Decode(v)
+}
+
+// DecodeRaw a YAML input in the form of a byte slice.
+func DecodeRaw(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
+
+// Encode a model to YAML.
+func Encode(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
@@ -66,8 +66,16 @@ func main() {
 	routesBuilder := patronhttp.NewRoutesBuilder().
 		Append(patronhttp.NewGetRouteBuilder("/", asyncComp.forwardToKafkaHandler).WithTrace().WithAuth(auth))
 
+	// the service is not ready while the producer is disconnected from the brokers
+	readyCheck := func() patronhttp.ReadyStatus {
+		if asyncComp.prd.Connected() {
+			return patronhttp.Ready
+		}
+		return patronhttp.NotReady
+	}
+
 	ctx := context.Background()
-	err = service.WithRoutesBuilder(routesBuilder).Run(ctx)
+	err = service.WithRoutesBuilder(routesBuilder).WithReadyCheck(readyCheck).Run(ctx)
 	if err != nil {
 		log.Fatalf("failed to create and run service %v", err)
 	}
@@ -76,6 +84,7 @@ func main() {
 type kafkaProducer struct {
 	prd   *v2.AsyncProducer
 	topic string
+	cl    *clienthttp.TracedClient
 }
 
 // newAsyncKafkaProducer creates a new asynchronous kafka producer client
@@ -90,12 +99,21 @@ func newAsyncKafkaProducer(kafkaBroker, topic string, readCommitted bool) (*kafk
 		return nil, err
 	}
 	go func() {
-		for {
-			err := <-chErr
+		for err := range chErr {
+			// the producer reports the loss of connectivity once and checks it again with a backoff,
+			// so errors are not logged while it is disconnected, to avoid flooding the logs
+			if !prd.Connected() {
+				continue
+			}
 			log.Errorf("error producing Kafka message: %v", err)
 		}
 	}()
-	return &kafkaProducer{prd: prd, topic: topic}, nil
+	// the HTTP client is created once and reused, in order to benefit from connection pooling
+	cl, err := clienthttp.New()
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaProducer{prd: prd, topic: topic, cl: cl}, nil
 }
 
 // forwardToKafkaHandler is an http handler that decodes the input request and
@@ -111,14 +129,11 @@ func (hc *kafkaProducer) forwardToKafkaHandler(ctx context.Context, req *patronh
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for www.google.com: %w", err)
 	}
-	cl, err := clienthttp.New(clienthttp.Timeout(5 * time.Second))
-	if err != nil {
-		return nil, err
-	}
-	_, err = cl.Do(googleReq)
+	rsp, err := hc.cl.DoWithTimeout(ctx, googleReq, 5*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get www.google.com: %w", err)
 	}
+	_ = rsp.Body.Close()
 
 	b, err := json.Encode(u)
 	if err != nil {
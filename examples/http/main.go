@@ -26,6 +26,8 @@ var (
 	assetsFolder  string
 	requestsCount int
 	refreshAfter  int64
+	// httpClient is shared by all handlers, since creating a client per request defeats connection reuse
+	httpClient *clienthttp.TracedClient
 )
 
 func init() {
@@ -58,20 +60,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	httpClient, err = clienthttp.New()
+	if err != nil {
+		log.Fatalf("failed to create HTTP client %v", err)
+	}
+
 	routesBuilder := patronhttp.NewRoutesBuilder().
 		Append(patronhttp.NewFileServer("/frontend/*path", assetsFolder, assetsFolder+"/index.html")).
 		Append(patronhttp.NewPostRouteBuilder("/api", httpHandler)).
 		Append(patronhttp.NewGetRouteBuilder("/api", getHandler).WithRateLimiting(50, 50))
 
-	// Setup a simple CORS middleware
-	middlewareCors := func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Add("Access-Control-Allow-Origin", "*")
-			w.Header().Add("Access-Control-Allow-Methods", "GET, POST")
-			w.Header().Add("Access-Control-Allow-Headers", "Origin, Authorization, Content-Type")
-			w.Header().Add("Access-Control-Allow-Credentials", "Allow")
-			h.ServeHTTP(w, r)
-		})
+	// Setup a CORS middleware
+	middlewareCors, err := patronhttp.NewCORSMiddleware(patronhttp.CORSOptions{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Origin", "Authorization", "Content-Type"},
+	})
+	if err != nil {
+		log.Fatalf("failed to create CORS middleware %v", err)
 	}
 	sig := func() {
 		log.Info("exit gracefully...")
@@ -132,14 +138,11 @@ func httpHandler(ctx context.Context, req *patronhttp.Request) (*patronhttp.Resp
 	httpRequest.Header.Add("Content-Type", protobuf.Type)
 	httpRequest.Header.Add("Accept", protobuf.Type)
 	httpRequest.Header.Add("Authorization", "Apikey 123456")
-	cl, err := clienthttp.New(clienthttp.Timeout(5 * time.Second))
-	if err != nil {
-		return nil, err
-	}
-	rsp, err := cl.Do(httpRequest)
+	rsp, err := httpClient.DoWithTimeout(ctx, httpRequest, 5*time.Second)
 	if err != nil {
 		return nil, patronhttp.NewErrorWithCodeAndPayload(http.StatusInternalServerError, fmt.Sprintf("failed to perform http request with protobuf payload: %v", err))
 	}
+	_ = rsp.Body.Close()
 	log.FromContext(ctx).Infof("request processed: %s %s", u.GetFirstname(), u.GetLastname())
 	return patronhttp.NewResponse(fmt.Sprintf("got %s from HTTP route", rsp.Status)), nil
 }
@@ -150,15 +153,13 @@ func DoIntervalRequest(ctx context.Context) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed create route request: %w", err)
 	}
-	cl, err := clienthttp.New(clienthttp.Timeout(5 * time.Second))
-	if err != nil {
-		return "", fmt.Errorf("could not create http client: %w", err)
-	}
-
-	response, err := cl.Do(request)
+	response, err := httpClient.DoWithTimeout(ctx, request, 5*time.Second)
 	if err != nil {
 		return "", fmt.Errorf("failed create get to http-cache service: %w", err)
 	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
 
 	tb, err := ioutil.ReadAll(response.Body)
 	if err != nil {
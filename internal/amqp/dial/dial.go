@@ -0,0 +1,39 @@
+// Package dial provides the dial configuration of the AMQP clients and components.
+package dial
+
+import "github.com/streadway/amqp"
+
+const connectionNameProperty = "connection_name"
+
+// MergeConfig returns the config, along with the TLS configuration, the heartbeat and the connection name of the previous one,
+// e.g. the ones set by dedicated options, unless the config sets them as well. The properties of the config are not modified.
+func MergeConfig(cfg, prev amqp.Config) amqp.Config {
+	if cfg.TLSClientConfig == nil {
+		cfg.TLSClientConfig = prev.TLSClientConfig
+	}
+	if cfg.Heartbeat == 0 {
+		cfg.Heartbeat = prev.Heartbeat
+	}
+	name, ok := prev.Properties[connectionNameProperty]
+	if !ok {
+		return cfg
+	}
+	if _, ok := cfg.Properties[connectionNameProperty]; ok {
+		return cfg
+	}
+	props := make(amqp.Table, len(cfg.Properties)+1)
+	for k, v := range cfg.Properties {
+		props[k] = v
+	}
+	props[connectionNameProperty] = name
+	cfg.Properties = props
+	return cfg
+}
+
+// WithConnectionName sets the name of the connection, which is shown in the management UI of the broker.
+func WithConnectionName(cfg *amqp.Config, name string) {
+	if cfg.Properties == nil {
+		cfg.Properties = amqp.Table{}
+	}
+	cfg.Properties[connectionNameProperty] = name
+}
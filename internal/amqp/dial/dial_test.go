@@ -0,0 +1,32 @@
+package dial
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeConfig(t *testing.T) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	prev := amqp.Config{Heartbeat: time.Second, TLSClientConfig: tlsCfg, Locale: "en_US"}
+	WithConnectionName(&prev, "orders")
+	props := amqp.Table{"product": "orders"}
+
+	got := MergeConfig(amqp.Config{Locale: "123", Properties: props}, prev)
+	assert.Equal(t, amqp.Config{
+		Locale:          "123",
+		Heartbeat:       time.Second,
+		TLSClientConfig: tlsCfg,
+		Properties:      amqp.Table{"product": "orders", "connection_name": "orders"},
+	}, got, "the fields of the previous config are kept")
+	assert.Equal(t, amqp.Table{"product": "orders"}, props, "the properties of the config are not modified")
+
+	otherTLS := &tls.Config{MinVersion: tls.VersionTLS13}
+	cfg := amqp.Config{Heartbeat: time.Minute, TLSClientConfig: otherTLS, Properties: amqp.Table{"connection_name": "other"}}
+	assert.Equal(t, cfg, MergeConfig(cfg, prev), "the fields of the config take precedence")
+
+	assert.Equal(t, amqp.Config{Locale: "123"}, MergeConfig(amqp.Config{Locale: "123"}, amqp.Config{}))
+}
@@ -0,0 +1,114 @@
+// Package security provides the SASL and TLS configuration of the Kafka clients and components.
+package security
+
+import (
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// SASL enables the SASL authentication with the mechanism, which is either sarama.SASLTypePlaintext,
+// sarama.SASLTypeSCRAMSHA256 or sarama.SASLTypeSCRAMSHA512.
+func SASL(cfg *sarama.Config, mechanism sarama.SASLMechanism, user, password string) error {
+	var hash scram.HashGeneratorFcn
+	switch mechanism {
+	case sarama.SASLTypePlaintext:
+	case sarama.SASLTypeSCRAMSHA256:
+		hash = scram.SHA256
+	case sarama.SASLTypeSCRAMSHA512:
+		hash = sha512.New
+	default:
+		return fmt.Errorf("unsupported SASL mechanism %s", mechanism)
+	}
+	if user == "" {
+		return errors.New("SASL user is empty")
+	}
+	if password == "" {
+		return errors.New("SASL password is empty")
+	}
+
+	cfg.Net.SASL.Enable = true
+	cfg.Net.SASL.Handshake = true
+	cfg.Net.SASL.Mechanism = mechanism
+	cfg.Net.SASL.User = user
+	cfg.Net.SASL.Password = password
+	cfg.Net.SASL.SCRAMClientGeneratorFunc = nil
+	if hash != nil {
+		cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hash: hash}
+		}
+	}
+	return nil
+}
+
+// TLS enables TLS, verifying the certificates of the brokers with the CA, or else with the system roots if the CA file is empty.
+// The client certificate is presented to the brokers if the cert and key files are provided.
+func TLS(cfg *sarama.Config, caFile, certFile, keyFile string) error {
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("TLS client certificate requires both a cert and a key file")
+	}
+
+	tlsCfg := tlsConfig(cfg)
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
+// TLSInsecureSkipVerify enables TLS without verifying the certificates of the brokers, which should only be used for testing.
+func TLSInsecureSkipVerify(cfg *sarama.Config) {
+	tlsConfig(cfg).InsecureSkipVerify = true //nolint:gosec
+}
+
+// tlsConfig enables TLS and returns its configuration, so that the TLS options can be applied in any order.
+func tlsConfig(cfg *sarama.Config) *tls.Config {
+	cfg.Net.TLS.Enable = true
+	if cfg.Net.TLS.Config == nil {
+		cfg.Net.TLS.Config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return cfg.Net.TLS.Config
+}
+
+// scramClient implements the sarama.SCRAMClient.
+type scramClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(user, password, authzID string) error {
+	client, err := c.hash.NewClient(user, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
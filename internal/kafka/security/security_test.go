@@ -0,0 +1,135 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSASL(t *testing.T) {
+	tests := map[string]struct {
+		mechanism   sarama.SASLMechanism
+		user        string
+		password    string
+		expectSCRAM bool
+		expectedErr string
+	}{
+		"plain":                 {mechanism: sarama.SASLTypePlaintext, user: "user", password: "pass"},
+		"scram sha256":          {mechanism: sarama.SASLTypeSCRAMSHA256, user: "user", password: "pass", expectSCRAM: true},
+		"scram sha512":          {mechanism: sarama.SASLTypeSCRAMSHA512, user: "user", password: "pass", expectSCRAM: true},
+		"unsupported mechanism": {mechanism: sarama.SASLTypeGSSAPI, user: "user", password: "pass", expectedErr: "unsupported SASL mechanism GSSAPI"},
+		"missing user":          {mechanism: sarama.SASLTypePlaintext, password: "pass", expectedErr: "SASL user is empty"},
+		"missing password":      {mechanism: sarama.SASLTypePlaintext, user: "user", expectedErr: "SASL password is empty"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := sarama.NewConfig()
+			err := SASL(cfg, tt.mechanism, tt.user, tt.password)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.False(t, cfg.Net.SASL.Enable)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, cfg.Net.SASL.Enable)
+			assert.Equal(t, tt.mechanism, cfg.Net.SASL.Mechanism)
+			assert.Equal(t, tt.user, cfg.Net.SASL.User)
+			assert.Equal(t, tt.password, cfg.Net.SASL.Password)
+			require.NoError(t, cfg.Validate())
+			if !tt.expectSCRAM {
+				assert.Nil(t, cfg.Net.SASL.SCRAMClientGeneratorFunc)
+				return
+			}
+			client := cfg.Net.SASL.SCRAMClientGeneratorFunc()
+			require.NoError(t, client.Begin(tt.user, tt.password, ""))
+			first, err := client.Step("")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(first, "n,,n=user,r="))
+			assert.False(t, client.Done())
+		})
+	}
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writeCertificate(t, certFile, keyFile)
+	// the self-signed client certificate is used as the CA
+	caPEM, err := ioutil.ReadFile(certFile)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(caFile, caPEM, 0o600))
+
+	tests := map[string]struct {
+		caFile       string
+		certFile     string
+		keyFile      string
+		expectCA     bool
+		expectedCert bool
+		expectedErr  string
+	}{
+		"system roots":       {},
+		"CA":                 {caFile: caFile, expectCA: true},
+		"client certificate": {caFile: caFile, certFile: certFile, keyFile: keyFile, expectCA: true, expectedCert: true},
+		"missing key":        {certFile: certFile, expectedErr: "TLS client certificate requires both a cert and a key file"},
+		"missing CA file":    {caFile: filepath.Join(dir, "missing.pem"), expectedErr: "failed to read CA file: open " + filepath.Join(dir, "missing.pem") + ": no such file or directory"},
+		"invalid CA file":    {caFile: keyFile, expectedErr: "no certificates found in CA file " + keyFile},
+		"invalid client key": {certFile: certFile, keyFile: caFile, expectedErr: "failed to load client certificate: tls: found a certificate rather than a key in the PEM for the private key"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := sarama.NewConfig()
+			err := TLS(cfg, tt.caFile, tt.certFile, tt.keyFile)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, cfg.Net.TLS.Enable)
+			assert.Equal(t, tt.expectCA, cfg.Net.TLS.Config.RootCAs != nil)
+			assert.Equal(t, tt.expectedCert, len(cfg.Net.TLS.Config.Certificates) == 1)
+			assert.False(t, cfg.Net.TLS.Config.InsecureSkipVerify)
+		})
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	cfg := sarama.NewConfig()
+	TLSInsecureSkipVerify(cfg)
+	require.NoError(t, TLS(cfg, "", "", ""))
+	assert.True(t, cfg.Net.TLS.Enable)
+	assert.True(t, cfg.Net.TLS.Config.InsecureSkipVerify, "the TLS options can be applied in any order")
+}
+
+func writeCertificate(t *testing.T, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
@@ -4,7 +4,9 @@ package log
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 )
 
 // The Level type definition.
@@ -42,6 +44,43 @@ func LevelOrder(lvl Level) int {
 	return levelOrder[lvl]
 }
 
+// ParseLevel returns the level of its string representation, e.g. "debug".
+func ParseLevel(lvl string) (Level, error) {
+	l := Level(lvl)
+	if _, ok := levelOrder[l]; !ok {
+		return NoLevel, fmt.Errorf("invalid log level %q", lvl)
+	}
+	return l, nil
+}
+
+// AtomicLevel is a level which can be changed concurrently while logging.
+// Loggers share it with their sub loggers, so that a level change applies to all of them.
+type AtomicLevel struct {
+	v atomic.Value
+}
+
+// NewAtomicLevel constructor.
+func NewAtomicLevel(lvl Level) *AtomicLevel {
+	al := &AtomicLevel{}
+	al.v.Store(lvl)
+	return al
+}
+
+// Level returns the current level.
+func (al *AtomicLevel) Level() Level {
+	return al.v.Load().(Level)
+}
+
+// SetLevel changes the level.
+func (al *AtomicLevel) SetLevel(lvl Level) {
+	al.v.Store(lvl)
+}
+
+// Enabled shows if the given level is logged with the current level.
+func (al *AtomicLevel) Enabled(lvl Level) bool {
+	return levelOrder[al.Level()] <= levelOrder[lvl]
+}
+
 // Logger interface definition of a logger.
 type Logger interface {
 	Sub(map[string]interface{}) Logger
@@ -60,6 +99,11 @@ type Logger interface {
 	Level() Level
 }
 
+// LevelSetter is implemented by the loggers whose level can be changed at runtime.
+type LevelSetter interface {
+	SetLevel(Level)
+}
+
 type ctxKey struct{}
 
 var (
@@ -79,6 +123,20 @@ func Setup(l Logger) error {
 	return nil
 }
 
+// SetLevel changes at runtime the level of the logger provided in Setup and of its sub loggers,
+// e.g. to get debug logs of a running service without restarting it.
+func SetLevel(lvl Level) error {
+	if _, ok := levelOrder[lvl]; !ok {
+		return fmt.Errorf("invalid log level %q", lvl)
+	}
+	ls, ok := logger.(LevelSetter)
+	if !ok {
+		return errors.New("logger does not support changing the level")
+	}
+	ls.SetLevel(lvl)
+	return nil
+}
+
 // FromContext returns the logger in the context or a nil logger.
 func FromContext(ctx context.Context) Logger {
 	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
@@ -165,6 +223,26 @@ func Enabled(l Level) bool {
 	return levelOrder[logger.Level()] <= levelOrder[l]
 }
 
+// NewNop returns a logger which discards everything, e.g. for benchmarks or for embedding without logging.
+// Its level is NoLevel, so Enabled reports false for all levels, and logging does not allocate.
+func NewNop() Logger {
+	return &nopLogger{}
+}
+
+type nopLogger struct {
+	nilLogger
+}
+
+// Sub returns the same logger, since fields are discarded.
+func (nl *nopLogger) Sub(map[string]interface{}) Logger {
+	return nl
+}
+
+// Level returns the level of the no-op logger, which does not log at any level.
+func (nl *nopLogger) Level() Level {
+	return NoLevel
+}
+
 type nilLogger struct{}
 
 // Sub returns a sub logger with new fields attached.
@@ -187,6 +187,40 @@ func TestLog_Level(t *testing.T) {
 	}
 }
 
+func TestParseLevel(t *testing.T) {
+	lvl, err := ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, WarnLevel, lvl)
+	_, err = ParseLevel("verbose")
+	assert.EqualError(t, err, `invalid log level "verbose"`)
+}
+
+func TestSetLevel(t *testing.T) {
+	defer func() {
+		logger = &nilLogger{}
+	}()
+
+	logger = &testLogger{level: InfoLevel}
+	assert.EqualError(t, SetLevel(DebugLevel), "logger does not support changing the level")
+
+	logger = &levelSetterLogger{testLogger: testLogger{level: InfoLevel}}
+	assert.EqualError(t, SetLevel("verbose"), `invalid log level "verbose"`)
+	assert.NoError(t, SetLevel(DebugLevel))
+	assert.Equal(t, DebugLevel, logger.Level())
+	assert.True(t, Enabled(DebugLevel))
+}
+
+func TestAtomicLevel(t *testing.T) {
+	al := NewAtomicLevel(InfoLevel)
+	assert.Equal(t, InfoLevel, al.Level())
+	assert.False(t, al.Enabled(DebugLevel))
+	al.SetLevel(DebugLevel)
+	assert.Equal(t, DebugLevel, al.Level())
+	assert.True(t, al.Enabled(DebugLevel))
+	al.SetLevel(NoLevel)
+	assert.False(t, al.Enabled(PanicLevel))
+}
+
 func Test_nilLogger(t *testing.T) {
 	l := &nilLogger{}
 	l.Panic("test")
@@ -206,6 +240,36 @@ func Test_nilLogger(t *testing.T) {
 	assert.Equal(t, DebugLevel, l.Level())
 }
 
+func TestNewNop(t *testing.T) {
+	l := NewNop()
+	l.Error("test")
+	l.Errorf("test", "123")
+	l.Info("test")
+	l.Infof("test", "123")
+	l.Debug("test")
+	l.Debugf("test", "123")
+
+	assert.Equal(t, l, l.Sub(map[string]interface{}{"key": "val"}))
+	assert.Equal(t, NoLevel, l.Level())
+
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Debug("test")
+		l.Infof("test %s", "123")
+		l.Sub(nil).Errorf("test %s", "123")
+	})
+	assert.Zero(t, allocs)
+}
+
+func Benchmark_Nop(b *testing.B) {
+	l := NewNop()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		l.Infof("test %s", "123")
+	}
+}
+
 var bCtx context.Context
 
 func Benchmark_WithContext(b *testing.B) {
@@ -296,3 +360,11 @@ func (t *testLogger) Debugf(_ string, _ ...interface{}) {
 func (t *testLogger) Level() Level {
 	return t.level
 }
+
+type levelSetterLogger struct {
+	testLogger
+}
+
+func (l *levelSetterLogger) SetLevel(lvl Level) {
+	l.level = lvl
+}
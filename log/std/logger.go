@@ -23,7 +23,7 @@ var levelMap = map[patronLog.Level]string{
 
 // Logger implementation of the std log.
 type Logger struct {
-	level      patronLog.Level
+	level      *patronLog.AtomicLevel
 	fields     map[string]interface{}
 	fieldsLine string
 	debug      *log.Logger
@@ -41,6 +41,10 @@ func New(out io.Writer, lvl patronLog.Level, fields map[string]interface{}) *Log
 
 // NewWithFlags constructor.
 func NewWithFlags(out io.Writer, lvl patronLog.Level, fields map[string]interface{}, flags int) *Logger {
+	return newLogger(out, patronLog.NewAtomicLevel(lvl), fields, flags)
+}
+
+func newLogger(out io.Writer, lvl *patronLog.AtomicLevel, fields map[string]interface{}, flags int) *Logger {
 	fieldsLine := createFieldsLine(fields)
 
 	return &Logger{
@@ -92,11 +96,8 @@ func (l *Logger) Sub(fields map[string]interface{}) patronLog.Logger {
 		fields[key] = value
 	}
 
-	newLogger := *l
-	newLogger.fields = fields
-	newLogger.fieldsLine = createFieldsLine(fields)
-
-	return &newLogger
+	// the loggers are recreated, since the fields are part of their prefix
+	return newLogger(l.info.Writer(), l.level, fields, l.info.Flags())
 }
 
 // Fatal logging.
@@ -211,11 +212,16 @@ func (l *Logger) Debugf(msg string, args ...interface{}) {
 
 // Level of the logging.
 func (l *Logger) Level() patronLog.Level {
-	return l.level
+	return l.level.Level()
+}
+
+// SetLevel changes the level of the logger and of its sub loggers.
+func (l *Logger) SetLevel(lvl patronLog.Level) {
+	l.level.SetLevel(lvl)
 }
 
 func (l *Logger) shouldLog(lvl patronLog.Level) bool {
-	return patronLog.LevelOrder(l.level) <= patronLog.LevelOrder(lvl)
+	return l.level.Enabled(lvl)
 }
 
 func output(logger *log.Logger, args ...interface{}) string {
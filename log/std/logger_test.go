@@ -42,6 +42,20 @@ func TestNewWithFlagsLogger(t *testing.T) {
 	assert.NotContains(t, logger.fieldsLine, year)
 }
 
+func TestLogger_SetLevel(t *testing.T) {
+	var b bytes.Buffer
+	logger := New(&b, log.InfoLevel, map[string]interface{}{"name": "john doe"})
+	subLogger := logger.Sub(map[string]interface{}{"age": 18})
+
+	subLogger.Debug("before")
+	assert.Empty(t, b.String())
+
+	logger.SetLevel(log.DebugLevel)
+	assert.Equal(t, log.DebugLevel, subLogger.Level(), "the level is shared with the sub loggers")
+	subLogger.Debug("after")
+	assert.Contains(t, b.String(), "after")
+}
+
 func TestNewSub(t *testing.T) {
 	var b bytes.Buffer
 	logger := New(&b, log.InfoLevel, map[string]interface{}{"name": "john doe"})
@@ -57,6 +71,8 @@ func TestNewSub(t *testing.T) {
 	assert.Equal(t, subLogger.fields, map[string]interface{}{"name": "john doe", "age": 18})
 	assert.Contains(t, subLogger.fieldsLine, "age=18")
 	assert.Contains(t, subLogger.fieldsLine, "name=john doe")
+	subLogger.Info("message")
+	assert.Contains(t, b.String(), "age=18 name=john doe message")
 }
 
 func TestLogger(t *testing.T) {
@@ -187,7 +203,7 @@ func TestLogger_shouldLog(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			l := &Logger{level: tt.setupLevel}
+			l := &Logger{level: log.NewAtomicLevel(tt.setupLevel)}
 			assert.Equal(t, tt.want, l.shouldLog(tt.args.lvl))
 		})
 	}
@@ -14,16 +14,6 @@ import (
 	"github.com/rs/zerolog"
 )
 
-var levelMap = map[log.Level]zerolog.Level{
-	log.NoLevel:    zerolog.NoLevel,
-	log.DebugLevel: zerolog.DebugLevel,
-	log.InfoLevel:  zerolog.InfoLevel,
-	log.WarnLevel:  zerolog.WarnLevel,
-	log.ErrorLevel: zerolog.ErrorLevel,
-	log.FatalLevel: zerolog.FatalLevel,
-	log.PanicLevel: zerolog.PanicLevel,
-}
-
 var (
 	defaultSourceHook sourceHook = sourceHookByPackagePath{
 		packagePath: "vendor/github.com/beatlabs/",
@@ -41,7 +31,7 @@ func init() {
 type Logger struct {
 	logger  *zerolog.Logger
 	loggerf *zerolog.Logger
-	level   log.Level
+	level   *log.AtomicLevel
 }
 
 // New creates a new logger.
@@ -52,9 +42,10 @@ func New(out io.Writer, lvl log.Level, f map[string]interface{}) log.Logger {
 	if len(f) == 0 {
 		f = make(map[string]interface{})
 	}
-	logger := zl.Level(levelMap[lvl]).With().Fields(f).Logger()
-	loggerf := zlf.Level(levelMap[lvl]).With().Fields(f).Logger()
-	return &Logger{logger: &logger, loggerf: &loggerf, level: lvl}
+	// the level is checked before logging instead of by zerolog, since it can be changed at runtime
+	logger := zl.With().Fields(f).Logger()
+	loggerf := zlf.With().Fields(f).Logger()
+	return &Logger{logger: &logger, loggerf: &loggerf, level: log.NewAtomicLevel(lvl)}
 }
 
 // Sub returns a sub logger with new fields attached.
@@ -69,67 +60,108 @@ func (l *Logger) Sub(ff map[string]interface{}) log.Logger {
 
 // Panic logging.
 func (l *Logger) Panic(args ...interface{}) {
+	if !l.level.Enabled(log.PanicLevel) {
+		return
+	}
 	l.logger.Panic().Msg(fmt.Sprint(args...))
 }
 
 // Panicf logging.
 func (l *Logger) Panicf(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.PanicLevel) {
+		return
+	}
 	l.loggerf.Panic().Msgf(msg, args...)
 }
 
 // Fatal logging.
 func (l *Logger) Fatal(args ...interface{}) {
+	if !l.level.Enabled(log.FatalLevel) {
+		return
+	}
 	l.logger.Fatal().Msg(fmt.Sprint(args...))
 }
 
 // Fatalf logging.
 func (l *Logger) Fatalf(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.FatalLevel) {
+		return
+	}
 	l.loggerf.Fatal().Msgf(msg, args...)
 }
 
 // Error logging.
 func (l *Logger) Error(args ...interface{}) {
+	if !l.level.Enabled(log.ErrorLevel) {
+		return
+	}
 	l.logger.Error().Msg(fmt.Sprint(args...))
 }
 
 // Errorf logging.
 func (l *Logger) Errorf(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.ErrorLevel) {
+		return
+	}
 	l.loggerf.Error().Msgf(msg, args...)
 }
 
 // Warn logging.
 func (l *Logger) Warn(args ...interface{}) {
+	if !l.level.Enabled(log.WarnLevel) {
+		return
+	}
 	l.logger.Warn().Msg(fmt.Sprint(args...))
 }
 
 // Warnf logging.
 func (l *Logger) Warnf(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.WarnLevel) {
+		return
+	}
 	l.loggerf.Warn().Msgf(msg, args...)
 }
 
 // Info logging.
 func (l *Logger) Info(args ...interface{}) {
+	if !l.level.Enabled(log.InfoLevel) {
+		return
+	}
 	l.logger.Info().Msg(fmt.Sprint(args...))
 }
 
 // Infof logging.
 func (l *Logger) Infof(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.InfoLevel) {
+		return
+	}
 	l.loggerf.Info().Msgf(msg, args...)
 }
 
 // Debug logging.
 func (l *Logger) Debug(args ...interface{}) {
+	if !l.level.Enabled(log.DebugLevel) {
+		return
+	}
 	l.logger.Debug().Msg(fmt.Sprint(args...))
 }
 
 // Debugf logging.
 func (l *Logger) Debugf(msg string, args ...interface{}) {
+	if !l.level.Enabled(log.DebugLevel) {
+		return
+	}
 	l.loggerf.Debug().Msgf(msg, args...)
 }
 
 // Level return the logging level.
 func (l *Logger) Level() log.Level {
-	return l.level
+	return l.level.Level()
+}
+
+// SetLevel changes the logging level of the logger and of its sub loggers.
+func (l *Logger) SetLevel(lvl log.Level) {
+	l.level.SetLevel(lvl)
 }
 
 type sourceHook interface {
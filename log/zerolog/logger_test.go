@@ -165,6 +165,20 @@ func TestLog_Level(t *testing.T) {
 	}
 }
 
+func TestLogger_SetLevel(t *testing.T) {
+	var b bytes.Buffer
+	logger := New(&b, log.InfoLevel, f)
+	subLogger := logger.Sub(map[string]interface{}{"age": 18})
+
+	subLogger.Debug("before")
+	assert.Empty(t, b.String())
+
+	logger.(log.LevelSetter).SetLevel(log.DebugLevel)
+	assert.Equal(t, log.DebugLevel, subLogger.Level(), "the level is shared with the sub loggers")
+	subLogger.Debug("after")
+	assert.Contains(t, b.String(), `"msg":"after"`)
+}
+
 var t int
 
 func Benchmark_LoggingEnabled(b *testing.B) {
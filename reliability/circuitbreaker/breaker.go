@@ -29,6 +29,7 @@ var (
 	tsFuture       = int64(math.MaxInt64)
 	openError      = new(OpenError)
 	breakerCounter *prometheus.CounterVec
+	breakerGauge   *prometheus.GaugeVec
 	statusMap      = map[status]string{close: "close", open: "open"}
 )
 
@@ -43,11 +44,22 @@ func init() {
 		[]string{"name", "status"},
 	)
 
-	prometheus.MustRegister(breakerCounter)
+	breakerGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "reliability",
+			Subsystem: "circuit_breaker",
+			Name:      "state",
+			Help:      "Circuit breaker state, classified by name, which is 0 when closed and 1 when open or half-open",
+		},
+		[]string{"name"},
+	)
+
+	prometheus.MustRegister(breakerCounter, breakerGauge)
 }
 
 func breakerCounterInc(name string, st status) {
 	breakerCounter.WithLabelValues(name, statusMap[st]).Inc()
+	breakerGauge.WithLabelValues(name).Set(float64(st))
 }
 
 // Setting definition.
@@ -87,6 +99,8 @@ func New(name string, s Setting) (*CircuitBreaker, error) {
 		return nil, errors.New("max retry has to be greater than the retry threshold")
 	}
 
+	breakerGauge.WithLabelValues(name).Set(float64(close))
+
 	return &CircuitBreaker{
 		name:       name,
 		set:        s,
@@ -116,6 +130,12 @@ func (cb *CircuitBreaker) isOpen() bool {
 	return false
 }
 
+// IsOpen returns true while the circuit is open and the executions are rejected.
+// It returns false when the circuit is half-open, since executions are allowed in order to probe for successes.
+func (cb *CircuitBreaker) IsOpen() bool {
+	return cb.isOpen()
+}
+
 func (cb *CircuitBreaker) isClose() bool {
 	cb.RLock()
 	defer cb.RUnlock()
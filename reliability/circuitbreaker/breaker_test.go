@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,6 +89,18 @@ func TestCircuitBreaker_isOpen(t *testing.T) {
 	}
 }
 
+func TestCircuitBreaker_IsOpen(t *testing.T) {
+	set := Setting{FailureThreshold: uint(1), RetryTimeout: 5 * time.Millisecond, RetrySuccessThreshold: 1, MaxRetryExecutionThreshold: 1}
+	cb, err := New("test-is-open", set)
+	assert.NoError(t, err)
+	assert.False(t, cb.IsOpen())
+	_, err = cb.Execute(testFailureAction)
+	assert.EqualError(t, err, "test error")
+	assert.True(t, cb.IsOpen())
+	time.Sleep(7 * time.Millisecond)
+	assert.False(t, cb.IsOpen(), "the circuit is half-open after the retry timeout")
+}
+
 func TestCircuitBreaker_isClose(t *testing.T) {
 	type fields struct {
 		status    status
@@ -127,6 +140,7 @@ func TestCircuitBreaker_Close_Open_HalfOpen_Open_HalfOpen_Close(t *testing.T) {
 	assert.Equal(t, uint(0), cb.retries)
 	assert.True(t, cb.isClose())
 	assert.Equal(t, tsFuture, cb.nextRetry)
+	assert.Equal(t, 0.0, testutil.ToFloat64(breakerGauge.WithLabelValues("test")))
 	// will transition to open
 	_, err = cb.Execute(testFailureAction)
 	assert.EqualError(t, err, "test error")
@@ -134,6 +148,7 @@ func TestCircuitBreaker_Close_Open_HalfOpen_Open_HalfOpen_Close(t *testing.T) {
 	assert.Equal(t, uint(0), cb.executions)
 	assert.Equal(t, uint(0), cb.retries)
 	assert.True(t, cb.isOpen())
+	assert.Equal(t, 1.0, testutil.ToFloat64(breakerGauge.WithLabelValues("test")))
 	assert.True(t, cb.nextRetry < tsFuture)
 	// open, returns err immediately
 	_, err = cb.Execute(testSuccessAction)
@@ -176,6 +191,7 @@ func TestCircuitBreaker_Close_Open_HalfOpen_Open_HalfOpen_Close(t *testing.T) {
 	assert.Equal(t, uint(0), cb.retries)
 	assert.True(t, cb.isClose())
 	assert.Equal(t, tsFuture, cb.nextRetry)
+	assert.Equal(t, 0.0, testutil.ToFloat64(breakerGauge.WithLabelValues("test")))
 }
 
 var err error
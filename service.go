@@ -2,17 +2,20 @@ package patron
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/beatlabs/patron/component/http"
+	"github.com/beatlabs/patron/component/shutdown"
+	"github.com/beatlabs/patron/correlation"
 	patronErrors "github.com/beatlabs/patron/errors"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/log/std"
@@ -25,6 +28,9 @@ const (
 	srv  = "srv"
 	ver  = "ver"
 	host = "host"
+	// shutdownForceCloseMargin is given to the components after the shutdown timeout,
+	// in order to return after force-closing their remaining work.
+	shutdownForceCloseMargin = time.Second
 )
 
 // Component interface for implementing service components.
@@ -36,6 +42,7 @@ type Component interface {
 // The service will start by default a HTTP component in order to host management endpoint.
 type service struct {
 	name              string
+	version           string
 	cps               []Component
 	routesBuilder     *http.RoutesBuilder
 	middlewares       []http.MiddlewareFunc
@@ -43,11 +50,35 @@ type service struct {
 	rcf               http.ReadyCheckFunc
 	termSig           chan os.Signal
 	sighupHandler     func()
+	sigusr1Handler    func()
 	uncompressedPaths []string
+	noSniff           bool
+	toggleables       []*http.ToggleableMiddleware
+	openAPI           bool
+	shutdownTimeout   time.Duration
+	readinessChecks   []check
+	livenessChecks    []check
+	profiling         *http.ProfilingOptions
+	contextValues     []contextValue
+}
+
+type check struct {
+	name string
+	fn   http.CheckFunc
+}
+
+// contextValue is either a value or the constructor of a value.
+type contextValue struct {
+	key  interface{}
+	val  interface{}
+	ctor http.ContextConstructor
 }
 
 func (s *service) setupOSSignal() {
 	signal.Notify(s.termSig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	if s.sigusr1Handler != nil {
+		signal.Notify(s.termSig, syscall.SIGUSR1)
+	}
 }
 
 func (s *service) run(ctx context.Context) error {
@@ -58,30 +89,77 @@ func (s *service) run(ctx context.Context) error {
 		}
 	}()
 	cctx, cnl := context.WithCancel(ctx)
+	// with a shutdown timeout, the components drain their in-flight work in a context which outlives theirs until the deadline
+	dctx, dcnl := context.WithCancel(ctx)
+	defer dcnl()
+	if s.shutdownTimeout > 0 {
+		cctx = shutdown.ContextWithDrain(cctx, dctx)
+	}
 	chErr := make(chan error, len(s.cps))
-	wg := sync.WaitGroup{}
-	wg.Add(len(s.cps))
-	for _, cp := range s.cps {
-		go func(c Component) {
-			defer wg.Done()
+	dones := make([]chan struct{}, len(s.cps))
+	for i, cp := range s.cps {
+		dones[i] = make(chan struct{})
+		go func(c Component, done chan<- struct{}) {
+			defer close(done)
 			chErr <- c.Run(cctx)
-		}(cp)
+		}(cp, dones[i])
 	}
 
 	log.FromContext(ctx).Infof("service %s started", s.name)
 	ee := make([]error, 0, len(s.cps))
 	ee = append(ee, s.waitTermination(chErr))
 	cnl()
+	if s.shutdownTimeout > 0 {
+		drain := time.AfterFunc(s.shutdownTimeout, dcnl)
+		defer drain.Stop()
+	}
 
-	wg.Wait()
-	close(chErr)
+	ee = append(ee, s.waitComponents(dones))
 
-	for err := range chErr {
-		ee = append(ee, err)
+	// components which were cut off have not sent their error
+	for len(chErr) > 0 {
+		ee = append(ee, <-chErr)
 	}
 	return patronErrors.Aggregate(ee...)
 }
 
+// waitComponents waits for the components to return after the service is terminated.
+// With a shutdown timeout, the components still running after it, plus a margin for force-closing their work, are cut off and reported.
+func (s *service) waitComponents(dones []chan struct{}) error {
+	chAll := make(chan struct{})
+	go func() {
+		for _, done := range dones {
+			<-done
+		}
+		close(chAll)
+	}()
+
+	if s.shutdownTimeout <= 0 {
+		<-chAll
+		return nil
+	}
+
+	select {
+	case <-chAll:
+		return nil
+	case <-time.After(s.shutdownTimeout + shutdownForceCloseMargin):
+	}
+
+	var running []string
+	for i, done := range dones {
+		select {
+		case <-done:
+		default:
+			running = append(running, fmt.Sprintf("%T", s.cps[i]))
+		}
+	}
+	if len(running) == 0 {
+		return nil
+	}
+	log.Errorf("shutdown timeout of %v exceeded, components still running: %s", s.shutdownTimeout, strings.Join(running, ", "))
+	return fmt.Errorf("shutdown timeout of %v exceeded, components still running: %s", s.shutdownTimeout, strings.Join(running, ", "))
+}
+
 func (s *service) createHTTPComponent() (Component, error) {
 	var err error
 	portVal := int64(50000)
@@ -97,6 +175,16 @@ func (s *service) createHTTPComponent() (Component, error) {
 
 	b := http.NewBuilder().WithPort(int(portVal))
 
+	adminPort, ok := os.LookupEnv("PATRON_HTTP_ADMIN_PORT")
+	if ok {
+		adminPortVal, err := strconv.Atoi(adminPort)
+		if err != nil {
+			return nil, fmt.Errorf("env var for HTTP admin port is not valid: %w", err)
+		}
+		b.WithAdminPort(adminPortVal)
+		log.Debugf("setting up default HTTP admin port %s", adminPort)
+	}
+
 	httpReadTimeout, ok := os.LookupEnv("PATRON_HTTP_READ_TIMEOUT")
 	if ok {
 		readTimeout, err := time.ParseDuration(httpReadTimeout)
@@ -117,6 +205,36 @@ func (s *service) createHTTPComponent() (Component, error) {
 		log.Debugf("setting up default HTTP write timeout %s", httpWriteTimeout)
 	}
 
+	httpReadHeaderTimeout, ok := os.LookupEnv("PATRON_HTTP_READ_HEADER_TIMEOUT")
+	if ok {
+		readHeaderTimeout, err := time.ParseDuration(httpReadHeaderTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("env var for HTTP read header timeout is not valid: %w", err)
+		}
+		b.WithReadHeaderTimeout(readHeaderTimeout)
+		log.Debugf("setting up default HTTP read header timeout %s", httpReadHeaderTimeout)
+	}
+
+	httpIdleTimeout, ok := os.LookupEnv("PATRON_HTTP_IDLE_TIMEOUT")
+	if ok {
+		idleTimeout, err := time.ParseDuration(httpIdleTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("env var for HTTP idle timeout is not valid: %w", err)
+		}
+		b.WithIdleTimeout(idleTimeout)
+		log.Debugf("setting up default HTTP idle timeout %s", httpIdleTimeout)
+	}
+
+	maxHeaderBytes, ok := os.LookupEnv("PATRON_HTTP_MAX_HEADER_BYTES")
+	if ok {
+		maxHeaderBytesVal, err := strconv.Atoi(maxHeaderBytes)
+		if err != nil {
+			return nil, fmt.Errorf("env var for HTTP max header bytes is not valid: %w", err)
+		}
+		b.WithMaxHeaderBytes(maxHeaderBytesVal)
+		log.Debugf("setting up default HTTP max header bytes %s", maxHeaderBytes)
+	}
+
 	deflateLevel, ok := os.LookupEnv("PATRON_COMPRESSION_DEFLATE_LEVEL")
 	if ok {
 		deflateLevelInt, err := strconv.Atoi(deflateLevel)
@@ -127,6 +245,30 @@ func (s *service) createHTTPComponent() (Component, error) {
 		log.Debugf("setting up default HTTP deflate level  %s", deflateLevel)
 	}
 
+	certFile, keyFile := os.Getenv("PATRON_HTTP_TLS_CERT_FILE"), os.Getenv("PATRON_HTTP_TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		b.WithSSL(certFile, keyFile)
+		log.Debugf("setting up default HTTP TLS with cert file %s", certFile)
+	}
+
+	clientCAFile, ok := os.LookupEnv("PATRON_HTTP_TLS_CLIENT_CA_FILE")
+	if ok {
+		pool, err := loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("env var for HTTP TLS client CA file is not valid: %w", err)
+		}
+		mode := tls.RequireAndVerifyClientCert
+		clientAuth, ok := os.LookupEnv("PATRON_HTTP_TLS_CLIENT_AUTH")
+		if ok {
+			mode, err = parseClientAuth(clientAuth)
+			if err != nil {
+				return nil, fmt.Errorf("env var for HTTP TLS client auth is not valid: %w", err)
+			}
+		}
+		b.WithClientCertificates(pool, mode)
+		log.Debugf("setting up default HTTP client certificate verification with CA file %s", clientCAFile)
+	}
+
 	if s.acf != nil {
 		b.WithAliveCheckFunc(s.acf)
 	}
@@ -135,6 +277,14 @@ func (s *service) createHTTPComponent() (Component, error) {
 		b.WithReadyCheckFunc(s.rcf)
 	}
 
+	for _, c := range s.readinessChecks {
+		b.WithReadinessCheck(c.name, c.fn)
+	}
+
+	for _, c := range s.livenessChecks {
+		b.WithLivenessCheck(c.name, c.fn)
+	}
+
 	if s.routesBuilder != nil {
 		b.WithRoutesBuilder(s.routesBuilder)
 	}
@@ -147,6 +297,34 @@ func (s *service) createHTTPComponent() (Component, error) {
 		b.WithUncompressedPaths(s.uncompressedPaths...)
 	}
 
+	if s.noSniff {
+		b.WithNoSniff()
+	}
+
+	if len(s.toggleables) > 0 {
+		b.WithToggleableMiddlewares(s.toggleables...)
+	}
+
+	if s.openAPI {
+		b.WithOpenAPI(s.name, s.version)
+	}
+
+	if s.shutdownTimeout > 0 {
+		b.WithShutdownGracePeriod(s.shutdownTimeout)
+	}
+
+	if s.profiling != nil {
+		b.WithProfiling(*s.profiling)
+	}
+
+	for _, cv := range s.contextValues {
+		if cv.ctor != nil {
+			b.WithContextConstructor(cv.key, cv.ctor)
+		} else {
+			b.WithContextValue(cv.key, cv.val)
+		}
+	}
+
 	cp, err := b.Create()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create default HTTP component: %w", err)
@@ -155,6 +333,29 @@ func (s *service) createHTTPComponent() (Component, error) {
 	return cp, nil
 }
 
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "require_and_verify":
+		return tls.RequireAndVerifyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("client auth %q should be either require_and_verify or verify_if_given", mode)
+	}
+}
+
 func (s *service) waitTermination(chErr <-chan error) error {
 	for {
 		select {
@@ -164,6 +365,9 @@ func (s *service) waitTermination(chErr <-chan error) error {
 			case syscall.SIGHUP:
 				s.sighupHandler()
 				return nil
+			case syscall.SIGUSR1:
+				// the service keeps running
+				s.sigusr1Handler()
 			default:
 				return nil
 			}
@@ -189,13 +393,25 @@ type Builder struct {
 	rcf               http.ReadyCheckFunc
 	termSig           chan os.Signal
 	sighupHandler     func()
+	sigusr1Handler    func()
 	uncompressedPaths []string
+	noSniff           bool
+	toggleables       []*http.ToggleableMiddleware
+	nopTracing        bool
+	openAPI           bool
+	shutdownTimeout   time.Duration
+	readinessChecks   []check
+	livenessChecks    []check
+	profiling         *http.ProfilingOptions
+	propagatedHeaders []string
+	contextValues     []contextValue
 }
 
 // Config for setting up the builder.
 type Config struct {
-	fields map[string]interface{}
-	logger log.Logger
+	fields     map[string]interface{}
+	logger     log.Logger
+	nopTracing bool
 }
 
 // Option for providing function configuration.
@@ -228,6 +444,14 @@ func TextLogger() Option {
 	}
 }
 
+// NopTracing to use a no-op tracer instead of Jaeger, e.g. for benchmarks.
+// Combined with Logger(log.NewNop()), it disables the logging and tracing overhead.
+func NopTracing() Option {
+	return func(cfg *Config) {
+		cfg.nopTracing = true
+	}
+}
+
 // New creates a builder with functional options.
 func New(name, version string, options ...Option) (*Builder, error) {
 	if name == "" {
@@ -260,9 +484,26 @@ func New(name, version string, options ...Option) (*Builder, error) {
 		rcf:           http.DefaultReadyCheck,
 		termSig:       make(chan os.Signal, 1),
 		sighupHandler: func() { log.Debug("SIGHUP received: nothing setup") },
+		nopTracing:    cfg.nopTracing,
 	}, nil
 }
 
+// DebugLevelToggle returns a handler, e.g. for WithSIGUSR1, which changes the log level to debug,
+// and back to the initial level on the next call.
+func DebugLevelToggle(initial log.Level) func() {
+	return func() {
+		lvl := log.DebugLevel
+		if log.Enabled(log.DebugLevel) {
+			lvl = initial
+		}
+		if err := log.SetLevel(lvl); err != nil {
+			log.Errorf("failed to change the log level: %v", err)
+			return
+		}
+		log.Infof("log level changed to %q", lvl)
+	}
+}
+
 func getLogLevel() log.Level {
 	lvl, ok := os.LookupEnv("PATRON_LOG_LEVEL")
 	if !ok {
@@ -354,6 +595,18 @@ func (b *Builder) WithMiddlewares(mm ...http.MiddlewareFunc) *Builder {
 	return b
 }
 
+// WithToggleableMiddlewares adds middlewares to the default HTTP component which can be enabled or disabled at runtime.
+func (b *Builder) WithToggleableMiddlewares(tt ...*http.ToggleableMiddleware) *Builder {
+	if len(tt) == 0 {
+		b.errors = append(b.errors, errors.New("provided toggleable middlewares slice was empty"))
+	} else {
+		log.Debug("setting toggleable middlewares")
+		b.toggleables = append(b.toggleables, tt...)
+	}
+
+	return b
+}
+
 // WithAliveCheck overrides the default liveness check of the default HTTP component.
 func (b *Builder) WithAliveCheck(acf http.AliveCheckFunc) *Builder {
 	if acf == nil {
@@ -378,6 +631,33 @@ func (b *Builder) WithReadyCheck(rcf http.ReadyCheckFunc) *Builder {
 	return b
 }
 
+// WithReadinessCheck registers a named check of a dependency, e.g. a Kafka broker, a database or a downstream HTTP service,
+// which is aggregated by the /ready endpoint of the default HTTP component.
+// If any check fails, /ready responds with a 503 Service Unavailable and a JSON breakdown of the results.
+func (b *Builder) WithReadinessCheck(name string, fn http.CheckFunc) *Builder {
+	if name == "" || fn == nil {
+		b.errors = append(b.errors, errors.New("provided readiness check was empty or nil"))
+	} else {
+		log.Debugf("setting readiness check %s", name)
+		b.readinessChecks = append(b.readinessChecks, check{name: name, fn: fn})
+	}
+
+	return b
+}
+
+// WithLivenessCheck registers a named check, which is aggregated by the /alive endpoint of the default HTTP component.
+// If any check fails, /alive responds with a 503 Service Unavailable and a JSON breakdown of the results.
+func (b *Builder) WithLivenessCheck(name string, fn http.CheckFunc) *Builder {
+	if name == "" || fn == nil {
+		b.errors = append(b.errors, errors.New("provided liveness check was empty or nil"))
+	} else {
+		log.Debugf("setting liveness check %s", name)
+		b.livenessChecks = append(b.livenessChecks, check{name: name, fn: fn})
+	}
+
+	return b
+}
+
 // WithComponents adds custom components to the Patron service.
 func (b *Builder) WithComponents(cc ...Component) *Builder {
 	if len(cc) == 0 {
@@ -390,6 +670,20 @@ func (b *Builder) WithComponents(cc ...Component) *Builder {
 	return b
 }
 
+// WithSIGUSR1 adds a handler for when the service receives a SIGUSR1, e.g. DebugLevelToggle, which toggles the log level
+// between debug and the initial level. Unlike SIGHUP, the service keeps running after handling the signal.
+// The service traps SIGUSR1 only when a handler is provided.
+func (b *Builder) WithSIGUSR1(handler func()) *Builder {
+	if handler == nil {
+		b.errors = append(b.errors, errors.New("provided SIGUSR1 handler was nil"))
+	} else {
+		log.Debug("setting SIGUSR1 handler func")
+		b.sigusr1Handler = handler
+	}
+
+	return b
+}
+
 // WithSIGHUP adds a custom handler for when the service receives a SIGHUP.
 func (b *Builder) WithSIGHUP(handler func()) *Builder {
 	if handler == nil {
@@ -414,19 +708,109 @@ func (b *Builder) WithUncompressedPaths(p ...string) *Builder {
 	return b
 }
 
+// WithPropagatedHeaders declares the inbound headers, e.g. a tenant ID, a locale or feature flags, which are lifted into the context
+// of the HTTP and gRPC requests, and injected into the outbound requests and messages of the clients.
+func (b *Builder) WithPropagatedHeaders(headers ...string) *Builder {
+	if len(headers) == 0 {
+		b.errors = append(b.errors, errors.New("provided propagated headers slice was empty"))
+	} else {
+		log.Debug("setting propagated headers")
+		b.propagatedHeaders = headers
+	}
+
+	return b
+}
+
+// WithContextValue adds a value to the context of all the requests of the default HTTP component under the key,
+// e.g. a database connection pool, so that the processors do not rely on package-level variables.
+func (b *Builder) WithContextValue(key, val interface{}) *Builder {
+	if key == nil {
+		b.errors = append(b.errors, errors.New("provided context key was nil"))
+	} else {
+		log.Debugf("setting context value %v", key)
+		b.contextValues = append(b.contextValues, contextValue{key: key, val: val})
+	}
+
+	return b
+}
+
+// WithContextConstructor adds a value constructed for each request of the default HTTP component to its context under the key,
+// e.g. a request-scoped database session or the tenant of the request.
+func (b *Builder) WithContextConstructor(key interface{}, ctor http.ContextConstructor) *Builder {
+	if key == nil {
+		b.errors = append(b.errors, errors.New("provided context key was nil"))
+	} else if ctor == nil {
+		b.errors = append(b.errors, errors.New("provided context constructor was nil"))
+	} else {
+		log.Debugf("setting context value %v", key)
+		b.contextValues = append(b.contextValues, contextValue{key: key, ctor: ctor})
+	}
+
+	return b
+}
+
+// WithNoSniff sets the X-Content-Type-Options header to nosniff on all responses encoded by the default HTTP component.
+func (b *Builder) WithNoSniff() *Builder {
+	log.Debug("setting nosniff on encoded responses")
+	b.noSniff = true
+
+	return b
+}
+
+// WithOpenAPI serves an OpenAPI document of the routes of the default HTTP component at /openapi.json,
+// using the name and version of the service.
+func (b *Builder) WithOpenAPI() *Builder {
+	log.Debug("setting OpenAPI document")
+	b.openAPI = true
+
+	return b
+}
+
+// WithProfiling configures the profiling endpoints of the default HTTP component, e.g. disabling the pprof endpoints,
+// enabling the expvar endpoint or protecting them with an authenticator.
+func (b *Builder) WithProfiling(opts http.ProfilingOptions) *Builder {
+	log.Debug("setting profiling options")
+	b.profiling = &opts
+
+	return b
+}
+
+// WithShutdownTimeout sets the deadline for the components to drain their work when the service is terminated.
+// The default HTTP component stops accepting connections and closes the ones with in-flight requests after the deadline,
+// while other components are expected to stop accepting work when their context is cancelled, and to drain their
+// in-flight work in the context of shutdown.DrainFromContext, which is done once the deadline is exceeded.
+// Components still running after the deadline are cut off and reported in the error returned by Run.
+// Without a shutdown timeout, the service waits for the components indefinitely.
+func (b *Builder) WithShutdownTimeout(d time.Duration) *Builder {
+	if d <= 0 {
+		b.errors = append(b.errors, errors.New("shutdown timeout must be positive"))
+	} else {
+		log.Debugf("setting shutdown timeout %v", d)
+		b.shutdownTimeout = d
+	}
+
+	return b
+}
+
 // Build constructs the Patron service by applying the gathered properties.
 func (b *Builder) build() (*service, error) {
 	if len(b.errors) > 0 {
 		return nil, patronErrors.Aggregate(b.errors...)
 	}
 
-	err := setupJaegerTracing(b.name, b.version)
-	if err != nil {
-		return nil, err
+	if b.nopTracing {
+		log.Debug("setting up no-op tracing")
+		trace.SetupNop()
+	} else {
+		err := setupJaegerTracing(b.name, b.version)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	s := service{
 		name:              b.name,
+		version:           b.version,
 		cps:               b.cps,
 		routesBuilder:     b.routesBuilder,
 		middlewares:       b.middlewares,
@@ -434,7 +818,16 @@ func (b *Builder) build() (*service, error) {
 		rcf:               b.rcf,
 		termSig:           b.termSig,
 		sighupHandler:     b.sighupHandler,
+		sigusr1Handler:    b.sigusr1Handler,
 		uncompressedPaths: b.uncompressedPaths,
+		noSniff:           b.noSniff,
+		toggleables:       b.toggleables,
+		openAPI:           b.openAPI,
+		shutdownTimeout:   b.shutdownTimeout,
+		readinessChecks:   b.readinessChecks,
+		livenessChecks:    b.livenessChecks,
+		profiling:         b.profiling,
+		contextValues:     b.contextValues,
 	}
 
 	httpCp, err := s.createHTTPComponent()
@@ -443,6 +836,9 @@ func (b *Builder) build() (*service, error) {
 	}
 
 	s.cps = append(s.cps, httpCp)
+	if len(b.propagatedHeaders) > 0 {
+		correlation.PropagateHeaders(b.propagatedHeaders...)
+	}
 	s.setupOSSignal()
 	return &s, nil
 }
@@ -8,11 +8,16 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
 	patronhttp "github.com/beatlabs/patron/component/http"
+	"github.com/beatlabs/patron/component/shutdown"
+	"github.com/beatlabs/patron/correlation"
 	"github.com/beatlabs/patron/log"
 	"github.com/beatlabs/patron/log/std"
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,6 +38,7 @@ func TestNewServer(t *testing.T) {
 		"ready check func provided was nil\n" +
 		"provided components slice was empty\n" +
 		"provided SIGHUP handler was nil\n" +
+		"provided SIGUSR1 handler was nil\n" +
 		"provided uncompressed paths slice was empty\n"
 
 	tests := map[string]struct {
@@ -43,6 +49,7 @@ func TestNewServer(t *testing.T) {
 		acf               patronhttp.AliveCheckFunc
 		rcf               patronhttp.ReadyCheckFunc
 		sighupHandler     func()
+		sigusr1Handler    func()
 		uncompressedPaths []string
 		wantErr           string
 	}{
@@ -54,6 +61,7 @@ func TestNewServer(t *testing.T) {
 			acf:               patronhttp.DefaultAliveCheck,
 			rcf:               patronhttp.DefaultReadyCheck,
 			sighupHandler:     func() { log.Info("SIGHUP received: nothing setup") },
+			sigusr1Handler:    func() { log.Info("SIGUSR1 received: nothing setup") },
 			uncompressedPaths: []string{"/foo", "/bar"},
 			wantErr:           "",
 		},
@@ -64,6 +72,7 @@ func TestNewServer(t *testing.T) {
 			acf:               nil,
 			rcf:               nil,
 			sighupHandler:     nil,
+			sigusr1Handler:    nil,
 			uncompressedPaths: nil,
 			wantErr:           httpBuilderAllErrors,
 		},
@@ -74,6 +83,7 @@ func TestNewServer(t *testing.T) {
 			acf:               nil,
 			rcf:               nil,
 			sighupHandler:     nil,
+			sigusr1Handler:    nil,
 			uncompressedPaths: []string{},
 			wantErr:           httpBuilderAllErrors,
 		},
@@ -90,6 +100,7 @@ func TestNewServer(t *testing.T) {
 				WithReadyCheck(tt.rcf).
 				WithComponents(tt.cps...).
 				WithSIGHUP(tt.sighupHandler).
+				WithSIGUSR1(tt.sigusr1Handler).
 				WithUncompressedPaths(tt.uncompressedPaths...).
 				build()
 
@@ -108,6 +119,7 @@ func TestNewServer(t *testing.T) {
 				assert.NotNil(t, gotService.acf)
 				assert.NotNil(t, gotService.termSig)
 				assert.NotNil(t, gotService.sighupHandler)
+				assert.NotNil(t, gotService.sigusr1Handler)
 
 				for _, comp := range tt.cps {
 					assert.Contains(t, gotService.cps, comp)
@@ -290,6 +302,88 @@ func TestServer_SetupReadWriteTimeouts(t *testing.T) {
 	}
 }
 
+func TestServer_SetupServerTimeouts(t *testing.T) {
+	tests := map[string]struct {
+		env         map[string]string
+		expectedErr string
+	}{
+		"success": {env: map[string]string{
+			"PATRON_HTTP_READ_HEADER_TIMEOUT": "5s", "PATRON_HTTP_IDLE_TIMEOUT": "90s", "PATRON_HTTP_MAX_HEADER_BYTES": "8192",
+		}},
+		"invalid read header timeout": {
+			env:         map[string]string{"PATRON_HTTP_READ_HEADER_TIMEOUT": "foo"},
+			expectedErr: `env var for HTTP read header timeout is not valid: time: invalid duration "foo"`,
+		},
+		"zero read header timeout": {
+			env:         map[string]string{"PATRON_HTTP_READ_HEADER_TIMEOUT": "0s"},
+			expectedErr: "failed to create default HTTP component: negative or zero read header timeout provided\n",
+		},
+		"invalid idle timeout": {
+			env:         map[string]string{"PATRON_HTTP_IDLE_TIMEOUT": "foo"},
+			expectedErr: `env var for HTTP idle timeout is not valid: time: invalid duration "foo"`,
+		},
+		"negative idle timeout": {
+			env:         map[string]string{"PATRON_HTTP_IDLE_TIMEOUT": "-1s"},
+			expectedErr: "failed to create default HTTP component: negative or zero idle timeout provided\n",
+		},
+		"invalid max header bytes": {
+			env:         map[string]string{"PATRON_HTTP_MAX_HEADER_BYTES": "foo"},
+			expectedErr: `env var for HTTP max header bytes is not valid: strconv.Atoi: parsing "foo": invalid syntax`,
+		},
+		"zero max header bytes": {
+			env:         map[string]string{"PATRON_HTTP_MAX_HEADER_BYTES": "0"},
+			expectedErr: "failed to create default HTTP component: negative or zero max header bytes provided\n",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			defer os.Clearenv()
+
+			for k, v := range tt.env {
+				require.NoError(t, os.Setenv(k, v))
+			}
+			svc, err := New("test", "", TextLogger())
+			require.NoError(t, err)
+
+			_, err = svc.WithComponents(&testComponent{}).build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestServer_SetupAdminPort(t *testing.T) {
+	tests := map[string]struct {
+		adminPort   string
+		expectedErr string
+	}{
+		"success":      {adminPort: "50101"},
+		"invalid port": {adminPort: "foo", expectedErr: "env var for HTTP admin port is not valid: strconv.Atoi: parsing \"foo\": invalid syntax"},
+		"same port":    {adminPort: "50000", expectedErr: "failed to create default HTTP component: admin port should be different from the HTTP port"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			defer os.Clearenv()
+
+			require.NoError(t, os.Setenv("PATRON_HTTP_ADMIN_PORT", tt.adminPort))
+			svc, err := New("test", "", TextLogger())
+			require.NoError(t, err)
+
+			_, err = svc.build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestServer_SetupDeflateLevel(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -328,6 +422,51 @@ func TestServer_SetupDeflateLevel(t *testing.T) {
 	}
 }
 
+func TestServer_SetupClientCertificates(t *testing.T) {
+	tests := []struct {
+		name        string
+		caFile      string
+		clientAuth  string
+		expectedErr string
+	}{
+		{name: "success with client CA file", caFile: "component/http/testdata/server.pem"},
+		{name: "success with client auth", caFile: "component/http/testdata/server.pem", clientAuth: "verify_if_given"},
+		{
+			name: "failed with missing client CA file", caFile: "testdata/missing.pem",
+			expectedErr: "env var for HTTP TLS client CA file is not valid: open testdata/missing.pem: no such file or directory",
+		},
+		{
+			name: "failed with client CA file without certificates", caFile: "component/http/testdata/server.key",
+			expectedErr: "env var for HTTP TLS client CA file is not valid: no certificates found in component/http/testdata/server.key",
+		},
+		{
+			name: "failed with invalid client auth", caFile: "component/http/testdata/server.pem", clientAuth: "request",
+			expectedErr: `env var for HTTP TLS client auth is not valid: client auth "request" should be either require_and_verify or verify_if_given`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer os.Clearenv()
+
+			require.NoError(t, os.Setenv("PATRON_HTTP_TLS_CERT_FILE", "component/http/testdata/server.pem"))
+			require.NoError(t, os.Setenv("PATRON_HTTP_TLS_KEY_FILE", "component/http/testdata/server.key"))
+			require.NoError(t, os.Setenv("PATRON_HTTP_TLS_CLIENT_CA_FILE", tt.caFile))
+			if tt.clientAuth != "" {
+				require.NoError(t, os.Setenv("PATRON_HTTP_TLS_CLIENT_AUTH", tt.clientAuth))
+			}
+			svc, err := New("test", "", TextLogger())
+			require.NoError(t, err)
+
+			_, err = svc.build()
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func getRandomPort(t *testing.T) string {
 	bg, err := rand.Int(rand.Reader, big.NewInt(10000))
 	require.NoError(t, err)
@@ -345,6 +484,182 @@ func (ts testComponent) Run(_ context.Context) error {
 	return nil
 }
 
+type blockingComponent struct {
+	release chan struct{}
+}
+
+func (bc blockingComponent) Run(_ context.Context) error {
+	<-bc.release
+	return nil
+}
+
+func TestServer_Run_ShutdownTimeout(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", getRandomPort(t)))
+
+	svc, err := New("test", "", Logger(log.NewNop()), NopTracing())
+	require.NoError(t, err)
+	release := make(chan struct{})
+	defer close(release)
+	s, err := svc.WithComponents(&testComponent{}, blockingComponent{release: release}).
+		WithShutdownTimeout(100 * time.Millisecond).build()
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, s.shutdownTimeout)
+
+	s.termSig <- syscall.SIGTERM
+	start := time.Now()
+	err = s.run(context.Background())
+	assert.EqualError(t, err, "shutdown timeout of 100ms exceeded, components still running: patron.blockingComponent\n")
+	assert.Less(t, time.Since(start).Seconds(), (100*time.Millisecond + 2*shutdownForceCloseMargin).Seconds())
+}
+
+// drainingComponent drains its in-flight work once its context is done, until its drain context is done.
+type drainingComponent struct {
+	drained chan time.Duration
+}
+
+func (dc drainingComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	start := time.Now()
+	<-shutdown.DrainFromContext(ctx).Done()
+	dc.drained <- time.Since(start)
+	return nil
+}
+
+func TestServer_Run_ShutdownTimeout_Drain(t *testing.T) {
+	defer os.Clearenv()
+	require.NoError(t, os.Setenv("PATRON_HTTP_DEFAULT_PORT", getRandomPort(t)))
+
+	svc, err := New("test", "", Logger(log.NewNop()), NopTracing())
+	require.NoError(t, err)
+	dc := drainingComponent{drained: make(chan time.Duration, 1)}
+	s, err := svc.WithComponents(dc).WithShutdownTimeout(100 * time.Millisecond).build()
+	require.NoError(t, err)
+
+	s.termSig <- syscall.SIGTERM
+	assert.NoError(t, s.run(context.Background()))
+	assert.GreaterOrEqual(t, (<-dc.drained).Milliseconds(), int64(100), "the drain context is done once the shutdown timeout is exceeded")
+}
+
+func TestServer_waitTermination_SIGUSR1(t *testing.T) {
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	handled := make(chan struct{}, 1)
+	s, err := svc.WithSIGUSR1(func() { handled <- struct{}{} }).build()
+	require.NoError(t, err)
+
+	chDone := make(chan error)
+	go func() {
+		chDone <- s.waitTermination(make(chan error))
+	}()
+
+	s.termSig <- syscall.SIGUSR1
+	<-handled
+	select {
+	case <-chDone:
+		assert.Fail(t, "the service should keep running after a SIGUSR1")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.termSig <- syscall.SIGTERM
+	assert.NoError(t, <-chDone)
+}
+
+func TestDebugLevelToggle(t *testing.T) {
+	_, err := New("test", "", TextLogger())
+	require.NoError(t, err)
+	if _, ok := log.Sub(map[string]interface{}{}).(log.LevelSetter); !ok {
+		t.Skip("the logger set up by the tests does not support changing the level")
+	}
+	initial := log.FromContext(context.Background()).Level()
+	defer func() {
+		_ = log.SetLevel(initial)
+	}()
+	require.NoError(t, log.SetLevel(log.InfoLevel))
+
+	toggle := DebugLevelToggle(log.InfoLevel)
+	toggle()
+	assert.True(t, log.Enabled(log.DebugLevel))
+	toggle()
+	assert.False(t, log.Enabled(log.DebugLevel))
+	assert.True(t, log.Enabled(log.InfoLevel))
+}
+
+func TestBuilder_WithShutdownTimeout(t *testing.T) {
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithShutdownTimeout(0)
+	assert.Len(t, svc.errors, 1)
+	assert.EqualError(t, svc.errors[0], "shutdown timeout must be positive")
+
+	svc, err = New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithShutdownTimeout(time.Second)
+	assert.Empty(t, svc.errors)
+	assert.Equal(t, time.Second, svc.shutdownTimeout)
+}
+
+func TestBuilder_WithProfiling(t *testing.T) {
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithProfiling(patronhttp.ProfilingOptions{DisablePprof: true, ExpVars: true})
+	assert.Equal(t, &patronhttp.ProfilingOptions{DisablePprof: true, ExpVars: true}, svc.profiling)
+
+	_, err = svc.build()
+	assert.NoError(t, err)
+}
+
+func TestBuilder_WithPropagatedHeaders(t *testing.T) {
+	defer correlation.PropagateHeaders()
+
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithPropagatedHeaders()
+	assert.EqualError(t, svc.errors[0], "provided propagated headers slice was empty")
+
+	svc, err = New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	_, err = svc.WithPropagatedHeaders("X-Tenant-Id", "Accept-Language").build()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"X-Tenant-Id", "Accept-Language"}, correlation.PropagatedHeaders())
+}
+
+func TestBuilder_WithContextValues(t *testing.T) {
+	ctor := func(*http.Request) (interface{}, func(), error) { return "session", nil, nil }
+
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithContextValue(nil, "pool").WithContextConstructor("session", nil).WithContextConstructor(nil, ctor)
+	require.Len(t, svc.errors, 3)
+	assert.EqualError(t, svc.errors[0], "provided context key was nil")
+	assert.EqualError(t, svc.errors[1], "provided context constructor was nil")
+	assert.EqualError(t, svc.errors[2], "provided context key was nil")
+
+	svc, err = New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	_, err = svc.WithContextValue("pool", "pool").WithContextConstructor("session", ctor).build()
+	require.NoError(t, err)
+	assert.Len(t, svc.contextValues, 2)
+}
+
+func TestBuilder_WithChecks(t *testing.T) {
+	check := func(context.Context) error { return nil }
+
+	svc, err := New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithReadinessCheck("db", check).WithLivenessCheck("worker", check)
+	assert.Empty(t, svc.errors)
+	assert.Len(t, svc.readinessChecks, 1)
+	assert.Len(t, svc.livenessChecks, 1)
+
+	svc, err = New("test", "", Logger(log.NewNop()))
+	require.NoError(t, err)
+	svc.WithReadinessCheck("", check).WithLivenessCheck("worker", nil)
+	assert.Len(t, svc.errors, 2)
+	assert.EqualError(t, svc.errors[0], "provided readiness check was empty or nil")
+	assert.EqualError(t, svc.errors[1], "provided liveness check was empty or nil")
+}
+
 func TestLogFields(t *testing.T) {
 	defaultFields := defaultLogFields("test", "1.0")
 	fields := map[string]interface{}{"key": "value"}
@@ -385,3 +700,16 @@ func TestLogger(t *testing.T) {
 	Logger(logger)(&cfg)
 	assert.Equal(t, logger, cfg.logger)
 }
+
+func TestNopTracing(t *testing.T) {
+	cfg := Config{}
+	NopTracing()(&cfg)
+	assert.True(t, cfg.nopTracing)
+
+	svc, err := New("test", "", Logger(log.NewNop()), NopTracing())
+	require.NoError(t, err)
+	s, err := svc.WithComponents(&testComponent{}).build()
+	require.NoError(t, err)
+	assert.IsType(t, opentracing.NoopTracer{}, opentracing.GlobalTracer())
+	assert.NoError(t, s.run(context.Background()))
+}
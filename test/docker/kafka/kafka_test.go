@@ -38,6 +38,7 @@ const (
 	failAllRetriesTopic2 = "failAllRetriesTopic2"
 	failAndRetryTopic1   = "failAndRetryTopic1"
 	failAndRetryTopic2   = "failAndRetryTopic2"
+	txnTopic             = "txnTopic"
 )
 
 func TestMain(m *testing.M) {
@@ -56,6 +57,7 @@ func TestMain(m *testing.M) {
 		getTopic(successTopic1),
 		getTopic(successTopic2),
 		getTopic(successTopic3),
+		getTopic(txnTopic),
 	}
 	k, err := create(120*time.Second, topics...)
 	if err != nil {
@@ -127,6 +129,9 @@ func (k *kafkaRuntime) setup() error {
 			"KAFKA_ADVERTISED_HOST_NAME=127.0.0.1",
 			fmt.Sprintf("KAFKA_CREATE_TOPICS=%s", strings.Join(k.topics, ",")),
 			fmt.Sprintf("KAFKA_ZOOKEEPER_CONNECT=%s:%s", ip, zookeeperPort),
+			// the transaction state log of a single broker
+			"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR=1",
+			"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR=1",
 		}}
 
 	_, err = k.RunWithOptions(runOptions)
@@ -0,0 +1,98 @@
+//go:build integration
+// +build integration
+
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	v2 "github.com/beatlabs/patron/client/kafka/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionalProducer(t *testing.T) {
+	const group = "txn-group"
+	saramaCfg, err := v2.DefaultProducerSaramaConfig("test-txn-producer", true)
+	require.NoError(t, err)
+	saramaCfg.Version = sarama.V2_1_0_0
+
+	producer, err := v2.New(Brokers(), saramaCfg).CreateTransactional("test-txn-producer")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, producer.Close()) }()
+
+	ctx := context.Background()
+	require.NoError(t, producer.BeginTxn())
+	require.NoError(t, producer.SendBatch(ctx, []*sarama.ProducerMessage{
+		getProducerMessage(txnTopic, "one"),
+		getProducerMessage(txnTopic, "two"),
+	}))
+	require.NoError(t, producer.SendOffsets(group, &sarama.ConsumerMessage{Topic: txnTopic, Partition: 0, Offset: 41}))
+	require.NoError(t, producer.CommitTxn())
+
+	require.NoError(t, producer.BeginTxn())
+	_, _, err = producer.Send(ctx, getProducerMessage(txnTopic, "aborted"))
+	require.NoError(t, err)
+	require.NoError(t, producer.SendOffsets(group, &sarama.ConsumerMessage{Topic: txnTopic, Partition: 0, Offset: 99}))
+	require.NoError(t, producer.AbortTxn())
+
+	// the next transaction is added to the coordinator while the aborted one is completing
+	require.NoError(t, producer.BeginTxn())
+	_, _, err = producer.Send(ctx, getProducerMessage(txnTopic, "three"))
+	require.NoError(t, err)
+	require.NoError(t, producer.CommitTxn())
+
+	consumerCfg := sarama.NewConfig()
+	consumerCfg.Version = sarama.V2_1_0_0
+	consumerCfg.Consumer.IsolationLevel = sarama.ReadCommitted
+	consumer, err := sarama.NewConsumer(Brokers(), consumerCfg)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, consumer.Close()) }()
+	pc, err := consumer.ConsumePartition(txnTopic, 0, sarama.OffsetOldest)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, pc.Close()) }()
+
+	var received []string
+	timeout := time.After(30 * time.Second)
+	for len(received) < 3 {
+		select {
+		case msg := <-pc.Messages():
+			received = append(received, string(msg.Value))
+		case <-timeout:
+			t.Fatalf("timed out waiting for the committed messages, received %v", received)
+		}
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, received, "the messages of the aborted transaction are not visible")
+
+	admin, err := sarama.NewClusterAdmin(Brokers(), consumerCfg)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, admin.Close()) }()
+	offsets, err := admin.ListConsumerGroupOffsets(group, map[string][]int32{txnTopic: {0}})
+	require.NoError(t, err)
+	block := offsets.GetBlock(txnTopic, 0)
+	require.NotNil(t, block)
+	assert.Equal(t, int64(42), block.Offset, "the offsets of the aborted transaction are not committed")
+}
+
+func TestTransactionalProducer_Fenced(t *testing.T) {
+	saramaCfg, err := v2.DefaultProducerSaramaConfig("test-txn-producer-fenced", true)
+	require.NoError(t, err)
+	saramaCfg.Version = sarama.V2_1_0_0
+
+	producer, err := v2.New(Brokers(), saramaCfg).CreateTransactional("test-txn-producer-fenced")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, producer.Close()) }()
+	require.NoError(t, producer.BeginTxn())
+	_, _, err = producer.Send(context.Background(), getProducerMessage(txnTopic, "fenced"))
+	require.NoError(t, err)
+
+	// a new instance with the same transactional ID fences off the previous one
+	next, err := v2.New(Brokers(), saramaCfg).CreateTransactional("test-txn-producer-fenced")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, next.Close()) }()
+
+	assert.Error(t, producer.CommitTxn())
+}
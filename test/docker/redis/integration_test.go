@@ -106,6 +106,37 @@ func TestCache(t *testing.T) {
 	})
 }
 
+func TestCache_KeyPrefix(t *testing.T) {
+	dsn, err := runtime.DSN()
+	require.NoError(t, err)
+
+	opts := cacheredis.Options{Addr: dsn}
+	routes, err := cacheredis.New(context.Background(), opts, cacheredis.KeyPrefix("routes:"), cacheredis.TTL(time.Minute))
+	require.NoError(t, err)
+	other, err := cacheredis.New(context.Background(), opts, cacheredis.KeyPrefix("other:"))
+	require.NoError(t, err)
+	cl := redis.New(redis.Options(opts))
+
+	require.NoError(t, routes.Set("key", "value"))
+	require.NoError(t, other.Set("key", "other value"))
+
+	got, err := cl.Get(context.Background(), "routes:key").Result()
+	require.NoError(t, err)
+	assert.Equal(t, "value", got)
+	ttl, err := cl.TTL(context.Background(), "routes:key").Result()
+	require.NoError(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	require.NoError(t, routes.Purge())
+	_, exists, err := routes.Get("key")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	val, exists, err := other.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "other value", val)
+}
+
 func TestClient(t *testing.T) {
 	mtr := mocktracer.New()
 	opentracing.SetGlobalTracer(mtr)
@@ -64,6 +64,18 @@ func Setup(name, ver, agent, typ string, prm float64, buckets []float64) error {
 	return nil
 }
 
+// SetupNop sets up a no-op tracer, which discards all spans, e.g. for benchmarks or for embedding without tracing.
+func SetupNop() {
+	cls = nopCloser{}
+	opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
 // Close the tracer.
 func Close() error {
 	log.Debug("closing tracer")
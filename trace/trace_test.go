@@ -18,6 +18,12 @@ func TestSetup_Tracer_Close(t *testing.T) {
 	Version = "dev"
 }
 
+func TestSetupNop_Close(t *testing.T) {
+	SetupNop()
+	assert.IsType(t, opentracing.NoopTracer{}, opentracing.GlobalTracer())
+	assert.NoError(t, Close())
+}
+
 func TestStartFinishConsumerSpan(t *testing.T) {
 	mtr := mocktracer.New()
 	opentracing.SetGlobalTracer(mtr)